@@ -128,9 +128,19 @@ func (api *ChatApi) ChatStream(c *gin.Context) {
 		Options:    req.Options,
 	}
 
+	// 按估算用量预扣配额
+	quota, err := reserveStreamQuota(c, api.quotaService, req.Message, req.Options, "chat", uuid.New().String())
+	if err != nil {
+		c.SSEvent("error", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// 获取流式响应通道
 	streamChan, err := api.chatService.StartChatStream(c.Request.Context(), serviceReq)
 	if err != nil {
+		quota.release("聊天流启动失败，退回预扣配额")
 		c.SSEvent("error", gin.H{
 			"error": err.Error(),
 		})
@@ -138,15 +148,20 @@ func (api *ChatApi) ChatStream(c *gin.Context) {
 	}
 
 	// 流式推送
+	content := ""
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case <-c.Request.Context().Done():
+			// 客户端断开，按已生成内容结算
+			quota.settle(c.Request.Context(), 0, content)
 			return false
 
 		case chunk, ok := <-streamChan:
 			if !ok {
+				quota.settle(c.Request.Context(), 0, content)
 				return false
 			}
+			content = chunk.Content
 
 			if chunk.IsComplete {
 				c.SSEvent("done", gin.H{
@@ -157,18 +172,8 @@ func (api *ChatApi) ChatStream(c *gin.Context) {
 					"model":      chunk.Model,
 				})
 
-				// 异步消费配额
-				go func() {
-					userID, _ := c.Get("user_id")
-					_ = api.quotaService.ConsumeQuota(
-						c.Request.Context(),
-						userID.(string),
-						chunk.TokensUsed,
-						"chat",
-						chunk.Model,
-						chunk.MessageID,
-					)
-				}()
+				// 按实际用量结算
+				quota.settle(c.Request.Context(), chunk.TokensUsed, chunk.Content)
 
 				return false
 			}
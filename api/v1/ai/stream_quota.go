@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	aiModels "Qingyu_backend/models/ai"
+	aiService "Qingyu_backend/service/ai"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamQuota 流式请求的两阶段配额计费
+// 开始前按估算用量预扣，结束后按实际用量结算；客户端中途断开时按已生成内容估算结算
+type streamQuota struct {
+	quotaService *aiService.QuotaService
+	reservation  *aiModels.QuotaReservation
+	promptTokens int
+}
+
+// reserveStreamQuota 为流式请求预扣配额
+// 配额服务未注入或无用户信息时不计费（由路由上的配额中间件兜底）
+func reserveStreamQuota(c *gin.Context, quotaService *aiService.QuotaService, prompt string, options *aiModels.GenerateOptions, service, requestID string) (*streamQuota, error) {
+	sq := &streamQuota{
+		quotaService: quotaService,
+		promptTokens: aiService.EstimateTokens(prompt),
+	}
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(string)
+	if quotaService == nil || uid == "" {
+		return sq, nil
+	}
+
+	var model string
+	maxTokens := 0
+	if options != nil {
+		model = options.Model
+		maxTokens = options.MaxTokens
+	}
+
+	reservation, err := quotaService.ReserveQuota(
+		c.Request.Context(),
+		uid,
+		aiService.EstimateRequestTokens(sq.promptTokens, maxTokens),
+		service,
+		model,
+		requestID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sq.reservation = reservation
+	return sq, nil
+}
+
+// release 流未能启动时全额退回预扣
+func (sq *streamQuota) release(reason string) {
+	if sq.reservation == nil {
+		return
+	}
+	ctx := context.Background()
+	if err := sq.quotaService.ReleaseQuota(ctx, sq.reservation.ID.Hex(), reason); err != nil {
+		fmt.Printf("警告: 退回配额预留失败: %v\n", err)
+	}
+}
+
+// settle 按实际用量结算；tokensUsed 为 0（提供商未返回 Usage 或客户端断开）时按已生成内容估算
+// 使用脱离请求生命周期的 context，避免请求结束后结算被取消
+func (sq *streamQuota) settle(ctx context.Context, tokensUsed int, content string) {
+	if sq.reservation == nil {
+		return
+	}
+	if tokensUsed <= 0 {
+		tokensUsed = sq.promptTokens + aiService.EstimateTokens(content)
+	}
+
+	reservationID := sq.reservation.ID.Hex()
+	ctx = context.WithoutCancel(ctx)
+	sq.reservation = nil
+
+	go func() {
+		if _, err := sq.quotaService.SettleQuota(ctx, reservationID, tokensUsed); err != nil {
+			fmt.Printf("警告: 结算配额预留失败: %v\n", err)
+		}
+	}()
+}
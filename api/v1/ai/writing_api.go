@@ -127,9 +127,19 @@ func (api *WritingApi) ContinueWritingStream(c *gin.Context) {
 		serviceReq.Prompt += fmt.Sprintf("\n\n请续写约%d字的内容。", req.ContinueLength)
 	}
 
+	// 按估算用量预扣配额
+	quota, err := reserveStreamQuota(c, api.quotaService, serviceReq.Prompt, req.Options, "continue_writing", requestID)
+	if err != nil {
+		c.SSEvent("error", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// 获取流式响应通道
 	streamChan, err := api.aiService.GenerateContentStream(c.Request.Context(), serviceReq)
 	if err != nil {
+		quota.release("生成流启动失败，退回预扣配额")
 		c.SSEvent("error", gin.H{
 			"error": err.Error(),
 		})
@@ -144,7 +154,8 @@ func (api *WritingApi) ContinueWritingStream(c *gin.Context) {
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case <-c.Request.Context().Done():
-			// 客户端断开连接
+			// 客户端断开连接，按已生成内容结算
+			quota.settle(c.Request.Context(), 0, fullContent)
 			return false
 
 		case chunk, ok := <-streamChan:
@@ -157,18 +168,8 @@ func (api *WritingApi) ContinueWritingStream(c *gin.Context) {
 					"model":      model,
 				})
 
-				// 异步消费配额
-				go func() {
-					userID, _ := c.Get("user_id")
-					_ = api.quotaService.ConsumeQuota(
-						c.Request.Context(),
-						userID.(string),
-						totalTokens,
-						"continue_writing",
-						model,
-						requestID,
-					)
-				}()
+				// 按实际用量结算
+				quota.settle(c.Request.Context(), totalTokens, fullContent)
 
 				return false
 			}
@@ -320,9 +321,19 @@ func (api *WritingApi) RewriteTextStream(c *gin.Context) {
 		Options:   req.Options,
 	}
 
+	// 按估算用量预扣配额
+	quota, err := reserveStreamQuota(c, api.quotaService, serviceReq.Prompt, req.Options, "rewrite", requestID)
+	if err != nil {
+		c.SSEvent("error", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// 获取流式响应通道
 	streamChan, err := api.aiService.GenerateContentStream(c.Request.Context(), serviceReq)
 	if err != nil {
+		quota.release("生成流启动失败，退回预扣配额")
 		c.SSEvent("error", gin.H{
 			"error": err.Error(),
 		})
@@ -337,6 +348,7 @@ func (api *WritingApi) RewriteTextStream(c *gin.Context) {
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case <-c.Request.Context().Done():
+			quota.settle(c.Request.Context(), 0, fullContent)
 			return false
 
 		case chunk, ok := <-streamChan:
@@ -348,18 +360,8 @@ func (api *WritingApi) RewriteTextStream(c *gin.Context) {
 					"model":      model,
 				})
 
-				// 异步消费配额
-				go func() {
-					userID, _ := c.Get("user_id")
-					_ = api.quotaService.ConsumeQuota(
-						c.Request.Context(),
-						userID.(string),
-						totalTokens,
-						"rewrite",
-						model,
-						requestID,
-					)
-				}()
+				// 按实际用量结算
+				quota.settle(c.Request.Context(), totalTokens, fullContent)
 
 				return false
 			}
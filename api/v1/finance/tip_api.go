@@ -0,0 +1,188 @@
+package finance
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/pkg/response"
+	financeService "Qingyu_backend/service/finance"
+)
+
+// TipAPI 读者打赏API处理器
+type TipAPI struct {
+	tipService *financeService.TipService
+}
+
+// NewTipAPI 创建打赏API实例
+func NewTipAPI(tipService *financeService.TipService) *TipAPI {
+	return &TipAPI{
+		tipService: tipService,
+	}
+}
+
+// TipRequest 打赏请求
+type TipRequest struct {
+	BookID    string `json:"book_id" binding:"required"`
+	ChapterID string `json:"chapter_id"`
+	GiftCode  string `json:"gift_code" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"omitempty,min=1,max=99"`
+	Message   string `json:"message" binding:"omitempty,max=200"`
+}
+
+// GetGifts 获取打赏礼物档位
+//
+//	@Summary		获取打赏礼物档位
+//	@Description	获取预设的打赏礼物及单价（分）
+//	@Tags			读者打赏
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success 200 {object} response.APIResponse
+//	@Router			/api/v1/finance/tips/gifts [get]
+func (api *TipAPI) GetGifts(c *gin.Context) {
+	response.Success(c, api.tipService.GetGifts())
+}
+
+// Tip 打赏书籍
+//
+//	@Summary		打赏书籍
+//	@Description	从钱包扣款打赏书籍，可附带章节与留言
+//	@Tags			读者打赏
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			request	body		TipRequest	true	"打赏信息"
+//	@Success 200 {object} response.APIResponse
+//	@Router			/api/v1/finance/tips [post]
+func (api *TipAPI) Tip(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "未认证")
+		return
+	}
+
+	var req TipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误", err.Error())
+		return
+	}
+
+	tip, err := api.tipService.Tip(c.Request.Context(), userID.(string), &financeService.TipRequest{
+		BookID:     req.BookID,
+		ChapterID:  req.ChapterID,
+		GiftCode:   req.GiftCode,
+		Quantity:   req.Quantity,
+		Message:    req.Message,
+		SenderName: c.GetString("username"),
+	})
+	if err != nil {
+		if errors.Is(err, financeService.ErrInvalidTip) {
+			response.BadRequest(c, "打赏失败", err.Error())
+			return
+		}
+		response.InternalError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "打赏成功", tip)
+}
+
+// GetMyTips 获取我的打赏记录
+//
+//	@Summary		获取我的打赏记录
+//	@Description	获取当前用户的打赏记录
+//	@Tags			读者打赏
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			page		query		int		false	"页码"	default(1)
+//	@Param			page_size	query		int		false	"每页数量"	default(20)
+//	@Success 200 {object} response.APIResponse
+//	@Router			/api/v1/finance/tips/mine [get]
+func (api *TipAPI) GetMyTips(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "未认证")
+		return
+	}
+
+	page, pageSize := tipPagination(c)
+	tips, total, err := api.tipService.ListUserTips(c.Request.Context(), userID.(string), page, pageSize)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	response.Paginated(c, tips, total, page, pageSize, "获取打赏记录成功")
+}
+
+// GetBookTips 获取书籍的打赏记录
+//
+//	@Summary		获取书籍的打赏记录
+//	@Description	获取指定书籍最近的打赏记录
+//	@Tags			读者打赏
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			bookId		path		string	true	"书籍ID"
+//	@Param			page		query		int		false	"页码"	default(1)
+//	@Param			page_size	query		int		false	"每页数量"	default(20)
+//	@Success 200 {object} response.APIResponse
+//	@Router			/api/v1/finance/tips/books/{bookId} [get]
+func (api *TipAPI) GetBookTips(c *gin.Context) {
+	page, pageSize := tipPagination(c)
+	tips, total, err := api.tipService.ListBookTips(c.Request.Context(), c.Param("bookId"), page, pageSize)
+	if err != nil {
+		if errors.Is(err, financeService.ErrInvalidTip) {
+			response.BadRequest(c, "请求参数错误", err.Error())
+			return
+		}
+		response.InternalError(c, err)
+		return
+	}
+
+	response.Paginated(c, tips, total, page, pageSize, "获取打赏记录成功")
+}
+
+// GetFanLeaderboard 获取书籍粉丝榜
+//
+//	@Summary		获取书籍粉丝榜
+//	@Description	按粉丝值获取书籍的粉丝排行（总榜或本周榜）
+//	@Tags			读者打赏
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			bookId	path		string	true	"书籍ID"
+//	@Param			period	query		string	false	"榜单周期: all, weekly"	default(all)
+//	@Param			limit	query		int		false	"数量"	default(20)
+//	@Success 200 {object} response.APIResponse
+//	@Router			/api/v1/finance/tips/books/{bookId}/fans [get]
+func (api *TipAPI) GetFanLeaderboard(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	fans, err := api.tipService.GetFanLeaderboard(c.Request.Context(), c.Param("bookId"), c.DefaultQuery("period", "all"), limit)
+	if err != nil {
+		if errors.Is(err, financeService.ErrInvalidTip) {
+			response.BadRequest(c, "请求参数错误", err.Error())
+			return
+		}
+		response.InternalError(c, err)
+		return
+	}
+
+	response.Success(c, fans)
+}
+
+func tipPagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
@@ -155,3 +155,59 @@ func (api *ContextAPI) GetCharacterRelations(c *gin.Context) {
 		"relations": relations,
 	})
 }
+
+// GetItems 获取项目物品列表
+// @Summary 获取项目物品列表
+// @Description 获取指定项目下所有物品设定数据
+// @Tags Internal-Context
+// @Produce json
+// @Param id path string true "项目ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/internal/projects/{id}/items [get]
+func (api *ContextAPI) GetItems(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目ID不能为空"})
+		return
+	}
+
+	items, err := api.aggregator.GetItems(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+	})
+}
+
+// GetOrganizations 获取项目组织列表
+// @Summary 获取项目组织列表
+// @Description 获取指定项目下所有组织设定数据，包含成员与组织间关系
+// @Tags Internal-Context
+// @Produce json
+// @Param id path string true "项目ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/internal/projects/{id}/organizations [get]
+func (api *ContextAPI) GetOrganizations(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目ID不能为空"})
+		return
+	}
+
+	organizations, err := api.aggregator.GetOrganizations(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organizations": organizations,
+	})
+}
@@ -32,6 +32,28 @@ type SearchRequest struct {
 	Sort     []SortFieldRequest     `json:"sort"`                     // 排序字段
 	Page     int                    `json:"page"`                     // 页码，默认 1
 	PageSize int                    `json:"page_size"`                // 每页数量，默认 20
+	Hybrid   *HybridRequest         `json:"hybrid,omitempty"`         // 混合检索选项（仅 books、documents 支持）
+}
+
+// HybridRequest 混合检索（关键词 + 语义）请求
+type HybridRequest struct {
+	Fusion        string  `json:"fusion"`         // 融合方式：rrf（默认）| weighted
+	KeywordWeight float64 `json:"keyword_weight"` // 关键词结果权重
+	VectorWeight  float64 `json:"vector_weight"`  // 向量结果权重
+	RRFK          int     `json:"rrf_k"`          // RRF 平滑常数，默认 60
+}
+
+// toServiceOptions 转换为 Service 层选项
+func (r *HybridRequest) toServiceOptions() *searchModels.HybridOptions {
+	if r == nil {
+		return nil
+	}
+	return &searchModels.HybridOptions{
+		Fusion:        r.Fusion,
+		KeywordWeight: r.KeywordWeight,
+		VectorWeight:  r.VectorWeight,
+		RRFK:          r.RRFK,
+	}
 }
 
 // SortFieldRequest 排序字段请求
@@ -134,6 +156,7 @@ func (api *SearchAPI) Search(c *gin.Context) {
 		Page:     req.Page,
 		PageSize: req.PageSize,
 	}
+	serviceReq.Hybrid = req.Hybrid.toServiceOptions()
 
 	// 转换排序字段
 	if len(req.Sort) > 0 {
@@ -276,6 +299,7 @@ func (api *SearchAPI) SearchBatch(c *gin.Context) {
 			Filter:   q.Filter,
 			Page:     q.Page,
 			PageSize: q.PageSize,
+			Hybrid:   q.Hybrid.toServiceOptions(),
 		}
 
 		// 转换排序字段
@@ -716,3 +716,30 @@ func TestSearch_InvalidJSON(t *testing.T) {
 	assert.Equal(t, float64(1001), response["code"])
 	assert.Contains(t, response["message"], "参数错误")
 }
+
+// TestSuggest_InvalidParams 测试联想参数校验
+func TestSuggest_InvalidParams(t *testing.T) {
+	searchAPI, router := setupTestAPI()
+
+	router.GET("/api/v1/search/suggest", searchAPI.Suggest)
+	router.GET("/api/v1/search/suggest/documents", searchAPI.SuggestDocuments)
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"empty prefix", "/api/v1/search/suggest?q=%20", http.StatusBadRequest},
+		{"unsupported type", "/api/v1/search/suggest?q=dpcq&types=book,document", http.StatusBadRequest},
+		{"documents without user", "/api/v1/search/suggest/documents?q=di", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -23,6 +23,16 @@ func RegisterSearchRoutes(router *gin.RouterGroup, searchSvc *searchService.Sear
 
 		// 健康检查
 		searchGroup.GET("/health", searchAPI.Health)
+
+		// 搜索联想（书名、作者、标签）
+		searchGroup.GET("/suggest", searchAPI.Suggest)
+	}
+
+	// 文档联想（需要认证，仅返回当前用户的文档）
+	suggestGroup := router.Group("/search/suggest")
+	suggestGroup.Use(auth.JWTAuth())
+	{
+		suggestGroup.GET("/documents", searchAPI.SuggestDocuments)
 	}
 
 	// 灰度配置管理路由组（需要认证）
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/pkg/response"
+	"Qingyu_backend/service/search/suggest"
+)
+
+// Suggest 搜索联想
+//
+//	@Summary		搜索联想
+//	@Description	输入即联想书名、作者与标签，支持原文前缀、全拼（doupo）与首字母（dpcq），按热度排序
+//	@Tags			搜索
+//	@Produce		json
+//	@Param			q		query		string	true	"输入前缀"
+//	@Param			types	query		string	false	"联想类别，逗号分隔：book,author,tag，默认全部"
+//	@Param			limit	query		int		false	"每个类别返回条数，默认 5，最大 20"
+//	@Success		200		{object} response.APIResponse
+//	@Failure		400		{object} response.APIResponse
+//	@Router			/api/v1/search/suggest [get]
+func (api *SearchAPI) Suggest(c *gin.Context) {
+	kinds, ok := parseSuggestKinds(c.Query("types"))
+	if !ok {
+		response.BadRequest(c, "参数错误", "types 仅支持 book、author、tag")
+		return
+	}
+
+	api.suggest(c, &suggest.Request{
+		Prefix: c.Query("q"),
+		Kinds:  kinds,
+		Limit:  parseSuggestLimit(c.Query("limit")),
+	})
+}
+
+// SuggestDocuments 当前用户的文档联想
+//
+//	@Summary		文档联想
+//	@Description	联想当前用户项目中的文档标题，按最近更新时间排序
+//	@Tags			搜索
+//	@Produce		json
+//	@Param			q		query		string	true	"输入前缀"
+//	@Param			limit	query		int		false	"返回条数，默认 5，最大 20"
+//	@Success		200		{object} response.APIResponse
+//	@Failure		401		{object} response.APIResponse
+//	@Router			/api/v1/search/suggest/documents [get]
+func (api *SearchAPI) SuggestDocuments(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	api.suggest(c, &suggest.Request{
+		Prefix: c.Query("q"),
+		UserID: userID,
+		Kinds:  []suggest.Kind{suggest.KindDocument},
+		Limit:  parseSuggestLimit(c.Query("limit")),
+	})
+}
+
+func (api *SearchAPI) suggest(c *gin.Context, req *suggest.Request) {
+	if strings.TrimSpace(req.Prefix) == "" {
+		response.BadRequest(c, "参数错误", "输入前缀不能为空")
+		return
+	}
+
+	result, err := api.searchService.Suggest(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "获取联想成功", result)
+}
+
+// parseSuggestKinds 解析公开联想类别，为空时返回 nil（查询全部公开类别）
+func parseSuggestKinds(raw string) ([]suggest.Kind, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, true
+	}
+	var kinds []suggest.Kind
+	for _, part := range strings.Split(raw, ",") {
+		kind := suggest.Kind(strings.TrimSpace(part))
+		switch kind {
+		case suggest.KindBook, suggest.KindAuthor, suggest.KindTag:
+			kinds = append(kinds, kind)
+		case "":
+		default:
+			return nil, false
+		}
+	}
+	return kinds, true
+}
+
+func parseSuggestLimit(raw string) int {
+	limit, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0
+	}
+	return limit
+}
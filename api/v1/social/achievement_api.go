@@ -0,0 +1,110 @@
+package social
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/shared"
+	"Qingyu_backend/pkg/response"
+	"Qingyu_backend/service/interfaces"
+)
+
+// AchievementAPI 成就API处理器
+type AchievementAPI struct {
+	achievementService interfaces.AchievementService
+}
+
+// NewAchievementAPI 创建成就API实例
+func NewAchievementAPI(achievementService interfaces.AchievementService) *AchievementAPI {
+	return &AchievementAPI{
+		achievementService: achievementService,
+	}
+}
+
+// GetMyAchievements 获取当前用户的成就（已解锁与进行中）
+// @Summary 获取我的成就
+// @Tags 社交-成就
+// @Produce json
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/social/achievements [get]
+// @Security Bearer
+func (api *AchievementAPI) GetMyAchievements(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	overview, err := api.achievementService.ListUserAchievements(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, overview)
+}
+
+// GetUserAchievements 获取指定用户已解锁的成就
+// @Summary 获取用户已解锁的成就
+// @Tags 社交-成就
+// @Produce json
+// @Param userId path string true "用户ID"
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/social/users/{userId}/achievements [get]
+// @Security Bearer
+func (api *AchievementAPI) GetUserAchievements(c *gin.Context) {
+	userID, ok := shared.GetRequiredParam(c, "userId", "用户ID")
+	if !ok {
+		return
+	}
+
+	earned, err := api.achievementService.ListEarnedAchievements(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, earned)
+}
+
+// BackfillAchievementsRequest 成就回填请求
+type BackfillAchievementsRequest struct {
+	Since string `json:"since"` // 仅回放该时间之后的事件（RFC3339），为空表示全部
+}
+
+// BackfillAchievements 回放历史事件为存量用户补算成就
+// @Summary 回填成就
+// @Tags 管理员-成就
+// @Accept json
+// @Produce json
+// @Param request body BackfillAchievementsRequest false "回填参数"
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/admin/achievements/backfill [post]
+// @Security Bearer
+func (api *AchievementAPI) BackfillAchievements(c *gin.Context) {
+	var req BackfillAchievementsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "参数错误", err.Error())
+			return
+		}
+	}
+
+	var since *time.Time
+	if req.Since != "" {
+		parsed, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			response.BadRequest(c, "参数错误", "since时间格式无效，请使用ISO8601格式")
+			return
+		}
+		since = &parsed
+	}
+
+	result, err := api.achievementService.BackfillAchievements(c.Request.Context(), since)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, result)
+}
@@ -0,0 +1,142 @@
+package social_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	socialAPI "Qingyu_backend/api/v1/social"
+	"Qingyu_backend/models/social"
+	"Qingyu_backend/service/interfaces"
+)
+
+// MockAchievementService 模拟成就服务接口
+type MockAchievementService struct {
+	mock.Mock
+}
+
+func (m *MockAchievementService) ListUserAchievements(ctx context.Context, userID string) (*social.AchievementOverview, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*social.AchievementOverview), args.Error(1)
+}
+
+func (m *MockAchievementService) ListEarnedAchievements(ctx context.Context, userID string) ([]*social.AchievementView, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*social.AchievementView), args.Error(1)
+}
+
+func (m *MockAchievementService) BackfillAchievements(ctx context.Context, since *time.Time) (*social.AchievementBackfillResult, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*social.AchievementBackfillResult), args.Error(1)
+}
+
+func setupAchievementTestRouter(achievementService interfaces.AchievementService, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+
+	api := socialAPI.NewAchievementAPI(achievementService)
+	r.GET("/api/v1/social/achievements", api.GetMyAchievements)
+	r.GET("/api/v1/social/users/:userId/achievements", api.GetUserAchievements)
+	r.POST("/api/v1/admin/achievements/backfill", api.BackfillAchievements)
+	return r
+}
+
+// TestAchievementAPI_GetMyAchievements 测试获取我的成就
+func TestAchievementAPI_GetMyAchievements(t *testing.T) {
+	mockService := new(MockAchievementService)
+	router := setupAchievementTestRouter(mockService, "user-1")
+
+	overview := &social.AchievementOverview{
+		Earned: []*social.AchievementView{{
+			AchievementDefinition: social.AchievementDefinition{ID: "first_like", Threshold: 1},
+			Progress:              1,
+			Unlocked:              true,
+		}},
+		InProgress: []*social.AchievementView{},
+		Total:      15,
+	}
+	mockService.On("ListUserAchievements", mock.Anything, "user-1").Return(overview, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/social/achievements", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(15), data["total"])
+	assert.Len(t, data["earned"], 1)
+	mockService.AssertExpectations(t)
+}
+
+// TestAchievementAPI_GetMyAchievements_Unauthorized 测试未登录获取成就
+func TestAchievementAPI_GetMyAchievements_Unauthorized(t *testing.T) {
+	mockService := new(MockAchievementService)
+	router := setupAchievementTestRouter(mockService, "")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/social/achievements", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertNotCalled(t, "ListUserAchievements", mock.Anything, mock.Anything)
+}
+
+// TestAchievementAPI_BackfillAchievements 测试成就回填
+func TestAchievementAPI_BackfillAchievements(t *testing.T) {
+	mockService := new(MockAchievementService)
+	router := setupAchievementTestRouter(mockService, "admin-1")
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("BackfillAchievements", mock.Anything, mock.MatchedBy(func(t *time.Time) bool {
+		return t != nil && t.Equal(since)
+	})).Return(&social.AchievementBackfillResult{ReplayedCount: 5, AwardedCount: 2}, nil)
+
+	body, _ := json.Marshal(map[string]string{"since": "2026-01-01T00:00:00Z"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/admin/achievements/backfill", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestAchievementAPI_BackfillAchievements_InvalidSince 测试回填时间格式错误
+func TestAchievementAPI_BackfillAchievements_InvalidSince(t *testing.T) {
+	mockService := new(MockAchievementService)
+	router := setupAchievementTestRouter(mockService, "admin-1")
+
+	body, _ := json.Marshal(map[string]string{"since": "yesterday"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/admin/achievements/backfill", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "BackfillAchievements", mock.Anything, mock.Anything)
+}
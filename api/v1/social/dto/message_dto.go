@@ -41,6 +41,9 @@ type MessageItem struct {
 	Attachments    []MessageAttachmentDTO `json:"attachments,omitempty"`
 	ReplyTo        *string                `json:"reply_to,omitempty"`
 	Read           bool                   `json:"read"`
+	ReadCount      int                    `json:"read_count"`
+	Status         string                 `json:"status"`
+	EditedAt       *time.Time             `json:"edited_at,omitempty"`
 	SentAt         time.Time              `json:"sent_at"`
 }
 
@@ -90,3 +93,87 @@ type MessageAttachmentDTO struct {
 	Size     int64  `json:"size"`
 	MimeType string `json:"mime_type"`
 }
+
+// MuteConversationRequest 设置免打扰请求
+type MuteConversationRequest struct {
+	Muted *bool `json:"muted" binding:"required"`
+}
+
+// MuteConversationResponse 设置免打扰响应
+type MuteConversationResponse struct {
+	ConversationID string `json:"conversation_id"`
+	Muted          bool   `json:"muted"`
+}
+
+// ========== 群聊 ==========
+
+// CreateGroupRequest 创建群聊请求
+type CreateGroupRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=50"`
+	Description string   `json:"description" binding:"max=500"`
+	Avatar      string   `json:"avatar"`
+	MemberIDs   []string `json:"member_ids" binding:"required,min=1"`
+	MaxMembers  int      `json:"max_members" binding:"omitempty,min=2,max=2000"`
+}
+
+// GroupMembersRequest 邀请成员请求
+type GroupMembersRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required,min=1"`
+}
+
+// TransferOwnershipRequest 转让群主请求
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// SetAdminRequest 设置管理员请求
+type SetAdminRequest struct {
+	Admin *bool `json:"admin" binding:"required"`
+}
+
+// GroupInfoResponse 群聊信息响应
+type GroupInfoResponse struct {
+	ConversationID string    `json:"conversation_id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description,omitempty"`
+	Avatar         string    `json:"avatar,omitempty"`
+	OwnerID        string    `json:"owner_id"`
+	AdminIDs       []string  `json:"admin_ids"`
+	Members        []string  `json:"members"`
+	MaxMembers     int       `json:"max_members"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ========== 撤回、编辑与已读回执 ==========
+
+// EditMessageRequest 编辑消息请求
+type EditMessageRequest struct {
+	Content string `json:"content" binding:"required,min=1,max=5000"`
+}
+
+// MessageEditItem 编辑历史项
+type MessageEditItem struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// MessageEditHistoryResponse 消息编辑历史响应
+type MessageEditHistoryResponse struct {
+	MessageID string            `json:"message_id"`
+	Content   string            `json:"content"`
+	EditedAt  *time.Time        `json:"edited_at,omitempty"`
+	History   []MessageEditItem `json:"history"`
+}
+
+// ReadReceiptItem 已读回执项
+type ReadReceiptItem struct {
+	UserID string    `json:"user_id"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+// ReadReceiptsResponse 消息已读回执响应
+type ReadReceiptsResponse struct {
+	MessageID string            `json:"message_id"`
+	ReadCount int               `json:"read_count"`
+	Receipts  []ReadReceiptItem `json:"receipts"`
+}
@@ -1,6 +1,7 @@
 package social
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -113,18 +114,7 @@ func (api *MessageAPIV2) GetMessages(c *gin.Context) {
 	// 转换为响应格式
 	messageItems := make([]dto.MessageItem, len(messages))
 	for i, msg := range messages {
-		messageItems[i] = dto.MessageItem{
-			ID:             msg.ID.Hex(),
-			ConversationID: msg.ConversationID,
-			SenderID:       msg.SenderID,
-			ReceiverID:     msg.ReceiverID,
-			Content:        msg.Content,
-			Type:           string(msg.Type),
-			Attachments:    convertAttachments(msg.Extra),
-			ReplyTo:        msg.ParentID,
-			Read:           msg.IsRead,
-			SentAt:         msg.CreatedAt,
-		}
+		messageItems[i] = toMessageItem(msg, userID)
 	}
 
 	hasMore := len(messages) == req.PageSize
@@ -193,13 +183,10 @@ func (api *MessageAPIV2) SendMessage(c *gin.Context) {
 		replyToID = req.ReplyTo
 	}
 
-	// 确定接收者
+	// 确定接收者（群聊消息没有单一接收者）
 	var receiverID string
-	for _, participantID := range conv.ParticipantIDs {
-		if participantID != userID {
-			receiverID = participantID
-			break
-		}
+	if !conv.IsGroup() {
+		receiverID, _ = conv.GetOtherParticipantID(userID)
 	}
 
 	// 创建消息
@@ -237,15 +224,19 @@ func (api *MessageAPIV2) SendMessage(c *gin.Context) {
 			"read":           savedMessage.IsRead,
 			"createdAt":      savedMessage.CreatedAt,
 		}
-		api.wsHub.SendMessage(conversationID, wsMessage, userID)
+		// 经 REST 发送，发送者的其他设备同样需要收到
+		api.wsHub.SendMessage(conversationID, conv.ParticipantIDs, wsMessage, "")
 	}
 
 	// 获取接收者未读数
-	unreadCount, _ := api.messageService.GetUnreadCount(
-		c.Request.Context(),
-		conversationID,
-		receiverID,
-	)
+	var unreadCount int
+	if receiverID != "" {
+		unreadCount, _ = api.messageService.GetUnreadCount(
+			c.Request.Context(),
+			conversationID,
+			receiverID,
+		)
+	}
 
 	response.Success(c, dto.SendMessageResponse{
 		MessageID:   savedMessage.ID.Hex(),
@@ -346,14 +337,297 @@ func (api *MessageAPIV2) MarkConversationRead(c *gin.Context) {
 		return
 	}
 
+	// 推送已读回执给会话参与者（含本人其他设备，用于同步未读数）
+	if api.wsHub != nil && affected > 0 {
+		if conv, err := api.conversationService.Get(c.Request.Context(), conversationID); err == nil {
+			api.wsHub.SendEvent("read_receipt", conversationID, conv.ParticipantIDs, map[string]interface{}{
+				"conversationId": conversationID,
+				"userId":         userID,
+				"readAt":         readAt,
+				"count":          affected,
+			}, "")
+		}
+	}
+
 	response.Success(c, dto.MarkAsReadResponse{
 		Success: true,
 		Message: fmt.Sprintf("已标记%d条消息为已读", affected),
 	})
 }
 
+// RecallMessage 撤回消息
+// @Summary 撤回消息
+// @Description 发送者可在时限内撤回自己的消息，群主和管理员可撤回群内任意消息
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param messageId path string true "消息ID"
+// @Success 200 {object} response.APIResponse{data=dto.MessageItem}
+// @Failure 400 {object} response.APIResponse "超过撤回时限"
+// @Failure 403 {object} response.APIResponse "无权操作"
+// @Failure 404 {object} response.APIResponse "消息不存在"
+// @Router /api/v1/social/messages/conversations/{conversationId}/messages/{messageId}/recall [post]
+func (api *MessageAPIV2) RecallMessage(c *gin.Context) {
+	userID, conv, ok := api.participantConversation(c)
+	if !ok {
+		return
+	}
+
+	message, err := api.messageService.Recall(c.Request.Context(), conv, c.Param("messageId"), userID)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	if api.wsHub != nil {
+		api.wsHub.SendEvent("message_recalled", message.ConversationID, conv.ParticipantIDs, map[string]interface{}{
+			"id":             message.ID.Hex(),
+			"conversationId": message.ConversationID,
+			"recalledBy":     userID,
+			"recalledAt":     message.RecalledAt,
+		}, "")
+	}
+
+	response.SuccessWithMessage(c, "撤回成功", toMessageItem(message, userID))
+}
+
+// EditMessage 编辑消息
+// @Summary 编辑消息
+// @Description 发送者可在时限内编辑自己的文本消息，旧内容保存在编辑历史中
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param messageId path string true "消息ID"
+// @Param request body dto.EditMessageRequest true "编辑消息请求"
+// @Success 200 {object} response.APIResponse{data=dto.MessageItem}
+// @Failure 400 {object} response.APIResponse "超过编辑时限"
+// @Failure 403 {object} response.APIResponse "无权操作"
+// @Failure 404 {object} response.APIResponse "消息不存在"
+// @Router /api/v1/social/messages/conversations/{conversationId}/messages/{messageId} [put]
+func (api *MessageAPIV2) EditMessage(c *gin.Context) {
+	var req dto.EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	userID, conv, ok := api.participantConversation(c)
+	if !ok {
+		return
+	}
+
+	message, err := api.messageService.Edit(c.Request.Context(), conv, c.Param("messageId"), userID, req.Content)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	item := toMessageItem(message, userID)
+	if api.wsHub != nil {
+		api.wsHub.SendEvent("message_edited", message.ConversationID, conv.ParticipantIDs, item, "")
+	}
+
+	response.SuccessWithMessage(c, "编辑成功", item)
+}
+
+// GetMessageEditHistory 获取消息编辑历史
+// @Summary 获取消息编辑历史
+// @Tags Social Messages
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param messageId path string true "消息ID"
+// @Success 200 {object} response.APIResponse{data=dto.MessageEditHistoryResponse}
+// @Failure 403 {object} response.APIResponse "无权访问"
+// @Failure 404 {object} response.APIResponse "消息不存在"
+// @Router /api/v1/social/messages/conversations/{conversationId}/messages/{messageId}/edits [get]
+func (api *MessageAPIV2) GetMessageEditHistory(c *gin.Context) {
+	_, conv, ok := api.participantConversation(c)
+	if !ok {
+		return
+	}
+
+	message, err := api.messageService.GetMessage(c.Request.Context(), conv, c.Param("messageId"))
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	resp := dto.MessageEditHistoryResponse{
+		MessageID: message.ID.Hex(),
+		Content:   message.Content,
+		EditedAt:  message.EditedAt,
+		History:   make([]dto.MessageEditItem, 0, len(message.EditHistory)),
+	}
+	if message.IsRecalled() {
+		resp.Content = ""
+	} else {
+		for _, record := range message.EditHistory {
+			resp.History = append(resp.History, dto.MessageEditItem{Content: record.Content, EditedAt: record.EditedAt})
+		}
+	}
+
+	response.Success(c, resp)
+}
+
+// GetReadReceipts 获取消息已读回执
+// @Summary 获取消息已读回执
+// @Description 返回已读该消息的用户及已读时间（如群聊中“3人已读”）
+// @Tags Social Messages
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param messageId path string true "消息ID"
+// @Success 200 {object} response.APIResponse{data=dto.ReadReceiptsResponse}
+// @Failure 403 {object} response.APIResponse "无权访问"
+// @Failure 404 {object} response.APIResponse "消息不存在"
+// @Router /api/v1/social/messages/conversations/{conversationId}/messages/{messageId}/receipts [get]
+func (api *MessageAPIV2) GetReadReceipts(c *gin.Context) {
+	_, conv, ok := api.participantConversation(c)
+	if !ok {
+		return
+	}
+
+	message, err := api.messageService.GetMessage(c.Request.Context(), conv, c.Param("messageId"))
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	receipts := make([]dto.ReadReceiptItem, 0, len(message.ReadBy))
+	for _, r := range message.ReadBy {
+		receipts = append(receipts, dto.ReadReceiptItem{UserID: r.UserID, ReadAt: r.ReadAt})
+	}
+	// 早于已读回执的单聊消息只有 is_read 标记
+	if len(receipts) == 0 && message.IsRead && message.ReadAt != nil {
+		receipts = append(receipts, dto.ReadReceiptItem{UserID: message.ReceiverID, ReadAt: *message.ReadAt})
+	}
+
+	response.Success(c, dto.ReadReceiptsResponse{
+		MessageID: message.ID.Hex(),
+		ReadCount: len(receipts),
+		Receipts:  receipts,
+	})
+}
+
+// MuteConversation 设置会话免打扰
+// @Summary 设置会话免打扰
+// @Description 开启免打扰后该会话的新消息不再产生通知
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param request body dto.MuteConversationRequest true "免打扰设置"
+// @Success 200 {object} response.APIResponse{data=dto.MuteConversationResponse}
+// @Failure 403 {object} response.APIResponse "无权访问"
+// @Failure 404 {object} response.APIResponse "会话不存在"
+// @Router /api/v1/social/messages/conversations/{conversationId}/mute [put]
+func (api *MessageAPIV2) MuteConversation(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	var req dto.MuteConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	conversationID := c.Param("conversationId")
+	conv, err := api.conversationService.SetMuted(c.Request.Context(), conversationID, userID, *req.Muted)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	response.Success(c, dto.MuteConversationResponse{
+		ConversationID: conversationID,
+		Muted:          conv.IsMutedByUser(userID),
+	})
+}
+
 // 辅助函数
 
+// participantConversation 校验登录用户并加载其参与的会话，失败时已写入响应
+func (api *MessageAPIV2) participantConversation(c *gin.Context) (string, *modelsMessaging.Conversation, bool) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return "", nil, false
+	}
+
+	conv, err := api.conversationService.Get(c.Request.Context(), c.Param("conversationId"))
+	if err != nil {
+		handleMessagingError(c, err)
+		return "", nil, false
+	}
+	if !conv.HasParticipant(userID) {
+		response.Forbidden(c, "无权访问")
+		return "", nil, false
+	}
+	return userID, conv, true
+}
+
+// handleMessagingError 将消息服务错误转换为响应
+func handleMessagingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, modelsMessaging.ErrConversationNotFound),
+		errors.Is(err, serviceMessaging.ErrConversationNotFound):
+		response.NotFound(c, "会话不存在")
+	case errors.Is(err, serviceMessaging.ErrMessageNotFound):
+		response.NotFound(c, "消息不存在")
+	case errors.Is(err, serviceMessaging.ErrNotParticipant):
+		response.Forbidden(c, "无权访问")
+	case errors.Is(err, serviceMessaging.ErrPermissionDenied):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, serviceMessaging.ErrNotGroup),
+		errors.Is(err, serviceMessaging.ErrGroupFull),
+		errors.Is(err, serviceMessaging.ErrOwnerMustTransfer),
+		errors.Is(err, serviceMessaging.ErrInvalidParticipantIDs),
+		errors.Is(err, serviceMessaging.ErrRecallExpired),
+		errors.Is(err, serviceMessaging.ErrEditNotAllowed):
+		response.BadRequest(c, "操作失败", err.Error())
+	default:
+		c.Error(err)
+	}
+}
+
+// toMessageItem 转换消息为当前用户视角的响应格式，已撤回消息不返回内容
+func toMessageItem(msg *modelsMessaging.DirectMessage, userID string) dto.MessageItem {
+	item := dto.MessageItem{
+		ID:             msg.ID.Hex(),
+		ConversationID: msg.ConversationID,
+		SenderID:       msg.SenderID,
+		ReceiverID:     msg.ReceiverID,
+		Content:        msg.Content,
+		Type:           string(msg.Type),
+		Attachments:    convertAttachments(msg.Extra),
+		ReplyTo:        msg.ParentID,
+		Read:           msg.IsReadBy(userID),
+		ReadCount:      msg.ReadCount(),
+		Status:         string(msg.Status),
+		EditedAt:       msg.EditedAt,
+		SentAt:         msg.CreatedAt,
+	}
+	// 自己发送的消息以是否有人已读为准
+	if msg.SenderID == userID {
+		item.Read = item.ReadCount > 0
+	}
+	if msg.IsRecalled() {
+		item.Content = ""
+		item.Attachments = nil
+		item.EditedAt = nil
+	}
+	return item
+}
+
 // contains 检查字符串切片是否包含某元素
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
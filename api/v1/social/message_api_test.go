@@ -45,6 +45,11 @@ func setupMessageAPITestRouter(messageRepo *mocks.MockMessageRepository, convers
 		v1.POST("/conversations/:conversationId/messages", api.SendMessage)
 		v1.POST("/conversations", api.CreateConversation)
 		v1.POST("/conversations/:conversationId/read", api.MarkConversationRead)
+		v1.PUT("/conversations/:conversationId/mute", api.MuteConversation)
+		v1.POST("/conversations/:conversationId/messages/:messageId/recall", api.RecallMessage)
+		v1.GET("/conversations/:conversationId/messages/:messageId/receipts", api.GetReadReceipts)
+		v1.POST("/groups", api.CreateGroup)
+		v1.DELETE("/conversations/:conversationId/members/:userId", api.RemoveGroupMember)
 	}
 
 	return r
@@ -806,3 +811,199 @@ func TestMessageAPI_MarkConversationRead_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockMessageRepo.AssertExpectations(t)
 }
+
+// =========================
+// 群聊、撤回与已读回执测试
+// =========================
+
+// newAPITestGroup 创建测试群聊
+func newAPITestGroup(ownerID string, members ...string) *modelsMessaging.Conversation {
+	conv := &modelsMessaging.Conversation{
+		ParticipantIDs: append([]string{ownerID}, members...),
+		Type:           modelsMessaging.ConversationTypeGroup,
+		IsActive:       true,
+		GroupInfo:      &modelsMessaging.ConversationGroupInfo{Name: "测试群", OwnerID: ownerID, MaxMembers: 10},
+	}
+	conv.ID = primitive.NewObjectID()
+	return conv
+}
+
+// TestMessageAPI_CreateGroup_Success 测试创建群聊
+func TestMessageAPI_CreateGroup_Success(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*messaging.Conversation")).
+		Return(nil)
+
+	body := dto.CreateGroupRequest{Name: "读书会", MemberIDs: []string{"u1", "u2"}}
+	bodyBytes, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/api/v1/social/messages/groups", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Data dto.GroupInfoResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, userID, resp.Data.OwnerID)
+	assert.Equal(t, []string{userID, "u1", "u2"}, resp.Data.Members)
+	mockConvRepo.AssertExpectations(t)
+}
+
+// TestMessageAPI_RemoveGroupMember_Forbidden 测试普通成员不能移出他人
+func TestMessageAPI_RemoveGroupMember_Forbidden(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	conv := newAPITestGroup("owner", userID, "u2")
+	mockConvRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/social/messages/conversations/"+conv.ID.Hex()+"/members/u2", nil)
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockConvRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestMessageAPI_RecallMessage_Success 测试撤回消息
+func TestMessageAPI_RecallMessage_Success(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	conv := newAPITestGroup("owner", userID)
+	mockConvRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+
+	msg := &modelsMessaging.DirectMessage{
+		ConversationID: conv.ID.Hex(),
+		Type:           modelsMessaging.MessageTypeText,
+		Content:        "说错了",
+		Status:         modelsMessaging.MessageStatusNormal,
+	}
+	msg.ID = primitive.NewObjectID()
+	msg.SenderID = userID
+	msg.CreatedAt = time.Now()
+	mockMessageRepo.On("FindByID", mock.Anything, msg.ID.Hex()).Return(msg, nil)
+	mockMessageRepo.On("Update", mock.Anything, msg).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/social/messages/conversations/"+conv.ID.Hex()+"/messages/"+msg.ID.Hex()+"/recall", nil)
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data dto.MessageItem `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "recalled", resp.Data.Status)
+	assert.Empty(t, resp.Data.Content)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+// TestMessageAPI_RecallMessage_Expired 测试超过撤回时限
+func TestMessageAPI_RecallMessage_Expired(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	conv := newAPITestGroup("owner", userID)
+	mockConvRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+
+	msg := &modelsMessaging.DirectMessage{ConversationID: conv.ID.Hex(), Status: modelsMessaging.MessageStatusNormal}
+	msg.ID = primitive.NewObjectID()
+	msg.SenderID = userID
+	msg.CreatedAt = time.Now().Add(-time.Hour)
+	mockMessageRepo.On("FindByID", mock.Anything, msg.ID.Hex()).Return(msg, nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/social/messages/conversations/"+conv.ID.Hex()+"/messages/"+msg.ID.Hex()+"/recall", nil)
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockMessageRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestMessageAPI_GetReadReceipts_Success 测试获取已读回执
+func TestMessageAPI_GetReadReceipts_Success(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	conv := newAPITestGroup(userID, "u1", "u2", "u3")
+	mockConvRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+
+	msg := &modelsMessaging.DirectMessage{ConversationID: conv.ID.Hex(), Status: modelsMessaging.MessageStatusNormal}
+	msg.ID = primitive.NewObjectID()
+	msg.SenderID = userID
+	now := time.Now()
+	msg.ReadBy = []modelsMessaging.MessageReadReceipt{{UserID: "u1", ReadAt: now}, {UserID: "u2", ReadAt: now}, {UserID: "u3", ReadAt: now}}
+	mockMessageRepo.On("FindByID", mock.Anything, msg.ID.Hex()).Return(msg, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/social/messages/conversations/"+conv.ID.Hex()+"/messages/"+msg.ID.Hex()+"/receipts", nil)
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data dto.ReadReceiptsResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Data.ReadCount)
+}
+
+// TestMessageAPI_MuteConversation_Success 测试设置免打扰
+func TestMessageAPI_MuteConversation_Success(t *testing.T) {
+	// Given
+	mockMessageRepo := new(mocks.MockMessageRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	userID := primitive.NewObjectID().Hex()
+	router := setupMessageAPITestRouter(mockMessageRepo, mockConvRepo, userID)
+
+	conv := newAPITestGroup("owner", userID)
+	mockConvRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+	mockConvRepo.On("Update", mock.Anything, conv).Return(nil)
+
+	bodyBytes, _ := json.Marshal(map[string]bool{"muted": true})
+	req, _ := http.NewRequest("PUT", "/api/v1/social/messages/conversations/"+conv.ID.Hex()+"/mute", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+
+	// When
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, conv.IsMutedByUser(userID))
+	mockConvRepo.AssertExpectations(t)
+}
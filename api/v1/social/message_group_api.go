@@ -0,0 +1,248 @@
+package social
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/social/dto"
+	modelsMessaging "Qingyu_backend/models/messaging"
+	"Qingyu_backend/pkg/response"
+	serviceMessaging "Qingyu_backend/service/messaging"
+)
+
+// CreateGroup 创建群聊
+// @Summary 创建群聊
+// @Description 创建群聊，当前用户为群主
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateGroupRequest true "创建群聊请求"
+// @Success 201 {object} response.APIResponse{data=dto.GroupInfoResponse}
+// @Failure 400 {object} response.APIResponse "参数错误"
+// @Router /api/v1/social/messages/groups [post]
+func (api *MessageAPIV2) CreateGroup(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	var req dto.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	conv, err := api.conversationService.CreateGroup(c.Request.Context(), userID, &serviceMessaging.CreateGroupRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		Avatar:      req.Avatar,
+		MemberIDs:   req.MemberIDs,
+		MaxMembers:  req.MaxMembers,
+	})
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	api.pushGroupUpdate(conv, "created", userID)
+	response.Created(c, toGroupInfo(conv))
+}
+
+// InviteGroupMembers 邀请成员入群
+// @Summary 邀请成员入群
+// @Description 群主或管理员邀请用户加入群聊
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param request body dto.GroupMembersRequest true "邀请成员请求"
+// @Success 200 {object} response.APIResponse{data=dto.GroupInfoResponse}
+// @Failure 400 {object} response.APIResponse "参数错误或群已满"
+// @Failure 403 {object} response.APIResponse "无权操作"
+// @Router /api/v1/social/messages/conversations/{conversationId}/members [post]
+func (api *MessageAPIV2) InviteGroupMembers(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	var req dto.GroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	conv, added, err := api.conversationService.InviteMembers(c.Request.Context(), c.Param("conversationId"), userID, req.UserIDs)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	if len(added) > 0 {
+		api.pushGroupUpdate(conv, "members_added", userID)
+	}
+	response.Success(c, toGroupInfo(conv))
+}
+
+// RemoveGroupMember 移出群成员
+// @Summary 移出群成员
+// @Description 群主可移出任何成员，管理员只能移出普通成员
+// @Tags Social Messages
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param userId path string true "成员ID"
+// @Success 200 {object} response.APIResponse{data=dto.GroupInfoResponse}
+// @Failure 403 {object} response.APIResponse "无权操作"
+// @Router /api/v1/social/messages/conversations/{conversationId}/members/{userId} [delete]
+func (api *MessageAPIV2) RemoveGroupMember(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	targetID := c.Param("userId")
+	conv, err := api.conversationService.RemoveMember(c.Request.Context(), c.Param("conversationId"), userID, targetID)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	// 被移出的成员也需要收到通知
+	api.pushGroupUpdate(conv, "member_removed", userID, targetID)
+	response.Success(c, toGroupInfo(conv))
+}
+
+// LeaveGroup 退出群聊
+// @Summary 退出群聊
+// @Description 群主须先转让群主才能退出
+// @Tags Social Messages
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse "群主须先转让"
+// @Router /api/v1/social/messages/conversations/{conversationId}/leave [post]
+func (api *MessageAPIV2) LeaveGroup(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	conv, err := api.conversationService.Leave(c.Request.Context(), c.Param("conversationId"), userID)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	api.pushGroupUpdate(conv, "member_left", userID, userID)
+	response.SuccessWithMessage(c, "已退出群聊", nil)
+}
+
+// TransferGroupOwnership 转让群主
+// @Summary 转让群主
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param request body dto.TransferOwnershipRequest true "转让群主请求"
+// @Success 200 {object} response.APIResponse{data=dto.GroupInfoResponse}
+// @Failure 403 {object} response.APIResponse "仅群主可操作"
+// @Router /api/v1/social/messages/conversations/{conversationId}/transfer [post]
+func (api *MessageAPIV2) TransferGroupOwnership(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	conv, err := api.conversationService.TransferOwnership(c.Request.Context(), c.Param("conversationId"), userID, req.UserID)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	api.pushGroupUpdate(conv, "owner_transferred", userID)
+	response.Success(c, toGroupInfo(conv))
+}
+
+// SetGroupAdmin 设置或取消管理员
+// @Summary 设置或取消管理员
+// @Tags Social Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversationId path string true "会话ID"
+// @Param userId path string true "成员ID"
+// @Param request body dto.SetAdminRequest true "设置管理员请求"
+// @Success 200 {object} response.APIResponse{data=dto.GroupInfoResponse}
+// @Failure 403 {object} response.APIResponse "仅群主可操作"
+// @Router /api/v1/social/messages/conversations/{conversationId}/admins/{userId} [put]
+func (api *MessageAPIV2) SetGroupAdmin(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	var req dto.SetAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误", err.Error())
+		return
+	}
+
+	conv, err := api.conversationService.SetAdmin(c.Request.Context(), c.Param("conversationId"), userID, c.Param("userId"), *req.Admin)
+	if err != nil {
+		handleMessagingError(c, err)
+		return
+	}
+
+	api.pushGroupUpdate(conv, "admins_changed", userID)
+	response.Success(c, toGroupInfo(conv))
+}
+
+// pushGroupUpdate 向群成员（及额外接收者，如被移出的成员）推送群信息变更
+func (api *MessageAPIV2) pushGroupUpdate(conv *modelsMessaging.Conversation, action, operatorID string, extraRecipients ...string) {
+	if api.wsHub == nil {
+		return
+	}
+	recipients := append(append([]string{}, conv.ParticipantIDs...), extraRecipients...)
+	api.wsHub.SendEvent("group_updated", conv.ID.Hex(), recipients, map[string]interface{}{
+		"action":     action,
+		"operatorId": operatorID,
+		"group":      toGroupInfo(conv),
+	}, "")
+}
+
+// toGroupInfo 转换群聊信息为响应格式
+func toGroupInfo(conv *modelsMessaging.Conversation) dto.GroupInfoResponse {
+	info := dto.GroupInfoResponse{
+		ConversationID: conv.ID.Hex(),
+		Members:        conv.ParticipantIDs,
+		CreatedAt:      conv.CreatedAt,
+	}
+	if conv.GroupInfo != nil {
+		info.Name = conv.GroupInfo.Name
+		info.Description = conv.GroupInfo.Description
+		info.Avatar = conv.GroupInfo.Avatar
+		info.OwnerID = conv.GroupInfo.OwnerID
+		info.AdminIDs = conv.GroupInfo.AdminIDs
+		info.MaxMembers = conv.GroupInfo.MaxMembers
+	}
+	if info.AdminIDs == nil {
+		info.AdminIDs = []string{}
+	}
+	return info
+}
@@ -0,0 +1,38 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/shared"
+	"Qingyu_backend/pkg/response"
+	"Qingyu_backend/service/interfaces"
+)
+
+// ContinuityApi 故事连贯性检查API处理器
+type ContinuityApi struct {
+	continuityService interfaces.ContinuityService
+}
+
+// NewContinuityApi 创建ContinuityApi实例
+func NewContinuityApi(continuityService interfaces.ContinuityService) *ContinuityApi {
+	return &ContinuityApi{
+		continuityService: continuityService,
+	}
+}
+
+// CheckProject 检查项目连贯性
+// GET /api/v1/writer/projects/:id/continuity
+func (api *ContinuityApi) CheckProject(c *gin.Context) {
+	projectID, ok := shared.GetRequiredParam(c, "id", "项目ID")
+	if !ok {
+		return
+	}
+
+	report, err := api.continuityService.CheckProject(c.Request.Context(), projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, report)
+}
@@ -1,6 +1,8 @@
 package writer
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 
 	"Qingyu_backend/api/v1/shared"
@@ -22,7 +24,7 @@ func NewDashboardApi(dashboardService *writer.DashboardService) *DashboardApi {
 
 // GetDashboardStats 获取作者仪表板统计
 // @Summary 获取作者仪表板统计数据
-// @Description 获取作者工作台的汇总统计，包括总字数、项目数、今日字数、待审核数、连续写作天数
+// @Description 获取作者工作台的汇总统计，包括总字数、项目数、今日/本周/本月字数、待审核数、连续写作天数和目标进度
 // @Tags Writer-Dashboard
 // @Accept json
 // @Produce json
@@ -44,3 +46,147 @@ func (api *DashboardApi) GetDashboardStats(c *gin.Context) {
 
 	response.Success(c, stats)
 }
+
+// GetWritingOutput 获取每日写作产出
+// @Summary 获取每日写作产出
+// @Description 按作者时区返回最近若干天（含今天）的新增、删除与净字数，无记录的日期补零
+// @Tags Writer-Dashboard
+// @Produce json
+// @Param days query int false "天数（1-366，默认30）"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Router /api/v1/writer/dashboard/output [get]
+func (api *DashboardApi) GetWritingOutput(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		response.BadRequest(c, "参数错误", "days必须为整数")
+		return
+	}
+
+	output, err := api.dashboardService.GetDailyOutput(c.Request.Context(), userID, days)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, output)
+}
+
+// GetWritingGoal 获取写作目标
+// @Summary 获取作者每日字数目标与时区
+// @Tags Writer-Dashboard
+// @Produce json
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/writer/dashboard/goals [get]
+func (api *DashboardApi) GetWritingGoal(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	goal, err := api.dashboardService.GetWritingGoal(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, goal)
+}
+
+// UpdateWritingGoal 更新写作目标
+// @Summary 更新作者每日字数目标与时区
+// @Description 时区用于划分写作日和计算连续写作天数，只影响之后记录的流水
+// @Tags Writer-Dashboard
+// @Accept json
+// @Produce json
+// @Param request body writer.UpdateWritingGoalRequest true "写作目标"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Router /api/v1/writer/dashboard/goals [put]
+func (api *DashboardApi) UpdateWritingGoal(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req writer.UpdateWritingGoalRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	goal, err := api.dashboardService.UpdateWritingGoal(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, goal)
+}
+
+// UpdateProjectGoalsRequest 更新项目目标请求
+type UpdateProjectGoalsRequest struct {
+	WordCountGoal *int `json:"wordCountGoal"`
+	DailyWordGoal *int `json:"dailyWordGoal"`
+}
+
+// UpdateProjectGoals 更新项目字数目标
+// @Summary 更新项目总字数目标与每日字数目标
+// @Tags Writer-Dashboard
+// @Accept json
+// @Produce json
+// @Param projectId path string true "项目ID"
+// @Param request body UpdateProjectGoalsRequest true "项目目标"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Router /api/v1/writer/dashboard/projects/{projectId}/goals [put]
+func (api *DashboardApi) UpdateProjectGoals(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := shared.GetRequiredParam(c, "projectId", "项目ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateProjectGoalsRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	if err := api.dashboardService.UpdateProjectGoals(c.Request.Context(), userID, projectID, req.WordCountGoal, req.DailyWordGoal); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// BackfillWritingLedger 回填写作流水
+// @Summary 由历史修订回填写作流水
+// @Description 根据 file_revisions 中的字数记录重建作者名下项目的每日流水，已有流水的日期不会被覆盖
+// @Tags Writer-Dashboard
+// @Produce json
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/writer/dashboard/ledger/backfill [post]
+func (api *DashboardApi) BackfillWritingLedger(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := api.dashboardService.BackfillWritingLedger(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, result)
+}
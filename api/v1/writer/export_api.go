@@ -62,7 +62,7 @@ func (api *ExportApi) ExportDocument(c *gin.Context) {
 
 // ExportProject 导出项目
 // @Summary 导出项目
-// @Description 将整个项目导出为ZIP包
+// @Description 将整个项目导出为ZIP包或EPUB电子书（format=epub）
 // @Tags 导出管理
 // @Accept json
 // @Produce json
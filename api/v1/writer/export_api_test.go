@@ -78,6 +78,22 @@ func (m *mockExportAPIService) ImportProject(ctx context.Context, userID string,
 	return args.Get(0).(*interfaces.ImportResult), args.Error(1)
 }
 
+func (m *mockExportAPIService) PreviewManuscriptImport(ctx context.Context, userID string, data []byte, req *interfaces.ManuscriptImportRequest) (*interfaces.ManuscriptPreview, error) {
+	args := m.Called(ctx, userID, data, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ManuscriptPreview), args.Error(1)
+}
+
+func (m *mockExportAPIService) ImportManuscript(ctx context.Context, userID string, data []byte, req *interfaces.ManuscriptImportRequest) (*interfaces.ExportTask, error) {
+	args := m.Called(ctx, userID, data, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ExportTask), args.Error(1)
+}
+
 func (m *mockExportAPIService) CancelExportTask(ctx context.Context, taskID, userID string) error {
 	return m.Called(ctx, taskID, userID).Error(0)
 }
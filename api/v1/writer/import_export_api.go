@@ -2,8 +2,10 @@ package writer
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -127,6 +129,129 @@ func (api *ImportExportApi) ImportProject(c *gin.Context) {
 	response.Created(c, result)
 }
 
+// manuscriptMaxFileSize 稿件文件大小上限
+const manuscriptMaxFileSize = 50 * 1024 * 1024
+
+// PreviewManuscriptImport 预览稿件导入
+// @Summary 预览稿件导入
+// @Description 解析TXT/DOCX/EPUB稿件并按卷章标题拆分，返回目录结构和字数，不写入数据
+// @Tags 导入导出
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "稿件文件（.txt/.docx/.epub）"
+// @Param projectId formData string false "导入到已有项目"
+// @Param projectTitle formData string false "新建项目标题"
+// @Param volumePatterns formData []string false "卷标题正则"
+// @Param chapterPatterns formData []string false "章标题正则"
+// @Param maxHeadingLength formData int false "标题行最大字数"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Router /api/v1/writer/projects/import/manuscript/preview [post]
+func (api *ImportExportApi) PreviewManuscriptImport(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	req, data, ok := bindManuscriptImport(c)
+	if !ok {
+		return
+	}
+
+	preview, err := api.exportService.PreviewManuscriptImport(c.Request.Context(), userID, data, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// ImportManuscript 导入稿件
+// @Summary 导入稿件
+// @Description 将TXT/DOCX/EPUB稿件按卷章拆分导入项目，返回异步任务，通过导出任务接口查询进度或取消
+// @Tags 导入导出
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "稿件文件（.txt/.docx/.epub）"
+// @Param projectId formData string false "导入到已有项目"
+// @Param projectTitle formData string false "新建项目标题"
+// @Param volumePatterns formData []string false "卷标题正则"
+// @Param chapterPatterns formData []string false "章标题正则"
+// @Param maxHeadingLength formData int false "标题行最大字数"
+// @Success 202 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Router /api/v1/writer/projects/import/manuscript [post]
+func (api *ImportExportApi) ImportManuscript(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	req, data, ok := bindManuscriptImport(c)
+	if !ok {
+		return
+	}
+
+	task, err := api.exportService.ImportManuscript(c.Request.Context(), userID, data, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, task)
+}
+
+// bindManuscriptImport 读取上传的稿件文件和拆分选项
+func bindManuscriptImport(c *gin.Context) (*interfaces.ManuscriptImportRequest, []byte, bool) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "参数错误", "请上传稿件文件")
+		return nil, nil, false
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".txt", ".docx", ".epub":
+	default:
+		response.BadRequest(c, "参数错误", "只支持TXT、DOCX、EPUB格式文件")
+		return nil, nil, false
+	}
+
+	if header.Size > manuscriptMaxFileSize {
+		response.BadRequest(c, "参数错误", "文件大小不能超过50MB")
+		return nil, nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, manuscriptMaxFileSize+1))
+	if err != nil {
+		c.Error(err)
+		return nil, nil, false
+	}
+	if len(data) > manuscriptMaxFileSize {
+		response.BadRequest(c, "参数错误", "文件大小不能超过50MB")
+		return nil, nil, false
+	}
+
+	req := &interfaces.ManuscriptImportRequest{
+		FileName:        header.Filename,
+		ProjectID:       c.PostForm("projectId"),
+		ProjectTitle:    c.PostForm("projectTitle"),
+		VolumePatterns:  c.PostFormArray("volumePatterns"),
+		ChapterPatterns: c.PostFormArray("chapterPatterns"),
+	}
+	if value := c.PostForm("maxHeadingLength"); value != "" {
+		maxHeadingLength, err := strconv.Atoi(value)
+		if err != nil || maxHeadingLength <= 0 {
+			response.BadRequest(c, "参数错误", "maxHeadingLength必须是正整数")
+			return nil, nil, false
+		}
+		req.MaxHeadingLength = maxHeadingLength
+	}
+	return req, data, true
+}
+
 // sanitizeFilename 清理文件名
 func sanitizeFilename(name string) string {
 	// 替换不安全字符
@@ -93,6 +93,24 @@ func (m *MockImportExportService) ImportProject(ctx context.Context, userID stri
 	return args.Get(0).(*interfaces.ImportResult), args.Error(1)
 }
 
+// PreviewManuscriptImport 预览稿件导入
+func (m *MockImportExportService) PreviewManuscriptImport(ctx context.Context, userID string, data []byte, req *interfaces.ManuscriptImportRequest) (*interfaces.ManuscriptPreview, error) {
+	args := m.Called(ctx, userID, data, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ManuscriptPreview), args.Error(1)
+}
+
+// ImportManuscript 导入稿件（异步任务）
+func (m *MockImportExportService) ImportManuscript(ctx context.Context, userID string, data []byte, req *interfaces.ManuscriptImportRequest) (*interfaces.ExportTask, error) {
+	args := m.Called(ctx, userID, data, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ExportTask), args.Error(1)
+}
+
 // CancelExportTask 取消导出任务
 func (m *MockImportExportService) CancelExportTask(ctx context.Context, taskID, userID string) error {
 	args := m.Called(ctx, taskID, userID)
@@ -129,6 +147,8 @@ func setupImportExportTestRouter(service *MockImportExportService, userID string
 	importExportAPI := writer.NewImportExportApi(service)
 	r.GET("/api/v1/writer/projects/:id/export", importExportAPI.ExportProject)
 	r.POST("/api/v1/writer/projects/import", importExportAPI.ImportProject)
+	r.POST("/api/v1/writer/projects/import/manuscript/preview", importExportAPI.PreviewManuscriptImport)
+	r.POST("/api/v1/writer/projects/import/manuscript", importExportAPI.ImportManuscript)
 
 	return r
 }
@@ -311,6 +331,73 @@ func TestImportExportApi_ImportProject_InvalidFileType(t *testing.T) {
 	assert.Equal(t, float64(1001), response["code"]) // 1001 = InvalidParams
 }
 
+// TestImportExportApi_PreviewManuscriptImport_PassesOptions 测试稿件预览读取文件和拆分选项
+func TestImportExportApi_PreviewManuscriptImport_PassesOptions(t *testing.T) {
+	// Given
+	mockService := new(MockImportExportService)
+	userID := primitive.NewObjectID().Hex()
+	router := setupImportExportTestRouter(mockService, userID)
+
+	body := &bytes.Buffer{}
+	mpWriter := multipart.NewWriter(body)
+	part, _ := mpWriter.CreateFormFile("file", "小说.txt")
+	part.Write([]byte("第一章 开端\n正文"))
+	_ = mpWriter.WriteField("projectTitle", "新书")
+	_ = mpWriter.WriteField("chapterPatterns", "^Part")
+	_ = mpWriter.WriteField("chapterPatterns", "^第.+章")
+	_ = mpWriter.WriteField("maxHeadingLength", "30")
+	mpWriter.Close()
+
+	preview := &interfaces.ManuscriptPreview{Title: "新书", Format: "txt", ChapterCount: 1}
+	mockService.On("PreviewManuscriptImport", mock.Anything, userID, []byte("第一章 开端\n正文"),
+		mock.MatchedBy(func(req *interfaces.ManuscriptImportRequest) bool {
+			return req.FileName == "小说.txt" && req.ProjectTitle == "新书" && req.MaxHeadingLength == 30 &&
+				len(req.ChapterPatterns) == 2 && req.ChapterPatterns[1] == "^第.+章"
+		})).Return(preview, nil)
+
+	// When
+	req, _ := http.NewRequest("POST", "/api/v1/writer/projects/import/manuscript/preview", body)
+	req.Header.Set("Content-Type", mpWriter.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "新书", data["title"])
+	assert.Equal(t, float64(1), data["chapterCount"])
+
+	mockService.AssertExpectations(t)
+}
+
+// TestImportExportApi_ImportManuscript_InvalidFileType 测试稿件导入拒绝不支持的格式
+func TestImportExportApi_ImportManuscript_InvalidFileType(t *testing.T) {
+	// Given
+	mockService := new(MockImportExportService)
+	userID := primitive.NewObjectID().Hex()
+	router := setupImportExportTestRouter(mockService, userID)
+
+	body := &bytes.Buffer{}
+	mpWriter := multipart.NewWriter(body)
+	part, _ := mpWriter.CreateFormFile("file", "小说.pdf")
+	part.Write([]byte("%PDF"))
+	mpWriter.Close()
+
+	// When
+	req, _ := http.NewRequest("POST", "/api/v1/writer/projects/import/manuscript", body)
+	req.Header.Set("Content-Type", mpWriter.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "ImportManuscript", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 // createTestZip 创建测试用的 ZIP 文件
 func createTestZip(t *testing.T) []byte {
 	buf := new(bytes.Buffer)
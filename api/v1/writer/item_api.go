@@ -0,0 +1,150 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/shared"
+	writerModels "Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/response"
+	"Qingyu_backend/service/interfaces"
+)
+
+// ItemApi 物品API处理器
+type ItemApi struct {
+	itemService interfaces.ItemService
+}
+
+// NewItemApi 创建ItemApi实例
+func NewItemApi(itemService interfaces.ItemService) *ItemApi {
+	return &ItemApi{
+		itemService: itemService,
+	}
+}
+
+// CreateItem 创建物品
+func (api *ItemApi) CreateItem(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	var req interfaces.CreateItemRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	userID := shared.GetUserIDOptional(c)
+
+	item, err := api.itemService.Create(c.Request.Context(), projectID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Created(c, item)
+}
+
+// GetItem 获取物品详情
+func (api *ItemApi) GetItem(c *gin.Context) {
+	itemID := c.Param("itemId")
+	projectID := c.Query("projectId")
+
+	if itemID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "itemId和projectId不能为空")
+		return
+	}
+
+	item, err := api.itemService.GetByID(c.Request.Context(), itemID, projectID)
+	if err != nil {
+		response.NotFound(c, "物品不存在")
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// ListItems 获取项目物品列表，可按持有角色过滤
+func (api *ItemApi) ListItems(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	var (
+		items []*writerModels.Item
+		err   error
+	)
+	if ownerID := c.Query("ownerId"); ownerID != "" {
+		items, err = api.itemService.ListByOwner(c.Request.Context(), projectID, ownerID)
+	} else {
+		items, err = api.itemService.List(c.Request.Context(), projectID)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, items)
+}
+
+// GetItemTree 获取物品层级树
+func (api *ItemApi) GetItemTree(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	tree, err := api.itemService.GetItemTree(c.Request.Context(), projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, tree)
+}
+
+// UpdateItem 更新物品
+func (api *ItemApi) UpdateItem(c *gin.Context) {
+	itemID := c.Param("itemId")
+	projectID := c.Query("projectId")
+
+	if itemID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "itemId和projectId不能为空")
+		return
+	}
+
+	var req interfaces.UpdateItemRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	item, err := api.itemService.Update(c.Request.Context(), itemID, projectID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// DeleteItem 删除物品
+func (api *ItemApi) DeleteItem(c *gin.Context) {
+	itemID := c.Param("itemId")
+	projectID := c.Query("projectId")
+
+	if itemID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "itemId和projectId不能为空")
+		return
+	}
+
+	err := api.itemService.Delete(c.Request.Context(), itemID, projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -0,0 +1,258 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/shared"
+	writerModels "Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/response"
+	"Qingyu_backend/service/interfaces"
+)
+
+// OrganizationApi 组织API处理器
+type OrganizationApi struct {
+	organizationService interfaces.OrganizationService
+}
+
+// NewOrganizationApi 创建OrganizationApi实例
+func NewOrganizationApi(organizationService interfaces.OrganizationService) *OrganizationApi {
+	return &OrganizationApi{
+		organizationService: organizationService,
+	}
+}
+
+// CreateOrganization 创建组织
+func (api *OrganizationApi) CreateOrganization(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	var req interfaces.CreateOrganizationRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	userID := shared.GetUserIDOptional(c)
+
+	org, err := api.organizationService.Create(c.Request.Context(), projectID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Created(c, org)
+}
+
+// GetOrganization 获取组织详情
+func (api *OrganizationApi) GetOrganization(c *gin.Context) {
+	orgID := c.Param("orgId")
+	projectID := c.Query("projectId")
+
+	if orgID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "orgId和projectId不能为空")
+		return
+	}
+
+	org, err := api.organizationService.GetByID(c.Request.Context(), orgID, projectID)
+	if err != nil {
+		response.NotFound(c, "组织不存在")
+		return
+	}
+
+	response.Success(c, org)
+}
+
+// ListOrganizations 获取项目组织列表，可按成员角色过滤
+func (api *OrganizationApi) ListOrganizations(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	var (
+		orgs []*writerModels.Organization
+		err  error
+	)
+	if memberID := c.Query("memberId"); memberID != "" {
+		orgs, err = api.organizationService.ListByMember(c.Request.Context(), projectID, memberID)
+	} else {
+		orgs, err = api.organizationService.List(c.Request.Context(), projectID)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, orgs)
+}
+
+// GetOrganizationTree 获取组织层级树
+func (api *OrganizationApi) GetOrganizationTree(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	tree, err := api.organizationService.GetOrganizationTree(c.Request.Context(), projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, tree)
+}
+
+// UpdateOrganization 更新组织
+func (api *OrganizationApi) UpdateOrganization(c *gin.Context) {
+	orgID := c.Param("orgId")
+	projectID := c.Query("projectId")
+
+	if orgID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "orgId和projectId不能为空")
+		return
+	}
+
+	var req interfaces.UpdateOrganizationRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	org, err := api.organizationService.Update(c.Request.Context(), orgID, projectID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, org)
+}
+
+// DeleteOrganization 删除组织
+func (api *OrganizationApi) DeleteOrganization(c *gin.Context) {
+	orgID := c.Param("orgId")
+	projectID := c.Query("projectId")
+
+	if orgID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "orgId和projectId不能为空")
+		return
+	}
+
+	err := api.organizationService.Delete(c.Request.Context(), orgID, projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// AddOrganizationMember 添加组织成员
+func (api *OrganizationApi) AddOrganizationMember(c *gin.Context) {
+	orgID := c.Param("orgId")
+	projectID := c.Query("projectId")
+
+	if orgID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "orgId和projectId不能为空")
+		return
+	}
+
+	var req interfaces.OrganizationMemberRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	org, err := api.organizationService.AddMember(c.Request.Context(), orgID, projectID, req.CharacterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, org)
+}
+
+// RemoveOrganizationMember 移除组织成员
+func (api *OrganizationApi) RemoveOrganizationMember(c *gin.Context) {
+	orgID := c.Param("orgId")
+	characterID := c.Param("characterId")
+	projectID := c.Query("projectId")
+
+	if orgID == "" || characterID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "orgId、characterId和projectId不能为空")
+		return
+	}
+
+	org, err := api.organizationService.RemoveMember(c.Request.Context(), orgID, projectID, characterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, org)
+}
+
+// CreateOrganizationRelation 创建组织关系
+func (api *OrganizationApi) CreateOrganizationRelation(c *gin.Context) {
+	projectID := c.Query("projectId")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	var req interfaces.CreateOrgRelationRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	relation, err := api.organizationService.CreateRelation(c.Request.Context(), projectID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Created(c, relation)
+}
+
+// ListOrganizationRelations 获取组织关系列表
+func (api *OrganizationApi) ListOrganizationRelations(c *gin.Context) {
+	projectID := c.Param("id")
+	if projectID == "" {
+		response.BadRequest(c, "项目ID不能为空", "")
+		return
+	}
+
+	orgID := c.Query("orgId")
+	var orgIDPtr *string
+	if orgID != "" {
+		orgIDPtr = &orgID
+	}
+
+	relations, err := api.organizationService.ListRelations(c.Request.Context(), projectID, orgIDPtr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, relations)
+}
+
+// DeleteOrganizationRelation 删除组织关系
+func (api *OrganizationApi) DeleteOrganizationRelation(c *gin.Context) {
+	relationID := c.Param("relationId")
+	projectID := c.Query("projectId")
+
+	if relationID == "" || projectID == "" {
+		response.BadRequest(c, "参数错误", "relationId和projectId不能为空")
+		return
+	}
+
+	err := api.organizationService.DeleteRelation(c.Request.Context(), relationID, projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -299,3 +299,96 @@ func (api *PublishApi) GetPublicationRecord(c *gin.Context) {
 
 	response.Success(c, record)
 }
+
+// GetScheduledPublications 获取定时发布列表
+// @Summary 获取定时发布列表
+// @Description 获取项目下尚未发布的定时发布记录，按发布时间升序
+// @Tags 发布管理
+// @Accept json
+// @Produce json
+// @Param id path string true "项目ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Router /api/v1/writer/projects/{id}/scheduled-publications [get]
+func (api *PublishApi) GetScheduledPublications(c *gin.Context) {
+	projectID, ok := shared.GetRequiredParam(c, "id", "项目ID")
+	if !ok {
+		return
+	}
+	if !isValidProjectID(projectID) {
+		response.BadRequest(c, "参数错误", "项目ID格式不正确")
+		return
+	}
+
+	userID := shared.GetUserIDOptional(c)
+
+	records, err := api.publishService.GetScheduledPublications(c.Request.Context(), projectID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, records)
+}
+
+// ReschedulePublication 修改定时发布时间
+// @Summary 修改定时发布时间
+// @Description 修改尚未执行的定时发布的发布时间
+// @Tags 发布管理
+// @Accept json
+// @Produce json
+// @Param id path string true "记录ID"
+// @Param request body interfaces.ReschedulePublicationRequest true "改期请求"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Router /api/v1/writer/publications/{id}/schedule [put]
+func (api *PublishApi) ReschedulePublication(c *gin.Context) {
+	recordID, ok := shared.GetRequiredParam(c, "id", "记录ID")
+	if !ok {
+		return
+	}
+
+	var req interfaces.ReschedulePublicationRequest
+	if !shared.BindJSON(c, &req) {
+		return
+	}
+
+	userID := shared.GetUserIDOptional(c)
+
+	record, err := api.publishService.ReschedulePublication(c.Request.Context(), recordID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, record)
+}
+
+// CancelScheduledPublication 取消定时发布
+// @Summary 取消定时发布
+// @Description 取消尚未执行的定时发布
+// @Tags 发布管理
+// @Accept json
+// @Produce json
+// @Param id path string true "记录ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Router /api/v1/writer/publications/{id}/schedule [delete]
+func (api *PublishApi) CancelScheduledPublication(c *gin.Context) {
+	recordID, ok := shared.GetRequiredParam(c, "id", "记录ID")
+	if !ok {
+		return
+	}
+
+	userID := shared.GetUserIDOptional(c)
+
+	if err := api.publishService.CancelScheduledPublication(c.Request.Context(), recordID, userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, nil)
+}
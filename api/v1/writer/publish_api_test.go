@@ -97,6 +97,27 @@ func (m *MockPublishService) ReviewPublication(ctx context.Context, recordID, re
 	return args.Get(0).(*interfaces.PublicationRecord), args.Error(1)
 }
 
+func (m *MockPublishService) GetScheduledPublications(ctx context.Context, projectID, userID string) ([]*interfaces.PublicationRecord, error) {
+	args := m.Called(ctx, projectID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*interfaces.PublicationRecord), args.Error(1)
+}
+
+func (m *MockPublishService) ReschedulePublication(ctx context.Context, recordID, userID string, req *interfaces.ReschedulePublicationRequest) (*interfaces.PublicationRecord, error) {
+	args := m.Called(ctx, recordID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.PublicationRecord), args.Error(1)
+}
+
+func (m *MockPublishService) CancelScheduledPublication(ctx context.Context, recordID, userID string) error {
+	args := m.Called(ctx, recordID, userID)
+	return args.Error(0)
+}
+
 // setupPublishTestRouter 设置测试路由
 func setupPublishTestRouter(publishService interfaces.PublishService, userID string) *gin.Engine {
 	gin.SetMode(gin.TestMode)
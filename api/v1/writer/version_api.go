@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"Qingyu_backend/api/v1/shared"
 	"Qingyu_backend/pkg/response"
 	"Qingyu_backend/service/writer/project"
 )
@@ -78,6 +79,7 @@ func (api *VersionApi) GetVersion(c *gin.Context) {
 // @Param documentId path string true "文档ID"
 // @Param fromVersion query string true "源版本ID"
 // @Param toVersion query string true "目标版本ID"
+// @Param context query int false "差异块上下文行数" default(3)
 // @Success 200 {object} response.APIResponse
 // @Router /api/v1/documents/{documentId}/versions/compare [get]
 func (api *VersionApi) CompareVersions(c *gin.Context) {
@@ -90,7 +92,13 @@ func (api *VersionApi) CompareVersions(c *gin.Context) {
 		return
 	}
 
-	diff, err := api.versionService.CompareVersions(c.Request.Context(), documentID, fromVersion, toVersion)
+	contextLines, err := strconv.Atoi(c.DefaultQuery("context", strconv.Itoa(project.DefaultDiffContextLines)))
+	if err != nil || contextLines < 0 {
+		response.BadRequest(c, "参数错误", "context必须为非负整数")
+		return
+	}
+
+	diff, err := api.versionService.CompareVersionsWithContext(c.Request.Context(), documentID, fromVersion, toVersion, contextLines)
 	if err != nil {
 		c.Error(err)
 		return
@@ -101,7 +109,7 @@ func (api *VersionApi) CompareVersions(c *gin.Context) {
 
 // RestoreVersion 恢复版本
 // @Summary 恢复版本
-// @Description 将文档恢复到特定版本
+// @Description 将文档恢复到特定版本，恢复结果记录为一条新的修订
 // @Tags 版本控制
 // @Accept json
 // @Produce json
@@ -113,10 +121,44 @@ func (api *VersionApi) RestoreVersion(c *gin.Context) {
 	documentID := c.Param("documentId")
 	versionID := c.Param("versionId")
 
-	if err := api.versionService.RestoreVersion(c.Request.Context(), documentID, versionID); err != nil {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	revision, err := api.versionService.RestoreVersionAsRevision(c.Request.Context(), documentID, versionID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, revision)
+}
+
+// UndoRestore 撤销恢复
+// @Summary 撤销恢复
+// @Description 撤销一次版本恢复，回到恢复前的内容（同样记录为新修订）
+// @Tags 版本控制
+// @Accept json
+// @Produce json
+// @Param documentId path string true "文档ID"
+// @Param versionId path string true "恢复操作产生的版本ID"
+// @Success 200 {object} response.APIResponse
+// @Router /api/v1/documents/{documentId}/versions/{versionId}/undo-restore [post]
+func (api *VersionApi) UndoRestore(c *gin.Context) {
+	documentID := c.Param("documentId")
+	versionID := c.Param("versionId")
+
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	revision, err := api.versionService.UndoRestore(c.Request.Context(), documentID, versionID, userID)
+	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	response.Success(c, nil)
+	response.Success(c, revision)
 }
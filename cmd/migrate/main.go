@@ -106,6 +106,9 @@ func main() {
 		{"004_create_chapters_indexes", &mongodbpkg.CreateChaptersIndexes{}},
 		{"005_create_reading_progress_indexes", &mongodbpkg.CreateReadingProgressIndexes{}},
 		{"006_create_core_query_indexes", &mongodbpkg.CreateCoreQueryIndexes{}},
+		{"008_create_author_earning_indexes", &mongodbpkg.CreateAuthorEarningIndexes{}},
+		{"009_create_settlement_indexes", &mongodbpkg.CreateSettlementIndexes{}},
+		{"010_create_tip_indexes", &mongodbpkg.CreateTipIndexes{}},
 	}
 
 	for _, m := range migrations {
@@ -0,0 +1,79 @@
+// replay_royalties 从事件存储（events_log）回放购买与打赏事件，补齐缺失的作者收入记录
+//
+// 已入账的订单/打赏按来源ID跳过，可重复执行：
+//
+//	go run ./cmd/tools/replay_royalties -db qingyu -since 2026-01-01
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	mongoBookstore "Qingyu_backend/repository/mongodb/bookstore"
+	mongoFinance "Qingyu_backend/repository/mongodb/finance"
+	"Qingyu_backend/service/events"
+	financeService "Qingyu_backend/service/finance"
+)
+
+func main() {
+	dbName := flag.String("db", "qingyu", "数据库名称")
+	sinceStr := flag.String("since", "", "只回放该日期（YYYY-MM-DD）之后的事件，为空表示全部")
+	timeout := flag.Duration("timeout", 30*time.Minute, "整体超时时间")
+	flag.Parse()
+
+	var since *time.Time
+	if *sinceStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *sinceStr, time.Local)
+		if err != nil {
+			log.Fatalf("❌ 无效的 -since 参数: %v", err)
+		}
+		since = &parsed
+	}
+
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		log.Fatalf("❌ 连接MongoDB失败: %v", err)
+	}
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			log.Printf("⚠️  断开MongoDB连接失败: %v", err)
+		}
+	}()
+	if err := client.Ping(ctx, nil); err != nil {
+		log.Fatalf("❌ MongoDB连接测试失败: %v", err)
+	}
+
+	db := client.Database(*dbName)
+	log.Printf("✅ 已连接到数据库: %s", *dbName)
+
+	royaltySvc := financeService.NewRoyaltyService(
+		mongoFinance.NewAuthorRevenueRepository(db),
+		mongoBookstore.NewMongoBookRepository(client, *dbName),
+	)
+	royaltySvc.SetEventReplayer(events.NewMongoEventStore(db, events.DefaultEventStoreConfig()))
+
+	result, err := royaltySvc.RebuildEarnings(ctx, since)
+	if err != nil {
+		log.Fatalf("❌ 重建作者收入失败: %v", err)
+	}
+
+	log.Printf("✅ 回放完成: 回放 %d 条事件，失败 %d 条，新建收入 %d 条，跳过 %d 条",
+		result.ReplayedCount, result.FailedCount, result.CreatedCount, result.SkippedCount)
+	if result.FailedCount > 0 {
+		os.Exit(1)
+	}
+}
@@ -94,6 +94,28 @@ type AIConfig struct {
 	Temperature   int                    `mapstructure:"temperature"`
 	PythonService *PythonAIServiceConfig `mapstructure:"python_service"`
 	AIService     *AIServiceConfig       `mapstructure:"ai_service"`
+	ResponseCache *AIResponseCacheConfig `mapstructure:"response_cache"`
+}
+
+// AIResponseCacheConfig AI响应缓存配置
+type AIResponseCacheConfig struct {
+	Enabled             bool           `mapstructure:"enabled"`
+	DefaultTTL          int            `mapstructure:"default_ttl"`          // 默认缓存时间（秒）
+	TaskTTLs            map[string]int `mapstructure:"task_ttls"`            // 按任务类型的缓存时间（秒），0 表示该任务不缓存
+	Semantic            bool           `mapstructure:"semantic"`             // 是否启用语义相似匹配
+	SimilarityThreshold float64        `mapstructure:"similarity_threshold"` // 语义命中的余弦相似度阈值
+	MaxEntriesPerUser   int            `mapstructure:"max_entries_per_user"` // 每个用户的语义索引上限
+}
+
+// GetTaskTTL 获取任务的缓存时间，返回 0 表示不缓存
+func (c *AIResponseCacheConfig) GetTaskTTL(task string) time.Duration {
+	if c == nil || !c.Enabled {
+		return 0
+	}
+	if ttl, ok := c.TaskTTLs[task]; ok {
+		return time.Duration(ttl) * time.Second
+	}
+	return time.Duration(c.DefaultTTL) * time.Second
 }
 
 // AIServiceConfig AI服务配置（gRPC）
@@ -120,6 +142,18 @@ type PythonAIServiceConfig struct {
 type ExternalAPIConfig struct {
 	DefaultProvider string                     `mapstructure:"default_provider"`
 	Providers       map[string]*ProviderConfig `mapstructure:"providers"`
+	Routing         *AIRoutingConfig           `mapstructure:"routing"`
+}
+
+// AIRoutingConfig 多提供商路由配置
+type AIRoutingConfig struct {
+	Strategy            string   `mapstructure:"strategy"`              // priority / weighted / cost / latency
+	FallbackChain       []string `mapstructure:"fallback_chain"`        // 故障转移顺序（priority 策略），未配置时按 Priority 排序
+	MaxAttempts         int      `mapstructure:"max_attempts"`          // 单次请求最多尝试的提供商数，0 表示不限
+	FailureRatio        float64  `mapstructure:"failure_ratio"`         // 熔断失败率阈值
+	MinRequests         uint32   `mapstructure:"min_requests"`          // 熔断统计的最小请求数
+	OpenTimeout         int      `mapstructure:"open_timeout"`          // 熔断打开后进入半开的等待时间（秒）
+	HealthCheckInterval int      `mapstructure:"health_check_interval"` // 健康检查间隔（秒），0 表示不启用
 }
 
 // ProviderConfig 提供商配置
@@ -131,6 +165,8 @@ type ProviderConfig struct {
 	Priority        int      `mapstructure:"priority"`
 	Enabled         bool     `mapstructure:"enabled"`
 	SupportedModels []string `mapstructure:"supported_models"`
+	Weight          int      `mapstructure:"weight"`             // 加权路由的流量权重
+	CostPer1KTokens float64  `mapstructure:"cost_per_1k_tokens"` // 每千 token 成本（cost 策略）
 }
 
 // AIQuotaConfig AI配额配置
@@ -140,6 +176,8 @@ type AIQuotaConfig struct {
 	WarningThreshold float64              `mapstructure:"warning_threshold"`
 	AllowOverdraft   bool                 `mapstructure:"allow_overdraft"`
 	OverdraftLimit   int                  `mapstructure:"overdraft_limit"`
+	ModelMultipliers map[string]float64   `mapstructure:"model_multipliers"` // 模型计价倍率（按模型名或前缀匹配）
+	ReservationTTL   int                  `mapstructure:"reservation_ttl"`   // 配额预留过期时间（秒）
 }
 
 // DefaultQuotasConfig 默认配额配置
@@ -262,6 +300,27 @@ func (c *AIQuotaConfig) GetDefaultQuota(userRole, membershipLevel string) int {
 	return 5
 }
 
+// GetModelMultiplier 获取模型计价倍率
+// 优先精确匹配，其次取最长的前缀匹配（如 "gpt-4" 匹配 "gpt-4-turbo"），未配置时为 1
+func (c *AIQuotaConfig) GetModelMultiplier(model string) float64 {
+	if c == nil || len(c.ModelMultipliers) == 0 || model == "" {
+		return 1
+	}
+	if multiplier, ok := c.ModelMultipliers[model]; ok && multiplier > 0 {
+		return multiplier
+	}
+
+	matched := ""
+	multiplier := 1.0
+	for prefix, value := range c.ModelMultipliers {
+		if value > 0 && len(prefix) > len(matched) && strings.HasPrefix(model, prefix) {
+			matched = prefix
+			multiplier = value
+		}
+	}
+	return multiplier
+}
+
 // DefaultRateLimitConfig returns a default rate limit configuration
 // Note: Actual defaults are set in setDefaults() for Viper
 func DefaultRateLimitConfig() *RateLimitConfig {
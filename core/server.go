@@ -1,7 +1,13 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"Qingyu_backend/api/v1/system"
 	"Qingyu_backend/config"
@@ -10,6 +16,7 @@ import (
 	"Qingyu_backend/pkg/logger"
 	"Qingyu_backend/pkg/metrics"
 	"Qingyu_backend/router"
+	"Qingyu_backend/service"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -17,6 +24,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// shutdownTimeout 优雅关闭的最长等待时间
+const shutdownTimeout = 30 * time.Second
+
 // InitServer 初始化服务器
 func InitServer() (*gin.Engine, error) {
 	cfg := config.GlobalConfig.Server
@@ -146,8 +156,41 @@ func RunServer(r *gin.Engine) error {
 	}
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
-	fmt.Printf("Server is running on port %s in %s mode\n", cfg.Port, cfg.Mode)
-	return r.Run(addr)
+	srv := &http.Server{Addr: addr, Handler: r}
+
+	// 收到退出信号时优雅关闭：先停止接收请求，再关闭服务容器（停止后台调度任务、断开数据库连接）
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server is running on port %s in %s mode\n", cfg.Port, cfg.Mode)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down server", zap.String("module", "server"))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Server shutdown failed", zap.Error(err))
+	}
+	if err := service.CloseServices(shutdownCtx); err != nil {
+		logger.Warn("Failed to close services", zap.Error(err))
+	}
+	return nil
 }
 
 func buildLoggerMiddlewareConfig(logCfg *config.LogConfig) map[string]interface{} {
@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateAuthorEarningIndexes 作者收入与版税合同索引
+// source_id + type 唯一索引保证同一笔购买/打赏只入账一次
+type CreateAuthorEarningIndexes struct{}
+
+func (m *CreateAuthorEarningIndexes) Up(ctx context.Context, db *mongo.Database) error {
+	earnings := db.Collection("author_earnings")
+	names, err := earnings.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "source_id", Value: 1},
+				{Key: "type", Value: 1},
+			},
+			Options: options.Index().
+				SetName("source_id_1_type_1_unique").
+				SetUnique(true).
+				SetBackground(true).
+				SetPartialFilterExpression(bson.M{"source_id": bson.M{"$exists": true, "$gt": ""}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "is_settled", Value: 1},
+				{Key: "earned_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("author_id_1_is_settled_1_earned_at_1").
+				SetBackground(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create author_earnings indexes: %w", err)
+	}
+	log.Printf("✅ AuthorEarnings索引创建成功: %v", names)
+
+	contracts := db.Collection("royalty_contracts")
+	names, err = contracts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "effective_from", Value: -1},
+			},
+			Options: options.Index().
+				SetName("author_id_1_effective_from_-1").
+				SetBackground(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create royalty_contracts indexes: %w", err)
+	}
+	log.Printf("✅ RoyaltyContracts索引创建成功: %v", names)
+
+	return nil
+}
+
+func (m *CreateAuthorEarningIndexes) Down(ctx context.Context, db *mongo.Database) error {
+	indexGroups := map[string][]string{
+		"author_earnings": {
+			"source_id_1_type_1_unique",
+			"author_id_1_is_settled_1_earned_at_1",
+		},
+		"royalty_contracts": {
+			"author_id_1_effective_from_-1",
+		},
+	}
+
+	for collectionName, indexNames := range indexGroups {
+		col := db.Collection(collectionName)
+		for _, indexName := range indexNames {
+			_, err := col.Indexes().DropOne(ctx, indexName)
+			if err != nil {
+				log.Printf("删除索引失败 %s.%s: %v", collectionName, indexName, err)
+			} else {
+				log.Printf("✅ 删除索引: %s.%s", collectionName, indexName)
+			}
+		}
+	}
+
+	return nil
+}
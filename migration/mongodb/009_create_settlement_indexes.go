@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateSettlementIndexes 作者结算索引
+// author_id + period 唯一索引保证每位作者每个周期只生成一张结算单
+type CreateSettlementIndexes struct{}
+
+func (m *CreateSettlementIndexes) Up(ctx context.Context, db *mongo.Database) error {
+	settlements := db.Collection("settlements")
+	names, err := settlements.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().
+				SetName("author_id_1_period_1_unique").
+				SetUnique(true).
+				SetBackground(true).
+				SetPartialFilterExpression(bson.M{"period": bson.M{"$exists": true, "$gt": ""}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create settlements indexes: %w", err)
+	}
+	log.Printf("✅ Settlements索引创建成功: %v", names)
+
+	withdrawals := db.Collection("withdrawal_requests")
+	names, err = withdrawals.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("user_id_1_status_1_created_at_1").
+				SetBackground(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create withdrawal_requests indexes: %w", err)
+	}
+	log.Printf("✅ WithdrawalRequests索引创建成功: %v", names)
+
+	return nil
+}
+
+func (m *CreateSettlementIndexes) Down(ctx context.Context, db *mongo.Database) error {
+	indexGroups := map[string][]string{
+		"settlements": {
+			"author_id_1_period_1_unique",
+		},
+		"withdrawal_requests": {
+			"user_id_1_status_1_created_at_1",
+		},
+	}
+
+	for collectionName, indexNames := range indexGroups {
+		col := db.Collection(collectionName)
+		for _, indexName := range indexNames {
+			_, err := col.Indexes().DropOne(ctx, indexName)
+			if err != nil {
+				log.Printf("删除索引失败 %s.%s: %v", collectionName, indexName, err)
+			} else {
+				log.Printf("✅ 删除索引: %s.%s", collectionName, indexName)
+			}
+		}
+	}
+
+	return nil
+}
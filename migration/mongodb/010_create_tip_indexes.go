@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateTipIndexes 读者打赏与粉丝榜索引
+// book_id + period + user_id 唯一索引保证并发打赏时每位读者在每个榜单周期只有一条粉丝记录
+type CreateTipIndexes struct{}
+
+func (m *CreateTipIndexes) Up(ctx context.Context, db *mongo.Database) error {
+	tips := db.Collection("tips")
+	names, err := tips.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "book_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("book_id_1_created_at_-1").
+				SetBackground(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("user_id_1_created_at_-1").
+				SetBackground(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create tips indexes: %w", err)
+	}
+	log.Printf("✅ Tips索引创建成功: %v", names)
+
+	fans := db.Collection("book_fans")
+	names, err = fans.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "book_id", Value: 1},
+				{Key: "period", Value: 1},
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("book_id_1_period_1_user_id_1_unique").
+				SetUnique(true).
+				SetBackground(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "book_id", Value: 1},
+				{Key: "period", Value: 1},
+				{Key: "fan_value", Value: -1},
+				{Key: "last_tipped_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("book_id_1_period_1_fan_value_-1_last_tipped_at_1").
+				SetBackground(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create book_fans indexes: %w", err)
+	}
+	log.Printf("✅ BookFans索引创建成功: %v", names)
+
+	return nil
+}
+
+func (m *CreateTipIndexes) Down(ctx context.Context, db *mongo.Database) error {
+	indexGroups := map[string][]string{
+		"tips": {
+			"book_id_1_created_at_-1",
+			"user_id_1_created_at_-1",
+		},
+		"book_fans": {
+			"book_id_1_period_1_user_id_1_unique",
+			"book_id_1_period_1_fan_value_-1_last_tipped_at_1",
+		},
+	}
+
+	for collectionName, indexNames := range indexGroups {
+		col := db.Collection(collectionName)
+		for _, indexName := range indexNames {
+			_, err := col.Indexes().DropOne(ctx, indexName)
+			if err != nil {
+				log.Printf("删除索引失败 %s.%s: %v", collectionName, indexName, err)
+			} else {
+				log.Printf("✅ 删除索引: %s.%s", collectionName, indexName)
+			}
+		}
+	}
+
+	return nil
+}
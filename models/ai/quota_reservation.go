@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReservationStatus 配额预留状态
+type ReservationStatus string
+
+const (
+	ReservationStatusReserved ReservationStatus = "reserved" // 已预留，等待结算
+	ReservationStatusSettled  ReservationStatus = "settled"  // 已按实际用量结算
+	ReservationStatusReleased ReservationStatus = "released" // 请求失败，全额退回
+	ReservationStatusExpired  ReservationStatus = "expired"  // 超时未结算，全额退回
+)
+
+// QuotaReservation 配额预留（预授权）
+// 请求开始前按估算用量预扣配额，结束后按实际 Usage 结算并退还差额
+type QuotaReservation struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID          string             `json:"userId" bson:"user_id"`
+	QuotaType       QuotaType          `json:"quotaType" bson:"quota_type"`
+	EstimatedTokens int                `json:"estimatedTokens" bson:"estimated_tokens"`                 // 估算 token 数
+	ReservedAmount  int                `json:"reservedAmount" bson:"reserved_amount"`                   // 预扣配额（已乘模型倍率）
+	ActualTokens    int                `json:"actualTokens,omitempty" bson:"actual_tokens,omitempty"`   // 实际 token 数
+	SettledAmount   int                `json:"settledAmount,omitempty" bson:"settled_amount,omitempty"` // 实际扣除配额
+	Multiplier      float64            `json:"multiplier" bson:"multiplier"`                            // 模型计价倍率
+	Service         string             `json:"service" bson:"service"`                                  // 服务类型
+	Model           string             `json:"model,omitempty" bson:"model,omitempty"`                  // 使用的模型
+	RequestID       string             `json:"requestId,omitempty" bson:"request_id,omitempty"`         // 请求ID
+	Status          ReservationStatus  `json:"status" bson:"status"`                                    // 预留状态
+	ExpiresAt       time.Time          `json:"expiresAt" bson:"expires_at"`                             // 过期时间
+	SettledAt       *time.Time         `json:"settledAt,omitempty" bson:"settled_at,omitempty"`         // 结算/退回时间
+	CreatedAt       time.Time          `json:"createdAt" bson:"created_at"`
+}
+
+// CollectionName 指定集合名
+func (QuotaReservation) CollectionName() string {
+	return "ai_quota_reservations"
+}
+
+// IsPending 是否仍待结算
+func (r *QuotaReservation) IsPending() bool {
+	return r.Status == ReservationStatusReserved
+}
+
+// 配额预留错误
+var (
+	ErrReservationNotFound = &QuotaError{Code: "RESERVATION_NOT_FOUND", Message: "配额预留记录不存在"}
+	ErrReservationClosed   = &QuotaError{Code: "RESERVATION_CLOSED", Message: "配额预留已结算或已失效"}
+)
@@ -59,6 +59,7 @@ type Book struct {
 	RatingCount   int64                `bson:"rating_count" json:"ratingCount" validate:"min=0"`       // 评分人数
 	ViewCount     int64                `bson:"view_count" json:"viewCount" validate:"min=0"`           // 浏览量
 	CollectCount  int64                `bson:"collect_count,omitempty" json:"collectCount,omitempty"`  // 收藏数
+	FanValue      int64                `bson:"fan_value,omitempty" json:"fanValue,omitempty"`          // 粉丝值（读者打赏累计）
 	WordCount     int64                `bson:"word_count" json:"wordCount" validate:"min=0"`           // 字数
 	ChapterCount  int                  `bson:"chapter_count" json:"chapterCount"`                      // 章节数
 	Price         float64              `bson:"price" json:"price" validate:"min=0"`                    // 价格 (分，使用float64以兼容MongoDB默认数字类型)
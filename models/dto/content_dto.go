@@ -211,6 +211,9 @@ type VersionInfo struct {
 	CreatedAt time.Time `json:"createdAt"`
 	CreatedBy string    `json:"createdBy"`
 	WordCount int       `json:"wordCount"`
+	// Operation 特殊修订类型：restore（恢复）、backup（恢复前自动备份）
+	Operation           string `json:"operation,omitempty"`
+	RestoredFromVersion int    `json:"restoredFromVersion,omitempty"`
 }
 
 // VersionDetail 版本详情
@@ -227,18 +230,41 @@ type VersionDetail struct {
 
 // VersionDiff 版本差异
 type VersionDiff struct {
-	FromVersion  string       `json:"fromVersion"`
-	ToVersion    string       `json:"toVersion"`
-	Changes      []ChangeItem `json:"changes"`
-	AddedLines   int          `json:"addedLines"`
-	DeletedLines int          `json:"deletedLines"`
+	FromVersion   string       `json:"fromVersion"`
+	ToVersion     string       `json:"toVersion"`
+	Changes       []ChangeItem `json:"changes"`
+	Hunks         []DiffHunk   `json:"hunks"`
+	Unified       string       `json:"unified,omitempty"` // 统一差异格式文本，可作为 unified 补丁提交
+	AddedLines    int          `json:"addedLines"`
+	DeletedLines  int          `json:"deletedLines"`
+	ModifiedLines int          `json:"modifiedLines"`
+}
+
+// DiffHunk 差异块（包含上下文行）
+type DiffHunk struct {
+	OldStart int          `json:"oldStart"`
+	OldLines int          `json:"oldLines"`
+	NewStart int          `json:"newStart"`
+	NewLines int          `json:"newLines"`
+	Header   string       `json:"header"`
+	Lines    []ChangeItem `json:"lines"`
 }
 
 // ChangeItem 变更项
 type ChangeItem struct {
-	Type    string `json:"type"` // added, deleted, modified
-	Line    int    `json:"line"`
-	Content string `json:"content"`
+	Type       string          `json:"type"` // added, deleted, modified, context
+	Line       int             `json:"line"`
+	OldLine    int             `json:"oldLine,omitempty"`
+	NewLine    int             `json:"newLine,omitempty"`
+	Content    string          `json:"content"`
+	OldContent string          `json:"oldContent,omitempty"` // 修改前内容（仅 modified）
+	Segments   []InlineSegment `json:"segments,omitempty"`   // 行内字符级差异（仅 modified）
+}
+
+// InlineSegment 行内差异片段
+type InlineSegment struct {
+	Type string `json:"type"` // equal, insert, delete
+	Text string `json:"text"`
 }
 
 // ============================================================================
@@ -450,6 +476,8 @@ type PublishProjectRequest struct {
 	AuthorNote    string     `json:"authorNote" validate:"max=500"`
 	EnableComment bool       `json:"enableComment"`
 	EnableShare   bool       `json:"enableShare"`
+	// CheckContinuity 发布前执行故事连贯性检查，存在确定矛盾时拒绝发布
+	CheckContinuity bool `json:"checkContinuity"`
 }
 
 // PublishDocumentRequest 发布文档（章节）请求
@@ -477,6 +505,29 @@ type BatchPublishDocumentsRequest struct {
 	StartNumber   int        `json:"startNumber" validate:"min=1"`
 	IsFree        bool       `json:"isFree"`
 	PublishTime   *time.Time `json:"publishTime,omitempty"`
+	// Drip 按固定间隔逐章定时发布（如每日一更），设置后忽略 PublishTime
+	Drip *DripSchedule `json:"drip,omitempty"`
+}
+
+// DripSchedule 批量定时发布计划
+// 第 i 章的发布时间为 StartAt + (i / ChaptersPerRelease) * IntervalHours
+type DripSchedule struct {
+	StartAt            time.Time `json:"startAt" validate:"required"`
+	IntervalHours      int       `json:"intervalHours" validate:"min=0"`      // 默认24小时
+	ChaptersPerRelease int       `json:"chaptersPerRelease" validate:"min=0"` // 默认每次1章
+}
+
+// ReschedulePublicationRequest 修改定时发布时间请求
+type ReschedulePublicationRequest struct {
+	PublishTime time.Time `json:"publishTime" validate:"required"`
+}
+
+// ScheduledDispatchResult 定时发布调度结果
+type ScheduledDispatchResult struct {
+	Due       int `json:"due"`
+	Claimed   int `json:"claimed"`
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
 }
 
 // BatchPublishResult 批量发布结果
@@ -577,6 +628,12 @@ const (
 	PublicationStatusUnpublished = "unpublished"
 	// PublicationStatusFailed 发布失败
 	PublicationStatusFailed = "failed"
+	// PublicationStatusScheduled 审核通过，等待定时发布
+	PublicationStatusScheduled = "scheduled"
+	// PublicationStatusPublishing 定时发布执行中
+	PublicationStatusPublishing = "publishing"
+	// PublicationStatusCancelled 定时发布已取消
+	PublicationStatusCancelled = "cancelled"
 )
 
 // ============================================================================
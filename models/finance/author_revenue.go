@@ -15,12 +15,17 @@ type AuthorEarning struct {
 	BookTitle      string             `bson:"book_title" json:"book_title"`                               // 书名
 	ChapterID      primitive.ObjectID `bson:"chapter_id,omitempty" json:"chapter_id,omitempty"`           // 章节ID（如果是章节购买）
 	ChapterTitle   string             `bson:"chapter_title,omitempty" json:"chapter_title,omitempty"`     // 章节标题
-	Type           string             `bson:"type" json:"type"`                                           // 收入类型：chapter_purchase, reward, vip_reading
+	Type           string             `bson:"type" json:"type"`                                           // 收入类型：book_purchase, chapter_purchase, reward, vip_reading
+	SourceID       string             `bson:"source_id,omitempty" json:"source_id,omitempty"`             // 来源ID（订单ID或打赏ID，用于幂等）
 	Amount         types.Money        `bson:"amount_cents" json:"-"`                                      // 收入金额（分）
 	ReaderID       string             `bson:"reader_id,omitempty" json:"reader_id,omitempty"`             // 读者ID
 	ReaderNickname string             `bson:"reader_nickname,omitempty" json:"reader_nickname,omitempty"` // 读者昵称
 	PlatformFee    types.Money        `bson:"platform_fee_cents" json:"-"`                                // 平台抽成（分）
 	AuthorIncome   types.Money        `bson:"author_income_cents" json:"-"`                               // 作者收入（分）
+	AuthorRate     float64            `bson:"author_rate,omitempty" json:"author_rate,omitempty"`         // 适用的作者分成比例
+	ContractID     primitive.ObjectID `bson:"contract_id,omitempty" json:"contract_id,omitempty"`         // 适用的版税合同
+	Promotion      string             `bson:"promotion,omitempty" json:"promotion,omitempty"`             // 适用的推广活动
+	EarnedAt       time.Time          `bson:"earned_at,omitempty" json:"earned_at,omitempty"`             // 收入发生时间（购买/打赏时间）
 	WordCount      int                `bson:"word_count,omitempty" json:"word_count,omitempty"`           // 字数（VIP阅读）
 	SettlementID   primitive.ObjectID `bson:"settlement_id,omitempty" json:"settlement_id,omitempty"`     // 结算ID
 	IsSettled      bool               `bson:"is_settled" json:"is_settled"`                               // 是否已结算
@@ -43,6 +48,7 @@ type WithdrawalRequest struct {
 	ApprovedAt    *time.Time         `bson:"approved_at,omitempty" json:"approved_at,omitempty"`      // 审批时间
 	CompletedAt   *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`    // 完成时间
 	TransactionID string             `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"` // 交易流水号
+	SettlementID  primitive.ObjectID `bson:"settlement_id,omitempty" json:"settlement_id,omitempty"`   // 抵扣该提现的结算单（未经钱包扣款的预支提现）
 	Note          string             `bson:"note,omitempty" json:"note,omitempty"`                    // 备注
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
@@ -64,13 +70,20 @@ type Settlement struct {
 	AuthorNickname string             `bson:"author_nickname" json:"author_nickname"`                   // 作者昵称
 	PeriodStart    time.Time          `bson:"period_start" json:"period_start"`                         // 结算周期开始
 	PeriodEnd      time.Time          `bson:"period_end" json:"period_end"`                             // 结算周期结束
+	Period         string             `bson:"period,omitempty" json:"period,omitempty"`                 // 结算周期标识，如 2024-01
+	Lines          []SettlementLine   `bson:"lines,omitempty" json:"lines,omitempty"`                   // 按书籍与收入类型汇总的明细
 	TotalRevenue   types.Money        `bson:"total_revenue_cents" json:"-"`                            // 总收入（分）
 	PlatformFee    types.Money        `bson:"platform_fee_cents" json:"-"`                              // 平台费用（分）
 	ActualIncome   types.Money        `bson:"actual_income_cents" json:"-"`                            // 实际收入（分）
+	TaxRate        float64            `bson:"tax_rate" json:"tax_rate"`                                 // 适用税率
 	TaxFee         types.Money        `bson:"tax_fee_cents" json:"-"`                                  // 税费（分）
+	WithdrawnFee   types.Money        `bson:"withdrawn_cents" json:"-"`                                 // 抵扣的已提现金额（分）
 	FinalIncome    types.Money        `bson:"final_income_cents" json:"-"`                              // 最终收入（分）
 	EarningCount   int                `bson:"earning_count" json:"earning_count"`                      // 收入记录数
 	Status         string             `bson:"status" json:"status"`                                    // 状态：pending, processing, completed, failed
+	Locked         bool               `bson:"locked" json:"locked"`                                     // 金额已锁定，不再随收入变化
+	LockedAt       *time.Time         `bson:"locked_at,omitempty" json:"locked_at,omitempty"`           // 锁定时间
+	StatementFile  string             `bson:"statement_file,omitempty" json:"statement_file,omitempty"` // 结算对账单文件
 	ProcessedAt    *time.Time         `bson:"processed_at,omitempty" json:"processed_at,omitempty"`    // 处理时间
 	TransactionID  string             `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"` // 交易流水号
 	Note           string             `bson:"note,omitempty" json:"note,omitempty"`                    // 备注
@@ -78,6 +91,27 @@ type Settlement struct {
 	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// SettlementRunResult 一次周期结算任务的执行结果
+type SettlementRunResult struct {
+	Period      string   `json:"period"`       // 结算周期
+	AuthorCount int      `json:"author_count"` // 待结算作者数
+	Settled     int      `json:"settled"`      // 本次完成结算的作者数
+	Skipped     int      `json:"skipped"`      // 已结算或被其他实例处理的作者数
+	Failed      int      `json:"failed"`       // 结算失败的作者数
+	FailedIDs   []string `json:"failed_ids,omitempty"`
+}
+
+// SettlementLine 结算明细行（按书籍与收入类型汇总）
+type SettlementLine struct {
+	BookID       primitive.ObjectID `bson:"book_id" json:"book_id"`             // 书籍ID
+	BookTitle    string             `bson:"book_title" json:"book_title"`       // 书名
+	Type         string             `bson:"type" json:"type"`                   // 收入类型
+	EarningCount int                `bson:"earning_count" json:"earning_count"` // 收入记录数
+	Amount       types.Money        `bson:"amount_cents" json:"-"`              // 收入金额（分）
+	PlatformFee  types.Money        `bson:"platform_fee_cents" json:"-"`        // 平台抽成（分）
+	AuthorIncome types.Money        `bson:"author_income_cents" json:"-"`       // 作者收入（分）
+}
+
 // RevenueDetail 收入明细
 type RevenueDetail struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -128,6 +162,7 @@ type TaxInfo struct {
 
 // EarningType 收入类型枚举
 const (
+	EarningTypeBookPurchase    = "book_purchase"    // 整本购买
 	EarningTypeChapterPurchase = "chapter_purchase" // 章节购买
 	EarningTypeReward          = "reward"           // 打赏
 	EarningTypeVIPReading      = "vip_reading"      // VIP阅读
@@ -150,8 +185,12 @@ const (
 	SettlementStatusFailed     = "failed"     // 失败
 )
 
-// 收入分成规则常量
+// 收入分成规则常量（无版税合同时的默认分成，合同规则见 RoyaltyContract）
 const (
+	// 整本购买分成
+	BookPurchaseAuthorRate   = 0.70 // 作者70%
+	BookPurchasePlatformRate = 0.30 // 平台30%
+
 	// 章节购买分成
 	ChapterPurchaseAuthorRate   = 0.70 // 作者70%
 	ChapterPurchasePlatformRate = 0.30 // 平台30%
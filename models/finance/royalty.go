@@ -0,0 +1,163 @@
+package finance
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoyaltyContract 版税合同
+// BookID 为空时为作者级合同，适用于该作者的全部作品；书籍级合同优先于作者级合同
+type RoyaltyContract struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AuthorID      string             `bson:"author_id" json:"author_id"`                           // 作者ID
+	BookID        string             `bson:"book_id,omitempty" json:"book_id,omitempty"`           // 书籍ID（为空表示作者级合同）
+	Type          string             `bson:"type" json:"type"`                                     // 合同类型：exclusive, non_exclusive
+	Tier          string             `bson:"tier" json:"tier"`                                     // 签约等级：standard, senior, premium
+	Rates         map[string]float64 `bson:"rates,omitempty" json:"rates,omitempty"`               // 按收入类型覆盖作者分成比例
+	Promotions    []RoyaltyPromotion `bson:"promotions,omitempty" json:"promotions,omitempty"`     // 推广期加成
+	EffectiveFrom time.Time          `bson:"effective_from" json:"effective_from"`                 // 生效时间
+	EffectiveTo   *time.Time         `bson:"effective_to,omitempty" json:"effective_to,omitempty"` // 失效时间（为空表示长期有效）
+	Status        string             `bson:"status" json:"status"`                                 // 状态：active, terminated
+	Note          string             `bson:"note,omitempty" json:"note,omitempty"`                 // 备注
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// RoyaltyPromotion 推广期分成加成
+type RoyaltyPromotion struct {
+	Name         string    `bson:"name" json:"name"`                                       // 活动名称
+	StartAt      time.Time `bson:"start_at" json:"start_at"`                               // 开始时间
+	EndAt        time.Time `bson:"end_at" json:"end_at"`                                   // 结束时间
+	EarningTypes []string  `bson:"earning_types,omitempty" json:"earning_types,omitempty"` // 适用收入类型（为空表示全部）
+	RateBonus    float64   `bson:"rate_bonus" json:"rate_bonus"`                           // 作者分成加成（如 0.05 表示 +5%）
+}
+
+// RoyaltyRate 一笔收入适用的分成结果
+type RoyaltyRate struct {
+	AuthorRate float64            `json:"author_rate"`           // 作者分成比例
+	ContractID primitive.ObjectID `json:"contract_id,omitempty"` // 适用合同（无合同时为空）
+	Promotion  string             `json:"promotion,omitempty"`   // 适用的推广活动
+}
+
+// ContractType 合同类型枚举
+const (
+	ContractTypeExclusive    = "exclusive"     // 独家签约
+	ContractTypeNonExclusive = "non_exclusive" // 非独家签约
+)
+
+// ContractTier 签约等级枚举
+const (
+	ContractTierStandard = "standard" // 普通签约
+	ContractTierSenior   = "senior"   // 资深作者
+	ContractTierPremium  = "premium"  // 白金作者
+)
+
+// ContractStatus 合同状态枚举
+const (
+	ContractStatusActive     = "active"     // 生效中
+	ContractStatusTerminated = "terminated" // 已终止
+)
+
+// contractBaseRates 各合同类型的作者基础分成比例
+// 独家签约沿用平台默认分成，非独家签约购买与VIP阅读分成下调10%
+var contractBaseRates = map[string]map[string]float64{
+	ContractTypeExclusive: {
+		EarningTypeBookPurchase:    BookPurchaseAuthorRate,
+		EarningTypeChapterPurchase: ChapterPurchaseAuthorRate,
+		EarningTypeReward:          RewardAuthorRate,
+		EarningTypeVIPReading:      VIPReadingAuthorRate,
+	},
+	ContractTypeNonExclusive: {
+		EarningTypeBookPurchase:    BookPurchaseAuthorRate - 0.10,
+		EarningTypeChapterPurchase: ChapterPurchaseAuthorRate - 0.10,
+		EarningTypeReward:          RewardAuthorRate,
+		EarningTypeVIPReading:      VIPReadingAuthorRate - 0.10,
+	},
+}
+
+// contractTierBonus 签约等级加成（不作用于打赏）
+var contractTierBonus = map[string]float64{
+	ContractTierStandard: 0,
+	ContractTierSenior:   0.03,
+	ContractTierPremium:  0.05,
+}
+
+// DefaultAuthorRate 无合同时的作者分成比例
+func DefaultAuthorRate(earningType string) (float64, bool) {
+	rate, ok := contractBaseRates[ContractTypeExclusive][earningType]
+	return rate, ok
+}
+
+// IsEffectiveAt 判断合同在指定时间是否生效
+func (c *RoyaltyContract) IsEffectiveAt(at time.Time) bool {
+	if c.Status != ContractStatusActive {
+		return false
+	}
+	if at.Before(c.EffectiveFrom) {
+		return false
+	}
+	return c.EffectiveTo == nil || at.Before(*c.EffectiveTo)
+}
+
+// AuthorRate 计算合同在指定时间对某类收入的作者分成比例
+// 显式覆盖的比例优先，否则取合同类型基础比例加签约等级加成；推广期加成在最后叠加，结果限制在 [0, 1]
+func (c *RoyaltyContract) AuthorRate(earningType string, at time.Time) (RoyaltyRate, bool) {
+	rate, ok := c.Rates[earningType]
+	if !ok {
+		base, known := contractBaseRates[c.Type][earningType]
+		if !known {
+			return RoyaltyRate{}, false
+		}
+		rate = base
+		if earningType != EarningTypeReward {
+			rate += contractTierBonus[c.Tier]
+		}
+	}
+
+	result := RoyaltyRate{ContractID: c.ID}
+	for _, promo := range c.Promotions {
+		if promo.appliesTo(earningType, at) {
+			rate += promo.RateBonus
+			result.Promotion = promo.Name
+			break
+		}
+	}
+	result.AuthorRate = clampRate(rate)
+	return result, true
+}
+
+// appliesTo 判断推广活动在指定时间是否适用于该收入类型
+func (p RoyaltyPromotion) appliesTo(earningType string, at time.Time) bool {
+	if at.Before(p.StartAt) || !at.Before(p.EndAt) {
+		return false
+	}
+	if len(p.EarningTypes) == 0 {
+		return true
+	}
+	for _, t := range p.EarningTypes {
+		if t == earningType {
+			return true
+		}
+	}
+	return false
+}
+
+// clampRate 将分成比例限制在 [0, 1]
+func clampRate(rate float64) float64 {
+	if rate < 0 {
+		return 0
+	}
+	if rate > 1 {
+		return 1
+	}
+	return rate
+}
+
+// RoyaltyReplayResult 回放重建收入记录的结果
+type RoyaltyReplayResult struct {
+	ReplayedCount int64 `json:"replayed_count"` // 成功回放的事件数
+	FailedCount   int64 `json:"failed_count"`   // 处理失败的事件数
+	CreatedCount  int   `json:"created_count"`  // 新建的收入记录数
+	SkippedCount  int   `json:"skipped_count"`  // 已入账或无需入账而跳过的事件数
+}
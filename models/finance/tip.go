@@ -0,0 +1,104 @@
+package finance
+
+import (
+	"fmt"
+	"time"
+
+	"Qingyu_backend/models/shared/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tip 读者打赏记录
+type Tip struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"user_id"`                                   // 打赏读者ID
+	AuthorID      string             `bson:"author_id" json:"author_id"`                               // 作者ID
+	BookID        primitive.ObjectID `bson:"book_id" json:"book_id"`                                   // 书籍ID
+	BookTitle     string             `bson:"book_title" json:"book_title"`                             // 书名（冗余字段）
+	ChapterID     primitive.ObjectID `bson:"chapter_id,omitempty" json:"chapter_id,omitempty"`         // 附带的章节ID（可选）
+	GiftCode      string             `bson:"gift_code" json:"gift_code"`                               // 礼物档位
+	GiftName      string             `bson:"gift_name" json:"gift_name"`                               // 礼物名称
+	Quantity      int                `bson:"quantity" json:"quantity"`                                 // 礼物数量
+	Amount        types.Money        `bson:"amount_cents" json:"amount"`                               // 打赏总额（分）
+	FanValue      int64              `bson:"fan_value" json:"fan_value"`                               // 本次获得的粉丝值
+	Message       string             `bson:"message,omitempty" json:"message,omitempty"`               // 打赏留言
+	TransactionID string             `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"` // 钱包扣款交易ID
+	EarningID     primitive.ObjectID `bson:"earning_id,omitempty" json:"earning_id,omitempty"`         // 作者收入记录ID
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TipGift 预设打赏礼物档位
+type TipGift struct {
+	Code  string      `json:"code"`  // 档位标识
+	Name  string      `json:"name"`  // 礼物名称
+	Price types.Money `json:"price"` // 单价（分）
+}
+
+// BookFan 书籍粉丝榜条目，按 (book_id, period, user_id) 累计
+type BookFan struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BookID       primitive.ObjectID `bson:"book_id" json:"book_id"`                 // 书籍ID
+	UserID       string             `bson:"user_id" json:"user_id"`                 // 读者ID
+	Period       string             `bson:"period" json:"period"`                   // 统计周期：all 或 ISO 周（2006-W01）
+	Rank         int                `bson:"-" json:"rank"`                          // 排名（查询时填充）
+	FanValue     int64              `bson:"fan_value" json:"fan_value"`             // 粉丝值
+	TipCount     int64              `bson:"tip_count" json:"tip_count"`             // 打赏次数
+	TotalAmount  types.Money        `bson:"total_amount_cents" json:"total_amount"` // 打赏总额（分）
+	LastTippedAt time.Time          `bson:"last_tipped_at" json:"last_tipped_at"`   // 最近打赏时间
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// 礼物档位标识
+const (
+	TipGiftFlower = "flower" // 鲜花
+	TipGiftCoffee = "coffee" // 咖啡
+	TipGiftCake   = "cake"   // 蛋糕
+	TipGiftTrophy = "trophy" // 奖杯
+	TipGiftCrown  = "crown"  // 皇冠
+)
+
+// FanPeriod 粉丝榜周期
+const (
+	FanPeriodAll    = "all"    // 总榜
+	FanPeriodWeekly = "weekly" // 周榜
+)
+
+const (
+	// MaxTipQuantity 单次打赏的最大礼物数量
+	MaxTipQuantity = 99
+	// MaxTipMessageLength 打赏留言最大字数
+	MaxTipMessageLength = 200
+	// FanValuePerYuan 每打赏1元获得的粉丝值
+	FanValuePerYuan = 1
+)
+
+// TipGifts 预设打赏礼物档位（按单价升序）
+var TipGifts = []TipGift{
+	{Code: TipGiftFlower, Name: "鲜花", Price: 100},
+	{Code: TipGiftCoffee, Name: "咖啡", Price: 500},
+	{Code: TipGiftCake, Name: "蛋糕", Price: 1000},
+	{Code: TipGiftTrophy, Name: "奖杯", Price: 10000},
+	{Code: TipGiftCrown, Name: "皇冠", Price: 100000},
+}
+
+// FindTipGift 按档位标识查找礼物
+func FindTipGift(code string) (TipGift, bool) {
+	for _, gift := range TipGifts {
+		if gift.Code == code {
+			return gift, true
+		}
+	}
+	return TipGift{}, false
+}
+
+// TipFanValue 按打赏金额计算粉丝值（不足1元的部分不计）
+func TipFanValue(amount types.Money) int64 {
+	return int64(amount) / 100 * FanValuePerYuan
+}
+
+// FanWeekPeriod 返回粉丝周榜的周期标识（ISO 周，与书城周榜一致）
+func FanWeekPeriod(at time.Time) string {
+	year, week := at.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
@@ -65,6 +65,7 @@ const (
 	TransactionTypeTransferOut = "transfer_out" // 转出
 	TransactionTypeWithdraw    = "withdraw"     // 提现
 	TransactionTypeRefund      = "refund"       // 退款
+	TransactionTypeSettlement  = "settlement"   // 作者结算入账
 )
 
 // 交易状态
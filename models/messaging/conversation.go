@@ -53,6 +53,15 @@ type ConversationGroupInfo struct {
 	MaxMembers  int      `bson:"max_members" json:"maxMembers"`                      // 最大成员数
 }
 
+// GroupRole 群成员角色
+type GroupRole string
+
+const (
+	GroupRoleOwner  GroupRole = "owner"  // 群主
+	GroupRoleAdmin  GroupRole = "admin"  // 管理员
+	GroupRoleMember GroupRole = "member" // 普通成员
+)
+
 // ConversationParticipantSnapshot 对话参与者快照
 type ConversationParticipantSnapshot struct {
 	ID       string `bson:"id" json:"id"`
@@ -73,8 +82,35 @@ type DirectMessage struct {
 	Status         MessageStatus          `bson:"status" json:"status" validate:"required,oneof=normal recalled deleted"`   // 消息状态
 	Extra          map[string]interface{} `bson:"extra,omitempty" json:"extra,omitempty"`                                   // 额外数据
 	SenderSnapshot *MessageSenderSnapshot `bson:"sender_snapshot,omitempty" json:"senderSnapshot,omitempty"`                // 发送者快照
+
+	// 已读回执与编辑撤回
+	ReadBy      []MessageReadReceipt `bson:"read_by,omitempty" json:"readBy,omitempty"`           // 已读回执（不含发送者）
+	EditHistory []MessageEditRecord  `bson:"edit_history,omitempty" json:"editHistory,omitempty"` // 编辑历史（旧内容）
+	EditedAt    *time.Time           `bson:"edited_at,omitempty" json:"editedAt,omitempty"`       // 最后编辑时间
+	RecalledAt  *time.Time           `bson:"recalled_at,omitempty" json:"recalledAt,omitempty"`   // 撤回时间
+}
+
+// MessageReadReceipt 消息已读回执
+type MessageReadReceipt struct {
+	UserID string    `bson:"user_id" json:"userId"`
+	ReadAt time.Time `bson:"read_at" json:"readAt"`
+}
+
+// MessageEditRecord 消息编辑记录，保存被替换前的内容
+type MessageEditRecord struct {
+	Content  string    `bson:"content" json:"content"`
+	EditedAt time.Time `bson:"edited_at" json:"editedAt"`
 }
 
+const (
+	// DefaultRecallWindow 默认撤回时限
+	DefaultRecallWindow = 2 * time.Minute
+	// DefaultEditWindow 默认编辑时限
+	DefaultEditWindow = 15 * time.Minute
+	// DefaultGroupMaxMembers 默认群成员上限
+	DefaultGroupMaxMembers = 500
+)
+
 // MessageType 消息类型
 type MessageType string
 
@@ -285,6 +321,60 @@ func (c *Conversation) IsMutedByUser(userID string) bool {
 	return c.MutedBy[userID]
 }
 
+// RoleOf 获取用户在群聊中的角色，非成员返回空
+func (c *Conversation) RoleOf(userID string) GroupRole {
+	if !c.IsGroup() || !c.HasParticipant(userID) {
+		return ""
+	}
+	if c.GroupInfo != nil {
+		if c.GroupInfo.OwnerID == userID {
+			return GroupRoleOwner
+		}
+		for _, id := range c.GroupInfo.AdminIDs {
+			if id == userID {
+				return GroupRoleAdmin
+			}
+		}
+	}
+	return GroupRoleMember
+}
+
+// IsOwner 判断是否为群主
+func (c *Conversation) IsOwner(userID string) bool {
+	return c.RoleOf(userID) == GroupRoleOwner
+}
+
+// CanManage 判断是否有群管理权限（群主或管理员）
+func (c *Conversation) CanManage(userID string) bool {
+	role := c.RoleOf(userID)
+	return role == GroupRoleOwner || role == GroupRoleAdmin
+}
+
+// SetAdmin 设置或取消管理员（仅群聊，目标须为非群主成员）
+func (c *Conversation) SetAdmin(userID string, admin bool) bool {
+	role := c.RoleOf(userID)
+	if c.GroupInfo == nil || role == "" || role == GroupRoleOwner {
+		return false
+	}
+	c.GroupInfo.AdminIDs = removeString(c.GroupInfo.AdminIDs, userID)
+	if admin {
+		c.GroupInfo.AdminIDs = append(c.GroupInfo.AdminIDs, userID)
+	}
+	c.Touch()
+	return true
+}
+
+// TransferOwnership 转让群主，原群主成为普通成员
+func (c *Conversation) TransferOwnership(newOwnerID string) bool {
+	if c.GroupInfo == nil || !c.HasParticipant(newOwnerID) || c.GroupInfo.OwnerID == newOwnerID {
+		return false
+	}
+	c.GroupInfo.AdminIDs = removeString(c.GroupInfo.AdminIDs, newOwnerID)
+	c.GroupInfo.OwnerID = newOwnerID
+	c.Touch()
+	return true
+}
+
 // AddParticipant 添加参与者（仅群聊）
 func (c *Conversation) AddParticipant(userID string, snapshot *ConversationParticipantSnapshot) bool {
 	if !c.IsGroup() {
@@ -325,6 +415,9 @@ func (c *Conversation) RemoveParticipant(userID string) bool {
 			if c.ParticipantSnapshots != nil {
 				delete(c.ParticipantSnapshots, userID)
 			}
+			if c.GroupInfo != nil {
+				c.GroupInfo.AdminIDs = removeString(c.GroupInfo.AdminIDs, userID)
+			}
 			c.Touch()
 			return true
 		}
@@ -457,17 +550,58 @@ func (m *DirectMessage) IsRecalled() bool {
 	return m.Status == MessageStatusRecalled
 }
 
-// Recall 撤回消息
-func (m *DirectMessage) Recall() bool {
-	// 消息发送超过2分钟不能撤回
-	if time.Since(m.CreatedAt) > 2*time.Minute {
+// Recall 在时限内撤回消息，window 为 0 表示不限时（如群管理员撤回）
+// 撤回成功时将消息状态置为已撤回并记录撤回时间
+func (m *DirectMessage) Recall(now time.Time, window time.Duration) bool {
+	if m.Status != MessageStatusNormal {
+		return false
+	}
+	if window > 0 && now.Sub(m.CreatedAt) > window {
 		return false
 	}
 	m.Status = MessageStatusRecalled
+	m.RecalledAt = &now
+	m.Touch()
+	return true
+}
+
+// Edit 在时限内编辑文本消息，旧内容记入编辑历史
+func (m *DirectMessage) Edit(content string, window time.Duration) bool {
+	if m.Status != MessageStatusNormal || m.Type != MessageTypeText || content == m.Content {
+		return false
+	}
+	if window > 0 && time.Since(m.CreatedAt) > window {
+		return false
+	}
+	now := time.Now()
+	m.EditHistory = append(m.EditHistory, MessageEditRecord{Content: m.Content, EditedAt: now})
+	m.Content = content
+	m.EditedAt = &now
 	m.Touch()
 	return true
 }
 
+// IsReadBy 判断用户是否已读该消息
+func (m *DirectMessage) IsReadBy(userID string) bool {
+	if m.ReceiverID == userID && m.IsRead {
+		return true
+	}
+	for _, r := range m.ReadBy {
+		if r.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadCount 已读人数
+func (m *DirectMessage) ReadCount() int {
+	if len(m.ReadBy) == 0 && m.IsRead {
+		return 1
+	}
+	return len(m.ReadBy)
+}
+
 // CanDelete 判断是否可以删除
 func (m *DirectMessage) CanDelete(userID string) bool {
 	return m.SenderID == userID
@@ -483,6 +617,17 @@ func (m *DirectMessage) SoftDelete(userID string) bool {
 	return true
 }
 
+// removeString 从切片中移除指定元素
+func removeString(items []string, target string) []string {
+	out := items[:0]
+	for _, item := range items {
+		if item != target {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 // ValidationError 验证错误
 type ValidationError struct {
 	Message string
@@ -2,6 +2,7 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,6 +14,8 @@ import (
 // MessageRepository 消息Repository接口
 type MessageRepository interface {
 	Create(ctx context.Context, message *DirectMessage) error
+	FindByID(ctx context.Context, id string) (*DirectMessage, error)
+	Update(ctx context.Context, message *DirectMessage) error
 	FindByConversationID(ctx context.Context, conversationID, userID string, page, pageSize int, before, after *string) ([]*DirectMessage, int, error)
 	MarkConversationRead(ctx context.Context, conversationID, userID string, readAt time.Time) (int, error)
 	CountUnreadInConversation(ctx context.Context, conversationID, userID string) (int, error)
@@ -50,6 +53,33 @@ func (r *MongoMessageRepository) Create(ctx context.Context, msg *DirectMessage)
 	return err
 }
 
+// FindByID 根据ID查找消息
+func (r *MongoMessageRepository) FindByID(ctx context.Context, id string) (*DirectMessage, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	var msg DirectMessage
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&msg)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// Update 更新消息
+func (r *MongoMessageRepository) Update(ctx context.Context, msg *DirectMessage) error {
+	msg.UpdatedAt = time.Now()
+	update := bson.M{"$set": msg}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": msg.ID}, update)
+	return err
+}
+
 // FindByConversationID 根据会话ID查找消息
 func (r *MongoMessageRepository) FindByConversationID(
 	ctx context.Context,
@@ -110,33 +140,47 @@ func (r *MongoMessageRepository) FindByConversationID(
 	return messages, int(total), nil
 }
 
-// MarkConversationRead 标记会话中的所有未读消息为已读
+// MarkConversationRead 标记会话中其他人发送的消息为已读
+// 为每条消息追加当前用户的已读回执，单聊消息同时更新 is_read，返回新增回执的消息数
 func (r *MongoMessageRepository) MarkConversationRead(
 	ctx context.Context,
 	conversationID string,
 	userID string,
 	readAt time.Time,
 ) (int, error) {
-	// 查询条件：会话中未读的、发给该用户的消息
+	now := time.Now()
+
+	// 追加已读回执：会话中他人发送、当前用户尚未读过的消息
+	receiptFilter := bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       bson.M{"$ne": userID},
+		"read_by.user_id": bson.M{"$ne": userID},
+		"status":          bson.M{"$ne": MessageStatusDeleted},
+	}
+	receiptUpdate := bson.M{
+		"$push": bson.M{"read_by": MessageReadReceipt{UserID: userID, ReadAt: readAt}},
+		"$set":  bson.M{"updated_at": now},
+	}
+	result, err := r.collection.UpdateMany(ctx, receiptFilter, receiptUpdate)
+	if err != nil {
+		return 0, err
+	}
+
+	// 单聊兼容字段：发给该用户的未读消息
 	filter := bson.M{
 		"conversation_id": conversationID,
 		"receiver_id":     userID,
 		"is_read":         false,
 		"status":          bson.M{"$ne": MessageStatusDeleted},
 	}
-
-	// 更新操作
 	update := bson.M{
 		"$set": bson.M{
-			"is_read":   true,
-			"read_at":   readAt,
-			"updated_at": time.Now(),
+			"is_read":    true,
+			"read_at":    readAt,
+			"updated_at": now,
 		},
 	}
-
-	// 执行更新
-	result, err := r.collection.UpdateMany(ctx, filter, update)
-	if err != nil {
+	if _, err := r.collection.UpdateMany(ctx, filter, update); err != nil {
 		return 0, err
 	}
 
@@ -149,11 +193,13 @@ func (r *MongoMessageRepository) CountUnreadInConversation(
 	conversationID string,
 	userID string,
 ) (int, error) {
+	// 他人发送、无该用户回执，且不是已标记 is_read 的单聊消息
 	filter := bson.M{
 		"conversation_id": conversationID,
-		"receiver_id":     userID,
-		"is_read":         false,
+		"sender_id":       bson.M{"$ne": userID},
+		"read_by.user_id": bson.M{"$ne": userID},
 		"status":          bson.M{"$ne": MessageStatusDeleted},
+		"$nor":            bson.A{bson.M{"receiver_id": userID, "is_read": true}},
 	}
 
 	count, err := r.collection.CountDocuments(ctx, filter)
@@ -163,3 +209,6 @@ func (r *MongoMessageRepository) CountUnreadInConversation(
 
 	return int(count), nil
 }
+
+// ErrMessageNotFound 消息不存在错误
+var ErrMessageNotFound = errors.New("消息不存在")
@@ -20,6 +20,21 @@ func (m *MockMessageRepository) Create(ctx context.Context, message *modelsMessa
 	return args.Error(0)
 }
 
+// FindByID 模拟根据ID查找消息
+func (m *MockMessageRepository) FindByID(ctx context.Context, id string) (*modelsMessaging.DirectMessage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*modelsMessaging.DirectMessage), args.Error(1)
+}
+
+// Update 模拟更新消息
+func (m *MockMessageRepository) Update(ctx context.Context, message *modelsMessaging.DirectMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
 // FindByConversationID 模拟根据会话ID查找消息
 func (m *MockMessageRepository) FindByConversationID(ctx context.Context, conversationID, userID string, page, pageSize int, before, after *string) ([]*modelsMessaging.DirectMessage, int, error) {
 	args := m.Called(ctx, conversationID, userID, page, pageSize, before, after)
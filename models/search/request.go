@@ -9,6 +9,21 @@ type SearchRequest struct {
 	Page     int                   `json:"page"`                        // 页码，默认 1
 	PageSize int                   `json:"page_size"`                   // 每页数量，默认 20
 	Options  map[string]interface{} `json:"options"`                     // 额外选项
+	Hybrid   *HybridOptions         `json:"hybrid,omitempty"`            // 混合检索选项（为空时仅关键词检索）
+}
+
+// 混合检索融合方式
+const (
+	FusionRRF      = "rrf"      // 倒数排名融合
+	FusionWeighted = "weighted" // 分数归一化后加权
+)
+
+// HybridOptions 混合检索（关键词 + 语义）选项
+type HybridOptions struct {
+	Fusion        string  `json:"fusion"`         // 融合方式：rrf（默认）| weighted
+	KeywordWeight float64 `json:"keyword_weight"` // 关键词结果权重，默认 1
+	VectorWeight  float64 `json:"vector_weight"`  // 向量结果权重，默认 1
+	RRFK          int     `json:"rrf_k"`          // RRF 平滑常数，默认 60
 }
 
 // BookSearchFilter 书籍搜索过滤条件
@@ -25,6 +25,17 @@ type SearchItem struct {
 	Score     float64                `json:"score"`               // 相关性评分
 	Data      map[string]interface{} `json:"data"`                // 文档数据
 	Highlight map[string][]string    `json:"highlight,omitempty"` // 高亮片段
+	Explain   *HybridExplain         `json:"explain,omitempty"`   // 混合检索评分说明
+}
+
+// HybridExplain 混合检索中单个结果的排名来源，排名从 1 开始，0 表示未被该路召回
+type HybridExplain struct {
+	Fusion       string  `json:"fusion"`
+	KeywordRank  int     `json:"keyword_rank"`
+	KeywordScore float64 `json:"keyword_score"`
+	VectorRank   int     `json:"vector_rank"`
+	VectorScore  float64 `json:"vector_score"`
+	FusedScore   float64 `json:"fused_score"`
 }
 
 // HighlightConfig 高亮配置
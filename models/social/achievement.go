@@ -0,0 +1,58 @@
+package social
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AchievementDefinition 成就定义
+// 同一计数器可以对应多个门槛（分级徽章），进度达到 Threshold 即解锁
+type AchievementDefinition struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Category    string `json:"category"`  // social / reading / writing
+	CounterID   string `json:"counterId"` // 关联的进度计数器
+	Threshold   int64  `json:"threshold"` // 解锁门槛
+}
+
+// AchievementProgress 用户成就计数器进度
+type AchievementProgress struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	CounterID string             `bson:"counter_id" json:"counterId"`
+	Value     int64              `bson:"value" json:"value"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// UserAchievement 用户已解锁的成就
+type UserAchievement struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"userId"`
+	AchievementID string             `bson:"achievement_id" json:"achievementId"`
+	UnlockedAt    time.Time          `bson:"unlocked_at" json:"unlockedAt"`
+}
+
+// AchievementView 成就展示信息（定义 + 用户进度）
+type AchievementView struct {
+	AchievementDefinition
+	Progress   int64      `json:"progress"`
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlockedAt,omitempty"`
+}
+
+// AchievementOverview 用户成就总览
+type AchievementOverview struct {
+	Earned     []*AchievementView `json:"earned"`     // 已解锁
+	InProgress []*AchievementView `json:"inProgress"` // 已有进度但未解锁
+	Total      int                `json:"total"`      // 成就总数
+}
+
+// AchievementBackfillResult 成就回填结果
+type AchievementBackfillResult struct {
+	ReplayedCount int64 `json:"replayedCount"` // 成功回放的事件数
+	FailedCount   int64 `json:"failedCount"`   // 处理失败的事件数
+	AwardedCount  int   `json:"awardedCount"`  // 回填中新解锁的成就数
+}
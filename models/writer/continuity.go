@@ -0,0 +1,81 @@
+package writer
+
+import "time"
+
+// ContinuityIssueType 连贯性问题类型
+type ContinuityIssueType string
+
+const (
+	// ContinuityLocationConflict 同一角色在同一故事时间出现在不同地点
+	ContinuityLocationConflict ContinuityIssueType = "location_conflict"
+	// ContinuityChronologyOrder 事件的故事时间与章节顺序相悖
+	ContinuityChronologyOrder ContinuityIssueType = "chronology_order"
+	// ContinuityRelationAfterEnd 关系在失效章节之后仍被使用
+	ContinuityRelationAfterEnd ContinuityIssueType = "relation_after_end"
+	// ContinuityAppearanceBeforeIntro 角色在首次登场章节之前出现
+	ContinuityAppearanceBeforeIntro ContinuityIssueType = "appearance_before_intro"
+)
+
+// ContinuitySeverity 问题严重程度
+type ContinuitySeverity string
+
+const (
+	ContinuitySeverityError   ContinuitySeverity = "error"   // 确定的矛盾
+	ContinuitySeverityWarning ContinuitySeverity = "warning" // 可能是有意为之（如倒叙），需作者确认
+)
+
+// ContinuityIssue 连贯性问题
+type ContinuityIssue struct {
+	Type         ContinuityIssueType `json:"type"`
+	Severity     ContinuitySeverity  `json:"severity"`
+	Message      string              `json:"message"`
+	CharacterIDs []string            `json:"characterIds,omitempty"`
+	LocationIDs  []string            `json:"locationIds,omitempty"`
+	EventIDs     []string            `json:"eventIds,omitempty"`   // 时间线事件ID
+	ChapterIDs   []string            `json:"chapterIds,omitempty"` // 文档ID
+	RelationID   string              `json:"relationId,omitempty"`
+}
+
+// ContinuityReport 项目连贯性检查报告
+type ContinuityReport struct {
+	ProjectID    string            `json:"projectId"`
+	Issues       []ContinuityIssue `json:"issues"`
+	ErrorCount   int               `json:"errorCount"`
+	WarningCount int               `json:"warningCount"`
+	CheckedAt    time.Time         `json:"checkedAt"`
+}
+
+// AddIssue 添加问题并更新计数
+func (r *ContinuityReport) AddIssue(issue ContinuityIssue) {
+	r.Issues = append(r.Issues, issue)
+	switch issue.Severity {
+	case ContinuitySeverityError:
+		r.ErrorCount++
+	case ContinuitySeverityWarning:
+		r.WarningCount++
+	}
+}
+
+// HasErrors 是否存在确定的矛盾
+func (r *ContinuityReport) HasErrors() bool {
+	return r.ErrorCount > 0
+}
+
+// Compare 比较两个故事时间的先后
+// 仅当双方纪元一致且至少给出年份时可比较；ok 为 false 表示无法比较
+func (st *StoryTime) Compare(other *StoryTime) (result int, ok bool) {
+	if st == nil || other == nil || st.Era != other.Era || st.Year == 0 || other.Year == 0 {
+		return 0, false
+	}
+	a := [...]int{st.Year, st.Month, st.Day, st.Hour, st.Minute}
+	b := [...]int{other.Year, other.Month, other.Day, other.Hour, other.Minute}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
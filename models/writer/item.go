@@ -10,16 +10,32 @@ type Item struct {
 	Type        string `bson:"type" json:"type"` // 武器、消耗品、关键道具、货币
 	Description string `bson:"description" json:"description"`
 
+	// 层级（如套装与部件、容器与内容物）
+	ParentID string `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+
 	// 归属
 	OwnerID    string `bson:"owner_id,omitempty" json:"ownerId,omitempty"`       // 当前持有者(角色ID)
 	LocationID string `bson:"location_id,omitempty" json:"locationId,omitempty"` // 当前所在地(若无持有者)
 
 	// 属性
-	Rarity   string `bson:"rarity,omitempty" json:"rarity,omitempty"`     // 稀有度
-	Function string `bson:"function,omitempty" json:"function,omitempty"` // 功能/用途
-	Origin   string `bson:"origin,omitempty" json:"origin,omitempty"`     // 来源/出处
+	Alias    []string `bson:"alias,omitempty" json:"alias,omitempty"`       // 别称（用于正文识别）
+	Rarity   string   `bson:"rarity,omitempty" json:"rarity,omitempty"`     // 稀有度
+	Function string   `bson:"function,omitempty" json:"function,omitempty"` // 功能/用途
+	Origin   string   `bson:"origin,omitempty" json:"origin,omitempty"`     // 来源/出处
 
 	ImageURL  string    `bson:"image_url,omitempty" json:"imageUrl,omitempty"`
 	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
 }
+
+// BeforeCreate 创建前设置时间戳。
+func (i *Item) BeforeCreate() {
+	now := time.Now()
+	i.CreatedAt = now
+	i.UpdatedAt = now
+}
+
+// BeforeUpdate 更新前刷新时间戳。
+func (i *Item) BeforeUpdate() {
+	i.UpdatedAt = time.Now()
+}
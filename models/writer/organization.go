@@ -14,22 +14,67 @@ type Organization struct {
 
 	// 组织架构
 	ParentID string   `bson:"parent_id,omitempty" json:"parentId,omitempty"` // 上级组织
-	Members  []string `bson:"members,omitempty" json:"members,omitempty"`    // 核心成员ID列表(冗余，主要通过Character.OrgID查询)
+	Members  []string `bson:"members,omitempty" json:"members,omitempty"`    // 成员角色ID列表
 
 	// 设定细节
-	Motto     string `bson:"motto,omitempty" json:"motto,omitempty"`         // 信条/口号
-	Resources string `bson:"resources,omitempty" json:"resources,omitempty"` // 拥有的资源/特产
+	Alias     []string `bson:"alias,omitempty" json:"alias,omitempty"`         // 别称/简称（用于正文识别）
+	Motto     string   `bson:"motto,omitempty" json:"motto,omitempty"`         // 信条/口号
+	Resources string   `bson:"resources,omitempty" json:"resources,omitempty"` // 拥有的资源/特产
 
 	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
 }
 
+// BeforeCreate 创建前设置时间戳。
+func (o *Organization) BeforeCreate() {
+	now := time.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+}
+
+// BeforeUpdate 更新前刷新时间戳。
+func (o *Organization) BeforeUpdate() {
+	o.UpdatedAt = time.Now()
+}
+
+// HasMember 判断角色是否为组织成员
+func (o *Organization) HasMember(characterID string) bool {
+	for _, id := range o.Members {
+		if id == characterID {
+			return true
+		}
+	}
+	return false
+}
+
+// OrgRelationType 组织关系类型
+type OrgRelationType string
+
+const (
+	OrgRelationHostile     OrgRelationType = "敌对"
+	OrgRelationAlly        OrgRelationType = "盟友"
+	OrgRelationSubordinate OrgRelationType = "从属"
+	OrgRelationNeutral     OrgRelationType = "中立"
+)
+
+// IsValid 验证组织关系类型是否合法
+func (t OrgRelationType) IsValid() bool {
+	switch t {
+	case OrgRelationHostile, OrgRelationAlly, OrgRelationSubordinate, OrgRelationNeutral:
+		return true
+	default:
+		return false
+	}
+}
+
 // OrgRelation 组织外交关系
 // 不同于 RoleRelation，这是宏观层面的
 type OrgRelation struct {
-	ID        string `bson:"_id,omitempty" json:"id"`
-	FromOrgID string `bson:"from_org_id" json:"fromOrgId"`
-	ToOrgID   string `bson:"to_org_id" json:"toOrgId"`
-	Relation  string `bson:"relation" json:"relation"` // 敌对、盟友、从属、中立
-	Notes     string `bson:"notes" json:"notes"`
+	ID        string          `bson:"_id,omitempty" json:"id"`
+	ProjectID string          `bson:"project_id" json:"projectId"`
+	FromOrgID string          `bson:"from_org_id" json:"fromOrgId"`
+	ToOrgID   string          `bson:"to_org_id" json:"toOrgId"`
+	Relation  OrgRelationType `bson:"relation" json:"relation"` // 敌对、盟友、从属、中立
+	Notes     string          `bson:"notes" json:"notes"`
+	CreatedAt time.Time       `bson:"created_at" json:"createdAt"`
 }
@@ -106,6 +106,7 @@ type ConflictInfo struct {
 	ExpectedVersion      int            `json:"expectedVersion"`
 	ConflictingRevisions []FileRevision `json:"conflictingRevisions,omitempty"`
 	LastModified         time.Time      `json:"lastModified,omitempty"`
+	Merge                *MergeResult   `json:"merge,omitempty"` // 提供了本地内容时的三路合并结果
 }
 
 type BatchConflictResult struct {
@@ -129,10 +130,13 @@ func GetSnapshotStrategy(contentSize int) string {
 }
 
 type ConflictResolution struct {
-	Strategy      string `json:"strategy"`
-	ResolvedBy    string `json:"resolvedBy"`
-	Resolution    string `json:"resolution"`
-	MergedContent string `json:"mergedContent"`
+	Strategy      string                `json:"strategy"`
+	ResolvedBy    string                `json:"resolvedBy"`
+	Resolution    string                `json:"resolution"`
+	MergedContent string                `json:"mergedContent"`
+	BaseVersion   int                   `json:"baseVersion,omitempty"` // 本地修改所基于的版本（auto/choices 时必填）
+	Content       string                `json:"content,omitempty"`     // 本地修改后的内容（auto/choices 时必填）
+	Choices       []ConflictBlockChoice `json:"choices,omitempty"`     // 按冲突块逐个解决
 }
 
 type BatchConflictResolution struct {
@@ -147,3 +151,40 @@ type CommitFile struct {
 	Content         string `json:"content"`
 	ExpectedVersion int    `json:"expectedVersion"`
 }
+
+// MergeConflictBlock 三路合并中双方修改重叠的冲突块
+// 纯文本按段落（行）表示；tiptap_json 时每个元素为一个顶层节点的 JSON
+type MergeConflictBlock struct {
+	Index     int      `json:"index"`
+	BaseStart int      `json:"baseStart"` // 冲突块在基线中的起始段落（从1开始）
+	Base      []string `json:"base"`
+	Ours      []string `json:"ours"`
+	Theirs    []string `json:"theirs"`
+}
+
+// MergeResult 三路合并结果
+type MergeResult struct {
+	ContentType   string               `json:"contentType"`
+	BaseVersion   int                  `json:"baseVersion"`
+	TheirsVersion int                  `json:"theirsVersion"`
+	HasConflicts  bool                 `json:"hasConflicts"`
+	MergedContent string               `json:"mergedContent,omitempty"` // 仅在无冲突时给出
+	Conflicts     []MergeConflictBlock `json:"conflicts,omitempty"`
+	AutoMerged    int                  `json:"autoMerged"` // 自动合并的非重叠修改数
+}
+
+// 冲突块解决方式
+const (
+	ConflictChoiceOurs   = "ours"
+	ConflictChoiceTheirs = "theirs"
+	ConflictChoiceBase   = "base"
+	ConflictChoiceBoth   = "both" // 先 ours 后 theirs
+	ConflictChoiceCustom = "custom"
+)
+
+// ConflictBlockChoice 单个冲突块的解决选择
+type ConflictBlockChoice struct {
+	Index   int      `json:"index"`
+	Choice  string   `json:"choice"`
+	Content []string `json:"content,omitempty"` // choice 为 custom 时使用
+}
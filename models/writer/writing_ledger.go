@@ -0,0 +1,43 @@
+package writer
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// WritingLedgerDateLayout 流水日期格式（作者时区下的自然日）
+	WritingLedgerDateLayout = "2006-01-02"
+	// DefaultWritingTimezone 作者未设置时区时使用的默认时区
+	DefaultWritingTimezone = "Asia/Shanghai"
+)
+
+// 流水来源
+const (
+	WritingLedgerSourceLive     = "live"     // 保存内容时实时记录
+	WritingLedgerSourceBackfill = "backfill" // 由历史修订回填
+)
+
+// WritingLedgerEntry 作者每日写作流水
+// 按 作者+项目+日期 聚合，记录当天的新增、删除与净字数
+type WritingLedgerEntry struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AuthorID     string             `bson:"author_id" json:"authorId"`
+	ProjectID    string             `bson:"project_id" json:"projectId"`
+	Date         string             `bson:"date" json:"date"`                  // 作者时区下的日期 YYYY-MM-DD
+	WordsAdded   int                `bson:"words_added" json:"wordsAdded"`     // 新增字数
+	WordsDeleted int                `bson:"words_deleted" json:"wordsDeleted"` // 删除字数
+	NetWords     int                `bson:"net_words" json:"netWords"`         // 净字数（新增-删除）
+	SaveCount    int                `bson:"save_count" json:"saveCount"`       // 保存次数
+	Source       string             `bson:"source" json:"source"`              // live / backfill
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// WritingGoal 作者写作目标与时区设置
+type WritingGoal struct {
+	AuthorID      string    `bson:"author_id" json:"authorId"`
+	DailyWordGoal int       `bson:"daily_word_goal" json:"dailyWordGoal"` // 每日字数目标，0 表示未设置
+	Timezone      string    `bson:"timezone" json:"timezone"`             // IANA 时区名，如 Asia/Shanghai
+	UpdatedAt     time.Time `bson:"updated_at" json:"updatedAt"`
+}
@@ -30,6 +30,8 @@ type PrometheusMetrics struct {
 	AIRequestsTotal   *prometheus.CounterVec
 	AIRequestDuration *prometheus.HistogramVec
 	AITokensConsumed  *prometheus.CounterVec
+	AICacheRequests   *prometheus.CounterVec
+	AICacheTokens     *prometheus.CounterVec
 
 	// 业务指标 - 用户
 	UserActiveTotal   prometheus.Gauge
@@ -191,6 +193,20 @@ func InitPrometheusMetrics() *PrometheusMetrics {
 				},
 				[]string{"user_id", "model"},
 			),
+			AICacheRequests: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "ai_cache_requests_total",
+					Help: "AI响应缓存查询总数（result: exact/semantic/miss）",
+				},
+				[]string{"task", "result"},
+			),
+			AICacheTokens: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "ai_cache_saved_tokens_total",
+					Help: "AI响应缓存命中节省的Token总数",
+				},
+				[]string{"task"},
+			),
 
 			// ============ 用户指标 ============
 			UserActiveTotal: promauto.NewGauge(
@@ -12,11 +12,16 @@ type Runner interface {
 	Run(ctx context.Context, fn func(context.Context) error) error
 }
 
+// activeTransactionKey 标记 ctx 处于由 Runner 开启且尚未结束的事务中，值为该事务的 session
+type activeTransactionKey struct{}
+
 type mongoRunner struct {
 	client *mongo.Client
 }
 
 // NewMongoRunner 使用 MongoDB session 执行事务。
+// 已处于 Runner 开启的事务中的 ctx 会直接复用外层事务，使嵌套调用（如钱包扣款）与外层操作一起提交或回滚；
+// 仅携带 session 而未开启事务的 ctx 仍会开启新事务。
 func NewMongoRunner(client *mongo.Client) Runner {
 	return &mongoRunner{client: client}
 }
@@ -25,6 +30,9 @@ func (r *mongoRunner) Run(ctx context.Context, fn func(context.Context) error) e
 	if r.client == nil {
 		return fmt.Errorf("mongo client is nil")
 	}
+	if inActiveTransaction(ctx) {
+		return fn(ctx)
+	}
 
 	session, err := r.client.StartSession()
 	if err != nil {
@@ -33,7 +41,8 @@ func (r *mongoRunner) Run(ctx context.Context, fn func(context.Context) error) e
 	defer session.EndSession(ctx)
 
 	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		if err := fn(sessCtx); err != nil {
+		txCtx := mongo.NewSessionContext(context.WithValue(sessCtx, activeTransactionKey{}, sessCtx), sessCtx)
+		if err := fn(txCtx); err != nil {
 			return nil, err
 		}
 		return nil, nil
@@ -43,3 +52,12 @@ func (r *mongoRunner) Run(ctx context.Context, fn func(context.Context) error) e
 	}
 	return nil
 }
+
+// inActiveTransaction 判断 ctx 是否处于 Runner 开启的事务中，且当前 session 即为该事务的 session
+func inActiveTransaction(ctx context.Context) bool {
+	txSession, ok := ctx.Value(activeTransactionKey{}).(mongo.Session)
+	if !ok {
+		return false
+	}
+	return mongo.SessionFromContext(ctx) == txSession
+}
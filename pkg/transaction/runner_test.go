@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func TestMongoRunnerNilClient(t *testing.T) {
@@ -17,3 +19,44 @@ func TestMongoRunnerNilClient(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "mongo client is nil")
 }
+
+func TestMongoRunnerReusesOuterTransaction(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	runner := NewMongoRunner(client)
+	var outer, inner mongo.Session
+	err = runner.Run(context.Background(), func(ctx context.Context) error {
+		outer = mongo.SessionFromContext(ctx)
+		return runner.Run(ctx, func(ctx context.Context) error {
+			inner = mongo.SessionFromContext(ctx)
+			return nil
+		})
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, outer)
+	require.Same(t, outer, inner)
+}
+
+func TestMongoRunnerStartsTransactionForBareSession(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	session, err := client.StartSession()
+	require.NoError(t, err)
+	defer session.EndSession(context.Background())
+	sessCtx := mongo.NewSessionContext(context.Background(), session)
+
+	var txSession mongo.Session
+	err = NewMongoRunner(client).Run(sessCtx, func(ctx context.Context) error {
+		txSession = mongo.SessionFromContext(ctx)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, txSession)
+	require.NotEqual(t, session, txSession, "未开启事务的外层 session 不应被复用")
+}
@@ -0,0 +1,277 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrCollabRevConflict 追加操作时日志已被其他实例推进
+	ErrCollabRevConflict = errors.New("collab_rev_conflict")
+	// ErrCollabSessionExpired 协作会话在共享存储中已过期
+	ErrCollabSessionExpired = errors.New("collab_session_expired")
+)
+
+// CollabBroker 协作会话的共享状态：基线内容、有序操作日志与跨实例广播
+// 单实例部署使用内存实现，多实例部署通过 Redis 共享，保证所有实例对同一文档看到相同的操作顺序
+type CollabBroker interface {
+	// Join 实例加入文档会话并返回基线内容；首个加入的实例以 content 作为基线
+	Join(ctx context.Context, documentID, instanceID, content string) (string, error)
+	// Leave 实例离开文档会话，最后一个实例离开时清理会话状态并返回 true
+	Leave(ctx context.Context, documentID, instanceID string) (bool, error)
+	// Append 在日志长度等于 rev 时追加一条操作记录，否则返回 ErrCollabRevConflict
+	Append(ctx context.Context, documentID string, rev int, record []byte) error
+	// Since 返回序号 rev 之后（rev 为已应用的记录数）的全部操作记录
+	Since(ctx context.Context, documentID string, rev int) ([][]byte, error)
+	// Publish 向所有实例广播消息
+	Publish(ctx context.Context, documentID string, payload []byte) error
+	// Subscribe 订阅文档广播，返回取消订阅函数
+	Subscribe(ctx context.Context, documentID string, handler func(payload []byte)) (func(), error)
+}
+
+// MemoryCollabBroker 进程内协作状态（单实例部署与测试）
+type MemoryCollabBroker struct {
+	mu       sync.Mutex
+	sessions map[string]*memoryCollabSession
+	nextSub  int
+}
+
+type memoryCollabSession struct {
+	base      string
+	records   [][]byte
+	instances map[string]bool
+	subs      map[int]func([]byte)
+}
+
+// NewMemoryCollabBroker 创建进程内协作状态
+func NewMemoryCollabBroker() *MemoryCollabBroker {
+	return &MemoryCollabBroker{sessions: make(map[string]*memoryCollabSession)}
+}
+
+func (b *MemoryCollabBroker) session(documentID string) *memoryCollabSession {
+	s, ok := b.sessions[documentID]
+	if !ok {
+		s = &memoryCollabSession{instances: map[string]bool{}, subs: map[int]func([]byte){}}
+		b.sessions[documentID] = s
+	}
+	return s
+}
+
+// Join 实例加入文档会话
+func (b *MemoryCollabBroker) Join(ctx context.Context, documentID, instanceID, content string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.session(documentID)
+	if len(s.instances) == 0 && len(s.records) == 0 {
+		s.base = content
+	}
+	s.instances[instanceID] = true
+	return s.base, nil
+}
+
+// Leave 实例离开文档会话
+func (b *MemoryCollabBroker) Leave(ctx context.Context, documentID, instanceID string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.sessions[documentID]
+	if !ok {
+		return true, nil
+	}
+	delete(s.instances, instanceID)
+	if len(s.instances) > 0 {
+		return false, nil
+	}
+	s.base, s.records = "", nil
+	if len(s.subs) == 0 {
+		delete(b.sessions, documentID)
+	}
+	return true, nil
+}
+
+// Append 追加操作记录
+func (b *MemoryCollabBroker) Append(ctx context.Context, documentID string, rev int, record []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.sessions[documentID]
+	if !ok || len(s.instances) == 0 {
+		return ErrCollabSessionExpired
+	}
+	if len(s.records) != rev {
+		return ErrCollabRevConflict
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Since 返回 rev 之后的操作记录
+func (b *MemoryCollabBroker) Since(ctx context.Context, documentID string, rev int) ([][]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.sessions[documentID]
+	if !ok || rev >= len(s.records) {
+		return nil, nil
+	}
+	return append([][]byte(nil), s.records[rev:]...), nil
+}
+
+// Publish 广播消息
+func (b *MemoryCollabBroker) Publish(ctx context.Context, documentID string, payload []byte) error {
+	b.mu.Lock()
+	var handlers []func([]byte)
+	if s, ok := b.sessions[documentID]; ok {
+		for _, h := range s.subs {
+			handlers = append(handlers, h)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		go h(payload)
+	}
+	return nil
+}
+
+// Subscribe 订阅文档广播
+func (b *MemoryCollabBroker) Subscribe(ctx context.Context, documentID string, handler func([]byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.session(documentID)
+	b.nextSub++
+	id := b.nextSub
+	s.subs[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(s.subs, id)
+		if len(s.subs) == 0 && len(s.instances) == 0 && b.sessions[documentID] == s {
+			delete(b.sessions, documentID)
+		}
+	}, nil
+}
+
+// RedisCollabBroker 基于 Redis 的协作状态，支持多实例部署
+// 键使用 {documentID} 作为哈希标签，集群模式下同一文档的键落在同一槽位
+type RedisCollabBroker struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisCollabBroker 创建 Redis 协作状态
+func NewRedisCollabBroker(client redis.UniversalClient, prefix string) *RedisCollabBroker {
+	if prefix == "" {
+		prefix = "collab"
+	}
+	return &RedisCollabBroker{client: client, prefix: prefix, ttl: 24 * time.Hour}
+}
+
+func (b *RedisCollabBroker) keys(documentID string) []string {
+	return []string{
+		fmt.Sprintf("%s:{%s}:base", b.prefix, documentID),
+		fmt.Sprintf("%s:{%s}:ops", b.prefix, documentID),
+		fmt.Sprintf("%s:{%s}:members", b.prefix, documentID),
+	}
+}
+
+func (b *RedisCollabBroker) channel(documentID string) string {
+	return fmt.Sprintf("%s:{%s}:events", b.prefix, documentID)
+}
+
+// 加入会话：登记实例，首个实例写入基线，刷新过期时间
+var collabJoinScript = redis.NewScript(`
+redis.call('SADD', KEYS[3], ARGV[1])
+redis.call('SET', KEYS[1], ARGV[2], 'NX')
+for i = 1, 3 do redis.call('PEXPIRE', KEYS[i], ARGV[3]) end
+return redis.call('GET', KEYS[1])
+`)
+
+// 离开会话：最后一个实例离开时删除会话状态
+var collabLeaveScript = redis.NewScript(`
+redis.call('SREM', KEYS[3], ARGV[1])
+if redis.call('SCARD', KEYS[3]) == 0 then
+	redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+	return 1
+end
+return 0
+`)
+
+// 追加操作：仅当日志长度等于期望序号时写入
+var collabAppendScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return -1 end
+if redis.call('LLEN', KEYS[2]) ~= tonumber(ARGV[1]) then return 0 end
+redis.call('RPUSH', KEYS[2], ARGV[2])
+for i = 1, 3 do redis.call('PEXPIRE', KEYS[i], ARGV[3]) end
+return 1
+`)
+
+// Join 实例加入文档会话
+func (b *RedisCollabBroker) Join(ctx context.Context, documentID, instanceID, content string) (string, error) {
+	base, err := collabJoinScript.Run(ctx, b.client, b.keys(documentID), instanceID, content, b.ttl.Milliseconds()).Text()
+	if err != nil {
+		return "", fmt.Errorf("加入协作会话失败: %w", err)
+	}
+	return base, nil
+}
+
+// Leave 实例离开文档会话
+func (b *RedisCollabBroker) Leave(ctx context.Context, documentID, instanceID string) (bool, error) {
+	last, err := collabLeaveScript.Run(ctx, b.client, b.keys(documentID), instanceID).Int()
+	if err != nil {
+		return false, fmt.Errorf("离开协作会话失败: %w", err)
+	}
+	return last == 1, nil
+}
+
+// Append 追加操作记录
+func (b *RedisCollabBroker) Append(ctx context.Context, documentID string, rev int, record []byte) error {
+	res, err := collabAppendScript.Run(ctx, b.client, b.keys(documentID), rev, record, b.ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("追加协作操作失败: %w", err)
+	}
+	switch res {
+	case -1:
+		return ErrCollabSessionExpired
+	case 0:
+		return ErrCollabRevConflict
+	}
+	return nil
+}
+
+// Since 返回 rev 之后的操作记录
+func (b *RedisCollabBroker) Since(ctx context.Context, documentID string, rev int) ([][]byte, error) {
+	values, err := b.client.LRange(ctx, b.keys(documentID)[1], int64(rev), -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取协作操作失败: %w", err)
+	}
+	records := make([][]byte, 0, len(values))
+	for _, v := range values {
+		records = append(records, []byte(v))
+	}
+	return records, nil
+}
+
+// Publish 广播消息
+func (b *RedisCollabBroker) Publish(ctx context.Context, documentID string, payload []byte) error {
+	return b.client.Publish(ctx, b.channel(documentID), payload).Err()
+}
+
+// Subscribe 订阅文档广播，订阅确认后才返回，避免丢失随后发布的消息
+func (b *RedisCollabBroker) Subscribe(ctx context.Context, documentID string, handler func([]byte)) (func(), error) {
+	pubsub := b.client.Subscribe(ctx, b.channel(documentID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("订阅协作频道失败: %w", err)
+	}
+	ch := pubsub.Channel()
+	go func() {
+		for msg := range ch {
+			handler([]byte(msg.Payload))
+		}
+	}()
+	return func() { pubsub.Close() }, nil
+}
@@ -0,0 +1,350 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// collabTextBlockTypes 支持字符级编辑的 TipTap 文本块类型
+var collabTextBlockTypes = map[string]bool{
+	"paragraph": true,
+	"heading":   true,
+	"codeBlock": true,
+}
+
+// inlineChar 文本块中的一个字符及其样式比较键
+type inlineChar struct {
+	r     rune
+	marks string
+}
+
+// collabBlock 协作文档中的一个顶层块
+// raw 非空时为不透明块（列表、引用、图片等），只能整体替换
+type collabBlock struct {
+	nodeType string
+	attrs    json.RawMessage
+	text     []inlineChar
+	raw      json.RawMessage
+}
+
+// collabDocument 协作会话中的文档状态
+type collabDocument struct {
+	blocks []collabBlock
+	// envelope 保存文档除 content 外的顶层字段
+	envelope map[string]json.RawMessage
+}
+
+type collabNode struct {
+	Type    string          `json:"type"`
+	Attrs   json.RawMessage `json:"attrs,omitempty"`
+	Content json.RawMessage `json:"content,omitempty"`
+	Text    string          `json:"text,omitempty"`
+	Marks   json.RawMessage `json:"marks,omitempty"`
+}
+
+type collabInline struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Marks json.RawMessage `json:"marks,omitempty"`
+}
+
+type collabTextNode struct {
+	Type    string          `json:"type"`
+	Attrs   json.RawMessage `json:"attrs,omitempty"`
+	Content []collabInline  `json:"content,omitempty"`
+}
+
+// parseCollabDocument 解析 TipTap JSON 文档，空内容视为空文档
+func parseCollabDocument(content string) (*collabDocument, error) {
+	doc := &collabDocument{envelope: map[string]json.RawMessage{}}
+	if strings.TrimSpace(content) == "" {
+		return doc, nil
+	}
+	if err := json.Unmarshal([]byte(content), &doc.envelope); err != nil {
+		return nil, fmt.Errorf("解析TipTap文档失败: %w", err)
+	}
+	var nodes []json.RawMessage
+	if raw, ok := doc.envelope["content"]; ok {
+		if err := json.Unmarshal(raw, &nodes); err != nil {
+			return nil, fmt.Errorf("解析TipTap节点失败: %w", err)
+		}
+	}
+	for _, raw := range nodes {
+		block, err := parseCollabBlock(raw)
+		if err != nil {
+			return nil, err
+		}
+		doc.blocks = append(doc.blocks, block)
+	}
+	return doc, nil
+}
+
+// parseCollabBlock 将顶层节点转换为块；仅含文本和换行的段落/标题/代码块转为文本块
+func parseCollabBlock(raw json.RawMessage) (collabBlock, error) {
+	var node collabNode
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return collabBlock{}, fmt.Errorf("invalid node: %w", err)
+	}
+	if node.Type == "" {
+		return collabBlock{}, errors.New("node type is required")
+	}
+
+	opaque := func() (collabBlock, error) {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return collabBlock{}, err
+		}
+		canonical, err := json.Marshal(v)
+		if err != nil {
+			return collabBlock{}, err
+		}
+		return collabBlock{nodeType: node.Type, raw: canonical}, nil
+	}
+	if !collabTextBlockTypes[node.Type] {
+		return opaque()
+	}
+
+	var children []collabNode
+	if len(node.Content) > 0 {
+		if err := json.Unmarshal(node.Content, &children); err != nil {
+			return collabBlock{}, fmt.Errorf("invalid node content: %w", err)
+		}
+	}
+	block := collabBlock{nodeType: node.Type}
+	if len(node.Attrs) > 0 && string(node.Attrs) != "null" {
+		attrs, err := canonicalJSON(node.Attrs)
+		if err != nil {
+			return collabBlock{}, err
+		}
+		block.attrs = attrs
+	}
+	for _, child := range children {
+		switch {
+		case child.Type == "text":
+			var marks []json.RawMessage
+			if len(child.Marks) > 0 {
+				if err := json.Unmarshal(child.Marks, &marks); err != nil {
+					return collabBlock{}, fmt.Errorf("invalid marks: %w", err)
+				}
+			}
+			marks, err := canonicalMarks(marks)
+			if err != nil {
+				return collabBlock{}, err
+			}
+			key := marksKey(marks)
+			for _, r := range child.Text {
+				block.text = append(block.text, inlineChar{r: r, marks: key})
+			}
+		case child.Type == "hardBreak" && node.Type != "codeBlock":
+			block.text = append(block.text, inlineChar{r: '\n'})
+		default:
+			// 含图片、提及等行内节点的块无法按字符编辑
+			return opaque()
+		}
+	}
+	return block, nil
+}
+
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// node 将块还原为 TipTap 节点
+func (b collabBlock) node() json.RawMessage {
+	if b.raw != nil {
+		return b.raw
+	}
+	out := collabTextNode{Type: b.nodeType, Attrs: b.attrs}
+	var run []rune
+	runMarks := ""
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		inline := collabInline{Type: "text", Text: string(run)}
+		if runMarks != "" {
+			inline.Marks = json.RawMessage(runMarks)
+		}
+		out.Content = append(out.Content, inline)
+		run = run[:0]
+	}
+	for _, c := range b.text {
+		if c.r == '\n' && b.nodeType != "codeBlock" {
+			flush()
+			out.Content = append(out.Content, collabInline{Type: "hardBreak"})
+			continue
+		}
+		if c.marks != runMarks {
+			flush()
+			runMarks = c.marks
+		}
+		run = append(run, c.r)
+	}
+	flush()
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// JSON 序列化为 TipTap 文档
+func (d *collabDocument) JSON() string {
+	out := make(map[string]json.RawMessage, len(d.envelope)+2)
+	for k, v := range d.envelope {
+		out[k] = v
+	}
+	if _, ok := out["type"]; !ok {
+		out["type"] = json.RawMessage(`"doc"`)
+	}
+	nodes := make([]json.RawMessage, 0, len(d.blocks))
+	for _, b := range d.blocks {
+		nodes = append(nodes, b.node())
+	}
+	content, _ := json.Marshal(nodes)
+	out["content"] = content
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// apply 依次应用操作，返回新文档；任一操作失败时原文档不变
+func (d *collabDocument) apply(ops []CollabOp) (*collabDocument, error) {
+	blocks := append([]collabBlock(nil), d.blocks...)
+	for i, op := range ops {
+		var err error
+		if blocks, err = applyCollabOp(blocks, op); err != nil {
+			return nil, fmt.Errorf("%w: op %d: %v", ErrCollabInvalidOp, i, err)
+		}
+	}
+	return &collabDocument{blocks: blocks, envelope: d.envelope}, nil
+}
+
+func applyCollabOp(blocks []collabBlock, op CollabOp) ([]collabBlock, error) {
+	if op.Type == CollabOpInsertBlock {
+		if op.Index > len(blocks) {
+			return nil, fmt.Errorf("insert index %d out of range", op.Index)
+		}
+		block, err := parseCollabBlock(op.Node)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, collabBlock{})
+		copy(blocks[op.Index+1:], blocks[op.Index:])
+		blocks[op.Index] = block
+		return blocks, nil
+	}
+
+	if op.Index >= len(blocks) {
+		return nil, fmt.Errorf("block index %d out of range", op.Index)
+	}
+	switch op.Type {
+	case CollabOpDeleteBlock:
+		return append(blocks[:op.Index], blocks[op.Index+1:]...), nil
+	case CollabOpReplaceBlock:
+		block, err := parseCollabBlock(op.Node)
+		if err != nil {
+			return nil, err
+		}
+		blocks[op.Index] = block
+	case CollabOpSetBlock:
+		block, err := setCollabBlock(blocks[op.Index], op)
+		if err != nil {
+			return nil, err
+		}
+		blocks[op.Index] = block
+	case CollabOpText:
+		block := blocks[op.Index]
+		if block.raw != nil {
+			return nil, fmt.Errorf("block %d is not a text block", op.Index)
+		}
+		text, err := applyTextComponents(block.text, op.Text)
+		if err != nil {
+			return nil, err
+		}
+		block.text = text
+		blocks[op.Index] = block
+	default:
+		return nil, fmt.Errorf("unknown op type %q", op.Type)
+	}
+	return blocks, nil
+}
+
+// setCollabBlock 修改块的类型与属性
+func setCollabBlock(block collabBlock, op CollabOp) (collabBlock, error) {
+	var attrs json.RawMessage
+	if len(op.Attrs) > 0 {
+		canonical, err := canonicalJSON(op.Attrs)
+		if err != nil {
+			return collabBlock{}, err
+		}
+		attrs = canonical
+	}
+
+	if block.raw == nil {
+		if op.BlockType != "" {
+			if !collabTextBlockTypes[op.BlockType] {
+				return collabBlock{}, fmt.Errorf("cannot convert text block to %q", op.BlockType)
+			}
+			block.nodeType = op.BlockType
+		}
+		if attrs != nil {
+			block.attrs = attrs
+		}
+		return block, nil
+	}
+
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(block.raw, &node); err != nil {
+		return collabBlock{}, err
+	}
+	if op.BlockType != "" {
+		node["type"], _ = json.Marshal(op.BlockType)
+	}
+	if attrs != nil {
+		node["attrs"] = attrs
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return collabBlock{}, err
+	}
+	return parseCollabBlock(data)
+}
+
+// applyTextComponents 对文本块应用字符级编辑，省略的末尾视为保留
+func applyTextComponents(text []inlineChar, comps []TextComponent) ([]inlineChar, error) {
+	out := make([]inlineChar, 0, len(text))
+	pos := 0
+	for _, c := range comps {
+		switch {
+		case c.Retain > 0:
+			if pos+c.Retain > len(text) {
+				return nil, errors.New("retain past end of text")
+			}
+			if c.Format == nil {
+				out = append(out, text[pos:pos+c.Retain]...)
+			} else {
+				key := marksKey(*c.Format)
+				for _, ch := range text[pos : pos+c.Retain] {
+					out = append(out, inlineChar{r: ch.r, marks: key})
+				}
+			}
+			pos += c.Retain
+		case c.Delete > 0:
+			if pos+c.Delete > len(text) {
+				return nil, errors.New("delete past end of text")
+			}
+			pos += c.Delete
+		case c.Insert != "":
+			key := marksKey(c.Marks)
+			for _, r := range c.Insert {
+				out = append(out, inlineChar{r: r, marks: key})
+			}
+		default:
+			return nil, errors.New("empty text component")
+		}
+	}
+	return append(out, text[pos:]...), nil
+}
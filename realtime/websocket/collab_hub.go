@@ -0,0 +1,708 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"Qingyu_backend/service/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// 协作编辑消息类型
+const (
+	CollabMsgInit     = "init"     // 加入成功：当前文档、版本号与在线协作者
+	CollabMsgOp       = "op"       // 客户端提交操作 / 服务端广播他人的操作
+	CollabMsgAck      = "ack"      // 服务端确认客户端提交的操作
+	CollabMsgCursor   = "cursor"   // 光标与选区
+	CollabMsgPresence = "presence" // 协作者加入、离开
+	CollabMsgReset    = "reset"    // 会话状态重建，客户端需丢弃本地未确认操作并重新加载
+	CollabMsgError    = "error"
+)
+
+const (
+	collabOpTimeout         = 5 * time.Second
+	collabMaxAppendAttempts = 8
+	collabMaxMessageSize    = 1 << 20
+	// collabMaxHistory 内存中保留的最近操作数，基于更早版本的提交无法变换，需重新加载
+	collabMaxHistory = 1000
+)
+
+// CollabDocumentStore 协作会话的文档存储
+type CollabDocumentStore interface {
+	// LoadDocument 校验用户对文档的编辑权限并返回当前内容（TipTap JSON，存储格式由实现负责转换）
+	LoadDocument(ctx context.Context, userID, documentID string) (string, error)
+	// StartAutoSave 启动定期持久化，snapshot 返回最新内容、最后编辑者以及自上次保存后是否有修改
+	StartAutoSave(documentID string, snapshot func() (content, editorID string, changed bool)) error
+	// StopAutoSave 停止定期持久化并立即保存未落盘的修改
+	StopAutoSave(documentID string) error
+}
+
+// CollabWSHub 文档实时协作 Hub
+// 服务端为每个文档维护权威的操作序列：客户端基于某个版本号提交操作，
+// 服务端将其变换到最新版本后追加到共享日志并广播，所有客户端按相同顺序应用从而收敛
+type CollabWSHub struct {
+	jwtService auth.JWTService
+	broker     CollabBroker
+	store      CollabDocumentStore
+	instanceID string
+	mu         sync.Mutex
+	rooms      map[string]*collabRoom
+}
+
+// CollabWSClient 协作编辑客户端连接
+type CollabWSClient struct {
+	ID     string
+	UserID string
+	Conn   *websocket.Conn
+	Send   chan []byte
+	room   *collabRoom
+	cursor json.RawMessage
+}
+
+// CollabWSMessage 服务端下发的协作消息
+type CollabWSMessage struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// collabClientMessage 客户端上行消息
+type collabClientMessage struct {
+	Type       string          `json:"type"`
+	Rev        int             `json:"rev"`
+	ClientOpID string          `json:"clientOpId,omitempty"`
+	Ops        []CollabOp      `json:"ops,omitempty"`
+	Cursor     json.RawMessage `json:"cursor,omitempty"`
+}
+
+// collabRecord 操作日志中的一条记录，rev 为其在日志中的序号加一
+type collabRecord struct {
+	ClientID string     `json:"clientId"`
+	UserID   string     `json:"userId"`
+	Ops      []CollabOp `json:"ops"`
+}
+
+// collabEnvelope 实例间广播的消息
+type collabEnvelope struct {
+	Instance string          `json:"instance"`
+	Kind     string          `json:"kind"`
+	Join     bool            `json:"join,omitempty"`
+	Message  json.RawMessage `json:"message,omitempty"`
+}
+
+type collabPeer struct {
+	ClientID string          `json:"clientId"`
+	UserID   string          `json:"userId"`
+	Action   string          `json:"action,omitempty"`
+	Cursor   json.RawMessage `json:"cursor,omitempty"`
+}
+
+// collabRoom 单个文档在本实例上的协作会话
+type collabRoom struct {
+	hub        *CollabWSHub
+	documentID string
+
+	// ready 在会话打开完成（或失败）后关闭，done 在会话关闭并从 Hub 移除后关闭
+	ready   chan struct{}
+	openErr error
+	done    chan struct{}
+
+	mu          sync.Mutex
+	doc         *collabDocument
+	rev         int
+	history     [][]CollabOp // 最近的操作，history[i] 对应版本 historyBase+i+1
+	historyBase int
+	clients     map[string]*CollabWSClient
+	unsubscribe func()
+	closed      bool
+	// dirty 仅由本实例客户端的修改置位，各实例只负责保存自己客户端的编辑
+	dirty      bool
+	lastEditor string
+}
+
+// NewCollabWSHub 创建协作编辑 Hub，broker 为空时使用进程内状态
+func NewCollabWSHub(jwtService auth.JWTService, broker CollabBroker) *CollabWSHub {
+	if broker == nil {
+		log.Printf("协作编辑未配置共享状态，使用进程内状态，仅支持单实例部署")
+		broker = NewMemoryCollabBroker()
+	}
+	return &CollabWSHub{
+		jwtService: jwtService,
+		broker:     broker,
+		instanceID: generateMessagingClientID(),
+		rooms:      make(map[string]*collabRoom),
+	}
+}
+
+// SetStore 设置文档存储（由写作模块初始化时注入）
+func (h *CollabWSHub) SetStore(store CollabDocumentStore) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.store = store
+}
+
+func (h *CollabWSHub) getStore() CollabDocumentStore {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.store
+}
+
+// HandleCollabWebSocket 协作编辑 WebSocket 处理器
+// @Summary 文档实时协作
+// @Description 多人实时编辑同一文档，通过 WebSocket 子协议或 Authorization Header 传递 token
+// @Tags Writer
+// @Param documentId path string true "文档ID"
+// @Param token header string true "JWT认证token (Sec-WebSocket-Protocol 或 Authorization: Bearer)"
+// @Router /ws/writer/documents/{documentId}/collab [get]
+func (h *CollabWSHub) HandleCollabWebSocket(c *gin.Context) {
+	documentID := c.Param("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少文档ID"})
+		return
+	}
+
+	token := extractWebSocketToken(c.Request)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少token参数，请通过Sec-WebSocket-Protocol或Authorization传递"})
+		return
+	}
+	userID, err := h.validateCollabToken(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
+		return
+	}
+
+	store := h.getStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "协作编辑服务未就绪"})
+		return
+	}
+	content, err := store.LoadDocument(c.Request.Context(), userID, documentID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := messagingUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("协作WebSocket升级失败: %v", err)
+		return
+	}
+
+	client := &CollabWSClient{
+		ID:     generateMessagingClientID(),
+		UserID: userID,
+		Conn:   conn,
+		Send:   make(chan []byte, 256),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collabOpTimeout)
+	defer cancel()
+	if err := h.join(ctx, documentID, content, client); err != nil {
+		log.Printf("加入协作会话失败: documentID=%s, error=%v", documentID, err)
+		conn.WriteJSON(CollabWSMessage{Type: CollabMsgError, Data: "加入协作会话失败", Timestamp: time.Now().Unix()})
+		conn.Close()
+		return
+	}
+
+	go client.writePump()
+	go client.readPump()
+}
+
+// validateCollabToken 验证JWT token
+func (h *CollabWSHub) validateCollabToken(ctx context.Context, token string) (string, error) {
+	if h.jwtService == nil {
+		return "", fmt.Errorf("JWT服务未初始化")
+	}
+	claims, err := h.jwtService.ValidateToken(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("token验证失败: %w", err)
+	}
+	return claims.UserID, nil
+}
+
+// join 将客户端加入文档会话，本实例上首个连接负责打开会话
+// 打开、关闭会话涉及共享状态与存储读写，均在 Hub 锁外进行，避免阻塞其他文档的连接
+func (h *CollabWSHub) join(ctx context.Context, documentID, content string, client *CollabWSClient) error {
+	for {
+		room, opener := h.acquireRoom(documentID)
+		if opener {
+			if err := h.openRoom(ctx, room, content); err != nil {
+				room.openErr = err
+				h.removeRoom(room)
+			}
+			close(room.ready)
+		}
+
+		select {
+		case <-room.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if room.openErr != nil {
+			return room.openErr
+		}
+
+		room.mu.Lock()
+		if room.closed {
+			// 会话正在关闭，等待其移除后重新打开
+			room.mu.Unlock()
+			select {
+			case <-room.done:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		client.room = room
+		room.clients[client.ID] = client
+
+		peers := make([]collabPeer, 0, len(room.clients))
+		for _, other := range room.clients {
+			if other.ID != client.ID {
+				peers = append(peers, collabPeer{ClientID: other.ID, UserID: other.UserID, Cursor: other.cursor})
+			}
+		}
+		room.sendLocked(client, collabMessage(CollabMsgInit, map[string]interface{}{
+			"documentId": documentID,
+			"clientId":   client.ID,
+			"userId":     client.UserID,
+			"rev":        room.rev,
+			"content":    json.RawMessage(room.doc.JSON()),
+			"peers":      peers,
+		}))
+		msg := collabMessage(CollabMsgPresence, collabPeer{ClientID: client.ID, UserID: client.UserID, Action: "join"})
+		room.broadcastLocked(msg, client.ID)
+		room.mu.Unlock()
+
+		room.publish(ctx, collabEnvelope{Kind: CollabMsgPresence, Join: true, Message: msg})
+		return nil
+	}
+}
+
+// acquireRoom 返回文档会话，不存在时创建并由调用方负责打开
+func (h *CollabWSHub) acquireRoom(documentID string) (*collabRoom, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room, ok := h.rooms[documentID]; ok {
+		return room, false
+	}
+	room := &collabRoom{
+		hub:        h,
+		documentID: documentID,
+		ready:      make(chan struct{}),
+		done:       make(chan struct{}),
+		clients:    make(map[string]*CollabWSClient),
+	}
+	h.rooms[documentID] = room
+	return room, true
+}
+
+// removeRoom 从 Hub 移除会话（已被新会话替换时不处理）
+func (h *CollabWSHub) removeRoom(room *collabRoom) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rooms[room.documentID] == room {
+		delete(h.rooms, room.documentID)
+	}
+}
+
+// openRoom 加入共享会话、订阅跨实例广播并重放已有操作
+func (h *CollabWSHub) openRoom(ctx context.Context, room *collabRoom, content string) error {
+	documentID := room.documentID
+	base, err := h.broker.Join(ctx, documentID, h.instanceID, content)
+	if err != nil {
+		return err
+	}
+	doc, err := parseCollabDocument(base)
+	if err != nil {
+		h.broker.Leave(ctx, documentID, h.instanceID)
+		return err
+	}
+	room.doc = doc
+
+	// 先订阅再重放，避免遗漏两者之间追加的操作
+	unsubscribe, err := h.broker.Subscribe(ctx, documentID, room.handleBroadcast)
+	if err != nil {
+		h.broker.Leave(ctx, documentID, h.instanceID)
+		return err
+	}
+	room.unsubscribe = unsubscribe
+
+	room.mu.Lock()
+	err = room.catchUpLocked(ctx)
+	room.mu.Unlock()
+	if err != nil {
+		unsubscribe()
+		h.broker.Leave(ctx, documentID, h.instanceID)
+		return err
+	}
+
+	if store := h.getStore(); store != nil {
+		if err := store.StartAutoSave(documentID, room.snapshot); err != nil {
+			log.Printf("启动协作自动保存失败: documentID=%s, error=%v", documentID, err)
+		}
+	}
+	return nil
+}
+
+// leave 移除客户端，本实例上最后一个连接离开时保存并关闭会话
+func (h *CollabWSHub) leave(client *CollabWSClient) {
+	room := client.room
+	room.mu.Lock()
+	if _, ok := room.clients[client.ID]; !ok {
+		room.mu.Unlock()
+		return
+	}
+	delete(room.clients, client.ID)
+	close(client.Send)
+	empty := len(room.clients) == 0
+	if empty {
+		// 标记关闭后新连接会等待本会话移除，再重新打开
+		room.closed = true
+	}
+	msg := collabMessage(CollabMsgPresence, collabPeer{ClientID: client.ID, UserID: client.UserID, Action: "leave"})
+	room.broadcastLocked(msg, client.ID)
+	room.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), collabOpTimeout)
+	defer cancel()
+	room.publish(ctx, collabEnvelope{Kind: CollabMsgPresence, Message: msg})
+	if !empty {
+		return
+	}
+
+	if store := h.getStore(); store != nil {
+		if err := store.StopAutoSave(room.documentID); err != nil {
+			log.Printf("保存协作文档失败: documentID=%s, error=%v", room.documentID, err)
+		}
+	}
+	room.unsubscribe()
+	if _, err := h.broker.Leave(ctx, room.documentID, h.instanceID); err != nil {
+		log.Printf("离开协作会话失败: documentID=%s, error=%v", room.documentID, err)
+	}
+	h.removeRoom(room)
+	close(room.done)
+}
+
+func collabMessage(msgType string, data interface{}) []byte {
+	payload, _ := json.Marshal(CollabWSMessage{Type: msgType, Data: data, Timestamp: time.Now().Unix()})
+	return payload
+}
+
+// sendLocked 向客户端发送消息，发送队列已满的慢客户端直接断开
+func (r *collabRoom) sendLocked(client *CollabWSClient, data []byte) {
+	select {
+	case client.Send <- data:
+	default:
+		log.Printf("协作客户端发送队列已满，断开连接: clientID=%s", client.ID)
+		client.Conn.Close()
+	}
+}
+
+func (r *collabRoom) broadcastLocked(data []byte, excludeClientID string) {
+	for id, client := range r.clients {
+		if id != excludeClientID {
+			r.sendLocked(client, data)
+		}
+	}
+}
+
+// publish 向其他实例广播
+func (r *collabRoom) publish(ctx context.Context, env collabEnvelope) {
+	env.Instance = r.hub.instanceID
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return
+	}
+	if err := r.hub.broker.Publish(ctx, r.documentID, payload); err != nil {
+		log.Printf("广播协作消息失败: documentID=%s, error=%v", r.documentID, err)
+	}
+}
+
+// catchUpLocked 应用共享日志中本实例尚未应用的操作并推送给本地客户端
+func (r *collabRoom) catchUpLocked(ctx context.Context) error {
+	records, err := r.hub.broker.Since(ctx, r.documentID, r.rev)
+	if err != nil {
+		return err
+	}
+	for _, data := range records {
+		var rec collabRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("解析协作操作失败: %w", err)
+		}
+		doc, err := r.doc.apply(rec.Ops)
+		if err != nil {
+			return fmt.Errorf("重放协作操作失败: %w", err)
+		}
+		r.doc = doc
+		r.rev++
+		r.appendHistoryLocked(rec.Ops)
+		r.broadcastLocked(collabMessage(CollabMsgOp, map[string]interface{}{
+			"rev":      r.rev,
+			"clientId": rec.ClientID,
+			"userId":   rec.UserID,
+			"ops":      rec.Ops,
+		}), "")
+	}
+	return nil
+}
+
+// appendHistoryLocked 记录已应用的操作，超出上限时丢弃最早的操作
+func (r *collabRoom) appendHistoryLocked(ops []CollabOp) {
+	r.history = append(r.history, ops)
+	if over := len(r.history) - collabMaxHistory; over > 0 {
+		r.history = r.history[over:]
+		r.historyBase += over
+	}
+}
+
+// resetLocked 共享状态丢失或无法重放时，以本实例当前文档重建会话并通知客户端重新加载
+func (r *collabRoom) resetLocked(ctx context.Context) {
+	base, err := r.hub.broker.Join(ctx, r.documentID, r.hub.instanceID, r.doc.JSON())
+	if err == nil {
+		var doc *collabDocument
+		if doc, err = parseCollabDocument(base); err == nil {
+			r.doc, r.rev, r.history, r.historyBase = doc, 0, nil, 0
+			err = r.catchUpLocked(ctx)
+		}
+	}
+	if err != nil {
+		log.Printf("重建协作会话失败: documentID=%s, error=%v", r.documentID, err)
+	}
+	r.broadcastLocked(collabMessage(CollabMsgReset, map[string]interface{}{
+		"rev":     r.rev,
+		"content": json.RawMessage(r.doc.JSON()),
+	}), "")
+}
+
+// submit 处理客户端提交的操作：变换到最新版本、追加到共享日志、确认并广播
+func (r *collabRoom) submit(ctx context.Context, client *CollabWSClient, msg *collabClientMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fail := func(reason string) {
+		r.sendLocked(client, collabMessage(CollabMsgError, map[string]interface{}{
+			"clientOpId": msg.ClientOpID,
+			"error":      reason,
+		}))
+	}
+
+	ops, err := normalizeCollabOps(msg.Ops)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	if err := r.catchUpLocked(ctx); err != nil {
+		log.Printf("同步协作操作失败: documentID=%s, error=%v", r.documentID, err)
+		r.resetLocked(ctx)
+		return
+	}
+	if msg.Rev < 0 || msg.Rev > r.rev {
+		fail(fmt.Sprintf("invalid rev %d, current rev is %d", msg.Rev, r.rev))
+		return
+	}
+
+	for attempt := 0; attempt < collabMaxAppendAttempts; attempt++ {
+		if msg.Rev < r.historyBase {
+			// 提交基于的版本已移出操作历史，无法变换，通知客户端重新加载
+			r.sendLocked(client, collabMessage(CollabMsgReset, map[string]interface{}{
+				"rev":     r.rev,
+				"content": json.RawMessage(r.doc.JSON()),
+			}))
+			return
+		}
+		transformed := ops
+		for _, applied := range r.history[msg.Rev-r.historyBase:] {
+			transformed, _ = transformCollabOps(transformed, applied, false)
+		}
+		doc, err := r.doc.apply(transformed)
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+		if transformed == nil {
+			transformed = []CollabOp{}
+		}
+		record, err := json.Marshal(collabRecord{ClientID: client.ID, UserID: client.UserID, Ops: transformed})
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+
+		err = r.hub.broker.Append(ctx, r.documentID, r.rev, record)
+		switch {
+		case errors.Is(err, ErrCollabRevConflict):
+			// 其他实例先追加了操作，同步后重新变换
+			if err := r.catchUpLocked(ctx); err != nil {
+				log.Printf("同步协作操作失败: documentID=%s, error=%v", r.documentID, err)
+				r.resetLocked(ctx)
+				return
+			}
+			continue
+		case errors.Is(err, ErrCollabSessionExpired):
+			r.resetLocked(ctx)
+			return
+		case err != nil:
+			fail("保存操作失败")
+			return
+		}
+
+		r.doc = doc
+		r.rev++
+		r.appendHistoryLocked(transformed)
+		r.dirty = true
+		r.lastEditor = client.UserID
+
+		r.sendLocked(client, collabMessage(CollabMsgAck, map[string]interface{}{
+			"rev":        r.rev,
+			"clientOpId": msg.ClientOpID,
+		}))
+		r.broadcastLocked(collabMessage(CollabMsgOp, map[string]interface{}{
+			"rev":      r.rev,
+			"clientId": client.ID,
+			"userId":   client.UserID,
+			"ops":      transformed,
+		}), client.ID)
+		r.publish(ctx, collabEnvelope{Kind: CollabMsgOp})
+		return
+	}
+	fail("并发修改过多，请稍后重试")
+}
+
+// updateCursor 记录并广播客户端光标
+func (r *collabRoom) updateCursor(ctx context.Context, client *CollabWSClient, cursor json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client.cursor = cursor
+	msg := collabMessage(CollabMsgCursor, collabPeer{ClientID: client.ID, UserID: client.UserID, Cursor: cursor})
+	r.broadcastLocked(msg, client.ID)
+	r.publish(ctx, collabEnvelope{Kind: CollabMsgCursor, Message: msg})
+}
+
+// handleBroadcast 处理其他实例的广播
+func (r *collabRoom) handleBroadcast(payload []byte) {
+	var env collabEnvelope
+	if err := json.Unmarshal(payload, &env); err != nil || env.Instance == r.hub.instanceID {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collabOpTimeout)
+	defer cancel()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	switch env.Kind {
+	case CollabMsgOp:
+		if err := r.catchUpLocked(ctx); err != nil {
+			log.Printf("同步协作操作失败: documentID=%s, error=%v", r.documentID, err)
+			r.resetLocked(ctx)
+		}
+	case CollabMsgPresence, CollabMsgCursor:
+		r.broadcastLocked(env.Message, "")
+		if env.Join {
+			// 新协作者加入其他实例时，回报本实例的在线协作者
+			for _, client := range r.clients {
+				msg := collabMessage(CollabMsgPresence, collabPeer{ClientID: client.ID, UserID: client.UserID, Action: "here", Cursor: client.cursor})
+				r.publish(ctx, collabEnvelope{Kind: CollabMsgPresence, Message: msg})
+			}
+		}
+	}
+}
+
+// snapshot 供自动保存读取最新内容，读取后清除修改标记
+func (r *collabRoom) snapshot() (string, string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), collabOpTimeout)
+	defer cancel()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return "", "", false
+	}
+	// 保存前同步其他实例的操作，避免用旧状态覆盖
+	if err := r.catchUpLocked(ctx); err != nil {
+		log.Printf("同步协作操作失败: documentID=%s, error=%v", r.documentID, err)
+	}
+	r.dirty = false
+	return r.doc.JSON(), r.lastEditor, true
+}
+
+// readPump 读取客户端提交的操作与光标
+func (c *CollabWSClient) readPump() {
+	hub := c.room.hub
+	defer func() {
+		hub.leave(c)
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadLimit(collabMaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		_, message, err := c.Conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("协作WebSocket读取错误: %v", err)
+			}
+			break
+		}
+
+		var msg collabClientMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), collabOpTimeout)
+		switch msg.Type {
+		case CollabMsgOp:
+			c.room.submit(ctx, c, &msg)
+		case CollabMsgCursor:
+			c.room.updateCursor(ctx, c, msg.Cursor)
+		}
+		cancel()
+	}
+}
+
+// writePump 向客户端写入消息，每条消息单独一帧
+func (c *CollabWSClient) writePump() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
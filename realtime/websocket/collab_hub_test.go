@@ -0,0 +1,367 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Qingyu_backend/service/shared/auth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCollabStore 记录保存内容的文档存储
+type fakeCollabStore struct {
+	mu        sync.Mutex
+	content   string
+	snapshots map[string]func() (string, string, bool)
+	saved     []string
+}
+
+func (s *fakeCollabStore) LoadDocument(ctx context.Context, userID, documentID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.content, nil
+}
+
+func (s *fakeCollabStore) StartAutoSave(documentID string, snapshot func() (string, string, bool)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshots == nil {
+		s.snapshots = map[string]func() (string, string, bool){}
+	}
+	s.snapshots[documentID] = snapshot
+	return nil
+}
+
+func (s *fakeCollabStore) StopAutoSave(documentID string) error {
+	s.mu.Lock()
+	snapshot := s.snapshots[documentID]
+	delete(s.snapshots, documentID)
+	s.mu.Unlock()
+	if snapshot == nil {
+		return nil
+	}
+	if content, _, changed := snapshot(); changed {
+		s.mu.Lock()
+		s.content = content
+		s.saved = append(s.saved, content)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *fakeCollabStore) savedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.saved)
+}
+
+func newCollabTestServer(t *testing.T, hub *CollabWSHub) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws/writer/documents/:documentId/collab", hub.HandleCollabWebSocket)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+type collabTestClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+	id   string
+	rev  int
+}
+
+func dialCollab(t *testing.T, server *httptest.Server, documentID, token string) *collabTestClient {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/writer/documents/" + documentID + "/collab"
+	header := http.Header{"Authorization": []string{"Bearer " + token}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+
+	c := &collabTestClient{t: t, conn: conn}
+	init := c.expect(CollabMsgInit)
+	c.id = init["clientId"].(string)
+	c.rev = int(init["rev"].(float64))
+	return c
+}
+
+// expect 读取消息直到出现指定类型，返回其数据
+func (c *collabTestClient) expect(msgType string) map[string]interface{} {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		_, data, err := c.conn.ReadMessage()
+		require.NoError(c.t, err, "waiting for %s", msgType)
+		var msg struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(c.t, json.Unmarshal(data, &msg))
+		if msg.Type == msgType {
+			return msg.Data
+		}
+	}
+}
+
+// expectAll 读取消息直到每种类型都出现过一次（顺序不限）
+func (c *collabTestClient) expectAll(msgTypes ...string) map[string]map[string]interface{} {
+	c.t.Helper()
+	want := make(map[string]bool, len(msgTypes))
+	for _, typ := range msgTypes {
+		want[typ] = true
+	}
+	got := make(map[string]map[string]interface{}, len(msgTypes))
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for len(got) < len(want) {
+		_, data, err := c.conn.ReadMessage()
+		require.NoError(c.t, err, "waiting for %v", msgTypes)
+		var msg struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(c.t, json.Unmarshal(data, &msg))
+		if want[msg.Type] && got[msg.Type] == nil {
+			got[msg.Type] = msg.Data
+		}
+	}
+	return got
+}
+
+func (c *collabTestClient) send(msg collabClientMessage) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.WriteJSON(msg))
+}
+
+func TestCollabWSHub_ConvergesAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newHub := func() *CollabWSHub {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		jwt := new(MockJWTService)
+		jwt.On("ValidateToken", mock.Anything, "token-a").Return(&auth.TokenClaims{UserID: "user-a"}, nil)
+		jwt.On("ValidateToken", mock.Anything, "token-b").Return(&auth.TokenClaims{UserID: "user-b"}, nil)
+		return NewCollabWSHub(jwt, NewRedisCollabBroker(client, "collab"))
+	}
+
+	initial := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"林动睁开眼"}]}]}`
+	storeA, storeB := &fakeCollabStore{content: initial}, &fakeCollabStore{content: initial}
+	hubA, hubB := newHub(), newHub()
+	hubA.SetStore(storeA)
+	hubB.SetStore(storeB)
+	serverA, serverB := newCollabTestServer(t, hubA), newCollabTestServer(t, hubB)
+
+	alice := dialCollab(t, serverA, "doc-1", "token-a")
+	bob := dialCollab(t, serverB, "doc-1", "token-b")
+	presence := alice.expect(CollabMsgPresence)
+	assert.Equal(t, "join", presence["action"])
+	assert.Equal(t, "user-b", presence["userId"])
+
+	// 双方基于同一版本并发编辑同一段落
+	alice.send(collabClientMessage{Type: CollabMsgOp, Rev: 0, ClientOpID: "a1", Ops: []CollabOp{
+		{Type: CollabOpText, Index: 0, Text: []TextComponent{{Insert: "清晨，"}}},
+	}})
+	bob.send(collabClientMessage{Type: CollabMsgOp, Rev: 0, ClientOpID: "b1", Ops: []CollabOp{
+		{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 5}, {Insert: "。"}}},
+		{Type: CollabOpInsertBlock, Index: 1, Node: paragraphNode("山风很冷。")},
+	}})
+
+	// 每一方都收到自己操作的确认和对方（已变换）的操作
+	gotA := alice.expectAll(CollabMsgAck, CollabMsgOp)
+	gotB := bob.expectAll(CollabMsgAck, CollabMsgOp)
+	assert.Equal(t, "a1", gotA[CollabMsgAck]["clientOpId"])
+	assert.Equal(t, "b1", gotB[CollabMsgAck]["clientOpId"])
+	assert.ElementsMatch(t, []float64{1, 2}, []float64{gotA[CollabMsgAck]["rev"].(float64), gotB[CollabMsgAck]["rev"].(float64)})
+	assert.Equal(t, "user-b", gotA[CollabMsgOp]["userId"])
+	assert.Equal(t, "user-a", gotB[CollabMsgOp]["userId"])
+
+	expected := `{"type":"doc","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"清晨，林动睁开眼。"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"山风很冷。"}]}
+	]}`
+	for _, hub := range []*CollabWSHub{hubA, hubB} {
+		hub.mu.Lock()
+		room := hub.rooms["doc-1"]
+		hub.mu.Unlock()
+		room.mu.Lock()
+		assert.Equal(t, 2, room.rev)
+		assert.JSONEq(t, expected, room.doc.JSON())
+		room.mu.Unlock()
+	}
+
+	// 光标跨实例转发
+	bob.send(collabClientMessage{Type: CollabMsgCursor, Cursor: json.RawMessage(`{"index":0,"offset":3}`)})
+	cursor := alice.expect(CollabMsgCursor)
+	assert.Equal(t, bob.id, cursor["clientId"])
+
+	// 实例各自在最后一个连接离开时保存本实例客户端的修改
+	alice.conn.Close()
+	// 加入时对方实例会回报已在线的协作者（action=here），跳过直到收到离开通知
+	for leave := bob.expect(CollabMsgPresence); leave["action"] != "leave"; leave = bob.expect(CollabMsgPresence) {
+		assert.Equal(t, "here", leave["action"])
+	}
+	bob.conn.Close()
+
+	for _, store := range []*fakeCollabStore{storeA, storeB} {
+		require.Eventually(t, func() bool { return store.savedCount() == 1 }, 3*time.Second, 20*time.Millisecond)
+		store.mu.Lock()
+		assert.JSONEq(t, expected, store.content)
+		store.mu.Unlock()
+	}
+
+	// 会话结束后共享状态被清理
+	require.Eventually(t, func() bool { return len(mr.Keys()) == 0 }, 3*time.Second, 20*time.Millisecond)
+}
+
+func TestCollabWSHub_LateJoinerReceivesCurrentState(t *testing.T) {
+	jwt := new(MockJWTService)
+	jwt.On("ValidateToken", mock.Anything, "token-a").Return(&auth.TokenClaims{UserID: "user-a"}, nil)
+	jwt.On("ValidateToken", mock.Anything, "token-b").Return(&auth.TokenClaims{UserID: "user-b"}, nil)
+	hub := NewCollabWSHub(jwt, nil)
+	hub.SetStore(&fakeCollabStore{})
+	server := newCollabTestServer(t, hub)
+
+	alice := dialCollab(t, server, "doc-2", "token-a")
+	alice.send(collabClientMessage{Type: CollabMsgOp, Rev: 0, ClientOpID: "a1", Ops: []CollabOp{
+		{Type: CollabOpInsertBlock, Index: 0, Node: paragraphNode("第一章")},
+	}})
+	alice.expect(CollabMsgAck)
+
+	// 基于过期版本的越界提交被拒绝
+	alice.send(collabClientMessage{Type: CollabMsgOp, Rev: 5, ClientOpID: "a2", Ops: []CollabOp{
+		{Type: CollabOpDeleteBlock, Index: 0},
+	}})
+	rejected := alice.expect(CollabMsgError)
+	assert.Equal(t, "a2", rejected["clientOpId"])
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/writer/documents/doc-2/collab"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": []string{"Bearer token-b"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	bob := &collabTestClient{t: t, conn: conn}
+	init := bob.expect(CollabMsgInit)
+	assert.Equal(t, float64(1), init["rev"])
+	content, _ := json.Marshal(init["content"])
+	assert.JSONEq(t, `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"第一章"}]}]}`, string(content))
+	peers := init["peers"].([]interface{})
+	require.Len(t, peers, 1)
+	assert.Equal(t, "user-a", peers[0].(map[string]interface{})["userId"])
+}
+
+func TestCollabWSHub_RejectsInvalidToken(t *testing.T) {
+	jwt := new(MockJWTService)
+	jwt.On("ValidateToken", mock.Anything, "bad").Return(nil, assert.AnError)
+	hub := NewCollabWSHub(jwt, nil)
+	hub.SetStore(&fakeCollabStore{})
+	server := newCollabTestServer(t, hub)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/writer/documents/doc-3/collab"
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": []string{"Bearer bad"}})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestCollabWSHub_TrimsHistoryAndResetsStaleClients(t *testing.T) {
+	hub := NewCollabWSHub(nil, nil)
+	hub.SetStore(&fakeCollabStore{})
+	ctx := context.Background()
+	client := &CollabWSClient{ID: "c1", UserID: "user-a", Send: make(chan []byte, 2*collabMaxHistory+16)}
+	require.NoError(t, hub.join(ctx, "doc-4", "", client))
+	room := client.room
+
+	total := collabMaxHistory + 5
+	for rev := 0; rev < total; rev++ {
+		room.submit(ctx, client, &collabClientMessage{Type: CollabMsgOp, Rev: rev, Ops: []CollabOp{
+			{Type: CollabOpInsertBlock, Index: 0, Node: paragraphNode("段落")},
+		}})
+	}
+
+	room.mu.Lock()
+	assert.Equal(t, total, room.rev)
+	assert.Len(t, room.history, collabMaxHistory)
+	assert.Equal(t, 5, room.historyBase)
+	room.mu.Unlock()
+
+	drain := func() (last CollabWSMessage) {
+		for {
+			select {
+			case data := <-client.Send:
+				require.NoError(t, json.Unmarshal(data, &last))
+			default:
+				return last
+			}
+		}
+	}
+	drain()
+
+	// 基于已裁剪版本的提交收到重置消息，不会写入日志
+	room.submit(ctx, client, &collabClientMessage{Type: CollabMsgOp, Rev: 4, ClientOpID: "stale", Ops: []CollabOp{
+		{Type: CollabOpDeleteBlock, Index: 0},
+	}})
+	msg := drain()
+	assert.Equal(t, CollabMsgReset, msg.Type)
+	assert.Equal(t, float64(total), msg.Data.(map[string]interface{})["rev"])
+
+	// 历史内最早的版本仍可变换
+	room.submit(ctx, client, &collabClientMessage{Type: CollabMsgOp, Rev: 5, ClientOpID: "oldest", Ops: []CollabOp{
+		{Type: CollabOpDeleteBlock, Index: 0},
+	}})
+	msg = drain()
+	assert.Equal(t, CollabMsgAck, msg.Type)
+	assert.Equal(t, "oldest", msg.Data.(map[string]interface{})["clientOpId"])
+}
+
+// blockingCollabStore 保存时阻塞，用于验证关闭会话不占用 Hub 锁
+type blockingCollabStore struct {
+	fakeCollabStore
+	release chan struct{}
+}
+
+func (s *blockingCollabStore) StopAutoSave(documentID string) error {
+	<-s.release
+	return s.fakeCollabStore.StopAutoSave(documentID)
+}
+
+func TestCollabWSHub_SavingDoesNotBlockOtherDocuments(t *testing.T) {
+	hub := NewCollabWSHub(nil, nil)
+	store := &blockingCollabStore{release: make(chan struct{})}
+	hub.SetStore(store)
+	ctx := context.Background()
+
+	first := &CollabWSClient{ID: "c1", UserID: "user-a", Send: make(chan []byte, 16)}
+	require.NoError(t, hub.join(ctx, "doc-5", "", first))
+	left := make(chan struct{})
+	go func() {
+		hub.leave(first)
+		close(left)
+	}()
+
+	// 保存进行中时其他文档仍可加入
+	second := &CollabWSClient{ID: "c2", UserID: "user-b", Send: make(chan []byte, 16)}
+	joined := make(chan error, 1)
+	go func() { joined <- hub.join(ctx, "doc-6", "", second) }()
+	select {
+	case err := <-joined:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("join blocked by a pending save")
+	}
+
+	close(store.release)
+	<-left
+	hub.mu.Lock()
+	_, open := hub.rooms["doc-5"]
+	hub.mu.Unlock()
+	assert.False(t, open)
+}
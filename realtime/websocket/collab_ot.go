@@ -0,0 +1,431 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// 协作编辑操作类型
+// 文档按 TipTap 顶层节点划分为块：段落、标题、代码块等文本块支持字符级编辑，
+// 列表、引用等结构化节点作为整体替换
+const (
+	CollabOpInsertBlock  = "insert_block"  // 在 index 处插入块
+	CollabOpDeleteBlock  = "delete_block"  // 删除 index 处的块
+	CollabOpSetBlock     = "set_block"     // 修改块类型/属性（如段落改为标题），不影响文本
+	CollabOpReplaceBlock = "replace_block" // 整块替换（非文本块的编辑）
+	CollabOpText         = "text"          // 文本块内的字符级编辑
+)
+
+// ErrCollabInvalidOp 协作操作格式错误或与文档状态不匹配
+var ErrCollabInvalidOp = errors.New("collab_invalid_op")
+
+// CollabOp 协作编辑操作
+type CollabOp struct {
+	Type      string          `json:"type"`
+	Index     int             `json:"index"`
+	Node      json.RawMessage `json:"node,omitempty"`      // insert_block / replace_block 的 TipTap 节点
+	BlockType string          `json:"blockType,omitempty"` // set_block 的新节点类型
+	Attrs     json.RawMessage `json:"attrs,omitempty"`     // set_block 的新属性
+	Text      []TextComponent `json:"text,omitempty"`      // text 的编辑分量
+}
+
+// TextComponent 文本编辑分量，Retain/Insert/Delete 三者取其一
+// 位置以 Unicode 码点计数，行内换行（hardBreak）记为一个 '\n'
+type TextComponent struct {
+	Retain int    `json:"retain,omitempty"`
+	Insert string `json:"insert,omitempty"`
+	Delete int    `json:"delete,omitempty"`
+	// Marks 插入文本的样式
+	Marks []json.RawMessage `json:"marks,omitempty"`
+	// Format 非空时将保留区间的样式整体设置为该值（空数组表示清除样式）
+	Format *[]json.RawMessage `json:"format,omitempty"`
+}
+
+func (c TextComponent) length() int {
+	switch {
+	case c.Retain > 0:
+		return c.Retain
+	case c.Delete > 0:
+		return c.Delete
+	default:
+		return utf8.RuneCountInString(c.Insert)
+	}
+}
+
+// isTarget 操作是否作用于已有块（而非插入/删除块）
+func (op CollabOp) isTarget() bool {
+	return op.Type == CollabOpSetBlock || op.Type == CollabOpReplaceBlock || op.Type == CollabOpText
+}
+
+// normalizeCollabOps 校验客户端提交的操作并规范化样式 JSON，使样式比较与字段顺序无关
+func normalizeCollabOps(ops []CollabOp) ([]CollabOp, error) {
+	out := make([]CollabOp, 0, len(ops))
+	for i, op := range ops {
+		if op.Index < 0 {
+			return nil, fmt.Errorf("%w: op %d has negative index", ErrCollabInvalidOp, i)
+		}
+		switch op.Type {
+		case CollabOpInsertBlock, CollabOpReplaceBlock:
+			if _, err := parseCollabBlock(op.Node); err != nil {
+				return nil, fmt.Errorf("%w: op %d: %v", ErrCollabInvalidOp, i, err)
+			}
+		case CollabOpDeleteBlock:
+		case CollabOpSetBlock:
+			if op.BlockType == "" && len(op.Attrs) == 0 {
+				return nil, fmt.Errorf("%w: op %d sets nothing", ErrCollabInvalidOp, i)
+			}
+			if len(op.Attrs) > 0 && !json.Valid(op.Attrs) {
+				return nil, fmt.Errorf("%w: op %d has invalid attrs", ErrCollabInvalidOp, i)
+			}
+		case CollabOpText:
+			text, err := normalizeTextComponents(op.Text)
+			if err != nil {
+				return nil, fmt.Errorf("%w: op %d: %v", ErrCollabInvalidOp, i, err)
+			}
+			if len(text) == 0 {
+				continue
+			}
+			op.Text = text
+		default:
+			return nil, fmt.Errorf("%w: op %d has unknown type %q", ErrCollabInvalidOp, i, op.Type)
+		}
+		out = append(out, op)
+	}
+	return out, nil
+}
+
+func normalizeTextComponents(comps []TextComponent) ([]TextComponent, error) {
+	var b textBuilder
+	for _, c := range comps {
+		kinds := 0
+		if c.Retain != 0 {
+			kinds++
+		}
+		if c.Insert != "" {
+			kinds++
+		}
+		if c.Delete != 0 {
+			kinds++
+		}
+		if kinds != 1 || c.Retain < 0 || c.Delete < 0 {
+			return nil, errors.New("text component must be exactly one of retain, insert or delete")
+		}
+		marks, err := canonicalMarks(c.Marks)
+		if err != nil {
+			return nil, err
+		}
+		c.Marks = marks
+		if c.Format != nil {
+			format, err := canonicalMarks(*c.Format)
+			if err != nil {
+				return nil, err
+			}
+			if format == nil {
+				format = []json.RawMessage{}
+			}
+			c.Format = &format
+		}
+		b.push(c)
+	}
+	// 末尾不带样式的保留可省略
+	return b.trimmed(), nil
+}
+
+// canonicalMarks 将样式列表重新序列化为键有序的规范形式
+func canonicalMarks(marks []json.RawMessage) ([]json.RawMessage, error) {
+	if len(marks) == 0 {
+		return nil, nil
+	}
+	out := make([]json.RawMessage, 0, len(marks))
+	for _, m := range marks {
+		var v map[string]interface{}
+		if err := json.Unmarshal(m, &v); err != nil {
+			return nil, fmt.Errorf("invalid mark: %w", err)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+// marksKey 样式列表的比较键，无样式时为空串
+func marksKey(marks []json.RawMessage) string {
+	if len(marks) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(marks)
+	return string(data)
+}
+
+func formatKey(format *[]json.RawMessage) string {
+	if format == nil {
+		return "-"
+	}
+	return marksKey(*format)
+}
+
+// textBuilder 构建文本编辑分量，合并相邻的同类分量
+type textBuilder struct {
+	comps []TextComponent
+}
+
+func (b *textBuilder) push(c TextComponent) {
+	if c.length() == 0 {
+		return
+	}
+	if n := len(b.comps); n > 0 {
+		last := &b.comps[n-1]
+		switch {
+		case c.Retain > 0 && last.Retain > 0 && formatKey(c.Format) == formatKey(last.Format):
+			last.Retain += c.Retain
+			return
+		case c.Delete > 0 && last.Delete > 0:
+			last.Delete += c.Delete
+			return
+		case c.Insert != "" && last.Insert != "" && marksKey(c.Marks) == marksKey(last.Marks):
+			last.Insert += c.Insert
+			return
+		}
+	}
+	b.comps = append(b.comps, c)
+}
+
+func (b *textBuilder) retain(n int, format *[]json.RawMessage) {
+	b.push(TextComponent{Retain: n, Format: format})
+}
+
+func (b *textBuilder) delete(n int) {
+	b.push(TextComponent{Delete: n})
+}
+
+// trimmed 返回去掉末尾无样式保留后的分量
+func (b *textBuilder) trimmed() []TextComponent {
+	comps := b.comps
+	if n := len(comps); n > 0 && comps[n-1].Retain > 0 && comps[n-1].Format == nil {
+		comps = comps[:n-1]
+	}
+	return comps
+}
+
+// textIter 按长度逐段消费文本编辑分量
+type textIter struct {
+	comps  []TextComponent
+	index  int
+	offset int
+}
+
+func (it *textIter) peek() *TextComponent {
+	if it.index >= len(it.comps) {
+		return nil
+	}
+	return &it.comps[it.index]
+}
+
+func (it *textIter) remaining() int {
+	return it.comps[it.index].length() - it.offset
+}
+
+func (it *textIter) advance(n int) {
+	it.offset += n
+	if it.offset >= it.comps[it.index].length() {
+		it.index++
+		it.offset = 0
+	}
+}
+
+// textBaseLen 分量作用的原文长度（保留与删除之和）
+func textBaseLen(comps []TextComponent) int {
+	n := 0
+	for _, c := range comps {
+		if c.Insert == "" {
+			n += c.length()
+		}
+	}
+	return n
+}
+
+// transformText 变换两个作用于同一文本的编辑，返回 (a', b') 使 apply(apply(s, a), b') == apply(apply(s, b), a')
+// 同一位置的插入与同一区间的样式设置由优先方胜出
+func transformText(a, b []TextComponent, aPriority bool) ([]TextComponent, []TextComponent) {
+	// 省略的末尾保留按对方长度补齐
+	if la, lb := textBaseLen(a), textBaseLen(b); la < lb {
+		a = append(append([]TextComponent(nil), a...), TextComponent{Retain: lb - la})
+	} else if lb < la {
+		b = append(append([]TextComponent(nil), b...), TextComponent{Retain: la - lb})
+	}
+
+	ia, ib := &textIter{comps: a}, &textIter{comps: b}
+	var a2, b2 textBuilder
+	for {
+		ca, cb := ia.peek(), ib.peek()
+		if ca == nil && cb == nil {
+			break
+		}
+		if ca != nil && ca.Insert != "" && (aPriority || cb == nil || cb.Insert == "") {
+			a2.push(*ca)
+			b2.retain(ca.length(), nil)
+			ia.index++
+			continue
+		}
+		if cb != nil && cb.Insert != "" {
+			a2.retain(cb.length(), nil)
+			b2.push(*cb)
+			ib.index++
+			continue
+		}
+		if ca == nil || cb == nil {
+			// 补齐后两侧长度一致，不会出现
+			break
+		}
+
+		n := ia.remaining()
+		if m := ib.remaining(); m < n {
+			n = m
+		}
+		switch {
+		case ca.Delete > 0 && cb.Delete > 0:
+			// 双方删除同一段文本
+		case ca.Delete > 0:
+			a2.delete(n)
+		case cb.Delete > 0:
+			b2.delete(n)
+		case ca.Format != nil && cb.Format != nil:
+			if aPriority {
+				a2.retain(n, ca.Format)
+				b2.retain(n, nil)
+			} else {
+				a2.retain(n, nil)
+				b2.retain(n, cb.Format)
+			}
+		default:
+			a2.retain(n, ca.Format)
+			b2.retain(n, cb.Format)
+		}
+		ia.advance(n)
+		ib.advance(n)
+	}
+	return a2.trimmed(), b2.trimmed()
+}
+
+// transformCollabOp 变换两个并发的协作操作，nil 表示变换后操作失效
+// aPriority 为 true 时 a 在同位置插入、属性设置、整块替换等冲突中胜出
+func transformCollabOp(a, b CollabOp, aPriority bool) (*CollabOp, *CollabOp) {
+	a2, b2 := a, b
+	switch {
+	case a.Type == CollabOpInsertBlock && b.Type == CollabOpInsertBlock:
+		if a.Index < b.Index || (a.Index == b.Index && aPriority) {
+			b2.Index++
+		} else {
+			a2.Index++
+		}
+	case a.Type == CollabOpInsertBlock && b.Type == CollabOpDeleteBlock:
+		if a.Index <= b.Index {
+			b2.Index++
+		} else {
+			a2.Index--
+		}
+	case a.Type == CollabOpDeleteBlock && b.Type == CollabOpInsertBlock:
+		if b.Index <= a.Index {
+			a2.Index++
+		} else {
+			b2.Index--
+		}
+	case a.Type == CollabOpInsertBlock:
+		if a.Index <= b.Index {
+			b2.Index++
+		}
+	case b.Type == CollabOpInsertBlock:
+		if b.Index <= a.Index {
+			a2.Index++
+		}
+	case a.Type == CollabOpDeleteBlock && b.Type == CollabOpDeleteBlock:
+		switch {
+		case a.Index == b.Index:
+			return nil, nil
+		case a.Index < b.Index:
+			b2.Index--
+		default:
+			a2.Index--
+		}
+	case a.Type == CollabOpDeleteBlock:
+		switch {
+		case a.Index == b.Index:
+			return &a2, nil
+		case a.Index < b.Index:
+			b2.Index--
+		}
+	case b.Type == CollabOpDeleteBlock:
+		switch {
+		case a.Index == b.Index:
+			return nil, &b2
+		case b.Index < a.Index:
+			a2.Index--
+		}
+	case a.Index != b.Index:
+		// 作用于不同块的修改互不影响
+	default:
+		return transformSameBlock(a, b, aPriority)
+	}
+	return &a2, &b2
+}
+
+// transformSameBlock 变换作用于同一块的两个修改
+func transformSameBlock(a, b CollabOp, aPriority bool) (*CollabOp, *CollabOp) {
+	switch {
+	case a.Type == CollabOpReplaceBlock && b.Type == CollabOpReplaceBlock,
+		a.Type == CollabOpSetBlock && b.Type == CollabOpSetBlock:
+		if aPriority {
+			return &a, nil
+		}
+		return nil, &b
+	case a.Type == CollabOpReplaceBlock:
+		// 整块替换覆盖对方的局部修改
+		return &a, nil
+	case b.Type == CollabOpReplaceBlock:
+		return nil, &b
+	case a.Type == CollabOpText && b.Type == CollabOpText:
+		at, bt := transformText(a.Text, b.Text, aPriority)
+		a.Text, b.Text = at, bt
+		var a2, b2 *CollabOp
+		if len(at) > 0 {
+			a2 = &a
+		}
+		if len(bt) > 0 {
+			b2 = &b
+		}
+		return a2, b2
+	default:
+		// 属性修改与文本编辑互不影响
+		return &a, &b
+	}
+}
+
+// transformCollabOps 将操作序列 a 变换到已应用 b 之后的文档上，同时返回 b 相对 a 的变换结果
+func transformCollabOps(a, b []CollabOp, aPriority bool) ([]CollabOp, []CollabOp) {
+	var bOut []CollabOp
+	for _, bop := range b {
+		cur := &bop
+		next := make([]CollabOp, 0, len(a))
+		for _, aop := range a {
+			if cur == nil {
+				next = append(next, aop)
+				continue
+			}
+			a2, b2 := transformCollabOp(aop, *cur, aPriority)
+			if a2 != nil {
+				next = append(next, *a2)
+			}
+			cur = b2
+		}
+		a = next
+		if cur != nil {
+			bOut = append(bOut, *cur)
+		}
+	}
+	return a, bOut
+}
@@ -0,0 +1,215 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func paragraphNode(text string) json.RawMessage {
+	if text == "" {
+		return json.RawMessage(`{"type":"paragraph"}`)
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":    "paragraph",
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+	})
+	return data
+}
+
+func testDocument(t *testing.T, paragraphs ...string) *collabDocument {
+	nodes := make([]json.RawMessage, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		nodes = append(nodes, paragraphNode(p))
+	}
+	content, _ := json.Marshal(map[string]interface{}{"type": "doc", "content": nodes})
+	doc, err := parseCollabDocument(string(content))
+	require.NoError(t, err)
+	return doc
+}
+
+// assertConverges 校验 apply(apply(d, a), b') == apply(apply(d, b), a')，b 为已先应用的一方
+func assertConverges(t *testing.T, doc *collabDocument, a, b []CollabOp) string {
+	t.Helper()
+	a2, b2 := transformCollabOps(a, b, false)
+
+	afterA, err := doc.apply(a)
+	require.NoError(t, err)
+	left, err := afterA.apply(b2)
+	require.NoError(t, err)
+
+	afterB, err := doc.apply(b)
+	require.NoError(t, err)
+	right, err := afterB.apply(a2)
+	require.NoError(t, err)
+
+	require.Equal(t, left.JSON(), right.JSON())
+	return right.JSON()
+}
+
+func TestTransformText_ConcurrentInsertsAtSamePosition(t *testing.T) {
+	doc := testDocument(t, "林动")
+	a := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 2}, {Insert: "甲"}}}}
+	b := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 2}, {Insert: "乙"}}}}
+
+	result := assertConverges(t, doc, a, b)
+	// 先应用的一方优先
+	assert.Contains(t, result, `"林动乙甲"`)
+}
+
+func TestTransformText_OverlappingDeletes(t *testing.T) {
+	doc := testDocument(t, "一二三四五")
+	a := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 1}, {Delete: 3}}}}
+	b := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 2}, {Delete: 3}}}}
+
+	result := assertConverges(t, doc, a, b)
+	assert.Contains(t, result, `"一"`)
+}
+
+func TestTransformText_FormatAndInsert(t *testing.T) {
+	doc := testDocument(t, "夜色深沉")
+	bold := []json.RawMessage{json.RawMessage(`{"type":"bold"}`)}
+	a := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 4, Format: &bold}}}}
+	b := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 2}, {Insert: "的"}}}}
+
+	result := assertConverges(t, doc, a, b)
+	// 格式化只作用于原有文字，并发插入的文字保持原样
+	assert.JSONEq(t, `{"type":"doc","content":[{"type":"paragraph","content":[
+		{"type":"text","text":"夜色","marks":[{"type":"bold"}]},
+		{"type":"text","text":"的"},
+		{"type":"text","text":"深沉","marks":[{"type":"bold"}]}
+	]}]}`, result)
+}
+
+func TestTransformCollabOp_DeleteBlockDropsConcurrentEdit(t *testing.T) {
+	doc := testDocument(t, "甲", "乙", "丙")
+	a := []CollabOp{{Type: CollabOpText, Index: 1, Text: []TextComponent{{Insert: "改"}}}}
+	b := []CollabOp{{Type: CollabOpDeleteBlock, Index: 1}}
+
+	a2, _ := transformCollabOps(a, b, false)
+	assert.Empty(t, a2)
+	result := assertConverges(t, doc, a, b)
+	assert.NotContains(t, result, "乙")
+}
+
+func TestTransformCollabOp_InsertBlockShiftsEdits(t *testing.T) {
+	doc := testDocument(t, "甲", "乙")
+	a := []CollabOp{{Type: CollabOpText, Index: 1, Text: []TextComponent{{Retain: 1}, {Insert: "二"}}}}
+	b := []CollabOp{{Type: CollabOpInsertBlock, Index: 0, Node: paragraphNode("序")}}
+
+	a2, _ := transformCollabOps(a, b, false)
+	require.Len(t, a2, 1)
+	assert.Equal(t, 2, a2[0].Index)
+	assertConverges(t, doc, a, b)
+}
+
+func TestTransformCollabOp_SetBlockAndTextCommute(t *testing.T) {
+	doc := testDocument(t, "第一章")
+	a := []CollabOp{{Type: CollabOpSetBlock, Index: 0, BlockType: "heading", Attrs: json.RawMessage(`{"level":1}`)}}
+	b := []CollabOp{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 3}, {Insert: " 启程"}}}}
+
+	result := assertConverges(t, doc, a, b)
+	assert.JSONEq(t, `{"type":"doc","content":[{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"第一章 启程"}]}]}`, result)
+}
+
+func TestNormalizeCollabOps_Rejects(t *testing.T) {
+	cases := [][]CollabOp{
+		{{Type: "move", Index: 0}},
+		{{Type: CollabOpText, Index: -1, Text: []TextComponent{{Insert: "x"}}}},
+		{{Type: CollabOpText, Index: 0, Text: []TextComponent{{Retain: 1, Insert: "x"}}}},
+		{{Type: CollabOpInsertBlock, Index: 0, Node: json.RawMessage(`{"content":[]}`)}},
+		{{Type: CollabOpSetBlock, Index: 0}},
+	}
+	for i, ops := range cases {
+		_, err := normalizeCollabOps(ops)
+		assert.ErrorIs(t, err, ErrCollabInvalidOp, "case %d", i)
+	}
+}
+
+func TestCollabDocument_RoundTripsRichContent(t *testing.T) {
+	content := `{"type":"doc","content":[` +
+		`{"type":"paragraph","attrs":{"textAlign":"center"},"content":[{"type":"text","text":"甲","marks":[{"type":"bold"}]},{"type":"hardBreak"},{"type":"text","text":"乙"}]},` +
+		`{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"项"}]}]}]},` +
+		`{"type":"codeBlock","content":[{"type":"text","text":"a\nb"}]}]}`
+	doc, err := parseCollabDocument(content)
+	require.NoError(t, err)
+
+	require.Len(t, doc.blocks, 3)
+	assert.Nil(t, doc.blocks[0].raw)
+	assert.NotNil(t, doc.blocks[1].raw)
+	assert.Nil(t, doc.blocks[2].raw)
+	assert.JSONEq(t, content, doc.JSON())
+
+	_, err = doc.apply([]CollabOp{{Type: CollabOpText, Index: 1, Text: []TextComponent{{Insert: "x"}}}})
+	assert.ErrorIs(t, err, ErrCollabInvalidOp)
+}
+
+// randomTextOp 生成作用于长度为 n 的文本的随机编辑
+func randomTextOp(rng *rand.Rand, n int) []TextComponent {
+	var b textBuilder
+	pos := 0
+	bold := []json.RawMessage{json.RawMessage(`{"type":"bold"}`)}
+	for pos < n {
+		k := 1 + rng.Intn(n-pos)
+		switch rng.Intn(4) {
+		case 0:
+			b.delete(k)
+		case 1:
+			b.push(TextComponent{Insert: string(rune('a' + rng.Intn(26)))})
+			continue
+		case 2:
+			b.retain(k, &bold)
+		default:
+			b.retain(k, nil)
+		}
+		pos += k
+	}
+	if rng.Intn(2) == 0 {
+		b.push(TextComponent{Insert: "z"})
+	}
+	return b.trimmed()
+}
+
+func randomCollabOp(rng *rand.Rand, doc *collabDocument) CollabOp {
+	n := len(doc.blocks)
+	if n == 0 || rng.Intn(5) == 0 {
+		return CollabOp{Type: CollabOpInsertBlock, Index: rng.Intn(n + 1), Node: paragraphNode(fmt.Sprintf("新%d", rng.Intn(100)))}
+	}
+	index := rng.Intn(n)
+	switch rng.Intn(6) {
+	case 0:
+		return CollabOp{Type: CollabOpDeleteBlock, Index: index}
+	case 1:
+		return CollabOp{Type: CollabOpSetBlock, Index: index, Attrs: json.RawMessage(fmt.Sprintf(`{"level":%d}`, rng.Intn(3)))}
+	case 2:
+		return CollabOp{Type: CollabOpReplaceBlock, Index: index, Node: paragraphNode(fmt.Sprintf("换%d", rng.Intn(100)))}
+	default:
+		return CollabOp{Type: CollabOpText, Index: index, Text: randomTextOp(rng, len(doc.blocks[index].text))}
+	}
+}
+
+func randomCollabOps(t *testing.T, rng *rand.Rand, doc *collabDocument) []CollabOp {
+	var ops []CollabOp
+	for i := 0; i < 1+rng.Intn(3); i++ {
+		op := randomCollabOp(rng, doc)
+		next, err := doc.apply([]CollabOp{op})
+		require.NoError(t, err)
+		ops = append(ops, op)
+		doc = next
+	}
+	return ops
+}
+
+func TestTransformCollabOps_RandomizedConvergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < 2000; i++ {
+		doc := testDocument(t, "春眠不觉晓", "处处闻啼鸟", "夜来风雨声")
+		a := randomCollabOps(t, rng, doc)
+		b := randomCollabOps(t, rng, doc)
+		assertConverges(t, doc, a, b)
+	}
+}
@@ -91,7 +91,7 @@ func TestNotificationHubValidateToken(t *testing.T) {
 // TestMessagingHubValidateToken 测试消息Hub的token验证
 func TestMessagingHubValidateToken(t *testing.T) {
 	mockJWT := new(MockJWTService)
-	hub := NewMessagingWSHub(mockJWT)
+	hub := NewMessagingWSHub(mockJWT, nil)
 	ctx := context.Background()
 
 	t.Run("有效token", func(t *testing.T) {
@@ -121,7 +121,7 @@ func TestMessagingHubValidateToken(t *testing.T) {
 	})
 
 	t.Run("JWT服务未初始化", func(t *testing.T) {
-		hubWithoutJWT := NewMessagingWSHub(nil)
+		hubWithoutJWT := NewMessagingWSHub(nil, nil)
 
 		userID, err := hubWithoutJWT.validateMessagingToken(ctx, "any_token")
 
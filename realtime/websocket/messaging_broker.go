@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MessagingBroker 消息推送的跨实例广播
+// 每个实例把会话消息发布到 broker，所有实例收到后只投递给本实例上连接的参与者
+type MessagingBroker interface {
+	// Publish 向所有实例广播消息
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe 订阅广播，返回取消订阅函数
+	Subscribe(ctx context.Context, handler func(payload []byte)) (func(), error)
+}
+
+// MemoryMessagingBroker 进程内广播（测试与单进程内多 Hub）
+// 处理函数同步调用，保证同一发布方的消息顺序
+type MemoryMessagingBroker struct {
+	mu      sync.Mutex
+	subs    map[int]func([]byte)
+	nextSub int
+}
+
+// NewMemoryMessagingBroker 创建进程内广播
+func NewMemoryMessagingBroker() *MemoryMessagingBroker {
+	return &MemoryMessagingBroker{subs: make(map[int]func([]byte))}
+}
+
+// Publish 广播消息
+func (b *MemoryMessagingBroker) Publish(ctx context.Context, payload []byte) error {
+	b.mu.Lock()
+	handlers := make([]func([]byte), 0, len(b.subs))
+	for _, h := range b.subs {
+		handlers = append(handlers, h)
+	}
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+// Subscribe 订阅广播
+func (b *MemoryMessagingBroker) Subscribe(ctx context.Context, handler func([]byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextSub++
+	id := b.nextSub
+	b.subs[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}, nil
+}
+
+// RedisMessagingBroker 基于 Redis pub/sub 的跨实例广播
+type RedisMessagingBroker struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewRedisMessagingBroker 创建 Redis 广播
+func NewRedisMessagingBroker(client redis.UniversalClient, channel string) *RedisMessagingBroker {
+	if channel == "" {
+		channel = "messaging:events"
+	}
+	return &RedisMessagingBroker{client: client, channel: channel}
+}
+
+// Publish 广播消息
+func (b *RedisMessagingBroker) Publish(ctx context.Context, payload []byte) error {
+	if err := b.client.Publish(ctx, b.channel, payload).Err(); err != nil {
+		return fmt.Errorf("发布消息广播失败: %w", err)
+	}
+	return nil
+}
+
+// Subscribe 订阅广播，订阅确认后才返回，避免丢失随后发布的消息
+func (b *RedisMessagingBroker) Subscribe(ctx context.Context, handler func([]byte)) (func(), error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("订阅消息频道失败: %w", err)
+	}
+	ch := pubsub.Channel()
+	go func() {
+		for msg := range ch {
+			handler([]byte(msg.Payload))
+		}
+	}()
+	return func() { pubsub.Close() }, nil
+}
@@ -17,13 +17,24 @@ import (
 )
 
 // MessagingWSHub 消息WebSocket Hub
+// 同一用户可同时保持多个连接（多设备），消息只投递给会话参与者；
+// 配置 broker 后消息经 broker 广播到所有实例，由各实例投递给本地连接
 type MessagingWSHub struct {
-	clients    map[string]*MessagingWSClient // key: userID
-	mu         sync.RWMutex
-	register   chan *MessagingWSClient
-	unregister chan *MessagingWSClient
-	broadcast  chan *MessageBroadcast
-	jwtService auth.JWTService
+	clients     map[string]map[string]*MessagingWSClient // key: userID -> clientID
+	mu          sync.RWMutex
+	register    chan *MessagingWSClient
+	unregister  chan *MessagingWSClient
+	broadcast   chan *MessageBroadcast
+	jwtService  auth.JWTService
+	broker      MessagingBroker
+	instanceID  string
+	unsubscribe func()
+	resolver    ParticipantResolver
+}
+
+// ParticipantResolver 查询会话参与者，用于转发客户端上行的 typing 等临时事件
+type ParticipantResolver interface {
+	ConversationParticipants(ctx context.Context, conversationID string) ([]string, error)
 }
 
 // MessagingWSClient 消息WebSocket客户端
@@ -33,18 +44,54 @@ type MessagingWSClient struct {
 	Conn   *websocket.Conn
 	Send   chan []byte
 	Hub    *MessagingWSHub
+
+	lastTyping map[string]time.Time // 会话ID -> 上次转发“正在输入”的时间，仅 readPump 访问
+}
+
+// typingThrottle 同一连接同一会话“正在输入”的最小转发间隔
+const typingThrottle = 3 * time.Second
+
+// typingResolveTimeout 查询 typing 会话参与者的超时时间
+const typingResolveTimeout = 2 * time.Second
+
+// TypingEvent 正在输入事件（客户端上行与服务端下行共用）
+type TypingEvent struct {
+	ConversationID string `json:"conversationId"`
+	UserID         string `json:"userId,omitempty"`
+	Typing         bool   `json:"typing"`
+}
+
+// messagingClientFrame 客户端上行帧
+type messagingClientFrame struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
 }
 
 // MessageBroadcast 消息广播
 type MessageBroadcast struct {
-	ConversationID string
-	Message        interface{}
-	ExcludeUserID  string // 排除某个用户（发送者）
+	ConversationID  string
+	ParticipantIDs  []string // 会话参与者，只投递给这些用户
+	Message         interface{}
+	ExcludeClientID string // 排除发起推送的连接，发送者的其他设备照常接收
+
+	payload []byte // 已序列化的 MessageWSMessage，跨实例转发时复用
+}
+
+// messagingEnvelope 跨实例广播的消息信封
+type messagingEnvelope struct {
+	Instance        string          `json:"instance"`
+	ConversationID  string          `json:"conversation_id"`
+	ParticipantIDs  []string        `json:"participant_ids"`
+	ExcludeClientID string          `json:"exclude_client_id,omitempty"`
+	Payload         json.RawMessage `json:"payload"`
 }
 
+// messagingPublishTimeout 发布跨实例广播的超时时间
+const messagingPublishTimeout = 3 * time.Second
+
 // MessageWSMessage WebSocket消息格式
 type MessageWSMessage struct {
-	Type      string      `json:"type"` // new_message, read, typing, error
+	Type      string      `json:"type"` // new_message, read_receipt, typing, message_recalled, message_edited, group_updated, error
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp"`
 }
@@ -79,35 +126,61 @@ var messagingUpgrader = websocket.Upgrader{
 	Subprotocols: []string{"Bearer-Token"},
 }
 
-// NewMessagingWSHub 创建消息WebSocket Hub
-func NewMessagingWSHub(jwtService auth.JWTService) *MessagingWSHub {
+// NewMessagingWSHub 创建消息WebSocket Hub，broker 为空时只投递本实例的连接
+func NewMessagingWSHub(jwtService auth.JWTService, broker MessagingBroker) *MessagingWSHub {
 	hub := &MessagingWSHub{
-		clients:    make(map[string]*MessagingWSClient),
+		clients:    make(map[string]map[string]*MessagingWSClient),
 		register:   make(chan *MessagingWSClient),
 		unregister: make(chan *MessagingWSClient),
 		broadcast:  make(chan *MessageBroadcast, 256),
 		jwtService: jwtService,
+		instanceID: generateMessagingClientID(),
+	}
+	if broker != nil {
+		unsubscribe, err := broker.Subscribe(context.Background(), hub.handleRemoteBroadcast)
+		if err != nil {
+			log.Printf("订阅跨实例消息广播失败，仅投递本实例连接: %v", err)
+		} else {
+			hub.broker = broker
+			hub.unsubscribe = unsubscribe
+		}
 	}
 	go hub.Run()
 	return hub
 }
 
+// SetParticipantResolver 设置会话参与者查询，未设置时忽略客户端的 typing 事件
+func (h *MessagingWSHub) SetParticipantResolver(resolver ParticipantResolver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resolver = resolver
+}
+
+// Close 取消跨实例广播订阅
+func (h *MessagingWSHub) Close() {
+	if h.unsubscribe != nil {
+		h.unsubscribe()
+	}
+}
+
 // Run 启动Hub
 func (h *MessagingWSHub) Run() {
 	for {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			h.clients[client.UserID] = client
+			conns, ok := h.clients[client.UserID]
+			if !ok {
+				conns = make(map[string]*MessagingWSClient)
+				h.clients[client.UserID] = conns
+			}
+			conns[client.ID] = client
 			h.mu.Unlock()
 			log.Printf("消息WebSocket客户端已连接")
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				close(client.Send)
-			}
+			h.removeClientLocked(client)
 			h.mu.Unlock()
 			log.Printf("消息WebSocket客户端已断开")
 
@@ -118,61 +191,181 @@ func (h *MessagingWSHub) Run() {
 	}
 }
 
-// broadcastToConversation 向会话的所有参与者广播消息
+// ConnectionCount 用户在本实例上的连接数
+func (h *MessagingWSHub) ConnectionCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
+
+// removeClientLocked 移除连接并关闭发送通道，调用方须持有写锁
+func (h *MessagingWSHub) removeClientLocked(client *MessagingWSClient) {
+	conns, ok := h.clients[client.UserID]
+	if !ok || conns[client.ID] != client {
+		return
+	}
+	delete(conns, client.ID)
+	close(client.Send)
+	if len(conns) == 0 {
+		delete(h.clients, client.UserID)
+	}
+}
+
+// broadcastToConversation 向本实例上会话参与者的全部连接投递消息
 func (h *MessagingWSHub) broadcastToConversation(broadcast *MessageBroadcast) {
-	message := MessageWSMessage{
-		Type:      "new_message",
-		Data:      broadcast.Message,
-		Timestamp: time.Now().Unix(),
+	data := broadcast.payload
+	if data == nil {
+		var err error
+		data, err = marshalMessagingFrame("new_message", broadcast.Message)
+		if err != nil {
+			log.Printf("消息序列化失败: %v", err)
+			return
+		}
+	}
+
+	// 第一阶段：读锁下投递并收集发送缓冲已满的连接
+	var clientsToDelete []*MessagingWSClient
+
+	h.mu.RLock()
+	for _, userID := range broadcast.ParticipantIDs {
+		for id, client := range h.clients[userID] {
+			// 只排除发起推送的连接
+			if id == broadcast.ExcludeClientID {
+				continue
+			}
+			select {
+			case client.Send <- data:
+			default:
+				clientsToDelete = append(clientsToDelete, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	// 第二阶段：写锁下删除失效连接
+	if len(clientsToDelete) > 0 {
+		h.mu.Lock()
+		for _, client := range clientsToDelete {
+			h.removeClientLocked(client)
+		}
+		h.mu.Unlock()
 	}
+}
+
+// SendMessage 发送消息到会话参与者（含其他实例上的连接）
+func (h *MessagingWSHub) SendMessage(conversationID string, participantIDs []string, message interface{}, excludeClientID string) {
+	h.SendEvent("new_message", conversationID, participantIDs, message, excludeClientID)
+}
 
-	data, err := json.Marshal(message)
+// SendEvent 向会话参与者推送指定类型的事件（含其他实例上的连接）
+// excludeClientID 为发起推送的 WebSocket 连接ID，经 REST 接口发起的推送传空字符串
+func (h *MessagingWSHub) SendEvent(eventType, conversationID string, participantIDs []string, data interface{}, excludeClientID string) {
+	payload, err := marshalMessagingFrame(eventType, data)
 	if err != nil {
 		log.Printf("消息序列化失败: %v", err)
 		return
 	}
 
-	// 第一阶段：读锁下收集需要剔除的连接
-	var userIDsToDelete []string
+	h.broadcast <- &MessageBroadcast{
+		ConversationID:  conversationID,
+		ParticipantIDs:  participantIDs,
+		Message:         data,
+		ExcludeClientID: excludeClientID,
+		payload:         payload,
+	}
 
+	if h.broker == nil {
+		return
+	}
+	envelope, err := json.Marshal(messagingEnvelope{
+		Instance:        h.instanceID,
+		ConversationID:  conversationID,
+		ParticipantIDs:  participantIDs,
+		ExcludeClientID: excludeClientID,
+		Payload:         payload,
+	})
+	if err != nil {
+		log.Printf("消息广播序列化失败: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), messagingPublishTimeout)
+	defer cancel()
+	if err := h.broker.Publish(ctx, envelope); err != nil {
+		log.Printf("跨实例消息广播失败: %v", err)
+	}
+}
+
+// relayTyping 将“正在输入”状态转发给会话其他参与者，不落库
+// 发送方须为会话参与者；开始输入的事件按 typingThrottle 节流，停止输入总是转发
+func (h *MessagingWSHub) relayTyping(client *MessagingWSClient, event TypingEvent) {
+	if event.ConversationID == "" {
+		return
+	}
 	h.mu.RLock()
-	for userID, client := range h.clients {
-		// 排除发送者
-		if userID == broadcast.ExcludeUserID {
-			continue
+	resolver := h.resolver
+	h.mu.RUnlock()
+	if resolver == nil {
+		return
+	}
+
+	now := time.Now()
+	if event.Typing {
+		if last, ok := client.lastTyping[event.ConversationID]; ok && now.Sub(last) < typingThrottle {
+			return
 		}
+	}
 
-		// TODO: 检查用户是否在conversation中
+	ctx, cancel := context.WithTimeout(context.Background(), typingResolveTimeout)
+	defer cancel()
+	participantIDs, err := resolver.ConversationParticipants(ctx, event.ConversationID)
+	if err != nil || !containsUser(participantIDs, client.UserID) {
+		return
+	}
 
-		select {
-		case client.Send <- data:
-		default:
-			userIDsToDelete = append(userIDsToDelete, userID)
-		}
+	if client.lastTyping == nil {
+		client.lastTyping = make(map[string]time.Time)
+	}
+	if event.Typing {
+		client.lastTyping[event.ConversationID] = now
+	} else {
+		delete(client.lastTyping, event.ConversationID)
 	}
-	h.mu.RUnlock()
 
-	// 第二阶段：写锁下删除失效连接
-	if len(userIDsToDelete) > 0 {
-		h.mu.Lock()
-		for _, userID := range userIDsToDelete {
-			if client, ok := h.clients[userID]; ok {
-				delete(h.clients, userID)
-				close(client.Send)
-			}
+	event.UserID = client.UserID
+	h.SendEvent("typing", event.ConversationID, participantIDs, event, client.ID)
+}
+
+// containsUser 判断用户是否在列表中
+func containsUser(userIDs []string, userID string) bool {
+	for _, id := range userIDs {
+		if id == userID {
+			return true
 		}
-		h.mu.Unlock()
 	}
+	return false
 }
 
-// SendMessage 发送消息到会话
-func (h *MessagingWSHub) SendMessage(conversationID string, message interface{}, excludeUserID string) {
-	broadcast := &MessageBroadcast{
-		ConversationID: conversationID,
-		Message:        message,
-		ExcludeUserID:  excludeUserID,
+// handleRemoteBroadcast 处理其他实例发布的消息，本实例发布的已在本地投递过
+func (h *MessagingWSHub) handleRemoteBroadcast(payload []byte) {
+	var env messagingEnvelope
+	if err := json.Unmarshal(payload, &env); err != nil || env.Instance == h.instanceID {
+		return
+	}
+	h.broadcast <- &MessageBroadcast{
+		ConversationID:  env.ConversationID,
+		ParticipantIDs:  env.ParticipantIDs,
+		ExcludeClientID: env.ExcludeClientID,
+		payload:         env.Payload,
 	}
-	h.broadcast <- broadcast
+}
+
+// marshalMessagingFrame 序列化推送给客户端的消息帧
+func marshalMessagingFrame(msgType string, data interface{}) ([]byte, error) {
+	return json.Marshal(MessageWSMessage{
+		Type:      msgType,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
 }
 
 // HandleMessagingWebSocket WebSocket连接处理器
@@ -258,16 +451,20 @@ func (c *MessagingWSClient) readPump() {
 		}
 
 		// 处理客户端消息（如typing状态）
-		var wsMsg MessageWSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
+		var frame messagingClientFrame
+		if err := json.Unmarshal(message, &frame); err != nil {
 			continue
 		}
 
 		// 处理不同类型的消息
-		switch wsMsg.Type {
+		switch frame.Type {
 		case "typing":
 			// 广播typing状态给会话其他参与者
-			// TODO: 实现typing广播
+			var event TypingEvent
+			if err := json.Unmarshal(frame.Data, &event); err != nil {
+				continue
+			}
+			c.Hub.relayTyping(c, event)
 		}
 	}
 }
@@ -0,0 +1,223 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Qingyu_backend/service/shared/auth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessagingTestServer(t *testing.T, hub *MessagingWSHub) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws/messages", hub.HandleMessagingWebSocket)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newMessagingTestJWT() *MockJWTService {
+	jwt := new(MockJWTService)
+	for _, user := range []string{"user-a", "user-b", "user-c"} {
+		jwt.On("ValidateToken", mock.Anything, "token-"+strings.TrimPrefix(user, "user-")).
+			Return(&auth.TokenClaims{UserID: user}, nil)
+	}
+	return jwt
+}
+
+func dialMessaging(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/messages"
+	header := http.Header{"Authorization": []string{"Bearer " + token}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readMessagingFrames 读取一次写入的全部消息（排队的消息以换行分隔合并写入）
+func readMessagingFrames(t *testing.T, conn *websocket.Conn) []MessageWSMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var frames []MessageWSMessage
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		var msg MessageWSMessage
+		require.NoError(t, json.Unmarshal(line, &msg))
+		frames = append(frames, msg)
+	}
+	return frames
+}
+
+func expectMessagingContent(t *testing.T, conn *websocket.Conn, content string) {
+	t.Helper()
+	frames := readMessagingFrames(t, conn)
+	require.Len(t, frames, 1)
+	assert.Equal(t, "new_message", frames[0].Type)
+	assert.Equal(t, content, frames[0].Data.(map[string]interface{})["content"])
+}
+
+func expectNoMessaging(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, _, err := conn.ReadMessage()
+	assert.Error(t, err, "unexpected message delivered")
+}
+
+func TestMessagingWSHub_RoutesToParticipantsAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newHub := func() *MessagingWSHub {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		hub := NewMessagingWSHub(newMessagingTestJWT(), NewRedisMessagingBroker(client, "messaging:events"))
+		t.Cleanup(hub.Close)
+		return hub
+	}
+	hubA, hubB := newHub(), newHub()
+	serverA, serverB := newMessagingTestServer(t, hubA), newMessagingTestServer(t, hubB)
+
+	// user-a 在实例 A 上有两台设备，user-b 连接实例 B，user-c 不在会话中
+	alicePhone := dialMessaging(t, serverA, "token-a")
+	aliceLaptop := dialMessaging(t, serverA, "token-a")
+	bob := dialMessaging(t, serverB, "token-b")
+	carol := dialMessaging(t, serverA, "token-c")
+	require.Eventually(t, func() bool {
+		return hubA.ConnectionCount("user-a") == 2 && hubA.ConnectionCount("user-c") == 1 && hubB.ConnectionCount("user-b") == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	participants := []string{"user-a", "user-b"}
+
+	// 消息经实例 A 发出：user-a 的两台设备都收到，实例 B 上的 user-b 通过 Redis 收到
+	hubA.SendMessage("conv-1", participants, map[string]interface{}{"content": "在吗"}, "")
+	expectMessagingContent(t, alicePhone, "在吗")
+	expectMessagingContent(t, aliceLaptop, "在吗")
+	expectMessagingContent(t, bob, "在吗")
+
+	// 非参与者收不到
+	expectNoMessaging(t, carol)
+
+	// 一台设备断开不影响另一台
+	alicePhone.Close()
+	require.Eventually(t, func() bool { return hubA.ConnectionCount("user-a") == 1 }, 3*time.Second, 10*time.Millisecond)
+	hubB.SendMessage("conv-1", participants, map[string]interface{}{"content": "还在吗"}, "")
+	expectMessagingContent(t, aliceLaptop, "还在吗")
+}
+
+func TestMessagingWSHub_ExcludesOnlySendingConnection(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newHub := func() *MessagingWSHub {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		hub := NewMessagingWSHub(newMessagingTestJWT(), NewRedisMessagingBroker(client, "messaging:events"))
+		t.Cleanup(hub.Close)
+		return hub
+	}
+	hubA, hubB := newHub(), newHub()
+	serverA, serverB := newMessagingTestServer(t, hubA), newMessagingTestServer(t, hubB)
+
+	// user-a 的手机连接实例 A，电脑连接实例 B
+	alicePhone := dialMessaging(t, serverA, "token-a")
+	aliceLaptop := dialMessaging(t, serverB, "token-a")
+	bob := dialMessaging(t, serverB, "token-b")
+	require.Eventually(t, func() bool {
+		return hubA.ConnectionCount("user-a") == 1 && hubB.ConnectionCount("user-a") == 1 && hubB.ConnectionCount("user-b") == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	hubA.mu.RLock()
+	var phoneID string
+	for id := range hubA.clients["user-a"] {
+		phoneID = id
+	}
+	hubA.mu.RUnlock()
+
+	// 手机发出的消息：发送连接自身收不到，发送者的另一台设备与接收者都收到
+	hubA.SendMessage("conv-1", []string{"user-a", "user-b"}, map[string]interface{}{"content": "出发了"}, phoneID)
+	expectMessagingContent(t, aliceLaptop, "出发了")
+	expectMessagingContent(t, bob, "出发了")
+	expectNoMessaging(t, alicePhone)
+}
+
+func TestMessagingWSHub_WithoutBrokerDeliversLocally(t *testing.T) {
+	hub := NewMessagingWSHub(newMessagingTestJWT(), nil)
+	server := newMessagingTestServer(t, hub)
+
+	alice := dialMessaging(t, server, "token-a")
+	carol := dialMessaging(t, server, "token-c")
+	require.Eventually(t, func() bool {
+		return hub.ConnectionCount("user-a") == 1 && hub.ConnectionCount("user-c") == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	hub.SendMessage("conv-1", []string{"user-a", "user-b"}, map[string]interface{}{"content": "你好"}, "")
+	expectMessagingContent(t, alice, "你好")
+	expectNoMessaging(t, carol)
+}
+
+// staticParticipants 固定的会话参与者
+type staticParticipants map[string][]string
+
+func (s staticParticipants) ConversationParticipants(ctx context.Context, conversationID string) ([]string, error) {
+	ids, ok := s[conversationID]
+	if !ok {
+		return nil, errors.New("conversation not found")
+	}
+	return ids, nil
+}
+
+func sendTyping(t *testing.T, conn *websocket.Conn, conversationID string, typing bool) {
+	t.Helper()
+	frame := map[string]interface{}{
+		"type": "typing",
+		"data": map[string]interface{}{"conversationId": conversationID, "typing": typing},
+	}
+	require.NoError(t, conn.WriteJSON(frame))
+}
+
+func TestMessagingWSHub_RelaysTyping(t *testing.T) {
+	hub := NewMessagingWSHub(newMessagingTestJWT(), nil)
+	hub.SetParticipantResolver(staticParticipants{"conv-1": {"user-a", "user-b"}})
+	server := newMessagingTestServer(t, hub)
+
+	alice := dialMessaging(t, server, "token-a")
+	bob := dialMessaging(t, server, "token-b")
+	carol := dialMessaging(t, server, "token-c")
+	require.Eventually(t, func() bool {
+		return hub.ConnectionCount("user-a") == 1 && hub.ConnectionCount("user-b") == 1 && hub.ConnectionCount("user-c") == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	sendTyping(t, alice, "conv-1", true)
+	frames := readMessagingFrames(t, bob)
+	require.Len(t, frames, 1)
+	assert.Equal(t, "typing", frames[0].Type)
+	data := frames[0].Data.(map[string]interface{})
+	assert.Equal(t, "conv-1", data["conversationId"])
+	assert.Equal(t, "user-a", data["userId"])
+	assert.Equal(t, true, data["typing"])
+
+	// 节流时间内重复的“正在输入”不转发，停止输入立即转发
+	sendTyping(t, alice, "conv-1", true)
+	sendTyping(t, alice, "conv-1", false)
+	frames = readMessagingFrames(t, bob)
+	require.Len(t, frames, 1)
+	assert.Equal(t, false, frames[0].Data.(map[string]interface{})["typing"])
+
+	// 非参与者的 typing 与未知会话被忽略，发送者自己收不到
+	sendTyping(t, carol, "conv-1", true)
+	sendTyping(t, alice, "conv-x", true)
+	expectNoMessaging(t, bob)
+	expectNoMessaging(t, alice)
+}
@@ -43,6 +43,8 @@ type RepositoryFactory interface {
 	// 设定百科相关Repository
 	CreateCharacterRepository() writer.CharacterRepository
 	CreateLocationRepository() writer.LocationRepository
+	CreateItemRepository() writer.ItemRepository
+	CreateOrganizationRepository() writer.OrganizationRepository
 	CreateTimelineRepository() writer.TimelineRepository
 	CreateTimelineEventRepository() writer.TimelineEventRepository
 	CreateOutlineRepository() writer.OutlineRepository
@@ -86,6 +88,7 @@ type RepositoryFactory interface {
 	CreateWalletRepository() FinanceInterfaces.WalletRepository
 	CreateMembershipRepository() FinanceInterfaces.MembershipRepository
 	CreateAuthorRevenueRepository() FinanceInterfaces.AuthorRevenueRepository
+	CreateTipRepository() FinanceInterfaces.TipRepository
 
 	// Admin相关Repository
 	CreateAuditRepository() adminInterfaces.AuditRepository
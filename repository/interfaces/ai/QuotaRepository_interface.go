@@ -13,6 +13,10 @@ type QuotaRepository interface {
 	CreateQuota(ctx context.Context, quota *aiModels.UserQuota) error
 	GetQuotaByUserID(ctx context.Context, userID string, quotaType aiModels.QuotaType) (*aiModels.UserQuota, error)
 	UpdateQuota(ctx context.Context, quota *aiModels.UserQuota) error
+	// DeductQuota 原子扣减配额：仅当配额可用且剩余量不少于 amount 时扣减，否则返回 ErrInsufficientQuota；返回扣减后的配额
+	DeductQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, amount int) (*aiModels.UserQuota, error)
+	// AdjustUsedQuota 原子调整已用配额（正数补扣、负数退回），不检查余额；返回调整后的配额
+	AdjustUsedQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, delta int) (*aiModels.UserQuota, error)
 	DeleteQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType) error
 
 	// 批量操作
@@ -24,6 +28,13 @@ type QuotaRepository interface {
 	GetTransactionsByUserID(ctx context.Context, userID string, limit, offset int) ([]*aiModels.QuotaTransaction, error)
 	GetTransactionsByTimeRange(ctx context.Context, userID string, startTime, endTime time.Time) ([]*aiModels.QuotaTransaction, error)
 
+	// 配额预留（预授权）
+	CreateReservation(ctx context.Context, reservation *aiModels.QuotaReservation) error
+	GetReservation(ctx context.Context, reservationID string) (*aiModels.QuotaReservation, error)
+	// CloseReservation 将待结算的预留置为终态，预留已关闭时返回 false（保证只结算一次）
+	CloseReservation(ctx context.Context, reservationID string, status aiModels.ReservationStatus, actualTokens, settledAmount int) (bool, error)
+	ListExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*aiModels.QuotaReservation, error)
+
 	// 统计查询
 	GetQuotaStatistics(ctx context.Context, userID string) (*QuotaStatistics, error)
 	GetTotalConsumption(ctx context.Context, userID string, quotaType aiModels.QuotaType, startTime, endTime time.Time) (int, error)
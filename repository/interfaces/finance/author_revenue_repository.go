@@ -3,6 +3,7 @@ package finance
 import (
 	financeModel "Qingyu_backend/models/finance"
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -17,6 +18,20 @@ type AuthorRevenueRepository interface {
 	BatchUpdateEarnings(ctx context.Context, earningIDs []primitive.ObjectID, updates map[string]interface{}) error
 	GetEarningsByAuthor(ctx context.Context, authorID string, page, pageSize int) ([]*financeModel.AuthorEarning, int64, error)
 	GetEarningsByBook(ctx context.Context, bookID primitive.ObjectID, page, pageSize int) ([]*financeModel.AuthorEarning, int64, error)
+	// CreateEarningIfAbsent 按来源ID幂等创建收入记录，已存在时返回 false
+	CreateEarningIfAbsent(ctx context.Context, earning *financeModel.AuthorEarning) (bool, error)
+	// ListUnsettledAuthors 列出在 end 之前有未结算收入的作者
+	ListUnsettledAuthors(ctx context.Context, end time.Time) ([]string, error)
+	// ListUnsettledEarnings 列出作者在 end 之前的全部未结算收入（含往期结算后补记的收入）
+	ListUnsettledEarnings(ctx context.Context, authorID string, end time.Time) ([]*financeModel.AuthorEarning, error)
+	// GetOldestUnsettledEarningTime 获取 end 之前最早一笔未结算收入的发生时间，没有时返回零值
+	GetOldestUnsettledEarningTime(ctx context.Context, end time.Time) (time.Time, error)
+
+	// 版税合同管理
+	CreateContract(ctx context.Context, contract *financeModel.RoyaltyContract) error
+	GetContract(ctx context.Context, contractID primitive.ObjectID) (*financeModel.RoyaltyContract, error)
+	UpdateContract(ctx context.Context, contractID primitive.ObjectID, updates map[string]interface{}) error
+	ListAuthorContracts(ctx context.Context, authorID string) ([]*financeModel.RoyaltyContract, error)
 
 	// 提现申请管理
 	CreateWithdrawalRequest(ctx context.Context, request *financeModel.WithdrawalRequest) error
@@ -24,6 +39,8 @@ type AuthorRevenueRepository interface {
 	ListWithdrawalRequests(ctx context.Context, filter map[string]interface{}, page, pageSize int) ([]*financeModel.WithdrawalRequest, int64, error)
 	UpdateWithdrawalRequest(ctx context.Context, requestID primitive.ObjectID, updates map[string]interface{}) error
 	GetUserWithdrawalRequests(ctx context.Context, userID string, page, pageSize int) ([]*financeModel.WithdrawalRequest, int64, error)
+	// ListUnoffsetWithdrawals 列出 before 之前已批准、未经钱包扣款且尚未被结算抵扣的提现
+	ListUnoffsetWithdrawals(ctx context.Context, userID string, before time.Time) ([]*financeModel.WithdrawalRequest, error)
 
 	// 结算管理
 	CreateSettlement(ctx context.Context, settlement *financeModel.Settlement) error
@@ -32,6 +49,10 @@ type AuthorRevenueRepository interface {
 	UpdateSettlement(ctx context.Context, settlementID primitive.ObjectID, updates map[string]interface{}) error
 	GetAuthorSettlements(ctx context.Context, authorID string, page, pageSize int) ([]*financeModel.Settlement, int64, error)
 	GetPendingSettlements(ctx context.Context) ([]*financeModel.Settlement, error)
+	// CreateSettlementIfAbsent 按作者与结算周期幂等创建结算记录，已存在时返回 false
+	CreateSettlementIfAbsent(ctx context.Context, settlement *financeModel.Settlement) (bool, error)
+	// GetSettlementByPeriod 获取作者某周期的结算记录，不存在时返回 nil
+	GetSettlementByPeriod(ctx context.Context, authorID, period string) (*financeModel.Settlement, error)
 
 	// 收入统计
 	GetRevenueStatistics(ctx context.Context, authorID string, period string, limit int) ([]*financeModel.RevenueStatistics, error)
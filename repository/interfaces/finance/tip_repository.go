@@ -0,0 +1,27 @@
+package finance
+
+import (
+	financeModel "Qingyu_backend/models/finance"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TipRepository 打赏仓储接口
+type TipRepository interface {
+	// 打赏记录
+	CreateTip(ctx context.Context, tip *financeModel.Tip) error
+	ListBookTips(ctx context.Context, bookID primitive.ObjectID, page, pageSize int) ([]*financeModel.Tip, int64, error)
+	ListUserTips(ctx context.Context, userID string, page, pageSize int) ([]*financeModel.Tip, int64, error)
+
+	// 粉丝榜
+	// IncrementBookFan 累加读者在某书某周期的粉丝值、打赏次数与金额，不存在时创建
+	IncrementBookFan(ctx context.Context, tip *financeModel.Tip, period string) error
+	// ListTopFans 按粉丝值降序列出某书某周期的粉丝
+	ListTopFans(ctx context.Context, bookID primitive.ObjectID, period string, limit int) ([]*financeModel.BookFan, error)
+	// GetBookFan 获取读者在某书某周期的粉丝记录，不存在时返回 nil
+	GetBookFan(ctx context.Context, bookID primitive.ObjectID, period, userID string) (*financeModel.BookFan, error)
+
+	// IncrementBookFanValue 累加书籍的粉丝值（书城榜单计算使用）
+	IncrementBookFanValue(ctx context.Context, bookID primitive.ObjectID, fanValue int64) error
+}
@@ -0,0 +1,38 @@
+package social
+
+import (
+	"context"
+	"time"
+
+	"Qingyu_backend/models/social"
+)
+
+// AchievementRepository 成就仓储接口
+type AchievementRepository interface {
+	// ========== 幂等记录 ==========
+
+	// MarkApplied 记录事件指纹，指纹已存在时返回 false（事件已计入）
+	MarkApplied(ctx context.Context, fingerprint, userID, counterID string, at time.Time) (bool, error)
+
+	// UnmarkApplied 撤销事件指纹（计数失败时回滚，允许后续重试）
+	UnmarkApplied(ctx context.Context, fingerprint string) error
+
+	// ========== 进度 ==========
+
+	// IncrementProgress 累加计数器并返回累加后的值
+	IncrementProgress(ctx context.Context, userID, counterID string, delta int64, at time.Time) (int64, error)
+
+	// ListProgress 获取用户全部计数器进度
+	ListProgress(ctx context.Context, userID string) ([]*social.AchievementProgress, error)
+
+	// ========== 已解锁成就 ==========
+
+	// AwardAchievement 颁发成就，已颁发过时返回 false
+	AwardAchievement(ctx context.Context, achievement *social.UserAchievement) (bool, error)
+
+	// ListUserAchievements 获取用户已解锁的成就
+	ListUserAchievements(ctx context.Context, userID string) ([]*social.UserAchievement, error)
+
+	// Health 健康检查
+	Health(ctx context.Context) error
+}
@@ -0,0 +1,21 @@
+package writer
+
+import (
+	"Qingyu_backend/models/writer"
+	"context"
+)
+
+// ItemRepository 物品Repository接口
+type ItemRepository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, item *writer.Item) error
+	FindByID(ctx context.Context, itemID string) (*writer.Item, error)
+	FindByProjectID(ctx context.Context, projectID string) ([]*writer.Item, error)
+	FindByParentID(ctx context.Context, parentID string) ([]*writer.Item, error)
+	FindByOwnerID(ctx context.Context, projectID, ownerID string) ([]*writer.Item, error)
+	Update(ctx context.Context, item *writer.Item) error
+	Delete(ctx context.Context, itemID string) error
+
+	// 辅助方法
+	CountByProjectID(ctx context.Context, projectID string) (int64, error)
+}
@@ -0,0 +1,32 @@
+package writer
+
+import (
+	"Qingyu_backend/models/writer"
+	"context"
+)
+
+// OrganizationRepository 组织Repository接口
+type OrganizationRepository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, org *writer.Organization) error
+	FindByID(ctx context.Context, orgID string) (*writer.Organization, error)
+	FindByProjectID(ctx context.Context, projectID string) ([]*writer.Organization, error)
+	FindByParentID(ctx context.Context, parentID string) ([]*writer.Organization, error)
+	Update(ctx context.Context, org *writer.Organization) error
+	Delete(ctx context.Context, orgID string) error
+
+	// 成员管理
+	AddMember(ctx context.Context, orgID, characterID string) error
+	RemoveMember(ctx context.Context, orgID, characterID string) error
+	FindByMemberID(ctx context.Context, projectID, characterID string) ([]*writer.Organization, error)
+
+	// 组织关系管理
+	CreateRelation(ctx context.Context, relation *writer.OrgRelation) error
+	FindRelations(ctx context.Context, projectID string, orgID *string) ([]*writer.OrgRelation, error)
+	FindRelationByID(ctx context.Context, relationID string) (*writer.OrgRelation, error)
+	DeleteRelation(ctx context.Context, relationID string) error
+	DeleteRelationsByOrgID(ctx context.Context, orgID string) error
+
+	// 辅助方法
+	CountByProjectID(ctx context.Context, projectID string) (int64, error)
+}
@@ -18,6 +18,7 @@ import (
 type MongoQuotaRepository struct {
 	quotaCollection       *mongo.Collection
 	transactionCollection *mongo.Collection
+	reservationCollection *mongo.Collection
 }
 
 // NewMongoQuotaRepository 创建MongoDB配额Repository
@@ -25,6 +26,7 @@ func NewMongoQuotaRepository(db *mongo.Database) ai.QuotaRepository {
 	return &MongoQuotaRepository{
 		quotaCollection:       db.Collection(aiModels.UserQuota{}.CollectionName()),
 		transactionCollection: db.Collection(aiModels.QuotaTransaction{}.CollectionName()),
+		reservationCollection: db.Collection(aiModels.QuotaReservation{}.CollectionName()),
 	}
 }
 
@@ -102,6 +104,74 @@ func (r *MongoQuotaRepository) UpdateQuota(ctx context.Context, quota *aiModels.
 	return nil
 }
 
+// DeductQuota 原子扣减配额，余额检查与扣减在同一次条件更新中完成，避免并发请求超额
+func (r *MongoQuotaRepository) DeductQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, amount int) (*aiModels.UserQuota, error) {
+	filter := bson.M{
+		"user_id":         userID,
+		"quota_type":      quotaType,
+		"status":          aiModels.QuotaStatusActive,
+		"remaining_quota": bson.M{"$gte": amount},
+	}
+
+	quota, err := r.incUsedQuota(ctx, filter, amount)
+	if err == mongo.ErrNoDocuments {
+		return nil, aiModels.ErrInsufficientQuota
+	}
+	return quota, err
+}
+
+// AdjustUsedQuota 原子调整已用配额
+func (r *MongoQuotaRepository) AdjustUsedQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, delta int) (*aiModels.UserQuota, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"quota_type": quotaType,
+	}
+
+	quota, err := r.incUsedQuota(ctx, filter, delta)
+	if err == mongo.ErrNoDocuments {
+		return nil, aiModels.ErrQuotaNotFound
+	}
+	return quota, err
+}
+
+// incUsedQuota 以 $inc 增减已用与剩余配额，并按调整后的剩余量同步用尽状态
+func (r *MongoQuotaRepository) incUsedQuota(ctx context.Context, filter bson.M, delta int) (*aiModels.UserQuota, error) {
+	update := bson.M{
+		"$inc": bson.M{
+			"used_quota":      delta,
+			"remaining_quota": -delta,
+		},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var quota aiModels.UserQuota
+	if err := r.quotaCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&quota); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, err
+		}
+		return nil, fmt.Errorf("更新配额失败: %w", err)
+	}
+
+	var status aiModels.QuotaStatus
+	statusFilter := bson.M{"_id": quota.ID, "status": quota.Status}
+	switch {
+	case quota.Status == aiModels.QuotaStatusActive && quota.RemainingQuota <= 0:
+		status = aiModels.QuotaStatusExhausted
+		statusFilter["remaining_quota"] = bson.M{"$lte": 0}
+	case quota.Status == aiModels.QuotaStatusExhausted && quota.RemainingQuota > 0:
+		status = aiModels.QuotaStatusActive
+		statusFilter["remaining_quota"] = bson.M{"$gt": 0}
+	default:
+		return &quota, nil
+	}
+	// 以剩余量为条件切换状态，并发调整后不会写入过期的状态；失败不影响本次扣减，下次调整时再同步
+	if _, err := r.quotaCollection.UpdateOne(ctx, statusFilter, bson.M{"$set": bson.M{"status": status}}); err == nil {
+		quota.Status = status
+	}
+	return &quota, nil
+}
+
 // DeleteQuota 删除配额
 func (r *MongoQuotaRepository) DeleteQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType) error {
 	filter := bson.M{
@@ -247,6 +317,95 @@ func (r *MongoQuotaRepository) GetTransactionsByTimeRange(ctx context.Context, u
 	return transactions, nil
 }
 
+// CreateReservation 创建配额预留
+func (r *MongoQuotaRepository) CreateReservation(ctx context.Context, reservation *aiModels.QuotaReservation) error {
+	if reservation.ID.IsZero() {
+		reservation.ID = primitive.NewObjectID()
+	}
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
+
+	_, err := r.reservationCollection.InsertOne(ctx, reservation)
+	if err != nil {
+		return fmt.Errorf("创建配额预留失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetReservation 获取配额预留
+func (r *MongoQuotaRepository) GetReservation(ctx context.Context, reservationID string) (*aiModels.QuotaReservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return nil, aiModels.ErrReservationNotFound
+	}
+
+	var reservation aiModels.QuotaReservation
+	err = r.reservationCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&reservation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, aiModels.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("查询配额预留失败: %w", err)
+	}
+
+	return &reservation, nil
+}
+
+// CloseReservation 关闭待结算的配额预留
+func (r *MongoQuotaRepository) CloseReservation(ctx context.Context, reservationID string, status aiModels.ReservationStatus, actualTokens, settledAmount int) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return false, aiModels.ErrReservationNotFound
+	}
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": aiModels.ReservationStatusReserved,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":         status,
+			"actual_tokens":  actualTokens,
+			"settled_amount": settledAmount,
+			"settled_at":     time.Now(),
+		},
+	}
+
+	result, err := r.reservationCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("关闭配额预留失败: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// ListExpiredReservations 获取已过期仍未结算的预留
+func (r *MongoQuotaRepository) ListExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*aiModels.QuotaReservation, error) {
+	filter := bson.M{
+		"status":     aiModels.ReservationStatusReserved,
+		"expires_at": bson.M{"$lt": before},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.reservationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询过期配额预留失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*aiModels.QuotaReservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, fmt.Errorf("解析配额预留失败: %w", err)
+	}
+
+	return reservations, nil
+}
+
 // GetQuotaStatistics 获取配额统计信息
 func (r *MongoQuotaRepository) GetQuotaStatistics(ctx context.Context, userID string) (*ai.QuotaStatistics, error) {
 	// 获取所有配额
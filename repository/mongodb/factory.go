@@ -154,6 +154,16 @@ func (f *MongoRepositoryFactory) CreateLocationRepository() writerRepo.LocationR
 	return mongoWriter.NewLocationRepository(f.database)
 }
 
+// CreateItemRepository 创建物品Repository
+func (f *MongoRepositoryFactory) CreateItemRepository() writerRepo.ItemRepository {
+	return mongoWriter.NewItemRepository(f.database)
+}
+
+// CreateOrganizationRepository 创建组织Repository
+func (f *MongoRepositoryFactory) CreateOrganizationRepository() writerRepo.OrganizationRepository {
+	return mongoWriter.NewOrganizationRepository(f.database)
+}
+
 // CreateTimelineRepository 创建时间线Repository
 func (f *MongoRepositoryFactory) CreateTimelineRepository() writerRepo.TimelineRepository {
 	return mongoWriter.NewTimelineRepository(f.database)
@@ -334,6 +344,11 @@ func (f *MongoRepositoryFactory) CreateAuthorRevenueRepository() financeRepo.Aut
 	return mongoFinance.NewAuthorRevenueRepository(f.database)
 }
 
+// CreateTipRepository 创建打赏Repository
+func (f *MongoRepositoryFactory) CreateTipRepository() financeRepo.TipRepository {
+	return mongoFinance.NewTipRepository(f.database)
+}
+
 // ========== Admin Module Repositories ==========
 
 // CreateAuditRepository 创建审核记录Repository (使用新的 admin 模块)
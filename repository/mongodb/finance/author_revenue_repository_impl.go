@@ -23,6 +23,7 @@ type AuthorRevenueRepositoryImpl struct {
 	detailCollection     *mongo.Collection
 	statisticsCollection *mongo.Collection
 	taxInfoCollection    *mongo.Collection
+	contractCollection   *mongo.Collection
 }
 
 // NewAuthorRevenueRepository 创建作者收入Repository
@@ -35,6 +36,7 @@ func NewAuthorRevenueRepository(db *mongo.Database) financeInterfaces.AuthorReve
 		detailCollection:     db.Collection("revenue_details"),
 		statisticsCollection: db.Collection("revenue_statistics"),
 		taxInfoCollection:    db.Collection("tax_info"),
+		contractCollection:   db.Collection("royalty_contracts"),
 	}
 }
 
@@ -139,6 +141,185 @@ func (r *AuthorRevenueRepositoryImpl) GetEarningsByBook(ctx context.Context, boo
 	return r.ListEarnings(ctx, filter, page, pageSize)
 }
 
+// CreateEarningIfAbsent 按来源ID与收入类型幂等创建收入记录
+// 使用 upsert + $setOnInsert，已存在相同来源的记录时不做修改并返回 false
+func (r *AuthorRevenueRepositoryImpl) CreateEarningIfAbsent(ctx context.Context, earning *financeModel.AuthorEarning) (bool, error) {
+	if earning.SourceID == "" {
+		return false, fmt.Errorf("收入记录缺少来源ID")
+	}
+
+	now := time.Now()
+	if earning.ID.IsZero() {
+		earning.ID = primitive.NewObjectID()
+	}
+	earning.CreatedAt = now
+	earning.UpdatedAt = now
+
+	filter := bson.M{"source_id": earning.SourceID, "type": earning.Type}
+	result, err := r.earningCollection.UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": earning},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("创建收入记录失败: %w", err)
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+// unsettledEarningFilter end 之前的未结算收入过滤条件，历史记录缺少 earned_at 时按创建时间归属周期
+func unsettledEarningFilter(end time.Time) bson.M {
+	return bson.M{
+		"is_settled": false,
+		"$or": bson.A{
+			bson.M{"earned_at": bson.M{"$lt": end}},
+			bson.M{"earned_at": bson.M{"$exists": false}, "created_at": bson.M{"$lt": end}},
+		},
+	}
+}
+
+// ListUnsettledAuthors 列出在 end 之前有未结算收入的作者
+func (r *AuthorRevenueRepositoryImpl) ListUnsettledAuthors(ctx context.Context, end time.Time) ([]string, error) {
+	values, err := r.earningCollection.Distinct(ctx, "author_id", unsettledEarningFilter(end))
+	if err != nil {
+		return nil, fmt.Errorf("查询待结算作者失败: %w", err)
+	}
+
+	authorIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if authorID, ok := value.(string); ok && authorID != "" {
+			authorIDs = append(authorIDs, authorID)
+		}
+	}
+
+	return authorIDs, nil
+}
+
+// ListUnsettledEarnings 列出作者在 end 之前的全部未结算收入
+func (r *AuthorRevenueRepositoryImpl) ListUnsettledEarnings(ctx context.Context, authorID string, end time.Time) ([]*financeModel.AuthorEarning, error) {
+	filter := unsettledEarningFilter(end)
+	filter["author_id"] = authorID
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.earningCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询未结算收入失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var earnings []*financeModel.AuthorEarning
+	if err := cursor.All(ctx, &earnings); err != nil {
+		return nil, fmt.Errorf("解析未结算收入失败: %w", err)
+	}
+
+	return earnings, nil
+}
+
+// GetOldestUnsettledEarningTime 获取 end 之前最早一笔未结算收入的发生时间，没有时返回零值
+// 分别按 earned_at 与（缺少 earned_at 的历史记录）created_at 查询最早记录后取较早者
+func (r *AuthorRevenueRepositoryImpl) GetOldestUnsettledEarningTime(ctx context.Context, end time.Time) (time.Time, error) {
+	queries := []struct {
+		filter bson.M
+		field  string
+	}{
+		{bson.M{"is_settled": false, "earned_at": bson.M{"$lt": end}}, "earned_at"},
+		{bson.M{"is_settled": false, "earned_at": bson.M{"$exists": false}, "created_at": bson.M{"$lt": end}}, "created_at"},
+	}
+
+	var oldest time.Time
+	for _, query := range queries {
+		var earning financeModel.AuthorEarning
+		opts := options.FindOne().SetSort(bson.D{{Key: query.field, Value: 1}})
+		err := r.earningCollection.FindOne(ctx, query.filter, opts).Decode(&earning)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("查询最早未结算收入失败: %w", err)
+		}
+
+		at := earning.EarnedAt
+		if at.IsZero() {
+			at = earning.CreatedAt
+		}
+		if oldest.IsZero() || at.Before(oldest) {
+			oldest = at
+		}
+	}
+
+	return oldest, nil
+}
+
+// ============ 版税合同管理 ============
+
+// CreateContract 创建版税合同
+func (r *AuthorRevenueRepositoryImpl) CreateContract(ctx context.Context, contract *financeModel.RoyaltyContract) error {
+	now := time.Now()
+	contract.CreatedAt = now
+	contract.UpdatedAt = now
+
+	result, err := r.contractCollection.InsertOne(ctx, contract)
+	if err != nil {
+		return fmt.Errorf("创建版税合同失败: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		contract.ID = oid
+	}
+
+	return nil
+}
+
+// GetContract 获取版税合同
+func (r *AuthorRevenueRepositoryImpl) GetContract(ctx context.Context, contractID primitive.ObjectID) (*financeModel.RoyaltyContract, error) {
+	var contract financeModel.RoyaltyContract
+	err := r.contractCollection.FindOne(ctx, bson.M{"_id": contractID}).Decode(&contract)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("版税合同不存在: %s", contractID.Hex())
+		}
+		return nil, fmt.Errorf("查询版税合同失败: %w", err)
+	}
+
+	return &contract, nil
+}
+
+// UpdateContract 更新版税合同
+func (r *AuthorRevenueRepositoryImpl) UpdateContract(ctx context.Context, contractID primitive.ObjectID, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	result, err := r.contractCollection.UpdateOne(ctx, bson.M{"_id": contractID}, bson.M{"$set": updates})
+	if err != nil {
+		return fmt.Errorf("更新版税合同失败: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("版税合同不存在: %s", contractID.Hex())
+	}
+
+	return nil
+}
+
+// ListAuthorContracts 列出作者的全部版税合同（含书籍级与作者级），按生效时间倒序
+func (r *AuthorRevenueRepositoryImpl) ListAuthorContracts(ctx context.Context, authorID string) ([]*financeModel.RoyaltyContract, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "effective_from", Value: -1}})
+
+	cursor, err := r.contractCollection.Find(ctx, bson.M{"author_id": authorID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询版税合同失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var contracts []*financeModel.RoyaltyContract
+	if err := cursor.All(ctx, &contracts); err != nil {
+		return nil, fmt.Errorf("解析版税合同失败: %w", err)
+	}
+
+	return contracts, nil
+}
+
 // ============ 提现申请管理 ============
 
 // CreateWithdrawalRequest 创建提现申请
@@ -222,6 +403,32 @@ func (r *AuthorRevenueRepositoryImpl) GetUserWithdrawalRequests(ctx context.Cont
 	return r.ListWithdrawalRequests(ctx, filter, page, pageSize)
 }
 
+// ListUnoffsetWithdrawals 列出 before 之前已批准、未经钱包扣款且尚未被结算抵扣的提现
+// 经钱包发起的提现（transaction_id 非空）已在申请时扣减余额，不参与结算抵扣
+func (r *AuthorRevenueRepositoryImpl) ListUnoffsetWithdrawals(ctx context.Context, userID string, before time.Time) ([]*financeModel.WithdrawalRequest, error) {
+	filter := bson.M{
+		"user_id":        userID,
+		"status":         bson.M{"$in": bson.A{financeModel.WithdrawStatusApproved, financeModel.WithdrawStatusCompleted}},
+		"created_at":     bson.M{"$lt": before},
+		"transaction_id": bson.M{"$in": bson.A{nil, ""}},
+		"settlement_id":  bson.M{"$exists": false},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.withdrawalCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询待抵扣提现失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var requests []*financeModel.WithdrawalRequest
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, fmt.Errorf("解析待抵扣提现失败: %w", err)
+	}
+
+	return requests, nil
+}
+
 // ============ 结算管理 ============
 
 // CreateSettlement 创建结算记录
@@ -323,6 +530,37 @@ func (r *AuthorRevenueRepositoryImpl) GetPendingSettlements(ctx context.Context)
 	return settlements, nil
 }
 
+// CreateSettlementIfAbsent 按作者与结算周期幂等创建结算记录
+// 依赖 (author_id, period) 唯一索引，重复插入时返回 false
+func (r *AuthorRevenueRepositoryImpl) CreateSettlementIfAbsent(ctx context.Context, settlement *financeModel.Settlement) (bool, error) {
+	if settlement.Period == "" {
+		return false, fmt.Errorf("结算记录缺少结算周期")
+	}
+
+	if err := r.CreateSettlement(ctx, settlement); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetSettlementByPeriod 获取作者某周期的结算记录，不存在时返回 nil
+func (r *AuthorRevenueRepositoryImpl) GetSettlementByPeriod(ctx context.Context, authorID, period string) (*financeModel.Settlement, error) {
+	var settlement financeModel.Settlement
+	err := r.settlementCollection.FindOne(ctx, bson.M{"author_id": authorID, "period": period}).Decode(&settlement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询结算记录失败: %w", err)
+	}
+
+	return &settlement, nil
+}
+
 // ============ 收入统计 ============
 
 // GetRevenueStatistics 获取收入统计
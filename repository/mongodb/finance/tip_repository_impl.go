@@ -0,0 +1,160 @@
+package finance
+
+import (
+	financeModel "Qingyu_backend/models/finance"
+	"context"
+	"fmt"
+	"time"
+
+	financeInterfaces "Qingyu_backend/repository/interfaces/finance"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TipRepositoryImpl 打赏Repository实现
+type TipRepositoryImpl struct {
+	db             *mongo.Database
+	tipCollection  *mongo.Collection
+	fanCollection  *mongo.Collection
+	bookCollection *mongo.Collection
+}
+
+// NewTipRepository 创建打赏Repository
+func NewTipRepository(db *mongo.Database) financeInterfaces.TipRepository {
+	return &TipRepositoryImpl{
+		db:             db,
+		tipCollection:  db.Collection("tips"),
+		fanCollection:  db.Collection("book_fans"),
+		bookCollection: db.Collection("books"),
+	}
+}
+
+// ============ 打赏记录 ============
+
+// CreateTip 创建打赏记录
+func (r *TipRepositoryImpl) CreateTip(ctx context.Context, tip *financeModel.Tip) error {
+	if tip.ID.IsZero() {
+		tip.ID = primitive.NewObjectID()
+	}
+	if tip.CreatedAt.IsZero() {
+		tip.CreatedAt = time.Now()
+	}
+
+	if _, err := r.tipCollection.InsertOne(ctx, tip); err != nil {
+		return fmt.Errorf("创建打赏记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// ListBookTips 获取书籍的打赏记录
+func (r *TipRepositoryImpl) ListBookTips(ctx context.Context, bookID primitive.ObjectID, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	return r.listTips(ctx, bson.M{"book_id": bookID}, page, pageSize)
+}
+
+// ListUserTips 获取读者的打赏记录
+func (r *TipRepositoryImpl) ListUserTips(ctx context.Context, userID string, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	return r.listTips(ctx, bson.M{"user_id": userID}, page, pageSize)
+}
+
+func (r *TipRepositoryImpl) listTips(ctx context.Context, filter bson.M, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.tipCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询打赏记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tips []*financeModel.Tip
+	if err := cursor.All(ctx, &tips); err != nil {
+		return nil, 0, fmt.Errorf("解析打赏记录失败: %w", err)
+	}
+
+	total, err := r.tipCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计打赏记录失败: %w", err)
+	}
+
+	return tips, total, nil
+}
+
+// ============ 粉丝榜 ============
+
+// IncrementBookFan 累加读者在某书某周期的粉丝值
+// 使用 upsert + $inc，(book_id, period, user_id) 唯一索引保证并发打赏只生成一条记录
+func (r *TipRepositoryImpl) IncrementBookFan(ctx context.Context, tip *financeModel.Tip, period string) error {
+	now := time.Now()
+	filter := bson.M{"book_id": tip.BookID, "period": period, "user_id": tip.UserID}
+	update := bson.M{
+		"$inc": bson.M{
+			"fan_value":          tip.FanValue,
+			"tip_count":          1,
+			"total_amount_cents": tip.Amount,
+		},
+		"$max": bson.M{"last_tipped_at": tip.CreatedAt},
+		"$set": bson.M{"updated_at": now},
+	}
+
+	if _, err := r.fanCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("更新粉丝榜失败: %w", err)
+	}
+
+	return nil
+}
+
+// ListTopFans 按粉丝值降序列出某书某周期的粉丝，粉丝值相同时先达到者在前
+func (r *TipRepositoryImpl) ListTopFans(ctx context.Context, bookID primitive.ObjectID, period string, limit int) ([]*financeModel.BookFan, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "fan_value", Value: -1}, {Key: "last_tipped_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.fanCollection.Find(ctx, bson.M{"book_id": bookID, "period": period}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询粉丝榜失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var fans []*financeModel.BookFan
+	if err := cursor.All(ctx, &fans); err != nil {
+		return nil, fmt.Errorf("解析粉丝榜失败: %w", err)
+	}
+
+	return fans, nil
+}
+
+// GetBookFan 获取读者在某书某周期的粉丝记录，不存在时返回 nil
+func (r *TipRepositoryImpl) GetBookFan(ctx context.Context, bookID primitive.ObjectID, period, userID string) (*financeModel.BookFan, error) {
+	var fan financeModel.BookFan
+	err := r.fanCollection.FindOne(ctx, bson.M{"book_id": bookID, "period": period, "user_id": userID}).Decode(&fan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询粉丝记录失败: %w", err)
+	}
+
+	return &fan, nil
+}
+
+// IncrementBookFanValue 累加书籍的粉丝值
+func (r *TipRepositoryImpl) IncrementBookFanValue(ctx context.Context, bookID primitive.ObjectID, fanValue int64) error {
+	result, err := r.bookCollection.UpdateOne(ctx,
+		bson.M{"_id": bookID},
+		bson.M{"$inc": bson.M{"fan_value": fanValue}})
+	if err != nil {
+		return fmt.Errorf("更新书籍粉丝值失败: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("书籍不存在: %s", bookID.Hex())
+	}
+
+	return nil
+}
@@ -140,7 +140,7 @@ func (r *WalletRepositoryImpl) UpdateBalance(ctx context.Context, userID string,
 		ctx,
 		bson.M{"user_id": safeUserID},
 		bson.M{
-			"$inc": bson.M{"balance": amount},
+			"$inc": bson.M{"balance_cents": amount},
 			"$set": bson.M{"updated_at": time.Now()},
 		},
 	)
@@ -175,10 +175,10 @@ func (r *WalletRepositoryImpl) UpdateBalanceWithCheck(ctx context.Context, userI
 		ctx,
 		bson.M{
 			"user_id": safeUserID,
-			"balance": bson.M{"$gte": -amount}, // 确保扣款后余额 >= 0
+			"balance_cents": bson.M{"$gte": -amount}, // 确保扣款后余额 >= 0
 		},
 		bson.M{
-			"$inc": bson.M{"balance": amount},
+			"$inc": bson.M{"balance_cents": amount},
 			"$set": bson.M{"updated_at": time.Now()},
 		},
 	)
@@ -0,0 +1,48 @@
+package finance_test
+
+import (
+	"context"
+	"testing"
+
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/shared/types"
+	financeRepo "Qingyu_backend/repository/mongodb/finance"
+	"Qingyu_backend/test/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWalletRepository_UpdateBalanceUsesBalanceCents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	repo := financeRepo.NewWalletRepository(db)
+	ctx := context.Background()
+	userID := primitive.NewObjectID().Hex()
+
+	missing, err := repo.GetWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, repo.CreateWallet(ctx, &financeModel.Wallet{UserID: userID}))
+	require.NoError(t, repo.UpdateBalance(ctx, userID, 1696))
+	require.NoError(t, repo.UpdateBalanceWithCheck(ctx, userID, -500))
+	assert.Error(t, repo.UpdateBalanceWithCheck(ctx, userID, -5000))
+
+	wallet, err := repo.GetWallet(ctx, userID)
+	require.NoError(t, err)
+	require.NotNil(t, wallet)
+	assert.Equal(t, types.Money(1196), wallet.Balance)
+
+	var raw bson.M
+	require.NoError(t, db.Collection("wallets").FindOne(ctx, bson.M{"user_id": userID}).Decode(&raw))
+	assert.EqualValues(t, 1196, raw["balance_cents"])
+	assert.NotContains(t, raw, "balance")
+}
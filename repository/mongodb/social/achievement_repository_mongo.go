@@ -0,0 +1,166 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"Qingyu_backend/models/social"
+	socialRepo "Qingyu_backend/repository/interfaces/social"
+)
+
+// MongoAchievementRepository MongoDB成就仓储实现
+// achievement_progress 存计数器，user_achievements 存已解锁成就，
+// achievement_events 以事件指纹为 _id 保证同一事件只计一次
+type MongoAchievementRepository struct {
+	progress     *mongo.Collection
+	achievements *mongo.Collection
+	applied      *mongo.Collection
+}
+
+// NewMongoAchievementRepository 创建MongoDB成就仓储实例
+func NewMongoAchievementRepository(db *mongo.Database) socialRepo.AchievementRepository {
+	repo := &MongoAchievementRepository{
+		progress:     db.Collection("achievement_progress"),
+		achievements: db.Collection("user_achievements"),
+		applied:      db.Collection("achievement_events"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := repo.progress.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "counter_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to create achievement_progress indexes: %v\n", err)
+	}
+
+	_, err = repo.achievements.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "achievement_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to create user_achievements indexes: %v\n", err)
+	}
+
+	_, err = repo.applied.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to create achievement_events indexes: %v\n", err)
+	}
+
+	return repo
+}
+
+// MarkApplied 记录事件指纹，指纹已存在时返回 false
+func (r *MongoAchievementRepository) MarkApplied(ctx context.Context, fingerprint, userID, counterID string, at time.Time) (bool, error) {
+	_, err := r.applied.InsertOne(ctx, bson.M{
+		"_id":        fingerprint,
+		"user_id":    userID,
+		"counter_id": counterID,
+		"applied_at": at,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark achievement event: %w", err)
+	}
+	return true, nil
+}
+
+// UnmarkApplied 撤销事件指纹
+func (r *MongoAchievementRepository) UnmarkApplied(ctx context.Context, fingerprint string) error {
+	if _, err := r.applied.DeleteOne(ctx, bson.M{"_id": fingerprint}); err != nil {
+		return fmt.Errorf("failed to unmark achievement event: %w", err)
+	}
+	return nil
+}
+
+// IncrementProgress 累加计数器并返回累加后的值
+func (r *MongoAchievementRepository) IncrementProgress(ctx context.Context, userID, counterID string, delta int64, at time.Time) (int64, error) {
+	filter := bson.M{"user_id": userID, "counter_id": counterID}
+	update := bson.M{
+		"$inc": bson.M{"value": delta},
+		"$set": bson.M{"updated_at": at},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var progress social.AchievementProgress
+	err := r.progress.FindOneAndUpdate(ctx, filter, update, opts).Decode(&progress)
+	if mongo.IsDuplicateKeyError(err) {
+		// 并发 upsert 时另一方已创建计数器，重试即为普通更新
+		err = r.progress.FindOneAndUpdate(ctx, filter, update, opts).Decode(&progress)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment achievement progress: %w", err)
+	}
+	return progress.Value, nil
+}
+
+// ListProgress 获取用户全部计数器进度
+func (r *MongoAchievementRepository) ListProgress(ctx context.Context, userID string) ([]*social.AchievementProgress, error) {
+	cursor, err := r.progress.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list achievement progress: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var progress []*social.AchievementProgress
+	if err := cursor.All(ctx, &progress); err != nil {
+		return nil, fmt.Errorf("failed to decode achievement progress: %w", err)
+	}
+	return progress, nil
+}
+
+// AwardAchievement 颁发成就，已颁发过时返回 false
+func (r *MongoAchievementRepository) AwardAchievement(ctx context.Context, achievement *social.UserAchievement) (bool, error) {
+	filter := bson.M{"user_id": achievement.UserID, "achievement_id": achievement.AchievementID}
+	update := bson.M{"$setOnInsert": bson.M{"unlocked_at": achievement.UnlockedAt}}
+
+	result, err := r.achievements.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to award achievement: %w", err)
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// ListUserAchievements 获取用户已解锁的成就
+func (r *MongoAchievementRepository) ListUserAchievements(ctx context.Context, userID string) ([]*social.UserAchievement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "unlocked_at", Value: -1}})
+	cursor, err := r.achievements.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user achievements: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []*social.UserAchievement
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, fmt.Errorf("failed to decode user achievements: %w", err)
+	}
+	return achievements, nil
+}
+
+// Health 健康检查
+func (r *MongoAchievementRepository) Health(ctx context.Context) error {
+	if _, err := r.progress.EstimatedDocumentCount(ctx); err != nil {
+		return fmt.Errorf("achievement_progress collection health check failed: %w", err)
+	}
+	return nil
+}
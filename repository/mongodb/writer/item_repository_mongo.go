@@ -0,0 +1,117 @@
+package writer
+
+import (
+	"context"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/repository/mongodb/base"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ItemRepositoryMongo Item Repository的MongoDB实现
+type ItemRepositoryMongo struct {
+	*base.BaseMongoRepository
+}
+
+// NewItemRepository 创建ItemRepository实例
+func NewItemRepository(db *mongo.Database) writerRepo.ItemRepository {
+	return &ItemRepositoryMongo{
+		BaseMongoRepository: base.NewBaseMongoRepository(db, "items"),
+	}
+}
+
+// Create 创建物品
+func (r *ItemRepositoryMongo) Create(ctx context.Context, item *writer.Item) error {
+	if item.ID == "" {
+		item.ID = r.GenerateID()
+	}
+	item.BeforeCreate()
+
+	if _, err := r.GetCollection().InsertOne(ctx, item); err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "create item failed", err)
+	}
+	return nil
+}
+
+// FindByID 根据ID查询物品
+func (r *ItemRepositoryMongo) FindByID(ctx context.Context, itemID string) (*writer.Item, error) {
+	var item writer.Item
+	err := r.GetCollection().FindOne(ctx, bson.M{"_id": itemID}).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewRepositoryError(errors.RepositoryErrorNotFound, "item not found", err)
+		}
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find item failed", err)
+	}
+	return &item, nil
+}
+
+// FindByProjectID 查询项目下的所有物品
+func (r *ItemRepositoryMongo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Item, error) {
+	return r.find(ctx, bson.M{"project_id": projectID})
+}
+
+// FindByParentID 查询父物品下的子物品
+func (r *ItemRepositoryMongo) FindByParentID(ctx context.Context, parentID string) ([]*writer.Item, error) {
+	return r.find(ctx, bson.M{"parent_id": parentID})
+}
+
+// FindByOwnerID 查询角色持有的物品
+func (r *ItemRepositoryMongo) FindByOwnerID(ctx context.Context, projectID, ownerID string) ([]*writer.Item, error) {
+	return r.find(ctx, bson.M{"project_id": projectID, "owner_id": ownerID})
+}
+
+func (r *ItemRepositoryMongo) find(ctx context.Context, filter bson.M) ([]*writer.Item, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.GetCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find items failed", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []*writer.Item
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "decode items failed", err)
+	}
+	return items, nil
+}
+
+// Update 更新物品
+func (r *ItemRepositoryMongo) Update(ctx context.Context, item *writer.Item) error {
+	item.BeforeUpdate()
+
+	result, err := r.GetCollection().UpdateOne(ctx, bson.M{"_id": item.ID}, bson.M{"$set": item})
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "update item failed", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "item not found", nil)
+	}
+	return nil
+}
+
+// Delete 删除物品
+func (r *ItemRepositoryMongo) Delete(ctx context.Context, itemID string) error {
+	result, err := r.GetCollection().DeleteOne(ctx, bson.M{"_id": itemID})
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "delete item failed", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "item not found", nil)
+	}
+	return nil
+}
+
+// CountByProjectID 统计项目下的物品数量
+func (r *ItemRepositoryMongo) CountByProjectID(ctx context.Context, projectID string) (int64, error) {
+	count, err := r.GetCollection().CountDocuments(ctx, bson.M{"project_id": projectID})
+	if err != nil {
+		return 0, errors.NewRepositoryError(errors.RepositoryErrorInternal, "count items failed", err)
+	}
+	return count, nil
+}
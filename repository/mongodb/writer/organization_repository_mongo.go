@@ -0,0 +1,212 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/repository/mongodb/base"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationRepositoryMongo Organization Repository的MongoDB实现
+type OrganizationRepositoryMongo struct {
+	*base.BaseMongoRepository                   // 嵌入organization集合的基类
+	relationCollection        *mongo.Collection // relation集合单独管理
+}
+
+// NewOrganizationRepository 创建OrganizationRepository实例
+func NewOrganizationRepository(db *mongo.Database) writerRepo.OrganizationRepository {
+	return &OrganizationRepositoryMongo{
+		BaseMongoRepository: base.NewBaseMongoRepository(db, "organizations"),
+		relationCollection:  db.Collection("organization_relations"),
+	}
+}
+
+// Create 创建组织
+func (r *OrganizationRepositoryMongo) Create(ctx context.Context, org *writer.Organization) error {
+	if org.ID == "" {
+		org.ID = r.GenerateID()
+	}
+	org.BeforeCreate()
+
+	if _, err := r.GetCollection().InsertOne(ctx, org); err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "create organization failed", err)
+	}
+	return nil
+}
+
+// FindByID 根据ID查询组织
+func (r *OrganizationRepositoryMongo) FindByID(ctx context.Context, orgID string) (*writer.Organization, error) {
+	var org writer.Organization
+	err := r.GetCollection().FindOne(ctx, bson.M{"_id": orgID}).Decode(&org)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization not found", err)
+		}
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find organization failed", err)
+	}
+	return &org, nil
+}
+
+// FindByProjectID 查询项目下的所有组织
+func (r *OrganizationRepositoryMongo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Organization, error) {
+	return r.find(ctx, bson.M{"project_id": projectID})
+}
+
+// FindByParentID 查询上级组织下的子组织
+func (r *OrganizationRepositoryMongo) FindByParentID(ctx context.Context, parentID string) ([]*writer.Organization, error) {
+	return r.find(ctx, bson.M{"parent_id": parentID})
+}
+
+// FindByMemberID 查询角色所属的组织
+func (r *OrganizationRepositoryMongo) FindByMemberID(ctx context.Context, projectID, characterID string) ([]*writer.Organization, error) {
+	return r.find(ctx, bson.M{"project_id": projectID, "members": characterID})
+}
+
+func (r *OrganizationRepositoryMongo) find(ctx context.Context, filter bson.M) ([]*writer.Organization, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.GetCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find organizations failed", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orgs []*writer.Organization
+	if err = cursor.All(ctx, &orgs); err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "decode organizations failed", err)
+	}
+	return orgs, nil
+}
+
+// Update 更新组织
+func (r *OrganizationRepositoryMongo) Update(ctx context.Context, org *writer.Organization) error {
+	org.BeforeUpdate()
+
+	result, err := r.GetCollection().UpdateOne(ctx, bson.M{"_id": org.ID}, bson.M{"$set": org})
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "update organization failed", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization not found", nil)
+	}
+	return nil
+}
+
+// Delete 删除组织
+func (r *OrganizationRepositoryMongo) Delete(ctx context.Context, orgID string) error {
+	result, err := r.GetCollection().DeleteOne(ctx, bson.M{"_id": orgID})
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "delete organization failed", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization not found", nil)
+	}
+	return nil
+}
+
+// AddMember 添加成员（已是成员时不重复添加）
+func (r *OrganizationRepositoryMongo) AddMember(ctx context.Context, orgID, characterID string) error {
+	return r.updateMembers(ctx, orgID, bson.M{"$addToSet": bson.M{"members": characterID}})
+}
+
+// RemoveMember 移除成员
+func (r *OrganizationRepositoryMongo) RemoveMember(ctx context.Context, orgID, characterID string) error {
+	return r.updateMembers(ctx, orgID, bson.M{"$pull": bson.M{"members": characterID}})
+}
+
+func (r *OrganizationRepositoryMongo) updateMembers(ctx context.Context, orgID string, update bson.M) error {
+	update["$set"] = bson.M{"updated_at": time.Now()}
+	result, err := r.GetCollection().UpdateOne(ctx, bson.M{"_id": orgID}, update)
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "update organization members failed", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization not found", nil)
+	}
+	return nil
+}
+
+// CreateRelation 创建组织关系
+func (r *OrganizationRepositoryMongo) CreateRelation(ctx context.Context, relation *writer.OrgRelation) error {
+	if relation.ID == "" {
+		relation.ID = r.GenerateID()
+	}
+	relation.CreatedAt = time.Now()
+
+	if _, err := r.relationCollection.InsertOne(ctx, relation); err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "create organization relation failed", err)
+	}
+	return nil
+}
+
+// FindRelations 查询组织关系
+func (r *OrganizationRepositoryMongo) FindRelations(ctx context.Context, projectID string, orgID *string) ([]*writer.OrgRelation, error) {
+	filter := bson.M{"project_id": projectID}
+	if orgID != nil {
+		filter["$or"] = []bson.M{
+			{"from_org_id": *orgID},
+			{"to_org_id": *orgID},
+		}
+	}
+
+	cursor, err := r.relationCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find organization relations failed", err)
+	}
+	defer cursor.Close(ctx)
+
+	var relations []*writer.OrgRelation
+	if err = cursor.All(ctx, &relations); err != nil {
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "decode organization relations failed", err)
+	}
+	return relations, nil
+}
+
+// FindRelationByID 根据ID查询组织关系
+func (r *OrganizationRepositoryMongo) FindRelationByID(ctx context.Context, relationID string) (*writer.OrgRelation, error) {
+	var relation writer.OrgRelation
+	err := r.relationCollection.FindOne(ctx, bson.M{"_id": relationID}).Decode(&relation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization relation not found", err)
+		}
+		return nil, errors.NewRepositoryError(errors.RepositoryErrorInternal, "find organization relation failed", err)
+	}
+	return &relation, nil
+}
+
+// DeleteRelation 删除组织关系
+func (r *OrganizationRepositoryMongo) DeleteRelation(ctx context.Context, relationID string) error {
+	result, err := r.relationCollection.DeleteOne(ctx, bson.M{"_id": relationID})
+	if err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "delete organization relation failed", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.NewRepositoryError(errors.RepositoryErrorNotFound, "organization relation not found", nil)
+	}
+	return nil
+}
+
+// DeleteRelationsByOrgID 删除与组织相关的全部关系
+func (r *OrganizationRepositoryMongo) DeleteRelationsByOrgID(ctx context.Context, orgID string) error {
+	filter := bson.M{"$or": []bson.M{{"from_org_id": orgID}, {"to_org_id": orgID}}}
+	if _, err := r.relationCollection.DeleteMany(ctx, filter); err != nil {
+		return errors.NewRepositoryError(errors.RepositoryErrorInternal, "delete organization relations failed", err)
+	}
+	return nil
+}
+
+// CountByProjectID 统计项目下的组织数量
+func (r *OrganizationRepositoryMongo) CountByProjectID(ctx context.Context, projectID string) (int64, error) {
+	count, err := r.GetCollection().CountDocuments(ctx, bson.M{"project_id": projectID})
+	if err != nil {
+		return 0, errors.NewRepositoryError(errors.RepositoryErrorInternal, "count organizations failed", err)
+	}
+	return count, nil
+}
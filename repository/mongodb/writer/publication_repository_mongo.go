@@ -233,6 +233,80 @@ func (r *MongoPublicationRepository) FindPublishedByProjectID(ctx context.Contex
 	return r.toRecord(&doc), nil
 }
 
+// FindScheduledByProjectID 查询项目下设置了发布时间且尚未发布的记录，按发布时间升序
+func (r *MongoPublicationRepository) FindScheduledByProjectID(ctx context.Context, projectID string) ([]*serviceInterfaces.PublicationRecord, error) {
+	filter := bson.M{
+		"project_id":     projectID,
+		"scheduled_time": bson.M{"$ne": nil},
+		"status": bson.M{"$in": []string{
+			serviceInterfaces.PublicationStatusPending,
+			serviceInterfaces.PublicationStatusScheduled,
+			serviceInterfaces.PublicationStatusPublishing,
+		}},
+	}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "scheduled_time", Value: 1},
+		{Key: "metadata.chapter_number", Value: 1},
+	})
+	return r.findAll(ctx, filter, opts)
+}
+
+// FindDueScheduled 查询到期的定时发布记录，以及认领后超过 staleBefore 仍未完成的记录
+// 结果按发布时间和章节号升序，保证补发时章节顺序不乱
+func (r *MongoPublicationRepository) FindDueScheduled(ctx context.Context, dueBefore, staleBefore time.Time, limit int) ([]*serviceInterfaces.PublicationRecord, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": serviceInterfaces.PublicationStatusScheduled, "scheduled_time": bson.M{"$lte": dueBefore}},
+		{"status": serviceInterfaces.PublicationStatusPublishing, "updated_at": bson.M{"$lte": staleBefore}},
+	}}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "scheduled_time", Value: 1},
+		{Key: "metadata.chapter_number", Value: 1},
+	})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	return r.findAll(ctx, filter, opts)
+}
+
+// UpdateIfUnchanged 仅当记录的状态和更新时间仍为读取时的值时才写入，返回是否写入成功
+// 用于定时发布的认领、改期和取消，防止多实例或并发请求重复处理同一记录
+func (r *MongoPublicationRepository) UpdateIfUnchanged(ctx context.Context, record *serviceInterfaces.PublicationRecord, expectedStatus string, expectedUpdatedAt time.Time) (bool, error) {
+	doc, err := r.toDocument(ctx, record)
+	if err != nil {
+		return false, err
+	}
+	if doc.ID.IsZero() {
+		return false, fmt.Errorf("publication record id is required")
+	}
+
+	// 按存储精度（毫秒）比较更新时间
+	filter := bson.M{"_id": doc.ID, "status": expectedStatus, "updated_at": primitive.NewDateTimeFromTime(expectedUpdatedAt)}
+	result, err := r.collection.ReplaceOne(ctx, filter, doc)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *MongoPublicationRepository) findAll(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*serviceInterfaces.PublicationRecord, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []publicationRecordDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	records := make([]*serviceInterfaces.PublicationRecord, 0, len(docs))
+	for i := range docs {
+		records = append(records, r.toRecord(&docs[i]))
+	}
+	return records, nil
+}
+
 func (r *MongoPublicationRepository) toDocument(ctx context.Context, record *serviceInterfaces.PublicationRecord) (*publicationRecordDocument, error) {
 	projectID, err := r.resolveProjectID(ctx, record)
 	if err != nil {
@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Qingyu_backend/models/writer"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWritingLedgerRepository 写作流水 Mongo 仓储
+// 流水存放在 writing_ledgers，作者目标存放在 writing_goals，回填时只读 file_revisions
+type MongoWritingLedgerRepository struct {
+	ledgers   *mongo.Collection
+	goals     *mongo.Collection
+	revisions *mongo.Collection
+}
+
+// NewMongoWritingLedgerRepository 创建写作流水仓储
+func NewMongoWritingLedgerRepository(db *mongo.Database) *MongoWritingLedgerRepository {
+	return &MongoWritingLedgerRepository{
+		ledgers:   db.Collection("writing_ledgers"),
+		goals:     db.Collection("writing_goals"),
+		revisions: db.Collection("file_revisions"),
+	}
+}
+
+// EnsureIndexes 创建索引
+// author_id+project_id+date 唯一，保证并发累加只落在同一条流水上
+func (r *MongoWritingLedgerRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.ledgers.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "project_id", Value: 1},
+				{Key: "date", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "date", Value: 1},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("创建写作流水索引失败: %w", err)
+	}
+
+	_, err = r.goals.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "author_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("创建写作目标索引失败: %w", err)
+	}
+	return nil
+}
+
+// Increment 累加某作者某项目某日的流水（不存在时创建）
+func (r *MongoWritingLedgerRepository) Increment(ctx context.Context, authorID, projectID, date string, added, deleted int, at time.Time) error {
+	filter := bson.M{"author_id": authorID, "project_id": projectID, "date": date}
+	update := bson.M{
+		"$inc": bson.M{
+			"words_added":   added,
+			"words_deleted": deleted,
+			"net_words":     added - deleted,
+			"save_count":    1,
+		},
+		"$max":         bson.M{"updated_at": at},
+		"$setOnInsert": bson.M{"source": writer.WritingLedgerSourceLive},
+	}
+	_, err := r.ledgers.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// 并发 upsert 时另一方已创建流水，重试即为普通更新
+		_, err = r.ledgers.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	}
+	return err
+}
+
+// InsertIfAbsent 仅当该作者、项目、日期尚无流水时写入，返回是否写入
+func (r *MongoWritingLedgerRepository) InsertIfAbsent(ctx context.Context, entry *writer.WritingLedgerEntry) (bool, error) {
+	filter := bson.M{"author_id": entry.AuthorID, "project_id": entry.ProjectID, "date": entry.Date}
+	update := bson.M{"$setOnInsert": bson.M{
+		"words_added":   entry.WordsAdded,
+		"words_deleted": entry.WordsDeleted,
+		"net_words":     entry.NetWords,
+		"save_count":    entry.SaveCount,
+		"source":        entry.Source,
+		"updated_at":    entry.UpdatedAt,
+	}}
+	result, err := r.ledgers.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// ListByAuthor 按日期闭区间查询作者流水
+func (r *MongoWritingLedgerRepository) ListByAuthor(ctx context.Context, authorID, fromDate, toDate string) ([]*writer.WritingLedgerEntry, error) {
+	filter := bson.M{
+		"author_id": authorID,
+		"date":      bson.M{"$gte": fromDate, "$lte": toDate},
+	}
+	cursor, err := r.ledgers.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*writer.WritingLedgerEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetGoal 获取作者写作目标，未设置时返回 nil
+func (r *MongoWritingLedgerRepository) GetGoal(ctx context.Context, authorID string) (*writer.WritingGoal, error) {
+	var goal writer.WritingGoal
+	if err := r.goals.FindOne(ctx, bson.M{"author_id": authorID}).Decode(&goal); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// SaveGoal 保存作者写作目标
+func (r *MongoWritingLedgerRepository) SaveGoal(ctx context.Context, goal *writer.WritingGoal) error {
+	_, err := r.goals.ReplaceOne(ctx, bson.M{"author_id": goal.AuthorID}, goal, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListProjectRevisions 按 node_id、version 升序返回项目的全部修订（不含快照内容）
+func (r *MongoWritingLedgerRepository) ListProjectRevisions(ctx context.Context, projectID string) ([]*writer.FileRevision, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "node_id", Value: 1}, {Key: "version", Value: 1}}).
+		SetProjection(bson.M{"snapshot": 0})
+	cursor, err := r.revisions.Find(ctx, bson.M{"project_id": projectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []*writer.FileRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -48,6 +48,8 @@ import (
 	searchService "Qingyu_backend/service/search"
 	searchengine "Qingyu_backend/service/search/engine"
 	searchprovider "Qingyu_backend/service/search/provider"
+	searchsuggest "Qingyu_backend/service/search/suggest"
+	searchsync "Qingyu_backend/service/search/sync"
 	sharedService "Qingyu_backend/service/shared"
 	statsService "Qingyu_backend/service/shared/stats"
 	sharedStorage "Qingyu_backend/service/shared/storage"
@@ -71,6 +73,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/olivere/elastic/v7"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -173,8 +176,17 @@ func RegisterRoutes(r *gin.Engine) {
 			authorRevenueAPI = financeApi.NewAuthorRevenueAPI(authorRevenueSvc)
 		}
 
+		// 获取打赏服务
+		tipSvc, tipErr := serviceContainer.GetTipService()
+		var tipAPI *financeApi.TipAPI
+		if tipErr != nil {
+			logger.Warn("获取打赏服务失败", zap.Error(tipErr))
+		} else {
+			tipAPI = financeApi.NewTipAPI(tipSvc)
+		}
+
 		// 注册财务路由
-		financeRouter.RegisterFinanceRoutes(v1, walletAPI, membershipAPI, authorRevenueAPI)
+		financeRouter.RegisterFinanceRoutes(v1, walletAPI, membershipAPI, authorRevenueAPI, tipAPI)
 		logger.Info("✓ 财务路由已注册到: /api/v1/finance/")
 		logger.Info("  - /api/v1/finance/wallet/* (钱包管理)")
 		if membershipAPI != nil {
@@ -183,6 +195,9 @@ func RegisterRoutes(r *gin.Engine) {
 		if authorRevenueAPI != nil {
 			logger.Info("  - /api/v1/finance/author/* (作者收入)")
 		}
+		if tipAPI != nil {
+			logger.Info("  - /api/v1/finance/tips/* (读者打赏)")
+		}
 		logger.Info("  - ⚠️  旧路由 /api/v1/shared/wallet/* 继续保留以向后兼容")
 	}
 
@@ -434,6 +449,12 @@ func RegisterRoutes(r *gin.Engine) {
 			conversationSvc := messagingService.NewConversationService(conversationRepo, messageRepo)
 			// 再创建MessageService（需要ConversationService）
 			messageSvc := messagingService.NewMessageService(messageRepo, conversationSvc)
+			// 新消息通知（跳过开启免打扰的成员）
+			if notifier, err := serviceContainer.GetNotificationService(); err == nil {
+				messageSvc.SetNotifier(notifier)
+			}
+			// typing 等临时事件需查询会话参与者
+			messagingWSHub.SetParticipantResolver(conversationSvc)
 
 			messageAPIV2 = socialApi.NewMessageAPIV2(messageSvc, conversationSvc, messagingWSHub)
 			logger.Info("✓ MessageAPIV2初始化完成")
@@ -447,6 +468,12 @@ func RegisterRoutes(r *gin.Engine) {
 		r.GET("/ws/messages", messagingWSHub.HandleMessagingWebSocket)
 		logger.Info("✓ WebSocket路由已注册: /ws/messages")
 	}
+	if collabWSHub, err := serviceContainer.GetCollabWSHub(); err == nil {
+		r.GET("/ws/writer/documents/:documentId/collab", collabWSHub.HandleCollabWebSocket)
+		logger.Info("✓ WebSocket路由已注册: /ws/writer/documents/:documentId/collab")
+	} else {
+		logger.Warn("CollabWSHub未配置", zap.Error(err))
+	}
 
 	// 注册统一社交路由
 	if commentAPI != nil || likeAPI != nil || collectionAPI != nil || reviewAPI != nil || booklistAPI != nil {
@@ -471,6 +498,15 @@ func RegisterRoutes(r *gin.Engine) {
 		logger.Info("  - /api/v1/social/follow/* (关注系统)")
 	}
 
+	// ============ 注册成就路由 ============
+	if achievementSvc, achievementErr := serviceContainer.GetAchievementService(); achievementErr != nil {
+		logger.Warn("成就服务未配置", zap.Error(achievementErr))
+	} else {
+		socialRouter.RegisterAchievementRoutes(v1, socialApi.NewAchievementAPI(achievementSvc))
+		logger.Info("✓ 成就路由已注册到: /api/v1/social/achievements")
+		logger.Info("  - /api/v1/admin/achievements/backfill (成就回填)")
+	}
+
 	// ============ 注册书单公开路由 (/api/v1/booklists) ============
 	// 前端 booklist 模块调用的路径，复用 social/booklist 处理器
 	if booklistAPI != nil {
@@ -674,6 +710,7 @@ func RegisterRoutes(r *gin.Engine) {
 		conceptRepo := mongoWriterRepo.NewConceptRepository(mongoDB)
 		draftService := internalAPIService.NewWriterDraftService(draftRepo)
 		conceptService := internalAPIService.NewConceptService(conceptRepo)
+		conceptService.SetEventBus(serviceContainer.GetEventBus())
 
 		// 向后兼容：初始化函数式handler依赖
 		internalAPI.InitDocumentHandlers(draftService)
@@ -717,6 +754,8 @@ func RegisterRoutes(r *gin.Engine) {
 		logger.Info("  - /api/v1/search/search (统一搜索)")
 		logger.Info("  - /api/v1/search/batch (批量搜索)")
 		logger.Info("  - /api/v1/search/health (健康检查)")
+		logger.Info("  - /api/v1/search/suggest (搜索联想)")
+		logger.Info("  - /api/v1/search/suggest/documents (文档联想，需认证)")
 	} else {
 		logger.Warn("⚠ 搜索服务初始化失败，跳过搜索路由注册")
 	}
@@ -1032,9 +1071,90 @@ func initSearchService(container *container.ServiceContainer, logger *zap.Logger
 		logger.Info("⚠ Elasticsearch 未配置或初始化失败，使用 MongoDB 搜索")
 	}
 
+	// 设置向量引擎，开启混合检索（关键词 + 语义召回）
+	if vectorEngine, embedder, err := container.GetVectorSearch(); err == nil {
+		searchSvc.SetVectorEngine(vectorEngine, embedder)
+		logger.Info("✓ 向量引擎已设置到 SearchService，混合检索已启用")
+	} else {
+		logger.Info("⚠ 向量引擎未配置，混合检索请求仅使用关键词结果", zap.Error(err))
+	}
+
+	// 设置搜索联想（内存字典树 + Redis 兜底）
+	initSearchSuggester(container, searchSvc, esEngine, logger)
+
 	return searchSvc, mongoEngine
 }
 
+// initSearchSuggester 初始化搜索联想，索引在后台加载，加载完成前由 Redis 兜底
+func initSearchSuggester(container *container.ServiceContainer, searchSvc *searchService.SearchService, esEngine searchengine.Engine, logger *zap.Logger) {
+	source, err := searchsuggest.NewMongoSource(container.GetMongoDB())
+	if err != nil {
+		logger.Warn("⚠ 创建联想数据源失败，跳过搜索联想", zap.Error(err))
+		return
+	}
+
+	var redisClient *redis.Client
+	if rc := container.GetRedisClient(); rc != nil {
+		redisClient, _ = rc.GetClient().(*redis.Client)
+	}
+
+	suggester, err := searchsuggest.NewSuggester(source, redisClient, searchsuggest.DefaultConfig())
+	if err != nil {
+		logger.Warn("⚠ 创建搜索联想服务失败", zap.Error(err))
+		return
+	}
+	searchSvc.SetSuggester(suggester)
+
+	go func() {
+		if err := suggester.Start(context.Background()); err != nil {
+			logger.Warn("⚠ 搜索联想索引首次加载失败，将定期重试", zap.Error(err))
+		}
+	}()
+	logger.Info("✓ 搜索联想已设置到 SearchService",
+		zap.Bool("redis_fallback", redisClient != nil),
+	)
+
+	startSearchSync(container, redisClient, esEngine, suggester, logger)
+}
+
+// startSearchSync 启动 MongoDB 变更流监听与同步 Worker，并注册 Suggester 接收增量变更
+// 变更流要求 MongoDB 副本集；Redis 不可用时不启动，联想索引仅依赖定期重建
+func startSearchSync(container *container.ServiceContainer, redisClient *redis.Client, esEngine searchengine.Engine, suggester *searchsuggest.Suggester, logger *zap.Logger) {
+	if redisClient == nil {
+		logger.Warn("⚠ Redis 不可用，搜索同步未启动，联想索引仅定期重建")
+		return
+	}
+
+	listener, err := searchsync.NewChangeStreamListener(container.GetMongoClient(), container.GetMongoDB(), redisClient, logger)
+	if err != nil {
+		logger.Warn("⚠ 创建变更流监听器失败，搜索同步未启动", zap.Error(err))
+		return
+	}
+	worker, err := searchsync.NewSyncWorker(container.GetMongoClient(), container.GetMongoDB(), redisClient, esEngine, logger, nil, nil)
+	if err != nil {
+		logger.Warn("⚠ 创建搜索同步 Worker 失败，搜索同步未启动", zap.Error(err))
+		return
+	}
+	worker.AddListener(suggester)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := worker.Start(ctx); err != nil {
+		cancel()
+		logger.Warn("⚠ 搜索同步 Worker 启动失败", zap.Error(err))
+		return
+	}
+	go func() {
+		if err := listener.WatchChanges(ctx); err != nil {
+			logger.Warn("⚠ 变更流监听异常退出", zap.Error(err))
+		}
+	}()
+	container.SetSearchSyncCancel(cancel)
+
+	logger.Info("✓ 搜索同步已启动，联想索引随变更事件增量更新",
+		zap.Bool("es_sync", esEngine != nil),
+	)
+}
+
 // initElasticsearch 初始化 Elasticsearch 客户端和引擎
 func initElasticsearch(logger *zap.Logger) (*searchService.SearchConfig, searchengine.Engine) {
 	cfg := config.GlobalConfig
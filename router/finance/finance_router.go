@@ -9,7 +9,7 @@ import (
 )
 
 // RegisterFinanceRoutes 注册所有财务相关路由
-func RegisterFinanceRoutes(r *gin.RouterGroup, walletAPI *financeApi.WalletAPI, membershipAPI *financeApi.MembershipAPI, authorRevenueAPI *financeApi.AuthorRevenueAPI) {
+func RegisterFinanceRoutes(r *gin.RouterGroup, walletAPI *financeApi.WalletAPI, membershipAPI *financeApi.MembershipAPI, authorRevenueAPI *financeApi.AuthorRevenueAPI, tipAPI *financeApi.TipAPI) {
 	// 财务路由需要认证
 	financeGroup := r.Group("/finance")
 	financeGroup.Use(auth.JWTAuth())
@@ -87,5 +87,22 @@ func RegisterFinanceRoutes(r *gin.RouterGroup, walletAPI *financeApi.WalletAPI,
 				authorGroup.PUT("/tax-info", authorRevenueAPI.UpdateTaxInfo)
 			}
 		}
+
+		// ========== 读者打赏 ==========
+		if tipAPI != nil {
+			tipGroup := financeGroup.Group("/tips")
+			{
+				// 礼物档位
+				tipGroup.GET("/gifts", tipAPI.GetGifts)
+
+				// 打赏与打赏记录
+				tipGroup.POST("", tipAPI.Tip)
+				tipGroup.GET("/mine", tipAPI.GetMyTips)
+				tipGroup.GET("/books/:bookId", tipAPI.GetBookTips)
+
+				// 书籍粉丝榜
+				tipGroup.GET("/books/:bookId/fans", tipAPI.GetFanLeaderboard)
+			}
+		}
 	}
 }
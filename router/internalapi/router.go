@@ -34,7 +34,7 @@ func RegisterInternalAPIRoutes(routerGroup *gin.RouterGroup, draftService *inter
 }
 
 // RegisterContextRoutes 注册内部上下文API路由
-// 供AI服务获取项目上下文数据（角色、大纲、文档内容、角色关系）
+// 供AI服务获取项目上下文数据（角色、大纲、文档内容、角色关系、物品、组织）
 func RegisterContextRoutes(v1 *gin.RouterGroup, aggregator *internalService.ContextAggregator) {
 	// 使用与现有内部AI API相同的认证中间件
 	internalGroup := v1.Group("/internal")
@@ -47,6 +47,8 @@ func RegisterContextRoutes(v1 *gin.RouterGroup, aggregator *internalService.Cont
 	internalGroup.GET("/projects/:id/characters", contextAPI.GetCharacters)
 	internalGroup.GET("/projects/:id/outline", contextAPI.GetOutline)
 	internalGroup.GET("/projects/:id/relations", contextAPI.GetCharacterRelations)
+	internalGroup.GET("/projects/:id/items", contextAPI.GetItems)
+	internalGroup.GET("/projects/:id/organizations", contextAPI.GetOrganizations)
 
 	// 文档内容路由
 	internalGroup.GET("/documents/:id/content", contextAPI.GetDocumentContent)
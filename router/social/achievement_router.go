@@ -0,0 +1,29 @@
+package social
+
+import (
+	"github.com/gin-gonic/gin"
+
+	socialApi "Qingyu_backend/api/v1/social"
+	"Qingyu_backend/internal/middleware/auth"
+)
+
+// RegisterAchievementRoutes 注册成就路由
+func RegisterAchievementRoutes(r *gin.RouterGroup, achievementAPI *socialApi.AchievementAPI) {
+	if achievementAPI == nil {
+		return
+	}
+
+	socialGroup := r.Group("/social")
+	socialGroup.Use(auth.JWTAuth())
+	{
+		socialGroup.GET("/achievements", achievementAPI.GetMyAchievements)
+		socialGroup.GET("/users/:userId/achievements", achievementAPI.GetUserAchievements)
+	}
+
+	adminGroup := r.Group("/admin/achievements")
+	adminGroup.Use(auth.JWTAuth())
+	adminGroup.Use(auth.RequireRole("admin"))
+	{
+		adminGroup.POST("/backfill", achievementAPI.BackfillAchievements)
+	}
+}
@@ -118,6 +118,21 @@ func RegisterSocialRoutes(r *gin.RouterGroup,
 			socialGroup.GET("/messages/conversations/:conversationId/messages", messageAPIV2.GetMessages)
 			socialGroup.POST("/messages/conversations/:conversationId/messages", messageAPIV2.SendMessage)
 			socialGroup.POST("/messages/conversations/:conversationId/read", messageAPIV2.MarkConversationRead)
+			socialGroup.PUT("/messages/conversations/:conversationId/mute", messageAPIV2.MuteConversation)
+
+			// 撤回、编辑与已读回执
+			socialGroup.POST("/messages/conversations/:conversationId/messages/:messageId/recall", messageAPIV2.RecallMessage)
+			socialGroup.PUT("/messages/conversations/:conversationId/messages/:messageId", messageAPIV2.EditMessage)
+			socialGroup.GET("/messages/conversations/:conversationId/messages/:messageId/edits", messageAPIV2.GetMessageEditHistory)
+			socialGroup.GET("/messages/conversations/:conversationId/messages/:messageId/receipts", messageAPIV2.GetReadReceipts)
+
+			// 群聊管理
+			socialGroup.POST("/messages/groups", messageAPIV2.CreateGroup)
+			socialGroup.POST("/messages/conversations/:conversationId/members", messageAPIV2.InviteGroupMembers)
+			socialGroup.DELETE("/messages/conversations/:conversationId/members/:userId", messageAPIV2.RemoveGroupMember)
+			socialGroup.POST("/messages/conversations/:conversationId/leave", messageAPIV2.LeaveGroup)
+			socialGroup.POST("/messages/conversations/:conversationId/transfer", messageAPIV2.TransferGroupOwnership)
+			socialGroup.PUT("/messages/conversations/:conversationId/admins/:userId", messageAPIV2.SetGroupAdmin)
 		}
 
 		// ========== 新增：书评系统 ==========
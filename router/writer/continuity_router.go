@@ -0,0 +1,18 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/writer"
+	"Qingyu_backend/service/interfaces"
+)
+
+// InitContinuityRoutes 初始化连贯性检查路由
+func InitContinuityRoutes(router *gin.RouterGroup, continuityService interfaces.ContinuityService) {
+	api := writer.NewContinuityApi(continuityService)
+
+	projectGroup := router.Group("/projects/:id")
+	{
+		projectGroup.GET("/continuity", api.CheckProject)
+	}
+}
@@ -11,5 +11,10 @@ func InitDashboardRouter(r *gin.RouterGroup, dashboardApi *writerApi.DashboardAp
 	dashboardGroup := r.Group("/dashboard")
 	{
 		dashboardGroup.GET("/stats", dashboardApi.GetDashboardStats)
+		dashboardGroup.GET("/output", dashboardApi.GetWritingOutput)
+		dashboardGroup.GET("/goals", dashboardApi.GetWritingGoal)
+		dashboardGroup.PUT("/goals", dashboardApi.UpdateWritingGoal)
+		dashboardGroup.PUT("/projects/:projectId/goals", dashboardApi.UpdateProjectGoals)
+		dashboardGroup.POST("/ledger/backfill", dashboardApi.BackfillWritingLedger)
 	}
 }
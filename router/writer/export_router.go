@@ -30,6 +30,10 @@ func InitExportRoutes(router *gin.RouterGroup, exportService interfaces.ExportSe
 	{
 		projectsGroup.GET("/:id/export", importExportApi.ExportProject) // 导出项目为ZIP（直接下载）
 		projectsGroup.POST("/import", importExportApi.ImportProject)    // 导入项目（上传ZIP）
+
+		// 稿件导入（TXT/DOCX/EPUB，按卷章拆分）
+		projectsGroup.POST("/import/manuscript/preview", importExportApi.PreviewManuscriptImport)
+		projectsGroup.POST("/import/manuscript", importExportApi.ImportManuscript)
 	}
 
 	// 导出任务管理路由
@@ -0,0 +1,29 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/writer"
+	"Qingyu_backend/service/interfaces"
+)
+
+// InitItemRoutes 初始化物品路由
+func InitItemRoutes(router *gin.RouterGroup, itemService interfaces.ItemService) {
+	api := writer.NewItemApi(itemService)
+
+	// 项目级别的物品路由
+	projectGroup := router.Group("/projects/:id")
+	{
+		projectGroup.POST("/items", api.CreateItem)
+		projectGroup.GET("/items", api.ListItems)
+		projectGroup.GET("/items/tree", api.GetItemTree)
+	}
+
+	// 物品级别的路由
+	itemGroup := router.Group("/items")
+	{
+		itemGroup.GET("/:itemId", api.GetItem)
+		itemGroup.PUT("/:itemId", api.UpdateItem)
+		itemGroup.DELETE("/:itemId", api.DeleteItem)
+	}
+}
@@ -0,0 +1,36 @@
+package writer
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Qingyu_backend/api/v1/writer"
+	"Qingyu_backend/service/interfaces"
+)
+
+// InitOrganizationRoutes 初始化组织路由
+func InitOrganizationRoutes(router *gin.RouterGroup, organizationService interfaces.OrganizationService) {
+	api := writer.NewOrganizationApi(organizationService)
+
+	// 项目级别的组织路由
+	projectGroup := router.Group("/projects/:id")
+	{
+		projectGroup.POST("/organizations", api.CreateOrganization)
+		projectGroup.GET("/organizations", api.ListOrganizations)
+		projectGroup.GET("/organizations/tree", api.GetOrganizationTree)
+		projectGroup.GET("/organizations/relations", api.ListOrganizationRelations)
+	}
+
+	// 组织级别的路由
+	orgGroup := router.Group("/organizations")
+	{
+		orgGroup.GET("/:orgId", api.GetOrganization)
+		orgGroup.PUT("/:orgId", api.UpdateOrganization)
+		orgGroup.DELETE("/:orgId", api.DeleteOrganization)
+
+		orgGroup.POST("/:orgId/members", api.AddOrganizationMember)
+		orgGroup.DELETE("/:orgId/members/:characterId", api.RemoveOrganizationMember)
+
+		orgGroup.POST("/relations", api.CreateOrganizationRelation)
+		orgGroup.DELETE("/relations/:relationId", api.DeleteOrganizationRelation)
+	}
+}
@@ -21,6 +21,7 @@ func InitPublishRoutes(router *gin.RouterGroup, publishService interfaces.Publis
 
 		// 发布记录
 		projectGroup.GET("/publications", api.GetPublicationRecords)
+		projectGroup.GET("/scheduled-publications", api.GetScheduledPublications)
 
 		// 批量发布文档
 		projectGroup.POST("/documents/batch-publish", api.BatchPublishDocuments)
@@ -37,5 +38,7 @@ func InitPublishRoutes(router *gin.RouterGroup, publishService interfaces.Publis
 	publicationsGroup := router.Group("/publications")
 	{
 		publicationsGroup.GET("/:id", api.GetPublicationRecord)
+		publicationsGroup.PUT("/:id/schedule", api.ReschedulePublication)
+		publicationsGroup.DELETE("/:id/schedule", api.CancelScheduledPublication)
 	}
 }
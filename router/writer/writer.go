@@ -190,6 +190,7 @@ func InitVersionRouter(r *gin.RouterGroup, versionApi *writer.VersionApi) {
 
 			// 版本恢复
 			versionGroup.POST("/:versionId/restore", versionApi.RestoreVersion)
+			versionGroup.POST("/:versionId/undo-restore", versionApi.UndoRestore)
 		}
 	}
 }
@@ -15,6 +15,9 @@ func InitWriterRoutes(
 	locationService interfaces.LocationService,
 	timelineService interfaces.TimelineService,
 	outlineService interfaces.OutlineService,
+	continuityService interfaces.ContinuityService,
+	itemService interfaces.ItemService,
+	organizationService interfaces.OrganizationService,
 ) {
 	zap.L().Info("InitWriterRoutes: 开始注册设定百科路由")
 
@@ -36,6 +39,18 @@ func InitWriterRoutes(
 			zap.L().Info("InitWriterRoutes: 地点路由注册完成")
 		}
 
+		// 物品管理路由
+		if itemService != nil {
+			InitItemRoutes(writerGroup, itemService)
+			zap.L().Info("InitWriterRoutes: 物品路由注册完成")
+		}
+
+		// 组织管理路由
+		if organizationService != nil {
+			InitOrganizationRoutes(writerGroup, organizationService)
+			zap.L().Info("InitWriterRoutes: 组织路由注册完成")
+		}
+
 		// 时间线管理路由
 		if timelineService != nil {
 			InitTimelineRoutes(writerGroup, timelineService)
@@ -47,6 +62,12 @@ func InitWriterRoutes(
 			InitOutlineRoutes(writerGroup, outlineService)
 			zap.L().Info("InitWriterRoutes: 大纲路由注册完成")
 		}
+
+		// 连贯性检查路由
+		if continuityService != nil {
+			InitContinuityRoutes(writerGroup, continuityService)
+			zap.L().Info("InitWriterRoutes: 连贯性检查路由注册完成")
+		}
 	}
 
 	zap.L().Info("InitWriterRoutes: 设定百科路由注册完成")
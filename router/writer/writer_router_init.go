@@ -15,6 +15,7 @@ import (
 	mongoWriterRepo "Qingyu_backend/repository/mongodb/writer"
 	writerrepo "Qingyu_backend/repository/mongodb/writer"
 	"Qingyu_backend/service"
+	aiService "Qingyu_backend/service/ai"
 	"Qingyu_backend/service/interfaces"
 	searchservice "Qingyu_backend/service/search"
 	writerservice "Qingyu_backend/service/writer"
@@ -62,6 +63,16 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 
 	// 创建VersionService（直接使用MongoDB数据库）
 	versionSvc := projectService.NewVersionService(mongoDB)
+	versionSvc.SetEventBus(eventBus)
+
+	// 为文档协作Hub注入文档存储（编辑权限校验与协作内容自动保存）
+	if collabHub, err := serviceContainer.GetCollabWSHub(); err == nil {
+		collabHub.SetStore(projectService.NewCollabDocumentStore(
+			versionSvc,
+			projectService.NewAutoSaveService(versionSvc),
+			documentService.NewAuthHelper(projectRepo, documentRepo, "CollabDocumentStore"),
+		))
+	}
 
 	// 创建ExportService（导出服务）
 	// 注意：需要先实现ExportTaskRepository和FileStorage接口
@@ -86,20 +97,31 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 			exportTaskRepo,
 			fileStorage,
 		)
+		exportSvc.(*writerservice.ExportService).SetAuthorRepository(
+			writerservice.NewAuthorRepoAdapter(repositoryFactory.CreateUserRepository()),
+		)
+		exportSvc.(*writerservice.ExportService).SetManuscriptRepository(
+			writerservice.NewManuscriptRepoAdapter(projectRepo, documentRepo, docContentRepo),
+		)
+		if itemRepo, orgRepo := repositoryFactory.CreateItemRepository(), repositoryFactory.CreateOrganizationRepository(); itemRepo != nil && orgRepo != nil {
+			exportSvc.(*writerservice.ExportService).SetWorldbuildingRepository(
+				writerservice.NewWorldbuildingRepoAdapter(itemRepo, orgRepo),
+			)
+		}
 		zap.L().Info("RegisterWriterRoutes: ExportService创建成功")
 	} else {
 		zap.L().Warn("RegisterWriterRoutes: mongoDB为nil，跳过ExportService创建")
 	}
 
-	// 创建PublishService（发布服务）
-	publicationRepo := mongoWriterRepo.NewMongoPublicationRepository(mongoDB)
-	publishSvc := writerservice.NewPublishService(
-		writerservice.NewPublishProjectRepositoryAdapter(projectRepo),
-		writerservice.NewPublishDocumentRepositoryAdapter(documentRepo, mongoDB),
-		publicationRepo,
-		writerservice.NewLocalBookstoreClient(mongoDB),
-		writerservice.NewPublishEventBusAdapter(eventBus),
-	)
+	// 获取PublishService（发布服务，定时发布调度器由服务容器管理启停）
+	// 接口变量须在服务存在时才赋值，避免包装出非 nil 的空接口
+	var publishSvc interfaces.PublishService
+	publishService, publishErr := serviceContainer.GetPublishService()
+	if publishErr == nil {
+		publishSvc = publishService
+	} else {
+		zap.L().Warn("RegisterWriterRoutes: PublishService未初始化", zap.Error(publishErr))
+	}
 
 	// 创建DocumentLockService（文档锁服务）
 	// 从服务容器获取Redis客户端
@@ -109,6 +131,25 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 		lockSvc = lock.NewRedisDocumentLockService(redisClient, "doclock")
 	}
 
+	// 创建分布式锁服务（用于保护全局总纲的并发创建）
+	var distLockSvc *distlock.RedisLockService
+	if redisClient != nil {
+		// 从接口中提取底层 redis.Client
+		rawClient := redisClient.GetClient()
+		if rawClient != nil {
+			if client, ok := rawClient.(*redis.Client); ok {
+				distLockSvc = distlock.NewRedisLockService(client, "distlock")
+				zap.L().Info("RegisterWriterRoutes: 分布式锁服务创建成功")
+			} else {
+				zap.L().Warn("RegisterWriterRoutes: 无法从 RedisClient 获取底层客户端，分布式锁服务未创建")
+			}
+		} else {
+			zap.L().Warn("RegisterWriterRoutes: RedisClient.GetClient() 返回 nil，分布式锁服务未创建")
+		}
+	} else {
+		zap.L().Warn("RegisterWriterRoutes: redisClient 为 nil，分布式锁服务未创建")
+	}
+
 	// 创建CommentService（批注服务）
 	var commentSvc writerservice.CommentService
 	// 直接创建 writer comment repository（因为 factory 的 CreateCommentRepository 返回的是 reading 模块的）
@@ -141,6 +182,48 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 		locationSvc = writerservice.NewLocationService(locationRepo, eventBus)
 	}
 
+	// 创建ItemService（物品服务）
+	itemRepo := repositoryFactory.CreateItemRepository()
+	var itemSvc interfaces.ItemService
+	if itemRepo != nil {
+		itemSvc = writerservice.NewItemService(itemRepo, eventBus)
+	}
+
+	// 创建OrganizationService（组织服务）
+	organizationRepo := repositoryFactory.CreateOrganizationRepository()
+	var organizationSvc interfaces.OrganizationService
+	if organizationRepo != nil {
+		organizationSvc = writerservice.NewOrganizationService(organizationRepo, characterRepo, eventBus)
+	}
+
+	// 创建实体扫描器：保存正文时自动关联文档中出现的角色与地点
+	if characterRepo != nil && locationRepo != nil {
+		entityScanner := aiService.NewEntityScanner(characterRepo, locationRepo)
+		if mongoDB != nil {
+			entityScanner.SetConceptRepository(mongoWriterRepo.NewConceptRepository(mongoDB))
+		}
+		if itemRepo != nil {
+			entityScanner.SetItemRepository(itemRepo)
+		}
+		if organizationRepo != nil {
+			entityScanner.SetOrganizationRepository(organizationRepo)
+		}
+		if eventBus != nil {
+			for _, eventType := range entityScanner.GetSupportedEventTypes() {
+				if err := eventBus.Subscribe(eventType, entityScanner); err != nil {
+					zap.L().Warn("RegisterWriterRoutes: 实体扫描器事件订阅失败", zap.String("eventType", eventType), zap.Error(err))
+				}
+			}
+		}
+		documentSvc.SetEntityLinker(func(ctx context.Context, projectID, text string) ([]string, []string, error) {
+			result, err := entityScanner.ScanContent(ctx, projectID, text)
+			if err != nil {
+				return nil, nil, err
+			}
+			return aiService.MatchedEntityIDs(result.Characters), aiService.MatchedEntityIDs(result.Locations), nil
+		})
+	}
+
 	// 创建TimelineService（时间线服务）
 	timelineRepo := repositoryFactory.CreateTimelineRepository()
 	timelineEventRepo := repositoryFactory.CreateTimelineEventRepository()
@@ -149,31 +232,26 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 		timelineSvc = writerservice.NewTimelineService(timelineRepo, timelineEventRepo, eventBus)
 	}
 
+	// 创建ContinuityService（连贯性检查服务），并在发布时按需执行
+	var continuitySvc interfaces.ContinuityService
+	if characterRepo != nil && timelineEventRepo != nil && mongoDB != nil {
+		continuitySvc = writerservice.NewContinuityService(
+			writerservice.NewPublishDocumentRepositoryAdapter(documentRepo, mongoDB),
+			characterRepo,
+			locationRepo,
+			timelineEventRepo,
+		)
+		if publishService != nil {
+			publishService.SetContinuityService(continuitySvc)
+		}
+	}
+
 	// 创建OutlineService（大纲服务）
 	outlineRepo := repositoryFactory.CreateOutlineRepository()
 	var outlineSvc interfaces.OutlineService
 	if outlineRepo != nil {
 		outlineSvc = writerservice.NewOutlineService(outlineRepo, eventBus)
 
-		// 创建分布式锁服务（用于保护全局总纲的并发创建）
-		var distLockSvc *distlock.RedisLockService
-		if redisClient != nil {
-			// 从接口中提取底层 redis.Client
-			rawClient := redisClient.GetClient()
-			if rawClient != nil {
-				if client, ok := rawClient.(*redis.Client); ok {
-					distLockSvc = distlock.NewRedisLockService(client, "distlock")
-					zap.L().Info("RegisterWriterRoutes: 分布式锁服务创建成功")
-				} else {
-					zap.L().Warn("RegisterWriterRoutes: 无法从 RedisClient 获取底层客户端，分布式锁服务未创建")
-				}
-			} else {
-				zap.L().Warn("RegisterWriterRoutes: RedisClient.GetClient() 返回 nil，分布式锁服务未创建")
-			}
-		} else {
-			zap.L().Warn("RegisterWriterRoutes: redisClient 为 nil，分布式锁服务未创建")
-		}
-
 		// 创建大纲-文档双向同步服务并注入
 		syncSvc := writerservice.NewOutlineDocumentSyncService(outlineRepo, documentRepo, projectRepo, outlineSvc.(*writerservice.OutlineService), distLockSvc)
 		outlineSvc.(*writerservice.OutlineService).SetSyncService(syncSvc)
@@ -197,17 +275,41 @@ func RegisterWriterRoutes(r *gin.RouterGroup, searchSvc *searchservice.SearchSer
 		dashboardSvc = writerservice.NewDashboardService(projectRepo, publishSvc)
 	}
 
+	// 创建写作流水服务：订阅内容保存事件记录每日净字数，驱动仪表板产出、连续写作天数与目标进度
+	if dashboardSvc != nil && mongoDB != nil {
+		ledgerRepo := mongoWriterRepo.NewMongoWritingLedgerRepository(mongoDB)
+		indexCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := ledgerRepo.EnsureIndexes(indexCtx); err != nil {
+			zap.L().Warn("RegisterWriterRoutes: 写作流水索引创建失败", zap.Error(err))
+		}
+		cancel()
+
+		ledgerSvc := writerservice.NewWritingLedgerService(ledgerRepo, projectRepo)
+		ledgerSvc.SetRevisionSource(ledgerRepo)
+		if eventBus != nil {
+			for _, eventType := range ledgerSvc.GetSupportedEventTypes() {
+				if err := eventBus.Subscribe(eventType, ledgerSvc); err != nil {
+					zap.L().Warn("RegisterWriterRoutes: 写作流水事件订阅失败", zap.String("eventType", eventType), zap.Error(err))
+				}
+			}
+		}
+		dashboardSvc.SetWritingLedgerService(ledgerSvc)
+	}
+
 	// 调用InitWriterRouter初始化文档编辑相关路由
 	InitWriterRouter(r, projectSvc, documentSvc, versionSvc, searchSvc, exportSvc, publishSvc, lockSvc, commentSvc, templateSvc, statsSvc, bookRepo, characterSvc, locationSvc, dashboardSvc)
 
-	// 调用InitWriterRoutes初始化设定百科路由（角色、地点、时间线、大纲）
+	// 调用InitWriterRoutes初始化设定百科路由（角色、地点、物品、组织、时间线、大纲、连贯性检查）
 	zap.L().Info("RegisterWriterRoutes: 调用InitWriterRoutes注册设定百科路由",
 		zap.Bool("characterSvc", characterSvc != nil),
 		zap.Bool("locationSvc", locationSvc != nil),
 		zap.Bool("timelineSvc", timelineSvc != nil),
 		zap.Bool("outlineSvc", outlineSvc != nil),
+		zap.Bool("continuitySvc", continuitySvc != nil),
+		zap.Bool("itemSvc", itemSvc != nil),
+		zap.Bool("organizationSvc", organizationSvc != nil),
 	)
-	InitWriterRoutes(r, characterSvc, locationSvc, timelineSvc, outlineSvc)
+	InitWriterRoutes(r, characterSvc, locationSvc, timelineSvc, outlineSvc, continuitySvc, itemSvc, organizationSvc)
 	zap.L().Info("RegisterWriterRoutes: 设定百科路由注册完成")
 }
 
@@ -326,3 +428,15 @@ func (m *MockPublishService) GetPendingPublicationRecords(ctx context.Context, p
 func (m *MockPublishService) ReviewPublication(ctx context.Context, recordID, reviewerID string, approved bool, note string) (*interfaces.PublicationRecord, error) {
 	return nil, nil
 }
+
+func (m *MockPublishService) GetScheduledPublications(ctx context.Context, projectID, userID string) ([]*interfaces.PublicationRecord, error) {
+	return []*interfaces.PublicationRecord{}, nil
+}
+
+func (m *MockPublishService) ReschedulePublication(ctx context.Context, recordID, userID string, req *interfaces.ReschedulePublicationRequest) (*interfaces.PublicationRecord, error) {
+	return nil, nil
+}
+
+func (m *MockPublishService) CancelScheduledPublication(ctx context.Context, recordID, userID string) error {
+	return nil
+}
@@ -23,6 +23,9 @@ type AIAdapter interface {
 	// 流式文本生成
 	TextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error)
 
+	// 流式对话生成（支持工具调用，通道关闭前的最后一个分片带 FinishReason）
+	ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error)
+
 	// 图像生成相关方法
 	ImageGeneration(ctx context.Context, req *ImageGenerationRequest) (*ImageGenerationResponse, error)
 
@@ -30,10 +33,38 @@ type AIAdapter interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// 消息角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool" // 工具执行结果
+)
+
+// FinishReasonToolCalls 模型请求调用工具时的结束原因（各提供商统一映射为该值）
+const FinishReasonToolCalls = "tool_calls"
+
 // Message 消息结构
 type Message struct {
-	Role    string `json:"role"`    // system, user, assistant
-	Content string `json:"content"` // 消息内容
+	Role       string     `json:"role"`                   // system, user, assistant, tool
+	Content    string     `json:"content"`                // 消息内容
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 发起的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // role=tool 时对应的工具调用ID
+	Name       string     `json:"name,omitempty"`         // role=tool 时的工具名称
+}
+
+// Tool 与提供商无关的工具定义
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"` // 参数的 JSON Schema
+}
+
+// ToolCall 模型发起的一次工具调用
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON 编码的参数
 }
 
 // TextGenerationRequest 文本生成请求结构
@@ -58,6 +89,8 @@ type ChatCompletionRequest struct {
 	Stream      bool      `json:"stream,omitempty"`
 	Stop        []string  `json:"stop,omitempty"`
 	User        string    `json:"user,omitempty"`
+	Tools       []Tool    `json:"tools,omitempty"`
+	ToolChoice  string    `json:"tool_choice,omitempty"` // auto / none / required / 指定工具名
 }
 
 // TextGenerationResponse 文本生成响应结构
@@ -80,6 +113,18 @@ type ChatCompletionResponse struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// ChatCompletionChunk 流式对话分片
+// 文本以 Delta 增量下发；工具调用在流结束时聚合后随最后一个分片一并下发
+type ChatCompletionChunk struct {
+	ID           string     `json:"id"`
+	Model        string     `json:"model"`
+	Delta        string     `json:"delta,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"` // 非空表示流结束
+	Usage        *Usage     `json:"usage,omitempty"`
+	Err          error      `json:"-"` // 流中途出错时携带，随后通道关闭
+}
+
 // Choice 选择结构
 type Choice struct {
 	Index        int     `json:"index"`
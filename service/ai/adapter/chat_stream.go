@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sort"
+	"strings"
+)
+
+// sseMaxLineSize SSE 单行最大长度（工具调用参数可能较长）
+const sseMaxLineSize = 1024 * 1024
+
+// readSSEData 逐行读取 SSE 流，把每个 data 行交给 onData 处理
+// 兼容 "data: xxx" 与 "data:xxx" 两种写法；onData 返回 true 时停止读取
+func readSSEData(ctx context.Context, body io.Reader, onData func(data string) (bool, error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), sseMaxLineSize)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// 空行、注释行以及 event/id 行都不携带数据
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		done, err := onData(data)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// sendChatChunk 向通道发送分片，ctx 取消时返回 false
+func sendChatChunk(ctx context.Context, ch chan<- *ChatCompletionChunk, chunk *ChatCompletionChunk) bool {
+	select {
+	case ch <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// toolCallAccumulator 聚合流式下发的工具调用片段
+// 各提供商按 index 增量下发 id/name/arguments，这里按 index 拼接
+type toolCallAccumulator struct {
+	calls map[int]*ToolCall
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{calls: make(map[int]*ToolCall)}
+}
+
+// add 追加一个片段；id、name 非空时覆盖，arguments 追加
+func (a *toolCallAccumulator) add(index int, id, name, arguments string) {
+	call, ok := a.calls[index]
+	if !ok {
+		call = &ToolCall{}
+		a.calls[index] = call
+	}
+	if id != "" {
+		call.ID = id
+	}
+	if name != "" {
+		call.Name = name
+	}
+	call.Arguments += arguments
+}
+
+// result 按 index 顺序返回聚合后的工具调用
+func (a *toolCallAccumulator) result() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		call := *a.calls[index]
+		if call.Arguments == "" {
+			call.Arguments = "{}"
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
@@ -55,10 +55,14 @@ func (a *ClaudeAdapter) GetSupportedModels() []string {
 	}
 }
 
+// claudeDefaultMaxTokens Claude 要求必须指定 max_tokens，未指定时使用该值
+const claudeDefaultMaxTokens = 1024
+
 // ClaudeMessage Claude消息格式
+// Content 为字符串或 []ClaudeContent（工具调用与工具结果需要使用内容块）
 type ClaudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
 }
 
 // ClaudeRequest Claude API请求结构
@@ -71,6 +75,15 @@ type ClaudeRequest struct {
 	Stop        []string        `json:"stop_sequences,omitempty"`
 	System      string          `json:"system,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+	Tools       []ClaudeTool    `json:"tools,omitempty"`
+	ToolChoice  interface{}     `json:"tool_choice,omitempty"`
+}
+
+// ClaudeTool Claude工具定义
+type ClaudeTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
 }
 
 // ClaudeResponse Claude API响应结构
@@ -87,8 +100,13 @@ type ClaudeResponse struct {
 
 // ClaudeContent Claude内容结构
 type ClaudeContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type      string          `json:"type"` // text / tool_use / tool_result
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
 }
 
 // ClaudeUsage Claude使用统计
@@ -99,15 +117,26 @@ type ClaudeUsage struct {
 
 // Claude流式响应结构体
 type ClaudeStreamResponse struct {
-	Type  string             `json:"type"`
-	Index int                `json:"index,omitempty"`
-	Delta *ClaudeStreamDelta `json:"delta,omitempty"`
-	Usage *ClaudeUsage       `json:"usage,omitempty"`
+	Type         string             `json:"type"`
+	Index        int                `json:"index,omitempty"`
+	Delta        *ClaudeStreamDelta `json:"delta,omitempty"`
+	Usage        *ClaudeUsage       `json:"usage,omitempty"`
+	Message      *ClaudeResponse    `json:"message,omitempty"`       // message_start
+	ContentBlock *ClaudeContent     `json:"content_block,omitempty"` // content_block_start
+	Error        *ClaudeStreamError `json:"error,omitempty"`
 }
 
 type ClaudeStreamDelta struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	PartialJSON string `json:"partial_json,omitempty"` // input_json_delta
+	StopReason  string `json:"stop_reason,omitempty"`  // message_delta
+}
+
+// ClaudeStreamError Claude流式错误
+type ClaudeStreamError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
 }
 
 // TextGeneration 实现文本生成方法
@@ -155,14 +184,196 @@ func (a *ClaudeAdapter) TextGeneration(ctx context.Context, req *TextGenerationR
 
 // ChatCompletion 实现对话完成方法
 func (a *ClaudeAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	// 转换消息格式
+	// 构建Claude请求
+	claudeReq := buildClaudeChatRequest(req, false)
+
+	// 发送请求
+	claudeResp, err := a.sendRequest(ctx, "/v1/messages", claudeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 提取文本内容与工具调用
+	message := Message{Role: "assistant"}
+	var text strings.Builder
+	for _, block := range claudeResp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: claudeToolArguments(block.Input),
+			})
+		}
+	}
+	message.Content = text.String()
+
+	return &ChatCompletionResponse{
+		ID:      claudeResp.ID,
+		Message: message,
+		Usage: Usage{
+			PromptTokens:     claudeResp.Usage.InputTokens,
+			CompletionTokens: claudeResp.Usage.OutputTokens,
+			TotalTokens:      claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
+		},
+		Model:        claudeResp.Model,
+		FinishReason: claudeFinishReason(claudeResp.StopReason),
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+// ChatCompletionStream 实现流式对话
+// 解析 message_start / content_block_* / message_delta 事件，tool_use 参数由 input_json_delta 拼接
+func (a *ClaudeAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	claudeReq := buildClaudeChatRequest(req, true)
+
+	requestBody, err := json.Marshal(claudeReq)
+	if err != nil {
+		return nil, NewAdapterError("claude", ErrorTypeInvalidRequest,
+			fmt.Sprintf("序列化请求失败: %v", err), "REQUEST_MARSHAL_ERROR", 0, false)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/v1/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, NewAdapterError("claude", ErrorTypeInvalidRequest,
+			fmt.Sprintf("创建HTTP请求失败: %v", err), "REQUEST_CREATE_ERROR", 0, false)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, NewAdapterError("claude", ErrorTypeTimeout,
+			fmt.Sprintf("发送HTTP请求失败: %v", err), "REQUEST_SEND_ERROR", 0, true)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, a.handleErrorResponse(resp.StatusCode, body)
+	}
+
+	chunkChan := make(chan *ChatCompletionChunk, 10)
+	go func() {
+		defer close(chunkChan)
+		defer resp.Body.Close()
+
+		if err := a.readChatStream(ctx, resp.Body, req.Model, chunkChan); err != nil {
+			sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{Model: req.Model, FinishReason: "error", Err: err})
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// readChatStream 读取Claude流式对话响应
+func (a *ClaudeAdapter) readChatStream(ctx context.Context, body io.Reader, model string, chunkChan chan<- *ChatCompletionChunk) error {
+	toolCalls := newToolCallAccumulator()
+	var id, stopReason string
+	var usage Usage
+
+	err := readSSEData(ctx, body, func(data string) (bool, error) {
+		var event ClaudeStreamResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, nil // 跳过无法解析的数据
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				id = event.Message.ID
+				if event.Message.Model != "" {
+					model = event.Message.Model
+				}
+				usage.PromptTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_start":
+			if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" {
+				// 起始块中的 input 恒为空对象，参数由后续 input_json_delta 下发
+				toolCalls.add(event.Index, event.ContentBlock.ID, event.ContentBlock.Name, "")
+			}
+		case "content_block_delta":
+			if event.Delta == nil {
+				return false, nil
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				if event.Delta.Text != "" && !sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{ID: id, Model: model, Delta: event.Delta.Text}) {
+					return true, ctx.Err()
+				}
+			case "input_json_delta":
+				toolCalls.add(event.Index, "", "", event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			if event.Delta != nil && event.Delta.StopReason != "" {
+				stopReason = event.Delta.StopReason
+			}
+			if event.Usage != nil {
+				usage.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "message_stop":
+			return true, nil
+		case "error":
+			message := "Claude流式响应错误"
+			if event.Error != nil {
+				message = event.Error.Message
+			}
+			return true, NewAdapterError("claude", ErrorTypeServiceUnavailable, message, "CLAUDE_STREAM_ERROR", 0, true)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{
+		ID:           id,
+		Model:        model,
+		ToolCalls:    toolCalls.result(),
+		FinishReason: claudeFinishReason(stopReason),
+		Usage:        &usage,
+	})
+	return nil
+}
+
+// buildClaudeChatRequest 构建Claude对话请求
+// system 消息提取到 System 字段；assistant 的工具调用转为 tool_use 内容块；
+// tool 消息转为 user 角色的 tool_result 内容块，连续的工具结果合并到同一条消息
+func buildClaudeChatRequest(req *ChatCompletionRequest, stream bool) *ClaudeRequest {
 	var claudeMessages []ClaudeMessage
 	var systemMessage string
 
 	for _, msg := range req.Messages {
-		if msg.Role == "system" {
+		switch {
+		case msg.Role == RoleSystem:
 			systemMessage = msg.Content
-		} else {
+		case msg.Role == RoleTool:
+			block := ClaudeContent{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content}
+			if n := len(claudeMessages); n > 0 && claudeMessages[n-1].Role == RoleUser {
+				if blocks, ok := claudeMessages[n-1].Content.([]ClaudeContent); ok {
+					claudeMessages[n-1].Content = append(blocks, block)
+					continue
+				}
+			}
+			claudeMessages = append(claudeMessages, ClaudeMessage{Role: RoleUser, Content: []ClaudeContent{block}})
+		case len(msg.ToolCalls) > 0:
+			var blocks []ClaudeContent
+			if msg.Content != "" {
+				blocks = append(blocks, ClaudeContent{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Arguments)
+				if len(input) == 0 || !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, ClaudeContent{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+			claudeMessages = append(claudeMessages, ClaudeMessage{Role: msg.Role, Content: blocks})
+		default:
 			claudeMessages = append(claudeMessages, ClaudeMessage{
 				Role:    msg.Role,
 				Content: msg.Content,
@@ -170,44 +381,65 @@ func (a *ClaudeAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionR
 		}
 	}
 
-	// 构建Claude请求
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = claudeDefaultMaxTokens
+	}
+
 	claudeReq := &ClaudeRequest{
 		Model:       req.Model,
-		MaxTokens:   req.MaxTokens,
+		MaxTokens:   maxTokens,
 		Messages:    claudeMessages,
 		Temperature: req.Temperature,
 		TopP:        req.TopP,
 		Stop:        req.Stop,
 		System:      systemMessage,
+		Stream:      stream,
 	}
 
-	// 发送请求
-	claudeResp, err := a.sendRequest(ctx, "/v1/messages", claudeReq)
-	if err != nil {
-		return nil, err
+	for _, tool := range req.Tools {
+		schema := tool.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		claudeReq.Tools = append(claudeReq.Tools, ClaudeTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	if len(claudeReq.Tools) > 0 {
+		switch req.ToolChoice {
+		case "":
+		case "auto", "none":
+			claudeReq.ToolChoice = map[string]string{"type": req.ToolChoice}
+		case "required":
+			claudeReq.ToolChoice = map[string]string{"type": "any"}
+		default:
+			claudeReq.ToolChoice = map[string]string{"type": "tool", "name": req.ToolChoice}
+		}
 	}
+	return claudeReq
+}
 
-	// 提取文本内容
-	var text string
-	if len(claudeResp.Content) > 0 && claudeResp.Content[0].Type == "text" {
-		text = claudeResp.Content[0].Text
+// claudeToolArguments 将 tool_use 的 input 压缩为参数字符串，与其他提供商的 arguments 格式一致
+func claudeToolArguments(input json.RawMessage) string {
+	if len(input) == 0 {
+		return "{}"
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, input); err != nil {
+		return string(input)
 	}
+	return compact.String()
+}
 
-	return &ChatCompletionResponse{
-		ID: claudeResp.ID,
-		Message: Message{
-			Role:    "assistant",
-			Content: text,
-		},
-		Usage: Usage{
-			PromptTokens:     claudeResp.Usage.InputTokens,
-			CompletionTokens: claudeResp.Usage.OutputTokens,
-			TotalTokens:      claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
-		},
-		Model:        claudeResp.Model,
-		FinishReason: claudeResp.StopReason,
-		CreatedAt:    time.Now(),
-	}, nil
+// claudeFinishReason 将 tool_use 统一映射为 tool_calls，其余结束原因保持原值
+func claudeFinishReason(stopReason string) string {
+	if stopReason == "tool_use" {
+		return FinishReasonToolCalls
+	}
+	return stopReason
 }
 
 // TextGenerationStream 实现流式文本生成
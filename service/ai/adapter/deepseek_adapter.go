@@ -65,6 +65,15 @@ func (a *DeepSeekAdapter) ChatCompletion(ctx context.Context, req *ChatCompletio
 	return a.OpenAIAdapter.ChatCompletion(ctx, req)
 }
 
+// ChatCompletionStream 流式对话（复用OpenAI适配器，DeepSeek 兼容 OpenAI 工具调用格式）
+func (a *DeepSeekAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	// 如果没有指定模型，使用默认模型
+	if req.Model == "" {
+		req.Model = "deepseek-chat"
+	}
+	return a.OpenAIAdapter.ChatCompletionStream(ctx, req)
+}
+
 // TextGenerationStream 流式文本生成（复用OpenAI适配器）
 func (a *DeepSeekAdapter) TextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
 	// 如果没有指定模型，使用默认模型
@@ -401,6 +401,15 @@ func (a *GeminiAdapter) processStreamResponse(body io.Reader, responseChan chan<
 	return scanner.Err()
 }
 
+// ChatCompletionStream 实现流式对话（暂不支持工具调用与流式对话）
+func (a *GeminiAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	return nil, &AdapterError{
+		Code:    ErrorTypeNotImplemented,
+		Message: "Gemini流式对话暂未实现",
+		Type:    ErrorTypeNotImplemented,
+	}
+}
+
 // ImageGeneration 实现图像生成方法
 func (a *GeminiAdapter) ImageGeneration(ctx context.Context, req *ImageGenerationRequest) (*ImageGenerationResponse, error) {
 	// Gemini目前主要用于文本生成，图像生成功能有限
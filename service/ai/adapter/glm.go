@@ -65,11 +65,13 @@ func (a *GLMAdapter) HealthCheck(ctx context.Context) error {
 	return err
 }
 
-// GLMRequest 智谱AI请求结构
+// GLMRequest 智谱AI请求结构（兼容 OpenAI 消息与工具格式）
 type GLMRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream,omitempty"`
+	Model      string          `json:"model"`
+	Messages   []OpenAIMessage `json:"messages"`
+	Stream     bool            `json:"stream,omitempty"`
+	Tools      []OpenAITool    `json:"tools,omitempty"`
+	ToolChoice interface{}     `json:"tool_choice,omitempty"`
 }
 
 // GLMResponse 智谱AI响应结构
@@ -78,9 +80,10 @@ type GLMResponse struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 	Choices []struct {
-		Index   int      `json:"index"`
-		Message Message  `json:"message"`
-		Delta   *Message `json:"delta,omitempty"` // 用于流式响应
+		Index        int            `json:"index"`
+		Message      OpenAIMessage  `json:"message"`
+		Delta        *OpenAIMessage `json:"delta,omitempty"` // 用于流式响应
+		FinishReason string         `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
 		PromptTokens     int `json:"prompt_tokens"`
@@ -97,37 +100,11 @@ type GLMResponse struct {
 // ChatCompletion 实现对话接口
 func (a *GLMAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
 	// 构建 GLM API 请求
-	glmReq := GLMRequest{
-		Model:    req.Model,
-		Messages: req.Messages,
-		Stream:   false,
-	}
-
-	// 使用默认模型
-	if glmReq.Model == "" {
-		glmReq.Model = "glm-4-flash"
-	}
-
-	reqBody, err := json.Marshal(glmReq)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
+	glmReq := a.buildRequest(req, false)
 
-	// 创建 HTTP 请求
-	url := fmt.Sprintf("%s/chat/completions", a.baseURL)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	resp, err := a.doRequest(ctx, glmReq)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
-
-	// 发送请求
-	resp, err := a.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -153,23 +130,104 @@ func (a *GLMAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequ
 		return nil, fmt.Errorf("智谱AI返回空响应")
 	}
 
+	finishReason := glmResp.Choices[0].FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+
 	// 转换为标准响应
 	result := &ChatCompletionResponse{
 		ID:      glmResp.ID,
-		Message: glmResp.Choices[0].Message,
+		Message: fromOpenAIMessage(glmResp.Choices[0].Message),
 		Usage: Usage{
 			PromptTokens:     glmResp.Usage.PromptTokens,
 			CompletionTokens: glmResp.Usage.CompletionTokens,
 			TotalTokens:      glmResp.Usage.TotalTokens,
 		},
 		Model:        glmResp.Model,
-		FinishReason: "stop",
+		FinishReason: finishReason,
 		CreatedAt:    time.Now(),
 	}
 
 	return result, nil
 }
 
+// ChatCompletionStream 实现流式对话接口（SSE 格式与 OpenAI 一致）
+func (a *GLMAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	glmReq := a.buildRequest(req, true)
+
+	resp, err := a.doRequest(ctx, glmReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("智谱AI流式请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+
+	chunkChan := make(chan *ChatCompletionChunk, 10)
+	go func() {
+		defer close(chunkChan)
+		defer resp.Body.Close()
+
+		handleErr := func(_ int, apiErr *OpenAIError) error {
+			return fmt.Errorf("智谱AI错误: %s", apiErr.Message)
+		}
+		if err := readOpenAIChatStream(ctx, resp.Body, glmReq.Model, chunkChan, handleErr); err != nil {
+			sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{Model: glmReq.Model, FinishReason: "error", Err: err})
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// buildRequest 构建智谱AI请求
+func (a *GLMAdapter) buildRequest(req *ChatCompletionRequest, stream bool) *GLMRequest {
+	glmReq := &GLMRequest{
+		Model:      req.Model,
+		Messages:   toOpenAIMessages(req.Messages),
+		Stream:     stream,
+		Tools:      toOpenAITools(req.Tools),
+		ToolChoice: openAIToolChoice(req.ToolChoice, len(req.Tools) > 0),
+	}
+
+	// 使用默认模型
+	if glmReq.Model == "" {
+		glmReq.Model = "glm-4-flash"
+	}
+	return glmReq
+}
+
+// doRequest 发送请求到智谱AI对话接口
+func (a *GLMAdapter) doRequest(ctx context.Context, glmReq *GLMRequest) (*http.Response, error) {
+	reqBody, err := json.Marshal(glmReq)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	// 创建 HTTP 请求
+	url := fmt.Sprintf("%s/chat/completions", a.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	if glmReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	// 发送请求
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	return resp, nil
+}
+
 // TextGeneration 实现文本生成接口
 func (a *GLMAdapter) TextGeneration(ctx context.Context, req *TextGenerationRequest) (*TextGenerationResponse, error) {
 	// 将文本生成请求转换为对话请求
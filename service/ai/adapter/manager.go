@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"Qingyu_backend/config"
 
@@ -23,6 +24,7 @@ type AdapterManager struct {
 	adapters        map[string]AIAdapter
 	config          *config.ExternalAPIConfig
 	defaultProvider string
+	router          *ProviderRouter // 多提供商路由与熔断
 	mu              sync.RWMutex
 }
 
@@ -37,6 +39,7 @@ func NewAdapterManager(cfg *config.ExternalAPIConfig) *AdapterManager {
 		adapters:        make(map[string]AIAdapter),
 		config:          cfg,
 		defaultProvider: cfg.DefaultProvider,
+		router:          NewProviderRouter(cfg.Routing),
 	}
 
 	// 初始化所有启用的适配器
@@ -178,6 +181,7 @@ func (m *AdapterManager) GetAdapterByModel(model string) (AIAdapter, error) {
 }
 
 // HealthCheck 检查所有适配器的健康状态
+// 检查结果同时计入各提供商的熔断器
 func (m *AdapterManager) HealthCheck(ctx context.Context) map[string]error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -187,11 +191,73 @@ func (m *AdapterManager) HealthCheck(ctx context.Context) map[string]error {
 	for name, adapter := range m.adapters {
 		err := adapter.HealthCheck(ctx)
 		results[name] = err
+		m.router.RecordHealth(name, err)
 	}
 
 	return results
 }
 
+// StartHealthChecks 按配置的间隔定期执行健康检查，直到 ctx 取消
+// 未配置 health_check_interval 时不启动
+func (m *AdapterManager) StartHealthChecks(ctx context.Context) {
+	m.mu.RLock()
+	interval := 0
+	if m.config.Routing != nil {
+		interval = m.config.Routing.HealthCheckInterval
+	}
+	m.mu.RUnlock()
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				m.HealthCheck(checkCtx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// GetRouter 获取提供商路由器
+func (m *AdapterManager) GetRouter() *ProviderRouter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.router
+}
+
+// routeCandidates 获取参与路由的提供商（已按策略排序）
+func (m *AdapterManager) routeCandidates(model string) ([]routeCandidate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := make([]routeCandidate, 0, len(m.adapters))
+	for name, adapter := range m.adapters {
+		candidates = append(candidates, routeCandidate{
+			name:    name,
+			adapter: adapter,
+			config:  m.config.Providers[name],
+		})
+	}
+	if len(candidates) == 0 {
+		return nil, &AdapterError{
+			Code:    ErrorTypeInvalidRequest,
+			Message: "没有已启用的AI提供商，请检查配置文件",
+			Type:    ErrorTypeInvalidRequest,
+		}
+	}
+
+	return m.router.order(candidates, model, m.defaultProvider), nil
+}
+
 // GetSupportedModels 获取所有支持的模型
 func (m *AdapterManager) GetSupportedModels() map[string][]string {
 	m.mu.RLock()
@@ -213,6 +279,7 @@ func (m *AdapterManager) ReloadConfig(cfg *config.ExternalAPIConfig) {
 
 	m.config = cfg
 	m.defaultProvider = cfg.DefaultProvider
+	m.router = NewProviderRouter(cfg.Routing)
 
 	// 清空现有适配器
 	m.adapters = make(map[string]AIAdapter)
@@ -291,18 +358,31 @@ func (m *AdapterManager) ChatCompletion(ctx context.Context, provider string, re
 	return adapter.ChatCompletion(ctx, req)
 }
 
-// AutoTextGenerationStream 自动选择最佳适配器进行流式文本生成
+// AutoTextGenerationStream 按路由策略进行流式文本生成
+// 首个分片到达前失败会自动切换到下一个提供商
 func (m *AdapterManager) AutoTextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
-	// 如果指定了模型，尝试根据模型选择适配器
-	if req.Model != "" {
-		adapter, err := m.GetAdapterByModel(req.Model)
-		if err == nil {
-			return adapter.TextGenerationStream(ctx, req)
-		}
-	}
+	return routeStream(ctx, m, "text_generation_stream", req.Model,
+		func(ctx context.Context, adapter AIAdapter, model string) (<-chan *TextGenerationResponse, error) {
+			routed := *req
+			routed.Model = model
+			return adapter.TextGenerationStream(ctx, &routed)
+		}, textChunkErr)
+}
+
+// AutoChatCompletionStream 按路由策略进行流式对话
+// 首个分片到达前失败会自动切换到下一个提供商
+func (m *AdapterManager) AutoChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	return routeStream(ctx, m, "chat_completion_stream", req.Model,
+		func(ctx context.Context, adapter AIAdapter, model string) (<-chan *ChatCompletionChunk, error) {
+			routed := *req
+			routed.Model = model
+			return adapter.ChatCompletionStream(ctx, &routed)
+		}, chatChunkErr)
+}
 
-	// 否则使用默认适配器
-	adapter, err := m.GetDefaultAdapter()
+// TextGenerationStream 使用指定提供商进行流式文本生成
+func (m *AdapterManager) TextGenerationStream(ctx context.Context, provider string, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
+	adapter, err := m.GetAdapter(provider)
 	if err != nil {
 		return nil, err
 	}
@@ -310,14 +390,14 @@ func (m *AdapterManager) AutoTextGenerationStream(ctx context.Context, req *Text
 	return adapter.TextGenerationStream(ctx, req)
 }
 
-// TextGenerationStream 使用指定提供商进行流式文本生成
-func (m *AdapterManager) TextGenerationStream(ctx context.Context, provider string, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
+// ChatCompletionStream 使用指定提供商进行流式对话（支持工具调用）
+func (m *AdapterManager) ChatCompletionStream(ctx context.Context, provider string, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
 	adapter, err := m.GetAdapter(provider)
 	if err != nil {
 		return nil, err
 	}
 
-	return adapter.TextGenerationStream(ctx, req)
+	return adapter.ChatCompletionStream(ctx, req)
 }
 
 // ImageGeneration 使用指定提供商进行图像生成
@@ -330,40 +410,22 @@ func (m *AdapterManager) ImageGeneration(ctx context.Context, provider string, r
 	return adapter.ImageGeneration(ctx, req)
 }
 
-// AutoTextGeneration 自动选择最佳适配器进行文本生成
+// AutoTextGeneration 按路由策略进行文本生成，失败时故障转移到其他提供商
 func (m *AdapterManager) AutoTextGeneration(ctx context.Context, req *TextGenerationRequest) (*TextGenerationResponse, error) {
-	// 如果指定了模型，尝试根据模型选择适配器
-	if req.Model != "" {
-		adapter, err := m.GetAdapterByModel(req.Model)
-		if err == nil {
-			return adapter.TextGeneration(ctx, req)
-		}
-	}
-
-	// 否则使用默认适配器
-	adapter, err := m.GetDefaultAdapter()
-	if err != nil {
-		return nil, err
-	}
-
-	return adapter.TextGeneration(ctx, req)
+	return routeCall(ctx, m, "text_generation", req.Model,
+		func(ctx context.Context, adapter AIAdapter, model string) (*TextGenerationResponse, error) {
+			routed := *req
+			routed.Model = model
+			return adapter.TextGeneration(ctx, &routed)
+		})
 }
 
-// AutoChatCompletion 自动选择最佳适配器进行对话完成
+// AutoChatCompletion 按路由策略进行对话完成，失败时故障转移到其他提供商
 func (m *AdapterManager) AutoChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	// 如果指定了模型，尝试根据模型选择适配器
-	if req.Model != "" {
-		adapter, err := m.GetAdapterByModel(req.Model)
-		if err == nil {
-			return adapter.ChatCompletion(ctx, req)
-		}
-	}
-
-	// 否则使用默认适配器
-	adapter, err := m.GetDefaultAdapter()
-	if err != nil {
-		return nil, err
-	}
-
-	return adapter.ChatCompletion(ctx, req)
+	return routeCall(ctx, m, "chat_completion", req.Model,
+		func(ctx context.Context, adapter AIAdapter, model string) (*ChatCompletionResponse, error) {
+			routed := *req
+			routed.Model = model
+			return adapter.ChatCompletion(ctx, &routed)
+		})
 }
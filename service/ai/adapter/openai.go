@@ -71,22 +71,60 @@ func (a *OpenAIAdapter) GetSupportedModels() []string {
 
 // OpenAIRequest OpenAI请求结构
 type OpenAIRequest struct {
-	Model       string                 `json:"model"`
-	Messages    []OpenAIMessage        `json:"messages,omitempty"`
-	Prompt      string                 `json:"prompt,omitempty"`
-	MaxTokens   int                    `json:"max_tokens,omitempty"`
-	Temperature float64                `json:"temperature,omitempty"`
-	TopP        float64                `json:"top_p,omitempty"`
-	Stream      bool                   `json:"stream,omitempty"`
-	Stop        []string               `json:"stop,omitempty"`
-	User        string                 `json:"user,omitempty"`
-	Extra       map[string]interface{} `json:"-"`
+	Model         string                 `json:"model"`
+	Messages      []OpenAIMessage        `json:"messages,omitempty"`
+	Prompt        string                 `json:"prompt,omitempty"`
+	MaxTokens     int                    `json:"max_tokens,omitempty"`
+	Temperature   float64                `json:"temperature,omitempty"`
+	TopP          float64                `json:"top_p,omitempty"`
+	Stream        bool                   `json:"stream,omitempty"`
+	Stop          []string               `json:"stop,omitempty"`
+	User          string                 `json:"user,omitempty"`
+	Tools         []OpenAITool           `json:"tools,omitempty"`
+	ToolChoice    interface{}            `json:"tool_choice,omitempty"`
+	StreamOptions *OpenAIStreamOptions   `json:"stream_options,omitempty"`
+	Extra         map[string]interface{} `json:"-"`
 }
 
 // OpenAIMessage OpenAI消息结构
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+// OpenAITool OpenAI工具定义
+type OpenAITool struct {
+	Type     string         `json:"type"` // 固定为 function
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction OpenAI函数定义
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// OpenAIToolCall OpenAI工具调用（流式响应中按 index 增量下发）
+type OpenAIToolCall struct {
+	Index    *int               `json:"index,omitempty"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall OpenAI函数调用
+type OpenAIFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// OpenAIStreamOptions OpenAI流式选项
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // OpenAIResponse OpenAI响应结构
@@ -142,8 +180,9 @@ type OpenAIStreamChoice struct {
 
 // OpenAIStreamDelta OpenAI流式增量结构
 type OpenAIStreamDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string           `json:"role,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
 }
 
 // TextGeneration 实现文本生成方法
@@ -209,23 +248,7 @@ func (a *OpenAIAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionR
 
 // doChatCompletion 执行对话完成
 func (a *OpenAIAdapter) doChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	messages := make([]OpenAIMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = OpenAIMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
-	}
-
-	openaiReq := &OpenAIRequest{
-		Model:       req.Model,
-		Messages:    messages,
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-		Stop:        req.Stop,
-		User:        req.User,
-	}
+	openaiReq := buildOpenAIChatRequest(req)
 
 	resp, err := a.sendRequest(ctx, "/chat/completions", openaiReq)
 	if err != nil {
@@ -243,10 +266,7 @@ func (a *OpenAIAdapter) doChatCompletion(ctx context.Context, req *ChatCompletio
 	choice := resp.Choices[0]
 	var message Message
 	if choice.Message != nil {
-		message = Message{
-			Role:    choice.Message.Role,
-			Content: choice.Message.Content,
-		}
+		message = fromOpenAIMessage(*choice.Message)
 	}
 
 	return &ChatCompletionResponse{
@@ -263,6 +283,65 @@ func (a *OpenAIAdapter) doChatCompletion(ctx context.Context, req *ChatCompletio
 	}, nil
 }
 
+// ChatCompletionStream 实现流式对话方法
+// 建立连接阶段的错误（鉴权、限流等）同步返回并按重试策略重试，之后的错误通过分片 Err 下发
+func (a *OpenAIAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	openaiReq := buildOpenAIChatRequest(req)
+	openaiReq.Stream = true
+	openaiReq.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+
+	result, err := ExecuteWithResult(ctx, a.errorHandler.retryer, func(ctx context.Context) (interface{}, error) {
+		return a.openStream(ctx, "/chat/completions", openaiReq)
+	})
+	if err != nil {
+		return nil, err
+	}
+	body := result.(io.ReadCloser)
+
+	chunkChan := make(chan *ChatCompletionChunk, 10)
+	go func() {
+		defer close(chunkChan)
+		defer body.Close()
+
+		if err := readOpenAIChatStream(ctx, body, req.Model, chunkChan, a.handleAPIError); err != nil {
+			sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{Model: req.Model, FinishReason: "error", Err: err})
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// openStream 发起流式请求并在状态码正常时返回响应体
+func (a *OpenAIAdapter) openStream(ctx context.Context, endpoint string, req *OpenAIRequest) (io.ReadCloser, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, NewAdapterError("openai", ErrorTypeInvalidRequest,
+			fmt.Sprintf("序列化请求失败: %v", err), "REQUEST_MARSHAL_ERROR", 0, false)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+endpoint, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, NewAdapterError("openai", ErrorTypeNetworkError,
+			"创建请求失败", "REQUEST_CREATE_ERROR", 0, true)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, NewAdapterError("openai", ErrorTypeNetworkError,
+			fmt.Sprintf("发送请求失败: %v", err), "REQUEST_SEND_ERROR", 0, true)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, a.handleHTTPError(resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
 // TextGenerationStream 实现流式文本生成方法
 func (a *OpenAIAdapter) TextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
 	result, err := ExecuteWithResult(ctx, a.errorHandler.retryer, func(ctx context.Context) (interface{}, error) {
@@ -605,3 +684,167 @@ func (a *OpenAIAdapter) handleHTTPError(statusCode int, body string) error {
 	return NewAdapterError("openai", errorType, message,
 		fmt.Sprintf("HTTP_%d", statusCode), statusCode, isRetryable)
 }
+
+// buildOpenAIChatRequest 构建 OpenAI 兼容格式的对话请求（OpenAI、DeepSeek、智谱共用）
+func buildOpenAIChatRequest(req *ChatCompletionRequest) *OpenAIRequest {
+	return &OpenAIRequest{
+		Model:       req.Model,
+		Messages:    toOpenAIMessages(req.Messages),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+		User:        req.User,
+		Tools:       toOpenAITools(req.Tools),
+		ToolChoice:  openAIToolChoice(req.ToolChoice, len(req.Tools) > 0),
+	}
+}
+
+// toOpenAIMessages 转换为 OpenAI 消息格式
+func toOpenAIMessages(messages []Message) []OpenAIMessage {
+	result := make([]OpenAIMessage, len(messages))
+	for i, msg := range messages {
+		result[i] = OpenAIMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.Name,
+		}
+		for _, call := range msg.ToolCalls {
+			result[i].ToolCalls = append(result[i].ToolCalls, OpenAIToolCall{
+				ID:   call.ID,
+				Type: "function",
+				Function: OpenAIFunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+	}
+	return result
+}
+
+// fromOpenAIMessage 转换 OpenAI 响应消息
+func fromOpenAIMessage(msg OpenAIMessage) Message {
+	message := Message{
+		Role:    msg.Role,
+		Content: msg.Content,
+	}
+	for _, call := range msg.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return message
+}
+
+// toOpenAITools 转换工具定义
+func toOpenAITools(tools []Tool) []OpenAITool {
+	if len(tools) == 0 {
+		return nil
+	}
+	result := make([]OpenAITool, len(tools))
+	for i, tool := range tools {
+		result[i] = OpenAITool{
+			Type: "function",
+			Function: OpenAIFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// openAIToolChoice 转换工具选择策略；未声明工具时不下发
+func openAIToolChoice(choice string, hasTools bool) interface{} {
+	if !hasTools || choice == "" {
+		return nil
+	}
+	switch choice {
+	case "auto", "none", "required":
+		return choice
+	default:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": choice},
+		}
+	}
+}
+
+// readOpenAIChatStream 读取 OpenAI 兼容格式的流式对话响应
+func readOpenAIChatStream(ctx context.Context, body io.Reader, model string, chunkChan chan<- *ChatCompletionChunk, handleAPIError func(int, *OpenAIError) error) error {
+	toolCalls := newToolCallAccumulator()
+	var id, finishReason string
+	var usage *Usage
+
+	err := readSSEData(ctx, body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var streamResp OpenAIStreamResponse
+		if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
+			return false, nil // 跳过无法解析的数据
+		}
+		if streamResp.Error != nil {
+			return true, handleAPIError(http.StatusBadRequest, streamResp.Error)
+		}
+		if streamResp.ID != "" {
+			id = streamResp.ID
+		}
+		if streamResp.Model != "" {
+			model = streamResp.Model
+		}
+		if streamResp.Usage != nil {
+			usage = &Usage{
+				PromptTokens:     streamResp.Usage.PromptTokens,
+				CompletionTokens: streamResp.Usage.CompletionTokens,
+				TotalTokens:      streamResp.Usage.TotalTokens,
+			}
+		}
+
+		for _, choice := range streamResp.Choices {
+			if choice.Delta != nil {
+				for i, call := range choice.Delta.ToolCalls {
+					index := i
+					if call.Index != nil {
+						index = *call.Index
+					}
+					toolCalls.add(index, call.ID, call.Function.Name, call.Function.Arguments)
+				}
+				if choice.Delta.Content != "" {
+					if !sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{ID: id, Model: model, Delta: choice.Delta.Content}) {
+						return true, ctx.Err()
+					}
+				}
+			}
+			if choice.FinishReason != nil && *choice.FinishReason != "" {
+				finishReason = *choice.FinishReason
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	calls := toolCalls.result()
+	if finishReason == "" {
+		finishReason = "stop"
+		if len(calls) > 0 {
+			finishReason = FinishReasonToolCalls
+		}
+	}
+	sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{
+		ID:           id,
+		Model:        model,
+		ToolCalls:    calls,
+		FinishReason: finishReason,
+		Usage:        usage,
+	})
+	return nil
+}
@@ -62,8 +62,11 @@ func (a *QwenAdapter) GetSupportedModels() []string {
 
 // QwenMessage 通义千问消息格式
 type QwenMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
 }
 
 // QwenParameters 通义千问参数
@@ -78,6 +81,10 @@ type QwenParameters struct {
 	Stop              []string `json:"stop,omitempty"`
 	EnableSearch      bool     `json:"enable_search,omitempty"`
 	IncrementalOutput bool     `json:"incremental_output,omitempty"`
+
+	// 工具调用（结构与 OpenAI 一致，需 result_format=message）
+	Tools      []OpenAITool `json:"tools,omitempty"`
+	ToolChoice interface{}  `json:"tool_choice,omitempty"`
 }
 
 // QwenInput 通义千问输入
@@ -97,6 +104,8 @@ type QwenResponse struct {
 	Output    QwenOutput `json:"output"`
 	Usage     QwenUsage  `json:"usage"`
 	RequestID string     `json:"request_id"`
+	Code      string     `json:"code,omitempty"`    // 流式响应中出错时返回
+	Message   string     `json:"message,omitempty"` // 流式响应中出错时返回
 }
 
 // QwenOutput 通义千问输出
@@ -164,31 +173,8 @@ func (a *QwenAdapter) TextGeneration(ctx context.Context, req *TextGenerationReq
 
 // ChatCompletion 实现对话完成方法
 func (a *QwenAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	// 转换消息格式
-	var qwenMessages []QwenMessage
-
-	for _, msg := range req.Messages {
-		qwenMessages = append(qwenMessages, QwenMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
-
 	// 构建通义千问请求
-	qwenReq := &QwenRequest{
-		Model: req.Model,
-		Input: QwenInput{
-			Messages: qwenMessages,
-		},
-		Parameters: QwenParameters{
-			ResultFormat:      "message",
-			MaxTokens:         req.MaxTokens,
-			Temperature:       req.Temperature,
-			TopP:              req.TopP,
-			Stop:              req.Stop,
-			IncrementalOutput: false,
-		},
-	}
+	qwenReq := buildQwenChatRequest(req, false)
 
 	// 发送请求
 	qwenResp, err := a.sendRequest(ctx, "/api/v1/services/aigc/text-generation/generation", qwenReq)
@@ -201,10 +187,11 @@ func (a *QwenAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionReq
 	var finishReason string
 	if len(qwenResp.Output.Choices) > 0 {
 		choice := qwenResp.Output.Choices[0]
-		message = Message{
-			Role:    choice.Message.Role,
-			Content: choice.Message.Content,
-		}
+		message = fromOpenAIMessage(OpenAIMessage{
+			Role:      choice.Message.Role,
+			Content:   choice.Message.Content,
+			ToolCalls: choice.Message.ToolCalls,
+		})
 		finishReason = choice.FinishReason
 	} else {
 		// 如果没有choices，使用text字段
@@ -229,6 +216,146 @@ func (a *QwenAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionReq
 	}, nil
 }
 
+// ChatCompletionStream 实现流式对话
+// 使用 DashScope SSE 增量输出，工具调用参数按 index 拼接
+func (a *QwenAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	qwenReq := buildQwenChatRequest(req, true)
+
+	requestBody, err := json.Marshal(qwenReq)
+	if err != nil {
+		return nil, NewAdapterError("qwen", ErrorTypeInvalidRequest,
+			fmt.Sprintf("序列化请求失败: %v", err), "REQUEST_MARSHAL_ERROR", 0, false)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/api/v1/services/aigc/text-generation/generation", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, NewAdapterError("qwen", ErrorTypeInvalidRequest,
+			fmt.Sprintf("创建HTTP请求失败: %v", err), "REQUEST_CREATE_ERROR", 0, false)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("X-DashScope-SSE", "enable")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, NewAdapterError("qwen", ErrorTypeTimeout,
+			fmt.Sprintf("发送HTTP请求失败: %v", err), "REQUEST_SEND_ERROR", 0, true)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, a.handleErrorResponse(resp.StatusCode, body)
+	}
+
+	chunkChan := make(chan *ChatCompletionChunk, 10)
+	go func() {
+		defer close(chunkChan)
+		defer resp.Body.Close()
+
+		if err := a.readChatStream(ctx, resp.Body, req.Model, chunkChan); err != nil {
+			sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{Model: req.Model, FinishReason: "error", Err: err})
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// readChatStream 读取通义千问流式响应
+func (a *QwenAdapter) readChatStream(ctx context.Context, body io.Reader, model string, chunkChan chan<- *ChatCompletionChunk) error {
+	toolCalls := newToolCallAccumulator()
+	var id, finishReason string
+	var usage *Usage
+
+	err := readSSEData(ctx, body, func(data string) (bool, error) {
+		var qwenResp QwenResponse
+		if err := json.Unmarshal([]byte(data), &qwenResp); err != nil {
+			return false, nil // 跳过无法解析的数据
+		}
+		if qwenResp.Code != "" {
+			return true, NewAdapterError("qwen", ErrorTypeInvalidResponse, qwenResp.Message, qwenResp.Code, 0, false)
+		}
+		id = qwenResp.RequestID
+		if qwenResp.Usage.TotalTokens > 0 {
+			usage = &Usage{
+				PromptTokens:     qwenResp.Usage.InputTokens,
+				CompletionTokens: qwenResp.Usage.OutputTokens,
+				TotalTokens:      qwenResp.Usage.TotalTokens,
+			}
+		}
+
+		for _, choice := range qwenResp.Output.Choices {
+			for i, call := range choice.Message.ToolCalls {
+				index := i
+				if call.Index != nil {
+					index = *call.Index
+				}
+				toolCalls.add(index, call.ID, call.Function.Name, call.Function.Arguments)
+			}
+			if choice.Message.Content != "" {
+				if !sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{ID: id, Model: model, Delta: choice.Message.Content}) {
+					return true, ctx.Err()
+				}
+			}
+			// 未结束时 DashScope 返回字符串 "null"
+			if choice.FinishReason != "" && choice.FinishReason != "null" {
+				finishReason = choice.FinishReason
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	calls := toolCalls.result()
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if len(calls) > 0 {
+		finishReason = FinishReasonToolCalls
+	}
+	sendChatChunk(ctx, chunkChan, &ChatCompletionChunk{
+		ID:           id,
+		Model:        model,
+		ToolCalls:    calls,
+		FinishReason: finishReason,
+		Usage:        usage,
+	})
+	return nil
+}
+
+// buildQwenChatRequest 构建通义千问对话请求
+func buildQwenChatRequest(req *ChatCompletionRequest, stream bool) *QwenRequest {
+	qwenMessages := make([]QwenMessage, 0, len(req.Messages))
+	for _, msg := range toOpenAIMessages(req.Messages) {
+		qwenMessages = append(qwenMessages, QwenMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.Name,
+		})
+	}
+
+	return &QwenRequest{
+		Model: req.Model,
+		Input: QwenInput{
+			Messages: qwenMessages,
+		},
+		Parameters: QwenParameters{
+			ResultFormat:      "message",
+			MaxTokens:         req.MaxTokens,
+			Temperature:       req.Temperature,
+			TopP:              req.TopP,
+			Stop:              req.Stop,
+			IncrementalOutput: stream,
+			Tools:             toOpenAITools(req.Tools),
+			ToolChoice:        openAIToolChoice(req.ToolChoice, len(req.Tools) > 0),
+		},
+	}
+}
+
 // TextGenerationStream 实现流式文本生成
 func (a *QwenAdapter) TextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
 	// 通义千问流式API实现较复杂，这里先返回错误
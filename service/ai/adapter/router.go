@@ -0,0 +1,484 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"Qingyu_backend/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sony/gobreaker"
+)
+
+// 路由策略
+const (
+	RoutingStrategyPriority = "priority" // 按故障转移链/优先级
+	RoutingStrategyWeighted = "weighted" // 按权重随机分流
+	RoutingStrategyCost     = "cost"     // 成本优先
+	RoutingStrategyLatency  = "latency"  // 延迟优先
+)
+
+const (
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerMinRequests  = 5
+	defaultBreakerOpenTimeout  = 30 * time.Second
+	defaultBreakerInterval     = 60 * time.Second // 关闭状态下统计窗口
+	latencyEWMAAlpha           = 0.3              // 延迟指数滑动平均系数
+)
+
+// routeCandidate 参与路由的提供商
+type routeCandidate struct {
+	name    string
+	adapter AIAdapter
+	config  *config.ProviderConfig
+}
+
+// ProviderRouter 提供商路由器
+// 按策略为请求排出候选顺序，并为每个提供商维护熔断器与延迟统计
+type ProviderRouter struct {
+	mu       sync.Mutex
+	config   config.AIRoutingConfig
+	breakers map[string]*gobreaker.TwoStepCircuitBreaker
+	latency  map[string]float64 // 提供商延迟滑动平均（毫秒）
+	rand     *rand.Rand
+	metrics  *RoutingMetrics
+}
+
+// NewProviderRouter 创建提供商路由器，cfg 为 nil 时使用 priority 策略与默认熔断参数
+func NewProviderRouter(cfg *config.AIRoutingConfig) *ProviderRouter {
+	r := &ProviderRouter{
+		breakers: make(map[string]*gobreaker.TwoStepCircuitBreaker),
+		latency:  make(map[string]float64),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		metrics:  GetGlobalRoutingMetrics(),
+	}
+	if cfg != nil {
+		r.config = *cfg
+	}
+	if r.config.Strategy == "" {
+		r.config.Strategy = RoutingStrategyPriority
+	}
+	if r.config.FailureRatio <= 0 {
+		r.config.FailureRatio = defaultBreakerFailureRatio
+	}
+	if r.config.MinRequests == 0 {
+		r.config.MinRequests = defaultBreakerMinRequests
+	}
+	return r
+}
+
+// Strategy 当前路由策略
+func (r *ProviderRouter) Strategy() string {
+	return r.config.Strategy
+}
+
+// BreakerState 获取提供商熔断器状态
+func (r *ProviderRouter) BreakerState(provider string) gobreaker.State {
+	return r.breaker(provider).State()
+}
+
+// breaker 获取（必要时创建）提供商熔断器
+func (r *ProviderRouter) breaker(provider string) *gobreaker.TwoStepCircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cb, exists := r.breakers[provider]; exists {
+		return cb
+	}
+
+	openTimeout := defaultBreakerOpenTimeout
+	if r.config.OpenTimeout > 0 {
+		openTimeout = time.Duration(r.config.OpenTimeout) * time.Second
+	}
+	minRequests := r.config.MinRequests
+	failureRatio := r.config.FailureRatio
+
+	cb := gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+		Name:        provider,
+		MaxRequests: 1, // 半开状态只放行一个探测请求
+		Interval:    defaultBreakerInterval,
+		Timeout:     openTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			if counts.Requests < minRequests {
+				return false
+			}
+			return float64(counts.TotalFailures)/float64(counts.Requests) >= failureRatio
+		},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			logrus.Warnf("AI提供商 %s 熔断器状态变化: %s -> %s", name, from, to)
+			r.metrics.UpdateBreakerState(name, to)
+		},
+	})
+	r.breakers[provider] = cb
+	r.metrics.UpdateBreakerState(provider, gobreaker.StateClosed)
+	return cb
+}
+
+// allow 申请调用提供商，熔断中返回错误
+func (r *ProviderRouter) allow(provider string) (func(success bool), error) {
+	done, err := r.breaker(provider).Allow()
+	if err != nil {
+		r.metrics.RecordBreakerRejection(provider)
+		return nil, err
+	}
+	return done, nil
+}
+
+// observeLatency 记录提供商延迟
+func (r *ProviderRouter) observeLatency(provider string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ms := float64(d.Milliseconds())
+	if prev, exists := r.latency[provider]; exists {
+		ms = latencyEWMAAlpha*ms + (1-latencyEWMAAlpha)*prev
+	}
+	r.latency[provider] = ms
+}
+
+// RecordHealth 将健康检查结果计入熔断器
+// 熔断打开期间不计入；半开状态下健康检查即作为探测请求，成功后熔断器恢复关闭
+func (r *ProviderRouter) RecordHealth(provider string, err error) {
+	done, allowErr := r.breaker(provider).Allow()
+	if allowErr != nil {
+		return
+	}
+	done(err == nil)
+}
+
+// order 按策略排列候选提供商
+// 支持请求模型的提供商排在前面，其余提供商作为故障转移备选
+func (r *ProviderRouter) order(candidates []routeCandidate, model, defaultProvider string) []routeCandidate {
+	ordered := append([]routeCandidate(nil), candidates...)
+
+	// 基础顺序：优先级升序，同优先级按名称保证稳定
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, pj := candidatePriority(ordered[i]), candidatePriority(ordered[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return ordered[i].name < ordered[j].name
+	})
+
+	switch r.config.Strategy {
+	case RoutingStrategyWeighted:
+		ordered = r.weightedShuffle(ordered)
+	case RoutingStrategyCost:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			ci, cj := candidateCost(ordered[i]), candidateCost(ordered[j])
+			// 未配置成本的提供商排在最后
+			if (ci == 0) != (cj == 0) {
+				return cj == 0
+			}
+			return ci < cj
+		})
+	case RoutingStrategyLatency:
+		r.mu.Lock()
+		latency := make(map[string]float64, len(r.latency))
+		for name, ms := range r.latency {
+			latency[name] = ms
+		}
+		r.mu.Unlock()
+		sort.SliceStable(ordered, func(i, j int) bool {
+			li, okI := latency[ordered[i].name]
+			lj, okJ := latency[ordered[j].name]
+			// 尚无延迟样本的提供商优先，以便获得样本
+			if okI != okJ {
+				return !okI
+			}
+			return li < lj
+		})
+	default:
+		chain := r.config.FallbackChain
+		if len(chain) == 0 && defaultProvider != "" {
+			chain = []string{defaultProvider}
+		}
+		rank := make(map[string]int, len(chain))
+		for i, name := range chain {
+			if _, exists := rank[name]; !exists {
+				rank[name] = i
+			}
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			ri, okI := rank[ordered[i].name]
+			rj, okJ := rank[ordered[j].name]
+			if okI != okJ {
+				return okI
+			}
+			return okI && ri < rj
+		})
+	}
+
+	if model != "" {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return supportsModel(ordered[i].config, model) && !supportsModel(ordered[j].config, model)
+		})
+	}
+	return ordered
+}
+
+// weightedShuffle 按权重做不放回随机抽样，首位即为本次分流目标
+func (r *ProviderRouter) weightedShuffle(candidates []routeCandidate) []routeCandidate {
+	remaining := append([]routeCandidate(nil), candidates...)
+	result := make([]routeCandidate, 0, len(candidates))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(remaining) > 0 {
+		total := 0
+		for _, c := range remaining {
+			total += candidateWeight(c)
+		}
+		pick := r.rand.Intn(total)
+		index := 0
+		for i, c := range remaining {
+			pick -= candidateWeight(c)
+			if pick < 0 {
+				index = i
+				break
+			}
+		}
+		result = append(result, remaining[index])
+		remaining = append(remaining[:index], remaining[index+1:]...)
+	}
+	return result
+}
+
+func candidatePriority(c routeCandidate) int {
+	if c.config == nil {
+		return 0
+	}
+	return c.config.Priority
+}
+
+func candidateCost(c routeCandidate) float64 {
+	if c.config == nil {
+		return 0
+	}
+	return c.config.CostPer1KTokens
+}
+
+// candidateWeight 未配置权重时按 1 计
+func candidateWeight(c routeCandidate) int {
+	if c.config == nil || c.config.Weight <= 0 {
+		return 1
+	}
+	return c.config.Weight
+}
+
+// supportsModel 提供商是否声明支持该模型
+func supportsModel(cfg *config.ProviderConfig, model string) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, supported := range cfg.SupportedModels {
+		if supported == model {
+			return true
+		}
+	}
+	return false
+}
+
+// modelFor 故障转移到不支持请求模型的提供商时，改用该提供商的首选模型
+func modelFor(c routeCandidate, model string) string {
+	if model == "" || c.config == nil || len(c.config.SupportedModels) == 0 || supportsModel(c.config, model) {
+		return model
+	}
+	return c.config.SupportedModels[0]
+}
+
+// shouldFailover 判断错误是否应切换到下一个提供商
+// 请求本身无效或调用方已取消时换提供商也无济于事
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var adapterErr *AdapterError
+	if errors.As(err, &adapterErr) && adapterErr.Type == ErrorTypeInvalidRequest {
+		return false
+	}
+	return true
+}
+
+// routeCall 按路由顺序调用提供商，失败时故障转移
+func routeCall[T any](ctx context.Context, m *AdapterManager, operation, model string,
+	call func(ctx context.Context, adapter AIAdapter, model string) (T, error)) (T, error) {
+	var zero T
+	candidates, err := m.routeCandidates(model)
+	if err != nil {
+		return zero, err
+	}
+
+	router := m.GetRouter()
+	attempts := 0
+	var lastErr error
+	for _, c := range candidates {
+		if router.config.MaxAttempts > 0 && attempts >= router.config.MaxAttempts {
+			break
+		}
+		done, err := router.allow(c.name)
+		if err != nil {
+			continue
+		}
+		attempts++
+		router.metrics.RecordRoutingDecision(router.config.Strategy, c.name)
+
+		start := time.Now()
+		result, err := call(ctx, c.adapter, modelFor(c, model))
+		elapsed := time.Since(start)
+		router.metrics.RecordProviderCall(c.name, operation, elapsed, err)
+
+		failover := shouldFailover(ctx, err)
+		done(!failover)
+		if err == nil {
+			router.observeLatency(c.name, elapsed)
+			return result, nil
+		}
+		lastErr = err
+		if !failover {
+			return zero, err
+		}
+		router.metrics.RecordFailover(c.name, operation, err)
+		logrus.Warnf("AI提供商 %s 调用失败，尝试故障转移: %v", c.name, err)
+	}
+
+	return zero, noProviderAvailable(lastErr)
+}
+
+// routeStream 按路由顺序建立流式调用
+// 首个分片到达前失败可透明切换提供商；首个分片交付后流已绑定到该提供商
+func routeStream[T any](ctx context.Context, m *AdapterManager, operation, model string,
+	open func(ctx context.Context, adapter AIAdapter, model string) (<-chan T, error),
+	chunkErr func(T) error) (<-chan T, error) {
+	candidates, err := m.routeCandidates(model)
+	if err != nil {
+		return nil, err
+	}
+
+	router := m.GetRouter()
+	attempts := 0
+	var lastErr error
+	for _, c := range candidates {
+		if router.config.MaxAttempts > 0 && attempts >= router.config.MaxAttempts {
+			break
+		}
+		done, err := router.allow(c.name)
+		if err != nil {
+			continue
+		}
+		attempts++
+		router.metrics.RecordRoutingDecision(router.config.Strategy, c.name)
+
+		start := time.Now()
+		first, in, err := openFirstChunk(ctx, c, model, open, chunkErr)
+		elapsed := time.Since(start)
+		router.metrics.RecordProviderCall(c.name, operation, elapsed, err)
+
+		if err != nil {
+			failover := shouldFailover(ctx, err)
+			done(!failover)
+			lastErr = err
+			if !failover {
+				return nil, err
+			}
+			router.metrics.RecordFailover(c.name, operation, err)
+			logrus.Warnf("AI提供商 %s 流式调用失败，尝试故障转移: %v", c.name, err)
+			continue
+		}
+
+		router.observeLatency(c.name, elapsed)
+		out := make(chan T, cap(in)+1)
+		go func() {
+			defer close(out)
+			var streamErr error
+			defer func() { done(!shouldFailover(ctx, streamErr)) }()
+
+			select {
+			case out <- first:
+			case <-ctx.Done():
+				go drain(in)
+				return
+			}
+			for chunk := range in {
+				if err := chunkErr(chunk); err != nil && streamErr == nil {
+					streamErr = err
+				}
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					go drain(in)
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+
+	return nil, noProviderAvailable(lastErr)
+}
+
+// openFirstChunk 建立流并等待首个有效分片
+func openFirstChunk[T any](ctx context.Context, c routeCandidate, model string,
+	open func(ctx context.Context, adapter AIAdapter, model string) (<-chan T, error),
+	chunkErr func(T) error) (T, <-chan T, error) {
+	var zero T
+	in, err := open(ctx, c.adapter, modelFor(c, model))
+	if err != nil {
+		return zero, nil, err
+	}
+
+	select {
+	case first, ok := <-in:
+		if !ok {
+			return zero, nil, NewAdapterError(c.name, ErrorTypeInvalidResponse, "流式响应为空", "EMPTY_STREAM", 0, true)
+		}
+		if err := chunkErr(first); err != nil {
+			go drain(in)
+			return zero, nil, err
+		}
+		return first, in, nil
+	case <-ctx.Done():
+		go drain(in)
+		return zero, nil, ctx.Err()
+	}
+}
+
+// drain 丢弃剩余分片，避免提供商的发送协程阻塞
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+// noProviderAvailable 全部候选失败或被熔断时的错误
+func noProviderAvailable(lastErr error) error {
+	if lastErr != nil {
+		return lastErr
+	}
+	return &AdapterError{
+		Code:    ErrorTypeServiceUnavailable,
+		Message: "没有可用的AI提供商（均处于熔断状态）",
+		Type:    ErrorTypeServiceUnavailable,
+	}
+}
+
+// textChunkErr 文本流以 FinishReason=error 的分片表示失败
+func textChunkErr(resp *TextGenerationResponse) error {
+	if resp != nil && resp.FinishReason == "error" {
+		return fmt.Errorf("流式文本生成失败")
+	}
+	return nil
+}
+
+// chatChunkErr 对话流分片携带的错误
+func chatChunkErr(chunk *ChatCompletionChunk) error {
+	if chunk == nil {
+		return nil
+	}
+	return chunk.Err
+}
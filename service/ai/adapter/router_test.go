@@ -0,0 +1,230 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"Qingyu_backend/config"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoutedAdapter 可编排结果的测试适配器
+type fakeRoutedAdapter struct {
+	name       string
+	err        error
+	streamErr  error // 首个分片携带的错误
+	calls      int
+	lastModel  string
+	healthErr  error
+	chatChunks []string
+}
+
+func (f *fakeRoutedAdapter) GetName() string                   { return f.name }
+func (f *fakeRoutedAdapter) GetSupportedModels() []string      { return nil }
+func (f *fakeRoutedAdapter) HealthCheck(context.Context) error { return f.healthErr }
+
+func (f *fakeRoutedAdapter) TextGeneration(ctx context.Context, req *TextGenerationRequest) (*TextGenerationResponse, error) {
+	f.calls++
+	f.lastModel = req.Model
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &TextGenerationResponse{Text: f.name, Model: req.Model}, nil
+}
+
+func (f *fakeRoutedAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	f.calls++
+	f.lastModel = req.Model
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ChatCompletionResponse{Message: Message{Role: RoleAssistant, Content: f.name}}, nil
+}
+
+func (f *fakeRoutedAdapter) TextGenerationStream(ctx context.Context, req *TextGenerationRequest) (<-chan *TextGenerationResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	ch := make(chan *TextGenerationResponse, 1)
+	ch <- &TextGenerationResponse{Text: f.name}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeRoutedAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	ch := make(chan *ChatCompletionChunk, len(f.chatChunks)+1)
+	if f.streamErr != nil {
+		ch <- &ChatCompletionChunk{FinishReason: "error", Err: f.streamErr}
+	}
+	for _, delta := range f.chatChunks {
+		ch <- &ChatCompletionChunk{Delta: delta}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeRoutedAdapter) ImageGeneration(ctx context.Context, req *ImageGenerationRequest) (*ImageGenerationResponse, error) {
+	return nil, f.err
+}
+
+func unavailable(provider string) error {
+	return NewAdapterError(provider, ErrorTypeServiceUnavailable, "服务不可用", "503", 503, true)
+}
+
+func newRoutedManager(routing *config.AIRoutingConfig, providers map[string]*config.ProviderConfig, adapters ...*fakeRoutedAdapter) *AdapterManager {
+	m := NewAdapterManager(&config.ExternalAPIConfig{
+		DefaultProvider: "primary",
+		Providers:       providers,
+		Routing:         routing,
+	})
+	for _, a := range adapters {
+		m.AddAdapter(a.name, a)
+	}
+	return m
+}
+
+func TestAdapterManager_AutoChatCompletionFailsOverToNextProvider(t *testing.T) {
+	primary := &fakeRoutedAdapter{name: "primary", err: unavailable("primary")}
+	backup := &fakeRoutedAdapter{name: "backup"}
+	m := newRoutedManager(
+		&config.AIRoutingConfig{FallbackChain: []string{"primary", "backup"}},
+		map[string]*config.ProviderConfig{
+			"primary": {SupportedModels: []string{"gpt-4"}},
+			"backup":  {SupportedModels: []string{"glm-4"}},
+		},
+		primary, backup,
+	)
+
+	resp, err := m.AutoChatCompletion(context.Background(), &ChatCompletionRequest{Model: "gpt-4"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "backup", resp.Message.Content)
+	assert.Equal(t, 1, primary.calls)
+	// 备用提供商不支持原模型时改用其首选模型
+	assert.Equal(t, "glm-4", backup.lastModel)
+}
+
+func TestAdapterManager_InvalidRequestDoesNotFailOver(t *testing.T) {
+	primary := &fakeRoutedAdapter{name: "primary", err: NewAdapterError("primary", ErrorTypeInvalidRequest, "参数错误", "400", 400, false)}
+	backup := &fakeRoutedAdapter{name: "backup"}
+	m := newRoutedManager(nil, map[string]*config.ProviderConfig{}, primary, backup)
+
+	_, err := m.AutoTextGeneration(context.Background(), &TextGenerationRequest{Prompt: "hi"})
+	require.Error(t, err)
+
+	assert.Equal(t, 0, backup.calls)
+	assert.Equal(t, gobreaker.StateClosed, m.GetRouter().BreakerState("primary"))
+}
+
+func TestAdapterManager_OpenBreakerSkipsProvider(t *testing.T) {
+	primary := &fakeRoutedAdapter{name: "primary", err: unavailable("primary")}
+	backup := &fakeRoutedAdapter{name: "backup"}
+	m := newRoutedManager(
+		&config.AIRoutingConfig{MinRequests: 2, FailureRatio: 0.5, OpenTimeout: 60},
+		map[string]*config.ProviderConfig{},
+		primary, backup,
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := m.AutoTextGeneration(context.Background(), &TextGenerationRequest{Prompt: "hi"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, gobreaker.StateOpen, m.GetRouter().BreakerState("primary"))
+
+	resp, err := m.AutoTextGeneration(context.Background(), &TextGenerationRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "backup", resp.Text)
+	assert.Equal(t, 2, primary.calls, "熔断后不再调用故障提供商")
+}
+
+func TestAdapterManager_HealthCheckFeedsBreaker(t *testing.T) {
+	primary := &fakeRoutedAdapter{name: "primary", healthErr: unavailable("primary")}
+	m := newRoutedManager(&config.AIRoutingConfig{MinRequests: 3}, map[string]*config.ProviderConfig{}, primary)
+
+	for i := 0; i < 3; i++ {
+		m.HealthCheck(context.Background())
+	}
+
+	assert.Equal(t, gobreaker.StateOpen, m.GetRouter().BreakerState("primary"))
+}
+
+func TestAdapterManager_StreamFailsOverBeforeFirstChunk(t *testing.T) {
+	primary := &fakeRoutedAdapter{name: "primary", streamErr: unavailable("primary")}
+	backup := &fakeRoutedAdapter{name: "backup", chatChunks: []string{"你", "好"}}
+	m := newRoutedManager(nil, map[string]*config.ProviderConfig{}, primary, backup)
+
+	ch, err := m.AutoChatCompletionStream(context.Background(), &ChatCompletionRequest{})
+	require.NoError(t, err)
+
+	var text string
+	for chunk := range ch {
+		require.NoError(t, chunk.Err)
+		text += chunk.Delta
+	}
+	assert.Equal(t, "你好", text)
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestAdapterManager_AllProvidersFailReturnsLastError(t *testing.T) {
+	m := newRoutedManager(&config.AIRoutingConfig{MaxAttempts: 1}, map[string]*config.ProviderConfig{},
+		&fakeRoutedAdapter{name: "primary", err: unavailable("primary")},
+		&fakeRoutedAdapter{name: "backup", err: unavailable("backup")},
+	)
+
+	_, err := m.AutoTextGenerationStream(context.Background(), &TextGenerationRequest{})
+	var adapterErr *AdapterError
+	require.ErrorAs(t, err, &adapterErr)
+	assert.Equal(t, "primary", adapterErr.Provider)
+}
+
+func TestProviderRouter_Order(t *testing.T) {
+	candidates := []routeCandidate{
+		{name: "a", config: &config.ProviderConfig{Priority: 1, CostPer1KTokens: 0.03, Weight: 1}},
+		{name: "b", config: &config.ProviderConfig{Priority: 2, CostPer1KTokens: 0.001, Weight: 9}},
+		{name: "c", config: &config.ProviderConfig{Priority: 3}},
+	}
+	names := func(ordered []routeCandidate) []string {
+		var result []string
+		for _, c := range ordered {
+			result = append(result, c.name)
+		}
+		return result
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		r := NewProviderRouter(nil)
+		assert.Equal(t, []string{"c", "a", "b"}, names(r.order(candidates, "", "c")))
+	})
+
+	t.Run("cost", func(t *testing.T) {
+		r := NewProviderRouter(&config.AIRoutingConfig{Strategy: RoutingStrategyCost})
+		assert.Equal(t, []string{"b", "a", "c"}, names(r.order(candidates, "", "")))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		r := NewProviderRouter(&config.AIRoutingConfig{Strategy: RoutingStrategyLatency})
+		r.latency["a"] = 900
+		r.latency["b"] = 100
+		assert.Equal(t, []string{"c", "b", "a"}, names(r.order(candidates, "", "")))
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		r := NewProviderRouter(&config.AIRoutingConfig{Strategy: RoutingStrategyWeighted})
+		first := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			ordered := r.order(candidates, "", "")
+			require.Len(t, ordered, 3)
+			first[ordered[0].name]++
+		}
+		assert.Greater(t, first["b"], 700)
+		assert.Greater(t, first["a"], 0)
+	})
+}
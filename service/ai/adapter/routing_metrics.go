@@ -0,0 +1,125 @@
+package adapter
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+)
+
+// RoutingMetrics 提供商路由指标
+type RoutingMetrics struct {
+	// RoutingDecisions 路由选中次数（按策略、提供商分类）
+	RoutingDecisions *prometheus.CounterVec
+
+	// ProviderRequests 提供商调用次数（按提供商、操作、状态分类）
+	ProviderRequests *prometheus.CounterVec
+
+	// ProviderLatency 提供商调用延迟（流式为首包延迟）
+	ProviderLatency *prometheus.HistogramVec
+
+	// Failovers 故障转移次数
+	Failovers *prometheus.CounterVec
+
+	// BreakerRejections 因熔断被跳过的次数
+	BreakerRejections *prometheus.CounterVec
+
+	// BreakerState 熔断器状态（0 关闭，1 半开，2 打开）
+	BreakerState *prometheus.GaugeVec
+}
+
+// NewRoutingMetrics 创建路由指标
+func NewRoutingMetrics() *RoutingMetrics {
+	return &RoutingMetrics{
+		RoutingDecisions: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ai_adapter_routing_decisions_total",
+				Help: "AI提供商路由选中次数",
+			},
+			[]string{"strategy", "provider"},
+		),
+		ProviderRequests: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ai_adapter_provider_requests_total",
+				Help: "AI提供商调用次数",
+			},
+			[]string{"provider", "operation", "status"},
+		),
+		ProviderLatency: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ai_adapter_provider_latency_seconds",
+				Help:    "AI提供商调用延迟分布（流式为首包延迟）",
+				Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			},
+			[]string{"provider", "operation"},
+		),
+		Failovers: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ai_adapter_failovers_total",
+				Help: "AI提供商故障转移次数",
+			},
+			[]string{"from", "operation", "reason"},
+		),
+		BreakerRejections: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ai_adapter_breaker_rejections_total",
+				Help: "因熔断被跳过的提供商次数",
+			},
+			[]string{"provider"},
+		),
+		BreakerState: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "ai_adapter_breaker_state",
+				Help: "AI提供商熔断器状态（0 关闭，1 半开，2 打开）",
+			},
+			[]string{"provider"},
+		),
+	}
+}
+
+// RecordRoutingDecision 记录路由选中
+func (m *RoutingMetrics) RecordRoutingDecision(strategy, provider string) {
+	m.RoutingDecisions.WithLabelValues(strategy, provider).Inc()
+}
+
+// RecordProviderCall 记录提供商调用结果与延迟
+func (m *RoutingMetrics) RecordProviderCall(provider, operation string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	m.ProviderRequests.WithLabelValues(provider, operation, status).Inc()
+	m.ProviderLatency.WithLabelValues(provider, operation).Observe(duration.Seconds())
+}
+
+// RecordFailover 记录故障转移
+func (m *RoutingMetrics) RecordFailover(from, operation string, err error) {
+	reason := "unknown"
+	if adapterErr, ok := err.(*AdapterError); ok && adapterErr.Type != "" {
+		reason = adapterErr.Type
+	}
+	m.Failovers.WithLabelValues(from, operation, reason).Inc()
+}
+
+// RecordBreakerRejection 记录熔断跳过
+func (m *RoutingMetrics) RecordBreakerRejection(provider string) {
+	m.BreakerRejections.WithLabelValues(provider).Inc()
+}
+
+// UpdateBreakerState 更新熔断器状态
+func (m *RoutingMetrics) UpdateBreakerState(provider string, state gobreaker.State) {
+	m.BreakerState.WithLabelValues(provider).Set(float64(state))
+}
+
+// 全局路由指标实例（Prometheus 指标只能注册一次，多个管理器共享）
+var globalRoutingMetrics *RoutingMetrics
+
+func init() {
+	globalRoutingMetrics = NewRoutingMetrics()
+}
+
+// GetGlobalRoutingMetrics 获取全局路由指标实例
+func GetGlobalRoutingMetrics() *RoutingMetrics {
+	return globalRoutingMetrics
+}
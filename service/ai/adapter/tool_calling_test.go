@@ -0,0 +1,292 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider 记录请求体并按固定内容响应的假提供商
+type fakeProvider struct {
+	server *httptest.Server
+	bodies []map[string]interface{}
+}
+
+func newFakeProvider(t *testing.T, contentType, response string) *fakeProvider {
+	p := &fakeProvider{}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &body))
+		p.bodies = append(p.bodies, body)
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func weatherTool() Tool {
+	return Tool{
+		Name:        "get_weather",
+		Description: "查询城市天气",
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
+// toolRoundTripMessages 一轮完整的工具调用对话：提问、模型发起调用、工具返回结果
+func toolRoundTripMessages() []Message {
+	return []Message{
+		{Role: RoleSystem, Content: "你是助手"},
+		{Role: RoleUser, Content: "北京天气如何"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}},
+		{Role: RoleTool, ToolCallID: "call_1", Content: "晴"},
+	}
+}
+
+func collectChunks(t *testing.T, ch <-chan *ChatCompletionChunk) (string, *ChatCompletionChunk) {
+	var text string
+	var last *ChatCompletionChunk
+	for chunk := range ch {
+		require.NoError(t, chunk.Err)
+		text += chunk.Delta
+		last = chunk
+	}
+	require.NotNil(t, last)
+	return text, last
+}
+
+func TestOpenAIAdapter_ChatCompletionWithTools(t *testing.T) {
+	provider := newFakeProvider(t, "application/json", `{
+		"id": "chatcmpl-1", "model": "gpt-4o",
+		"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+			"role": "assistant", "content": null,
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"北京\"}"}}]
+		}}],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8}
+	}`)
+	a := NewOpenAIAdapter("key", provider.server.URL)
+
+	resp, err := a.ChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:      "gpt-4o",
+		Messages:   toolRoundTripMessages(),
+		Tools:      []Tool{weatherTool()},
+		ToolChoice: "get_weather",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, FinishReasonToolCalls, resp.FinishReason)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, ToolCall{ID: "call_1", Name: "get_weather", Arguments: `{"city":"北京"}`}, resp.Message.ToolCalls[0])
+
+	require.Len(t, provider.bodies, 1)
+	body := provider.bodies[0]
+	tools := body["tools"].([]interface{})
+	require.Len(t, tools, 1)
+	function := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+	assert.Equal(t, "get_weather", function["name"])
+	assert.NotNil(t, function["parameters"])
+	assert.Equal(t, map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "get_weather"}}, body["tool_choice"])
+
+	messages := body["messages"].([]interface{})
+	require.Len(t, messages, 4)
+	assistant := messages[2].(map[string]interface{})
+	assert.Len(t, assistant["tool_calls"], 1)
+	toolResult := messages[3].(map[string]interface{})
+	assert.Equal(t, "tool", toolResult["role"])
+	assert.Equal(t, "call_1", toolResult["tool_call_id"])
+}
+
+func TestOpenAIAdapter_ChatCompletionStreamAggregatesToolCalls(t *testing.T) {
+	provider := newFakeProvider(t, "text/event-stream", ""+
+		"data: {\"id\":\"c1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"稍等\"}}]}\n\n"+
+		"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"ci\"}}]}}]}\n\n"+
+		"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"ty\\\":\\\"北京\\\"}\"}}]}}]}\n\n"+
+		"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n"+
+		"data: {\"id\":\"c1\",\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":3,\"total_tokens\":8}}\n\n"+
+		"data: [DONE]\n\n")
+	a := NewOpenAIAdapter("key", provider.server.URL)
+
+	ch, err := a.ChatCompletionStream(context.Background(), &ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: RoleUser, Content: "北京天气如何"}},
+		Tools:    []Tool{weatherTool()},
+	})
+	require.NoError(t, err)
+
+	text, last := collectChunks(t, ch)
+	assert.Equal(t, "稍等", text)
+	assert.Equal(t, FinishReasonToolCalls, last.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}, last.ToolCalls)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 8, last.Usage.TotalTokens)
+
+	body := provider.bodies[0]
+	assert.Equal(t, true, body["stream"])
+	assert.Equal(t, map[string]interface{}{"include_usage": true}, body["stream_options"])
+}
+
+func TestDeepSeekAdapter_ChatCompletionStreamUsesDefaultModel(t *testing.T) {
+	provider := newFakeProvider(t, "text/event-stream", ""+
+		"data: {\"id\":\"d1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"你好\"},\"finish_reason\":\"stop\"}]}\n\n"+
+		"data: [DONE]\n\n")
+	a := NewDeepSeekAdapter("key", provider.server.URL)
+
+	ch, err := a.ChatCompletionStream(context.Background(), &ChatCompletionRequest{
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	})
+	require.NoError(t, err)
+
+	text, last := collectChunks(t, ch)
+	assert.Equal(t, "你好", text)
+	assert.Equal(t, "stop", last.FinishReason)
+	assert.Equal(t, "deepseek-chat", provider.bodies[0]["model"])
+}
+
+func TestClaudeAdapter_ChatCompletionWithTools(t *testing.T) {
+	provider := newFakeProvider(t, "application/json", `{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-3-5-sonnet",
+		"content": [
+			{"type": "text", "text": "我来查一下"},
+			{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "北京"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 5, "output_tokens": 3}
+	}`)
+	a := NewClaudeAdapter("key", provider.server.URL)
+
+	resp, err := a.ChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:      "claude-3-5-sonnet",
+		Messages:   toolRoundTripMessages(),
+		Tools:      []Tool{weatherTool()},
+		ToolChoice: "required",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "我来查一下", resp.Message.Content)
+	assert.Equal(t, FinishReasonToolCalls, resp.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "toolu_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}, resp.Message.ToolCalls)
+
+	body := provider.bodies[0]
+	assert.Equal(t, "你是助手", body["system"])
+	assert.Equal(t, float64(claudeDefaultMaxTokens), body["max_tokens"])
+	assert.Equal(t, map[string]interface{}{"type": "any"}, body["tool_choice"])
+	tool := body["tools"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "get_weather", tool["name"])
+	assert.NotNil(t, tool["input_schema"])
+
+	messages := body["messages"].([]interface{})
+	require.Len(t, messages, 3)
+	toolUse := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_use", toolUse["type"])
+	assert.Equal(t, map[string]interface{}{"city": "北京"}, toolUse["input"])
+	toolResultMessage := messages[2].(map[string]interface{})
+	assert.Equal(t, "user", toolResultMessage["role"])
+	toolResult := toolResultMessage["content"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_result", toolResult["type"])
+	assert.Equal(t, "call_1", toolResult["tool_use_id"])
+	assert.Equal(t, "晴", toolResult["content"])
+}
+
+func TestClaudeAdapter_ChatCompletionStreamAggregatesToolUse(t *testing.T) {
+	provider := newFakeProvider(t, "text/event-stream", ""+
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-3-5-sonnet\",\"content\":[],\"usage\":{\"input_tokens\":5,\"output_tokens\":0}}}\n\n"+
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"+
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"我来查一下\"}}\n\n"+
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\",\"input\":{}}}\n\n"+
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\"}}\n\n"+
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"北京\\\"}\"}}\n\n"+
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":3}}\n\n"+
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	a := NewClaudeAdapter("key", provider.server.URL)
+
+	ch, err := a.ChatCompletionStream(context.Background(), &ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet",
+		Messages: []Message{{Role: RoleUser, Content: "北京天气如何"}},
+		Tools:    []Tool{weatherTool()},
+	})
+	require.NoError(t, err)
+
+	text, last := collectChunks(t, ch)
+	assert.Equal(t, "我来查一下", text)
+	assert.Equal(t, "msg_1", last.ID)
+	assert.Equal(t, FinishReasonToolCalls, last.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "toolu_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}, last.ToolCalls)
+	assert.Equal(t, Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}, *last.Usage)
+	assert.Equal(t, true, provider.bodies[0]["stream"])
+}
+
+func TestQwenAdapter_ChatCompletionStreamAggregatesToolCalls(t *testing.T) {
+	provider := newFakeProvider(t, "text/event-stream", ""+
+		"id:1\nevent:result\ndata:{\"output\":{\"choices\":[{\"message\":{\"role\":\"assistant\",\"content\":\"\",\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"city\\\":\"}}]},\"finish_reason\":\"null\"}]},\"request_id\":\"r1\"}\n\n"+
+		"id:2\nevent:result\ndata:{\"output\":{\"choices\":[{\"message\":{\"role\":\"assistant\",\"content\":\"\",\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"北京\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]},\"usage\":{\"input_tokens\":5,\"output_tokens\":3,\"total_tokens\":8},\"request_id\":\"r1\"}\n\n")
+	a := NewQwenAdapter("key", provider.server.URL)
+
+	ch, err := a.ChatCompletionStream(context.Background(), &ChatCompletionRequest{
+		Model:    "qwen-plus",
+		Messages: toolRoundTripMessages(),
+		Tools:    []Tool{weatherTool()},
+	})
+	require.NoError(t, err)
+
+	_, last := collectChunks(t, ch)
+	assert.Equal(t, FinishReasonToolCalls, last.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}, last.ToolCalls)
+
+	body := provider.bodies[0]
+	parameters := body["parameters"].(map[string]interface{})
+	assert.Len(t, parameters["tools"], 1)
+	assert.Equal(t, true, parameters["incremental_output"])
+	messages := body["input"].(map[string]interface{})["messages"].([]interface{})
+	toolResult := messages[3].(map[string]interface{})
+	assert.Equal(t, "tool", toolResult["role"])
+	assert.Equal(t, "call_1", toolResult["tool_call_id"])
+}
+
+func TestGLMAdapter_ChatCompletionWithTools(t *testing.T) {
+	provider := newFakeProvider(t, "application/json", `{
+		"id": "glm-1", "model": "glm-4",
+		"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+			"role": "assistant", "content": "",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"北京\"}"}}]
+		}}],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8}
+	}`)
+	a := NewGLMAdapter("key", provider.server.URL)
+
+	resp, err := a.ChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:      "glm-4",
+		Messages:   toolRoundTripMessages(),
+		Tools:      []Tool{weatherTool()},
+		ToolChoice: "auto",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, FinishReasonToolCalls, resp.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"北京"}`}}, resp.Message.ToolCalls)
+	assert.Equal(t, "auto", provider.bodies[0]["tool_choice"])
+	assert.Len(t, provider.bodies[0]["tools"], 1)
+}
+
+func TestUnsupportedProvidersRejectChatCompletionStream(t *testing.T) {
+	for _, a := range []AIAdapter{
+		NewGeminiAdapter("key", "http://127.0.0.1:0"),
+		NewWenxinAdapter("key", "secret", "http://127.0.0.1:0"),
+	} {
+		_, err := a.ChatCompletionStream(context.Background(), &ChatCompletionRequest{})
+		var adapterErr *AdapterError
+		require.ErrorAs(t, err, &adapterErr, a.GetName())
+		assert.Equal(t, ErrorTypeNotImplemented, adapterErr.Type)
+	}
+}
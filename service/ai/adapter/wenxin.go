@@ -216,6 +216,15 @@ func (a *WenxinAdapter) TextGenerationStream(ctx context.Context, req *TextGener
 	}
 }
 
+// ChatCompletionStream 实现流式对话（暂不支持工具调用与流式对话）
+func (a *WenxinAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (<-chan *ChatCompletionChunk, error) {
+	return nil, &AdapterError{
+		Code:    ErrorTypeNotImplemented,
+		Message: "文心一言流式对话暂未实现",
+		Type:    ErrorTypeNotImplemented,
+	}
+}
+
 // ImageGeneration 实现图像生成方法
 func (a *WenxinAdapter) ImageGeneration(ctx context.Context, req *ImageGenerationRequest) (*ImageGenerationResponse, error) {
 	// 文心一言有图像生成功能，但API结构不同，这里先返回错误
@@ -14,6 +14,7 @@ type AIGateway struct {
 	quotaService   *QuotaService
 	rateLimiter    RateLimiter
 	authService    AuthService
+	responseCache  *ResponseCache
 }
 
 // UnifiedRequest 统一 AI 请求结构
@@ -22,6 +23,8 @@ type UnifiedRequest struct {
 	UserID      string                 `json:"user_id"`
 	Model       string                 `json:"model,omitempty"`
 	TaskType    string                 `json:"task_type"`
+	Task        string                 `json:"task,omitempty"` // 写作任务（summarize/proofread/continue 等），决定缓存时间
+	NoCache     bool                   `json:"no_cache,omitempty"`
 	Prompt      string                 `json:"prompt,omitempty"`
 	Messages    []adapter.Message      `json:"messages,omitempty"`
 	Parameters  map[string]interface{} `json:"parameters,omitempty"`
@@ -39,6 +42,7 @@ type UnifiedResponse struct {
 	Model     string         `json:"model"`
 	Provider  string         `json:"provider"`
 	Latency   time.Duration  `json:"latency"`
+	Cached    bool           `json:"cached,omitempty"`
 }
 
 // GatewayError 网关错误
@@ -74,8 +78,14 @@ func NewAIGateway(
 	}
 }
 
+// SetResponseCache 设置响应缓存
+func (g *AIGateway) SetResponseCache(responseCache *ResponseCache) {
+	g.responseCache = responseCache
+}
+
 // ProcessRequest 处理统一 AI 请求
-// 执行流程：鉴权 → 限流 → 配额校验 → 模型选择 → 调用编排
+// 执行流程：鉴权 → 限流 → 缓存查询 → 配额校验 → 模型选择 → 调用编排 → 写入缓存
+// 缓存命中时直接返回，不校验也不消耗配额
 func (g *AIGateway) ProcessRequest(ctx context.Context, req *UnifiedRequest, token string) (*UnifiedResponse, error) {
 	startTime := time.Now()
 	requestID := req.RequestID
@@ -89,6 +99,20 @@ func (g *AIGateway) ProcessRequest(ctx context.Context, req *UnifiedRequest, tok
 	if !g.checkRateLimit(ctx, userID) {
 		return g.buildErrorResponse(requestID, "RATE_LIMIT_EXCEEDED", "请求过于频繁", time.Since(startTime)), nil
 	}
+	// 缓存查询
+	cached, probe := g.responseCache.lookup(ctx, userID, req)
+	if cached != nil {
+		return &UnifiedResponse{
+			RequestID: requestID,
+			Success:   true,
+			Data:      cached.Data(),
+			Usage:     &cached.Usage,
+			Model:     cached.Model,
+			Provider:  cached.Provider,
+			Latency:   time.Since(startTime),
+			Cached:    true,
+		}, nil
+	}
 	// 配额校验
 	estimatedTokens := g.estimateTokens(req)
 	if err := g.checkQuota(ctx, userID, estimatedTokens); err != nil {
@@ -108,6 +132,10 @@ func (g *AIGateway) ProcessRequest(ctx context.Context, req *UnifiedRequest, tok
 	if err := g.consumeQuota(ctx, userID, usage.TotalTokens); err != nil {
 		fmt.Printf("配额消费失败: %v\n", err)
 	}
+	// 写入缓存
+	if probe != nil {
+		g.responseCache.store(ctx, probe, newCachedResponse(result, usage, selectedModel, selectedProvider))
+	}
 
 	latency := time.Since(startTime)
 	return &UnifiedResponse{
@@ -264,6 +292,23 @@ func (g *AIGateway) estimateTokens(req *UnifiedRequest) int {
 	return total
 }
 
+// newCachedResponse 将调用结果转为缓存条目
+func newCachedResponse(result interface{}, usage *adapter.Usage, model, provider string) *CachedResponse {
+	resp := &CachedResponse{Model: model, Provider: provider}
+	if usage != nil {
+		resp.Usage = *usage
+	}
+	switch data := result.(type) {
+	case adapter.Message:
+		resp.Message = &data
+	case string:
+		resp.Text = data
+	default:
+		return nil
+	}
+	return resp
+}
+
 // buildErrorResponse 构建错误响应
 func (g *AIGateway) buildErrorResponse(requestID, code, message string, latency time.Duration) *UnifiedResponse {
 	return &UnifiedResponse{
@@ -0,0 +1,185 @@
+package ai
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// EntityKind 实体类型
+type EntityKind string
+
+const (
+	EntityKindCharacter    EntityKind = "character"
+	EntityKindLocation     EntityKind = "location"
+	EntityKindItem         EntityKind = "item"
+	EntityKindOrganization EntityKind = "organization"
+	EntityKindConcept      EntityKind = "concept"
+)
+
+// EntityTerm 可识别的实体词条（实体名称或别名各为一条）
+type EntityTerm struct {
+	Kind EntityKind
+	ID   string
+	Name string // 实体规范名
+	Term string // 匹配用词（名称或别名）
+}
+
+// EntityMatch 一次命中
+type EntityMatch struct {
+	EntityTerm
+	Start int // 起始位置（按字符计）
+	End   int // 结束位置（不含）
+}
+
+// acNode Aho-Corasick 自动机节点
+type acNode struct {
+	children map[rune]*acNode
+	fail     *acNode
+	output   *acNode      // 失败链上最近的词尾节点
+	terms    []EntityTerm // 以该节点结尾的词条
+	depth    int          // 词长（字符数）
+}
+
+// EntityMatcher 实体匹配自动机
+// 基于 Aho-Corasick 算法，一次扫描同时匹配全部名称与别名，
+// 重叠命中时取最左最长（如同时收录"张三"与"张三丰"时优先匹配"张三丰"）
+type EntityMatcher struct {
+	root  *acNode
+	terms int
+}
+
+// NewEntityMatcher 构建实体匹配自动机
+func NewEntityMatcher(terms []EntityTerm) *EntityMatcher {
+	m := &EntityMatcher{root: newACNode(0)}
+	for _, term := range terms {
+		m.add(term)
+	}
+	m.build()
+	return m
+}
+
+// Len 收录的词条数
+func (m *EntityMatcher) Len() int {
+	return m.terms
+}
+
+// FindAll 扫描文本，返回按位置排序且互不重叠的命中
+func (m *EntityMatcher) FindAll(text string) []EntityMatch {
+	if m.terms == 0 || text == "" {
+		return nil
+	}
+
+	// 收集所有候选命中（end 为词尾字符的下一位置）
+	type candidate struct {
+		node  *acNode
+		start int
+	}
+	var candidates []candidate
+
+	node := m.root
+	pos := 0
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		for node != m.root && node.children[r] == nil {
+			node = node.fail
+		}
+		if next := node.children[r]; next != nil {
+			node = next
+		}
+		pos++
+
+		for out := node; out != nil; out = out.output {
+			if len(out.terms) > 0 {
+				candidates = append(candidates, candidate{node: out, start: pos - out.depth})
+			}
+		}
+	}
+
+	// 最左最长、互不重叠
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].start != candidates[j].start {
+			return candidates[i].start < candidates[j].start
+		}
+		return candidates[i].node.depth > candidates[j].node.depth
+	})
+
+	var matches []EntityMatch
+	covered := 0
+	for _, c := range candidates {
+		if c.start < covered {
+			continue
+		}
+		end := c.start + c.node.depth
+		for _, term := range c.node.terms {
+			matches = append(matches, EntityMatch{EntityTerm: term, Start: c.start, End: end})
+		}
+		covered = end
+	}
+	return matches
+}
+
+// add 插入词条
+func (m *EntityMatcher) add(term EntityTerm) {
+	word := strings.TrimSpace(term.Term)
+	if word == "" || term.ID == "" {
+		return
+	}
+
+	node := m.root
+	for _, r := range strings.ToLower(word) {
+		next := node.children[r]
+		if next == nil {
+			next = newACNode(node.depth + 1)
+			node.children[r] = next
+		}
+		node = next
+	}
+
+	// 同一实体的重复别名只保留一条
+	for _, existing := range node.terms {
+		if existing.Kind == term.Kind && existing.ID == term.ID {
+			return
+		}
+	}
+	term.Term = word
+	node.terms = append(node.terms, term)
+	m.terms++
+}
+
+// build 按层序构建失败指针与输出链接
+func (m *EntityMatcher) build() {
+	queue := make([]*acNode, 0, len(m.root.children))
+	for _, child := range m.root.children {
+		child.fail = m.root
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for r, child := range node.children {
+			fail := node.fail
+			for fail != nil && fail.children[r] == nil {
+				fail = fail.fail
+			}
+			if fail == nil {
+				child.fail = m.root
+			} else {
+				child.fail = fail.children[r]
+			}
+
+			if len(child.fail.terms) > 0 {
+				child.output = child.fail
+			} else {
+				child.output = child.fail.output
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+func newACNode(depth int) *acNode {
+	return &acNode{children: make(map[rune]*acNode), depth: depth}
+}
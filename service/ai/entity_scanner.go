@@ -2,81 +2,321 @@ package ai
 
 import (
 	"context"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 
 	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	baseInterfaces "Qingyu_backend/service/interfaces/base"
 )
 
+// defaultMatcherTTL 项目匹配自动机的缓存时间（兜底，正常情况下由实体变更事件失效）
+const defaultMatcherTTL = 30 * time.Minute
+
+// EntityLoader 按项目加载可识别的实体词条
+type EntityLoader func(ctx context.Context, projectID string) ([]EntityTerm, error)
+
+// 触发自动机失效的实体变更事件
+var entityScannerEventTypes = []string{
+	"character.created", "character.updated", "character.deleted",
+	"location.created", "location.updated", "location.deleted",
+	"item.created", "item.updated", "item.deleted",
+	"organization.created", "organization.updated", "organization.deleted",
+	"concept.created", "concept.updated", "concept.deleted",
+}
+
 // EntityScanner 实体扫描器
-// 基于已有实体库进行文本匹配，自动关联文档与实体
+// 基于已有实体库进行文本匹配，自动关联文档与实体。
+// 每个项目的名称与别名构建为一个 Aho-Corasick 自动机并缓存，实体增删改时失效
 type EntityScanner struct {
 	characterRepo writerRepo.CharacterRepository
 	locationRepo  writerRepo.LocationRepository
+
+	loaders  map[EntityKind]EntityLoader
+	cacheTTL time.Duration
+
+	mu       sync.RWMutex
+	matchers map[string]*cachedMatcher // projectID -> 自动机
+	versions map[string]int            // projectID -> 失效次数，避免重建期间的失效被覆盖
+}
+
+// cachedMatcher 缓存的项目自动机
+type cachedMatcher struct {
+	matcher *EntityMatcher
+	builtAt time.Time
 }
 
 func NewEntityScanner(
 	characterRepo writerRepo.CharacterRepository,
 	locationRepo writerRepo.LocationRepository,
 ) *EntityScanner {
-	return &EntityScanner{
+	s := &EntityScanner{
 		characterRepo: characterRepo,
 		locationRepo:  locationRepo,
+		loaders:       make(map[EntityKind]EntityLoader),
+		cacheTTL:      defaultMatcherTTL,
+		matchers:      make(map[string]*cachedMatcher),
+		versions:      make(map[string]int),
+	}
+	if characterRepo != nil {
+		s.loaders[EntityKindCharacter] = s.loadCharacters
+	}
+	if locationRepo != nil {
+		s.loaders[EntityKindLocation] = s.loadLocations
+	}
+	return s
+}
+
+// SetConceptRepository 启用设定概念识别
+func (s *EntityScanner) SetConceptRepository(conceptRepo writerRepo.ConceptRepository) {
+	if conceptRepo == nil {
+		return
+	}
+	s.RegisterLoader(EntityKindConcept, func(ctx context.Context, projectID string) ([]EntityTerm, error) {
+		concepts, err := conceptRepo.ListByProject(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		terms := make([]EntityTerm, 0, len(concepts))
+		for _, concept := range concepts {
+			terms = append(terms, EntityTerm{Kind: EntityKindConcept, ID: concept.ID, Name: concept.Name, Term: concept.Name})
+		}
+		return terms, nil
+	})
+}
+
+// SetItemRepository 启用物品识别（名称与别名）
+func (s *EntityScanner) SetItemRepository(itemRepo writerRepo.ItemRepository) {
+	if itemRepo == nil {
+		return
 	}
+	s.RegisterLoader(EntityKindItem, func(ctx context.Context, projectID string) ([]EntityTerm, error) {
+		items, err := itemRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		var terms []EntityTerm
+		for _, item := range items {
+			terms = append(terms, EntityTerm{Kind: EntityKindItem, ID: item.ID, Name: item.Name, Term: item.Name})
+			for _, alias := range item.Alias {
+				terms = append(terms, EntityTerm{Kind: EntityKindItem, ID: item.ID, Name: item.Name, Term: alias})
+			}
+		}
+		return terms, nil
+	})
+}
+
+// SetOrganizationRepository 启用组织识别（名称与别名）
+func (s *EntityScanner) SetOrganizationRepository(orgRepo writerRepo.OrganizationRepository) {
+	if orgRepo == nil {
+		return
+	}
+	s.RegisterLoader(EntityKindOrganization, func(ctx context.Context, projectID string) ([]EntityTerm, error) {
+		orgs, err := orgRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		var terms []EntityTerm
+		for _, org := range orgs {
+			terms = append(terms, EntityTerm{Kind: EntityKindOrganization, ID: org.ID, Name: org.Name, Term: org.Name})
+			for _, alias := range org.Alias {
+				terms = append(terms, EntityTerm{Kind: EntityKindOrganization, ID: org.ID, Name: org.Name, Term: alias})
+			}
+		}
+		return terms, nil
+	})
+}
+
+// RegisterLoader 注册实体来源（同类型重复注册时覆盖），并清空已缓存的自动机
+func (s *EntityScanner) RegisterLoader(kind EntityKind, loader EntityLoader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loaders[kind] = loader
+	s.matchers = make(map[string]*cachedMatcher)
+}
+
+// Invalidate 使项目的匹配自动机失效
+func (s *EntityScanner) Invalidate(projectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.matchers, projectID)
+	s.versions[projectID]++
 }
 
 // ScanResult 扫描结果
 type ScanResult struct {
-	Characters []MatchedEntity `json:"characters"`
-	Locations  []MatchedEntity `json:"locations"`
+	Characters    []MatchedEntity `json:"characters"`
+	Locations     []MatchedEntity `json:"locations"`
+	Items         []MatchedEntity `json:"items,omitempty"`
+	Organizations []MatchedEntity `json:"organizations,omitempty"`
+	Concepts      []MatchedEntity `json:"concepts,omitempty"`
 }
 
 // MatchedEntity 匹配到的实体
 type MatchedEntity struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Count       int                `json:"count"`
+	Occurrences []EntityOccurrence `json:"occurrences,omitempty"`
+}
+
+// EntityOccurrence 实体在文本中的一次出现
+type EntityOccurrence struct {
+	Start int    `json:"start"` // 起始位置（按字符计）
+	End   int    `json:"end"`
+	Term  string `json:"term"` // 命中的名称或别名
+}
+
+// MatchedEntityIDs 提取匹配实体的ID列表（保持结果顺序）
+func MatchedEntityIDs(entities []MatchedEntity) []string {
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+	return ids
 }
 
 // ScanContent 扫描文本内容，匹配已有实体
+// 结果按出现次数降序排列
 func (s *EntityScanner) ScanContent(ctx context.Context, projectID string, text string) (*ScanResult, error) {
-	result := &ScanResult{}
+	matcher, err := s.matcherFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[EntityKind]map[string]*MatchedEntity)
+	order := make(map[EntityKind][]string)
+	for _, match := range matcher.FindAll(text) {
+		byID := grouped[match.Kind]
+		if byID == nil {
+			byID = make(map[string]*MatchedEntity)
+			grouped[match.Kind] = byID
+		}
+		entity := byID[match.ID]
+		if entity == nil {
+			entity = &MatchedEntity{ID: match.ID, Name: match.Name}
+			byID[match.ID] = entity
+			order[match.Kind] = append(order[match.Kind], match.ID)
+		}
+		entity.Count++
+		entity.Occurrences = append(entity.Occurrences, EntityOccurrence{
+			Start: match.Start,
+			End:   match.End,
+			Term:  match.Term,
+		})
+	}
+
+	collect := func(kind EntityKind) []MatchedEntity {
+		ids := order[kind]
+		if len(ids) == 0 {
+			return nil
+		}
+		entities := make([]MatchedEntity, 0, len(ids))
+		for _, id := range ids {
+			entities = append(entities, *grouped[kind][id])
+		}
+		// 稳定排序：次数相同时保持首次出现的先后
+		sort.SliceStable(entities, func(i, j int) bool {
+			return entities[i].Count > entities[j].Count
+		})
+		return entities
+	}
+
+	return &ScanResult{
+		Characters:    collect(EntityKindCharacter),
+		Locations:     collect(EntityKindLocation),
+		Items:         collect(EntityKindItem),
+		Organizations: collect(EntityKindOrganization),
+		Concepts:      collect(EntityKindConcept),
+	}, nil
+}
+
+// matcherFor 获取项目的匹配自动机，未缓存或已过期时重建
+func (s *EntityScanner) matcherFor(ctx context.Context, projectID string) (*EntityMatcher, error) {
+	s.mu.RLock()
+	cached := s.matchers[projectID]
+	version := s.versions[projectID]
+	loaders := make(map[EntityKind]EntityLoader, len(s.loaders))
+	for kind, loader := range s.loaders {
+		loaders[kind] = loader
+	}
+	s.mu.RUnlock()
+
+	if cached != nil && time.Since(cached.builtAt) < s.cacheTTL {
+		return cached.matcher, nil
+	}
+
+	var terms []EntityTerm
+	for _, loader := range loaders {
+		loaded, err := loader(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, loaded...)
+	}
+
+	matcher := NewEntityMatcher(terms)
+	s.mu.Lock()
+	if s.versions[projectID] == version {
+		s.matchers[projectID] = &cachedMatcher{matcher: matcher, builtAt: time.Now()}
+	}
+	s.mu.Unlock()
+	return matcher, nil
+}
 
-	// 获取并匹配角色
+// loadCharacters 加载角色名称与别名
+func (s *EntityScanner) loadCharacters(ctx context.Context, projectID string) ([]EntityTerm, error) {
 	characters, err := s.characterRepo.FindByProjectID(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
+	var terms []EntityTerm
 	for _, char := range characters {
-		if strings.Contains(text, char.Name) {
-			result.Characters = append(result.Characters, MatchedEntity{
-				ID:   char.ID.Hex(),
-				Name: char.Name,
-			})
-			continue
-		}
+		id := char.ID.Hex()
+		terms = append(terms, EntityTerm{Kind: EntityKindCharacter, ID: id, Name: char.Name, Term: char.Name})
 		for _, alias := range char.Alias {
-			if strings.Contains(text, alias) {
-				result.Characters = append(result.Characters, MatchedEntity{
-					ID:   char.ID.Hex(),
-					Name: char.Name,
-				})
-				break
-			}
+			terms = append(terms, EntityTerm{Kind: EntityKindCharacter, ID: id, Name: char.Name, Term: alias})
 		}
 	}
+	return terms, nil
+}
 
-	// 获取并匹配地点
+// loadLocations 加载地点名称
+func (s *EntityScanner) loadLocations(ctx context.Context, projectID string) ([]EntityTerm, error) {
 	locations, err := s.locationRepo.FindByProjectID(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
+	terms := make([]EntityTerm, 0, len(locations))
 	for _, loc := range locations {
-		if strings.Contains(text, loc.Name) {
-			result.Locations = append(result.Locations, MatchedEntity{
-				ID:   loc.ID.Hex(),
-				Name: loc.Name,
-			})
-		}
+		terms = append(terms, EntityTerm{Kind: EntityKindLocation, ID: loc.ID.Hex(), Name: loc.Name, Term: loc.Name})
 	}
+	return terms, nil
+}
+
+// ============ 事件处理（实体变更时失效自动机） ============
+
+// Handle 处理实体变更事件
+func (s *EntityScanner) Handle(ctx context.Context, event baseInterfaces.Event) error {
+	if event == nil {
+		return nil
+	}
+	data, ok := event.GetEventData().(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if projectID, _ := data["project_id"].(string); projectID != "" {
+		s.Invalidate(projectID)
+	}
+	return nil
+}
+
+// GetHandlerName 获取处理器名称
+func (s *EntityScanner) GetHandlerName() string {
+	return "EntityScanner"
+}
 
-	return result, nil
+// GetSupportedEventTypes 获取支持的事件类型
+func (s *EntityScanner) GetSupportedEventTypes() []string {
+	return entityScannerEventTypes
 }
@@ -0,0 +1,193 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"Qingyu_backend/models/writer"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/service/base"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubCharacterRepo 仅实现按项目查询的角色仓储
+type stubCharacterRepo struct {
+	writerRepo.CharacterRepository
+	characters []*writer.Character
+	loads      int
+}
+
+func (r *stubCharacterRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Character, error) {
+	r.loads++
+	return r.characters, nil
+}
+
+// stubLocationRepo 仅实现按项目查询的地点仓储
+type stubLocationRepo struct {
+	writerRepo.LocationRepository
+	locations []*writer.Location
+}
+
+func (r *stubLocationRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Location, error) {
+	return r.locations, nil
+}
+
+// stubItemRepo 仅实现按项目查询的物品仓储
+type stubItemRepo struct {
+	writerRepo.ItemRepository
+	items []*writer.Item
+}
+
+func (r *stubItemRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Item, error) {
+	return r.items, nil
+}
+
+// stubOrganizationRepo 仅实现按项目查询的组织仓储
+type stubOrganizationRepo struct {
+	writerRepo.OrganizationRepository
+	orgs []*writer.Organization
+}
+
+func (r *stubOrganizationRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Organization, error) {
+	return r.orgs, nil
+}
+
+func newCharacter(name string, alias ...string) *writer.Character {
+	c := &writer.Character{Alias: alias}
+	c.ID = primitive.NewObjectID()
+	c.Name = name
+	return c
+}
+
+func newLocation(name string) *writer.Location {
+	l := &writer.Location{}
+	l.ID = primitive.NewObjectID()
+	l.Name = name
+	return l
+}
+
+func TestEntityMatcher_LeftmostLongest(t *testing.T) {
+	matcher := NewEntityMatcher([]EntityTerm{
+		{Kind: EntityKindCharacter, ID: "1", Name: "张三", Term: "张三"},
+		{Kind: EntityKindCharacter, ID: "2", Name: "张三丰", Term: "张三丰"},
+		{Kind: EntityKindLocation, ID: "3", Name: "武当山", Term: "武当山"},
+		{Kind: EntityKindConcept, ID: "4", Name: "Qi", Term: "Qi"},
+	})
+
+	matches := matcher.FindAll("张三丰上了武当山，张三在山下练qi。")
+	require.Len(t, matches, 4)
+
+	assert.Equal(t, "2", matches[0].ID)
+	assert.Equal(t, 0, matches[0].Start)
+	assert.Equal(t, 3, matches[0].End)
+	assert.Equal(t, "3", matches[1].ID)
+	assert.Equal(t, 5, matches[1].Start)
+	assert.Equal(t, "1", matches[2].ID)
+	assert.Equal(t, "4", matches[3].ID, "英文名不区分大小写")
+}
+
+func TestEntityMatcher_FailLinksFindSuffixMatches(t *testing.T) {
+	matcher := NewEntityMatcher([]EntityTerm{
+		{Kind: EntityKindLocation, ID: "1", Name: "长安城", Term: "长安城"},
+		{Kind: EntityKindLocation, ID: "2", Name: "安城", Term: "安城"},
+	})
+
+	// "长安" 之后失配，需经失败指针跳到 "安城" 分支
+	matches := matcher.FindAll("长安安城")
+	require.Len(t, matches, 1)
+	assert.Equal(t, "2", matches[0].ID)
+	assert.Equal(t, 2, matches[0].Start)
+}
+
+func TestEntityScanner_ScanContentMatchesAliasesWithCounts(t *testing.T) {
+	hero := newCharacter("林远", "阿远", "小林")
+	mentor := newCharacter("苏明")
+	city := newLocation("临安")
+	scanner := NewEntityScanner(
+		&stubCharacterRepo{characters: []*writer.Character{mentor, hero}},
+		&stubLocationRepo{locations: []*writer.Location{city}},
+	)
+	scanner.RegisterLoader(EntityKindItem, func(ctx context.Context, projectID string) ([]EntityTerm, error) {
+		return []EntityTerm{{Kind: EntityKindItem, ID: "sword", Name: "青锋剑", Term: "青锋剑"}}, nil
+	})
+
+	text := "林远回到临安。苏明问：阿远，青锋剑呢？小林沉默不语。"
+	result, err := scanner.ScanContent(context.Background(), "p1", text)
+	require.NoError(t, err)
+
+	require.Len(t, result.Characters, 2)
+	assert.Equal(t, hero.ID.Hex(), result.Characters[0].ID, "按出现次数降序")
+	assert.Equal(t, "林远", result.Characters[0].Name)
+	assert.Equal(t, 3, result.Characters[0].Count)
+	assert.Equal(t, "阿远", result.Characters[0].Occurrences[1].Term)
+
+	occ := result.Characters[0].Occurrences[2]
+	assert.Equal(t, "小林", string([]rune(text)[occ.Start:occ.End]))
+
+	require.Len(t, result.Locations, 1)
+	assert.Equal(t, 1, result.Locations[0].Count)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "sword", result.Items[0].ID)
+}
+
+func TestEntityScanner_ItemAndOrganizationLoaders(t *testing.T) {
+	scanner := NewEntityScanner(&stubCharacterRepo{}, &stubLocationRepo{})
+	scanner.SetItemRepository(&stubItemRepo{items: []*writer.Item{
+		{ID: "sword", Name: "青锋剑", Alias: []string{"青锋"}},
+	}})
+	scanner.SetOrganizationRepository(&stubOrganizationRepo{orgs: []*writer.Organization{
+		{ID: "sect", Name: "天机阁", Alias: []string{"天机"}},
+	}})
+
+	result, err := scanner.ScanContent(context.Background(), "p1", "天机阁主持青锋剑，天机众人皆知青锋之名。")
+	require.NoError(t, err)
+
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "sword", result.Items[0].ID)
+	assert.Equal(t, 2, result.Items[0].Count)
+	require.Len(t, result.Organizations, 1)
+	assert.Equal(t, "天机阁", result.Organizations[0].Name)
+	assert.Equal(t, 2, result.Organizations[0].Count)
+}
+
+func TestEntityScanner_CachesMatcherUntilEntityChanges(t *testing.T) {
+	repo := &stubCharacterRepo{characters: []*writer.Character{newCharacter("林远")}}
+	scanner := NewEntityScanner(repo, &stubLocationRepo{})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := scanner.ScanContent(ctx, "p1", "林远")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, repo.loads)
+
+	repo.characters = append(repo.characters, newCharacter("苏明"))
+	require.NoError(t, scanner.Handle(ctx, &base.BaseEvent{
+		EventType: "character.created",
+		EventData: map[string]interface{}{"project_id": "p1"},
+	}))
+
+	result, err := scanner.ScanContent(ctx, "p1", "林远与苏明")
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.loads)
+	assert.Len(t, result.Characters, 2)
+}
+
+func BenchmarkEntityScanner_ScanContent(b *testing.B) {
+	var characters []*writer.Character
+	for i := 0; i < 300; i++ {
+		characters = append(characters, newCharacter("角色"+string(rune('甲'+i)), "别名"+string(rune('乙'+i))))
+	}
+	scanner := NewEntityScanner(&stubCharacterRepo{characters: characters}, &stubLocationRepo{})
+	text := strings.Repeat("角色甲在别名乙的陪同下穿过长街。", 700)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = scanner.ScanContent(ctx, "p1", text)
+	}
+}
@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"Qingyu_backend/service/auth"
+)
+
+// JWTGatewayAuth 基于 JWT 的网关鉴权
+// 校验访问令牌并取出用户ID，用于配额扣减与按用户隔离的响应缓存；已登录用户均可调用
+type JWTGatewayAuth struct {
+	jwtService auth.JWTService
+}
+
+// NewJWTGatewayAuth 创建 JWT 网关鉴权
+func NewJWTGatewayAuth(jwtService auth.JWTService) *JWTGatewayAuth {
+	return &JWTGatewayAuth{jwtService: jwtService}
+}
+
+// Authenticate 校验令牌并返回用户ID
+func (a *JWTGatewayAuth) Authenticate(ctx context.Context, token string) (string, error) {
+	claims, err := a.jwtService.ValidateToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if claims == nil || claims.UserID == "" {
+		return "", fmt.Errorf("令牌缺少用户信息")
+	}
+	return claims.UserID, nil
+}
+
+// Authorize 已通过鉴权的用户均可调用网关
+func (a *JWTGatewayAuth) Authorize(ctx context.Context, userID, action string) bool {
+	return userID != ""
+}
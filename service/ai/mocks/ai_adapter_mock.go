@@ -211,6 +211,66 @@ func (m *MockAIAdapter) TextGenerationStream(ctx context.Context, req *adapter.T
 	return respChan, nil
 }
 
+// ChatCompletionStream 流式对话
+// 预设了 ChatResponse 时按其内容下发（含工具调用），否则下发默认片段
+func (m *MockAIAdapter) ChatCompletionStream(ctx context.Context, req *adapter.ChatCompletionRequest) (<-chan *adapter.ChatCompletionChunk, error) {
+	m.CallCount++
+	m.LastRequest = req
+
+	// 模拟失败
+	if m.ShouldFail {
+		if m.FailureError != nil {
+			return nil, m.FailureError
+		}
+		return nil, &adapter.AdapterError{
+			Type:       adapter.ErrorTypeServiceUnavailable,
+			Message:    "模拟的服务错误",
+			Code:       "service_error",
+			StatusCode: 500,
+			Provider:   m.Name,
+			Retryable:  true,
+		}
+	}
+
+	chunks := []*adapter.ChatCompletionChunk{
+		{ID: "mock-chat-stream-id", Model: "mock-model", Delta: "流式对话片段"},
+		{ID: "mock-chat-stream-id", Model: "mock-model", Delta: "流式对话片段"},
+		{
+			ID:           "mock-chat-stream-id",
+			Model:        "mock-model",
+			FinishReason: "stop",
+			Usage:        &adapter.Usage{PromptTokens: 15, CompletionTokens: 10, TotalTokens: 25},
+		},
+	}
+	if m.ChatResponse != nil {
+		usage := m.ChatResponse.Usage
+		chunks = []*adapter.ChatCompletionChunk{{
+			ID:           m.ChatResponse.ID,
+			Model:        m.ChatResponse.Model,
+			Delta:        m.ChatResponse.Message.Content,
+			ToolCalls:    m.ChatResponse.Message.ToolCalls,
+			FinishReason: m.ChatResponse.FinishReason,
+			Usage:        &usage,
+		}}
+	}
+
+	// 创建响应通道
+	chunkChan := make(chan *adapter.ChatCompletionChunk, len(chunks))
+
+	go func() {
+		defer close(chunkChan)
+		for _, chunk := range chunks {
+			select {
+			case <-ctx.Done():
+				return
+			case chunkChan <- chunk:
+			}
+		}
+	}()
+
+	return chunkChan, nil
+}
+
 // ImageGeneration 图像生成
 func (m *MockAIAdapter) ImageGeneration(ctx context.Context, req *adapter.ImageGenerationRequest) (*adapter.ImageGenerationResponse, error) {
 	m.CallCount++
@@ -0,0 +1,287 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"Qingyu_backend/config"
+	"Qingyu_backend/models/ai"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultReservationTTL      = 10 * time.Minute // 预留默认有效期，超过即视为流已被放弃
+	expiredReservationBatch    = 100              // 单批处理的过期预留数
+	defaultReservationSweepGap = time.Minute
+)
+
+// ============ 计价 ============
+
+// CalculateQuotaCost 按模型计价倍率把 token 数换算为配额
+func CalculateQuotaCost(model string, tokens int) int {
+	return applyMultiplier(tokens, modelMultiplier(model))
+}
+
+// modelMultiplier 获取模型计价倍率（配置缺失时为 1）
+func modelMultiplier(model string) float64 {
+	if config.GlobalConfig == nil {
+		return 1
+	}
+	return config.GlobalConfig.AIQuota.GetModelMultiplier(model)
+}
+
+func applyMultiplier(tokens int, multiplier float64) int {
+	if tokens <= 0 {
+		return 0
+	}
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return int(math.Ceil(float64(tokens) * multiplier))
+}
+
+// reservationTTL 预留有效期
+func reservationTTL() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.AIQuota != nil && config.GlobalConfig.AIQuota.ReservationTTL > 0 {
+		return time.Duration(config.GlobalConfig.AIQuota.ReservationTTL) * time.Second
+	}
+	return defaultReservationTTL
+}
+
+// ============ 预授权与结算 ============
+
+// ReserveQuota 预扣配额（两阶段计费第一步）
+// 按估算 token 数与模型倍率预扣，余额不足时直接拒绝，避免长流式生成超额
+func (s *QuotaService) ReserveQuota(ctx context.Context, userID string, estimatedTokens int, service, model, requestID string) (*ai.QuotaReservation, error) {
+	multiplier := modelMultiplier(model)
+	amount := applyMultiplier(estimatedTokens, multiplier)
+
+	// 复用检查逻辑（含配额初始化与自动升级）
+	if err := s.CheckQuota(ctx, userID, amount); err != nil {
+		return nil, err
+	}
+
+	// 余额检查与扣减由仓储在一次条件更新中完成，并发请求不会超额
+	quota, err := s.quotaRepo.DeductQuota(ctx, userID, ai.QuotaTypeDaily, amount)
+	if err != nil {
+		return nil, err
+	}
+	beforeBalance := quota.RemainingQuota + amount
+	s.invalidateQuotaCache(ctx, userID, ai.QuotaTypeDaily)
+
+	now := time.Now()
+	reservation := &ai.QuotaReservation{
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		QuotaType:       ai.QuotaTypeDaily,
+		EstimatedTokens: estimatedTokens,
+		ReservedAmount:  amount,
+		Multiplier:      multiplier,
+		Service:         service,
+		Model:           model,
+		RequestID:       requestID,
+		Status:          ai.ReservationStatusReserved,
+		ExpiresAt:       now.Add(reservationTTL()),
+		CreatedAt:       now,
+	}
+	if err := s.quotaRepo.CreateReservation(ctx, reservation); err != nil {
+		// 预留记录写入失败时退回预扣，避免配额被无记录地占用
+		if _, restoreErr := s.quotaRepo.AdjustUsedQuota(ctx, userID, ai.QuotaTypeDaily, -amount); restoreErr != nil {
+			fmt.Printf("警告: 回滚预扣配额失败: %v\n", restoreErr)
+		}
+		s.invalidateQuotaCache(ctx, userID, ai.QuotaTypeDaily)
+		return nil, fmt.Errorf("创建配额预留失败: %w", err)
+	}
+
+	s.checkAndPublishWarning(ctx, quota)
+
+	transaction := &ai.QuotaTransaction{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		QuotaType:     ai.QuotaTypeDaily,
+		Amount:        amount,
+		Type:          "reserve",
+		Service:       service,
+		Model:         model,
+		RequestID:     requestID,
+		Description:   fmt.Sprintf("预扣%d配额用于%s服务（估算%d tokens）", amount, service, estimatedTokens),
+		BeforeBalance: beforeBalance,
+		AfterBalance:  quota.RemainingQuota,
+		Timestamp:     now,
+	}
+	if err := s.quotaRepo.CreateTransaction(ctx, transaction); err != nil {
+		fmt.Printf("警告: 记录配额预扣失败: %v\n", err)
+	}
+
+	return reservation, nil
+}
+
+// SettleQuota 按实际用量结算预留（两阶段计费第二步）
+// 实际用量少于预扣时退还差额，超出时补扣；同一预留只能结算一次
+func (s *QuotaService) SettleQuota(ctx context.Context, reservationID string, actualTokens int) (*ai.QuotaReservation, error) {
+	reservation, err := s.quotaRepo.GetReservation(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if !reservation.IsPending() {
+		return nil, ai.ErrReservationClosed
+	}
+
+	settledAmount := applyMultiplier(actualTokens, reservation.Multiplier)
+	closed, err := s.quotaRepo.CloseReservation(ctx, reservationID, ai.ReservationStatusSettled, actualTokens, settledAmount)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		// 并发结算或已过期退回
+		return nil, ai.ErrReservationClosed
+	}
+
+	// 补扣超出部分或退还差额；实际用量超出预留时不检查余额，如实记账
+	diff := settledAmount - reservation.ReservedAmount
+	quota, err := s.quotaRepo.AdjustUsedQuota(ctx, reservation.UserID, reservation.QuotaType, diff)
+	if err != nil {
+		return nil, err
+	}
+	beforeBalance := quota.RemainingQuota + diff
+	s.invalidateQuotaCache(ctx, reservation.UserID, reservation.QuotaType)
+	s.checkAndPublishWarning(ctx, quota)
+
+	now := time.Now()
+	transaction := &ai.QuotaTransaction{
+		ID:        primitive.NewObjectID(),
+		UserID:    reservation.UserID,
+		QuotaType: reservation.QuotaType,
+		Amount:    settledAmount,
+		Type:      "consume",
+		Service:   reservation.Service,
+		Model:     reservation.Model,
+		RequestID: reservation.RequestID,
+		Description: fmt.Sprintf("结算%d配额用于%s服务（预扣%d，实际%d tokens）",
+			settledAmount, reservation.Service, reservation.ReservedAmount, actualTokens),
+		BeforeBalance: beforeBalance,
+		AfterBalance:  quota.RemainingQuota,
+		Timestamp:     now,
+	}
+	if err := s.quotaRepo.CreateTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	reservation.Status = ai.ReservationStatusSettled
+	reservation.ActualTokens = actualTokens
+	reservation.SettledAmount = settledAmount
+	reservation.SettledAt = &now
+	return reservation, nil
+}
+
+// ReleaseQuota 全额退回预留（请求失败、未产生用量时调用）
+func (s *QuotaService) ReleaseQuota(ctx context.Context, reservationID, reason string) error {
+	reservation, err := s.quotaRepo.GetReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if !reservation.IsPending() {
+		return ai.ErrReservationClosed
+	}
+	return s.refundReservation(ctx, reservation, ai.ReservationStatusReleased, reason)
+}
+
+// ExpireReservations 退回已过期仍未结算的预留（被放弃的流式请求），返回处理数量
+func (s *QuotaService) ExpireReservations(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		reservations, err := s.quotaRepo.ListExpiredReservations(ctx, time.Now(), expiredReservationBatch)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, reservation := range reservations {
+			err := s.refundReservation(ctx, reservation, ai.ReservationStatusExpired, "配额预留超时未结算，自动退回")
+			if err == ai.ErrReservationClosed {
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(reservations) < expiredReservationBatch {
+			return expired, nil
+		}
+	}
+}
+
+// StartReservationSweeper 启动定时退回过期预留的任务
+func (s *QuotaService) StartReservationSweeper(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultReservationSweepGap
+	}
+	if s.sweepStop != nil {
+		return
+	}
+	s.sweepStop = make(chan struct{})
+	stop := s.sweepStop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ExpireReservations(context.Background()); err != nil {
+					fmt.Printf("退回过期配额预留失败: %v\n", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopReservationSweeper 停止过期预留退回任务
+func (s *QuotaService) StopReservationSweeper() {
+	if s.sweepStop != nil {
+		close(s.sweepStop)
+		s.sweepStop = nil
+	}
+}
+
+// refundReservation 关闭预留并全额退回预扣配额
+func (s *QuotaService) refundReservation(ctx context.Context, reservation *ai.QuotaReservation, status ai.ReservationStatus, reason string) error {
+	reservationID := reservation.ID.Hex()
+	closed, err := s.quotaRepo.CloseReservation(ctx, reservationID, status, 0, 0)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ai.ErrReservationClosed
+	}
+
+	quota, err := s.quotaRepo.AdjustUsedQuota(ctx, reservation.UserID, reservation.QuotaType, -reservation.ReservedAmount)
+	if err != nil {
+		return err
+	}
+	beforeBalance := quota.RemainingQuota - reservation.ReservedAmount
+	s.invalidateQuotaCache(ctx, reservation.UserID, reservation.QuotaType)
+
+	transaction := &ai.QuotaTransaction{
+		ID:            primitive.NewObjectID(),
+		UserID:        reservation.UserID,
+		QuotaType:     reservation.QuotaType,
+		Amount:        -reservation.ReservedAmount, // 负数表示退回
+		Type:          string(status),
+		Service:       reservation.Service,
+		Model:         reservation.Model,
+		RequestID:     reservation.RequestID,
+		Description:   reason,
+		BeforeBalance: beforeBalance,
+		AfterBalance:  quota.RemainingQuota,
+		Timestamp:     time.Now(),
+	}
+	return s.quotaRepo.CreateTransaction(ctx, transaction)
+}
@@ -0,0 +1,338 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"Qingyu_backend/config"
+	"Qingyu_backend/models/ai"
+	aiRepo "Qingyu_backend/repository/interfaces/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryQuotaRepository 内存配额仓储（仅用于测试）
+type memoryQuotaRepository struct {
+	mu           sync.Mutex
+	quotas       map[string]*ai.UserQuota
+	reservations map[string]*ai.QuotaReservation
+	transactions []*ai.QuotaTransaction
+}
+
+func newMemoryQuotaRepository(quotas ...*ai.UserQuota) *memoryQuotaRepository {
+	r := &memoryQuotaRepository{
+		quotas:       make(map[string]*ai.UserQuota),
+		reservations: make(map[string]*ai.QuotaReservation),
+	}
+	for _, q := range quotas {
+		r.quotas[q.UserID] = q
+	}
+	return r
+}
+
+func (r *memoryQuotaRepository) CreateQuota(ctx context.Context, quota *ai.UserQuota) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.quotas[quota.UserID] = quota
+	return nil
+}
+
+func (r *memoryQuotaRepository) GetQuotaByUserID(ctx context.Context, userID string, quotaType ai.QuotaType) (*ai.UserQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.quotas[userID]
+	if !ok {
+		return nil, ai.ErrQuotaNotFound
+	}
+	copied := *q
+	return &copied, nil
+}
+
+func (r *memoryQuotaRepository) UpdateQuota(ctx context.Context, quota *ai.UserQuota) error {
+	return r.CreateQuota(ctx, quota)
+}
+
+func (r *memoryQuotaRepository) DeductQuota(ctx context.Context, userID string, quotaType ai.QuotaType, amount int) (*ai.UserQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.quotas[userID]
+	if !ok {
+		return nil, ai.ErrQuotaNotFound
+	}
+	if err := q.Consume(amount); err != nil {
+		return nil, ai.ErrInsufficientQuota
+	}
+	copied := *q
+	return &copied, nil
+}
+
+func (r *memoryQuotaRepository) AdjustUsedQuota(ctx context.Context, userID string, quotaType ai.QuotaType, delta int) (*ai.UserQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.quotas[userID]
+	if !ok {
+		return nil, ai.ErrQuotaNotFound
+	}
+	q.UsedQuota += delta
+	q.BeforeUpdate()
+	copied := *q
+	return &copied, nil
+}
+
+func (r *memoryQuotaRepository) DeleteQuota(ctx context.Context, userID string, quotaType ai.QuotaType) error {
+	return nil
+}
+
+func (r *memoryQuotaRepository) GetAllQuotasByUserID(ctx context.Context, userID string) ([]*ai.UserQuota, error) {
+	return nil, nil
+}
+
+func (r *memoryQuotaRepository) BatchResetQuotas(ctx context.Context, quotaType ai.QuotaType) error {
+	return nil
+}
+
+func (r *memoryQuotaRepository) CreateTransaction(ctx context.Context, transaction *ai.QuotaTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transactions = append(r.transactions, transaction)
+	return nil
+}
+
+func (r *memoryQuotaRepository) GetTransactionsByUserID(ctx context.Context, userID string, limit, offset int) ([]*ai.QuotaTransaction, error) {
+	return nil, nil
+}
+
+func (r *memoryQuotaRepository) GetTransactionsByTimeRange(ctx context.Context, userID string, startTime, endTime time.Time) ([]*ai.QuotaTransaction, error) {
+	return nil, nil
+}
+
+func (r *memoryQuotaRepository) CreateReservation(ctx context.Context, reservation *ai.QuotaReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *reservation
+	r.reservations[reservation.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryQuotaRepository) GetReservation(ctx context.Context, reservationID string) (*ai.QuotaReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[reservationID]
+	if !ok {
+		return nil, ai.ErrReservationNotFound
+	}
+	copied := *reservation
+	return &copied, nil
+}
+
+func (r *memoryQuotaRepository) CloseReservation(ctx context.Context, reservationID string, status ai.ReservationStatus, actualTokens, settledAmount int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[reservationID]
+	if !ok || !reservation.IsPending() {
+		return false, nil
+	}
+	now := time.Now()
+	reservation.Status = status
+	reservation.ActualTokens = actualTokens
+	reservation.SettledAmount = settledAmount
+	reservation.SettledAt = &now
+	return true, nil
+}
+
+func (r *memoryQuotaRepository) ListExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*ai.QuotaReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*ai.QuotaReservation
+	for _, reservation := range r.reservations {
+		if reservation.IsPending() && reservation.ExpiresAt.Before(before) && len(result) < limit {
+			copied := *reservation
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryQuotaRepository) GetQuotaStatistics(ctx context.Context, userID string) (*aiRepo.QuotaStatistics, error) {
+	return nil, nil
+}
+
+func (r *memoryQuotaRepository) GetTotalConsumption(ctx context.Context, userID string, quotaType ai.QuotaType, startTime, endTime time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, tx := range r.transactions {
+		if tx.UserID == userID && tx.Type == "consume" {
+			total += tx.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *memoryQuotaRepository) Health(ctx context.Context) error {
+	return nil
+}
+
+func newTestQuota(userID string, total int) *ai.UserQuota {
+	return &ai.UserQuota{
+		UserID:         userID,
+		QuotaType:      ai.QuotaTypeDaily,
+		TotalQuota:     total,
+		RemainingQuota: total,
+		Status:         ai.QuotaStatusActive,
+		ResetAt:        time.Now().Add(24 * time.Hour),
+	}
+}
+
+func withQuotaConfig(t *testing.T, cfg *config.AIQuotaConfig) {
+	original := config.GlobalConfig
+	config.GlobalConfig = &config.Config{AIQuota: cfg}
+	t.Cleanup(func() { config.GlobalConfig = original })
+}
+
+func TestQuotaService_ReserveAndSettleRefundsDifference(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{ModelMultipliers: map[string]float64{"gpt-4": 2}})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 10000))
+	service := NewQuotaService(repo)
+	ctx := context.Background()
+
+	reservation, err := service.ReserveQuota(ctx, "u1", 1500, "chat", "gpt-4-turbo", "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3000, reservation.ReservedAmount)
+
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 7000, quota.RemainingQuota)
+
+	settled, err := service.SettleQuota(ctx, reservation.ID.Hex(), 400)
+	require.NoError(t, err)
+	assert.Equal(t, 800, settled.SettledAmount)
+
+	quota, _ = repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 9200, quota.RemainingQuota)
+
+	consumed, err := repo.GetTotalConsumption(ctx, "u1", ai.QuotaTypeDaily, time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 800, consumed, "统计只计入实际结算的用量")
+}
+
+func TestQuotaService_SettleChargesOverrun(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 1000))
+	service := NewQuotaService(repo)
+	ctx := context.Background()
+
+	reservation, err := service.ReserveQuota(ctx, "u1", 900, "chat", "", "req-1")
+	require.NoError(t, err)
+
+	_, err = service.SettleQuota(ctx, reservation.ID.Hex(), 1100)
+	require.NoError(t, err)
+
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 1100, quota.UsedQuota)
+	assert.Equal(t, ai.QuotaStatusExhausted, quota.Status)
+}
+
+func TestQuotaService_ReserveRejectsInsufficientQuota(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 500))
+	service := NewQuotaService(repo)
+
+	_, err := service.ReserveQuota(context.Background(), "u1", 1024, "chat", "", "req-1")
+	assert.ErrorIs(t, err, ai.ErrInsufficientQuota)
+	assert.Empty(t, repo.reservations)
+}
+
+func TestQuotaService_ConcurrentReservationsCannotExceedQuota(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 10000))
+	service := NewQuotaService(repo)
+	ctx := context.Background()
+
+	// 20 个并发流各预扣 1000，只有 10 个能成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ReserveQuota(ctx, "u1", 1000, "chat", "", ""); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, reserved)
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 10000, quota.UsedQuota)
+	assert.Equal(t, 0, quota.RemainingQuota)
+	assert.Equal(t, ai.QuotaStatusExhausted, quota.Status)
+	assert.Len(t, repo.reservations, 10)
+}
+
+func TestQuotaService_SettleTwiceIsRejected(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 10000))
+	service := NewQuotaService(repo)
+	ctx := context.Background()
+
+	reservation, err := service.ReserveQuota(ctx, "u1", 1000, "chat", "", "req-1")
+	require.NoError(t, err)
+
+	_, err = service.SettleQuota(ctx, reservation.ID.Hex(), 200)
+	require.NoError(t, err)
+	_, err = service.SettleQuota(ctx, reservation.ID.Hex(), 200)
+	assert.ErrorIs(t, err, ai.ErrReservationClosed)
+	assert.ErrorIs(t, service.ReleaseQuota(ctx, reservation.ID.Hex(), "重复退回"), ai.ErrReservationClosed)
+
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 9800, quota.RemainingQuota)
+}
+
+func TestQuotaService_ReleaseAndExpireRefundInFull(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 10000))
+	service := NewQuotaService(repo)
+	ctx := context.Background()
+
+	released, err := service.ReserveQuota(ctx, "u1", 1000, "chat", "", "req-1")
+	require.NoError(t, err)
+	abandoned, err := service.ReserveQuota(ctx, "u1", 2000, "chat", "", "req-2")
+	require.NoError(t, err)
+
+	require.NoError(t, service.ReleaseQuota(ctx, released.ID.Hex(), "流启动失败"))
+
+	// 模拟客户端放弃后预留超时
+	repo.reservations[abandoned.ID.Hex()].ExpiresAt = time.Now().Add(-time.Second)
+	expired, err := service.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, ai.ReservationStatusExpired, repo.reservations[abandoned.ID.Hex()].Status)
+
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 10000, quota.RemainingQuota)
+
+	// 已过期的预留不能再结算
+	_, err = service.SettleQuota(ctx, abandoned.ID.Hex(), 100)
+	assert.ErrorIs(t, err, ai.ErrReservationClosed)
+}
+
+func TestCalculateQuotaCost(t *testing.T) {
+	withQuotaConfig(t, &config.AIQuotaConfig{ModelMultipliers: map[string]float64{
+		"gpt-4":         3,
+		"gpt-4o":        1.5,
+		"deepseek-chat": 0.5,
+	}})
+
+	assert.Equal(t, 300, CalculateQuotaCost("gpt-4", 100))
+	assert.Equal(t, 150, CalculateQuotaCost("gpt-4o-mini", 100), "取最长前缀")
+	assert.Equal(t, 51, CalculateQuotaCost("deepseek-chat", 101), "向上取整")
+	assert.Equal(t, 100, CalculateQuotaCost("glm-4", 100), "未配置倍率按 1 计")
+	assert.Equal(t, 0, CalculateQuotaCost("gpt-4", 0))
+}
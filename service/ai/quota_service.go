@@ -27,6 +27,8 @@ type QuotaService struct {
 	// 预警阈值配置
 	warningThreshold  float64 // 预警阈值（默认20%）
 	criticalThreshold float64 // 严重阈值（默认10%）
+
+	sweepStop chan struct{} // 过期预留退回任务停止信号
 }
 
 // NewQuotaService 创建配额服务（基础版，无缓存）
@@ -0,0 +1,418 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Qingyu_backend/config"
+	"Qingyu_backend/pkg/cache"
+	"Qingyu_backend/pkg/metrics"
+	"Qingyu_backend/service/ai/adapter"
+)
+
+const (
+	responseCacheKeyPrefix       = "ai:resp_cache"
+	defaultSimilarityThreshold   = 0.97
+	defaultMaxSemanticEntries    = 500
+	responseCacheResultExact     = "exact"
+	responseCacheResultSemantic  = "semantic"
+	responseCacheResultMiss      = "miss"
+	responseCacheAnonymousUserID = "anonymous"
+)
+
+// TextEmbedder 文本向量化接口（search.EmbeddingClient 已实现）
+type TextEmbedder interface {
+	GetEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// CachedResponse 缓存的AI响应
+type CachedResponse struct {
+	Text     string           `json:"text,omitempty"`
+	Message  *adapter.Message `json:"message,omitempty"`
+	Usage    adapter.Usage    `json:"usage"`
+	Model    string           `json:"model"`
+	Provider string           `json:"provider"`
+	CachedAt time.Time        `json:"cachedAt"`
+}
+
+// Data 还原为网关响应数据
+func (r *CachedResponse) Data() interface{} {
+	if r.Message != nil {
+		return *r.Message
+	}
+	return r.Text
+}
+
+// ResponseCacheStats 缓存命中统计
+type ResponseCacheStats struct {
+	ExactHits    int64   `json:"exactHits"`
+	SemanticHits int64   `json:"semanticHits"`
+	Misses       int64   `json:"misses"`
+	HitRate      float64 `json:"hitRate"`
+}
+
+// memoryCacheEntry 本地缓存条目（未配置 Redis 时使用）
+type memoryCacheEntry struct {
+	response  *CachedResponse
+	expiresAt time.Time
+}
+
+// semanticEntry 语义索引条目
+type semanticEntry struct {
+	scope     string // 模型参数指纹，仅同参数请求之间做相似匹配
+	key       string // 对应的精确缓存键
+	vector    []float32
+	expiresAt time.Time
+}
+
+// cacheProbe 一次缓存查询的上下文，未命中时复用于写入
+type cacheProbe struct {
+	userID string
+	task   string
+	key    string
+	scope  string
+	ttl    time.Duration
+	vector []float32
+}
+
+// ResponseCache AI响应缓存
+// 精确匹配按请求内容与模型参数的哈希命中；启用语义匹配时，对同参数请求按向量相似度命中近似请求。
+// 所有缓存键和语义索引都按用户隔离，不同用户之间不会共享生成结果。
+// 配置 Redis 时精确缓存在多实例间共享；语义索引始终保存在进程内存中，
+// 只能命中本实例写入的近似请求，重启后需重新积累（未命中时仍会回退到精确匹配）。
+type ResponseCache struct {
+	config      *config.AIResponseCacheConfig
+	redisClient cache.RedisClient
+	embedder    TextEmbedder
+
+	mu       sync.RWMutex
+	entries  map[string]*memoryCacheEntry
+	semantic map[string][]*semanticEntry // userID -> 语义索引
+
+	exactHits    atomic.Int64
+	semanticHits atomic.Int64
+	misses       atomic.Int64
+}
+
+// NewResponseCache 创建AI响应缓存
+// redisClient 为空时使用进程内存储；embedder 为空时只做精确匹配
+func NewResponseCache(cfg *config.AIResponseCacheConfig, redisClient cache.RedisClient, embedder TextEmbedder) *ResponseCache {
+	return &ResponseCache{
+		config:      cfg,
+		redisClient: redisClient,
+		embedder:    embedder,
+		entries:     make(map[string]*memoryCacheEntry),
+		semantic:    make(map[string][]*semanticEntry),
+	}
+}
+
+// GetStats 获取命中统计
+func (c *ResponseCache) GetStats() ResponseCacheStats {
+	stats := ResponseCacheStats{
+		ExactHits:    c.exactHits.Load(),
+		SemanticHits: c.semanticHits.Load(),
+		Misses:       c.misses.Load(),
+	}
+	if total := stats.ExactHits + stats.SemanticHits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.ExactHits+stats.SemanticHits) / float64(total)
+	}
+	return stats
+}
+
+// Invalidate 清除用户的全部缓存（如用户删除作品或要求清除 AI 记录时）
+func (c *ResponseCache) Invalidate(ctx context.Context, userID string) error {
+	c.mu.Lock()
+	delete(c.semantic, userID)
+	prefix := userKeyPrefix(userID)
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.redisClient == nil {
+		return nil
+	}
+	indexKey := userIndexKey(userID)
+	keys, err := c.redisClient.SMembers(ctx, indexKey)
+	if err != nil {
+		return fmt.Errorf("读取缓存索引失败: %w", err)
+	}
+	return c.redisClient.Delete(ctx, append(keys, indexKey)...)
+}
+
+// lookup 查询缓存，返回命中的响应；请求不可缓存时 probe 为 nil
+func (c *ResponseCache) lookup(ctx context.Context, userID string, req *UnifiedRequest) (*CachedResponse, *cacheProbe) {
+	if c == nil || req.NoCache || userID == "" || userID == responseCacheAnonymousUserID {
+		return nil, nil
+	}
+	task := requestTask(req)
+	ttl := c.config.GetTaskTTL(task)
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	scope := hashRequest(task, req, false)
+	probe := &cacheProbe{
+		userID: userID,
+		task:   task,
+		key:    userKeyPrefix(userID) + hashRequest(task, req, true),
+		scope:  scope,
+		ttl:    ttl,
+	}
+
+	if resp := c.get(ctx, probe.key); resp != nil {
+		c.record(task, responseCacheResultExact, resp)
+		return resp, nil
+	}
+
+	if c.semanticEnabled() {
+		if text := requestText(req); text != "" {
+			vector, err := c.embedder.GetEmbedding(ctx, text)
+			if err != nil {
+				fmt.Printf("警告: 缓存语义向量化失败: %v\n", err)
+			} else {
+				probe.vector = vector
+				if key := c.nearest(userID, scope, vector); key != "" {
+					if resp := c.get(ctx, key); resp != nil {
+						c.record(task, responseCacheResultSemantic, resp)
+						return resp, nil
+					}
+				}
+			}
+		}
+	}
+
+	c.record(task, responseCacheResultMiss, nil)
+	return nil, probe
+}
+
+// store 写入缓存
+func (c *ResponseCache) store(ctx context.Context, probe *cacheProbe, resp *CachedResponse) {
+	if c == nil || probe == nil || resp == nil {
+		return
+	}
+	resp.CachedAt = time.Now()
+	expiresAt := resp.CachedAt.Add(probe.ttl)
+
+	if c.redisClient != nil {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		if err := c.redisClient.Set(ctx, probe.key, string(data), probe.ttl); err != nil {
+			fmt.Printf("警告: 写入AI响应缓存失败: %v\n", err)
+			return
+		}
+		// 用户索引随每次写入续期到最长缓存时间，保证不早于其中任何条目过期
+		indexKey := userIndexKey(probe.userID)
+		if err := c.redisClient.SAdd(ctx, indexKey, probe.key); err != nil {
+			fmt.Printf("警告: 写入AI响应缓存索引失败: %v\n", err)
+		} else if err := c.redisClient.Expire(ctx, indexKey, c.indexTTL(probe.ttl)); err != nil {
+			fmt.Printf("警告: 设置AI响应缓存索引过期时间失败: %v\n", err)
+		}
+	} else {
+		c.mu.Lock()
+		c.entries[probe.key] = &memoryCacheEntry{response: resp, expiresAt: expiresAt}
+		c.mu.Unlock()
+	}
+
+	if probe.vector != nil {
+		c.addSemanticEntry(probe.userID, &semanticEntry{
+			scope:     probe.scope,
+			key:       probe.key,
+			vector:    probe.vector,
+			expiresAt: expiresAt,
+		})
+	}
+}
+
+// get 读取精确缓存
+func (c *ResponseCache) get(ctx context.Context, key string) *CachedResponse {
+	if c.redisClient != nil {
+		data, err := c.redisClient.Get(ctx, key)
+		if err != nil || data == "" {
+			return nil
+		}
+		var resp CachedResponse
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			return nil
+		}
+		return &resp
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return nil
+	}
+	return entry.response
+}
+
+// nearest 在用户的语义索引中查找相似度最高且超过阈值的条目
+func (c *ResponseCache) nearest(userID, scope string, vector []float32) string {
+	threshold := c.config.SimilarityThreshold
+	if threshold <= 0 {
+		threshold = defaultSimilarityThreshold
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	bestKey := ""
+	bestScore := threshold
+	for _, entry := range c.semantic[userID] {
+		if entry.scope != scope || now.After(entry.expiresAt) {
+			continue
+		}
+		if score := cosineSimilarity(vector, entry.vector); score >= bestScore {
+			bestKey = entry.key
+			bestScore = score
+		}
+	}
+	return bestKey
+}
+
+// addSemanticEntry 写入语义索引，顺带清理过期条目并限制每个用户的条目数
+func (c *ResponseCache) addSemanticEntry(userID string, entry *semanticEntry) {
+	limit := c.config.MaxEntriesPerUser
+	if limit <= 0 {
+		limit = defaultMaxSemanticEntries
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries := c.semantic[userID][:0]
+	for _, existing := range c.semantic[userID] {
+		if now.Before(existing.expiresAt) && existing.key != entry.key {
+			entries = append(entries, existing)
+		}
+	}
+	entries = append(entries, entry)
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	c.semantic[userID] = entries
+}
+
+// indexTTL 用户索引的过期时间：取所有任务缓存时间中的最大值
+func (c *ResponseCache) indexTTL(ttl time.Duration) time.Duration {
+	maxTTL := ttl
+	if c.config == nil {
+		return maxTTL
+	}
+	if d := time.Duration(c.config.DefaultTTL) * time.Second; d > maxTTL {
+		maxTTL = d
+	}
+	for _, seconds := range c.config.TaskTTLs {
+		if d := time.Duration(seconds) * time.Second; d > maxTTL {
+			maxTTL = d
+		}
+	}
+	return maxTTL
+}
+
+func (c *ResponseCache) semanticEnabled() bool {
+	return c.embedder != nil && c.config != nil && c.config.Semantic
+}
+
+// record 记录命中统计
+func (c *ResponseCache) record(task, result string, resp *CachedResponse) {
+	switch result {
+	case responseCacheResultExact:
+		c.exactHits.Add(1)
+	case responseCacheResultSemantic:
+		c.semanticHits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+
+	m := metrics.GetDefaultMetrics()
+	m.AICacheRequests.WithLabelValues(task, result).Inc()
+	if resp != nil && resp.Usage.TotalTokens > 0 {
+		m.AICacheTokens.WithLabelValues(task).Add(float64(resp.Usage.TotalTokens))
+	}
+}
+
+// requestTask 缓存使用的任务类型：优先取写作任务，其次为网关任务类型
+func requestTask(req *UnifiedRequest) string {
+	if req.Task != "" {
+		return req.Task
+	}
+	return req.TaskType
+}
+
+// requestText 请求的文本内容（用于语义向量化）
+func requestText(req *UnifiedRequest) string {
+	parts := make([]string, 0, len(req.Messages)+1)
+	if req.Prompt != "" {
+		parts = append(parts, req.Prompt)
+	}
+	for _, msg := range req.Messages {
+		parts = append(parts, msg.Role+": "+msg.Content)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// hashRequest 计算请求指纹；withContent 为 false 时只包含模型参数
+func hashRequest(task string, req *UnifiedRequest, withContent bool) string {
+	fingerprint := map[string]interface{}{
+		"task":        task,
+		"model":       req.Model,
+		"max_tokens":  req.MaxTokens,
+		"temperature": req.Temperature,
+		"parameters":  req.Parameters,
+	}
+	if withContent {
+		fingerprint["prompt"] = req.Prompt
+		fingerprint["messages"] = req.Messages
+	}
+	// map 序列化时键有序，保证指纹稳定
+	data, _ := json.Marshal(fingerprint)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func userKeyPrefix(userID string) string {
+	return fmt.Sprintf("%s:%s:", responseCacheKeyPrefix, userID)
+}
+
+func userIndexKey(userID string) string {
+	return fmt.Sprintf("%s:%s:index", responseCacheKeyPrefix, userID)
+}
+
+// cosineSimilarity 余弦相似度
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
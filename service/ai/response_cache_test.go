@@ -0,0 +1,218 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"Qingyu_backend/config"
+	"Qingyu_backend/models/ai"
+	"Qingyu_backend/pkg/cache"
+	"Qingyu_backend/service/ai/adapter"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAdapter 记录调用次数的测试适配器
+type countingAdapter struct {
+	calls int
+}
+
+func (a *countingAdapter) GetName() string                   { return "fake" }
+func (a *countingAdapter) GetSupportedModels() []string      { return []string{"fake-model"} }
+func (a *countingAdapter) HealthCheck(context.Context) error { return nil }
+
+func (a *countingAdapter) TextGeneration(ctx context.Context, req *adapter.TextGenerationRequest) (*adapter.TextGenerationResponse, error) {
+	a.calls++
+	return &adapter.TextGenerationResponse{
+		Text:  "生成结果：" + req.Prompt,
+		Usage: adapter.Usage{PromptTokens: 100, CompletionTokens: 200, TotalTokens: 300},
+	}, nil
+}
+
+func (a *countingAdapter) ChatCompletion(ctx context.Context, req *adapter.ChatCompletionRequest) (*adapter.ChatCompletionResponse, error) {
+	a.calls++
+	return &adapter.ChatCompletionResponse{
+		Message: adapter.Message{Role: adapter.RoleAssistant, Content: "回复"},
+		Usage:   adapter.Usage{TotalTokens: 50},
+	}, nil
+}
+
+func (a *countingAdapter) TextGenerationStream(ctx context.Context, req *adapter.TextGenerationRequest) (<-chan *adapter.TextGenerationResponse, error) {
+	return nil, nil
+}
+
+func (a *countingAdapter) ChatCompletionStream(ctx context.Context, req *adapter.ChatCompletionRequest) (<-chan *adapter.ChatCompletionChunk, error) {
+	return nil, nil
+}
+
+func (a *countingAdapter) ImageGeneration(ctx context.Context, req *adapter.ImageGenerationRequest) (*adapter.ImageGenerationResponse, error) {
+	return nil, nil
+}
+
+// tokenAuth 以 token 作为用户ID
+type tokenAuth struct{}
+
+func (tokenAuth) Authenticate(ctx context.Context, token string) (string, error) { return token, nil }
+func (tokenAuth) Authorize(ctx context.Context, userID, action string) bool      { return true }
+
+// mapEmbedder 按文本返回预设向量
+type mapEmbedder map[string][]float32
+
+func (e mapEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if v, ok := e[text]; ok {
+		return v, nil
+	}
+	return []float32{0, 0, 1}, nil
+}
+
+func newCachedGateway(t *testing.T, cacheCfg *config.AIResponseCacheConfig, embedder TextEmbedder) (*AIGateway, *countingAdapter, *memoryQuotaRepository) {
+	withQuotaConfig(t, &config.AIQuotaConfig{})
+	fake := &countingAdapter{}
+	manager := adapter.NewAdapterManager(&config.ExternalAPIConfig{DefaultProvider: "fake"})
+	manager.AddAdapter("fake", fake)
+
+	repo := newMemoryQuotaRepository(newTestQuota("u1", 10000), newTestQuota("u2", 10000))
+	gateway := NewAIGateway(manager, NewQuotaService(repo), nil, tokenAuth{})
+	gateway.SetResponseCache(NewResponseCache(cacheCfg, nil, embedder))
+	return gateway, fake, repo
+}
+
+func summarizeRequest(prompt string) *UnifiedRequest {
+	return &UnifiedRequest{TaskType: "text_generation", Task: "summarize", Prompt: prompt, Temperature: 0.3}
+}
+
+func TestAIGateway_ExactCacheHitSkipsProviderAndQuota(t *testing.T) {
+	gateway, fake, repo := newCachedGateway(t, &config.AIResponseCacheConfig{Enabled: true, DefaultTTL: 60}, nil)
+	ctx := context.Background()
+
+	first, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+	require.NoError(t, err)
+	require.True(t, first.Success)
+	assert.False(t, first.Cached)
+
+	second, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+	require.NoError(t, err)
+	assert.True(t, second.Cached)
+	assert.Equal(t, first.Data, second.Data)
+	assert.Equal(t, 1, fake.calls)
+
+	quota, _ := repo.GetQuotaByUserID(ctx, "u1", ai.QuotaTypeDaily)
+	assert.Equal(t, 300, quota.UsedQuota, "命中缓存不消耗配额")
+
+	stats := gateway.responseCache.GetStats()
+	assert.Equal(t, int64(1), stats.ExactHits)
+	assert.InDelta(t, 0.5, stats.HitRate, 0.001)
+}
+
+func TestAIGateway_CacheIsIsolatedPerUser(t *testing.T) {
+	gateway, fake, _ := newCachedGateway(t, &config.AIResponseCacheConfig{Enabled: true, DefaultTTL: 60}, nil)
+	ctx := context.Background()
+
+	_, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+	require.NoError(t, err)
+	resp, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u2")
+	require.NoError(t, err)
+
+	assert.False(t, resp.Cached)
+	assert.Equal(t, 2, fake.calls)
+}
+
+func TestAIGateway_TaskTTLDisablesCaching(t *testing.T) {
+	gateway, fake, _ := newCachedGateway(t, &config.AIResponseCacheConfig{
+		Enabled:    true,
+		DefaultTTL: 60,
+		TaskTTLs:   map[string]int{"chat": 0},
+	}, nil)
+	ctx := context.Background()
+	req := &UnifiedRequest{TaskType: "chat", Messages: []adapter.Message{{Role: adapter.RoleUser, Content: "你好"}}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := gateway.ProcessRequest(ctx, req, "u1")
+		require.NoError(t, err)
+		assert.False(t, resp.Cached)
+	}
+	assert.Equal(t, 2, fake.calls)
+}
+
+func TestAIGateway_SemanticCacheMatchesNearIdenticalPrompt(t *testing.T) {
+	embedder := mapEmbedder{
+		"他推开门，走进了雨里。":  {1, 0, 0},
+		"他推开门，走进了雨中。":  {0.99, 0.01, 0},
+		"她关上窗，望着远处的山。": {0, 1, 0},
+	}
+	gateway, fake, _ := newCachedGateway(t, &config.AIResponseCacheConfig{
+		Enabled:             true,
+		DefaultTTL:          60,
+		Semantic:            true,
+		SimilarityThreshold: 0.95,
+	}, embedder)
+	ctx := context.Background()
+
+	_, err := gateway.ProcessRequest(ctx, summarizeRequest("他推开门，走进了雨里。"), "u1")
+	require.NoError(t, err)
+
+	similar, err := gateway.ProcessRequest(ctx, summarizeRequest("他推开门，走进了雨中。"), "u1")
+	require.NoError(t, err)
+	assert.True(t, similar.Cached)
+
+	different, err := gateway.ProcessRequest(ctx, summarizeRequest("她关上窗，望着远处的山。"), "u1")
+	require.NoError(t, err)
+	assert.False(t, different.Cached)
+
+	// 模型参数不同的请求不做语义匹配
+	hotter := summarizeRequest("他推开门，走进了雨中。")
+	hotter.Temperature = 0.9
+	resp, err := gateway.ProcessRequest(ctx, hotter, "u1")
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+
+	assert.Equal(t, 3, fake.calls)
+	assert.Equal(t, int64(1), gateway.responseCache.GetStats().SemanticHits)
+}
+
+func TestResponseCache_Invalidate(t *testing.T) {
+	gateway, fake, _ := newCachedGateway(t, &config.AIResponseCacheConfig{Enabled: true, DefaultTTL: 60}, nil)
+	ctx := context.Background()
+
+	_, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+	require.NoError(t, err)
+	require.NoError(t, gateway.responseCache.Invalidate(ctx, "u1"))
+
+	resp, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+	assert.Equal(t, 2, fake.calls)
+}
+
+func TestResponseCache_RedisIndexExpiresWithEntries(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCfg := config.DefaultRedisConfig()
+	redisCfg.Host = mr.Host()
+	redisCfg.Port, _ = strconv.Atoi(mr.Port())
+	redisClient, err := cache.NewRedisClient(redisCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisClient.Close() })
+
+	cacheCfg := &config.AIResponseCacheConfig{Enabled: true, DefaultTTL: 60, TaskTTLs: map[string]int{"summarize": 600, "continue": 30}}
+	gateway, fake, _ := newCachedGateway(t, cacheCfg, nil)
+	gateway.SetResponseCache(NewResponseCache(cacheCfg, redisClient, nil))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := gateway.ProcessRequest(ctx, summarizeRequest("第一章正文"), "u1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, fake.calls)
+
+	// 索引按最长任务缓存时间过期，写入较短缓存时间的条目不会缩短索引寿命
+	_, err = gateway.ProcessRequest(ctx, &UnifiedRequest{TaskType: "text_generation", Task: "continue", Prompt: "续写"}, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, 600*time.Second, mr.TTL(userIndexKey("u1")))
+
+	mr.FastForward(601 * time.Second)
+	assert.False(t, mr.Exists(userIndexKey("u1")))
+}
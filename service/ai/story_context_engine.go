@@ -17,11 +17,11 @@ import (
 // Layer 2: 大纲即摘要（当前章节 + 近期章节 + 卷级结构）
 // Layer 3: 最近已写文本（尾部截取）
 type StoryContextEngine struct {
-	documentRepo       writerRepo.DocumentRepository
+	documentRepo        writerRepo.DocumentRepository
 	documentContentRepo writerRepo.DocumentContentRepository
-	characterRepo      writerRepo.CharacterRepository
-	locationRepo       writerRepo.LocationRepository
-	outlineRepo        writerRepo.OutlineRepository
+	characterRepo       writerRepo.CharacterRepository
+	locationRepo        writerRepo.LocationRepository
+	outlineRepo         writerRepo.OutlineRepository
 }
 
 // NewStoryContextEngine 创建故事上下文引擎
@@ -33,11 +33,11 @@ func NewStoryContextEngine(
 	outlineRepo writerRepo.OutlineRepository,
 ) *StoryContextEngine {
 	return &StoryContextEngine{
-		documentRepo:       documentRepo,
+		documentRepo:        documentRepo,
 		documentContentRepo: documentContentRepo,
-		characterRepo:      characterRepo,
-		locationRepo:       locationRepo,
-		outlineRepo:        outlineRepo,
+		characterRepo:       characterRepo,
+		locationRepo:        locationRepo,
+		outlineRepo:         outlineRepo,
 	}
 }
 
@@ -91,10 +91,10 @@ func (e *StoryContextEngine) BuildStoryContext(
 
 	// 计算 token 估算
 	stageJSON, _ := json.Marshal(stage)
-	sc.StageTokens = estimateTokens(string(stageJSON))
-	sc.OutlineTokens = estimateTokens(outlineCtx)
-	sc.RAGTokens = estimateTokens(sc.RAGExcerpts)
-	sc.TotalTokens = sc.StageTokens + sc.OutlineTokens + sc.RAGTokens + estimateTokens(recentText)
+	sc.StageTokens = EstimateTokens(string(stageJSON))
+	sc.OutlineTokens = EstimateTokens(outlineCtx)
+	sc.RAGTokens = EstimateTokens(sc.RAGExcerpts)
+	sc.TotalTokens = sc.StageTokens + sc.OutlineTokens + sc.RAGTokens + EstimateTokens(recentText)
 
 	return sc, nil
 }
@@ -365,14 +365,3 @@ func strengthToTension(strength int) string {
 	}
 	return "low"
 }
-
-// estimateTokens 估算中文文本的 token 数
-// 中文约 1.5 字/token
-func estimateTokens(text string) int {
-	if text == "" {
-		return 0
-	}
-	runeCount := len([]rune(text))
-	// 每 2 个字符约 3 个 token
-	return runeCount * 3 / 2
-}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"math"
+	"unicode"
+)
+
+// token 估算参数（按 cl100k 类 BPE 分词器在中英文语料上的平均表现校准）
+const (
+	cjkTokensPerRune     = 1.3 // 汉字/假名/谚文平均约 1.3 token
+	latinRunesPerToken   = 4   // 英文单词约 4 个字母一个 token
+	otherRunesPerToken   = 2   // 其他拼音文字（带重音拉丁字母、西里尔字母等）
+	digitsPerToken       = 3   // 数字按 3 位一组切分
+	messageOverhead      = 4   // 每条对话消息的角色/分隔符开销
+	defaultOutputReserve = 1024
+)
+
+// EstimateTokens 估算文本的 token 数
+// 模拟 BPE 分词规律：CJK 按字计、英文按词长计、数字三位一组、标点与换行各计一个，
+// 单词前的空格并入该词不单独计数
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	var cjk float64
+	tokens := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJKRune(r):
+			cjk += cjkTokensPerRune
+			i++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			n := runLength(runes, i, func(r rune) bool { return r < unicode.MaxASCII && unicode.IsLetter(r) })
+			tokens += ceilDiv(n, latinRunesPerToken)
+			i += n
+		case unicode.IsDigit(r):
+			n := runLength(runes, i, unicode.IsDigit)
+			tokens += ceilDiv(n, digitsPerToken)
+			i += n
+		case unicode.IsLetter(r):
+			n := runLength(runes, i, func(r rune) bool { return unicode.IsLetter(r) && r >= unicode.MaxASCII && !isCJKRune(r) })
+			tokens += ceilDiv(n, otherRunesPerToken)
+			i += n
+		case r == '\n':
+			// 连续换行合并为一个 token
+			i += runLength(runes, i, func(r rune) bool { return r == '\n' || r == '\r' })
+			tokens++
+		case unicode.IsSpace(r):
+			n := runLength(runes, i, func(r rune) bool { return unicode.IsSpace(r) && r != '\n' })
+			// 单个空格并入后续单词，多余空白按一个 token 计
+			if n > 1 {
+				tokens++
+			}
+			i += n
+		default:
+			// 标点与符号
+			tokens++
+			i++
+		}
+	}
+
+	return tokens + int(math.Ceil(cjk))
+}
+
+// EstimateChatTokens 估算多轮对话输入的 token 数（含每条消息的格式开销）
+func EstimateChatTokens(contents ...string) int {
+	total := 0
+	for _, content := range contents {
+		total += EstimateTokens(content) + messageOverhead
+	}
+	return total
+}
+
+// EstimateRequestTokens 估算一次生成请求的总 token 数（输入 + 输出上限）
+// 未指定 maxTokens 时按默认输出预留计算
+func EstimateRequestTokens(promptTokens, maxTokens int) int {
+	if maxTokens <= 0 {
+		maxTokens = defaultOutputReserve
+	}
+	return promptTokens + maxTokens
+}
+
+// isCJKRune 是否为中日韩文字或全角标点
+func isCJKRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK 标点
+		(r >= 0xFF00 && r <= 0xFFEF) // 全角字符
+}
+
+// runLength 从 start 开始连续满足条件的字符数
+func runLength(runes []rune, start int, match func(rune) bool) int {
+	n := 0
+	for start+n < len(runes) && match(runes[start+n]) {
+		n++
+	}
+	return n
+}
+
+func ceilDiv(n, d int) int {
+	return (n + d - 1) / d
+}
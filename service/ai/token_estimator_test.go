@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"空文本", "", 0},
+		{"英文单词", "Hello world", 4},
+		{"中文按字计", "你好世界", 6},
+		{"中英混排", "AI写作", 4},
+		{"数字三位一组", "2024", 2},
+		{"标点与换行", "Hi!\n\nOk.", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EstimateTokens(tt.text))
+		})
+	}
+}
+
+func TestEstimateTokens_ChineseCostsMoreThanCharCountDivision(t *testing.T) {
+	text := "夜色渐深，城市的灯火一盏盏熄灭，只剩下远处钟楼的轮廓。"
+	runes := len([]rune(text))
+
+	// 旧算法按 len/4 估算会严重低估中文用量
+	assert.Greater(t, EstimateTokens(text), runes)
+}
+
+func TestEstimateRequestTokens(t *testing.T) {
+	assert.Equal(t, 100+defaultOutputReserve, EstimateRequestTokens(100, 0))
+	assert.Equal(t, 300, EstimateRequestTokens(100, 200))
+	assert.Equal(t, EstimateTokens("你好")+messageOverhead, EstimateChatTokens("你好"))
+}
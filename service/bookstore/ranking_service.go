@@ -20,6 +20,7 @@ type RankingConfig struct {
 	MonthlyLikeWeight  float64
 	NewbieViewWeight   float64
 	NewbieLikeWeight   float64
+	FanValueWeight     float64 // 粉丝值（读者打赏）权重，作用于所有榜单
 	NewbieMaxAge       time.Duration
 	MaxRankingItems    int
 }
@@ -33,6 +34,7 @@ func DefaultRankingConfig() *RankingConfig {
 		MonthlyLikeWeight:  0.3,
 		NewbieViewWeight:   0.6,
 		NewbieLikeWeight:   0.4,
+		FanValueWeight:     0.5,
 		NewbieMaxAge:       30 * 24 * time.Hour,
 		MaxRankingItems:    100,
 	}
@@ -126,15 +128,16 @@ func (s *RankingServiceImpl) calculateRanking(ctx context.Context, rankingType b
 
 // calculateScore 计算书籍分数
 func (s *RankingServiceImpl) calculateScore(book *bookstore2.Book, rankingType bookstore2.RankingType) float64 {
+	fanScore := float64(book.FanValue) * s.config.FanValueWeight
 	switch rankingType {
 	case bookstore2.RankingTypeRealtime:
-		return float64(book.ViewCount)*s.config.RealtimeViewWeight + float64(book.RatingCount)*s.config.RealtimeLikeWeight
+		return float64(book.ViewCount)*s.config.RealtimeViewWeight + float64(book.RatingCount)*s.config.RealtimeLikeWeight + fanScore
 	case bookstore2.RankingTypeWeekly:
-		return float64(book.ViewCount) * s.config.WeeklyViewWeight
+		return float64(book.ViewCount)*s.config.WeeklyViewWeight + fanScore
 	case bookstore2.RankingTypeMonthly:
-		return float64(book.ViewCount)*s.config.MonthlyViewWeight + float64(book.RatingCount)*s.config.MonthlyLikeWeight
+		return float64(book.ViewCount)*s.config.MonthlyViewWeight + float64(book.RatingCount)*s.config.MonthlyLikeWeight + fanScore
 	case bookstore2.RankingTypeNewbie:
-		return float64(book.ViewCount)*s.config.NewbieViewWeight + float64(book.RatingCount)*s.config.NewbieLikeWeight
+		return float64(book.ViewCount)*s.config.NewbieViewWeight + float64(book.RatingCount)*s.config.NewbieLikeWeight + fanScore
 	default:
 		return 0
 	}
@@ -13,12 +13,14 @@ import (
 
 	// Service implementations
 	aiService "Qingyu_backend/service/ai"
+	aiAdapter "Qingyu_backend/service/ai/adapter"
 	bookstoreService "Qingyu_backend/service/bookstore"
 	financeService "Qingyu_backend/service/finance"
 	readingService "Qingyu_backend/service/reader"
 	readingStatsService "Qingyu_backend/service/reader/stats"
 	socialService "Qingyu_backend/service/social"
 	userService "Qingyu_backend/service/user"
+	writerService "Qingyu_backend/service/writer"
 	projectService "Qingyu_backend/service/writer/project"
 
 	// Audit service
@@ -50,15 +52,19 @@ import (
 
 	// Search repository
 	searchRepo "Qingyu_backend/repository/search"
+	searchService "Qingyu_backend/service/search"
+	searchengine "Qingyu_backend/service/search/engine"
 
 	// Infrastructure
 	"Qingyu_backend/config"
 	"Qingyu_backend/pkg/cache"
+	"Qingyu_backend/pkg/distlock"
 	pkgmetrics "Qingyu_backend/pkg/metrics"
 	pkgtransaction "Qingyu_backend/pkg/transaction"
 	"Qingyu_backend/repository/mongodb"
 	mongoAdminRepo "Qingyu_backend/repository/mongodb/admin"
 	mongoSocialRepo "Qingyu_backend/repository/mongodb/social"
+	mongoWriterRepo "Qingyu_backend/repository/mongodb/writer"
 	eventservice "Qingyu_backend/service/events"
 
 	"github.com/redis/go-redis/v9"
@@ -103,9 +109,11 @@ type ServiceContainer struct {
 	likeService           *socialService.LikeService
 	collectionService     *socialService.CollectionService
 	followService         *socialService.FollowService
+	achievementService    *socialService.AchievementService
 	readingHistoryService *readingService.ReadingHistoryService
 	bookmarkService       readingService.BookmarkService
 	projectService        *projectService.ProjectService
+	publishService        *writerService.PublishService
 
 	// AI 相关服务
 	quotaService  *aiService.QuotaService
@@ -113,6 +121,11 @@ type ServiceContainer struct {
 	phase3Client  *aiService.Phase3Client
 	unifiedClient *aiService.UnifiedClient
 	storyContextEngine *aiService.StoryContextEngine
+	adapterManager     *aiAdapter.AdapterManager
+	aiGateway          *aiService.AIGateway
+	embeddingClient    *searchService.EmbeddingClient
+	milvusRepo         *searchRepo.MilvusRepository
+	vectorEngine       searchengine.Engine
 
 	// Shared services
 	authService           auth.AuthService
@@ -129,6 +142,9 @@ type ServiceContainer struct {
 	// 财务服务
 	membershipService    financeService.MembershipService
 	authorRevenueService financeService.AuthorRevenueService
+	royaltyService       *financeService.RoyaltyService
+	settlementService    *financeService.SettlementService
+	tipService           *financeService.TipService
 
 	// 审核服务
 	auditService *auditSvc.ContentAuditService
@@ -136,10 +152,17 @@ type ServiceContainer struct {
 	// WebSocket Hub
 	messagingWSHub    *websocketHub.MessagingWSHub
 	notificationWSHub *websocketHub.WSHub
+	collabWSHub       *websocketHub.CollabWSHub
 
 	// 存储相关服务端口（用于API层）
 	multipartService storage.MultipartUploadManager
 	imageProcessor   storage.ImageProcessorService
+
+	// 后台调度任务（SetupDefaultServices 结束时启动，Close 时停止）
+	publishScheduler    *writerService.PublishScheduler
+	settlementScheduler *financeService.SettlementScheduler
+	aiHealthCheckCancel context.CancelFunc
+	searchSyncCancel    context.CancelFunc
 }
 
 // NewServiceContainer 创建服务容器
@@ -298,6 +321,14 @@ func (c *ServiceContainer) GetFollowService() (*socialService.FollowService, err
 	return c.followService, nil
 }
 
+// GetAchievementService 获取成就服务
+func (c *ServiceContainer) GetAchievementService() (*socialService.AchievementService, error) {
+	if c.achievementService == nil {
+		return nil, fmt.Errorf("AchievementService未初始化")
+	}
+	return c.achievementService, nil
+}
+
 // GetReadingHistoryService 获取阅读历史服务
 func (c *ServiceContainer) GetReadingHistoryService() (*readingService.ReadingHistoryService, error) {
 	if c.readingHistoryService == nil {
@@ -457,6 +488,14 @@ func (c *ServiceContainer) GetMessagingWSHub() (*websocketHub.MessagingWSHub, er
 	return c.messagingWSHub, nil
 }
 
+// GetCollabWSHub 获取文档协作WebSocket Hub
+func (c *ServiceContainer) GetCollabWSHub() (*websocketHub.CollabWSHub, error) {
+	if c.collabWSHub == nil {
+		return nil, fmt.Errorf("CollabWSHub未初始化")
+	}
+	return c.collabWSHub, nil
+}
+
 // GetNotificationWSHub 获取通知WebSocket Hub
 func (c *ServiceContainer) GetNotificationWSHub() (*websocketHub.WSHub, error) {
 	if c.notificationWSHub == nil {
@@ -481,6 +520,104 @@ func (c *ServiceContainer) GetAuthorRevenueService() (financeService.AuthorReven
 	return c.authorRevenueService, nil
 }
 
+// GetRoyaltyService 获取版税服务
+func (c *ServiceContainer) GetRoyaltyService() (*financeService.RoyaltyService, error) {
+	if c.royaltyService == nil {
+		return nil, fmt.Errorf("RoyaltyService未初始化")
+	}
+	return c.royaltyService, nil
+}
+
+// GetSettlementService 获取作者结算服务
+func (c *ServiceContainer) GetSettlementService() (*financeService.SettlementService, error) {
+	if c.settlementService == nil {
+		return nil, fmt.Errorf("SettlementService未初始化")
+	}
+	return c.settlementService, nil
+}
+
+// GetTipService 获取读者打赏服务
+func (c *ServiceContainer) GetTipService() (*financeService.TipService, error) {
+	if c.tipService == nil {
+		return nil, fmt.Errorf("TipService未初始化")
+	}
+	return c.tipService, nil
+}
+
+// GetAdapterManager 获取外部AI提供商适配器管理器
+func (c *ServiceContainer) GetAdapterManager() (*aiAdapter.AdapterManager, error) {
+	if c.adapterManager == nil {
+		return nil, fmt.Errorf("AdapterManager未初始化")
+	}
+	return c.adapterManager, nil
+}
+
+// GetAIGateway 获取AI网关
+func (c *ServiceContainer) GetAIGateway() (*aiService.AIGateway, error) {
+	if c.aiGateway == nil {
+		return nil, fmt.Errorf("AIGateway未初始化")
+	}
+	return c.aiGateway, nil
+}
+
+// getEmbeddingClient 获取文本向量化客户端（复用 AI 服务的 gRPC 端点，首次使用时创建）
+func (c *ServiceContainer) getEmbeddingClient(aiCfg *config.AIConfig) (*searchService.EmbeddingClient, error) {
+	if c.embeddingClient != nil {
+		return c.embeddingClient, nil
+	}
+	if aiCfg == nil || aiCfg.AIService == nil || aiCfg.AIService.Endpoint == "" {
+		return nil, fmt.Errorf("AI服务端点未配置")
+	}
+	client, err := searchService.NewEmbeddingClient(aiCfg.AIService.Endpoint, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.embeddingClient = client
+	return client, nil
+}
+
+// initVectorEngine 连接 Milvus 并创建向量检索引擎，同时准备查询向量化客户端
+func (c *ServiceContainer) initVectorEngine(milvusCfg *config.MilvusConfig, aiCfg *config.AIConfig) error {
+	if _, err := c.getEmbeddingClient(aiCfg); err != nil {
+		return fmt.Errorf("向量化客户端初始化失败: %w", err)
+	}
+
+	repo, err := searchRepo.NewMilvusRepository(milvusCfg.CollectionName, milvusCfg.Dimension)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repo.Connect(ctx, milvusCfg.Host, milvusCfg.Port); err != nil {
+		return err
+	}
+
+	engine, err := searchService.NewVectorEngine(searchService.VectorConfig{Engine: searchService.VectorEngineMilvus}, repo, c.mongoDB)
+	if err != nil {
+		_ = repo.Close()
+		return err
+	}
+	c.milvusRepo = repo
+	c.vectorEngine = engine
+	return nil
+}
+
+// GetVectorSearch 获取混合检索所需的向量引擎与查询向量化客户端
+func (c *ServiceContainer) GetVectorSearch() (searchengine.Engine, *searchService.EmbeddingClient, error) {
+	if c.vectorEngine == nil || c.embeddingClient == nil {
+		return nil, nil, fmt.Errorf("向量检索引擎未初始化")
+	}
+	return c.vectorEngine, c.embeddingClient, nil
+}
+
+// GetPublishService 获取发布服务
+func (c *ServiceContainer) GetPublishService() (*writerService.PublishService, error) {
+	if c.publishService == nil {
+		return nil, fmt.Errorf("PublishService未初始化")
+	}
+	return c.publishService, nil
+}
+
 // GetEventBus 获取事件总线
 func (c *ServiceContainer) GetEventBus() serviceInterfaces.EventBus {
 	return c.eventBus
@@ -664,6 +801,9 @@ func (c *ServiceContainer) Health(ctx context.Context) error {
 func (c *ServiceContainer) Close(ctx context.Context) error {
 	var lastErr error
 
+	// 先停止后台调度任务，避免其在连接关闭后继续访问Redis/MongoDB
+	c.stopBackgroundJobs()
+
 	// 1. 关闭Redis客户端
 	if c.redisClient != nil {
 		if err := c.redisClient.Close(); err != nil {
@@ -679,6 +819,22 @@ func (c *ServiceContainer) Close(ctx context.Context) error {
 		}
 	}
 
+	if c.quotaService != nil {
+		c.quotaService.StopReservationSweeper()
+	}
+
+	if c.milvusRepo != nil {
+		if err := c.milvusRepo.Close(); err != nil {
+			lastErr = fmt.Errorf("关闭Milvus连接失败: %w", err)
+		}
+	}
+
+	if c.embeddingClient != nil {
+		if err := c.embeddingClient.Close(); err != nil {
+			lastErr = fmt.Errorf("关闭向量化客户端失败: %w", err)
+		}
+	}
+
 	if closer, ok := c.eventBus.(interface{ Close() error }); ok {
 		if err := closer.Close(); err != nil {
 			lastErr = fmt.Errorf("关闭事件总线失败: %w", err)
@@ -906,6 +1062,26 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 		return fmt.Errorf("注册项目服务失败: %w", err)
 	}
 
+	// ============ 4.10 创建发布服务与定时发布调度器 ============
+	if c.mongoDB != nil {
+		c.publishService = writerService.NewPublishService(
+			writerService.NewPublishProjectRepositoryAdapter(projectRepo),
+			writerService.NewPublishDocumentRepositoryAdapter(c.repositoryFactory.CreateDocumentRepository(), c.mongoDB),
+			mongoWriterRepo.NewMongoPublicationRepository(c.mongoDB),
+			writerService.NewLocalBookstoreClient(c.mongoDB),
+			writerService.NewPublishEventBusAdapter(c.eventBus),
+		).(*writerService.PublishService)
+
+		// 多实例部署时由分布式锁保证同一时刻只有一个实例调度
+		var publishLock *distlock.RedisLockService
+		if c.redisClient != nil {
+			if client, ok := c.redisClient.GetClient().(*redis.Client); ok {
+				publishLock = distlock.NewRedisLockService(client, "distlock")
+			}
+		}
+		c.publishScheduler = writerService.NewPublishScheduler(c.publishService, publishLock, zap.L())
+	}
+
 	// ============ 5. 创建AI服务 ============
 	// AIService需要ProjectService来构建上下文
 	c.aiService = aiService.NewServiceWithDependencies(c.projectService)
@@ -913,6 +1089,8 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 	// 创建AI配额服务
 	quotaRepo := c.repositoryFactory.CreateQuotaRepository()
 	c.quotaService = aiService.NewQuotaService(quotaRepo)
+	// 定时退回被放弃的流式请求遗留的配额预留
+	c.quotaService.StartReservationSweeper(time.Minute)
 	// 注意：QuotaService 不完全实现 BaseService，不注册到 services map
 
 	// 创建聊天服务（使用临时内存存储）
@@ -964,6 +1142,12 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 	)
 	fmt.Println("  ✓ StoryContextEngine初始化完成")
 
+	// ============ 5.4 外部AI提供商适配器（gRPC 服务不可用时的降级通道） ============
+	if extCfg := config.GlobalConfig.External; extCfg != nil {
+		c.adapterManager = aiAdapter.NewAdapterManager(extCfg)
+		fmt.Println("  ✓ AdapterManager初始化完成")
+	}
+
 	// ============ 5. 共享服务初始化 ============
 
 	// 5.1 创建 WalletService（简单版，只需要 WalletRepository）
@@ -1078,6 +1262,32 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 		}
 	}
 
+	// 5.2.2 AI网关：外部提供商调用统一经过鉴权、配额校验与按用户隔离的响应缓存
+	if c.adapterManager != nil {
+		c.aiGateway = aiService.NewAIGateway(c.adapterManager, c.quotaService, nil, aiService.NewJWTGatewayAuth(jwtService))
+		if aiCfg != nil && aiCfg.ResponseCache != nil && aiCfg.ResponseCache.Enabled {
+			var embedder aiService.TextEmbedder
+			if aiCfg.ResponseCache.Semantic {
+				if client, err := c.getEmbeddingClient(aiCfg); err != nil {
+					fmt.Printf("警告: 向量化客户端初始化失败，AI响应缓存仅做精确匹配: %v\n", err)
+				} else {
+					embedder = client
+				}
+			}
+			c.aiGateway.SetResponseCache(aiService.NewResponseCache(aiCfg.ResponseCache, c.redisClient, embedder))
+			fmt.Println("  ✓ AI响应缓存初始化完成")
+		}
+	}
+
+	// 5.2.3 混合检索向量引擎：Milvus 启用且向量化客户端可用时由搜索服务开启混合检索
+	if milvusCfg := config.GlobalConfig.Milvus; milvusCfg != nil && milvusCfg.Enabled {
+		if err := c.initVectorEngine(milvusCfg, aiCfg); err != nil {
+			fmt.Printf("警告: 向量检索引擎初始化失败，混合检索不可用: %v\n", err)
+		} else {
+			fmt.Println("  ✓ 向量检索引擎初始化完成")
+		}
+	}
+
 	// 5.2.1 创建 OAuthService（可选，需要配置）
 	// 初始化 OAuth 配置管理器
 	oauthConfigMgr := config.NewOAuthConfigManager()
@@ -1259,10 +1469,41 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 	fmt.Println("  ✓ NotificationService初始化完成")
 	fmt.Println("  ✓ NotificationWSHub初始化完成")
 
-	// 5.9.1 初始化消息WebSocket Hub（传入JWT服务）
-	c.messagingWSHub = websocketHub.NewMessagingWSHub(jwtService)
+	// 5.8.1 AchievementService（依赖通知服务颁发成就通知）
+	achievementRepo := mongoSocialRepo.NewMongoAchievementRepository(c.mongoDB)
+	c.achievementService = socialService.NewAchievementService(achievementRepo, notificationSvc)
+	if persisted := c.GetPersistedEventBus(); persisted != nil {
+		c.achievementService.SetEventReplayer(persisted)
+	}
+	achievementHandler := eventservice.NewSocialAchievementHandler(c.achievementService)
+	for _, eventType := range achievementHandler.GetSupportedEventTypes() {
+		if err := c.eventBus.Subscribe(eventType, achievementHandler); err != nil {
+			return fmt.Errorf("订阅成就事件 %s 失败: %w", eventType, err)
+		}
+	}
+	c.services["AchievementService"] = c.achievementService
+	fmt.Println("  ✓ AchievementService初始化完成")
+
+	// 5.9.1 初始化消息WebSocket Hub（有Redis时经pub/sub投递到其他实例上的连接）
+	var messagingBroker websocketHub.MessagingBroker
+	if c.redisClient != nil {
+		if client, ok := c.redisClient.GetClient().(*redis.Client); ok {
+			messagingBroker = websocketHub.NewRedisMessagingBroker(client, "messaging:events")
+		}
+	}
+	c.messagingWSHub = websocketHub.NewMessagingWSHub(jwtService, messagingBroker)
 	fmt.Println("  ✓ MessagingWSHub初始化完成")
 
+	// 5.9.2 初始化文档协作WebSocket Hub（有Redis时多实例共享协作状态）
+	var collabBroker websocketHub.CollabBroker
+	if c.redisClient != nil {
+		if client, ok := c.redisClient.GetClient().(*redis.Client); ok {
+			collabBroker = websocketHub.NewRedisCollabBroker(client, "collab")
+		}
+	}
+	c.collabWSHub = websocketHub.NewCollabWSHub(jwtService, collabBroker)
+	fmt.Println("  ✓ CollabWSHub初始化完成")
+
 	// 5.10 Finance Services
 	membershipRepo := c.repositoryFactory.CreateMembershipRepository()
 	mongoTxRunnerInstance, err := c.GetProvider("mongoTransactionRunner")
@@ -1278,6 +1519,32 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 	authorRevenueRepo = c.repositoryFactory.CreateAuthorRevenueRepository()
 	c.authorRevenueService = financeService.NewAuthorRevenueServiceWithDependencies(authorRevenueRepo, walletRepo, mongoTxRunner)
 
+	// 版税入账：购买与打赏事件按合同分成生成作者收入记录
+	c.royaltyService = financeService.NewRoyaltyService(authorRevenueRepo, c.repositoryFactory.CreateBookRepository())
+	if persisted := c.GetPersistedEventBus(); persisted != nil {
+		c.royaltyService.SetEventReplayer(persisted)
+	}
+	royaltyHandler := eventservice.NewRoyaltyCalculationHandler(c.royaltyService)
+	for _, eventType := range royaltyHandler.GetSupportedEventTypes() {
+		if err := c.eventBus.Subscribe(eventType, royaltyHandler); err != nil {
+			return fmt.Errorf("订阅版税事件 %s 失败: %w", eventType, err)
+		}
+	}
+
+	// 作者月度结算：多实例部署时由分布式锁保证每位作者每个周期只结算一次
+	c.settlementService = financeService.NewSettlementService(authorRevenueRepo, walletRepo, mongoTxRunner, c.eventBus)
+	if c.redisClient != nil {
+		if client, ok := c.redisClient.GetClient().(*redis.Client); ok {
+			c.settlementService.SetLock(distlock.NewRedisLockService(client, "distlock"))
+		}
+	}
+	c.settlementScheduler = financeService.NewSettlementScheduler(c.settlementService, zap.L())
+
+	// 读者打赏：钱包扣款、作者收入、粉丝榜与书籍粉丝值在同一事务内完成
+	c.tipService = financeService.NewTipService(c.repositoryFactory.CreateTipRepository(), c.walletService, c.royaltyService, bookRepo, mongoTxRunner, c.eventBus)
+	c.tipService.SetChapterLookup(chapterRepo)
+	c.tipService.SetNotifier(notificationSvc)
+
 	fmt.Println("  ✓ Finance服务初始化完成")
 
 	// 5.11 AuditService 当前为可选，待 service/audit 完整实现后再接入。
@@ -1292,9 +1559,59 @@ func (c *ServiceContainer) SetupDefaultServices() error {
 		}
 	}
 
+	// ============ 7. 启动后台调度任务 ============
+	c.startBackgroundJobs()
+
 	return nil
 }
 
+// startBackgroundJobs 启动后台调度任务，启动失败只记录日志不影响服务启动
+func (c *ServiceContainer) startBackgroundJobs() {
+	if c.publishScheduler != nil {
+		if err := c.publishScheduler.Start(); err != nil {
+			zap.L().Error("定时发布调度器启动失败", zap.Error(err))
+		} else {
+			fmt.Println("  ✓ 定时发布调度器已启动")
+		}
+	}
+	if c.adapterManager != nil {
+		// 按 external.routing.health_check_interval 定期探测提供商，结果计入熔断器
+		ctx, cancel := context.WithCancel(context.Background())
+		c.adapterManager.StartHealthChecks(ctx)
+		c.aiHealthCheckCancel = cancel
+	}
+	if c.settlementScheduler != nil {
+		if err := c.settlementScheduler.Start(); err != nil {
+			zap.L().Error("作者结算调度器启动失败", zap.Error(err))
+		} else {
+			fmt.Println("  ✓ 作者月度结算调度器已启动")
+		}
+	}
+}
+
+// stopBackgroundJobs 停止后台调度任务
+func (c *ServiceContainer) stopBackgroundJobs() {
+	if c.publishScheduler != nil {
+		c.publishScheduler.Stop()
+	}
+	if c.settlementScheduler != nil {
+		c.settlementScheduler.Stop()
+	}
+	if c.aiHealthCheckCancel != nil {
+		c.aiHealthCheckCancel()
+		c.aiHealthCheckCancel = nil
+	}
+	if c.searchSyncCancel != nil {
+		c.searchSyncCancel()
+		c.searchSyncCancel = nil
+	}
+}
+
+// SetSearchSyncCancel 设置搜索同步（变更流监听与同步 Worker）的停止函数，Close 时调用
+func (c *ServiceContainer) SetSearchSyncCancel(cancel context.CancelFunc) {
+	c.searchSyncCancel = cancel
+}
+
 // SetAuthService 设置认证服务
 func (c *ServiceContainer) SetAuthService(service auth.AuthService) {
 	c.authService = service
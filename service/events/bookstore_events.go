@@ -382,39 +382,36 @@ func (h *PurchaseStatisticsHandler) GetSupportedEventTypes() []string {
 	}
 }
 
+// RoyaltyProcessor 版税入账接口（由版税服务实现）
+type RoyaltyProcessor interface {
+	// ProcessEvent 根据购买/打赏事件为作者入账
+	ProcessEvent(ctx context.Context, event base.Event) error
+}
+
 // RoyaltyCalculationHandler 版税计算处理器
-// 计算作者版税
+// 将购买与打赏事件转交版税服务，按合同分成生成作者收入记录
 type RoyaltyCalculationHandler struct {
-	name string
+	name      string
+	processor RoyaltyProcessor
 }
 
 // NewRoyaltyCalculationHandler 创建版税计算处理器
-func NewRoyaltyCalculationHandler() *RoyaltyCalculationHandler {
+func NewRoyaltyCalculationHandler(processor RoyaltyProcessor) *RoyaltyCalculationHandler {
 	return &RoyaltyCalculationHandler{
-		name: "RoyaltyCalculationHandler",
+		name:      "RoyaltyCalculationHandler",
+		processor: processor,
 	}
 }
 
 // Handle 处理事件
 func (h *RoyaltyCalculationHandler) Handle(ctx context.Context, event base.Event) error {
-	switch event.GetEventType() {
-	case EventBookPurchased:
-		data, _ := event.GetEventData().(PurchaseEventData)
-		log.Printf("[RoyaltyCalculation] 计算书籍 %s 的版税，金额: %.2f", data.BookID, data.FinalAmount)
-		// 计算作者版税（通常是销售额的50-70%）
-		// 记录到作者收入账户
-
-	case EventChapterPurchased:
-		data, _ := event.GetEventData().(PurchaseEventData)
-		log.Printf("[RoyaltyCalculation] 计算章节 %s 的版税，金额: %.2f", data.ChapterID, data.FinalAmount)
-		// 计算章节版税
-
-	case EventRewardCreated:
-		data, _ := event.GetEventData().(RewardEventData)
-		log.Printf("[RoyaltyCalculation] 记录打赏 %s 给作者 %s，金额: %.2f", data.RewardID, data.AuthorID, data.Amount)
-		// 打赏金额通常全部给作者
+	if h.processor == nil {
+		return nil
+	}
+	if err := h.processor.ProcessEvent(ctx, event); err != nil {
+		log.Printf("[RoyaltyCalculation] 处理事件 %s 失败: %v", event.GetEventType(), err)
+		return err
 	}
-
 	return nil
 }
 
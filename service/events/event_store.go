@@ -2,8 +2,14 @@ package events
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
 	"Qingyu_backend/service/base"
 )
 
@@ -56,6 +62,51 @@ type StoredEvent struct {
 	ExpiresAt time.Time   `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
 }
 
+// DecodeEventData 将事件数据解码到指定结构体指针
+// 实时发布的事件携带原始结构体，直接赋值；回放时 MongoEventStore 返回 bson 文档，
+// 按 bson 默认字段名（与存储时一致）解码；其他 map 按 json tag 解码
+func DecodeEventData(raw interface{}, out interface{}) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("解码目标必须为非空指针")
+	}
+	if raw == nil {
+		return fmt.Errorf("事件数据为空")
+	}
+
+	value := reflect.ValueOf(raw)
+	elemType := target.Elem().Type()
+	if value.Type() == elemType {
+		target.Elem().Set(value)
+		return nil
+	}
+	if value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Type() == elemType {
+		target.Elem().Set(value.Elem())
+		return nil
+	}
+
+	switch data := raw.(type) {
+	case primitive.D, primitive.M, bson.Raw:
+		bytes, err := bson.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("序列化事件数据失败: %w", err)
+		}
+		if err := bson.Unmarshal(bytes, out); err != nil {
+			return fmt.Errorf("解码事件数据失败: %w", err)
+		}
+		return nil
+	default:
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("序列化事件数据失败: %w", err)
+		}
+		if err := json.Unmarshal(bytes, out); err != nil {
+			return fmt.Errorf("解码事件数据失败: %w", err)
+		}
+		return nil
+	}
+}
+
 // EventFilter 事件过滤器
 type EventFilter struct {
 	EventType string     `bson:"event_type,omitempty"`
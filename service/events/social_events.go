@@ -328,38 +328,38 @@ func (h *SocialStatisticsHandler) GetSupportedEventTypes() []string {
 	}
 }
 
+// AchievementProcessor 成就处理接口（由成就服务实现）
+type AchievementProcessor interface {
+	// ProcessEvent 按成就规则处理事件
+	ProcessEvent(ctx context.Context, event base.Event) error
+	// SupportedEventTypes 成就规则监听的事件类型
+	SupportedEventTypes() []string
+}
+
 // SocialAchievementHandler 社交成就处理器
-// 检查和颁发社交成就
+// 将社交、阅读、写作事件转交成就服务累计进度并颁发成就
 type SocialAchievementHandler struct {
-	name string
+	name      string
+	processor AchievementProcessor
 }
 
 // NewSocialAchievementHandler 创建社交成就处理器
-func NewSocialAchievementHandler() *SocialAchievementHandler {
+func NewSocialAchievementHandler(processor AchievementProcessor) *SocialAchievementHandler {
 	return &SocialAchievementHandler{
-		name: "SocialAchievementHandler",
+		name:      "SocialAchievementHandler",
+		processor: processor,
 	}
 }
 
 // Handle 处理事件
 func (h *SocialAchievementHandler) Handle(ctx context.Context, event base.Event) error {
-	switch event.GetEventType() {
-	case EventLikeAdded:
-		data, _ := event.GetEventData().(SocialEventData)
-		log.Printf("[SocialAchievement] 检查用户 %s 的点赞成就", data.UserID)
-		// 检查是否达成"获得100个点赞"等成就
-
-	case EventCommentCreated:
-		data, _ := event.GetEventData().(CommentEventData)
-		log.Printf("[SocialAchievement] 检查用户 %s 的评论成就", data.UserID)
-		// 检查是否达成"发表10条评论"等成就
-
-	case EventFollowAdded:
-		data, _ := event.GetEventData().(FollowEventData)
-		log.Printf("[SocialAchievement] 检查用户 %s 和 %s 的关注成就", data.FollowerID, data.FolloweeID)
-		// 检查是否达成"关注10位作者"等成就
+	if h.processor == nil {
+		return nil
+	}
+	if err := h.processor.ProcessEvent(ctx, event); err != nil {
+		log.Printf("[SocialAchievement] 处理事件 %s 失败: %v", event.GetEventType(), err)
+		return err
 	}
-
 	return nil
 }
 
@@ -370,9 +370,8 @@ func (h *SocialAchievementHandler) GetHandlerName() string {
 
 // GetSupportedEventTypes 获取支持的事件类型
 func (h *SocialAchievementHandler) GetSupportedEventTypes() []string {
-	return []string{
-		EventLikeAdded,
-		EventCommentCreated,
-		EventFollowAdded,
+	if h.processor == nil {
+		return []string{EventLikeAdded, EventCommentCreated, EventFollowAdded}
 	}
+	return h.processor.SupportedEventTypes()
 }
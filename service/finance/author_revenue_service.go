@@ -3,6 +3,7 @@ package finance
 import (
 	"context"
 	"fmt"
+	"time"
 
 	financeModel "Qingyu_backend/models/finance"
 	"Qingyu_backend/models/shared/types"
@@ -24,7 +25,7 @@ type AuthorRevenueService interface {
 
 	// 收入记录
 	CreateEarning(ctx context.Context, earning *financeModel.AuthorEarning) error
-	CalculateEarning(ctx context.Context, earningType string, amount float64, authorID string, bookID primitive.ObjectID) (float64, float64, error)
+	CalculateEarning(ctx context.Context, earningType string, amount types.Money, authorID string, bookID primitive.ObjectID) (types.Money, types.Money, error)
 
 	// 提现管理
 	CreateWithdrawalRequest(ctx context.Context, userID string, amount float64, method string, account financeModel.WithdrawAccount) (*financeModel.WithdrawalRequest, error)
@@ -130,28 +131,20 @@ func (s *AuthorRevenueServiceImpl) CreateEarning(ctx context.Context, earning *f
 }
 
 // CalculateEarning 计算收入分成
-// 返回: (作者收入, 平台收入, error)
-func (s *AuthorRevenueServiceImpl) CalculateEarning(ctx context.Context, earningType string, amount float64, authorID string, bookID primitive.ObjectID) (float64, float64, error) {
-	var authorRate float64
-	var platformRate float64
-
-	switch earningType {
-	case financeModel.EarningTypeChapterPurchase:
-		authorRate = financeModel.ChapterPurchaseAuthorRate
-		platformRate = financeModel.ChapterPurchasePlatformRate
-	case financeModel.EarningTypeReward:
-		authorRate = financeModel.RewardAuthorRate
-		platformRate = financeModel.RewardPlatformRate
-	case financeModel.EarningTypeVIPReading:
-		authorRate = financeModel.VIPReadingAuthorRate
-		platformRate = financeModel.VIPReadingPlatformRate
-	default:
-		return 0, 0, fmt.Errorf("未知的收入类型: %s", earningType)
+// 分成比例按当前生效的书籍级或作者级版税合同解析，无合同时使用默认分成
+// 返回: (作者收入, 平台收入, error)，平台收入为总额减去作者收入，二者之和恒等于总额
+func (s *AuthorRevenueServiceImpl) CalculateEarning(ctx context.Context, earningType string, amount types.Money, authorID string, bookID primitive.ObjectID) (types.Money, types.Money, error) {
+	bookHex := ""
+	if !bookID.IsZero() {
+		bookHex = bookID.Hex()
 	}
 
-	authorIncome := amount * authorRate
-	platformIncome := amount * platformRate
+	rate, err := resolveRoyaltyRate(ctx, s.revenueRepo, authorID, bookHex, earningType, time.Now())
+	if err != nil {
+		return 0, 0, err
+	}
 
+	authorIncome, platformIncome := splitRoyalty(amount, rate.AuthorRate)
 	return authorIncome, platformIncome, nil
 }
 
@@ -207,6 +200,9 @@ func (s *AuthorRevenueServiceImpl) CreateWithdrawalRequest(ctx context.Context,
 		if err != nil {
 			return fmt.Errorf("获取钱包失败: %w", err)
 		}
+		if wallet == nil {
+			return fmt.Errorf("钱包不存在")
+		}
 		if wallet.Frozen {
 			return fmt.Errorf("钱包已冻结，无法提现")
 		}
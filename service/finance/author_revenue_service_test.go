@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	financeModel "Qingyu_backend/models/finance"
 	"Qingyu_backend/models/shared/types"
@@ -15,6 +16,10 @@ import (
 
 type mockAuthorRevenueRepository struct {
 	withdrawals map[string]*financeModel.WithdrawalRequest
+	earnings    map[string]*financeModel.AuthorEarning
+	contracts   []*financeModel.RoyaltyContract
+	settlements map[string]*financeModel.Settlement
+	taxInfos    map[string]*financeModel.TaxInfo
 	failCreate  error
 	counter     int
 }
@@ -22,6 +27,9 @@ type mockAuthorRevenueRepository struct {
 func newMockAuthorRevenueRepository() *mockAuthorRevenueRepository {
 	return &mockAuthorRevenueRepository{
 		withdrawals: make(map[string]*financeModel.WithdrawalRequest),
+		earnings:    make(map[string]*financeModel.AuthorEarning),
+		settlements: make(map[string]*financeModel.Settlement),
+		taxInfos:    make(map[string]*financeModel.TaxInfo),
 	}
 }
 
@@ -42,6 +50,19 @@ func (m *mockAuthorRevenueRepository) UpdateEarning(ctx context.Context, earning
 }
 
 func (m *mockAuthorRevenueRepository) BatchUpdateEarnings(ctx context.Context, earningIDs []primitive.ObjectID, updates map[string]interface{}) error {
+	for _, earning := range m.earnings {
+		for _, id := range earningIDs {
+			if earning.ID != id {
+				continue
+			}
+			if settled, ok := updates["is_settled"].(bool); ok {
+				earning.IsSettled = settled
+			}
+			if settlementID, ok := updates["settlement_id"].(primitive.ObjectID); ok {
+				earning.SettlementID = settlementID
+			}
+		}
+	}
 	return nil
 }
 
@@ -53,6 +74,77 @@ func (m *mockAuthorRevenueRepository) GetEarningsByBook(ctx context.Context, boo
 	return nil, 0, nil
 }
 
+func (m *mockAuthorRevenueRepository) CreateEarningIfAbsent(ctx context.Context, earning *financeModel.AuthorEarning) (bool, error) {
+	key := earning.SourceID + "|" + earning.Type
+	if _, exists := m.earnings[key]; exists {
+		return false, nil
+	}
+	earning.ID = primitive.NewObjectID()
+	m.earnings[key] = earning
+	return true, nil
+}
+
+func (m *mockAuthorRevenueRepository) ListUnsettledAuthors(ctx context.Context, end time.Time) ([]string, error) {
+	seen := make(map[string]bool)
+	var authorIDs []string
+	for _, earning := range m.earnings {
+		if !earning.IsSettled && earning.EarnedAt.Before(end) && !seen[earning.AuthorID] {
+			seen[earning.AuthorID] = true
+			authorIDs = append(authorIDs, earning.AuthorID)
+		}
+	}
+	return authorIDs, nil
+}
+
+func (m *mockAuthorRevenueRepository) ListUnsettledEarnings(ctx context.Context, authorID string, end time.Time) ([]*financeModel.AuthorEarning, error) {
+	var earnings []*financeModel.AuthorEarning
+	for _, earning := range m.earnings {
+		if earning.AuthorID == authorID && !earning.IsSettled && earning.EarnedAt.Before(end) {
+			earnings = append(earnings, earning)
+		}
+	}
+	return earnings, nil
+}
+
+func (m *mockAuthorRevenueRepository) GetOldestUnsettledEarningTime(ctx context.Context, end time.Time) (time.Time, error) {
+	var oldest time.Time
+	for _, earning := range m.earnings {
+		if !earning.IsSettled && earning.EarnedAt.Before(end) && (oldest.IsZero() || earning.EarnedAt.Before(oldest)) {
+			oldest = earning.EarnedAt
+		}
+	}
+	return oldest, nil
+}
+
+func (m *mockAuthorRevenueRepository) CreateContract(ctx context.Context, contract *financeModel.RoyaltyContract) error {
+	contract.ID = primitive.NewObjectID()
+	m.contracts = append(m.contracts, contract)
+	return nil
+}
+
+func (m *mockAuthorRevenueRepository) GetContract(ctx context.Context, contractID primitive.ObjectID) (*financeModel.RoyaltyContract, error) {
+	for _, contract := range m.contracts {
+		if contract.ID == contractID {
+			return contract, nil
+		}
+	}
+	return nil, errors.New("contract not found")
+}
+
+func (m *mockAuthorRevenueRepository) UpdateContract(ctx context.Context, contractID primitive.ObjectID, updates map[string]interface{}) error {
+	return nil
+}
+
+func (m *mockAuthorRevenueRepository) ListAuthorContracts(ctx context.Context, authorID string) ([]*financeModel.RoyaltyContract, error) {
+	var result []*financeModel.RoyaltyContract
+	for _, contract := range m.contracts {
+		if contract.AuthorID == authorID {
+			result = append(result, contract)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockAuthorRevenueRepository) CreateWithdrawalRequest(ctx context.Context, request *financeModel.WithdrawalRequest) error {
 	if m.failCreate != nil {
 		return m.failCreate
@@ -76,6 +168,13 @@ func (m *mockAuthorRevenueRepository) ListWithdrawalRequests(ctx context.Context
 }
 
 func (m *mockAuthorRevenueRepository) UpdateWithdrawalRequest(ctx context.Context, requestID primitive.ObjectID, updates map[string]interface{}) error {
+	request, ok := m.withdrawals[requestID.Hex()]
+	if !ok {
+		return errors.New("not found")
+	}
+	if settlementID, ok := updates["settlement_id"].(primitive.ObjectID); ok {
+		request.SettlementID = settlementID
+	}
 	return nil
 }
 
@@ -83,6 +182,17 @@ func (m *mockAuthorRevenueRepository) GetUserWithdrawalRequests(ctx context.Cont
 	return nil, 0, nil
 }
 
+func (m *mockAuthorRevenueRepository) ListUnoffsetWithdrawals(ctx context.Context, userID string, before time.Time) ([]*financeModel.WithdrawalRequest, error) {
+	var requests []*financeModel.WithdrawalRequest
+	for _, request := range m.withdrawals {
+		if request.UserID == userID && request.Status == financeModel.WithdrawStatusCompleted &&
+			request.TransactionID == "" && request.SettlementID.IsZero() && request.CreatedAt.Before(before) {
+			requests = append(requests, request)
+		}
+	}
+	return requests, nil
+}
+
 func (m *mockAuthorRevenueRepository) CreateSettlement(ctx context.Context, settlement *financeModel.Settlement) error {
 	return nil
 }
@@ -96,7 +206,22 @@ func (m *mockAuthorRevenueRepository) ListSettlements(ctx context.Context, filte
 }
 
 func (m *mockAuthorRevenueRepository) UpdateSettlement(ctx context.Context, settlementID primitive.ObjectID, updates map[string]interface{}) error {
-	return nil
+	for _, settlement := range m.settlements {
+		if settlement.ID != settlementID {
+			continue
+		}
+		if status, ok := updates["status"].(string); ok {
+			settlement.Status = status
+		}
+		if file, ok := updates["statement_file"].(string); ok {
+			settlement.StatementFile = file
+		}
+		if transactionID, ok := updates["transaction_id"].(string); ok {
+			settlement.TransactionID = transactionID
+		}
+		return nil
+	}
+	return errors.New("settlement not found")
 }
 
 func (m *mockAuthorRevenueRepository) GetAuthorSettlements(ctx context.Context, authorID string, page, pageSize int) ([]*financeModel.Settlement, int64, error) {
@@ -107,6 +232,25 @@ func (m *mockAuthorRevenueRepository) GetPendingSettlements(ctx context.Context)
 	return nil, nil
 }
 
+func (m *mockAuthorRevenueRepository) CreateSettlementIfAbsent(ctx context.Context, settlement *financeModel.Settlement) (bool, error) {
+	key := settlement.AuthorID + "|" + settlement.Period
+	if _, exists := m.settlements[key]; exists {
+		return false, nil
+	}
+	stored := *settlement
+	m.settlements[key] = &stored
+	return true, nil
+}
+
+func (m *mockAuthorRevenueRepository) GetSettlementByPeriod(ctx context.Context, authorID, period string) (*financeModel.Settlement, error) {
+	settlement, ok := m.settlements[authorID+"|"+period]
+	if !ok {
+		return nil, nil
+	}
+	copied := *settlement
+	return &copied, nil
+}
+
 func (m *mockAuthorRevenueRepository) GetRevenueStatistics(ctx context.Context, authorID string, period string, limit int) ([]*financeModel.RevenueStatistics, error) {
 	return nil, nil
 }
@@ -140,7 +284,11 @@ func (m *mockAuthorRevenueRepository) CreateTaxInfo(ctx context.Context, taxInfo
 }
 
 func (m *mockAuthorRevenueRepository) GetTaxInfo(ctx context.Context, userID string) (*financeModel.TaxInfo, error) {
-	return nil, errors.New("not implemented")
+	taxInfo, ok := m.taxInfos[userID]
+	if !ok {
+		return nil, errors.New("tax info not found")
+	}
+	return taxInfo, nil
 }
 
 func (m *mockAuthorRevenueRepository) UpdateTaxInfo(ctx context.Context, userID string, updates map[string]interface{}) error {
@@ -153,6 +301,7 @@ type mockWalletRepository struct {
 	failCreateWithdraw  error
 	failUpdateBalance   error
 	withdrawRequestSeed int
+	transactions        []*financeModel.Transaction
 }
 
 func newMockWalletRepository() *mockWalletRepository {
@@ -170,7 +319,8 @@ func (m *mockWalletRepository) CreateWallet(ctx context.Context, wallet *finance
 func (m *mockWalletRepository) GetWallet(ctx context.Context, userID string) (*financeModel.Wallet, error) {
 	wallet, ok := m.wallets[userID]
 	if !ok {
-		return nil, errors.New("wallet not found")
+		// 与真实仓储一致：钱包不存在时返回 nil, nil
+		return nil, nil
 	}
 	return cloneWallet(wallet), nil
 }
@@ -205,6 +355,8 @@ func (m *mockWalletRepository) UpdateBalanceWithCheck(ctx context.Context, userI
 }
 
 func (m *mockWalletRepository) CreateTransaction(ctx context.Context, transaction *financeModel.Transaction) error {
+	transaction.ID = primitive.NewObjectID()
+	m.transactions = append(m.transactions, transaction)
 	return nil
 }
 
@@ -476,6 +476,9 @@ func (s *MembershipServiceImpl) ensureWalletCanPay(ctx context.Context, userID s
 	if err != nil {
 		return fmt.Errorf("获取钱包失败: %w", err)
 	}
+	if wallet == nil {
+		return fmt.Errorf("钱包不存在")
+	}
 	if wallet.Frozen {
 		return fmt.Errorf("钱包已冻结，无法购买会员")
 	}
@@ -0,0 +1,366 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bookstoreModel "Qingyu_backend/models/bookstore"
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/shared/types"
+	"Qingyu_backend/repository/interfaces/finance"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoyaltyBookLookup 书籍查询（由 bookstore.BookRepository 满足），用于确定购买事件的作者
+type RoyaltyBookLookup interface {
+	GetByID(ctx context.Context, id string) (*bookstoreModel.Book, error)
+}
+
+// RoyaltyEventReplayer 事件回放（MongoEventStore 与 PersistedEventBus 均满足）
+type RoyaltyEventReplayer interface {
+	Replay(ctx context.Context, handler base.EventHandler, filter events.EventFilter) (*events.ReplayResult, error)
+}
+
+// RoyaltySource 一笔待入账的收入来源
+type RoyaltySource struct {
+	SourceID    string      // 订单ID或打赏ID，幂等键
+	EarningType string      // 收入类型
+	AuthorID    string      // 作者ID（为空时按书籍查询）
+	BookID      string      // 书籍ID
+	ChapterID   string      // 章节ID
+	ReaderID    string      // 付费读者ID
+	Amount      types.Money // 实付金额（分）
+	OccurredAt  time.Time   // 发生时间，用于解析当时生效的合同与推广期
+}
+
+// RoyaltyService 版税服务
+// 消费购买与打赏事件，按版税合同计算分成并幂等地生成作者收入记录
+type RoyaltyService struct {
+	revenueRepo finance.AuthorRevenueRepository
+	books       RoyaltyBookLookup
+	replayer    RoyaltyEventReplayer
+}
+
+// NewRoyaltyService 创建版税服务
+func NewRoyaltyService(revenueRepo finance.AuthorRevenueRepository, books RoyaltyBookLookup) *RoyaltyService {
+	return &RoyaltyService{
+		revenueRepo: revenueRepo,
+		books:       books,
+	}
+}
+
+// SetEventReplayer 注入事件回放能力（用于重建收入记录）
+func (s *RoyaltyService) SetEventReplayer(replayer RoyaltyEventReplayer) {
+	s.replayer = replayer
+}
+
+// SupportedEventTypes 版税入账监听的事件类型
+func (s *RoyaltyService) SupportedEventTypes() []string {
+	return []string{
+		events.EventBookPurchased,
+		events.EventChapterPurchased,
+		events.EventRewardCreated,
+	}
+}
+
+// ProcessEvent 处理购买/打赏事件，重复事件不会重复入账
+func (s *RoyaltyService) ProcessEvent(ctx context.Context, event base.Event) error {
+	_, err := s.apply(ctx, event)
+	return err
+}
+
+// apply 将事件转换为收入来源并入账，返回是否新建了收入记录
+func (s *RoyaltyService) apply(ctx context.Context, event base.Event) (bool, error) {
+	source, err := royaltySourceFromEvent(event)
+	if err != nil {
+		return false, err
+	}
+	if source == nil {
+		return false, nil
+	}
+
+	_, created, err := s.RecordEarning(ctx, source)
+	return created, err
+}
+
+// royaltySourceFromEvent 从事件中提取收入来源，不支持的事件返回 nil
+func royaltySourceFromEvent(event base.Event) (*RoyaltySource, error) {
+	switch event.GetEventType() {
+	case events.EventBookPurchased, events.EventChapterPurchased:
+		var data events.PurchaseEventData
+		if err := events.DecodeEventData(event.GetEventData(), &data); err != nil {
+			return nil, fmt.Errorf("解析购买事件失败: %w", err)
+		}
+		earningType := financeModel.EarningTypeBookPurchase
+		if event.GetEventType() == events.EventChapterPurchased {
+			earningType = financeModel.EarningTypeChapterPurchase
+		}
+		return &RoyaltySource{
+			SourceID:    data.OrderID,
+			EarningType: earningType,
+			BookID:      data.BookID,
+			ChapterID:   data.ChapterID,
+			ReaderID:    data.UserID,
+			Amount:      types.NewMoneyFromYuan(data.FinalAmount),
+			OccurredAt:  eventTime(data.Time, event),
+		}, nil
+
+	case events.EventRewardCreated:
+		var data events.RewardEventData
+		if err := events.DecodeEventData(event.GetEventData(), &data); err != nil {
+			return nil, fmt.Errorf("解析打赏事件失败: %w", err)
+		}
+		return &RoyaltySource{
+			SourceID:    data.RewardID,
+			EarningType: financeModel.EarningTypeReward,
+			AuthorID:    data.AuthorID,
+			BookID:      data.BookID,
+			ChapterID:   data.ChapterID,
+			ReaderID:    data.SponsorID,
+			Amount:      types.NewMoneyFromYuan(data.Amount),
+			OccurredAt:  eventTime(data.Time, event),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// eventTime 优先使用业务时间，缺失时使用事件时间戳
+func eventTime(at time.Time, event base.Event) time.Time {
+	if !at.IsZero() {
+		return at
+	}
+	return event.GetTimestamp()
+}
+
+// RecordEarning 为一笔收入来源入账
+// 以来源ID幂等：同一订单或打赏重复入账时返回 created=false；金额非正时不入账
+func (s *RoyaltyService) RecordEarning(ctx context.Context, source *RoyaltySource) (*financeModel.AuthorEarning, bool, error) {
+	if source.SourceID == "" {
+		return nil, false, fmt.Errorf("收入来源缺少订单或打赏ID")
+	}
+	if !source.Amount.IsPositive() {
+		return nil, false, nil
+	}
+	if source.OccurredAt.IsZero() {
+		source.OccurredAt = time.Now()
+	}
+
+	earning := &financeModel.AuthorEarning{
+		AuthorID: source.AuthorID,
+		Type:     source.EarningType,
+		SourceID: source.SourceID,
+		Amount:   source.Amount,
+		ReaderID: source.ReaderID,
+		EarnedAt: source.OccurredAt,
+	}
+	if oid, err := primitive.ObjectIDFromHex(source.BookID); err == nil {
+		earning.BookID = oid
+	}
+	if oid, err := primitive.ObjectIDFromHex(source.ChapterID); err == nil {
+		earning.ChapterID = oid
+	}
+
+	if source.BookID != "" && s.books != nil {
+		book, err := s.books.GetByID(ctx, source.BookID)
+		if err != nil && earning.AuthorID == "" {
+			return nil, false, fmt.Errorf("查询书籍 %s 失败: %w", source.BookID, err)
+		}
+		if book != nil {
+			earning.BookTitle = book.Title
+			if earning.AuthorID == "" {
+				earning.AuthorID = book.AuthorID
+			}
+		}
+	}
+	if earning.AuthorID == "" {
+		return nil, false, fmt.Errorf("无法确定收入 %s 的作者", source.SourceID)
+	}
+
+	rate, err := resolveRoyaltyRate(ctx, s.revenueRepo, earning.AuthorID, source.BookID, source.EarningType, source.OccurredAt)
+	if err != nil {
+		return nil, false, err
+	}
+	earning.AuthorRate = rate.AuthorRate
+	earning.ContractID = rate.ContractID
+	earning.Promotion = rate.Promotion
+	earning.AuthorIncome, earning.PlatformFee = splitRoyalty(source.Amount, rate.AuthorRate)
+
+	created, err := s.revenueRepo.CreateEarningIfAbsent(ctx, earning)
+	if err != nil {
+		return nil, false, fmt.Errorf("创建收入记录失败: %w", err)
+	}
+	return earning, created, nil
+}
+
+// ResolveRate 解析作者某本书在指定时间的分成比例
+func (s *RoyaltyService) ResolveRate(ctx context.Context, authorID, bookID, earningType string, at time.Time) (financeModel.RoyaltyRate, error) {
+	return resolveRoyaltyRate(ctx, s.revenueRepo, authorID, bookID, earningType, at)
+}
+
+// ============ 版税合同 ============
+
+// CreateContract 创建版税合同
+func (s *RoyaltyService) CreateContract(ctx context.Context, contract *financeModel.RoyaltyContract) error {
+	if contract.AuthorID == "" {
+		return fmt.Errorf("版税合同缺少作者ID")
+	}
+	switch contract.Type {
+	case financeModel.ContractTypeExclusive, financeModel.ContractTypeNonExclusive:
+	default:
+		return fmt.Errorf("未知的合同类型: %s", contract.Type)
+	}
+	if contract.Tier == "" {
+		contract.Tier = financeModel.ContractTierStandard
+	}
+	for earningType, rate := range contract.Rates {
+		if _, ok := financeModel.DefaultAuthorRate(earningType); !ok {
+			return fmt.Errorf("未知的收入类型: %s", earningType)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("分成比例必须在0到1之间: %s=%v", earningType, rate)
+		}
+	}
+	if contract.EffectiveFrom.IsZero() {
+		contract.EffectiveFrom = time.Now()
+	}
+	if contract.EffectiveTo != nil && !contract.EffectiveTo.After(contract.EffectiveFrom) {
+		return fmt.Errorf("合同失效时间必须晚于生效时间")
+	}
+	if contract.Status == "" {
+		contract.Status = financeModel.ContractStatusActive
+	}
+
+	if err := s.revenueRepo.CreateContract(ctx, contract); err != nil {
+		return fmt.Errorf("创建版税合同失败: %w", err)
+	}
+	return nil
+}
+
+// ListContracts 获取作者的版税合同
+func (s *RoyaltyService) ListContracts(ctx context.Context, authorID string) ([]*financeModel.RoyaltyContract, error) {
+	contracts, err := s.revenueRepo.ListAuthorContracts(ctx, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("获取版税合同失败: %w", err)
+	}
+	return contracts, nil
+}
+
+// TerminateContract 终止版税合同，此后的收入不再适用该合同
+func (s *RoyaltyService) TerminateContract(ctx context.Context, contractID string) error {
+	oid, err := primitive.ObjectIDFromHex(contractID)
+	if err != nil {
+		return fmt.Errorf("无效的合同ID: %w", err)
+	}
+	now := time.Now()
+	return s.revenueRepo.UpdateContract(ctx, oid, map[string]interface{}{
+		"status":       financeModel.ContractStatusTerminated,
+		"effective_to": now,
+	})
+}
+
+// ============ 回放重建 ============
+
+// RebuildEarnings 回放事件存储中的购买与打赏事件，补齐缺失的作者收入记录
+// 已入账的订单或打赏按来源ID跳过，可重复执行
+func (s *RoyaltyService) RebuildEarnings(ctx context.Context, since *time.Time) (*financeModel.RoyaltyReplayResult, error) {
+	if s.replayer == nil {
+		return nil, fmt.Errorf("事件存储未配置，无法重建收入记录")
+	}
+
+	handler := &royaltyReplayHandler{service: s}
+	result := &financeModel.RoyaltyReplayResult{}
+	for _, eventType := range s.SupportedEventTypes() {
+		replayed, err := s.replayer.Replay(ctx, handler, events.EventFilter{
+			EventType: eventType,
+			StartTime: since,
+		})
+		if err != nil {
+			return result, fmt.Errorf("回放事件 %s 失败: %w", eventType, err)
+		}
+		result.ReplayedCount += replayed.ReplayedCount
+		result.FailedCount += replayed.FailedCount
+	}
+	result.CreatedCount = handler.created
+	result.SkippedCount = int(result.ReplayedCount) - handler.created
+	return result, nil
+}
+
+// royaltyReplayHandler 重建收入用事件处理器
+type royaltyReplayHandler struct {
+	service *RoyaltyService
+	created int
+}
+
+// Handle 处理回放事件
+func (h *royaltyReplayHandler) Handle(ctx context.Context, event base.Event) error {
+	created, err := h.service.apply(ctx, event)
+	if created {
+		h.created++
+	}
+	return err
+}
+
+// GetHandlerName 获取处理器名称
+func (h *royaltyReplayHandler) GetHandlerName() string {
+	return "RoyaltyReplayHandler"
+}
+
+// GetSupportedEventTypes 获取支持的事件类型
+func (h *royaltyReplayHandler) GetSupportedEventTypes() []string {
+	return h.service.SupportedEventTypes()
+}
+
+// ============ 分成计算 ============
+
+// resolveRoyaltyRate 按合同解析分成比例
+// 优先使用该书的书籍级合同，其次作者级合同，同级取生效时间最晚者；无适用合同时使用默认分成
+func resolveRoyaltyRate(ctx context.Context, repo finance.AuthorRevenueRepository, authorID, bookID, earningType string, at time.Time) (financeModel.RoyaltyRate, error) {
+	defaultRate, ok := financeModel.DefaultAuthorRate(earningType)
+	if !ok {
+		return financeModel.RoyaltyRate{}, fmt.Errorf("未知的收入类型: %s", earningType)
+	}
+
+	contracts, err := repo.ListAuthorContracts(ctx, authorID)
+	if err != nil {
+		return financeModel.RoyaltyRate{}, fmt.Errorf("查询版税合同失败: %w", err)
+	}
+
+	var bookContract, authorContract *financeModel.RoyaltyContract
+	for _, contract := range contracts {
+		if !contract.IsEffectiveAt(at) {
+			continue
+		}
+		switch {
+		case contract.BookID == "":
+			if authorContract == nil || contract.EffectiveFrom.After(authorContract.EffectiveFrom) {
+				authorContract = contract
+			}
+		case contract.BookID == bookID:
+			if bookContract == nil || contract.EffectiveFrom.After(bookContract.EffectiveFrom) {
+				bookContract = contract
+			}
+		}
+	}
+
+	for _, contract := range []*financeModel.RoyaltyContract{bookContract, authorContract} {
+		if contract == nil {
+			continue
+		}
+		if rate, ok := contract.AuthorRate(earningType, at); ok {
+			return rate, nil
+		}
+	}
+
+	return financeModel.RoyaltyRate{AuthorRate: defaultRate}, nil
+}
+
+// splitRoyalty 按作者分成比例拆分金额，平台收入取差额以保证分账无损
+func splitRoyalty(amount types.Money, authorRate float64) (types.Money, types.Money) {
+	authorIncome := amount.Mul(authorRate)
+	return authorIncome, amount.Sub(authorIncome)
+}
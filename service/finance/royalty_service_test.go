@@ -0,0 +1,198 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bookstoreModel "Qingyu_backend/models/bookstore"
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/shared/types"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type stubBookLookup map[string]*bookstoreModel.Book
+
+func (s stubBookLookup) GetByID(ctx context.Context, id string) (*bookstoreModel.Book, error) {
+	book, ok := s[id]
+	if !ok {
+		return nil, errors.New("book not found")
+	}
+	return book, nil
+}
+
+// bsonReplayer 模拟 MongoEventStore 回放：事件数据经 bson 往返后以 primitive.D 交给处理器
+type bsonReplayer struct {
+	events []base.Event
+}
+
+func (r *bsonReplayer) Replay(ctx context.Context, handler base.EventHandler, filter events.EventFilter) (*events.ReplayResult, error) {
+	result := &events.ReplayResult{}
+	for _, event := range r.events {
+		if event.GetEventType() != filter.EventType {
+			continue
+		}
+		raw, err := bson.Marshal(bson.M{"event_data": event.GetEventData()})
+		if err != nil {
+			return nil, err
+		}
+		var stored struct {
+			EventData interface{} `bson:"event_data"`
+		}
+		if err := bson.Unmarshal(raw, &stored); err != nil {
+			return nil, err
+		}
+		replayed := &base.BaseEvent{EventType: event.GetEventType(), EventData: stored.EventData, Timestamp: event.GetTimestamp()}
+		if err := handler.Handle(ctx, replayed); err != nil {
+			result.FailedCount++
+			continue
+		}
+		result.ReplayedCount++
+	}
+	return result, nil
+}
+
+func newRoyaltyTestService() (*RoyaltyService, *mockAuthorRevenueRepository, string) {
+	repo := newMockAuthorRevenueRepository()
+	bookID := primitive.NewObjectID().Hex()
+	books := stubBookLookup{bookID: {Title: "青羽", AuthorID: "author-1"}}
+	return NewRoyaltyService(repo, books), repo, bookID
+}
+
+func TestRoyaltyServiceChapterPurchaseIsIdempotent(t *testing.T) {
+	service, repo, bookID := newRoyaltyTestService()
+	event := events.NewChapterPurchasedEvent("reader-1", bookID, primitive.NewObjectID().Hex(), "order-1", "wallet", 0.99)
+
+	require.NoError(t, service.ProcessEvent(context.Background(), event))
+	require.NoError(t, service.ProcessEvent(context.Background(), event))
+
+	require.Len(t, repo.earnings, 1)
+	earning := repo.earnings["order-1|"+financeModel.EarningTypeChapterPurchase]
+	assert.Equal(t, "author-1", earning.AuthorID)
+	assert.Equal(t, "青羽", earning.BookTitle)
+	assert.Equal(t, types.Money(99), earning.Amount)
+	assert.Equal(t, types.Money(69), earning.AuthorIncome)
+	assert.Equal(t, types.Money(30), earning.PlatformFee)
+	assert.True(t, earning.ContractID.IsZero())
+}
+
+func TestRoyaltyServiceResolvesBookContractAndPromotion(t *testing.T) {
+	service, repo, bookID := newRoyaltyTestService()
+	now := time.Now()
+	ctx := context.Background()
+
+	require.NoError(t, service.CreateContract(ctx, &financeModel.RoyaltyContract{
+		AuthorID:      "author-1",
+		Type:          financeModel.ContractTypeExclusive,
+		Tier:          financeModel.ContractTierPremium,
+		EffectiveFrom: now.Add(-48 * time.Hour),
+	}))
+	require.NoError(t, service.CreateContract(ctx, &financeModel.RoyaltyContract{
+		AuthorID:      "author-1",
+		BookID:        bookID,
+		Type:          financeModel.ContractTypeNonExclusive,
+		EffectiveFrom: now.Add(-24 * time.Hour),
+		Promotions: []financeModel.RoyaltyPromotion{{
+			Name:         "新书推广",
+			StartAt:      now.Add(-time.Hour),
+			EndAt:        now.Add(time.Hour),
+			EarningTypes: []string{financeModel.EarningTypeBookPurchase},
+			RateBonus:    0.15,
+		}},
+	}))
+
+	// 书籍级非独家合同 60% + 推广 15%
+	bookRate, err := service.ResolveRate(ctx, "author-1", bookID, financeModel.EarningTypeBookPurchase, now)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, bookRate.AuthorRate, 1e-9)
+	assert.Equal(t, "新书推广", bookRate.Promotion)
+
+	// 其他书籍走作者级独家白金合同 70% + 5%
+	otherRate, err := service.ResolveRate(ctx, "author-1", primitive.NewObjectID().Hex(), financeModel.EarningTypeChapterPurchase, now)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, otherRate.AuthorRate, 1e-9)
+	assert.Empty(t, otherRate.Promotion)
+
+	// 推广期外恢复合同基础比例
+	laterRate, err := service.ResolveRate(ctx, "author-1", bookID, financeModel.EarningTypeBookPurchase, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 0.60, laterRate.AuthorRate, 1e-9)
+
+	earning, created, err := service.RecordEarning(ctx, &RoyaltySource{
+		SourceID:    "order-2",
+		EarningType: financeModel.EarningTypeBookPurchase,
+		BookID:      bookID,
+		ReaderID:    "reader-1",
+		Amount:      types.NewMoneyFromYuan(20),
+		OccurredAt:  now,
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, types.NewMoneyFromYuan(15), earning.AuthorIncome)
+	assert.Equal(t, types.NewMoneyFromYuan(5), earning.PlatformFee)
+	assert.Equal(t, repo.contracts[1].ID, earning.ContractID)
+}
+
+func TestRoyaltyServiceRebuildEarningsFromStoredEvents(t *testing.T) {
+	service, repo, bookID := newRoyaltyTestService()
+	replayer := &bsonReplayer{events: []base.Event{
+		events.NewBookPurchasedEvent("reader-1", bookID, "order-1", "wallet", 30, 5, 25),
+		events.NewRewardCreatedEvent("reward-1", "reader-2", "author-2", bookID, "", "加油", 10),
+		events.NewChapterPurchasedEvent("reader-3", bookID, "", "order-3", "wallet", 0),
+	}}
+	service.SetEventReplayer(replayer)
+
+	// 实时处理过的订单在重建时跳过
+	_, _, err := service.RecordEarning(context.Background(), &RoyaltySource{
+		SourceID:    "order-1",
+		EarningType: financeModel.EarningTypeBookPurchase,
+		BookID:      bookID,
+		Amount:      types.NewMoneyFromYuan(25),
+	})
+	require.NoError(t, err)
+
+	result, err := service.RebuildEarnings(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.ReplayedCount)
+	assert.Equal(t, int64(0), result.FailedCount)
+	assert.Equal(t, 1, result.CreatedCount)
+	assert.Equal(t, 2, result.SkippedCount)
+
+	reward := repo.earnings["reward-1|"+financeModel.EarningTypeReward]
+	require.NotNil(t, reward)
+	assert.Equal(t, "author-2", reward.AuthorID)
+	assert.Equal(t, "reader-2", reward.ReaderID)
+	assert.Equal(t, types.NewMoneyFromYuan(9), reward.AuthorIncome)
+	assert.Equal(t, types.NewMoneyFromYuan(1), reward.PlatformFee)
+	assert.False(t, reward.EarnedAt.IsZero())
+}
+
+func TestAuthorRevenueCalculateEarningUsesContracts(t *testing.T) {
+	repo := newMockAuthorRevenueRepository()
+	bookID := primitive.NewObjectID()
+	repo.contracts = append(repo.contracts, &financeModel.RoyaltyContract{
+		ID:            primitive.NewObjectID(),
+		AuthorID:      "author-1",
+		BookID:        bookID.Hex(),
+		Type:          financeModel.ContractTypeExclusive,
+		Rates:         map[string]float64{financeModel.EarningTypeVIPReading: 0.8},
+		Status:        financeModel.ContractStatusActive,
+		EffectiveFrom: time.Now().Add(-time.Hour),
+	})
+	service := NewAuthorRevenueService(repo)
+
+	authorIncome, platformIncome, err := service.CalculateEarning(context.Background(), financeModel.EarningTypeVIPReading, types.Money(333), "author-1", bookID)
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(266), authorIncome)
+	assert.Equal(t, types.Money(67), platformIncome)
+
+	_, _, err = service.CalculateEarning(context.Background(), "unknown", types.Money(100), "author-1", bookID)
+	assert.Error(t, err)
+}
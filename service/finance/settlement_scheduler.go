@@ -0,0 +1,78 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// settlementJobTimeout 单次结算任务的超时时间
+const settlementJobTimeout = 2 * time.Hour
+
+// SettlementScheduler 作者月度结算调度器
+// 每月1日凌晨3点结算所有已结束周期内的未结算收入；启动时补跑停机期间错过的周期，已结算的作者会被跳过
+type SettlementScheduler struct {
+	service *SettlementService
+	cron    *cron.Cron
+	logger  *zap.Logger
+}
+
+// NewSettlementScheduler 创建作者结算调度器
+func NewSettlementScheduler(service *SettlementService, logger *zap.Logger) *SettlementScheduler {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &SettlementScheduler{
+		service: service,
+		cron:    cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		logger:  logger,
+	}
+}
+
+// Start 启动调度器
+func (s *SettlementScheduler) Start() error {
+	_, err := s.cron.AddFunc("0 0 3 1 * *", s.settleOverdue)
+	if err != nil {
+		return fmt.Errorf("failed to add settlement job: %w", err)
+	}
+
+	s.cron.Start()
+	s.logger.Info("Settlement scheduler started")
+
+	// 补跑停机期间错过的结算
+	go s.settleOverdue()
+	return nil
+}
+
+// Stop 停止调度器
+func (s *SettlementScheduler) Stop() {
+	s.cron.Stop()
+	s.logger.Info("Settlement scheduler stopped")
+}
+
+func (s *SettlementScheduler) settleOverdue() {
+	ctx, cancel := context.WithTimeout(context.Background(), settlementJobTimeout)
+	defer cancel()
+
+	results, err := s.service.SettleOverduePeriods(ctx)
+	for _, result := range results {
+		if result.AuthorCount > 0 {
+			s.logger.Info("Settled period",
+				zap.String("period", result.Period),
+				zap.Int("settled", result.Settled),
+				zap.Int("skipped", result.Skipped),
+				zap.Int("failed", result.Failed))
+		}
+		if result.Failed > 0 {
+			s.logger.Warn("Settlement failed for authors",
+				zap.String("period", result.Period),
+				zap.Strings("author_ids", result.FailedIDs))
+		}
+	}
+	if err != nil {
+		s.logger.Error("Failed to settle overdue periods", zap.Error(err))
+	}
+}
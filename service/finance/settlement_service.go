@@ -0,0 +1,499 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/pkg/distlock"
+	pkgtransaction "Qingyu_backend/pkg/transaction"
+	"Qingyu_backend/repository/interfaces/finance"
+	sharedRepo "Qingyu_backend/repository/interfaces/shared"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// SettlementPeriodLayout 结算周期标识格式（按自然月结算）
+	SettlementPeriodLayout = "2006-01"
+	// settlementLockTTL 单个作者结算的分布式锁有效期
+	settlementLockTTL = 10 * time.Minute
+	// defaultSettlementStatementDir 结算对账单默认导出目录
+	defaultSettlementStatementDir = "exports/settlements"
+	settlementStatementSheet      = "结算单"
+)
+
+// ErrSettlementInProgress 其他实例正在结算同一作者同一周期
+var ErrSettlementInProgress = errors.New("该作者本周期结算正在进行中")
+
+// SettlementService 作者周期结算服务
+// 按自然月汇总作者未结算收入，扣除平台抽成、税费与预支提现后锁定结算单并计入作者钱包。
+// 结算单以 (author_id, period) 唯一，配合分布式锁保证每位作者每个周期只入账一次；
+// 中途失败可重复执行，已锁定的结算单只会补齐对账单导出与事件发布。
+// 往期结算单生成后才补记的收入（如事件回放）计入该作者下一个尚未结算的周期。
+type SettlementService struct {
+	revenueRepo  finance.AuthorRevenueRepository
+	walletRepo   sharedRepo.WalletRepository
+	txRunner     pkgtransaction.Runner
+	eventBus     base.EventBus
+	lock         *distlock.RedisLockService // 为 nil 时按单实例运行
+	statementDir string
+	now          func() time.Time
+}
+
+// NewSettlementService 创建作者结算服务
+func NewSettlementService(revenueRepo finance.AuthorRevenueRepository, walletRepo sharedRepo.WalletRepository, txRunner pkgtransaction.Runner, eventBus base.EventBus) *SettlementService {
+	return &SettlementService{
+		revenueRepo:  revenueRepo,
+		walletRepo:   walletRepo,
+		txRunner:     txRunner,
+		eventBus:     eventBus,
+		statementDir: defaultSettlementStatementDir,
+		now:          time.Now,
+	}
+}
+
+// SetLock 注入分布式锁（多实例部署时必须设置）
+func (s *SettlementService) SetLock(lock *distlock.RedisLockService) {
+	s.lock = lock
+}
+
+// SetStatementDir 设置结算对账单导出目录
+func (s *SettlementService) SetStatementDir(dir string) {
+	if dir != "" {
+		s.statementDir = dir
+	}
+}
+
+// SettlementPeriod 解析结算周期标识，返回周期的起止时间 [start, end)
+func SettlementPeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(SettlementPeriodLayout, period, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的结算周期 %q: %w", period, err)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// PreviousSettlementPeriod 返回 at 所在月份的上一个结算周期
+func PreviousSettlementPeriod(at time.Time) string {
+	at = at.In(time.Local)
+	monthStart := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.Local)
+	return monthStart.AddDate(0, -1, 0).Format(SettlementPeriodLayout)
+}
+
+// SettleOverduePeriods 按周期先后结算所有已结束周期内的未结算收入
+// 从最早一笔未结算收入所在周期补跑到上一个周期，用于调度任务与停机后的补偿
+func (s *SettlementService) SettleOverduePeriods(ctx context.Context) ([]*financeModel.SettlementRunResult, error) {
+	_, end, err := SettlementPeriod(PreviousSettlementPeriod(s.now()))
+	if err != nil {
+		return nil, err
+	}
+
+	oldest, err := s.revenueRepo.GetOldestUnsettledEarningTime(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if oldest.IsZero() {
+		return nil, nil
+	}
+
+	oldest = oldest.In(time.Local)
+	var results []*financeModel.SettlementRunResult
+	for start := time.Date(oldest.Year(), oldest.Month(), 1, 0, 0, 0, 0, time.Local); start.Before(end); start = start.AddDate(0, 1, 0) {
+		result, err := s.SettlePeriod(ctx, start.Format(SettlementPeriodLayout))
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// SettlePeriod 结算指定周期，周期结束前所有仍未结算的收入都计入本周期
+// 单个作者失败不影响其他作者，失败的作者会在下次执行时重试
+func (s *SettlementService) SettlePeriod(ctx context.Context, period string) (*financeModel.SettlementRunResult, error) {
+	start, end, err := SettlementPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if end.After(s.now()) {
+		return nil, fmt.Errorf("结算周期 %s 尚未结束", period)
+	}
+
+	authorIDs, err := s.revenueRepo.ListUnsettledAuthors(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &financeModel.SettlementRunResult{Period: period, AuthorCount: len(authorIDs)}
+	for _, authorID := range authorIDs {
+		_, settled, err := s.settleAuthor(ctx, authorID, period, start, end)
+		switch {
+		case errors.Is(err, ErrSettlementInProgress):
+			result.Skipped++
+		case err != nil:
+			result.Failed++
+			result.FailedIDs = append(result.FailedIDs, authorID)
+		case settled:
+			result.Settled++
+		default:
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+// SettleAuthor 结算单个作者的指定周期，周期内无收入时返回 nil
+// 已完成的结算单直接返回，不会重复入账
+func (s *SettlementService) SettleAuthor(ctx context.Context, authorID, period string) (*financeModel.Settlement, error) {
+	start, end, err := SettlementPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if end.After(s.now()) {
+		return nil, fmt.Errorf("结算周期 %s 尚未结束", period)
+	}
+
+	settlement, _, err := s.settleAuthor(ctx, authorID, period, start, end)
+	return settlement, err
+}
+
+// settleAuthor 在分布式锁保护下完成作者结算，返回本次执行是否完成了结算
+func (s *SettlementService) settleAuthor(ctx context.Context, authorID, period string, start, end time.Time) (*financeModel.Settlement, bool, error) {
+	if s.lock != nil {
+		lockKey := fmt.Sprintf("finance:settlement:%s:%s", authorID, period)
+		lockID, err := s.lock.Acquire(ctx, lockKey, settlementLockTTL)
+		if errors.Is(err, distlock.ErrLockAcquisitionFailed) {
+			return nil, false, ErrSettlementInProgress
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		defer s.lock.Release(context.Background(), lockKey, lockID)
+	}
+
+	settlement, err := s.revenueRepo.GetSettlementByPeriod(ctx, authorID, period)
+	if err != nil {
+		return nil, false, err
+	}
+	if settlement == nil {
+		settlement, err = s.lockSettlement(ctx, authorID, period, start, end)
+		if err != nil {
+			return nil, false, err
+		}
+		if settlement == nil {
+			return nil, false, nil
+		}
+	}
+	if settlement.Status == financeModel.SettlementStatusCompleted {
+		return settlement, false, nil
+	}
+
+	if err := s.completeSettlement(ctx, settlement); err != nil {
+		return settlement, false, err
+	}
+	return settlement, true, nil
+}
+
+// lockSettlement 在同一事务中生成锁定的结算单、标记收入与预支提现、计入作者钱包
+func (s *SettlementService) lockSettlement(ctx context.Context, authorID, period string, start, end time.Time) (*financeModel.Settlement, error) {
+	if s.walletRepo == nil {
+		return nil, fmt.Errorf("钱包仓储未配置，无法结算入账")
+	}
+
+	var settlement *financeModel.Settlement
+	err := s.runInTransaction(ctx, func(txCtx context.Context) error {
+		earnings, err := s.revenueRepo.ListUnsettledEarnings(txCtx, authorID, end)
+		if err != nil {
+			return err
+		}
+		if len(earnings) == 0 {
+			return nil
+		}
+
+		taxRate := financeModel.DefaultTaxRate
+		if taxInfo, err := s.revenueRepo.GetTaxInfo(txCtx, authorID); err == nil && taxInfo != nil {
+			taxRate = taxInfo.TaxRate
+		}
+
+		withdrawals, err := s.revenueRepo.ListUnoffsetWithdrawals(txCtx, authorID, end)
+		if err != nil {
+			return err
+		}
+
+		lockedAt := s.now()
+		draft, offset := buildSettlement(authorID, period, start, end, earnings, taxRate, withdrawals)
+		draft.ID = primitive.NewObjectID()
+		draft.Status = financeModel.SettlementStatusProcessing
+		draft.Locked = true
+		draft.LockedAt = &lockedAt
+
+		created, err := s.revenueRepo.CreateSettlementIfAbsent(txCtx, draft)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return fmt.Errorf("作者 %s 的 %s 结算单已存在", authorID, period)
+		}
+
+		earningIDs := make([]primitive.ObjectID, 0, len(earnings))
+		for _, earning := range earnings {
+			earningIDs = append(earningIDs, earning.ID)
+		}
+		if err := s.revenueRepo.BatchUpdateEarnings(txCtx, earningIDs, map[string]interface{}{
+			"settlement_id": draft.ID,
+			"is_settled":    true,
+		}); err != nil {
+			return err
+		}
+
+		for _, withdrawal := range offset {
+			if err := s.revenueRepo.UpdateWithdrawalRequest(txCtx, withdrawal.ID, map[string]interface{}{
+				"settlement_id": draft.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if draft.FinalIncome.IsPositive() {
+			transactionID, err := s.creditWallet(txCtx, draft)
+			if err != nil {
+				return err
+			}
+			draft.TransactionID = transactionID
+			if err := s.revenueRepo.UpdateSettlement(txCtx, draft.ID, map[string]interface{}{
+				"transaction_id": transactionID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		settlement = draft
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("锁定作者 %s 的 %s 结算单失败: %w", authorID, period, err)
+	}
+
+	return settlement, nil
+}
+
+// creditWallet 将结算金额计入作者可提现余额，钱包不存在时先创建
+func (s *SettlementService) creditWallet(ctx context.Context, settlement *financeModel.Settlement) (string, error) {
+	wallet, err := s.walletRepo.GetWallet(ctx, settlement.AuthorID)
+	if err != nil {
+		return "", fmt.Errorf("查询作者钱包失败: %w", err)
+	}
+	if wallet == nil {
+		if err := s.walletRepo.CreateWallet(ctx, &financeModel.Wallet{UserID: settlement.AuthorID}); err != nil {
+			return "", fmt.Errorf("创建作者钱包失败: %w", err)
+		}
+	}
+
+	if err := s.walletRepo.UpdateBalance(ctx, settlement.AuthorID, int64(settlement.FinalIncome)); err != nil {
+		return "", fmt.Errorf("结算入账失败: %w", err)
+	}
+
+	transaction := &financeModel.Transaction{
+		UserID:          settlement.AuthorID,
+		Type:            financeModel.TransactionTypeSettlement,
+		Amount:          settlement.FinalIncome,
+		Reason:          fmt.Sprintf("settlement:%s", settlement.Period),
+		Status:          financeModel.TransactionStatusSuccess,
+		OrderNo:         settlement.ID.Hex(),
+		TransactionTime: s.now(),
+	}
+	if err := s.walletRepo.CreateTransaction(ctx, transaction); err != nil {
+		return "", fmt.Errorf("创建结算入账流水失败: %w", err)
+	}
+
+	return transaction.ID.Hex(), nil
+}
+
+// completeSettlement 导出对账单、发布结算事件并将结算单标记为已完成
+// 对账单已导出时不会重复生成，可在失败后安全重试
+func (s *SettlementService) completeSettlement(ctx context.Context, settlement *financeModel.Settlement) error {
+	if settlement.StatementFile == "" {
+		path, err := s.writeStatement(settlement)
+		if err != nil {
+			return err
+		}
+		if err := s.revenueRepo.UpdateSettlement(ctx, settlement.ID, map[string]interface{}{
+			"statement_file": path,
+		}); err != nil {
+			return err
+		}
+		settlement.StatementFile = path
+	}
+
+	if s.eventBus != nil {
+		event := events.NewSettlementGeneratedEvent(
+			settlement.ID.Hex(),
+			settlement.AuthorID,
+			settlement.Period,
+			settlement.TotalRevenue.ToYuan(),
+			settlement.PlatformFee.ToYuan(),
+			settlement.ActualIncome.ToYuan(),
+			settlement.TaxFee.ToYuan(),
+			settlement.FinalIncome.ToYuan(),
+		)
+		if err := s.eventBus.PublishAsync(ctx, event); err != nil {
+			return fmt.Errorf("发布结算事件失败: %w", err)
+		}
+	}
+
+	processedAt := s.now()
+	if err := s.revenueRepo.UpdateSettlement(ctx, settlement.ID, map[string]interface{}{
+		"status":       financeModel.SettlementStatusCompleted,
+		"processed_at": processedAt,
+	}); err != nil {
+		return err
+	}
+	settlement.Status = financeModel.SettlementStatusCompleted
+	settlement.ProcessedAt = &processedAt
+
+	return nil
+}
+
+// ExportStatement 生成结算对账单（xlsx）
+func (s *SettlementService) ExportStatement(settlement *financeModel.Settlement) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", settlementStatementSheet); err != nil {
+		return nil, fmt.Errorf("创建对账单失败: %w", err)
+	}
+
+	rows := [][]interface{}{
+		{"结算单号", settlement.ID.Hex()},
+		{"作者ID", settlement.AuthorID},
+		{"结算周期", settlement.Period},
+		{"总收入(元)", settlement.TotalRevenue.ToYuan()},
+		{"平台抽成(元)", settlement.PlatformFee.ToYuan()},
+		{"作者分成(元)", settlement.ActualIncome.ToYuan()},
+		{"税率", settlement.TaxRate},
+		{"税费(元)", settlement.TaxFee.ToYuan()},
+		{"抵扣已提现(元)", settlement.WithdrawnFee.ToYuan()},
+		{"实际入账(元)", settlement.FinalIncome.ToYuan()},
+		{},
+		{"书籍ID", "书名", "收入类型", "笔数", "收入金额(元)", "平台抽成(元)", "作者分成(元)"},
+	}
+	for _, line := range settlement.Lines {
+		rows = append(rows, []interface{}{
+			line.BookID.Hex(),
+			line.BookTitle,
+			line.Type,
+			line.EarningCount,
+			line.Amount.ToYuan(),
+			line.PlatformFee.ToYuan(),
+			line.AuthorIncome.ToYuan(),
+		})
+	}
+
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.SetSheetRow(settlementStatementSheet, cell, &row); err != nil {
+			return nil, fmt.Errorf("写入对账单失败: %w", err)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("生成对账单失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeStatement 导出对账单到 {statementDir}/{period}/{settlementID}.xlsx
+func (s *SettlementService) writeStatement(settlement *financeModel.Settlement) (string, error) {
+	data, err := s.ExportStatement(settlement)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(s.statementDir, settlement.Period)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建对账单目录失败: %w", err)
+	}
+	path := filepath.Join(dir, settlement.ID.Hex()+".xlsx")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("写入对账单失败: %w", err)
+	}
+	return path, nil
+}
+
+func (s *SettlementService) runInTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txRunner == nil {
+		return fn(ctx)
+	}
+	return s.txRunner.Run(ctx, fn)
+}
+
+// buildSettlement 按书籍与收入类型汇总收入并计算结算金额
+// 实际入账 = 总收入 - 平台抽成 - 税费 - 预支提现；预支提现按申请先后整笔抵扣，超出部分留待后续周期
+func buildSettlement(authorID, period string, start, end time.Time, earnings []*financeModel.AuthorEarning, taxRate float64, withdrawals []*financeModel.WithdrawalRequest) (*financeModel.Settlement, []*financeModel.WithdrawalRequest) {
+	settlement := &financeModel.Settlement{
+		AuthorID:     authorID,
+		Period:       period,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		TaxRate:      taxRate,
+		EarningCount: len(earnings),
+	}
+
+	lines := make(map[string]*financeModel.SettlementLine)
+	for _, earning := range earnings {
+		key := earning.BookID.Hex() + "|" + earning.Type
+		line, ok := lines[key]
+		if !ok {
+			line = &financeModel.SettlementLine{BookID: earning.BookID, BookTitle: earning.BookTitle, Type: earning.Type}
+			lines[key] = line
+		}
+		line.EarningCount++
+		line.Amount = line.Amount.Add(earning.Amount)
+		line.PlatformFee = line.PlatformFee.Add(earning.PlatformFee)
+		line.AuthorIncome = line.AuthorIncome.Add(earning.AuthorIncome)
+
+		settlement.TotalRevenue = settlement.TotalRevenue.Add(earning.Amount)
+		settlement.PlatformFee = settlement.PlatformFee.Add(earning.PlatformFee)
+	}
+
+	for _, line := range lines {
+		settlement.Lines = append(settlement.Lines, *line)
+	}
+	sort.Slice(settlement.Lines, func(i, j int) bool {
+		if settlement.Lines[i].BookID != settlement.Lines[j].BookID {
+			return settlement.Lines[i].BookID.Hex() < settlement.Lines[j].BookID.Hex()
+		}
+		return settlement.Lines[i].Type < settlement.Lines[j].Type
+	})
+
+	settlement.ActualIncome = settlement.TotalRevenue.Sub(settlement.PlatformFee)
+	settlement.TaxFee = settlement.ActualIncome.Mul(taxRate)
+	available := settlement.ActualIncome.Sub(settlement.TaxFee)
+
+	var offset []*financeModel.WithdrawalRequest
+	for _, withdrawal := range withdrawals {
+		if settlement.WithdrawnFee.Add(withdrawal.Amount).GreaterThan(available) {
+			break
+		}
+		settlement.WithdrawnFee = settlement.WithdrawnFee.Add(withdrawal.Amount)
+		offset = append(offset, withdrawal)
+	}
+	settlement.FinalIncome = available.Sub(settlement.WithdrawnFee)
+
+	return settlement, offset
+}
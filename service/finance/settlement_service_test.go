@@ -0,0 +1,288 @@
+package finance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/shared/types"
+	"Qingyu_backend/pkg/distlock"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordingEventBus struct {
+	events []base.Event
+}
+
+func (b *recordingEventBus) Subscribe(eventType string, handler base.EventHandler) error {
+	return nil
+}
+
+func (b *recordingEventBus) Unsubscribe(eventType string, handlerName string) error {
+	return nil
+}
+
+func (b *recordingEventBus) Publish(ctx context.Context, event base.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *recordingEventBus) PublishAsync(ctx context.Context, event base.Event) error {
+	return b.Publish(ctx, event)
+}
+
+type settlementFixture struct {
+	service  *SettlementService
+	revenue  *mockAuthorRevenueRepository
+	wallets  *mockWalletRepository
+	eventBus *recordingEventBus
+	bookA    primitive.ObjectID
+	bookB    primitive.ObjectID
+}
+
+func addTestEarning(repo *mockAuthorRevenueRepository, sourceID, authorID string, bookID primitive.ObjectID, earningType string, amount, platformFee types.Money, earnedAt time.Time) {
+	_, _ = repo.CreateEarningIfAbsent(context.Background(), &financeModel.AuthorEarning{
+		AuthorID:     authorID,
+		BookID:       bookID,
+		BookTitle:    "书-" + bookID.Hex()[20:],
+		Type:         earningType,
+		SourceID:     sourceID,
+		Amount:       amount,
+		PlatformFee:  platformFee,
+		AuthorIncome: amount.Sub(platformFee),
+		EarnedAt:     earnedAt,
+	})
+}
+
+func newSettlementFixture(t *testing.T) *settlementFixture {
+	revenue := newMockAuthorRevenueRepository()
+	wallets := newMockWalletRepository()
+	eventBus := &recordingEventBus{}
+	fixture := &settlementFixture{
+		revenue:  revenue,
+		wallets:  wallets,
+		eventBus: eventBus,
+		bookA:    primitive.NewObjectID(),
+		bookB:    primitive.NewObjectID(),
+	}
+
+	september := time.Date(2026, 9, 15, 12, 0, 0, 0, time.Local)
+	addTestEarning(revenue, "order-1", "author-1", fixture.bookA, financeModel.EarningTypeChapterPurchase, 100, 30, september)
+	addTestEarning(revenue, "order-2", "author-1", fixture.bookA, financeModel.EarningTypeChapterPurchase, 100, 30, september)
+	addTestEarning(revenue, "reward-1", "author-1", fixture.bookA, financeModel.EarningTypeReward, 1000, 100, september)
+	addTestEarning(revenue, "order-3", "author-1", fixture.bookB, financeModel.EarningTypeBookPurchase, 2000, 600, september)
+	addTestEarning(revenue, "order-4", "author-2", fixture.bookB, financeModel.EarningTypeBookPurchase, 1000, 300, september)
+	// 下一周期的收入不参与本次结算
+	addTestEarning(revenue, "order-5", "author-1", fixture.bookA, financeModel.EarningTypeChapterPurchase, 100, 30, september.AddDate(0, 1, 0))
+
+	revenue.taxInfos["author-1"] = &financeModel.TaxInfo{UserID: "author-1", TaxRate: 0.1}
+	// 未经钱包扣款的预支提现在结算时抵扣，钱包发起的提现已扣过余额
+	advance := &financeModel.WithdrawalRequest{
+		ID: primitive.NewObjectID(), UserID: "author-1", Amount: 500,
+		Status: financeModel.WithdrawStatusCompleted, CreatedAt: september,
+	}
+	revenue.withdrawals[advance.ID.Hex()] = advance
+	walletBacked := &financeModel.WithdrawalRequest{
+		ID: primitive.NewObjectID(), UserID: "author-1", Amount: 300, TransactionID: "wallet-withdraw",
+		Status: financeModel.WithdrawStatusCompleted, CreatedAt: september,
+	}
+	revenue.withdrawals[walletBacked.ID.Hex()] = walletBacked
+
+	fixture.service = NewSettlementService(revenue, wallets, nil, eventBus)
+	fixture.service.SetStatementDir(t.TempDir())
+	fixture.service.now = func() time.Time { return time.Date(2026, 10, 1, 3, 0, 0, 0, time.Local) }
+	return fixture
+}
+
+func TestSettlementServiceSettlesPeriodExactlyOnce(t *testing.T) {
+	fixture := newSettlementFixture(t)
+	ctx := context.Background()
+
+	result, err := fixture.service.SettlePeriod(ctx, "2026-09")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.AuthorCount)
+	assert.Equal(t, 2, result.Settled)
+	assert.Equal(t, 0, result.Failed)
+
+	settlement, err := fixture.revenue.GetSettlementByPeriod(ctx, "author-1", "2026-09")
+	require.NoError(t, err)
+	require.NotNil(t, settlement)
+	assert.True(t, settlement.Locked)
+	assert.Equal(t, financeModel.SettlementStatusCompleted, settlement.Status)
+	assert.Equal(t, 4, settlement.EarningCount)
+	assert.Len(t, settlement.Lines, 3)
+	assert.Equal(t, types.Money(3200), settlement.TotalRevenue)
+	assert.Equal(t, types.Money(760), settlement.PlatformFee)
+	assert.Equal(t, types.Money(2440), settlement.ActualIncome)
+	assert.Equal(t, types.Money(244), settlement.TaxFee)
+	assert.Equal(t, types.Money(500), settlement.WithdrawnFee)
+	assert.Equal(t, types.Money(1696), settlement.FinalIncome)
+	assert.NotEmpty(t, settlement.TransactionID)
+
+	// 钱包不存在时自动创建并入账
+	wallet, err := fixture.wallets.GetWallet(ctx, "author-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(1696), wallet.Balance)
+
+	// 结算收入与预支提现都关联到结算单
+	assert.Equal(t, settlement.ID, fixture.revenue.earnings["order-3|"+financeModel.EarningTypeBookPurchase].SettlementID)
+	assert.False(t, fixture.revenue.earnings["order-5|"+financeModel.EarningTypeChapterPurchase].IsSettled)
+	for _, withdrawal := range fixture.revenue.withdrawals {
+		if withdrawal.TransactionID == "" {
+			assert.Equal(t, settlement.ID, withdrawal.SettlementID)
+		} else {
+			assert.True(t, withdrawal.SettlementID.IsZero())
+		}
+	}
+
+	// 对账单按结算周期导出
+	statement, err := excelize.OpenFile(settlement.StatementFile)
+	require.NoError(t, err)
+	defer statement.Close()
+	period, err := statement.GetCellValue(settlementStatementSheet, "B3")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09", period)
+	rows, err := statement.GetRows(settlementStatementSheet)
+	require.NoError(t, err)
+	assert.Len(t, rows, 12+len(settlement.Lines))
+
+	require.Len(t, fixture.eventBus.events, 2)
+	assert.Equal(t, events.EventSettlementGenerated, fixture.eventBus.events[0].GetEventType())
+
+	// 重复执行不会再次入账或发布事件
+	again, err := fixture.service.SettlePeriod(ctx, "2026-09")
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.AuthorCount)
+	repeated, err := fixture.service.SettleAuthor(ctx, "author-1", "2026-09")
+	require.NoError(t, err)
+	assert.Equal(t, settlement.ID, repeated.ID)
+	wallet, _ = fixture.wallets.GetWallet(ctx, "author-1")
+	assert.Equal(t, types.Money(1696), wallet.Balance)
+	assert.Len(t, fixture.wallets.transactions, 2)
+	assert.Len(t, fixture.eventBus.events, 2)
+}
+
+func TestSettlementServiceResumesLockedSettlement(t *testing.T) {
+	fixture := newSettlementFixture(t)
+	ctx := context.Background()
+
+	// 对账单目录不可写时结算单已锁定入账，但停留在处理中
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, []byte("x"), 0o644))
+	fixture.service.SetStatementDir(blocker)
+
+	_, err := fixture.service.SettleAuthor(ctx, "author-1", "2026-09")
+	require.Error(t, err)
+	pending, err := fixture.revenue.GetSettlementByPeriod(ctx, "author-1", "2026-09")
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, financeModel.SettlementStatusProcessing, pending.Status)
+	assert.Empty(t, fixture.eventBus.events)
+
+	// 重新执行只补齐导出与事件，不重复入账
+	fixture.service.SetStatementDir(t.TempDir())
+	settlement, err := fixture.service.SettleAuthor(ctx, "author-1", "2026-09")
+	require.NoError(t, err)
+	assert.Equal(t, pending.ID, settlement.ID)
+	assert.Equal(t, financeModel.SettlementStatusCompleted, settlement.Status)
+	assert.FileExists(t, settlement.StatementFile)
+
+	wallet, err := fixture.wallets.GetWallet(ctx, "author-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(1696), wallet.Balance)
+	assert.Len(t, fixture.wallets.transactions, 1)
+	assert.Len(t, fixture.eventBus.events, 1)
+}
+
+func TestSettlementServiceSkipsAuthorLockedByOtherInstance(t *testing.T) {
+	fixture := newSettlementFixture(t)
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	lock := distlock.NewRedisLockService(client, "distlock")
+	fixture.service.SetLock(lock)
+
+	_, err := lock.Acquire(ctx, "finance:settlement:author-1:2026-09", time.Minute)
+	require.NoError(t, err)
+
+	_, err = fixture.service.SettleAuthor(ctx, "author-1", "2026-09")
+	assert.ErrorIs(t, err, ErrSettlementInProgress)
+
+	result, err := fixture.service.SettlePeriod(ctx, "2026-09")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Settled)
+	assert.Equal(t, 1, result.Skipped)
+
+	settlement, err := fixture.revenue.GetSettlementByPeriod(ctx, "author-1", "2026-09")
+	require.NoError(t, err)
+	assert.Nil(t, settlement)
+}
+
+func TestSettlementServiceSettlesOverduePeriodsAndLateEarnings(t *testing.T) {
+	fixture := newSettlementFixture(t)
+	ctx := context.Background()
+
+	// 停机两个月：七月的收入与九月一起补跑，按周期先后各自生成结算单
+	july := time.Date(2026, 7, 31, 23, 0, 0, 0, time.Local)
+	addTestEarning(fixture.revenue, "order-7", "author-2", fixture.bookB, financeModel.EarningTypeBookPurchase, 1000, 300, july)
+	fixture.service.now = func() time.Time { return time.Date(2026, 10, 2, 8, 0, 0, 0, time.Local) }
+
+	results, err := fixture.service.SettleOverduePeriods(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{"2026-07", "2026-08", "2026-09"}, []string{results[0].Period, results[1].Period, results[2].Period})
+	assert.Equal(t, 1, results[0].Settled)
+	assert.Equal(t, 0, results[1].AuthorCount)
+	assert.Equal(t, 2, results[2].Settled)
+
+	julySettlement, err := fixture.revenue.GetSettlementByPeriod(ctx, "author-2", "2026-07")
+	require.NoError(t, err)
+	require.NotNil(t, julySettlement)
+	assert.Equal(t, 1, julySettlement.EarningCount)
+	assert.False(t, fixture.revenue.earnings["order-5|"+financeModel.EarningTypeChapterPurchase].IsSettled)
+
+	// 九月结算后事件回放补记的九月收入计入下一个结算周期
+	addTestEarning(fixture.revenue, "reward-replayed", "author-2", fixture.bookB, financeModel.EarningTypeReward, 200, 20, time.Date(2026, 9, 20, 0, 0, 0, 0, time.Local))
+	again, err := fixture.service.SettleOverduePeriods(ctx)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, 1, again[0].Skipped)
+	assert.False(t, fixture.revenue.earnings["reward-replayed|"+financeModel.EarningTypeReward].IsSettled)
+
+	fixture.service.now = func() time.Time { return time.Date(2026, 11, 1, 3, 0, 0, 0, time.Local) }
+	_, err = fixture.service.SettleOverduePeriods(ctx)
+	require.NoError(t, err)
+	october, err := fixture.revenue.GetSettlementByPeriod(ctx, "author-2", "2026-10")
+	require.NoError(t, err)
+	require.NotNil(t, october)
+	assert.Equal(t, 1, october.EarningCount)
+	assert.Equal(t, types.Money(200), october.TotalRevenue)
+	assert.Equal(t, october.ID, fixture.revenue.earnings["reward-replayed|"+financeModel.EarningTypeReward].SettlementID)
+
+	overdue, err := fixture.service.SettleOverduePeriods(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, overdue)
+}
+
+func TestSettlementServiceRejectsOpenPeriod(t *testing.T) {
+	fixture := newSettlementFixture(t)
+
+	_, err := fixture.service.SettlePeriod(context.Background(), "2026-10")
+	assert.Error(t, err)
+	_, err = fixture.service.SettlePeriod(context.Background(), "2026/09")
+	assert.Error(t, err)
+	assert.Equal(t, "2026-09", PreviousSettlementPeriod(time.Date(2026, 10, 1, 3, 0, 0, 0, time.Local)))
+}
@@ -0,0 +1,304 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bookstoreModel "Qingyu_backend/models/bookstore"
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/notification"
+	"Qingyu_backend/models/shared/types"
+	pkgtransaction "Qingyu_backend/pkg/transaction"
+	"Qingyu_backend/repository/interfaces/finance"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+	walletService "Qingyu_backend/service/finance/wallet"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultFanLeaderboardLimit = 20
+	maxFanLeaderboardLimit     = 100
+)
+
+// ErrInvalidTip 打赏请求不合法（礼物、数量、书籍或章节校验失败）
+var ErrInvalidTip = errors.New("无效的打赏请求")
+
+// TipChapterLookup 章节查询（由 bookstore.ChapterRepository 满足），用于校验附带章节
+type TipChapterLookup interface {
+	GetByID(ctx context.Context, id string) (*bookstoreModel.Chapter, error)
+}
+
+// TipNotifier 打赏通知发送接口（由通知服务实现）
+type TipNotifier interface {
+	SendNotificationWithTemplate(ctx context.Context, userID string, notificationType notification.NotificationType, action string, variables map[string]interface{}) error
+}
+
+// TipRequest 打赏请求
+type TipRequest struct {
+	BookID     string // 书籍ID
+	ChapterID  string // 附带的章节ID（可选）
+	GiftCode   string // 礼物档位
+	Quantity   int    // 礼物数量（为0时按1计）
+	Message    string // 打赏留言（可选）
+	SenderName string // 打赏者昵称，用于作者通知
+}
+
+// TipService 读者打赏服务
+// 钱包扣款、打赏记录、作者收入入账、粉丝榜与书籍粉丝值在同一事务内完成，任一步失败整体回滚；
+// 提交后发布打赏事件（版税入账按打赏ID幂等，不会重复计入）并通知作者。
+type TipService struct {
+	tipRepo  finance.TipRepository
+	wallet   walletService.WalletService
+	royalty  *RoyaltyService
+	books    RoyaltyBookLookup
+	chapters TipChapterLookup
+	txRunner pkgtransaction.Runner
+	eventBus base.EventBus
+	notifier TipNotifier
+	now      func() time.Time
+}
+
+// NewTipService 创建打赏服务
+func NewTipService(tipRepo finance.TipRepository, wallet walletService.WalletService, royalty *RoyaltyService, books RoyaltyBookLookup, txRunner pkgtransaction.Runner, eventBus base.EventBus) *TipService {
+	return &TipService{
+		tipRepo:  tipRepo,
+		wallet:   wallet,
+		royalty:  royalty,
+		books:    books,
+		txRunner: txRunner,
+		eventBus: eventBus,
+		now:      time.Now,
+	}
+}
+
+// SetChapterLookup 注入章节查询（未设置时只校验章节ID格式）
+func (s *TipService) SetChapterLookup(chapters TipChapterLookup) {
+	s.chapters = chapters
+}
+
+// SetNotifier 注入作者通知
+func (s *TipService) SetNotifier(notifier TipNotifier) {
+	s.notifier = notifier
+}
+
+// GetGifts 获取预设礼物档位
+func (s *TipService) GetGifts() []financeModel.TipGift {
+	return financeModel.TipGifts
+}
+
+// Tip 读者打赏书籍
+func (s *TipService) Tip(ctx context.Context, userID string, req *TipRequest) (*financeModel.Tip, error) {
+	gift, ok := financeModel.FindTipGift(req.GiftCode)
+	if !ok {
+		return nil, fmt.Errorf("%w: 未知的礼物 %q", ErrInvalidTip, req.GiftCode)
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 0 || quantity > financeModel.MaxTipQuantity {
+		return nil, fmt.Errorf("%w: 礼物数量必须在1到%d之间", ErrInvalidTip, financeModel.MaxTipQuantity)
+	}
+	message := strings.TrimSpace(req.Message)
+	if utf8.RuneCountInString(message) > financeModel.MaxTipMessageLength {
+		return nil, fmt.Errorf("%w: 留言不能超过%d字", ErrInvalidTip, financeModel.MaxTipMessageLength)
+	}
+
+	bookID, err := primitive.ObjectIDFromHex(req.BookID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无效的书籍ID", ErrInvalidTip)
+	}
+	book, err := s.books.GetByID(ctx, req.BookID)
+	if err != nil {
+		return nil, fmt.Errorf("查询书籍失败: %w", err)
+	}
+	if book == nil {
+		return nil, fmt.Errorf("%w: 书籍不存在", ErrInvalidTip)
+	}
+	if book.AuthorID == "" {
+		return nil, fmt.Errorf("书籍 %s 缺少作者信息，无法打赏", req.BookID)
+	}
+	if book.AuthorID == userID {
+		return nil, fmt.Errorf("%w: 不能打赏自己的作品", ErrInvalidTip)
+	}
+
+	amount := gift.Price * types.Money(quantity)
+	tip := &financeModel.Tip{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		AuthorID:  book.AuthorID,
+		BookID:    bookID,
+		BookTitle: book.Title,
+		GiftCode:  gift.Code,
+		GiftName:  gift.Name,
+		Quantity:  quantity,
+		Amount:    amount,
+		FanValue:  financeModel.TipFanValue(amount),
+		Message:   message,
+		CreatedAt: s.now(),
+	}
+	if req.ChapterID != "" {
+		if tip.ChapterID, err = s.resolveChapter(ctx, req.BookID, req.ChapterID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.runInTransaction(ctx, func(txCtx context.Context) error {
+		return s.applyTip(txCtx, tip)
+	}); err != nil {
+		return nil, err
+	}
+
+	s.afterTip(ctx, tip, req.SenderName)
+	return tip, nil
+}
+
+// resolveChapter 校验附带章节属于被打赏的书籍
+func (s *TipService) resolveChapter(ctx context.Context, bookID, chapterID string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(chapterID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: 无效的章节ID", ErrInvalidTip)
+	}
+	if s.chapters == nil {
+		return oid, nil
+	}
+
+	chapter, err := s.chapters.GetByID(ctx, chapterID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("查询章节失败: %w", err)
+	}
+	if chapter == nil || chapter.BookID != bookID {
+		return primitive.NilObjectID, fmt.Errorf("%w: 章节不属于该书籍", ErrInvalidTip)
+	}
+	return oid, nil
+}
+
+// applyTip 扣款并写入打赏、作者收入与粉丝值，须在事务内执行
+func (s *TipService) applyTip(ctx context.Context, tip *financeModel.Tip) error {
+	reason := fmt.Sprintf("打赏《%s》%s x%d", tip.BookTitle, tip.GiftName, tip.Quantity)
+	transaction, err := s.wallet.Consume(ctx, tip.UserID, tip.Amount.ToCents(), reason)
+	if err != nil {
+		return fmt.Errorf("钱包扣款失败: %w", err)
+	}
+	tip.TransactionID = transaction.ID
+
+	source := &RoyaltySource{
+		SourceID:    tip.ID.Hex(),
+		EarningType: financeModel.EarningTypeReward,
+		AuthorID:    tip.AuthorID,
+		BookID:      tip.BookID.Hex(),
+		ReaderID:    tip.UserID,
+		Amount:      tip.Amount,
+		OccurredAt:  tip.CreatedAt,
+	}
+	if !tip.ChapterID.IsZero() {
+		source.ChapterID = tip.ChapterID.Hex()
+	}
+	earning, _, err := s.royalty.RecordEarning(ctx, source)
+	if err != nil {
+		return fmt.Errorf("作者收入入账失败: %w", err)
+	}
+	if earning != nil {
+		tip.EarningID = earning.ID
+	}
+
+	if err := s.tipRepo.CreateTip(ctx, tip); err != nil {
+		return err
+	}
+	for _, period := range []string{financeModel.FanPeriodAll, financeModel.FanWeekPeriod(tip.CreatedAt)} {
+		if err := s.tipRepo.IncrementBookFan(ctx, tip, period); err != nil {
+			return err
+		}
+	}
+	return s.tipRepo.IncrementBookFanValue(ctx, tip.BookID, tip.FanValue)
+}
+
+// afterTip 事务提交后发布打赏事件并通知作者，失败不影响打赏结果
+func (s *TipService) afterTip(ctx context.Context, tip *financeModel.Tip, senderName string) {
+	chapterID := ""
+	if !tip.ChapterID.IsZero() {
+		chapterID = tip.ChapterID.Hex()
+	}
+	if s.eventBus != nil {
+		event := events.NewRewardCreatedEvent(tip.ID.Hex(), tip.UserID, tip.AuthorID, tip.BookID.Hex(), chapterID, tip.Message, tip.Amount.ToYuan())
+		if err := s.eventBus.PublishAsync(ctx, event); err != nil {
+			log.Printf("[Tip] 发布打赏事件失败: tip=%s err=%v", tip.ID.Hex(), err)
+		}
+	}
+
+	if s.notifier != nil {
+		if senderName == "" {
+			senderName = "读者"
+		}
+		err := s.notifier.SendNotificationWithTemplate(ctx, tip.AuthorID, notification.NotificationTypeReward, "received", map[string]interface{}{
+			"senderName": senderName,
+			"bookTitle":  tip.BookTitle,
+			"bookId":     tip.BookID.Hex(),
+			"amount":     tip.Amount.ToCents(),
+		})
+		if err != nil {
+			log.Printf("[Tip] 发送打赏通知失败: tip=%s author=%s err=%v", tip.ID.Hex(), tip.AuthorID, err)
+		}
+	}
+}
+
+// GetFanLeaderboard 获取书籍粉丝榜
+// period 为 all（总榜）或 weekly（本周榜）
+func (s *TipService) GetFanLeaderboard(ctx context.Context, bookID, period string, limit int) ([]*financeModel.BookFan, error) {
+	oid, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无效的书籍ID", ErrInvalidTip)
+	}
+
+	switch period {
+	case "", financeModel.FanPeriodAll:
+		period = financeModel.FanPeriodAll
+	case financeModel.FanPeriodWeekly:
+		period = financeModel.FanWeekPeriod(s.now())
+	default:
+		return nil, fmt.Errorf("%w: 未知的粉丝榜周期 %q", ErrInvalidTip, period)
+	}
+	if limit <= 0 {
+		limit = defaultFanLeaderboardLimit
+	}
+	if limit > maxFanLeaderboardLimit {
+		limit = maxFanLeaderboardLimit
+	}
+
+	fans, err := s.tipRepo.ListTopFans(ctx, oid, period, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i, fan := range fans {
+		fan.Rank = i + 1
+	}
+	return fans, nil
+}
+
+// ListBookTips 获取书籍的打赏记录
+func (s *TipService) ListBookTips(ctx context.Context, bookID string, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	oid, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: 无效的书籍ID", ErrInvalidTip)
+	}
+	return s.tipRepo.ListBookTips(ctx, oid, page, pageSize)
+}
+
+// ListUserTips 获取读者的打赏记录
+func (s *TipService) ListUserTips(ctx context.Context, userID string, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	return s.tipRepo.ListUserTips(ctx, userID, page, pageSize)
+}
+
+func (s *TipService) runInTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if s.txRunner == nil {
+		return fn(ctx)
+	}
+	return s.txRunner.Run(ctx, fn)
+}
@@ -0,0 +1,355 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	bookstoreModel "Qingyu_backend/models/bookstore"
+	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/notification"
+	"Qingyu_backend/models/shared/types"
+	"Qingyu_backend/service/events"
+	walletService "Qingyu_backend/service/finance/wallet"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mockTipRepository struct {
+	tips               []*financeModel.Tip
+	fans               map[string]*financeModel.BookFan
+	bookFanValues      map[primitive.ObjectID]int64
+	failIncrementValue error
+}
+
+func newMockTipRepository() *mockTipRepository {
+	return &mockTipRepository{
+		fans:          make(map[string]*financeModel.BookFan),
+		bookFanValues: make(map[primitive.ObjectID]int64),
+	}
+}
+
+func (m *mockTipRepository) CreateTip(ctx context.Context, tip *financeModel.Tip) error {
+	m.tips = append(m.tips, tip)
+	return nil
+}
+
+func (m *mockTipRepository) ListBookTips(ctx context.Context, bookID primitive.ObjectID, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	var result []*financeModel.Tip
+	for _, tip := range m.tips {
+		if tip.BookID == bookID {
+			result = append(result, tip)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *mockTipRepository) ListUserTips(ctx context.Context, userID string, page, pageSize int) ([]*financeModel.Tip, int64, error) {
+	var result []*financeModel.Tip
+	for _, tip := range m.tips {
+		if tip.UserID == userID {
+			result = append(result, tip)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *mockTipRepository) IncrementBookFan(ctx context.Context, tip *financeModel.Tip, period string) error {
+	key := tip.BookID.Hex() + "|" + period + "|" + tip.UserID
+	fan, ok := m.fans[key]
+	if !ok {
+		fan = &financeModel.BookFan{BookID: tip.BookID, UserID: tip.UserID, Period: period}
+		m.fans[key] = fan
+	}
+	fan.FanValue += tip.FanValue
+	fan.TipCount++
+	fan.TotalAmount = fan.TotalAmount.Add(tip.Amount)
+	if tip.CreatedAt.After(fan.LastTippedAt) {
+		fan.LastTippedAt = tip.CreatedAt
+	}
+	return nil
+}
+
+func (m *mockTipRepository) ListTopFans(ctx context.Context, bookID primitive.ObjectID, period string, limit int) ([]*financeModel.BookFan, error) {
+	var result []*financeModel.BookFan
+	for _, fan := range m.fans {
+		if fan.BookID == bookID && fan.Period == period {
+			copied := *fan
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FanValue == result[j].FanValue {
+			return result[i].LastTippedAt.Before(result[j].LastTippedAt)
+		}
+		return result[i].FanValue > result[j].FanValue
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *mockTipRepository) GetBookFan(ctx context.Context, bookID primitive.ObjectID, period, userID string) (*financeModel.BookFan, error) {
+	return m.fans[bookID.Hex()+"|"+period+"|"+userID], nil
+}
+
+func (m *mockTipRepository) IncrementBookFanValue(ctx context.Context, bookID primitive.ObjectID, fanValue int64) error {
+	if m.failIncrementValue != nil {
+		return m.failIncrementValue
+	}
+	m.bookFanValues[bookID] += fanValue
+	return nil
+}
+
+// snapshotTxRunner 模拟事务：fn 失败时恢复钱包、收入与打赏数据
+type snapshotTxRunner struct {
+	wallets  *mockWalletRepository
+	revenue  *mockAuthorRevenueRepository
+	tips     *mockTipRepository
+	rollback int
+}
+
+func (r *snapshotTxRunner) Run(ctx context.Context, fn func(context.Context) error) error {
+	balances := make(map[string]types.Money)
+	for userID, wallet := range r.wallets.wallets {
+		balances[userID] = wallet.Balance
+	}
+	transactions := len(r.wallets.transactions)
+	earnings := make(map[string]*financeModel.AuthorEarning)
+	for key, earning := range r.revenue.earnings {
+		earnings[key] = earning
+	}
+	tips := len(r.tips.tips)
+	fans := make(map[string]financeModel.BookFan)
+	for key, fan := range r.tips.fans {
+		fans[key] = *fan
+	}
+
+	if err := fn(ctx); err != nil {
+		r.rollback++
+		for userID, balance := range balances {
+			r.wallets.wallets[userID].Balance = balance
+		}
+		r.wallets.transactions = r.wallets.transactions[:transactions]
+		r.revenue.earnings = earnings
+		r.tips.tips = r.tips.tips[:tips]
+		r.tips.fans = make(map[string]*financeModel.BookFan)
+		for key, fan := range fans {
+			copied := fan
+			r.tips.fans[key] = &copied
+		}
+		return err
+	}
+	return nil
+}
+
+type stubChapterLookup map[string]*bookstoreModel.Chapter
+
+func (s stubChapterLookup) GetByID(ctx context.Context, id string) (*bookstoreModel.Chapter, error) {
+	return s[id], nil
+}
+
+type recordingTipNotifier struct {
+	userIDs   []string
+	variables []map[string]interface{}
+}
+
+func (n *recordingTipNotifier) SendNotificationWithTemplate(ctx context.Context, userID string, notificationType notification.NotificationType, action string, variables map[string]interface{}) error {
+	n.userIDs = append(n.userIDs, userID)
+	n.variables = append(n.variables, variables)
+	return nil
+}
+
+type tipFixture struct {
+	service  *TipService
+	tips     *mockTipRepository
+	revenue  *mockAuthorRevenueRepository
+	wallets  *mockWalletRepository
+	runner   *snapshotTxRunner
+	eventBus *recordingEventBus
+	notifier *recordingTipNotifier
+	bookID   string
+	now      time.Time
+}
+
+func newTipFixture(t *testing.T) *tipFixture {
+	tips := newMockTipRepository()
+	revenue := newMockAuthorRevenueRepository()
+	wallets := newMockWalletRepository()
+	wallets.wallets["reader-1"] = &financeModel.Wallet{UserID: "reader-1", Balance: 5000}
+	wallets.wallets["reader-2"] = &financeModel.Wallet{UserID: "reader-2", Balance: 5000}
+
+	bookID := primitive.NewObjectID()
+	book := &bookstoreModel.Book{Title: "测试书", AuthorID: "author-1"}
+	book.ID = bookID
+	books := stubBookLookup{bookID.Hex(): book}
+
+	fixture := &tipFixture{
+		tips:     tips,
+		revenue:  revenue,
+		wallets:  wallets,
+		runner:   &snapshotTxRunner{wallets: wallets, revenue: revenue, tips: tips},
+		eventBus: &recordingEventBus{},
+		notifier: &recordingTipNotifier{},
+		bookID:   bookID.Hex(),
+		now:      time.Date(2026, 10, 14, 20, 0, 0, 0, time.Local),
+	}
+	fixture.service = NewTipService(tips, walletService.NewUnifiedWalletServiceWithRunner(wallets, nil),
+		NewRoyaltyService(revenue, books), books, fixture.runner, fixture.eventBus)
+	fixture.service.SetNotifier(fixture.notifier)
+	fixture.service.now = func() time.Time { return fixture.now }
+	return fixture
+}
+
+func TestTipServiceTipDebitsWalletAndCreditsAuthor(t *testing.T) {
+	fixture := newTipFixture(t)
+	ctx := context.Background()
+	chapterID := primitive.NewObjectID().Hex()
+	fixture.service.SetChapterLookup(stubChapterLookup{chapterID: {BookID: fixture.bookID}})
+
+	tip, err := fixture.service.Tip(ctx, "reader-1", &TipRequest{
+		BookID:     fixture.bookID,
+		ChapterID:  chapterID,
+		GiftCode:   financeModel.TipGiftCoffee,
+		Quantity:   3,
+		Message:    "  太好看了  ",
+		SenderName: "小明",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(1500), tip.Amount)
+	assert.Equal(t, int64(15), tip.FanValue)
+	assert.Equal(t, "太好看了", tip.Message)
+	assert.Equal(t, chapterID, tip.ChapterID.Hex())
+	assert.NotEmpty(t, tip.TransactionID)
+
+	// 钱包扣款
+	wallet, err := fixture.wallets.GetWallet(ctx, "reader-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(3500), wallet.Balance)
+
+	// 打赏按版税规则计入作者收入，来源ID为打赏ID
+	earning := fixture.revenue.earnings[tip.ID.Hex()+"|"+financeModel.EarningTypeReward]
+	require.NotNil(t, earning)
+	assert.Equal(t, "author-1", earning.AuthorID)
+	assert.Equal(t, "测试书", earning.BookTitle)
+	assert.Equal(t, tip.EarningID, earning.ID)
+	assert.Equal(t, types.Money(1500), earning.AuthorIncome.Add(earning.PlatformFee))
+
+	// 粉丝榜与书籍粉丝值
+	assert.Equal(t, int64(15), fixture.tips.bookFanValues[tip.BookID])
+	allTime, err := fixture.tips.GetBookFan(ctx, tip.BookID, financeModel.FanPeriodAll, "reader-1")
+	require.NoError(t, err)
+	require.NotNil(t, allTime)
+	weekly, err := fixture.tips.GetBookFan(ctx, tip.BookID, "2026-W42", "reader-1")
+	require.NoError(t, err)
+	require.NotNil(t, weekly)
+	assert.Equal(t, int64(15), weekly.FanValue)
+
+	// 提交后发布打赏事件（版税入账按打赏ID幂等）并通知作者
+	require.Len(t, fixture.eventBus.events, 1)
+	event := fixture.eventBus.events[0]
+	assert.Equal(t, events.EventRewardCreated, event.GetEventType())
+	require.NoError(t, fixture.service.royalty.ProcessEvent(ctx, event))
+	assert.Len(t, fixture.revenue.earnings, 1)
+
+	require.Equal(t, []string{"author-1"}, fixture.notifier.userIDs)
+	assert.Equal(t, "小明", fixture.notifier.variables[0]["senderName"])
+	assert.Equal(t, int64(1500), fixture.notifier.variables[0]["amount"])
+}
+
+func TestTipServiceRollsBackWhenAnyStepFails(t *testing.T) {
+	fixture := newTipFixture(t)
+	ctx := context.Background()
+	fixture.tips.failIncrementValue = errors.New("books unavailable")
+
+	_, err := fixture.service.Tip(ctx, "reader-1", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftCake})
+	require.Error(t, err)
+	assert.Equal(t, 1, fixture.runner.rollback)
+
+	wallet, err := fixture.wallets.GetWallet(ctx, "reader-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.Money(5000), wallet.Balance)
+	assert.Empty(t, fixture.wallets.transactions)
+	assert.Empty(t, fixture.revenue.earnings)
+	assert.Empty(t, fixture.tips.tips)
+	assert.Empty(t, fixture.tips.fans)
+	assert.Empty(t, fixture.eventBus.events)
+	assert.Empty(t, fixture.notifier.userIDs)
+
+	// 余额不足时不产生任何记录
+	fixture.tips.failIncrementValue = nil
+	_, err = fixture.service.Tip(ctx, "reader-1", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftTrophy})
+	require.Error(t, err)
+	assert.Empty(t, fixture.tips.tips)
+	assert.Empty(t, fixture.revenue.earnings)
+}
+
+func TestTipServiceRejectsInvalidTip(t *testing.T) {
+	fixture := newTipFixture(t)
+	ctx := context.Background()
+	otherChapter := primitive.NewObjectID().Hex()
+	fixture.service.SetChapterLookup(stubChapterLookup{otherChapter: {BookID: primitive.NewObjectID().Hex()}})
+
+	cases := []struct {
+		name   string
+		userID string
+		req    *TipRequest
+	}{
+		{"未知礼物", "reader-1", &TipRequest{BookID: fixture.bookID, GiftCode: "rocket"}},
+		{"数量超限", "reader-1", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftFlower, Quantity: financeModel.MaxTipQuantity + 1}},
+		{"无效书籍", "reader-1", &TipRequest{BookID: "bad", GiftCode: financeModel.TipGiftFlower}},
+		{"打赏自己", "author-1", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftFlower}},
+		{"章节不属于书籍", "reader-1", &TipRequest{BookID: fixture.bookID, ChapterID: otherChapter, GiftCode: financeModel.TipGiftFlower}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := fixture.service.Tip(ctx, tc.userID, tc.req)
+			assert.ErrorIs(t, err, ErrInvalidTip)
+		})
+	}
+
+	assert.Empty(t, fixture.wallets.transactions)
+	assert.Empty(t, fixture.tips.tips)
+}
+
+func TestTipServiceFanLeaderboard(t *testing.T) {
+	fixture := newTipFixture(t)
+	ctx := context.Background()
+
+	_, err := fixture.service.Tip(ctx, "reader-1", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftCoffee})
+	require.NoError(t, err)
+	_, err = fixture.service.Tip(ctx, "reader-2", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftFlower, Quantity: 3})
+	require.NoError(t, err)
+
+	// 下一周只计入总榜与新一周的周榜
+	fixture.now = fixture.now.AddDate(0, 0, 7)
+	_, err = fixture.service.Tip(ctx, "reader-2", &TipRequest{BookID: fixture.bookID, GiftCode: financeModel.TipGiftFlower, Quantity: 3})
+	require.NoError(t, err)
+
+	allTime, err := fixture.service.GetFanLeaderboard(ctx, fixture.bookID, financeModel.FanPeriodAll, 0)
+	require.NoError(t, err)
+	require.Len(t, allTime, 2)
+	assert.Equal(t, "reader-2", allTime[0].UserID)
+	assert.Equal(t, 1, allTime[0].Rank)
+	assert.Equal(t, int64(6), allTime[0].FanValue)
+	assert.Equal(t, int64(2), allTime[0].TipCount)
+	assert.Equal(t, "reader-1", allTime[1].UserID)
+	assert.Equal(t, 2, allTime[1].Rank)
+
+	weekly, err := fixture.service.GetFanLeaderboard(ctx, fixture.bookID, financeModel.FanPeriodWeekly, 10)
+	require.NoError(t, err)
+	require.Len(t, weekly, 1)
+	assert.Equal(t, "reader-2", weekly[0].UserID)
+	assert.Equal(t, int64(3), weekly[0].FanValue)
+
+	bookID, _ := primitive.ObjectIDFromHex(fixture.bookID)
+	assert.Equal(t, int64(11), fixture.tips.bookFanValues[bookID])
+
+	_, err = fixture.service.GetFanLeaderboard(ctx, fixture.bookID, "monthly", 10)
+	assert.ErrorIs(t, err, ErrInvalidTip)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	socialModel "Qingyu_backend/models/social"
+)
+
+// AchievementService 成就服务接口
+type AchievementService interface {
+	ListUserAchievements(ctx context.Context, userID string) (*socialModel.AchievementOverview, error)
+	ListEarnedAchievements(ctx context.Context, userID string) ([]*socialModel.AchievementView, error)
+	BackfillAchievements(ctx context.Context, since *time.Time) (*socialModel.AchievementBackfillResult, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"Qingyu_backend/models/writer"
+	"context"
+)
+
+// ContinuityService 故事连贯性检查服务接口
+type ContinuityService interface {
+	// CheckProject 交叉比对时间线、角色关系、地点与章节，生成项目级连贯性报告
+	CheckProject(ctx context.Context, projectID string) (*writer.ContinuityReport, error)
+}
@@ -23,15 +23,51 @@ type ExportService interface {
 	// 项目导入
 	ImportProject(ctx context.Context, userID string, zipData []byte) (*ImportResult, error)
 
+	// 稿件导入（TXT/DOCX/EPUB，自动拆分卷章）
+	PreviewManuscriptImport(ctx context.Context, userID string, data []byte, req *ManuscriptImportRequest) (*ManuscriptPreview, error)
+	ImportManuscript(ctx context.Context, userID string, data []byte, req *ManuscriptImportRequest) (*ExportTask, error)
+
 	// 任务管理
 	CancelExportTask(ctx context.Context, taskID, userID string) error
 }
 
 // ImportResult 导入结果
 type ImportResult struct {
-	ProjectID     string `json:"projectId"`
-	Title         string `json:"title"`
-	DocumentCount int    `json:"documentCount"`
+	ProjectID         string `json:"projectId"`
+	Title             string `json:"title"`
+	DocumentCount     int    `json:"documentCount"`
+	ItemCount         int    `json:"itemCount"`
+	OrganizationCount int    `json:"organizationCount"`
+}
+
+// ManuscriptImportRequest 稿件导入请求
+type ManuscriptImportRequest struct {
+	FileName         string   `json:"fileName" validate:"required"` // 原始文件名，用于识别格式（.txt/.docx/.epub）
+	ProjectID        string   `json:"projectId,omitempty"`          // 导入到已有项目；为空时新建项目
+	ProjectTitle     string   `json:"projectTitle,omitempty"`       // 新建项目标题，默认取书名或文件名
+	VolumePatterns   []string `json:"volumePatterns,omitempty"`     // 卷标题正则，为空时使用默认规则（第X卷等）
+	ChapterPatterns  []string `json:"chapterPatterns,omitempty"`    // 章标题正则，为空时使用默认规则（第X章、番外等）
+	MaxHeadingLength int      `json:"maxHeadingLength,omitempty"`   // 标题行最大字数，默认 40
+}
+
+// ManuscriptPreview 稿件导入预览（不写入任何数据）
+type ManuscriptPreview struct {
+	Title        string                   `json:"title"`
+	Format       string                   `json:"format"`
+	VolumeCount  int                      `json:"volumeCount"`
+	ChapterCount int                      `json:"chapterCount"`
+	TotalWords   int                      `json:"totalWords"`
+	Outline      []*ManuscriptOutlineNode `json:"outline"`
+	Warnings     []string                 `json:"warnings,omitempty"`
+}
+
+// ManuscriptOutlineNode 预览中的卷/章节点
+type ManuscriptOutlineNode struct {
+	Type      string                   `json:"type"` // volume, chapter
+	Title     string                   `json:"title"`
+	WordCount int                      `json:"wordCount"`
+	Excerpt   string                   `json:"excerpt,omitempty"` // 正文开头摘录
+	Children  []*ManuscriptOutlineNode `json:"children,omitempty"`
 }
 
 // ExportDocumentRequest 导出文档请求
@@ -43,12 +79,16 @@ type ExportDocumentRequest struct {
 
 // ExportProjectRequest 导出项目请求
 type ExportProjectRequest struct {
-	IncludeDocuments  bool           `json:"includeDocuments"`                             // 是否包含文档
-	IncludeCharacters bool           `json:"includeCharacters"`                            // 是否包含角色
-	IncludeLocations  bool           `json:"includeLocations"`                             // 是否包含地点
-	IncludeTimeline   bool           `json:"includeTimeline"`                              // 是否包含时间线
-	DocumentFormats   string         `json:"documentFormats" validate:"oneof=txt md docx"` // 文档导出格式
-	Options           *ExportOptions `json:"options,omitempty"`
+	Format               string         `json:"format,omitempty" validate:"omitempty,oneof=zip epub"`             // 项目导出格式，默认 zip
+	Language             string         `json:"language,omitempty"`                                               // 电子书语言（EPUB），默认 zh-CN
+	IncludeDocuments     bool           `json:"includeDocuments"`                                                 // 是否包含文档
+	IncludeCharacters    bool           `json:"includeCharacters"`                                                // 是否包含角色
+	IncludeLocations     bool           `json:"includeLocations"`                                                 // 是否包含地点
+	IncludeTimeline      bool           `json:"includeTimeline"`                                                  // 是否包含时间线
+	IncludeItems         bool           `json:"includeItems"`                                                     // 是否包含物品
+	IncludeOrganizations bool           `json:"includeOrganizations"`                                             // 是否包含组织及组织关系
+	DocumentFormats      string         `json:"documentFormats,omitempty" validate:"omitempty,oneof=txt md docx"` // ZIP 内文档格式，默认 txt；EPUB 导出忽略
+	Options              *ExportOptions `json:"options,omitempty"`
 }
 
 // ExportOptions 导出选项
@@ -67,7 +107,7 @@ type ExportOptions struct {
 // ExportTask 导出任务
 type ExportTask struct {
 	ID            string     `json:"id"`
-	Type          string     `json:"type"`       // document, project
+	Type          string     `json:"type"`       // document, project, import
 	ResourceID    string     `json:"resourceId"` // 文档ID或项目ID
 	ResourceTitle string     `json:"resourceTitle"`
 	Format        string     `json:"format"`
@@ -107,10 +147,12 @@ const (
 	ExportFormatMD   = "md"
 	ExportFormatDOCX = "docx"
 	ExportFormatZIP  = "zip"
+	ExportFormatEPUB = "epub"
 )
 
 // ExportType 导出类型常量
 const (
 	ExportTypeDocument = "document"
 	ExportTypeProject  = "project"
+	ExportTypeImport   = "import" // 稿件导入，ResourceID 为目标项目ID
 )
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	financeModel "Qingyu_backend/models/finance"
+	"Qingyu_backend/models/shared/types"
 	"context"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -42,7 +43,7 @@ type AuthorRevenueService interface {
 
 	// 收入记录
 	CreateEarning(ctx context.Context, earning *financeModel.AuthorEarning) error
-	CalculateEarning(ctx context.Context, earningType string, amount float64, authorID string, bookID primitive.ObjectID) (float64, float64, error)
+	CalculateEarning(ctx context.Context, earningType string, amount types.Money, authorID string, bookID primitive.ObjectID) (types.Money, types.Money, error)
 
 	// 提现管理
 	CreateWithdrawalRequest(ctx context.Context, userID string, amount float64, method string, account financeModel.WithdrawAccount) (*financeModel.WithdrawalRequest, error)
@@ -0,0 +1,58 @@
+package interfaces
+
+import (
+	"Qingyu_backend/models/writer"
+	"context"
+)
+
+// ItemService 物品服务接口
+type ItemService interface {
+	// 基础CRUD
+	Create(ctx context.Context, projectID, userID string, req *CreateItemRequest) (*writer.Item, error)
+	GetByID(ctx context.Context, itemID, projectID string) (*writer.Item, error)
+	List(ctx context.Context, projectID string) ([]*writer.Item, error)
+	Update(ctx context.Context, itemID, projectID string, req *UpdateItemRequest) (*writer.Item, error)
+	Delete(ctx context.Context, itemID, projectID string) error
+
+	// 层级管理
+	GetItemTree(ctx context.Context, projectID string) ([]*ItemNode, error)
+
+	// 持有关系
+	ListByOwner(ctx context.Context, projectID, characterID string) ([]*writer.Item, error)
+}
+
+// CreateItemRequest 创建物品请求
+type CreateItemRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	ParentID    string   `json:"parentId"`
+	OwnerID     string   `json:"ownerId"`
+	LocationID  string   `json:"locationId"`
+	Alias       []string `json:"alias"`
+	Rarity      string   `json:"rarity"`
+	Function    string   `json:"function"`
+	Origin      string   `json:"origin"`
+	ImageURL    string   `json:"imageUrl"`
+}
+
+// UpdateItemRequest 更新物品请求
+type UpdateItemRequest struct {
+	Name        *string   `json:"name"`
+	Type        *string   `json:"type"`
+	Description *string   `json:"description"`
+	ParentID    *string   `json:"parentId"`
+	OwnerID     *string   `json:"ownerId"`
+	LocationID  *string   `json:"locationId"`
+	Alias       *[]string `json:"alias"`
+	Rarity      *string   `json:"rarity"`
+	Function    *string   `json:"function"`
+	Origin      *string   `json:"origin"`
+	ImageURL    *string   `json:"imageUrl"`
+}
+
+// ItemNode 物品树节点
+type ItemNode struct {
+	Item     *writer.Item `json:"item"`
+	Children []*ItemNode  `json:"children"`
+}
@@ -0,0 +1,75 @@
+package interfaces
+
+import (
+	"Qingyu_backend/models/writer"
+	"context"
+)
+
+// OrganizationService 组织服务接口
+type OrganizationService interface {
+	// 基础CRUD
+	Create(ctx context.Context, projectID, userID string, req *CreateOrganizationRequest) (*writer.Organization, error)
+	GetByID(ctx context.Context, orgID, projectID string) (*writer.Organization, error)
+	List(ctx context.Context, projectID string) ([]*writer.Organization, error)
+	Update(ctx context.Context, orgID, projectID string, req *UpdateOrganizationRequest) (*writer.Organization, error)
+	Delete(ctx context.Context, orgID, projectID string) error
+
+	// 层级管理
+	GetOrganizationTree(ctx context.Context, projectID string) ([]*OrganizationNode, error)
+
+	// 成员管理
+	AddMember(ctx context.Context, orgID, projectID, characterID string) (*writer.Organization, error)
+	RemoveMember(ctx context.Context, orgID, projectID, characterID string) (*writer.Organization, error)
+	ListByMember(ctx context.Context, projectID, characterID string) ([]*writer.Organization, error)
+
+	// 关系管理
+	CreateRelation(ctx context.Context, projectID string, req *CreateOrgRelationRequest) (*writer.OrgRelation, error)
+	ListRelations(ctx context.Context, projectID string, orgID *string) ([]*writer.OrgRelation, error)
+	DeleteRelation(ctx context.Context, relationID, projectID string) error
+}
+
+// CreateOrganizationRequest 创建组织请求
+type CreateOrganizationRequest struct {
+	Name           string   `json:"name" validate:"required"`
+	Type           string   `json:"type"`
+	Description    string   `json:"description"`
+	LeaderID       string   `json:"leaderId"`
+	BaseLocationID string   `json:"baseLocationId"`
+	ParentID       string   `json:"parentId"`
+	Members        []string `json:"members"`
+	Alias          []string `json:"alias"`
+	Motto          string   `json:"motto"`
+	Resources      string   `json:"resources"`
+}
+
+// UpdateOrganizationRequest 更新组织请求（成员通过成员接口维护）
+type UpdateOrganizationRequest struct {
+	Name           *string   `json:"name"`
+	Type           *string   `json:"type"`
+	Description    *string   `json:"description"`
+	LeaderID       *string   `json:"leaderId"`
+	BaseLocationID *string   `json:"baseLocationId"`
+	ParentID       *string   `json:"parentId"`
+	Alias          *[]string `json:"alias"`
+	Motto          *string   `json:"motto"`
+	Resources      *string   `json:"resources"`
+}
+
+// CreateOrgRelationRequest 创建组织关系请求
+type CreateOrgRelationRequest struct {
+	FromOrgID string `json:"fromOrgId" validate:"required"`
+	ToOrgID   string `json:"toOrgId" validate:"required"`
+	Relation  string `json:"relation" validate:"required"`
+	Notes     string `json:"notes"`
+}
+
+// OrganizationMemberRequest 组织成员请求
+type OrganizationMemberRequest struct {
+	CharacterID string `json:"characterId" validate:"required"`
+}
+
+// OrganizationNode 组织树节点
+type OrganizationNode struct {
+	Organization *writer.Organization `json:"organization"`
+	Children     []*OrganizationNode  `json:"children"`
+}
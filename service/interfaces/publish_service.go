@@ -22,6 +22,11 @@ type PublishService interface {
 	GetPublicationRecord(ctx context.Context, recordID string) (*PublicationRecord, error)
 	GetPendingPublicationRecords(ctx context.Context, page, pageSize int) ([]*PublicationRecord, int64, error)
 	ReviewPublication(ctx context.Context, recordID, reviewerID string, approved bool, note string) (*PublicationRecord, error)
+
+	// 定时发布
+	GetScheduledPublications(ctx context.Context, projectID, userID string) ([]*PublicationRecord, error)
+	ReschedulePublication(ctx context.Context, recordID, userID string, req *ReschedulePublicationRequest) (*PublicationRecord, error)
+	CancelScheduledPublication(ctx context.Context, recordID, userID string) error
 }
 
 // PublishProjectRequest 发布项目请求
@@ -40,6 +45,14 @@ type UpdateDocumentPublishStatusRequest = dto.UpdateDocumentPublishStatusRequest
 // Deprecated: 使用 dto.BatchPublishDocumentsRequest 替代
 type BatchPublishDocumentsRequest = dto.BatchPublishDocumentsRequest
 
+// DripSchedule 批量定时发布计划
+// Deprecated: 使用 dto.DripSchedule 替代
+type DripSchedule = dto.DripSchedule
+
+// ReschedulePublicationRequest 修改定时发布时间请求
+// Deprecated: 使用 dto.ReschedulePublicationRequest 替代
+type ReschedulePublicationRequest = dto.ReschedulePublicationRequest
+
 // BatchPublishResult 批量发布结果
 // Deprecated: 使用 dto.BatchPublishResult 替代
 type BatchPublishResult = dto.BatchPublishResult
@@ -63,6 +76,9 @@ const (
 	PublicationStatusRejected    = dto.PublicationStatusRejected    // 已拒绝
 	PublicationStatusUnpublished = dto.PublicationStatusUnpublished // 已取消发布
 	PublicationStatusFailed      = dto.PublicationStatusFailed      // 发布失败
+	PublicationStatusScheduled   = dto.PublicationStatusScheduled   // 等待定时发布
+	PublicationStatusPublishing  = dto.PublicationStatusPublishing  // 定时发布执行中
+	PublicationStatusCancelled   = dto.PublicationStatusCancelled   // 定时发布已取消
 )
 
 // PublishType 发布类型常量
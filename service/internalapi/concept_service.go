@@ -3,15 +3,18 @@ package internalapi
 import (
 	"context"
 	"errors"
+	"time"
 
 	"Qingyu_backend/models/writer"
 	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/service/base"
 )
 
 // ConceptService 设定百科服务
 // 处理Concept的CRUD操作，支持AI写作助手功能中的设定百科管理
 type ConceptService struct {
-	repo writerRepo.ConceptRepository
+	repo     writerRepo.ConceptRepository
+	eventBus base.EventBus // 可选，发布 concept.* 事件（实体识别缓存据此失效）
 }
 
 // NewConceptService 创建ConceptService实例
@@ -21,6 +24,11 @@ func NewConceptService(repo writerRepo.ConceptRepository) *ConceptService {
 	}
 }
 
+// SetEventBus 设置事件总线
+func (s *ConceptService) SetEventBus(eventBus base.EventBus) {
+	s.eventBus = eventBus
+}
+
 // CreateConceptRequest 创建概念请求
 type CreateConceptRequest struct {
 	UserID    string   `json:"user_id" binding:"required"`
@@ -65,6 +73,7 @@ func (s *ConceptService) Create(ctx context.Context, req *CreateConceptRequest)
 	if err := s.repo.Create(ctx, concept); err != nil {
 		return nil, err
 	}
+	s.publishEvent(ctx, "concept.created", concept)
 	return concept, nil
 }
 
@@ -124,6 +133,7 @@ func (s *ConceptService) Update(ctx context.Context, conceptID string, req *Upda
 	if err := s.repo.Update(ctx, concept); err != nil {
 		return nil, err
 	}
+	s.publishEvent(ctx, "concept.updated", concept)
 	return concept, nil
 }
 
@@ -147,7 +157,11 @@ func (s *ConceptService) Delete(ctx context.Context, userID, projectID, conceptI
 	if !sameProjectID(concept.ProjectID, projectID) {
 		return errors.New("concept not found")
 	}
-	return s.repo.Delete(ctx, conceptID)
+	if err := s.repo.Delete(ctx, conceptID); err != nil {
+		return err
+	}
+	s.publishEvent(ctx, "concept.deleted", concept)
+	return nil
 }
 
 // Search 搜索设定
@@ -224,3 +238,19 @@ func (s *ConceptService) ListByProject(ctx context.Context, userID, projectID st
 	concepts, err := s.repo.ListByProject(ctx, projectID)
 	return concepts, len(concepts), err
 }
+
+// publishEvent 发布设定变更事件
+func (s *ConceptService) publishEvent(ctx context.Context, eventType string, concept *writer.Concept) {
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.PublishAsync(ctx, &base.BaseEvent{
+		EventType: eventType,
+		EventData: map[string]interface{}{
+			"concept_id": concept.ID,
+			"project_id": concept.ProjectID,
+		},
+		Timestamp: time.Now(),
+		Source:    "ConceptService",
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"Qingyu_backend/models/writer"
+	"Qingyu_backend/service/base"
 )
 
 type mockConceptRepo struct {
@@ -36,6 +37,53 @@ func (m *mockConceptRepo) BatchGetByIDs(ctx context.Context, ids []string) ([]*w
 	return m.batchConcepts, nil
 }
 
+// recordingEventBus 记录发布的事件
+type recordingEventBus struct {
+	events []base.Event
+}
+
+func (b *recordingEventBus) Subscribe(eventType string, handler base.EventHandler) error { return nil }
+func (b *recordingEventBus) Unsubscribe(eventType string, handlerName string) error      { return nil }
+func (b *recordingEventBus) Publish(ctx context.Context, event base.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+func (b *recordingEventBus) PublishAsync(ctx context.Context, event base.Event) error {
+	return b.Publish(ctx, event)
+}
+
+func TestConceptService_PublishesChangeEvents(t *testing.T) {
+	repo := &mockConceptRepo{concept: &writer.Concept{ID: "concept-1", ProjectID: "project-a"}}
+	bus := &recordingEventBus{}
+	svc := NewConceptService(repo)
+	svc.SetEventBus(bus)
+
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, &CreateConceptRequest{ProjectID: "project-a", Name: "灵根"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := svc.Update(ctx, "concept-1", &UpdateConceptRequest{ProjectID: "project-a", Name: "仙根"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := svc.Delete(ctx, "u1", "project-a", "concept-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	want := []string{"concept.created", "concept.updated", "concept.deleted"}
+	if len(bus.events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(bus.events))
+	}
+	for i, event := range bus.events {
+		if event.GetEventType() != want[i] {
+			t.Fatalf("event %d: expected %s, got %s", i, want[i], event.GetEventType())
+		}
+		data, _ := event.GetEventData().(map[string]interface{})
+		if data["project_id"] != "project-a" {
+			t.Fatalf("event %d: expected project_id project-a, got %v", i, data["project_id"])
+		}
+	}
+}
+
 func TestConceptService_GetConcept_ProjectMismatch(t *testing.T) {
 	repo := &mockConceptRepo{
 		concept: &writer.Concept{ProjectID: "project-a"},
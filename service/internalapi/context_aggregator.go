@@ -12,13 +12,15 @@ import (
 )
 
 // ContextAggregator 上下文聚合服务
-// 供AI服务内部调用，聚合项目上下文数据（角色、大纲、文档内容、角色关系）
+// 供AI服务内部调用，聚合项目上下文数据（角色、大纲、文档内容、角色关系、物品、组织）
 type ContextAggregator struct {
 	projectRepo      writerRepo.ProjectRepository
 	characterRepo    writerRepo.CharacterRepository
 	outlineRepo      writerRepo.OutlineRepository
 	documentRepo     writerRepo.DocumentRepository
 	documentContent  writerRepo.DocumentContentRepository
+	itemRepo         writerRepo.ItemRepository
+	organizationRepo writerRepo.OrganizationRepository
 	logger           *zap.Logger
 }
 
@@ -28,12 +30,14 @@ func NewContextAggregator(factory interfaces.RepositoryFactory, logger *zap.Logg
 		logger, _ = zap.NewProduction()
 	}
 	return &ContextAggregator{
-		projectRepo:     factory.CreateProjectRepository(),
-		characterRepo:   factory.CreateCharacterRepository(),
-		outlineRepo:     factory.CreateOutlineRepository(),
-		documentRepo:    factory.CreateDocumentRepository(),
-		documentContent: factory.CreateDocumentContentRepository(),
-		logger:          logger,
+		projectRepo:      factory.CreateProjectRepository(),
+		characterRepo:    factory.CreateCharacterRepository(),
+		outlineRepo:      factory.CreateOutlineRepository(),
+		documentRepo:     factory.CreateDocumentRepository(),
+		documentContent:  factory.CreateDocumentContentRepository(),
+		itemRepo:         factory.CreateItemRepository(),
+		organizationRepo: factory.CreateOrganizationRepository(),
+		logger:           logger,
 	}
 }
 
@@ -62,17 +66,17 @@ type CharacterInfo struct {
 
 // OutlineNodeInfo 大纲节点摘要（供AI服务使用）
 type OutlineNodeInfo struct {
-	ID          string   `json:"id"`
-	Title       string   `json:"title"`
-	ParentID    string   `json:"parentId,omitempty"`
-	Order       int      `json:"order"`
-	Level       int      `json:"level"`
-	Summary     string   `json:"summary,omitempty"`
-	Type        string   `json:"type,omitempty"`
-	Tension     int      `json:"tension"`
-	DocumentID  string   `json:"documentId,omitempty"`
-	Characters  []string `json:"characters,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	ParentID   string   `json:"parentId,omitempty"`
+	Order      int      `json:"order"`
+	Level      int      `json:"level"`
+	Summary    string   `json:"summary,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Tension    int      `json:"tension"`
+	DocumentID string   `json:"documentId,omitempty"`
+	Characters []string `json:"characters,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
 }
 
 // DocumentContentInfo 文档内容摘要（供AI服务使用）
@@ -83,11 +87,46 @@ type DocumentContentInfo struct {
 
 // RelationInfo 角色关系摘要（供AI服务使用）
 type RelationInfo struct {
-	ID        string `json:"id"`
-	FromID    string `json:"fromId"`
-	ToID      string `json:"toId"`
-	Type      string `json:"type"`
-	Strength  int    `json:"strength"`
+	ID       string `json:"id"`
+	FromID   string `json:"fromId"`
+	ToID     string `json:"toId"`
+	Type     string `json:"type"`
+	Strength int    `json:"strength"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// ItemInfo 物品摘要（供AI服务使用）
+type ItemInfo struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"`
+	ParentID    string   `json:"parentId,omitempty"`
+	Alias       []string `json:"alias,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Function    string   `json:"function,omitempty"`
+	OwnerID     string   `json:"ownerId,omitempty"`
+	LocationID  string   `json:"locationId,omitempty"`
+}
+
+// OrganizationInfo 组织摘要（供AI服务使用）
+type OrganizationInfo struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Type           string             `json:"type,omitempty"`
+	ParentID       string             `json:"parentId,omitempty"`
+	Alias          []string           `json:"alias,omitempty"`
+	Description    string             `json:"description,omitempty"`
+	LeaderID       string             `json:"leaderId,omitempty"`
+	BaseLocationID string             `json:"baseLocationId,omitempty"`
+	Members        []string           `json:"members,omitempty"`
+	Relations      []*OrgRelationInfo `json:"relations,omitempty"`
+}
+
+// OrgRelationInfo 组织关系摘要（供AI服务使用）
+type OrgRelationInfo struct {
+	FromOrgID string `json:"fromOrgId"`
+	ToOrgID   string `json:"toOrgId"`
+	Relation  string `json:"relation"`
 	Notes     string `json:"notes,omitempty"`
 }
 
@@ -237,3 +276,75 @@ func (s *ContextAggregator) GetCharacterRelations(ctx context.Context, projectID
 
 	return result, nil
 }
+
+// GetItems 获取项目物品列表
+func (s *ContextAggregator) GetItems(ctx context.Context, projectID string) ([]*ItemInfo, error) {
+	if s.itemRepo == nil {
+		return []*ItemInfo{}, nil
+	}
+	items, err := s.itemRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查找物品列表失败: %w", err)
+	}
+
+	result := make([]*ItemInfo, 0, len(items))
+	for _, item := range items {
+		result = append(result, &ItemInfo{
+			ID:          item.ID,
+			Name:        item.Name,
+			Type:        item.Type,
+			ParentID:    item.ParentID,
+			Alias:       item.Alias,
+			Description: item.Description,
+			Function:    item.Function,
+			OwnerID:     item.OwnerID,
+			LocationID:  item.LocationID,
+		})
+	}
+
+	return result, nil
+}
+
+// GetOrganizations 获取项目组织列表（含组织间关系）
+func (s *ContextAggregator) GetOrganizations(ctx context.Context, projectID string) ([]*OrganizationInfo, error) {
+	if s.organizationRepo == nil {
+		return []*OrganizationInfo{}, nil
+	}
+	orgs, err := s.organizationRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("查找组织列表失败: %w", err)
+	}
+	relations, err := s.organizationRepo.FindRelations(ctx, projectID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查找组织关系失败: %w", err)
+	}
+
+	// 关系挂在发起方组织下
+	relationsByOrg := make(map[string][]*OrgRelationInfo)
+	for _, r := range relations {
+		relationsByOrg[r.FromOrgID] = append(relationsByOrg[r.FromOrgID], &OrgRelationInfo{
+			FromOrgID: r.FromOrgID,
+			ToOrgID:   r.ToOrgID,
+			Relation:  string(r.Relation),
+			Notes:     r.Notes,
+		})
+	}
+
+	result := make([]*OrganizationInfo, 0, len(orgs))
+	for _, org := range orgs {
+		result = append(result, &OrganizationInfo{
+			ID:             org.ID,
+			Name:           org.Name,
+			Type:           org.Type,
+			ParentID:       org.ParentID,
+			Alias:          org.Alias,
+			Description:    org.Description,
+			LeaderID:       org.LeaderID,
+			BaseLocationID: org.BaseLocationID,
+			Members:        org.Members,
+			Relations:      relationsByOrg[org.ID],
+		})
+	}
+
+	return result, nil
+}
@@ -103,3 +103,280 @@ func (s *ConversationService) GetConversations(
 ) ([]*messaging.Conversation, int64, error) {
 	return s.conversationRepo.FindByUserID(ctx, userID, page, pageSize)
 }
+
+// ConversationParticipants 获取活跃会话的参与者（供WebSocket转发typing等临时事件）
+func (s *ConversationService) ConversationParticipants(ctx context.Context, conversationID string) ([]string, error) {
+	conv, err := s.conversationRepo.FindByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsActive {
+		return nil, ErrConversationNotFound
+	}
+	return conv.ParticipantIDs, nil
+}
+
+// recordMessage 更新最后消息并为除发送者外的参与者增加未读计数
+func (s *ConversationService) recordMessage(ctx context.Context, message *messaging.DirectMessage) (*messaging.Conversation, error) {
+	conv, err := s.conversationRepo.FindByID(ctx, message.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	conv.UpdateLastMessage(message.ID.Hex(), message.Content, message.SenderID, message.CreatedAt)
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+
+	for _, participantID := range conv.ParticipantIDs {
+		if participantID == message.SenderID {
+			continue
+		}
+		if err := s.conversationRepo.IncrementUnreadCount(ctx, message.ConversationID, participantID); err != nil {
+			return nil, err
+		}
+	}
+
+	return conv, nil
+}
+
+// SetMuted 设置当前用户对会话的免打扰状态
+func (s *ConversationService) SetMuted(
+	ctx context.Context,
+	conversationID string,
+	userID string,
+	muted bool,
+) (*messaging.Conversation, error) {
+	conv, err := s.conversationRepo.FindByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.HasParticipant(userID) {
+		return nil, ErrNotParticipant
+	}
+
+	if muted {
+		conv.Mute(userID)
+	} else {
+		conv.Unmute(userID)
+	}
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// ========== 群聊管理 ==========
+
+// CreateGroup 创建群聊，创建者为群主
+func (s *ConversationService) CreateGroup(
+	ctx context.Context,
+	ownerID string,
+	req *CreateGroupRequest,
+) (*messaging.Conversation, error) {
+	maxMembers := req.MaxMembers
+	if maxMembers <= 0 {
+		maxMembers = messaging.DefaultGroupMaxMembers
+	}
+
+	participantIDs := []string{ownerID}
+	seen := map[string]bool{ownerID: true}
+	for _, id := range req.MemberIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		participantIDs = append(participantIDs, id)
+	}
+	if len(participantIDs) < 2 {
+		return nil, ErrInvalidParticipantIDs
+	}
+	if len(participantIDs) > maxMembers {
+		return nil, ErrGroupFull
+	}
+
+	conv := &messaging.Conversation{
+		ParticipantIDs: participantIDs,
+		Type:           messaging.ConversationTypeGroup,
+		IsActive:       true,
+		CreatedBy:      ownerID,
+		GroupInfo: &messaging.ConversationGroupInfo{
+			Name:        req.Name,
+			Description: req.Description,
+			Avatar:      req.Avatar,
+			OwnerID:     ownerID,
+			MaxMembers:  maxMembers,
+		},
+	}
+
+	if err := s.conversationRepo.Create(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// InviteMembers 邀请成员入群（群主或管理员），返回实际新增的成员
+func (s *ConversationService) InviteMembers(
+	ctx context.Context,
+	conversationID string,
+	operatorID string,
+	userIDs []string,
+) (*messaging.Conversation, []string, error) {
+	conv, err := s.loadGroup(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !conv.CanManage(operatorID) {
+		return nil, nil, ErrPermissionDenied
+	}
+
+	var added []string
+	for _, id := range userIDs {
+		if id == "" || conv.HasParticipant(id) {
+			continue
+		}
+		if !conv.AddParticipant(id, nil) {
+			return nil, nil, ErrGroupFull
+		}
+		added = append(added, id)
+	}
+	if len(added) == 0 {
+		return conv, nil, nil
+	}
+
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, nil, err
+	}
+	return conv, added, nil
+}
+
+// RemoveMember 将成员移出群聊
+// 群主可移除任何成员，管理员只能移除普通成员
+func (s *ConversationService) RemoveMember(
+	ctx context.Context,
+	conversationID string,
+	operatorID string,
+	targetID string,
+) (*messaging.Conversation, error) {
+	conv, err := s.loadGroup(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetRole := conv.RoleOf(targetID)
+	if targetRole == "" {
+		return nil, ErrNotParticipant
+	}
+	switch conv.RoleOf(operatorID) {
+	case messaging.GroupRoleOwner:
+		if targetID == operatorID {
+			return nil, ErrOwnerMustTransfer
+		}
+	case messaging.GroupRoleAdmin:
+		if targetRole != messaging.GroupRoleMember {
+			return nil, ErrPermissionDenied
+		}
+	default:
+		return nil, ErrPermissionDenied
+	}
+
+	conv.RemoveParticipant(targetID)
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// Leave 退出群聊，群主须先转让；最后一名成员退出后群聊停用
+func (s *ConversationService) Leave(
+	ctx context.Context,
+	conversationID string,
+	userID string,
+) (*messaging.Conversation, error) {
+	conv, err := s.loadGroup(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if conv.IsOwner(userID) && len(conv.ParticipantIDs) > 1 {
+		return nil, ErrOwnerMustTransfer
+	}
+
+	conv.RemoveParticipant(userID)
+	if len(conv.ParticipantIDs) == 0 {
+		conv.IsActive = false
+	}
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// TransferOwnership 转让群主（仅群主）
+func (s *ConversationService) TransferOwnership(
+	ctx context.Context,
+	conversationID string,
+	operatorID string,
+	newOwnerID string,
+) (*messaging.Conversation, error) {
+	conv, err := s.loadGroup(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsOwner(operatorID) {
+		return nil, ErrPermissionDenied
+	}
+	if !conv.HasParticipant(newOwnerID) {
+		return nil, ErrNotParticipant
+	}
+	if !conv.TransferOwnership(newOwnerID) {
+		return conv, nil
+	}
+
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// SetAdmin 设置或取消管理员（仅群主）
+func (s *ConversationService) SetAdmin(
+	ctx context.Context,
+	conversationID string,
+	operatorID string,
+	targetID string,
+	admin bool,
+) (*messaging.Conversation, error) {
+	conv, err := s.loadGroup(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsOwner(operatorID) {
+		return nil, ErrPermissionDenied
+	}
+	if !conv.SetAdmin(targetID, admin) {
+		if !conv.HasParticipant(targetID) {
+			return nil, ErrNotParticipant
+		}
+		return nil, ErrPermissionDenied
+	}
+
+	if err := s.conversationRepo.Update(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// loadGroup 加载群聊并校验操作者为成员
+func (s *ConversationService) loadGroup(ctx context.Context, conversationID, userID string) (*messaging.Conversation, error) {
+	conv, err := s.conversationRepo.FindByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsGroup() || conv.GroupInfo == nil {
+		return nil, ErrNotGroup
+	}
+	if !conv.HasParticipant(userID) {
+		return nil, ErrNotParticipant
+	}
+	return conv, nil
+}
@@ -0,0 +1,164 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/messaging"
+	"Qingyu_backend/models/messaging/mocks"
+)
+
+// newTestGroup 创建测试群聊：owner 为群主，admin 为管理员，其余为普通成员
+func newTestGroup(members ...string) *messaging.Conversation {
+	conv := &messaging.Conversation{
+		ParticipantIDs: append([]string{"owner", "admin"}, members...),
+		Type:           messaging.ConversationTypeGroup,
+		IsActive:       true,
+		GroupInfo: &messaging.ConversationGroupInfo{
+			Name:       "测试群",
+			OwnerID:    "owner",
+			AdminIDs:   []string{"admin"},
+			MaxMembers: 5,
+		},
+	}
+	conv.ID = primitive.NewObjectID()
+	return conv
+}
+
+func newGroupTestService(conv *messaging.Conversation) (*ConversationService, *mocks.MockConversationRepository) {
+	convRepo := new(mocks.MockConversationRepository)
+	convRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+	convRepo.On("Update", mock.Anything, conv).Return(nil)
+	return NewConversationService(convRepo, new(mocks.MockMessageRepository)), convRepo
+}
+
+func TestConversationService_CreateGroup(t *testing.T) {
+	convRepo := new(mocks.MockConversationRepository)
+	convRepo.On("Create", mock.Anything, mock.AnythingOfType("*messaging.Conversation")).Return(nil)
+	svc := NewConversationService(convRepo, new(mocks.MockMessageRepository))
+
+	conv, err := svc.CreateGroup(context.Background(), "owner", &CreateGroupRequest{
+		Name:      "读书会",
+		MemberIDs: []string{"u1", "owner", "u1", "u2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"owner", "u1", "u2"}, conv.ParticipantIDs)
+	assert.True(t, conv.IsGroup())
+	assert.True(t, conv.IsOwner("owner"))
+	assert.Equal(t, messaging.DefaultGroupMaxMembers, conv.GroupInfo.MaxMembers)
+
+	_, err = svc.CreateGroup(context.Background(), "owner", &CreateGroupRequest{Name: "空群", MemberIDs: []string{"owner"}})
+	assert.ErrorIs(t, err, ErrInvalidParticipantIDs)
+
+	_, err = svc.CreateGroup(context.Background(), "owner", &CreateGroupRequest{Name: "小群", MemberIDs: []string{"u1", "u2"}, MaxMembers: 2})
+	assert.ErrorIs(t, err, ErrGroupFull)
+}
+
+func TestConversationService_InviteMembers(t *testing.T) {
+	ctx := context.Background()
+
+	conv := newTestGroup("m1")
+	svc, _ := newGroupTestService(conv)
+
+	_, _, err := svc.InviteMembers(ctx, conv.ID.Hex(), "m1", []string{"u1"})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, added, err := svc.InviteMembers(ctx, conv.ID.Hex(), "admin", []string{"u1", "m1", "u2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2"}, added)
+	assert.Len(t, conv.ParticipantIDs, 5)
+
+	_, _, err = svc.InviteMembers(ctx, conv.ID.Hex(), "owner", []string{"u3"})
+	assert.ErrorIs(t, err, ErrGroupFull)
+}
+
+func TestConversationService_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("admin can only kick members", func(t *testing.T) {
+		conv := newTestGroup("m1", "m2")
+		conv.GroupInfo.AdminIDs = append(conv.GroupInfo.AdminIDs, "m2")
+		svc, _ := newGroupTestService(conv)
+
+		_, err := svc.RemoveMember(ctx, conv.ID.Hex(), "admin", "m2")
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = svc.RemoveMember(ctx, conv.ID.Hex(), "admin", "owner")
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = svc.RemoveMember(ctx, conv.ID.Hex(), "m1", "m2")
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = svc.RemoveMember(ctx, conv.ID.Hex(), "admin", "m1")
+		require.NoError(t, err)
+		assert.False(t, conv.HasParticipant("m1"))
+	})
+
+	t.Run("owner can kick admins", func(t *testing.T) {
+		conv := newTestGroup("m1")
+		svc, _ := newGroupTestService(conv)
+
+		_, err := svc.RemoveMember(ctx, conv.ID.Hex(), "owner", "admin")
+		require.NoError(t, err)
+		assert.False(t, conv.HasParticipant("admin"))
+		assert.Empty(t, conv.GroupInfo.AdminIDs)
+
+		_, err = svc.RemoveMember(ctx, conv.ID.Hex(), "owner", "owner")
+		assert.ErrorIs(t, err, ErrOwnerMustTransfer)
+		_, err = svc.RemoveMember(ctx, conv.ID.Hex(), "owner", "stranger")
+		assert.ErrorIs(t, err, ErrNotParticipant)
+	})
+}
+
+func TestConversationService_LeaveAndTransfer(t *testing.T) {
+	ctx := context.Background()
+	conv := newTestGroup("m1")
+	svc, _ := newGroupTestService(conv)
+
+	_, err := svc.Leave(ctx, conv.ID.Hex(), "owner")
+	assert.ErrorIs(t, err, ErrOwnerMustTransfer)
+
+	_, err = svc.TransferOwnership(ctx, conv.ID.Hex(), "admin", "m1")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	// 管理员接任群主后不再保留管理员身份
+	_, err = svc.TransferOwnership(ctx, conv.ID.Hex(), "owner", "admin")
+	require.NoError(t, err)
+	assert.True(t, conv.IsOwner("admin"))
+	assert.Equal(t, messaging.GroupRoleMember, conv.RoleOf("owner"))
+	assert.Empty(t, conv.GroupInfo.AdminIDs)
+
+	_, err = svc.Leave(ctx, conv.ID.Hex(), "owner")
+	require.NoError(t, err)
+	assert.False(t, conv.HasParticipant("owner"))
+
+	_, err = svc.SetAdmin(ctx, conv.ID.Hex(), "admin", "m1", true)
+	require.NoError(t, err)
+	assert.Equal(t, messaging.GroupRoleAdmin, conv.RoleOf("m1"))
+
+	// 最后一人退出后群聊停用
+	_, err = svc.Leave(ctx, conv.ID.Hex(), "m1")
+	require.NoError(t, err)
+	_, err = svc.Leave(ctx, conv.ID.Hex(), "admin")
+	require.NoError(t, err)
+	assert.False(t, conv.IsActive)
+}
+
+func TestConversationService_GroupOperationsOnDirect(t *testing.T) {
+	conv := &messaging.Conversation{ParticipantIDs: []string{"a", "b"}, Type: messaging.ConversationTypeDirect}
+	conv.ID = primitive.NewObjectID()
+	svc, _ := newGroupTestService(conv)
+
+	_, _, err := svc.InviteMembers(context.Background(), conv.ID.Hex(), "a", []string{"c"})
+	assert.ErrorIs(t, err, ErrNotGroup)
+
+	// 单聊也可以设置免打扰
+	_, err = svc.SetMuted(context.Background(), conv.ID.Hex(), "a", true)
+	require.NoError(t, err)
+	assert.True(t, conv.IsMutedByUser("a"))
+	_, err = svc.SetMuted(context.Background(), conv.ID.Hex(), "c", true)
+	assert.ErrorIs(t, err, ErrNotParticipant)
+}
@@ -2,15 +2,28 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"go.uber.org/zap"
+
 	"Qingyu_backend/models/messaging"
+	"Qingyu_backend/models/notification"
+	"Qingyu_backend/pkg/logger"
 )
 
+// MessageNotifier 新消息通知发送方（由 notification.NotificationService 实现）
+type MessageNotifier interface {
+	SendNotification(ctx context.Context, userID string, notificationType notification.NotificationType, title, content string, data map[string]interface{}) error
+}
+
 // MessageService 消息服务
 type MessageService struct {
 	messageRepo         messaging.MessageRepository
 	conversationService *ConversationService
+	notifier            MessageNotifier
+	recallWindow        time.Duration
+	editWindow          time.Duration
 }
 
 // NewMessageService 创建消息服务
@@ -21,6 +34,23 @@ func NewMessageService(
 	return &MessageService{
 		messageRepo:         messageRepo,
 		conversationService: conversationService,
+		recallWindow:        messaging.DefaultRecallWindow,
+		editWindow:          messaging.DefaultEditWindow,
+	}
+}
+
+// SetNotifier 设置新消息通知发送方，为空时不发送通知
+func (s *MessageService) SetNotifier(notifier MessageNotifier) {
+	s.notifier = notifier
+}
+
+// SetTimeWindows 设置撤回与编辑时限，非正值保持原设置
+func (s *MessageService) SetTimeWindows(recall, edit time.Duration) {
+	if recall > 0 {
+		s.recallWindow = recall
+	}
+	if edit > 0 {
+		s.editWindow = edit
 	}
 }
 
@@ -58,32 +88,62 @@ func (s *MessageService) Create(
 		return nil, err
 	}
 
-	// 更新会话的最后消息
-	err = s.conversationService.UpdateLastMessage(
-		ctx,
-		message.ConversationID,
-		message.ID.Hex(),
-		message.Content,
-		message.SenderID,
-		message.CreatedAt,
-	)
+	// 更新会话的最后消息与接收方未读计数
+	conv, err := s.conversationService.recordMessage(ctx, message)
 	if err != nil {
 		return nil, err
 	}
 
-	// 增加未读计数
-	err = s.conversationService.IncrementUnreadCount(
-		ctx,
-		message.ConversationID,
-		message.ReceiverID,
-	)
-	if err != nil {
-		return nil, err
-	}
+	s.notifyRecipients(ctx, conv, message)
 
 	return message, nil
 }
 
+// notifyRecipients 为未开启免打扰的接收方创建新消息通知，失败只记录日志
+func (s *MessageService) notifyRecipients(ctx context.Context, conv *messaging.Conversation, message *messaging.DirectMessage) {
+	if s.notifier == nil {
+		return
+	}
+
+	title := "收到新私信"
+	if conv.IsGroup() && conv.GroupInfo != nil {
+		title = "群聊「" + conv.GroupInfo.Name + "」有新消息"
+	}
+	data := map[string]interface{}{
+		"conversationId": message.ConversationID,
+		"messageId":      message.ID.Hex(),
+		"senderId":       message.SenderID,
+	}
+
+	for _, userID := range conv.ParticipantIDs {
+		if userID == message.SenderID || conv.IsMutedByUser(userID) {
+			continue
+		}
+		if err := s.notifier.SendNotification(ctx, userID, notification.NotificationTypeMessage, title, messagePreview(message), data); err != nil {
+			logger.Get().WithModule("messaging").Warn("Failed to send message notification",
+				zap.String("conversation_id", message.ConversationID),
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// messagePreview 通知中展示的消息摘要
+func messagePreview(message *messaging.DirectMessage) string {
+	switch message.Type {
+	case messaging.MessageTypeImage:
+		return "[图片]"
+	case messaging.MessageTypeFile:
+		return "[文件]"
+	}
+	runes := []rune(message.Content)
+	if len(runes) > 50 {
+		return string(runes[:50]) + "..."
+	}
+	return message.Content
+}
+
 // MarkConversationRead 标记会话已读
 func (s *MessageService) MarkConversationRead(
 	ctx context.Context,
@@ -107,3 +167,86 @@ func (s *MessageService) GetUnreadCount(
 ) (int, error) {
 	return s.messageRepo.CountUnreadInConversation(ctx, conversationID, userID)
 }
+
+// Recall 撤回消息
+// 发送者可在撤回时限内撤回自己的消息，群主和管理员可随时撤回群内消息
+func (s *MessageService) Recall(
+	ctx context.Context,
+	conv *messaging.Conversation,
+	messageID string,
+	userID string,
+) (*messaging.DirectMessage, error) {
+	message, err := s.loadMessage(ctx, conv, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := s.recallWindow
+	if message.SenderID != userID {
+		if !conv.CanManage(userID) {
+			return nil, ErrPermissionDenied
+		}
+		window = 0
+	}
+	if !message.Recall(time.Now(), window) {
+		return nil, ErrRecallExpired
+	}
+
+	if err := s.messageRepo.Update(ctx, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Edit 编辑自己发送的文本消息，旧内容保存在编辑历史中
+func (s *MessageService) Edit(
+	ctx context.Context,
+	conv *messaging.Conversation,
+	messageID string,
+	userID string,
+	content string,
+) (*messaging.DirectMessage, error) {
+	message, err := s.loadMessage(ctx, conv, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userID {
+		return nil, ErrPermissionDenied
+	}
+	if !message.Edit(content, s.editWindow) {
+		return nil, ErrEditNotAllowed
+	}
+
+	if err := s.messageRepo.Update(ctx, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// GetMessage 获取会话中的单条消息
+func (s *MessageService) GetMessage(
+	ctx context.Context,
+	conv *messaging.Conversation,
+	messageID string,
+) (*messaging.DirectMessage, error) {
+	return s.loadMessage(ctx, conv, messageID)
+}
+
+// loadMessage 加载消息并校验其属于该会话
+func (s *MessageService) loadMessage(
+	ctx context.Context,
+	conv *messaging.Conversation,
+	messageID string,
+) (*messaging.DirectMessage, error) {
+	message, err := s.messageRepo.FindByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, messaging.ErrMessageNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.ConversationID != conv.ID.Hex() || message.Status == messaging.MessageStatusDeleted {
+		return nil, ErrMessageNotFound
+	}
+	return message, nil
+}
@@ -0,0 +1,156 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/messaging"
+	"Qingyu_backend/models/messaging/mocks"
+	"Qingyu_backend/models/notification"
+)
+
+// mockNotifier 模拟通知发送方
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) SendNotification(ctx context.Context, userID string, notificationType notification.NotificationType, title, content string, data map[string]interface{}) error {
+	args := m.Called(ctx, userID, notificationType, title, content, data)
+	return args.Error(0)
+}
+
+func newTestMessage(conv *messaging.Conversation, senderID string, sentAt time.Time) *messaging.DirectMessage {
+	msg := &messaging.DirectMessage{
+		ConversationID: conv.ID.Hex(),
+		Type:           messaging.MessageTypeText,
+		Content:        "原始内容",
+		Status:         messaging.MessageStatusNormal,
+	}
+	msg.ID = primitive.NewObjectID()
+	msg.SenderID = senderID
+	msg.CreatedAt = sentAt
+	return msg
+}
+
+func TestMessageService_CreateNotifiesUnmutedRecipients(t *testing.T) {
+	conv := newTestGroup("m1", "m2")
+	conv.Mute("m1")
+
+	convRepo := new(mocks.MockConversationRepository)
+	convRepo.On("FindByID", mock.Anything, conv.ID.Hex()).Return(conv, nil)
+	convRepo.On("Update", mock.Anything, conv).Return(nil)
+	for _, id := range []string{"admin", "m1", "m2"} {
+		convRepo.On("IncrementUnreadCount", mock.Anything, conv.ID.Hex(), id).Return(nil).Once()
+	}
+	msgRepo := new(mocks.MockMessageRepository)
+	msgRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	notifier := new(mockNotifier)
+	for _, id := range []string{"admin", "m2"} {
+		notifier.On("SendNotification", mock.Anything, id, notification.NotificationTypeMessage, "群聊「测试群」有新消息", "大家好", mock.Anything).Return(nil).Once()
+	}
+
+	svc := NewMessageService(msgRepo, NewConversationService(convRepo, msgRepo))
+	svc.SetNotifier(notifier)
+
+	msg := &messaging.DirectMessage{ConversationID: conv.ID.Hex(), Type: messaging.MessageTypeText, Content: "大家好"}
+	msg.SenderID = "owner"
+	_, err := svc.Create(context.Background(), msg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "大家好", conv.LastMessagePreview)
+	convRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+	notifier.AssertNotCalled(t, "SendNotification", mock.Anything, "m1", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessageService_Recall(t *testing.T) {
+	ctx := context.Background()
+	conv := newTestGroup("m1", "m2")
+
+	setup := func(msg *messaging.DirectMessage) *MessageService {
+		msgRepo := new(mocks.MockMessageRepository)
+		msgRepo.On("FindByID", mock.Anything, msg.ID.Hex()).Return(msg, nil)
+		msgRepo.On("Update", mock.Anything, msg).Return(nil)
+		return NewMessageService(msgRepo, nil)
+	}
+
+	t.Run("sender within window", func(t *testing.T) {
+		msg := newTestMessage(conv, "m1", time.Now())
+		got, err := setup(msg).Recall(ctx, conv, msg.ID.Hex(), "m1")
+		require.NoError(t, err)
+		assert.True(t, got.IsRecalled())
+		assert.NotNil(t, got.RecalledAt)
+
+		_, err = setup(msg).Recall(ctx, conv, msg.ID.Hex(), "m1")
+		assert.ErrorIs(t, err, ErrRecallExpired)
+	})
+
+	t.Run("sender after window", func(t *testing.T) {
+		msg := newTestMessage(conv, "m1", time.Now().Add(-time.Hour))
+		_, err := setup(msg).Recall(ctx, conv, msg.ID.Hex(), "m1")
+		assert.ErrorIs(t, err, ErrRecallExpired)
+
+		// 窗口可配置
+		svc := setup(msg)
+		svc.SetTimeWindows(2*time.Hour, 0)
+		_, err = svc.Recall(ctx, conv, msg.ID.Hex(), "m1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("managers recall any message", func(t *testing.T) {
+		msg := newTestMessage(conv, "m1", time.Now().Add(-time.Hour))
+		_, err := setup(msg).Recall(ctx, conv, msg.ID.Hex(), "m2")
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = setup(msg).Recall(ctx, conv, msg.ID.Hex(), "admin")
+		require.NoError(t, err)
+		assert.True(t, msg.IsRecalled())
+	})
+
+	t.Run("message from another conversation", func(t *testing.T) {
+		msg := newTestMessage(newTestGroup(), "m1", time.Now())
+		_, err := setup(msg).Recall(ctx, conv, msg.ID.Hex(), "m1")
+		assert.ErrorIs(t, err, ErrMessageNotFound)
+	})
+}
+
+func TestMessageService_Edit(t *testing.T) {
+	ctx := context.Background()
+	conv := newTestGroup("m1")
+	msg := newTestMessage(conv, "m1", time.Now())
+
+	msgRepo := new(mocks.MockMessageRepository)
+	msgRepo.On("FindByID", mock.Anything, msg.ID.Hex()).Return(msg, nil)
+	msgRepo.On("Update", mock.Anything, msg).Return(nil)
+	svc := NewMessageService(msgRepo, nil)
+
+	_, err := svc.Edit(ctx, conv, msg.ID.Hex(), "owner", "改过的内容")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = svc.Edit(ctx, conv, msg.ID.Hex(), "m1", "第一次修改")
+	require.NoError(t, err)
+	_, err = svc.Edit(ctx, conv, msg.ID.Hex(), "m1", "第二次修改")
+	require.NoError(t, err)
+
+	assert.Equal(t, "第二次修改", msg.Content)
+	require.Len(t, msg.EditHistory, 2)
+	assert.Equal(t, "原始内容", msg.EditHistory[0].Content)
+	assert.Equal(t, "第一次修改", msg.EditHistory[1].Content)
+	assert.NotNil(t, msg.EditedAt)
+
+	// 内容未变化、已撤回或超时的消息不可编辑
+	_, err = svc.Edit(ctx, conv, msg.ID.Hex(), "m1", "第二次修改")
+	assert.ErrorIs(t, err, ErrEditNotAllowed)
+
+	old := newTestMessage(conv, "m1", time.Now().Add(-time.Hour))
+	msgRepo.On("FindByID", mock.Anything, old.ID.Hex()).Return(old, nil)
+	_, err = svc.Edit(ctx, conv, old.ID.Hex(), "m1", "迟到的修改")
+	assert.ErrorIs(t, err, ErrEditNotAllowed)
+}
@@ -26,8 +26,35 @@ var (
 
 	// ErrInvalidReplyToID 无效的回复消息ID
 	ErrInvalidReplyToID = errors.New("无效的回复消息ID")
+
+	// ErrNotGroup 不是群聊
+	ErrNotGroup = errors.New("不是群聊")
+
+	// ErrPermissionDenied 无权执行该操作
+	ErrPermissionDenied = errors.New("无权执行该操作")
+
+	// ErrGroupFull 群成员已满
+	ErrGroupFull = errors.New("群成员已满")
+
+	// ErrOwnerMustTransfer 群主须先转让群主才能退出
+	ErrOwnerMustTransfer = errors.New("群主须先转让群主才能退出")
+
+	// ErrRecallExpired 超过撤回时限或消息不可撤回
+	ErrRecallExpired = errors.New("消息已超过撤回时限或不可撤回")
+
+	// ErrEditNotAllowed 超过编辑时限或消息不可编辑
+	ErrEditNotAllowed = errors.New("消息已超过编辑时限或不可编辑")
 )
 
+// CreateGroupRequest 创建群聊请求
+type CreateGroupRequest struct {
+	Name        string
+	Description string
+	Avatar      string
+	MemberIDs   []string // 初始成员（不含群主也可）
+	MaxMembers  int      // 0 表示使用默认上限
+}
+
 // Attachment 附件
 type Attachment struct {
 	Type     string `bson:"type" json:"type"`           // image, file
@@ -98,6 +98,54 @@ func (m *MockQuotaRepository) GetTotalConsumption(ctx context.Context, userID st
 	return args.Int(0), args.Error(1)
 }
 
+// DeductQuota mocks base method
+func (m *MockQuotaRepository) DeductQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, amount int) (*aiModels.UserQuota, error) {
+	args := m.Called(ctx, userID, quotaType, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*aiModels.UserQuota), args.Error(1)
+}
+
+// AdjustUsedQuota mocks base method
+func (m *MockQuotaRepository) AdjustUsedQuota(ctx context.Context, userID string, quotaType aiModels.QuotaType, delta int) (*aiModels.UserQuota, error) {
+	args := m.Called(ctx, userID, quotaType, delta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*aiModels.UserQuota), args.Error(1)
+}
+
+// CreateReservation mocks base method
+func (m *MockQuotaRepository) CreateReservation(ctx context.Context, reservation *aiModels.QuotaReservation) error {
+	args := m.Called(ctx, reservation)
+	return args.Error(0)
+}
+
+// GetReservation mocks base method
+func (m *MockQuotaRepository) GetReservation(ctx context.Context, reservationID string) (*aiModels.QuotaReservation, error) {
+	args := m.Called(ctx, reservationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*aiModels.QuotaReservation), args.Error(1)
+}
+
+// CloseReservation mocks base method
+func (m *MockQuotaRepository) CloseReservation(ctx context.Context, reservationID string, status aiModels.ReservationStatus, actualTokens, settledAmount int) (bool, error) {
+	args := m.Called(ctx, reservationID, status, actualTokens, settledAmount)
+	return args.Bool(0), args.Error(1)
+}
+
+// ListExpiredReservations mocks base method
+func (m *MockQuotaRepository) ListExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*aiModels.QuotaReservation, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*aiModels.QuotaReservation), args.Error(1)
+}
+
 // Health mocks base method
 func (m *MockQuotaRepository) Health(ctx context.Context) error {
 	args := m.Called(ctx)
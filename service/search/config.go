@@ -27,6 +27,8 @@ type SearchConfig struct {
 
 	// Elasticsearch 配置
 	ES ESConfig `yaml:"elasticsearch"`
+	// 向量搜索配置
+	Vector VectorConfig `yaml:"vector"`
 }
 
 // SearchIndicesConfig 索引配置（从 search_indices.yaml 加载）
@@ -325,3 +327,32 @@ type GrayScaleConfig struct {
 	// 灰度流量百分比(0-100)
 	Percent int `yaml:"percent"`
 }
+
+// VectorConfig 向量搜索配置
+type VectorConfig struct {
+	// 引擎：milvus | local（为空时使用 milvus）
+	Engine string `yaml:"engine"`
+	// 本地向量引擎配置
+	Local LocalVectorConfig `yaml:"local"`
+}
+
+// LocalVectorConfig 本地向量引擎配置
+type LocalVectorConfig struct {
+	// 检索模式：hnsw | exact
+	Mode string `yaml:"mode"`
+	// 相似度：cosine | ip
+	Metric string `yaml:"metric"`
+	// 文档中的向量字段，默认 title_vector
+	VectorField string `yaml:"vector_field"`
+	// HNSW 参数
+	M              int   `yaml:"m"`
+	EfConstruction int   `yaml:"ef_construction"`
+	EfSearch       int   `yaml:"ef_search"`
+	Seed           int64 `yaml:"seed"`
+	// 持久化方式：memory | file | mongo
+	Persistence string `yaml:"persistence"`
+	// 文件持久化目录
+	Path string `yaml:"path"`
+	// MongoDB 持久化集合名
+	Collection string `yaml:"collection"`
+}
@@ -12,6 +12,7 @@ const (
 	EngineElasticsearch EngineType = "elasticsearch"
 	EngineMilvus        EngineType = "milvus"
 	EngineMongoDB       EngineType = "mongodb"
+	EngineLocalVector   EngineType = "local_vector"
 )
 
 // Engine 搜索引擎接口
@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// hnswGraph 分层可导航小世界图（HNSW）
+// 相似度越大越接近；节点的删除由上层通过 accept 过滤，图结构本身只增不减
+type hnswGraph struct {
+	m              int // 上层每个节点的最大连接数
+	mMax0          int // 第 0 层的最大连接数
+	efConstruction int
+	levelMult      float64
+	rng            *rand.Rand
+	similarity     func(a, b []float32) float32
+
+	nodes    []*hnswNode
+	entry    int
+	maxLevel int
+}
+
+// hnswNode 图节点，friends[l] 为第 l 层的邻居
+type hnswNode struct {
+	vec     []float32
+	friends [][]int
+}
+
+// hnswCandidate 搜索候选
+type hnswCandidate struct {
+	id  int
+	sim float32
+}
+
+func newHNSWGraph(m, efConstruction int, seed int64, similarity func(a, b []float32) float32) *hnswGraph {
+	return &hnswGraph{
+		m:              m,
+		mMax0:          2 * m,
+		efConstruction: efConstruction,
+		levelMult:      1 / math.Log(float64(m)),
+		rng:            rand.New(rand.NewSource(seed)),
+		similarity:     similarity,
+		entry:          -1,
+	}
+}
+
+// insert 插入向量，返回节点ID（与插入顺序一致）
+func (g *hnswGraph) insert(vec []float32) int {
+	id := len(g.nodes)
+	level := int(math.Floor(-math.Log(1-g.rng.Float64()) * g.levelMult))
+	node := &hnswNode{vec: vec, friends: make([][]int, level+1)}
+	g.nodes = append(g.nodes, node)
+
+	if g.entry < 0 {
+		g.entry = id
+		g.maxLevel = level
+		return id
+	}
+
+	ep := g.entry
+	for l := g.maxLevel; l > level; l-- {
+		ep = g.greedyClosest(vec, ep, l)
+	}
+
+	entryPoints := []int{ep}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		found := g.searchLayer(vec, entryPoints, g.efConstruction, l, nil)
+		neighbors := g.selectNeighbors(found, g.m)
+		node.friends[l] = neighbors
+
+		for _, n := range neighbors {
+			g.connect(n, id, l)
+		}
+
+		entryPoints = entryPoints[:0]
+		for _, c := range found {
+			entryPoints = append(entryPoints, c.id)
+		}
+	}
+
+	if level > g.maxLevel {
+		g.entry = id
+		g.maxLevel = level
+	}
+	return id
+}
+
+// connect 为 from 增加指向 to 的边，超出上限时按启发式裁剪
+func (g *hnswGraph) connect(from, to, level int) {
+	node := g.nodes[from]
+	node.friends[level] = append(node.friends[level], to)
+
+	maxConn := g.m
+	if level == 0 {
+		maxConn = g.mMax0
+	}
+	if len(node.friends[level]) <= maxConn {
+		return
+	}
+
+	candidates := make([]hnswCandidate, 0, len(node.friends[level]))
+	for _, f := range node.friends[level] {
+		candidates = append(candidates, hnswCandidate{id: f, sim: g.similarity(node.vec, g.nodes[f].vec)})
+	}
+	sortCandidates(candidates)
+	node.friends[level] = g.selectNeighbors(candidates, maxConn)
+}
+
+// selectNeighbors 启发式选邻：优先保留彼此方向分散的邻居，不足时用被裁掉的候选补齐
+// candidates 需按相似度降序
+func (g *hnswGraph) selectNeighbors(candidates []hnswCandidate, m int) []int {
+	selected := make([]int, 0, m)
+	var pruned []int
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if g.similarity(g.nodes[c.id].vec, g.nodes[s].vec) > c.sim {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c.id)
+		} else {
+			pruned = append(pruned, c.id)
+		}
+	}
+	for _, id := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, id)
+	}
+	return selected
+}
+
+// greedyClosest 在单层上贪心移动到最相似的节点
+func (g *hnswGraph) greedyClosest(q []float32, ep, level int) int {
+	best := ep
+	bestSim := g.similarity(q, g.nodes[ep].vec)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range g.nodes[best].friends[level] {
+			if sim := g.similarity(q, g.nodes[f].vec); sim > bestSim {
+				best, bestSim, changed = f, sim, true
+			}
+		}
+	}
+	return best
+}
+
+// search 检索与 q 最相似的 k 个节点（按相似度降序）
+// accept 为 nil 时接受所有节点；不被接受的节点仍参与图遍历，保证过滤后的召回
+func (g *hnswGraph) search(q []float32, k, ef int, accept func(id int) bool) []hnswCandidate {
+	if g.entry < 0 || k <= 0 {
+		return nil
+	}
+	ep := g.entry
+	for l := g.maxLevel; l > 0; l-- {
+		ep = g.greedyClosest(q, ep, l)
+	}
+	found := g.searchLayer(q, []int{ep}, max(ef, k), 0, accept)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// searchLayer 单层 best-first 搜索，返回至多 ef 个被接受的节点（按相似度降序）
+func (g *hnswGraph) searchLayer(q []float32, entryPoints []int, ef, level int, accept func(id int) bool) []hnswCandidate {
+	visited := make(map[int]struct{}, ef*4)
+	candidates := &maxCandidateHeap{}
+	results := &minCandidateHeap{}
+
+	for _, ep := range entryPoints {
+		if _, ok := visited[ep]; ok {
+			continue
+		}
+		visited[ep] = struct{}{}
+		c := hnswCandidate{id: ep, sim: g.similarity(q, g.nodes[ep].vec)}
+		heap.Push(candidates, c)
+		if accept == nil || accept(ep) {
+			heap.Push(results, c)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.sim < (*results)[0].sim {
+			break
+		}
+
+		node := g.nodes[current.id]
+		if level >= len(node.friends) {
+			continue
+		}
+		for _, f := range node.friends[level] {
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+
+			c := hnswCandidate{id: f, sim: g.similarity(q, g.nodes[f].vec)}
+			if results.Len() < ef || c.sim > (*results)[0].sim {
+				heap.Push(candidates, c)
+				if accept == nil || accept(f) {
+					heap.Push(results, c)
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	found := make([]hnswCandidate, results.Len())
+	copy(found, *results)
+	sortCandidates(found)
+	return found
+}
+
+// sortCandidates 按相似度降序排列
+func sortCandidates(candidates []hnswCandidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].sim > candidates[j].sim
+	})
+}
+
+// maxCandidateHeap 相似度最大堆（待扩展的候选）
+type maxCandidateHeap []hnswCandidate
+
+func (h maxCandidateHeap) Len() int            { return len(h) }
+func (h maxCandidateHeap) Less(i, j int) bool  { return h[i].sim > h[j].sim }
+func (h maxCandidateHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxCandidateHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *maxCandidateHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// minCandidateHeap 相似度最小堆（当前结果集，堆顶为最差结果）
+type minCandidateHeap []hnswCandidate
+
+func (h minCandidateHeap) Len() int            { return len(h) }
+func (h minCandidateHeap) Less(i, j int) bool  { return h[i].sim < h[j].sim }
+func (h minCandidateHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minCandidateHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *minCandidateHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,581 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"Qingyu_backend/pkg/logger"
+)
+
+// 本地向量引擎的检索模式与相似度度量
+const (
+	VectorModeHNSW  = "hnsw"  // 近似检索（默认）
+	VectorModeExact = "exact" // 暴力精确检索
+
+	VectorMetricCosine       = "cosine" // 余弦相似度（默认）
+	VectorMetricInnerProduct = "ip"     // 内积
+)
+
+// 默认参数
+const (
+	defaultVectorField        = "title_vector"
+	defaultHNSWM              = 16
+	defaultHNSWEfConstruction = 200
+	defaultHNSWEfSearch       = 64
+	// 删除条目超过存活条目时重建图，避免已删除节点拖慢检索
+	compactDeletedRatio = 1.0
+)
+
+// LocalVectorConfig 本地向量引擎配置
+type LocalVectorConfig struct {
+	Mode           string // hnsw | exact
+	Metric         string // cosine | ip
+	VectorField    string // 文档中存放向量的字段，默认 title_vector（与 Milvus 引擎一致）
+	M              int    // HNSW 每层最大连接数
+	EfConstruction int    // HNSW 构建时的候选集大小
+	EfSearch       int    // HNSW 检索时的候选集大小
+	Seed           int64  // 层级随机种子，固定后图结构可复现
+}
+
+// LocalVectorEngine 进程内向量搜索引擎
+// 纯 Go 实现，无需 Milvus 即可在开发、CI 与小规模部署中使用向量检索；
+// 查询参数与 MilvusEngine 一致（[]float32 或 []float64），支持按元数据过滤
+type LocalVectorEngine struct {
+	cfg    LocalVectorConfig
+	store  VectorStore // 为 nil 时仅保存在内存中
+	logger *logger.Logger
+
+	mu      sync.RWMutex
+	indices map[string]*vectorIndex
+}
+
+// vectorIndex 单个索引的内存结构
+type vectorIndex struct {
+	dim     int
+	ids     []string // 节点ID -> 文档ID
+	vectors [][]float32
+	sources []map[string]interface{}
+	deleted []bool
+	byDocID map[string]int
+	live    int
+	graph   *hnswGraph // exact 模式下为 nil
+}
+
+// NewLocalVectorEngine 创建本地向量引擎
+func NewLocalVectorEngine(cfg LocalVectorConfig, store VectorStore) (*LocalVectorEngine, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = VectorModeHNSW
+	}
+	if cfg.Mode != VectorModeHNSW && cfg.Mode != VectorModeExact {
+		return nil, fmt.Errorf("unsupported vector mode: %s", cfg.Mode)
+	}
+	if cfg.Metric == "" {
+		cfg.Metric = VectorMetricCosine
+	}
+	if cfg.Metric != VectorMetricCosine && cfg.Metric != VectorMetricInnerProduct {
+		return nil, fmt.Errorf("unsupported vector metric: %s", cfg.Metric)
+	}
+	if cfg.VectorField == "" {
+		cfg.VectorField = defaultVectorField
+	}
+	if cfg.M <= 1 {
+		cfg.M = defaultHNSWM
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = defaultHNSWEfConstruction
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = defaultHNSWEfSearch
+	}
+
+	return &LocalVectorEngine{
+		cfg:     cfg,
+		store:   store,
+		logger:  logger.Get().WithModule("local-vector-engine"),
+		indices: make(map[string]*vectorIndex),
+	}, nil
+}
+
+// Search 执行向量搜索
+// query 参数应为 []float32 或 []float64 类型的向量；opts.Filter 按文档元数据精确匹配，值为切片时表示“属于其一”
+func (e *LocalVectorEngine) Search(ctx context.Context, index string, query interface{}, opts *SearchOptions) (*SearchResult, error) {
+	startTime := time.Now()
+
+	vector, err := toFloat32Vector(query)
+	if err != nil {
+		return nil, fmt.Errorf("local vector search: %w", err)
+	}
+
+	from, size := 0, 10
+	var filter map[string]interface{}
+	if opts != nil {
+		if opts.From > 0 {
+			from = opts.From
+		}
+		if opts.Size > 0 {
+			size = opts.Size
+		}
+		filter = opts.Filter
+	}
+
+	idx, err := e.getIndex(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if idx.live == 0 {
+		return &SearchResult{Hits: []Hit{}, Took: time.Since(startTime)}, nil
+	}
+	if len(vector) != idx.dim {
+		return nil, fmt.Errorf("local vector search: query dimension %d does not match index dimension %d", len(vector), idx.dim)
+	}
+	if e.cfg.Metric == VectorMetricCosine {
+		vector = normalizeVector(vector)
+	}
+
+	accept := func(id int) bool {
+		return !idx.deleted[id] && matchVectorFilter(idx.sources[id], filter)
+	}
+
+	k := from + size
+	var found []hnswCandidate
+	if idx.graph == nil {
+		found = exactSearch(idx.vectors, vector, k, accept)
+	} else {
+		found = idx.graph.search(vector, k, max(e.cfg.EfSearch, k), accept)
+	}
+
+	hits := make([]Hit, 0, size)
+	for i := from; i < len(found); i++ {
+		id := found[i].id
+		hits = append(hits, Hit{
+			ID:     idx.ids[id],
+			Score:  float64(found[i].sim),
+			Source: copySource(idx.sources[id]),
+		})
+	}
+
+	took := time.Since(startTime)
+	e.logger.Debug("Local vector search completed",
+		zap.String("index", index),
+		zap.String("mode", e.cfg.Mode),
+		zap.Int("k", k),
+		zap.Int("results", len(hits)),
+		zap.Duration("took", took),
+	)
+
+	return &SearchResult{
+		Total: int64(len(found)),
+		Hits:  hits,
+		Took:  took,
+	}, nil
+}
+
+// Index 批量索引向量，已存在的文档会被覆盖
+func (e *LocalVectorEngine) Index(ctx context.Context, index string, documents []Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	idx, err := e.getIndex(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	records := make([]VectorRecord, 0, len(documents))
+	for _, doc := range documents {
+		record, err := e.toRecord(doc)
+		if err != nil {
+			e.logger.Warn("Document missing vector, skipping",
+				zap.String("id", doc.ID),
+				zap.String("field", e.cfg.VectorField),
+			)
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no valid vectors to index")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	dim := idx.dim
+	for _, r := range records {
+		if dim == 0 {
+			dim = len(r.Vector)
+		}
+		if len(r.Vector) != dim {
+			return fmt.Errorf("vector dimension mismatch for %s: expected %d, got %d", r.ID, dim, len(r.Vector))
+		}
+	}
+
+	if e.store != nil {
+		if err := e.store.Upsert(ctx, index, records); err != nil {
+			return fmt.Errorf("local vector index persist failed: %w", err)
+		}
+	}
+
+	for _, r := range records {
+		e.add(idx, r)
+	}
+	e.maybeCompact(idx)
+	return nil
+}
+
+// Update 更新向量（删除旧节点后重新插入）
+func (e *LocalVectorEngine) Update(ctx context.Context, index string, id string, document Document) error {
+	if document.ID == "" {
+		document.ID = id
+	}
+	if document.ID != id {
+		return fmt.Errorf("document id %s does not match %s", document.ID, id)
+	}
+	return e.Index(ctx, index, []Document{document})
+}
+
+// Delete 删除向量
+func (e *LocalVectorEngine) Delete(ctx context.Context, index string, id string) error {
+	idx, err := e.getIndex(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := idx.byDocID[id]; !ok {
+		return fmt.Errorf("document %s not found in index %s", id, index)
+	}
+	if e.store != nil {
+		if err := e.store.Delete(ctx, index, []string{id}); err != nil {
+			return fmt.Errorf("local vector delete persist failed: %w", err)
+		}
+	}
+	e.remove(idx, id)
+	e.maybeCompact(idx)
+	return nil
+}
+
+// CreateIndex 创建索引（已存在时加载持久化数据）
+// mapping 可为 nil，或包含 "dimension" 以提前固定向量维度
+func (e *LocalVectorEngine) CreateIndex(ctx context.Context, index string, mapping interface{}) error {
+	idx, err := e.getIndex(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	if m, ok := mapping.(map[string]interface{}); ok {
+		if dim, ok := toInt(m["dimension"]); ok && dim > 0 {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if idx.dim != 0 && idx.dim != dim {
+				return fmt.Errorf("index %s already has dimension %d", index, idx.dim)
+			}
+			idx.dim = dim
+		}
+	}
+	return nil
+}
+
+// Health 健康检查（进程内引擎始终可用）
+func (e *LocalVectorEngine) Health(ctx context.Context) error {
+	return nil
+}
+
+// Count 返回索引中的有效文档数
+func (e *LocalVectorEngine) Count(ctx context.Context, index string) (int, error) {
+	idx, err := e.getIndex(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return idx.live, nil
+}
+
+// getIndex 获取索引，首次访问时从存储加载
+func (e *LocalVectorEngine) getIndex(ctx context.Context, index string) (*vectorIndex, error) {
+	if index == "" {
+		return nil, fmt.Errorf("index name cannot be empty")
+	}
+
+	e.mu.RLock()
+	idx, ok := e.indices[index]
+	e.mu.RUnlock()
+	if ok {
+		return idx, nil
+	}
+
+	var records []VectorRecord
+	if e.store != nil {
+		loaded, err := e.store.Load(ctx, index)
+		if err != nil {
+			return nil, fmt.Errorf("load vector index %s failed: %w", index, err)
+		}
+		records = loaded
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if idx, ok := e.indices[index]; ok {
+		return idx, nil
+	}
+	idx = e.newIndex()
+	for _, r := range records {
+		if idx.dim == 0 {
+			idx.dim = len(r.Vector)
+		}
+		if len(r.Vector) != idx.dim {
+			e.logger.Warn("Skipping persisted vector with mismatched dimension", zap.String("index", index), zap.String("id", r.ID))
+			continue
+		}
+		e.add(idx, r)
+	}
+	e.indices[index] = idx
+
+	if len(records) > 0 {
+		e.logger.Info("Local vector index loaded", zap.String("index", index), zap.Int("count", idx.live))
+	}
+	return idx, nil
+}
+
+func (e *LocalVectorEngine) newIndex() *vectorIndex {
+	idx := &vectorIndex{byDocID: make(map[string]int)}
+	if e.cfg.Mode == VectorModeHNSW {
+		idx.graph = newHNSWGraph(e.cfg.M, e.cfg.EfConstruction, e.cfg.Seed, dotProduct)
+	}
+	return idx
+}
+
+// add 插入条目（调用方持有写锁），已存在的文档先标记删除
+func (e *LocalVectorEngine) add(idx *vectorIndex, r VectorRecord) {
+	e.remove(idx, r.ID)
+
+	vec := append([]float32(nil), r.Vector...)
+	if e.cfg.Metric == VectorMetricCosine {
+		vec = normalizeVector(vec)
+	}
+	if idx.dim == 0 {
+		idx.dim = len(vec)
+	}
+
+	id := len(idx.ids)
+	idx.ids = append(idx.ids, r.ID)
+	idx.vectors = append(idx.vectors, vec)
+	idx.sources = append(idx.sources, r.Source)
+	idx.deleted = append(idx.deleted, false)
+	idx.byDocID[r.ID] = id
+	idx.live++
+	if idx.graph != nil {
+		idx.graph.insert(vec)
+	}
+}
+
+// remove 标记删除（调用方持有写锁）
+func (e *LocalVectorEngine) remove(idx *vectorIndex, docID string) {
+	id, ok := idx.byDocID[docID]
+	if !ok {
+		return
+	}
+	idx.deleted[id] = true
+	delete(idx.byDocID, docID)
+	idx.live--
+}
+
+// maybeCompact 已删除节点过多时重建索引（调用方持有写锁）
+func (e *LocalVectorEngine) maybeCompact(idx *vectorIndex) {
+	removed := len(idx.ids) - idx.live
+	if removed == 0 || float64(removed) <= compactDeletedRatio*float64(idx.live) {
+		return
+	}
+
+	rebuilt := e.newIndex()
+	rebuilt.dim = idx.dim
+	for id, docID := range idx.ids {
+		if idx.deleted[id] {
+			continue
+		}
+		rebuilt.ids = append(rebuilt.ids, docID)
+		rebuilt.vectors = append(rebuilt.vectors, idx.vectors[id])
+		rebuilt.sources = append(rebuilt.sources, idx.sources[id])
+		rebuilt.deleted = append(rebuilt.deleted, false)
+		rebuilt.byDocID[docID] = len(rebuilt.ids) - 1
+		rebuilt.live++
+		if rebuilt.graph != nil {
+			rebuilt.graph.insert(idx.vectors[id])
+		}
+	}
+	*idx = *rebuilt
+}
+
+// toRecord 从文档中拆出向量与元数据
+func (e *LocalVectorEngine) toRecord(doc Document) (VectorRecord, error) {
+	if doc.ID == "" {
+		return VectorRecord{}, fmt.Errorf("document id cannot be empty")
+	}
+	vector, err := toFloat32Vector(doc.Source[e.cfg.VectorField])
+	if err != nil {
+		return VectorRecord{}, err
+	}
+	if len(vector) == 0 {
+		return VectorRecord{}, fmt.Errorf("empty vector")
+	}
+
+	source := make(map[string]interface{}, len(doc.Source))
+	for k, v := range doc.Source {
+		if k != e.cfg.VectorField {
+			source[k] = v
+		}
+	}
+	return VectorRecord{ID: doc.ID, Vector: vector, Source: source}, nil
+}
+
+// exactSearch 暴力计算全部向量的相似度
+func exactSearch(vectors [][]float32, q []float32, k int, accept func(id int) bool) []hnswCandidate {
+	found := make([]hnswCandidate, 0, k)
+	for id, vec := range vectors {
+		if !accept(id) {
+			continue
+		}
+		found = append(found, hnswCandidate{id: id, sim: dotProduct(q, vec)})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].sim > found[j].sim
+	})
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// dotProduct 内积；向量已归一化时即为余弦相似度
+func dotProduct(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// normalizeVector 归一化为单位向量（零向量原样返回）
+func normalizeVector(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	inv := float32(1 / math.Sqrt(norm))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x * inv
+	}
+	return out
+}
+
+// toFloat32Vector 将查询或文档中的向量转为 []float32
+func toFloat32Vector(v interface{}) ([]float32, error) {
+	switch vec := v.(type) {
+	case []float32:
+		return vec, nil
+	case []float64:
+		out := make([]float32, len(vec))
+		for i, f := range vec {
+			out[i] = float32(f)
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]float32, len(vec))
+		for i, item := range vec {
+			switch f := item.(type) {
+			case float64:
+				out[i] = float32(f)
+			case float32:
+				out[i] = f
+			case int:
+				out[i] = float32(f)
+			default:
+				return nil, fmt.Errorf("invalid vector element type at index %d: %T", i, item)
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("vector must be []float32 or []float64, got %T", v)
+	}
+}
+
+// matchVectorFilter 元数据过滤：每个条件都需满足；条件值为切片时匹配其中任一值，
+// 文档字段为切片时（如标签）包含条件值即可
+func matchVectorFilter(source map[string]interface{}, filter map[string]interface{}) bool {
+	for key, want := range filter {
+		got, ok := source[key]
+		if !ok {
+			return false
+		}
+		if !filterValueMatches(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterValueMatches(got, want interface{}) bool {
+	wants := flattenFilterValue(want)
+	for _, g := range flattenFilterValue(got) {
+		for _, w := range wants {
+			if g == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flattenFilterValue 将标量或切片展开为可比较的字符串集合
+func flattenFilterValue(v interface{}) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		out := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, fmt.Sprint(rv.Index(i).Interface()))
+		}
+		return out
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+func copySource(source map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(source))
+	for k, v := range source {
+		out[k] = v
+	}
+	return out
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
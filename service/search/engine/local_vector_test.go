@@ -0,0 +1,259 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomVectorDocs 生成带 project_id 元数据的随机向量文档
+func randomVectorDocs(n, dim int, seed int64) []Document {
+	rng := rand.New(rand.NewSource(seed))
+	docs := make([]Document, n)
+	for i := range docs {
+		vec := make([]float32, dim)
+		for j := range vec {
+			vec[j] = rng.Float32()*2 - 1
+		}
+		docs[i] = Document{
+			ID: fmt.Sprintf("doc-%d", i),
+			Source: map[string]interface{}{
+				"title_vector": vec,
+				"project_id":   fmt.Sprintf("p%d", i%4),
+				"tags":         []string{"t" + fmt.Sprint(i%3)},
+			},
+		}
+	}
+	return docs
+}
+
+func newTestLocalEngine(t *testing.T, mode string, store VectorStore) *LocalVectorEngine {
+	t.Helper()
+	e, err := NewLocalVectorEngine(LocalVectorConfig{Mode: mode, Seed: 42}, store)
+	require.NoError(t, err)
+	return e
+}
+
+func hitIDs(result *SearchResult) []string {
+	ids := make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestLocalVectorEngine_HNSWRecall(t *testing.T) {
+	ctx := context.Background()
+	docs := randomVectorDocs(2000, 32, 1)
+
+	exact := newTestLocalEngine(t, VectorModeExact, nil)
+	approx := newTestLocalEngine(t, VectorModeHNSW, nil)
+	require.NoError(t, exact.Index(ctx, "books", docs))
+	require.NoError(t, approx.Index(ctx, "books", docs))
+
+	queries := randomVectorDocs(50, 32, 2)
+	const k = 10
+	matched := 0
+	for _, q := range queries {
+		vec := q.Source["title_vector"]
+		want, err := exact.Search(ctx, "books", vec, &SearchOptions{Size: k})
+		require.NoError(t, err)
+		got, err := approx.Search(ctx, "books", vec, &SearchOptions{Size: k})
+		require.NoError(t, err)
+		require.Len(t, got.Hits, k)
+
+		wantSet := make(map[string]bool, k)
+		for _, id := range hitIDs(want) {
+			wantSet[id] = true
+		}
+		for _, id := range hitIDs(got) {
+			if wantSet[id] {
+				matched++
+			}
+		}
+	}
+
+	recall := float64(matched) / float64(len(queries)*k)
+	assert.GreaterOrEqual(t, recall, 0.9, "HNSW recall@10 too low: %.3f", recall)
+}
+
+func TestLocalVectorEngine_CosineScore(t *testing.T) {
+	ctx := context.Background()
+	e := newTestLocalEngine(t, VectorModeExact, nil)
+	require.NoError(t, e.Index(ctx, "books", []Document{
+		{ID: "same", Source: map[string]interface{}{"title_vector": []float64{2, 0}}},
+		{ID: "orthogonal", Source: map[string]interface{}{"title_vector": []float64{0, 3}}},
+		{ID: "opposite", Source: map[string]interface{}{"title_vector": []float64{-1, 0}}},
+	}))
+
+	result, err := e.Search(ctx, "books", []float32{1, 0}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"same", "orthogonal", "opposite"}, hitIDs(result))
+	assert.InDelta(t, 1.0, result.Hits[0].Score, 1e-6)
+	assert.InDelta(t, 0.0, result.Hits[1].Score, 1e-6)
+	assert.InDelta(t, -1.0, result.Hits[2].Score, 1e-6)
+	assert.NotContains(t, result.Hits[0].Source, "title_vector")
+}
+
+func TestLocalVectorEngine_InnerProductScore(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewLocalVectorEngine(LocalVectorConfig{Mode: VectorModeExact, Metric: VectorMetricInnerProduct}, nil)
+	require.NoError(t, err)
+	require.NoError(t, e.Index(ctx, "books", []Document{
+		{ID: "long", Source: map[string]interface{}{"title_vector": []float32{3, 0}}},
+		{ID: "short", Source: map[string]interface{}{"title_vector": []float32{1, 0}}},
+	}))
+
+	result, err := e.Search(ctx, "books", []float32{2, 0}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"long", "short"}, hitIDs(result))
+	assert.InDelta(t, 6.0, result.Hits[0].Score, 1e-6)
+}
+
+func TestLocalVectorEngine_FilteredSearch(t *testing.T) {
+	ctx := context.Background()
+	docs := randomVectorDocs(500, 16, 3)
+
+	for _, mode := range []string{VectorModeHNSW, VectorModeExact} {
+		t.Run(mode, func(t *testing.T) {
+			e := newTestLocalEngine(t, mode, nil)
+			require.NoError(t, e.Index(ctx, "documents", docs))
+			query := docs[0].Source["title_vector"]
+
+			result, err := e.Search(ctx, "documents", query, &SearchOptions{
+				Size:   20,
+				Filter: map[string]interface{}{"project_id": "p1"},
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Hits, 20)
+			for _, hit := range result.Hits {
+				assert.Equal(t, "p1", hit.Source["project_id"])
+			}
+
+			// 条件值为切片时匹配其一；文档字段为切片时包含即可
+			result, err = e.Search(ctx, "documents", query, &SearchOptions{
+				Size:   20,
+				Filter: map[string]interface{}{"project_id": []string{"p1", "p2"}, "tags": "t0"},
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Hits)
+			for _, hit := range result.Hits {
+				assert.Contains(t, []string{"p1", "p2"}, hit.Source["project_id"])
+				assert.Equal(t, []string{"t0"}, hit.Source["tags"])
+			}
+		})
+	}
+}
+
+func TestLocalVectorEngine_Pagination(t *testing.T) {
+	ctx := context.Background()
+	e := newTestLocalEngine(t, VectorModeExact, nil)
+	docs := randomVectorDocs(30, 8, 4)
+	require.NoError(t, e.Index(ctx, "books", docs))
+	query := docs[5].Source["title_vector"]
+
+	all, err := e.Search(ctx, "books", query, &SearchOptions{Size: 10})
+	require.NoError(t, err)
+	page, err := e.Search(ctx, "books", query, &SearchOptions{From: 5, Size: 5})
+	require.NoError(t, err)
+	assert.Equal(t, hitIDs(all)[5:], hitIDs(page))
+	assert.Equal(t, "doc-5", all.Hits[0].ID)
+}
+
+func TestLocalVectorEngine_UpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	e := newTestLocalEngine(t, VectorModeHNSW, nil)
+	require.NoError(t, e.Index(ctx, "books", []Document{
+		{ID: "a", Source: map[string]interface{}{"title_vector": []float32{1, 0, 0}, "project_id": "p1"}},
+		{ID: "b", Source: map[string]interface{}{"title_vector": []float32{0, 1, 0}, "project_id": "p1"}},
+	}))
+
+	require.NoError(t, e.Update(ctx, "books", "a", Document{
+		Source: map[string]interface{}{"title_vector": []float32{0, 0, 1}, "project_id": "p2"},
+	}))
+	result, err := e.Search(ctx, "books", []float32{0, 0, 1}, &SearchOptions{Size: 1})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "a", result.Hits[0].ID)
+	assert.Equal(t, "p2", result.Hits[0].Source["project_id"])
+
+	count, err := e.Count(ctx, "books")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, e.Delete(ctx, "books", "a"))
+	result, err = e.Search(ctx, "books", []float32{0, 0, 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, hitIDs(result))
+	assert.Error(t, e.Delete(ctx, "books", "a"))
+
+	// 维度不一致的写入与查询会被拒绝
+	assert.Error(t, e.Index(ctx, "books", []Document{
+		{ID: "c", Source: map[string]interface{}{"title_vector": []float32{1, 0}}},
+	}))
+	_, err = e.Search(ctx, "books", []float32{1, 0}, nil)
+	assert.Error(t, err)
+}
+
+func TestLocalVectorEngine_CompactsAfterDeletes(t *testing.T) {
+	ctx := context.Background()
+	e := newTestLocalEngine(t, VectorModeHNSW, nil)
+	docs := randomVectorDocs(100, 8, 5)
+	require.NoError(t, e.Index(ctx, "books", docs))
+
+	for i := 0; i < 80; i++ {
+		require.NoError(t, e.Delete(ctx, "books", docs[i].ID))
+	}
+
+	idx, err := e.getIndex(ctx, "books")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(idx.ids), 2*idx.live)
+	assert.Equal(t, 20, idx.live)
+
+	result, err := e.Search(ctx, "books", docs[90].Source["title_vector"], &SearchOptions{Size: 1})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "doc-90", result.Hits[0].ID)
+}
+
+func TestLocalVectorEngine_FilePersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewFileVectorStore(dir)
+	require.NoError(t, err)
+	e := newTestLocalEngine(t, VectorModeHNSW, store)
+	docs := randomVectorDocs(50, 8, 6)
+	require.NoError(t, e.Index(ctx, "books", docs))
+	require.NoError(t, e.Delete(ctx, "books", "doc-3"))
+
+	// 新进程重新加载
+	reopened, err := NewFileVectorStore(dir)
+	require.NoError(t, err)
+	e2 := newTestLocalEngine(t, VectorModeHNSW, reopened)
+
+	count, err := e2.Count(ctx, "books")
+	require.NoError(t, err)
+	assert.Equal(t, 49, count)
+
+	result, err := e2.Search(ctx, "books", docs[7].Source["title_vector"], &SearchOptions{Size: 1})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "doc-7", result.Hits[0].ID)
+	assert.Equal(t, "p3", result.Hits[0].Source["project_id"])
+
+	result, err = e2.Search(ctx, "books", docs[3].Source["title_vector"], &SearchOptions{Size: 50})
+	require.NoError(t, err)
+	assert.NotContains(t, hitIDs(result), "doc-3")
+}
+
+func TestNewLocalVectorEngine_InvalidConfig(t *testing.T) {
+	_, err := NewLocalVectorEngine(LocalVectorConfig{Mode: "ivf"}, nil)
+	assert.Error(t, err)
+	_, err = NewLocalVectorEngine(LocalVectorConfig{Metric: "l2"}, nil)
+	assert.Error(t, err)
+}
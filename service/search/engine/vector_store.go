@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VectorRecord 持久化的向量条目
+type VectorRecord struct {
+	ID     string                 `json:"id" bson:"doc_id"`
+	Vector []float32              `json:"vector" bson:"vector"`
+	Source map[string]interface{} `json:"source,omitempty" bson:"source,omitempty"`
+}
+
+// VectorStore 本地向量引擎的持久化接口
+// 只保存原始向量与元数据，HNSW 图在加载时重建
+type VectorStore interface {
+	// Load 加载索引的全部条目
+	Load(ctx context.Context, index string) ([]VectorRecord, error)
+	// Upsert 写入或覆盖条目
+	Upsert(ctx context.Context, index string, records []VectorRecord) error
+	// Delete 删除条目
+	Delete(ctx context.Context, index string, ids []string) error
+}
+
+// ============ 文件存储 ============
+
+// FileVectorStore 以 JSON 文件保存向量，每个索引一个文件
+// 每次写入整体重写文件（临时文件 + 重命名），适合开发、CI 与小规模部署
+type FileVectorStore struct {
+	dir string
+
+	mu      sync.Mutex
+	indices map[string]map[string]VectorRecord
+	order   map[string][]string
+}
+
+// NewFileVectorStore 创建文件存储
+func NewFileVectorStore(dir string) (*FileVectorStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("vector store dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create vector store dir failed: %w", err)
+	}
+	return &FileVectorStore{
+		dir:     dir,
+		indices: make(map[string]map[string]VectorRecord),
+		order:   make(map[string][]string),
+	}, nil
+}
+
+var unsafeIndexChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+func (s *FileVectorStore) path(index string) string {
+	return filepath.Join(s.dir, unsafeIndexChars.ReplaceAllString(index, "_")+".vectors.json")
+}
+
+// load 读取索引文件到内存（调用方持有锁）
+func (s *FileVectorStore) load(index string) (map[string]VectorRecord, error) {
+	if records, ok := s.indices[index]; ok {
+		return records, nil
+	}
+
+	records := make(map[string]VectorRecord)
+	var order []string
+	data, err := os.ReadFile(s.path(index))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("read vector file failed: %w", err)
+	default:
+		var list []VectorRecord
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("parse vector file failed: %w", err)
+		}
+		for _, r := range list {
+			if _, exists := records[r.ID]; !exists {
+				order = append(order, r.ID)
+			}
+			records[r.ID] = r
+		}
+	}
+
+	s.indices[index] = records
+	s.order[index] = order
+	return records, nil
+}
+
+// flush 将索引写回文件（调用方持有锁）
+func (s *FileVectorStore) flush(index string) error {
+	records := s.indices[index]
+	list := make([]VectorRecord, 0, len(records))
+	for _, id := range s.order[index] {
+		if r, ok := records[id]; ok {
+			list = append(list, r)
+		}
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	tmp := s.path(index) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write vector file failed: %w", err)
+	}
+	return os.Rename(tmp, s.path(index))
+}
+
+// Load 加载索引的全部条目（保持写入顺序）
+func (s *FileVectorStore) Load(ctx context.Context, index string) ([]VectorRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load(index)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]VectorRecord, 0, len(records))
+	for _, id := range s.order[index] {
+		if r, ok := records[id]; ok {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// Upsert 写入或覆盖条目
+func (s *FileVectorStore) Upsert(ctx context.Context, index string, records []VectorRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load(index)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if _, ok := existing[r.ID]; !ok {
+			s.order[index] = append(s.order[index], r.ID)
+		}
+		existing[r.ID] = r
+	}
+	return s.flush(index)
+}
+
+// Delete 删除条目
+func (s *FileVectorStore) Delete(ctx context.Context, index string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load(index)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(existing, id)
+	}
+	order := s.order[index][:0]
+	for _, id := range s.order[index] {
+		if _, ok := existing[id]; ok {
+			order = append(order, id)
+		}
+	}
+	s.order[index] = order
+	return s.flush(index)
+}
+
+// ============ MongoDB 存储 ============
+
+// defaultVectorCollection 默认的向量集合名
+const defaultVectorCollection = "search_vectors"
+
+// MongoVectorStore 以 MongoDB 集合保存向量，所有索引共用一个集合
+type MongoVectorStore struct {
+	collection *mongo.Collection
+}
+
+// mongoVectorDoc MongoDB 中的向量文档
+type mongoVectorDoc struct {
+	Key          string `bson:"_id"` // index/doc_id
+	Index        string `bson:"index"`
+	VectorRecord `bson:",inline"`
+}
+
+// NewMongoVectorStore 创建 MongoDB 存储，collection 为空时使用 search_vectors
+func NewMongoVectorStore(database *mongo.Database, collection string) (*MongoVectorStore, error) {
+	if database == nil {
+		return nil, fmt.Errorf("MongoDB database cannot be nil")
+	}
+	if collection == "" {
+		collection = defaultVectorCollection
+	}
+	return &MongoVectorStore{collection: database.Collection(collection)}, nil
+}
+
+func mongoVectorKey(index, id string) string {
+	return index + "/" + id
+}
+
+// Load 加载索引的全部条目
+func (s *MongoVectorStore) Load(ctx context.Context, index string) ([]VectorRecord, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"index": index}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("load vectors failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoVectorDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode vectors failed: %w", err)
+	}
+	records := make([]VectorRecord, 0, len(docs))
+	for _, doc := range docs {
+		records = append(records, doc.VectorRecord)
+	}
+	return records, nil
+}
+
+// Upsert 写入或覆盖条目
+func (s *MongoVectorStore) Upsert(ctx context.Context, index string, records []VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(records))
+	for _, r := range records {
+		key := mongoVectorKey(index, r.ID)
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": key}).
+			SetReplacement(mongoVectorDoc{Key: key, Index: index, VectorRecord: r}).
+			SetUpsert(true))
+	}
+	if _, err := s.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("upsert vectors failed: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除条目
+func (s *MongoVectorStore) Delete(ctx context.Context, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, mongoVectorKey(index, id))
+	}
+	if _, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}}); err != nil {
+		return fmt.Errorf("delete vectors failed: %w", err)
+	}
+	return nil
+}
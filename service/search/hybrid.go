@@ -0,0 +1,383 @@
+package search
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"Qingyu_backend/models/search"
+	"Qingyu_backend/pkg/metrics"
+	searchengine "Qingyu_backend/service/search/engine"
+	"Qingyu_backend/service/search/provider"
+)
+
+// QueryEmbedder 查询文本向量化（EmbeddingClient 已实现该接口）
+type QueryEmbedder interface {
+	GetEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+const (
+	// defaultRRFK RRF 平滑常数，常用取值
+	defaultRRFK = 60
+	// hybridCandidateWindow 每一路至少召回的候选数
+	hybridCandidateWindow = 50
+	// maxHybridCandidates 每一路召回候选数上限
+	maxHybridCandidates = 200
+)
+
+// hybridVectorIndices 支持混合检索的类型及其向量索引名（与 VectorProvider 的默认集合一致）
+var hybridVectorIndices = map[search.SearchType]string{
+	search.SearchTypeBooks:     "qingyu_books",
+	search.SearchTypeDocuments: "qingyu_documents",
+}
+
+// hybridOwnerFields 按用户隔离的搜索类型及其归属字段
+// 向量引擎不一定支持元数据过滤，这些类型仅由向量召回的结果回表后必须属于请求中的 user_id
+var hybridOwnerFields = map[search.SearchType]string{
+	search.SearchTypeDocuments: "user_id",
+}
+
+// supportsHybrid 判断搜索类型是否支持混合检索
+func (s *SearchService) supportsHybrid(searchType search.SearchType) bool {
+	_, ok := hybridVectorIndices[searchType]
+	return ok
+}
+
+// normalizeHybridOptions 补全默认值
+// 两个权重都未设置（均为 0）时各取 1；只设置其一时另一路权重为 0，即关闭该路
+func normalizeHybridOptions(opts *search.HybridOptions) search.HybridOptions {
+	normalized := search.HybridOptions{}
+	if opts != nil {
+		normalized = *opts
+	}
+
+	normalized.Fusion = strings.ToLower(normalized.Fusion)
+	if normalized.Fusion != search.FusionWeighted {
+		normalized.Fusion = search.FusionRRF
+	}
+	normalized.KeywordWeight = math.Max(normalized.KeywordWeight, 0)
+	normalized.VectorWeight = math.Max(normalized.VectorWeight, 0)
+	if normalized.KeywordWeight == 0 && normalized.VectorWeight == 0 {
+		normalized.KeywordWeight = 1
+		normalized.VectorWeight = 1
+	}
+	if normalized.RRFK <= 0 {
+		normalized.RRFK = defaultRRFK
+	}
+	return normalized
+}
+
+// searchHybrid 混合检索：并发执行关键词与向量检索，按 RRF 或加权归一化融合并按 ID 去重
+// 任意一路失败时降级为另一路的结果，两路都失败才返回错误
+func (s *SearchService) searchHybrid(ctx context.Context, req *search.SearchRequest, useES bool) (*search.SearchResponse, error) {
+	start := time.Now()
+	opts := normalizeHybridOptions(req.Hybrid)
+
+	// Provider 级别验证（如文档搜索必须携带 user_id）
+	prov, provErr := s.GetProvider(req.Type)
+	if provErr == nil {
+		if err := prov.Validate(req); err != nil {
+			s.logger.Printf("[SearchService] Provider validation failed: %v", err)
+			return nil, fmt.Errorf("provider validation failed: %w", err)
+		}
+	}
+
+	cacheKey := s.generateHybridCacheKey(req, useES, opts)
+	if resp := s.getCachedResponse(ctx, cacheKey); resp != nil {
+		return resp, nil
+	}
+
+	window := min(max(req.Page*req.PageSize, hybridCandidateWindow), maxHybridCandidates)
+
+	var (
+		wg                        sync.WaitGroup
+		keywordItems, vectorItems []search.SearchItem
+		keywordErr, vectorErr     error
+	)
+	if opts.KeywordWeight > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keywordItems, keywordErr = s.searchKeywordCandidates(ctx, req, useES, window)
+		}()
+	}
+	if opts.VectorWeight > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vectorItems, vectorErr = s.searchVectorCandidates(ctx, req, window)
+		}()
+	}
+	wg.Wait()
+
+	if keywordErr != nil && vectorErr != nil {
+		s.logger.Printf("[SearchService] Hybrid search failed: keyword=%v, vector=%v", keywordErr, vectorErr)
+		return nil, search.WrapError(fmt.Errorf("keyword: %v; vector: %w", keywordErr, vectorErr), search.ErrCodeEngineFailure, "Hybrid search failed")
+	}
+	if keywordErr != nil {
+		s.logger.Printf("[SearchService] Hybrid keyword search failed, using vector results only: %v", keywordErr)
+	}
+	if vectorErr != nil {
+		s.logger.Printf("[SearchService] Hybrid vector search failed, using keyword results only: %v", vectorErr)
+	}
+
+	fused := fuseResults(keywordItems, vectorItems, opts)
+	if provErr == nil {
+		fused = s.hydrateVectorOnlyItems(ctx, req, prov, fused)
+	}
+
+	from := min((req.Page-1)*req.PageSize, len(fused))
+	to := min(from+req.PageSize, len(fused))
+	took := time.Since(start)
+
+	resp := &search.SearchResponse{
+		Success: true,
+		Data: &search.SearchData{
+			Type:     req.Type,
+			Total:    int64(len(fused)),
+			Page:     req.Page,
+			PageSize: req.PageSize,
+			Results:  fused[from:to],
+			Took:     took,
+		},
+	}
+
+	s.setCachedResponse(ctx, cacheKey, resp, s.calculateTTL(req))
+	metrics.RecordSearch("hybrid", took)
+
+	resp.Meta = &search.MetaInfo{
+		RequestID: s.generateRequestID(),
+		TookMs:    took.Milliseconds(),
+	}
+	s.logger.Printf("[SearchService] Hybrid search completed: type=%s, fusion=%s, query=%s, keyword=%d, vector=%d, fused=%d, took=%v", // codeql[go/log-injection]
+		req.Type, opts.Fusion, req.Query, len(keywordItems), len(vectorItems), len(fused), took)
+
+	return resp, nil
+}
+
+// searchKeywordCandidates 关键词召回（沿用 ES / Provider 路由），取前 window 条
+func (s *SearchService) searchKeywordCandidates(ctx context.Context, req *search.SearchRequest, useES bool, window int) ([]search.SearchItem, error) {
+	keywordReq := *req
+	keywordReq.Page = 1
+	keywordReq.PageSize = window
+	keywordReq.Hybrid = nil
+
+	var (
+		resp *search.SearchResponse
+		err  error
+	)
+	if useES && s.esEngine != nil {
+		resp, err = s.searchWithES(ctx, &keywordReq)
+	} else {
+		resp, err = s.searchWithProvider(ctx, &keywordReq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success || resp.Data == nil {
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return nil, fmt.Errorf("keyword search returned no data")
+	}
+	return resp.Data.Results, nil
+}
+
+// searchVectorCandidates 语义召回：查询文本向量化后在向量引擎中检索，过滤条件按元数据下推
+func (s *SearchService) searchVectorCandidates(ctx context.Context, req *search.SearchRequest, window int) ([]search.SearchItem, error) {
+	if s.vectorEngine == nil || s.queryEmbedder == nil {
+		return nil, fmt.Errorf("vector engine not configured")
+	}
+
+	vector, err := s.queryEmbedder.GetEmbedding(ctx, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query failed: %w", err)
+	}
+
+	result, err := s.vectorEngine.Search(ctx, hybridVectorIndices[req.Type], vector, &searchengine.SearchOptions{
+		Size:   window,
+		Filter: req.Filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+
+	items := make([]search.SearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if item := s.convertHitToItem(hit, req.Type); item != nil {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
+// fuseResults 融合两路结果并按 ID 去重，返回按融合分数降序的列表
+// rrf: score = Σ w / (k + rank)；weighted: 每路分数 min-max 归一化后加权求和
+// 同时命中两路时保留关键词结果的数据与高亮
+func fuseResults(keywordItems, vectorItems []search.SearchItem, opts search.HybridOptions) []search.SearchItem {
+	byID := make(map[string]*search.SearchItem, len(keywordItems)+len(vectorItems))
+	order := make([]string, 0, len(keywordItems)+len(vectorItems))
+
+	collect := func(items []search.SearchItem, weight float64, isKeyword bool) {
+		normalized := normalizeScores(items)
+		for i, item := range items {
+			rank := i + 1
+			fused, ok := byID[item.ID]
+			if !ok {
+				entry := item
+				entry.Explain = &search.HybridExplain{Fusion: opts.Fusion}
+				fused = &entry
+				byID[item.ID] = fused
+				order = append(order, item.ID)
+			} else if fused.Data == nil {
+				fused.Data = item.Data
+			}
+
+			explain := fused.Explain
+			if isKeyword {
+				if explain.KeywordRank != 0 {
+					continue // 同一路中重复出现的 ID 只计最靠前的一次
+				}
+				explain.KeywordRank = rank
+				explain.KeywordScore = item.Score
+			} else {
+				if explain.VectorRank != 0 {
+					continue
+				}
+				explain.VectorRank = rank
+				explain.VectorScore = item.Score
+			}
+
+			if opts.Fusion == search.FusionWeighted {
+				explain.FusedScore += weight * normalized[i]
+			} else {
+				explain.FusedScore += weight / float64(opts.RRFK+rank)
+			}
+		}
+	}
+	collect(keywordItems, opts.KeywordWeight, true)
+	collect(vectorItems, opts.VectorWeight, false)
+
+	fused := make([]search.SearchItem, 0, len(order))
+	for _, id := range order {
+		item := byID[id]
+		item.Score = item.Explain.FusedScore
+		fused = append(fused, *item)
+	}
+	// 稳定排序：分数相同时关键词结果在前
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	return fused
+}
+
+// normalizeScores min-max 归一化到 [0, 1]，分数全部相同时均记为 1
+func normalizeScores(items []search.SearchItem) []float64 {
+	normalized := make([]float64, len(items))
+	if len(items) == 0 {
+		return normalized
+	}
+	lo, hi := items[0].Score, items[0].Score
+	for _, item := range items {
+		lo = math.Min(lo, item.Score)
+		hi = math.Max(hi, item.Score)
+	}
+	for i, item := range items {
+		if hi == lo {
+			normalized[i] = 1
+		} else {
+			normalized[i] = (item.Score - lo) / (hi - lo)
+		}
+	}
+	return normalized
+}
+
+// hydrateVectorOnlyItems 仅由向量召回的结果通过 Provider 回表：
+// 补全文档数据，并套用 Provider 的可见性规则（如书籍状态、私密设置），回表查不到的结果被剔除；
+// 按用户隔离的类型还会剔除不属于请求用户的结果
+func (s *SearchService) hydrateVectorOnlyItems(ctx context.Context, req *search.SearchRequest, prov provider.Provider, items []search.SearchItem) []search.SearchItem {
+	var ids []string
+	for _, item := range items {
+		if item.Explain != nil && item.Explain.KeywordRank == 0 {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return items
+	}
+
+	loaded, err := prov.GetBatch(ctx, ids)
+	if err != nil {
+		// 无法确认可见性时宁可丢弃，避免泄露不可见的内容
+		s.logger.Printf("[SearchService] Hydrate vector results failed, dropping %d vector-only hits: %v", len(ids), err)
+		loaded = nil
+	}
+	ownerField, ownerScoped := hybridOwnerFields[req.Type]
+	ownerID, _ := req.Filter["user_id"].(string)
+	dataByID := make(map[string]map[string]interface{}, len(loaded))
+	for _, item := range loaded {
+		if ownerScoped && (ownerID == "" || fmt.Sprint(item.Data[ownerField]) != ownerID) {
+			continue
+		}
+		dataByID[item.ID] = item.Data
+	}
+
+	kept := items[:0]
+	for _, item := range items {
+		if item.Explain != nil && item.Explain.KeywordRank == 0 {
+			data, ok := dataByID[item.ID]
+			if !ok {
+				continue
+			}
+			item.Data = data
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// generateHybridCacheKey 混合检索缓存键，包含融合参数
+func (s *SearchService) generateHybridCacheKey(req *search.SearchRequest, useES bool, opts search.HybridOptions) string {
+	optsData, _ := json.Marshal(opts)
+	base := strings.TrimPrefix(s.generateCacheKeyWithEngine(req, useES), "search:")
+	return fmt.Sprintf("search:hybrid:%s:%x", base, md5.Sum(optsData))
+}
+
+// getCachedResponse 读取缓存的响应，未启用缓存或未命中时返回 nil
+func (s *SearchService) getCachedResponse(ctx context.Context, key string) *search.SearchResponse {
+	if !s.config.EnableCache || s.cache == nil {
+		return nil
+	}
+	data, err := s.cache.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil
+	}
+	resp := &search.SearchResponse{}
+	if err := s.unmarshalResponse(data, resp); err != nil || resp.Data == nil {
+		return nil
+	}
+	s.logger.Printf("[SearchService] Cache hit for key: %s", key) // codeql[go/log-injection]
+	resp.Meta = &search.MetaInfo{RequestID: s.generateRequestID()}
+	resp.Data.Took = 0
+	return resp
+}
+
+// setCachedResponse 写入缓存
+func (s *SearchService) setCachedResponse(ctx context.Context, key string, resp *search.SearchResponse, ttl time.Duration) {
+	if !s.config.EnableCache || s.cache == nil || !resp.Success || resp.Data == nil {
+		return
+	}
+	data, err := s.marshalResponse(resp)
+	if err != nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, data, ttl); err != nil {
+		s.logger.Printf("[SearchService] Failed to set cache: %v", err)
+	}
+}
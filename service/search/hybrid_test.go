@@ -0,0 +1,362 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Qingyu_backend/models/search"
+	searchengine "Qingyu_backend/service/search/engine"
+)
+
+// stubKeywordProvider 按固定顺序返回关键词结果，GetBatch 只返回 visible 中的文档，owners 指定文档归属用户
+type stubKeywordProvider struct {
+	searchType search.SearchType
+	results    []search.SearchItem
+	visible    map[string]bool
+	owners     map[string]string
+	calls      int
+	mu         sync.Mutex
+}
+
+func (p *stubKeywordProvider) Search(ctx context.Context, req *search.SearchRequest) (*search.SearchResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	results := p.results
+	if len(results) > req.PageSize {
+		results = results[:req.PageSize]
+	}
+	return &search.SearchResponse{
+		Success: true,
+		Data:    &search.SearchData{Type: p.searchType, Total: int64(len(p.results)), Results: results},
+	}, nil
+}
+
+func (p *stubKeywordProvider) Type() search.SearchType { return p.searchType }
+
+func (p *stubKeywordProvider) Validate(req *search.SearchRequest) error {
+	if p.searchType == search.SearchTypeDocuments && req.Filter["user_id"] == nil {
+		return fmt.Errorf("user_id is required for document search")
+	}
+	return nil
+}
+
+func (p *stubKeywordProvider) GetByID(ctx context.Context, id string) (*search.SearchItem, error) {
+	return nil, search.ErrDocumentNotFound
+}
+
+func (p *stubKeywordProvider) GetBatch(ctx context.Context, ids []string) ([]search.SearchItem, error) {
+	var items []search.SearchItem
+	for _, id := range ids {
+		if p.visible[id] {
+			data := map[string]interface{}{"title": "hydrated " + id}
+			if owner, ok := p.owners[id]; ok {
+				data["user_id"] = owner
+			}
+			items = append(items, search.SearchItem{ID: id, Data: data})
+		}
+	}
+	return items, nil
+}
+
+// stubEmbedder 将查询映射为固定向量
+type stubEmbedder struct {
+	vectors map[string][]float32
+}
+
+func (e *stubEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vec, ok := e.vectors[text]
+	if !ok {
+		return nil, fmt.Errorf("no embedding for %q", text)
+	}
+	return vec, nil
+}
+
+// memoryCache 内存缓存
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryCache() *memoryCache { return &memoryCache{data: make(map[string][]byte)} }
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.data[key]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("cache miss")
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *memoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := strings.TrimSuffix(pattern, "*")
+	for k := range c.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.data, k)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok, nil
+}
+
+func (c *memoryCache) Clear(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = make(map[string][]byte)
+	return nil
+}
+
+func (c *memoryCache) Ping(ctx context.Context) error { return nil }
+func (c *memoryCache) Close() error                   { return nil }
+
+func ids(items []search.SearchItem) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return out
+}
+
+func keywordItems(idList ...string) []search.SearchItem {
+	items := make([]search.SearchItem, 0, len(idList))
+	for i, id := range idList {
+		items = append(items, search.SearchItem{ID: id, Score: float64(10 - i), Data: map[string]interface{}{"title": "kw " + id}})
+	}
+	return items
+}
+
+// setupHybridService 关键词结果 a,b,c；向量结果（按与查询的相似度）c,d,hidden,a，其中 hidden 回表不可见
+func setupHybridService(t *testing.T, enableCache bool) (*SearchService, *stubKeywordProvider) {
+	t.Helper()
+
+	svc := NewSearchService(log.New(io.Discard, "", 0), &Config{
+		EnableCache:           enableCache,
+		DefaultCacheTTL:       60,
+		MaxConcurrentSearches: 4,
+	}, nil)
+	if enableCache {
+		svc.SetCache(newMemoryCache())
+	}
+
+	prov := &stubKeywordProvider{
+		searchType: search.SearchTypeBooks,
+		results:    keywordItems("a", "b", "c"),
+		visible:    map[string]bool{"d": true},
+	}
+	svc.RegisterProvider(prov)
+
+	vectorEngine, err := searchengine.NewLocalVectorEngine(searchengine.LocalVectorConfig{Mode: searchengine.VectorModeExact}, nil)
+	require.NoError(t, err)
+	require.NoError(t, vectorEngine.Index(context.Background(), "qingyu_books", []searchengine.Document{
+		{ID: "c", Source: map[string]interface{}{"title_vector": []float32{1, 0, 0}, "title": "vec c"}},
+		{ID: "d", Source: map[string]interface{}{"title_vector": []float32{0.9, 0.1, 0}, "title": "vec d"}},
+		{ID: "a", Source: map[string]interface{}{"title_vector": []float32{0.5, 0.5, 0}, "title": "vec a"}},
+		{ID: "hidden", Source: map[string]interface{}{"title_vector": []float32{0.8, 0.2, 0}, "title": "vec hidden"}},
+	}))
+	svc.SetVectorEngine(vectorEngine, &stubEmbedder{vectors: map[string][]float32{"剑": {1, 0, 0}}})
+	return svc, prov
+}
+
+func TestFuseResults_RRF(t *testing.T) {
+	opts := normalizeHybridOptions(nil)
+	vector := []search.SearchItem{{ID: "c", Score: 0.9}, {ID: "d", Score: 0.8}, {ID: "a", Score: 0.1}}
+
+	fused := fuseResults(keywordItems("a", "b", "c"), vector, opts)
+
+	// a: 1/61+1/63, c: 1/63+1/61, b: 1/62, d: 1/62 —— 并列时关键词结果在前
+	require.Equal(t, []string{"a", "c", "b", "d"}, ids(fused))
+	assert.Equal(t, search.FusionRRF, fused[0].Explain.Fusion)
+	assert.Equal(t, 1, fused[0].Explain.KeywordRank)
+	assert.Equal(t, 3, fused[0].Explain.VectorRank)
+	assert.InDelta(t, 1.0/61+1.0/63, fused[0].Score, 1e-9)
+	assert.Equal(t, "kw a", fused[0].Data["title"])
+
+	d := fused[3]
+	assert.Equal(t, 0, d.Explain.KeywordRank)
+	assert.Equal(t, 2, d.Explain.VectorRank)
+	assert.InDelta(t, 0.8, d.Explain.VectorScore, 1e-9)
+}
+
+func TestFuseResults_WeightedWithPerRequestWeights(t *testing.T) {
+	opts := normalizeHybridOptions(&search.HybridOptions{Fusion: "Weighted", KeywordWeight: 0.2, VectorWeight: 0.8})
+	vector := []search.SearchItem{{ID: "c", Score: 0.9}, {ID: "d", Score: 0.5}, {ID: "a", Score: 0.1}}
+
+	fused := fuseResults(keywordItems("a", "b", "c"), vector, opts)
+
+	// 归一化后 keyword: a=1,b=0.5,c=0；vector: c=1,d=0.5,a=0
+	require.Equal(t, []string{"c", "d", "a", "b"}, ids(fused))
+	assert.InDelta(t, 0.8, fused[0].Score, 1e-9)
+	assert.InDelta(t, 0.4, fused[1].Score, 1e-9)
+	assert.InDelta(t, 0.2, fused[2].Score, 1e-9)
+	assert.InDelta(t, 0.1, fused[3].Score, 1e-9)
+	assert.Equal(t, search.FusionWeighted, fused[0].Explain.Fusion)
+}
+
+func TestNormalizeHybridOptions(t *testing.T) {
+	opts := normalizeHybridOptions(&search.HybridOptions{Fusion: "unknown", KeywordWeight: -1})
+	assert.Equal(t, search.FusionRRF, opts.Fusion)
+	assert.Equal(t, 1.0, opts.KeywordWeight)
+	assert.Equal(t, 1.0, opts.VectorWeight)
+	assert.Equal(t, defaultRRFK, opts.RRFK)
+
+	// 只设置一路权重时另一路关闭
+	opts = normalizeHybridOptions(&search.HybridOptions{VectorWeight: 2})
+	assert.Equal(t, 0.0, opts.KeywordWeight)
+	assert.Equal(t, 2.0, opts.VectorWeight)
+}
+
+func TestSearchService_HybridSearch(t *testing.T) {
+	svc, _ := setupHybridService(t, false)
+
+	resp, err := svc.Search(context.Background(), &search.SearchRequest{
+		Type:   search.SearchTypeBooks,
+		Query:  "剑",
+		Hybrid: &search.HybridOptions{},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	// hidden 只被向量召回且回表不可见，被剔除；d 回表后使用 Provider 数据
+	// b、d 分数相同（各 1/62），关键词结果在前
+	require.Equal(t, []string{"c", "a", "b", "d"}, ids(resp.Data.Results))
+	assert.EqualValues(t, 4, resp.Data.Total)
+	for _, item := range resp.Data.Results {
+		require.NotNil(t, item.Explain)
+	}
+	d := resp.Data.Results[3]
+	assert.Equal(t, "hydrated d", d.Data["title"])
+	assert.Equal(t, 0, d.Explain.KeywordRank)
+	assert.Equal(t, 2, d.Explain.VectorRank)
+
+	// 分页
+	resp, err = svc.Search(context.Background(), &search.SearchRequest{
+		Type:     search.SearchTypeBooks,
+		Query:    "剑",
+		Page:     2,
+		PageSize: 3,
+		Hybrid:   &search.HybridOptions{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids(resp.Data.Results))
+}
+
+func TestSearchService_HybridDegradesToKeyword(t *testing.T) {
+	svc, _ := setupHybridService(t, false)
+
+	// 查询无法向量化时仅返回关键词结果
+	resp, err := svc.Search(context.Background(), &search.SearchRequest{
+		Type:   search.SearchTypeBooks,
+		Query:  "刀",
+		Hybrid: &search.HybridOptions{Fusion: search.FusionWeighted},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	assert.Equal(t, []string{"a", "b", "c"}, ids(resp.Data.Results))
+	assert.Equal(t, 0, resp.Data.Results[0].Explain.VectorRank)
+}
+
+func TestSearchService_HybridRequiresProviderValidation(t *testing.T) {
+	svc, _ := setupHybridService(t, false)
+	svc.RegisterProvider(&stubKeywordProvider{searchType: search.SearchTypeDocuments})
+
+	_, err := svc.Search(context.Background(), &search.SearchRequest{
+		Type:   search.SearchTypeDocuments,
+		Query:  "剑",
+		Hybrid: &search.HybridOptions{},
+	})
+	assert.Error(t, err)
+}
+
+func TestSearchService_HybridCached(t *testing.T) {
+	svc, prov := setupHybridService(t, true)
+	req := func(weight float64) *search.SearchRequest {
+		return &search.SearchRequest{
+			Type:   search.SearchTypeBooks,
+			Query:  "剑",
+			Hybrid: &search.HybridOptions{KeywordWeight: weight, VectorWeight: 1},
+		}
+	}
+
+	first, err := svc.Search(context.Background(), req(1))
+	require.NoError(t, err)
+	second, err := svc.Search(context.Background(), req(1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, prov.calls)
+	assert.Equal(t, ids(first.Data.Results), ids(second.Data.Results))
+	require.NotNil(t, second.Data.Results[0].Explain)
+	assert.Equal(t, first.Data.Results[0].Explain.KeywordRank, second.Data.Results[0].Explain.KeywordRank)
+
+	// 融合权重不同则使用不同缓存键
+	_, err = svc.Search(context.Background(), req(3))
+	require.NoError(t, err)
+	assert.Equal(t, 2, prov.calls)
+}
+
+// filterIgnoringEngine 忽略元数据过滤的向量引擎（与 Milvus 引擎行为一致）
+type filterIgnoringEngine struct {
+	searchengine.Engine
+}
+
+func (e filterIgnoringEngine) Search(ctx context.Context, index string, query interface{}, opts *searchengine.SearchOptions) (*searchengine.SearchResult, error) {
+	return e.Engine.Search(ctx, index, query, &searchengine.SearchOptions{Size: opts.Size})
+}
+
+func TestSearchService_HybridDropsOtherUsersDocuments(t *testing.T) {
+	svc, _ := setupHybridService(t, false)
+	svc.RegisterProvider(&stubKeywordProvider{
+		searchType: search.SearchTypeDocuments,
+		results:    keywordItems("mine-kw"),
+		visible:    map[string]bool{"mine": true, "theirs": true},
+		owners:     map[string]string{"mine": "u1", "theirs": "u2"},
+	})
+
+	vectorEngine, err := searchengine.NewLocalVectorEngine(searchengine.LocalVectorConfig{Mode: searchengine.VectorModeExact}, nil)
+	require.NoError(t, err)
+	require.NoError(t, vectorEngine.Index(context.Background(), "qingyu_documents", []searchengine.Document{
+		{ID: "theirs", Source: map[string]interface{}{"title_vector": []float32{1, 0, 0}}},
+		{ID: "mine", Source: map[string]interface{}{"title_vector": []float32{0.9, 0.1, 0}}},
+	}))
+	svc.SetVectorEngine(filterIgnoringEngine{vectorEngine}, &stubEmbedder{vectors: map[string][]float32{"剑": {1, 0, 0}}})
+
+	// 向量引擎未按 user_id 过滤，其他用户的文档回表后被剔除
+	resp, err := svc.Search(context.Background(), &search.SearchRequest{
+		Type:   search.SearchTypeDocuments,
+		Query:  "剑",
+		Filter: map[string]interface{}{"user_id": "u1"},
+		Hybrid: &search.HybridOptions{},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"mine-kw", "mine"}, ids(resp.Data.Results))
+}
@@ -14,6 +14,7 @@ import (
 	"Qingyu_backend/service/search/cache"
 	searchengine "Qingyu_backend/service/search/engine"
 	"Qingyu_backend/service/search/provider"
+	"Qingyu_backend/service/search/suggest"
 )
 
 // SearchService 统一搜索服务
@@ -28,6 +29,13 @@ type SearchService struct {
 	esEngine    searchengine.Engine // ES 引擎（如果启用）
 	mongoEngine searchengine.Engine // MongoDB 引擎（fallback）
 
+	// 混合检索相关
+	vectorEngine  searchengine.Engine // 向量引擎（Milvus 或本地向量引擎）
+	queryEmbedder QueryEmbedder       // 查询文本向量化
+
+	// 搜索联想
+	suggester *suggest.Suggester
+
 	// 灰度决策
 	grayscaleDecision GrayScaleDecision // 灰度决策器
 }
@@ -111,6 +119,28 @@ func (s *SearchService) SetMongoEngine(engine searchengine.Engine) {
 	s.mongoEngine = engine
 }
 
+// SetVectorEngine 设置向量引擎与查询向量化客户端（启用混合检索）
+func (s *SearchService) SetVectorEngine(engine searchengine.Engine, embedder QueryEmbedder) {
+	s.vectorEngine = engine
+	s.queryEmbedder = embedder
+}
+
+// SetSuggester 设置搜索联想服务
+func (s *SearchService) SetSuggester(suggester *suggest.Suggester) {
+	s.suggester = suggester
+}
+
+// Suggest 搜索联想（书名、作者、标签及当前用户的文档）
+func (s *SearchService) Suggest(ctx context.Context, req *suggest.Request) (*suggest.Result, error) {
+	if s.suggester == nil {
+		return nil, fmt.Errorf("suggester not initialized")
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request is nil")
+	}
+	return s.suggester.Suggest(ctx, req)
+}
+
 // shouldUseES 判断是否应该使用 ES（灰度逻辑）
 func (s *SearchService) shouldUseES(ctx context.Context, searchType search.SearchType, userID string) bool {
 	// 如果 ES 未启用，使用 MongoDB
@@ -289,6 +319,11 @@ func (s *SearchService) Search(ctx context.Context, req *search.SearchRequest) (
 	// 记录灰度决策
 	metrics.RecordGrayscaleDecision(string(req.Type), useES)
 
+	// 混合检索走独立流程（同样经过缓存）
+	if req.Hybrid != nil && s.supportsHybrid(req.Type) {
+		return s.searchHybrid(ctx, req, useES)
+	}
+
 	// 4. 生成缓存键（基于是否使用 ES）
 	cacheKey := s.generateCacheKeyWithEngine(req, useES)
 
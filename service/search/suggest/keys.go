@@ -0,0 +1,141 @@
+package suggest
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// MatchType 联想命中方式
+type MatchType string
+
+const (
+	MatchPrefix   MatchType = "prefix"   // 原文前缀
+	MatchPinyin   MatchType = "pinyin"   // 全拼前缀，如 doupo -> 斗破苍穹
+	MatchInitials MatchType = "initials" // 首字母前缀，如 dpcq -> 斗破苍穹
+)
+
+// matchRank 同一条目多种方式命中时取最优的一种
+var matchRank = map[MatchType]int{
+	MatchPrefix:   0,
+	MatchPinyin:   1,
+	MatchInitials: 2,
+}
+
+// entryKey 条目在字典树中的一个索引键
+type entryKey struct {
+	key   string
+	match MatchType
+}
+
+// heteronymArgs 返回多音字的全部读音（不带声调）
+var heteronymArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Heteronym = true
+	return args
+}()
+
+// maxPinyinVariants 多音字组合出的拼音写法上限，超出部分只取首个读音
+const maxPinyinVariants = 8
+
+// normalize 归一化文本：转小写，去掉空白与标点，只保留字母、数字与汉字
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// suggestKeys 生成文本的索引键：原文、全拼与首字母，依次按命中方式从优到劣排列
+// 非汉字的连续字母数字作为一个音节整体保留（首字母取其第一个字符）；
+// 多音字按读音组合出多种写法（如 重生 -> zhongsheng、chongsheng），不含汉字时只生成原文键
+func suggestKeys(text string) []entryKey {
+	plain := normalize(text)
+	if plain == "" {
+		return nil
+	}
+	keys := []entryKey{{key: plain, match: MatchPrefix}}
+
+	var syllables [][]string
+	hasHan := false
+	var word strings.Builder
+	flushWord := func() {
+		if word.Len() > 0 {
+			syllables = append(syllables, []string{word.String()})
+			word.Reset()
+		}
+	}
+	for _, r := range plain {
+		if !unicode.Is(unicode.Han, r) {
+			word.WriteRune(r)
+			continue
+		}
+		flushWord()
+		readings := uniqueReadings(pinyin.SinglePinyin(r, heteronymArgs))
+		if len(readings) == 0 {
+			continue
+		}
+		hasHan = true
+		syllables = append(syllables, readings)
+	}
+	flushWord()
+	if !hasHan {
+		return keys
+	}
+
+	seen := map[string]bool{plain: true}
+	var initialKeys []entryKey
+	for _, variant := range expandReadings(syllables, maxPinyinVariants) {
+		full := strings.Join(variant, "")
+		if !seen[full] {
+			seen[full] = true
+			keys = append(keys, entryKey{key: full, match: MatchPinyin})
+		}
+
+		var initials strings.Builder
+		for _, syllable := range variant {
+			r, _ := utf8.DecodeRuneInString(syllable)
+			initials.WriteRune(r)
+		}
+		if i := initials.String(); !seen[i] {
+			seen[i] = true
+			initialKeys = append(initialKeys, entryKey{key: i, match: MatchInitials})
+		}
+	}
+	return append(keys, initialKeys...)
+}
+
+// uniqueReadings 去掉去声调后重复的读音
+func uniqueReadings(readings []string) []string {
+	out := readings[:0]
+	for _, r := range readings {
+		if r != "" && !slices.Contains(out, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// expandReadings 按音节组合读音，组合数超过 limit 后其余音节只取首个读音
+func expandReadings(syllables [][]string, limit int) [][]string {
+	variants := [][]string{nil}
+	for _, readings := range syllables {
+		if len(variants)*len(readings) > limit {
+			readings = readings[:1]
+		}
+		next := make([][]string, 0, len(variants)*len(readings))
+		for _, v := range variants {
+			for _, r := range readings {
+				next = append(next, append(slices.Clone(v), r))
+			}
+		}
+		variants = next
+	}
+	return variants
+}
@@ -0,0 +1,170 @@
+package suggest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// redisPipelineBatch 每批写入 Redis 的键数
+const redisPipelineBatch = 1000
+
+// redisKey 兜底数据键：search:suggest:{kind}:{scope}:{prefix}
+func redisKey(kind Kind, scope, prefix string) string {
+	return fmt.Sprintf("search:suggest:%s:%s:%s", kind, scope, prefix)
+}
+
+// payloads 计算变更键各级前缀（不超过 depth）当前的联想结果，值为 nil 表示该前缀已无结果需删除
+func (idx *index) payloads(touched touchedKeys, depth, limit int) map[string][]Suggestion {
+	out := make(map[string][]Suggestion)
+	for st, keys := range touched {
+		trie := idx.trie(st.kind, st.scope)
+		for key := range keys {
+			runes := []rune(key)
+			for n := 1; n <= min(depth, len(runes)); n++ {
+				prefix := string(runes[:n])
+				redisK := redisKey(st.kind, st.scope, prefix)
+				if _, done := out[redisK]; done {
+					continue
+				}
+				var items []Suggestion
+				if trie != nil {
+					if refs := trie.lookup(prefix, limit); len(refs) > 0 {
+						items = idx.suggestions(st.kind, trie, refs)
+					}
+				}
+				out[redisK] = items
+			}
+		}
+	}
+	return out
+}
+
+// syncSnapshot 将内存索引中不超过 RedisPrefixDepth 的全部前缀写入 Redis
+// 已不存在的旧前缀不主动清理，依赖 RedisTTL 过期
+func (s *Suggester) syncSnapshot(ctx context.Context) {
+	if s.redis == nil {
+		return
+	}
+
+	depth, limit := s.config.RedisPrefixDepth, s.config.MaxLimit
+	out := make(map[string][]Suggestion)
+	collect := func(kind Kind, scope string, trie *suggestTrie) {
+		trie.walk(depth, func(prefix string, node *trieNode) {
+			if refs := topRefs(node, limit); len(refs) > 0 {
+				out[redisKey(kind, scope, prefix)] = s.idx.suggestions(kind, trie, refs)
+			}
+		})
+	}
+
+	s.mu.RLock()
+	collect(KindBook, publicScope, s.idx.books)
+	collect(KindAuthor, publicScope, s.idx.authors)
+	collect(KindTag, publicScope, s.idx.tags)
+	for owner, trie := range s.idx.documents {
+		collect(KindDocument, owner, trie)
+	}
+	s.mu.RUnlock()
+
+	s.writeRedis(ctx, out)
+}
+
+// writeRedis 批量写入兜底数据，失败只记录日志
+func (s *Suggester) writeRedis(ctx context.Context, payloads map[string][]Suggestion) {
+	if s.redis == nil || len(payloads) == 0 {
+		return
+	}
+
+	pipe := s.redis.Pipeline()
+	pending := 0
+	flush := func() {
+		if pending == 0 {
+			return
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			s.logger.Warn("Failed to write suggestions to redis", zap.Error(err))
+		}
+		pending = 0
+	}
+
+	for key, items := range payloads {
+		if items == nil {
+			pipe.Del(ctx, key)
+		} else {
+			data, err := json.Marshal(items)
+			if err != nil {
+				continue
+			}
+			pipe.Set(ctx, key, data, s.config.RedisTTL)
+		}
+		pending++
+		if pending >= redisPipelineBatch {
+			flush()
+		}
+	}
+	flush()
+}
+
+// lookupRedis 从 Redis 读取兜底结果
+// 前缀超过 RedisPrefixDepth 时读取截断前缀的结果再按完整前缀过滤
+func (s *Suggester) lookupRedis(ctx context.Context, kind Kind, userID, prefix string, limit int) ([]Suggestion, error) {
+	items := []Suggestion{}
+	if s.redis == nil {
+		return items, nil
+	}
+
+	scope := publicScope
+	if kind == KindDocument {
+		scope = userID
+	}
+	runes := []rune(prefix)
+	truncated := len(runes) > s.config.RedisPrefixDepth
+	lookupPrefix := prefix
+	if truncated {
+		lookupPrefix = string(runes[:s.config.RedisPrefixDepth])
+	}
+
+	data, err := s.redis.Get(ctx, redisKey(kind, scope, lookupPrefix)).Bytes()
+	if err == redis.Nil {
+		return items, nil
+	}
+	if err != nil {
+		s.logger.Warn("Failed to read suggestions from redis",
+			zap.String("kind", string(kind)),
+			zap.Error(err),
+		)
+		return items, nil
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("decode cached suggestions failed: %w", err)
+	}
+
+	if truncated {
+		filtered := items[:0]
+		for _, item := range items {
+			if match, ok := matchPrefix(item.Text, prefix); ok {
+				item.Match = match
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// matchPrefix 判断文本是否以归一化前缀命中，返回最优命中方式
+func matchPrefix(text, prefix string) (MatchType, bool) {
+	for _, k := range suggestKeys(text) {
+		if strings.HasPrefix(k.key, prefix) {
+			return k.match, true
+		}
+	}
+	return "", false
+}
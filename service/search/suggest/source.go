@@ -0,0 +1,292 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"Qingyu_backend/models/bookstore"
+)
+
+// BookEntry 参与联想的书籍
+type BookEntry struct {
+	ID         string
+	Title      string
+	Author     string
+	Tags       []string
+	Popularity float64
+}
+
+// DocumentEntry 参与联想的写作文档（仅对项目作者可见）
+type DocumentEntry struct {
+	ID        string
+	ProjectID string
+	OwnerID   string
+	Title     string
+	UpdatedAt time.Time
+}
+
+// Source 联想数据来源
+type Source interface {
+	// LoadBooks 加载全部公开书籍（含热度）
+	LoadBooks(ctx context.Context) ([]BookEntry, error)
+	// LoadBook 加载单本书籍，不存在或不公开时返回 nil
+	LoadBook(ctx context.Context, id string) (*BookEntry, error)
+	// LoadDocuments 加载全部文档
+	LoadDocuments(ctx context.Context) ([]DocumentEntry, error)
+	// LoadDocument 加载单个文档，不存在或已删除时返回 nil
+	LoadDocument(ctx context.Context, id string) (*DocumentEntry, error)
+	// LoadStatistics 按统计记录ID加载书籍热度，返回书籍ID与热度
+	LoadStatistics(ctx context.Context, id string) (bookID string, popularity float64, err error)
+}
+
+// PopularityScore 由书籍统计计算联想热度：优先使用热度分，未计算时按互动量估算
+func PopularityScore(stats *bookstore.BookStatistics) float64 {
+	if stats == nil {
+		return 0
+	}
+	if stats.HotScore > 0 {
+		return stats.HotScore
+	}
+	return float64(stats.ViewCount) +
+		10*float64(stats.FavoriteCount) +
+		5*float64(stats.CommentCount) +
+		3*float64(stats.ShareCount)
+}
+
+// 联想数据所在集合
+const (
+	booksCollection      = "books"
+	statisticsCollection = "book_statistics"
+	documentsCollection  = "documents"
+	projectsCollection   = "projects"
+)
+
+// MongoSource 从 MongoDB 加载联想数据
+type MongoSource struct {
+	db *mongo.Database
+}
+
+// NewMongoSource 创建 MongoDB 数据来源
+func NewMongoSource(db *mongo.Database) (*MongoSource, error) {
+	if db == nil {
+		return nil, fmt.Errorf("MongoDB database cannot be nil")
+	}
+	return &MongoSource{db: db}, nil
+}
+
+// suggestBook books 集合中联想所需的字段
+type suggestBook struct {
+	ID     primitive.ObjectID `bson:"_id"`
+	Title  string             `bson:"title"`
+	Author string             `bson:"author"`
+	Tags   []string           `bson:"tags"`
+}
+
+var suggestBookProjection = bson.M{"title": 1, "author": 1, "tags": 1}
+
+func publicBookFilter() bson.M {
+	return bson.M{"status": bson.M{"$in": bookstore.PublicBookStatusQueryValues()}}
+}
+
+// LoadBooks 加载全部公开书籍
+func (s *MongoSource) LoadBooks(ctx context.Context) ([]BookEntry, error) {
+	cursor, err := s.db.Collection(booksCollection).Find(ctx, publicBookFilter(), options.Find().SetProjection(suggestBookProjection))
+	if err != nil {
+		return nil, fmt.Errorf("load books failed: %w", err)
+	}
+	var books []suggestBook
+	if err := cursor.All(ctx, &books); err != nil {
+		return nil, fmt.Errorf("decode books failed: %w", err)
+	}
+
+	popularity, err := s.loadPopularity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]BookEntry, 0, len(books))
+	for _, b := range books {
+		id := b.ID.Hex()
+		entries = append(entries, BookEntry{
+			ID:         id,
+			Title:      b.Title,
+			Author:     b.Author,
+			Tags:       b.Tags,
+			Popularity: popularity[id],
+		})
+	}
+	return entries, nil
+}
+
+// LoadBook 加载单本书籍
+func (s *MongoSource) LoadBook(ctx context.Context, id string) (*BookEntry, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+	filter := publicBookFilter()
+	filter["_id"] = oid
+
+	var b suggestBook
+	err = s.db.Collection(booksCollection).FindOne(ctx, filter, options.FindOne().SetProjection(suggestBookProjection)).Decode(&b)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load book failed: %w", err)
+	}
+
+	var stats bookstore.BookStatistics
+	popularity := 0.0
+	err = s.db.Collection(statisticsCollection).FindOne(ctx, bson.M{"book_id": oid}).Decode(&stats)
+	switch {
+	case err == nil:
+		popularity = PopularityScore(&stats)
+	case err != mongo.ErrNoDocuments:
+		return nil, fmt.Errorf("load book statistics failed: %w", err)
+	}
+
+	return &BookEntry{ID: id, Title: b.Title, Author: b.Author, Tags: b.Tags, Popularity: popularity}, nil
+}
+
+// LoadStatistics 按统计记录ID加载书籍热度
+func (s *MongoSource) LoadStatistics(ctx context.Context, id string) (string, float64, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", 0, nil
+	}
+	var stats bookstore.BookStatistics
+	err = s.db.Collection(statisticsCollection).FindOne(ctx, bson.M{"_id": oid}).Decode(&stats)
+	if err == mongo.ErrNoDocuments {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("load book statistics failed: %w", err)
+	}
+	return stats.BookID.Hex(), PopularityScore(&stats), nil
+}
+
+// loadPopularity 加载全部书籍热度
+func (s *MongoSource) loadPopularity(ctx context.Context) (map[string]float64, error) {
+	cursor, err := s.db.Collection(statisticsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("load book statistics failed: %w", err)
+	}
+	var stats []bookstore.BookStatistics
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("decode book statistics failed: %w", err)
+	}
+	popularity := make(map[string]float64, len(stats))
+	for i := range stats {
+		popularity[stats[i].BookID.Hex()] = PopularityScore(&stats[i])
+	}
+	return popularity, nil
+}
+
+// suggestDocument documents 集合中联想所需的字段
+type suggestDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	ProjectID primitive.ObjectID `bson:"project_id"`
+	Title     string             `bson:"title"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+var suggestDocumentProjection = bson.M{"project_id": 1, "title": 1, "updated_at": 1}
+
+func liveDocumentFilter() bson.M {
+	return bson.M{"deleted_at": nil}
+}
+
+// LoadDocuments 加载全部未删除文档，归属为项目作者
+func (s *MongoSource) LoadDocuments(ctx context.Context) ([]DocumentEntry, error) {
+	cursor, err := s.db.Collection(documentsCollection).Find(ctx, liveDocumentFilter(), options.Find().SetProjection(suggestDocumentProjection))
+	if err != nil {
+		return nil, fmt.Errorf("load documents failed: %w", err)
+	}
+	var docs []suggestDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode documents failed: %w", err)
+	}
+
+	owners, err := s.loadProjectOwners(ctx, bson.M{"deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DocumentEntry, 0, len(docs))
+	for _, d := range docs {
+		owner, ok := owners[d.ProjectID.Hex()]
+		if !ok {
+			continue
+		}
+		entries = append(entries, DocumentEntry{
+			ID:        d.ID.Hex(),
+			ProjectID: d.ProjectID.Hex(),
+			OwnerID:   owner,
+			Title:     d.Title,
+			UpdatedAt: d.UpdatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// LoadDocument 加载单个文档
+func (s *MongoSource) LoadDocument(ctx context.Context, id string) (*DocumentEntry, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+	filter := liveDocumentFilter()
+	filter["_id"] = oid
+
+	var d suggestDocument
+	err = s.db.Collection(documentsCollection).FindOne(ctx, filter, options.FindOne().SetProjection(suggestDocumentProjection)).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load document failed: %w", err)
+	}
+
+	owners, err := s.loadProjectOwners(ctx, bson.M{"_id": d.ProjectID, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := owners[d.ProjectID.Hex()]
+	if !ok {
+		return nil, nil
+	}
+	return &DocumentEntry{
+		ID:        id,
+		ProjectID: d.ProjectID.Hex(),
+		OwnerID:   owner,
+		Title:     d.Title,
+		UpdatedAt: d.UpdatedAt,
+	}, nil
+}
+
+// loadProjectOwners 加载项目ID到作者ID的映射
+func (s *MongoSource) loadProjectOwners(ctx context.Context, filter bson.M) (map[string]string, error) {
+	cursor, err := s.db.Collection(projectsCollection).Find(ctx, filter, options.Find().SetProjection(bson.M{"author_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("load projects failed: %w", err)
+	}
+	var projects []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		AuthorID primitive.ObjectID `bson:"author_id"`
+	}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, fmt.Errorf("decode projects failed: %w", err)
+	}
+	owners := make(map[string]string, len(projects))
+	for _, p := range projects {
+		owners[p.ID.Hex()] = p.AuthorID.Hex()
+	}
+	return owners, nil
+}
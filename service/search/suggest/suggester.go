@@ -0,0 +1,604 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"Qingyu_backend/models/search"
+	"Qingyu_backend/pkg/logger"
+)
+
+// Kind 联想类别
+type Kind string
+
+const (
+	KindBook     Kind = "book"     // 书名
+	KindAuthor   Kind = "author"   // 作者
+	KindTag      Kind = "tag"      // 标签
+	KindDocument Kind = "document" // 当前用户的写作文档
+)
+
+// PublicKinds 无需登录即可查询的联想类别
+var PublicKinds = []Kind{KindBook, KindAuthor, KindTag}
+
+// publicScope 公开类别在 Redis 键中的作用域
+const publicScope = "public"
+
+// Config 联想配置
+type Config struct {
+	DefaultLimit     int           // 每个类别默认返回条数
+	MaxLimit         int           // 每个类别最大返回条数
+	RedisPrefixDepth int           // 写入 Redis 兜底的最大前缀长度（字符数）
+	RedisTTL         time.Duration // Redis 兜底数据过期时间
+	RefreshInterval  time.Duration // 全量重建间隔，0 表示不定期重建
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultLimit:     5,
+		MaxLimit:         20,
+		RedisPrefixDepth: 8,
+		RedisTTL:         24 * time.Hour,
+		RefreshInterval:  30 * time.Minute,
+	}
+}
+
+// Suggestion 联想条目
+type Suggestion struct {
+	Text      string    `json:"text"`
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id,omitempty"`         // 书籍或文档ID，作者与标签为空
+	ProjectID string    `json:"project_id,omitempty"` // 文档所属项目
+	Score     float64   `json:"score"`
+	Match     MatchType `json:"match"`
+}
+
+// Request 联想请求
+type Request struct {
+	Prefix string
+	UserID string // 查询文档联想时必填
+	Kinds  []Kind // 为空时查询公开类别
+	Limit  int
+}
+
+// Result 联想结果，按类别分组
+type Result struct {
+	Books     []Suggestion `json:"books,omitempty"`
+	Authors   []Suggestion `json:"authors,omitempty"`
+	Tags      []Suggestion `json:"tags,omitempty"`
+	Documents []Suggestion `json:"documents,omitempty"`
+	Source    string       `json:"source"` // memory / redis
+}
+
+func (r *Result) set(kind Kind, items []Suggestion) {
+	switch kind {
+	case KindBook:
+		r.Books = items
+	case KindAuthor:
+		r.Authors = items
+	case KindTag:
+		r.Tags = items
+	case KindDocument:
+		r.Documents = items
+	}
+}
+
+// aggregate 作者、标签等由多本书聚合的条目，热度为各书热度之和
+type aggregate struct {
+	text  string
+	books map[string]float64
+}
+
+func (a *aggregate) score() float64 {
+	total := 0.0
+	for _, p := range a.books {
+		total += p
+	}
+	return total
+}
+
+// index 内存索引快照
+type index struct {
+	books      *suggestTrie
+	authors    *suggestTrie
+	tags       *suggestTrie
+	documents  map[string]*suggestTrie // ownerID -> 文档字典树
+	bookInfo   map[string]BookEntry
+	docInfo    map[string]DocumentEntry
+	authorAggs map[string]*aggregate
+	tagAggs    map[string]*aggregate
+	k          int
+}
+
+func newIndex(k int) *index {
+	return &index{
+		books:      newSuggestTrie(k),
+		authors:    newSuggestTrie(k),
+		tags:       newSuggestTrie(k),
+		documents:  make(map[string]*suggestTrie),
+		bookInfo:   make(map[string]BookEntry),
+		docInfo:    make(map[string]DocumentEntry),
+		authorAggs: make(map[string]*aggregate),
+		tagAggs:    make(map[string]*aggregate),
+		k:          k,
+	}
+}
+
+// Suggester 搜索联想服务
+// 内存字典树提供毫秒级前缀查询；内存索引未就绪（启动加载中或加载失败）时从 Redis 读取兜底结果
+type Suggester struct {
+	source Source
+	redis  *redis.Client
+	config *Config
+	logger *logger.Logger
+
+	mu    sync.RWMutex
+	idx   *index
+	ready bool
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewSuggester 创建联想服务，redisClient 为空时不启用 Redis 兜底
+func NewSuggester(source Source, redisClient *redis.Client, config *Config) (*Suggester, error) {
+	if source == nil {
+		return nil, fmt.Errorf("suggest source cannot be nil")
+	}
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.DefaultLimit <= 0 {
+		config.DefaultLimit = 5
+	}
+	if config.MaxLimit < config.DefaultLimit {
+		config.MaxLimit = config.DefaultLimit
+	}
+	if config.RedisPrefixDepth <= 0 {
+		config.RedisPrefixDepth = 8
+	}
+
+	s := &Suggester{
+		source: source,
+		redis:  redisClient,
+		config: config,
+		logger: logger.Get().WithModule("search-suggester"),
+		stopCh: make(chan struct{}),
+	}
+	s.idx = newIndex(s.trieK())
+	return s, nil
+}
+
+// trieK 字典树节点保留的条目数
+func (s *Suggester) trieK() int {
+	return s.config.MaxLimit
+}
+
+// Ready 内存索引是否已加载
+func (s *Suggester) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready
+}
+
+// Start 首次全量加载并按 RefreshInterval 定期重建
+// 首次加载失败时返回错误，但定期重建仍会继续尝试
+func (s *Suggester) Start(ctx context.Context) error {
+	err := s.Load(ctx)
+
+	if s.config.RefreshInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(s.config.RefreshInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.stopCh:
+					return
+				case <-ticker.C:
+					if err := s.Load(ctx); err != nil {
+						s.logger.Warn("Suggestion index refresh failed", zap.Error(err))
+					}
+				}
+			}
+		}()
+	}
+	return err
+}
+
+// Stop 停止定期重建
+func (s *Suggester) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+// Load 从数据源全量重建内存索引并刷新 Redis 兜底数据
+// 重建在锁外进行，完成后整体替换，期间查询不受影响
+func (s *Suggester) Load(ctx context.Context) error {
+	start := time.Now()
+
+	books, err := s.source.LoadBooks(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := s.source.LoadDocuments(ctx)
+	if err != nil {
+		return err
+	}
+
+	idx := newIndex(s.trieK())
+	for _, b := range books {
+		idx.upsertBook(b)
+	}
+	for _, d := range docs {
+		idx.upsertDocument(d)
+	}
+
+	s.mu.Lock()
+	s.idx = idx
+	s.ready = true
+	s.mu.Unlock()
+
+	s.logger.Info("Suggestion index loaded",
+		zap.Int("books", len(books)),
+		zap.Int("documents", len(docs)),
+		zap.Duration("took", time.Since(start)),
+	)
+
+	s.syncSnapshot(ctx)
+	return nil
+}
+
+// Suggest 查询联想
+func (s *Suggester) Suggest(ctx context.Context, req *Request) (*Result, error) {
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = PublicKinds
+	}
+	for _, kind := range kinds {
+		if kind == KindDocument && req.UserID == "" {
+			return nil, fmt.Errorf("user id is required for document suggestions")
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = s.config.DefaultLimit
+	}
+	limit = min(limit, s.config.MaxLimit)
+
+	prefix := normalize(req.Prefix)
+	result := &Result{Source: "memory"}
+	if prefix == "" {
+		return result, nil
+	}
+
+	s.mu.RLock()
+	if s.ready {
+		for _, kind := range kinds {
+			result.set(kind, s.idx.lookup(kind, req.UserID, prefix, limit))
+		}
+		s.mu.RUnlock()
+		return result, nil
+	}
+	s.mu.RUnlock()
+
+	result.Source = "redis"
+	for _, kind := range kinds {
+		items, err := s.lookupRedis(ctx, kind, req.UserID, prefix, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.set(kind, items)
+	}
+	return result, nil
+}
+
+// HandleSyncEvent 处理搜索同步事件，增量更新联想索引（实现 sync.SyncEventListener）
+func (s *Suggester) HandleSyncEvent(ctx context.Context, event *search.SyncEvent) error {
+	if event == nil {
+		return nil
+	}
+	deleted := event.Type == search.SyncEventDelete
+
+	switch event.Index {
+	case booksCollection:
+		if deleted {
+			s.RemoveBook(ctx, event.ID)
+			return nil
+		}
+		entry, err := s.source.LoadBook(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			// 不存在或已下架
+			s.RemoveBook(ctx, event.ID)
+			return nil
+		}
+		s.UpsertBook(ctx, *entry)
+
+	case documentsCollection:
+		if deleted {
+			s.RemoveDocument(ctx, event.ID)
+			return nil
+		}
+		entry, err := s.source.LoadDocument(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			s.RemoveDocument(ctx, event.ID)
+			return nil
+		}
+		s.UpsertDocument(ctx, *entry)
+
+	case statisticsCollection:
+		if deleted {
+			return nil
+		}
+		bookID, popularity, err := s.source.LoadStatistics(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if bookID != "" {
+			s.SetBookPopularity(ctx, bookID, popularity)
+		}
+	}
+	return nil
+}
+
+// UpsertBook 新增或更新书籍
+func (s *Suggester) UpsertBook(ctx context.Context, entry BookEntry) {
+	s.mu.Lock()
+	touched := s.idx.removeBook(entry.ID)
+	touched.merge(s.idx.upsertBook(entry))
+	payloads := s.idx.payloads(touched, s.config.RedisPrefixDepth, s.config.MaxLimit)
+	s.mu.Unlock()
+
+	s.writeRedis(ctx, payloads)
+}
+
+// RemoveBook 删除书籍
+func (s *Suggester) RemoveBook(ctx context.Context, bookID string) {
+	s.mu.Lock()
+	touched := s.idx.removeBook(bookID)
+	payloads := s.idx.payloads(touched, s.config.RedisPrefixDepth, s.config.MaxLimit)
+	s.mu.Unlock()
+
+	s.writeRedis(ctx, payloads)
+}
+
+// SetBookPopularity 更新书籍热度，未索引的书籍忽略
+func (s *Suggester) SetBookPopularity(ctx context.Context, bookID string, popularity float64) {
+	s.mu.Lock()
+	entry, ok := s.idx.bookInfo[bookID]
+	if !ok || entry.Popularity == popularity {
+		s.mu.Unlock()
+		return
+	}
+	entry.Popularity = popularity
+	touched := s.idx.removeBook(bookID)
+	touched.merge(s.idx.upsertBook(entry))
+	payloads := s.idx.payloads(touched, s.config.RedisPrefixDepth, s.config.MaxLimit)
+	s.mu.Unlock()
+
+	s.writeRedis(ctx, payloads)
+}
+
+// UpsertDocument 新增或更新文档
+func (s *Suggester) UpsertDocument(ctx context.Context, entry DocumentEntry) {
+	s.mu.Lock()
+	touched := s.idx.removeDocument(entry.ID)
+	touched.merge(s.idx.upsertDocument(entry))
+	payloads := s.idx.payloads(touched, s.config.RedisPrefixDepth, s.config.MaxLimit)
+	s.mu.Unlock()
+
+	s.writeRedis(ctx, payloads)
+}
+
+// RemoveDocument 删除文档
+func (s *Suggester) RemoveDocument(ctx context.Context, docID string) {
+	s.mu.Lock()
+	touched := s.idx.removeDocument(docID)
+	payloads := s.idx.payloads(touched, s.config.RedisPrefixDepth, s.config.MaxLimit)
+	s.mu.Unlock()
+
+	s.writeRedis(ctx, payloads)
+}
+
+// scopedTrie 某类别、某作用域下的字典树
+type scopedTrie struct {
+	kind  Kind
+	scope string
+}
+
+// touchedKeys 变更涉及的索引键，用于增量刷新 Redis
+type touchedKeys map[scopedTrie]map[string]struct{}
+
+func (t touchedKeys) add(kind Kind, scope, text string) {
+	st := scopedTrie{kind: kind, scope: scope}
+	keys := t[st]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		t[st] = keys
+	}
+	for _, k := range suggestKeys(text) {
+		keys[k.key] = struct{}{}
+	}
+}
+
+func (t touchedKeys) merge(other touchedKeys) {
+	for st, keys := range other {
+		if t[st] == nil {
+			t[st] = make(map[string]struct{}, len(keys))
+		}
+		for k := range keys {
+			t[st][k] = struct{}{}
+		}
+	}
+}
+
+// trie 返回类别与作用域对应的字典树，不存在时返回 nil
+func (idx *index) trie(kind Kind, scope string) *suggestTrie {
+	switch kind {
+	case KindBook:
+		return idx.books
+	case KindAuthor:
+		return idx.authors
+	case KindTag:
+		return idx.tags
+	case KindDocument:
+		return idx.documents[scope]
+	}
+	return nil
+}
+
+func (idx *index) lookup(kind Kind, userID, prefix string, limit int) []Suggestion {
+	scope := publicScope
+	if kind == KindDocument {
+		scope = userID
+	}
+	trie := idx.trie(kind, scope)
+	if trie == nil {
+		return []Suggestion{}
+	}
+	return idx.suggestions(kind, trie, trie.lookup(prefix, limit))
+}
+
+func (idx *index) suggestions(kind Kind, trie *suggestTrie, refs []trieRef) []Suggestion {
+	items := make([]Suggestion, 0, len(refs))
+	for _, ref := range refs {
+		entry, ok := trie.get(ref.id)
+		if !ok {
+			continue
+		}
+		item := Suggestion{Text: entry.text, Kind: kind, Score: entry.score, Match: ref.match}
+		switch kind {
+		case KindBook:
+			item.ID = ref.id
+		case KindDocument:
+			item.ID = ref.id
+			item.ProjectID = idx.docInfo[ref.id].ProjectID
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (idx *index) upsertBook(entry BookEntry) touchedKeys {
+	touched := touchedKeys{}
+	idx.bookInfo[entry.ID] = entry
+
+	idx.books.put(entry.ID, entry.Title, entry.Popularity)
+	touched.add(KindBook, publicScope, entry.Title)
+
+	if entry.Author != "" {
+		idx.addToAggregate(idx.authors, idx.authorAggs, entry.Author, entry.ID, entry.Popularity)
+		touched.add(KindAuthor, publicScope, entry.Author)
+	}
+	for _, tag := range entry.Tags {
+		if tag == "" {
+			continue
+		}
+		idx.addToAggregate(idx.tags, idx.tagAggs, tag, entry.ID, entry.Popularity)
+		touched.add(KindTag, publicScope, tag)
+	}
+	return touched
+}
+
+func (idx *index) removeBook(bookID string) touchedKeys {
+	touched := touchedKeys{}
+	entry, ok := idx.bookInfo[bookID]
+	if !ok {
+		return touched
+	}
+	delete(idx.bookInfo, bookID)
+
+	idx.books.remove(bookID)
+	touched.add(KindBook, publicScope, entry.Title)
+
+	if entry.Author != "" {
+		idx.removeFromAggregate(idx.authors, idx.authorAggs, entry.Author, bookID)
+		touched.add(KindAuthor, publicScope, entry.Author)
+	}
+	for _, tag := range entry.Tags {
+		if tag == "" {
+			continue
+		}
+		idx.removeFromAggregate(idx.tags, idx.tagAggs, tag, bookID)
+		touched.add(KindTag, publicScope, tag)
+	}
+	return touched
+}
+
+func (idx *index) addToAggregate(trie *suggestTrie, aggs map[string]*aggregate, text, bookID string, popularity float64) {
+	id := normalize(text)
+	if id == "" {
+		return
+	}
+	agg := aggs[id]
+	if agg == nil {
+		agg = &aggregate{text: text, books: make(map[string]float64)}
+		aggs[id] = agg
+	}
+	agg.books[bookID] = popularity
+	trie.put(id, agg.text, agg.score())
+}
+
+func (idx *index) removeFromAggregate(trie *suggestTrie, aggs map[string]*aggregate, text, bookID string) {
+	id := normalize(text)
+	agg := aggs[id]
+	if agg == nil {
+		return
+	}
+	delete(agg.books, bookID)
+	if len(agg.books) == 0 {
+		delete(aggs, id)
+		trie.remove(id)
+		return
+	}
+	trie.put(id, agg.text, agg.score())
+}
+
+// upsertDocument 文档按最近更新时间排序
+func (idx *index) upsertDocument(entry DocumentEntry) touchedKeys {
+	touched := touchedKeys{}
+	if entry.OwnerID == "" {
+		return touched
+	}
+	idx.docInfo[entry.ID] = entry
+
+	trie := idx.documents[entry.OwnerID]
+	if trie == nil {
+		trie = newSuggestTrie(idx.k)
+		idx.documents[entry.OwnerID] = trie
+	}
+	trie.put(entry.ID, entry.Title, float64(entry.UpdatedAt.Unix()))
+	touched.add(KindDocument, entry.OwnerID, entry.Title)
+	return touched
+}
+
+func (idx *index) removeDocument(docID string) touchedKeys {
+	touched := touchedKeys{}
+	entry, ok := idx.docInfo[docID]
+	if !ok {
+		return touched
+	}
+	delete(idx.docInfo, docID)
+
+	if trie := idx.documents[entry.OwnerID]; trie != nil {
+		trie.remove(docID)
+		if trie.len() == 0 {
+			delete(idx.documents, entry.OwnerID)
+		}
+	}
+	touched.add(KindDocument, entry.OwnerID, entry.Title)
+	return touched
+}
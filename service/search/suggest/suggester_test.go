@@ -0,0 +1,247 @@
+package suggest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Qingyu_backend/models/search"
+)
+
+// fakeSource 内存数据源
+type fakeSource struct {
+	books map[string]BookEntry
+	docs  map[string]DocumentEntry
+	stats map[string]string // 统计记录ID -> 书籍ID
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		books: map[string]BookEntry{
+			"b1": {ID: "b1", Title: "斗破苍穹", Author: "天蚕土豆", Tags: []string{"玄幻", "热血"}, Popularity: 100},
+			"b2": {ID: "b2", Title: "斗罗大陆", Author: "唐家三少", Tags: []string{"玄幻"}, Popularity: 300},
+			"b3": {ID: "b3", Title: "武动乾坤", Author: "天蚕土豆", Tags: []string{"玄幻"}, Popularity: 250},
+			"b4": {ID: "b4", Title: "Douluo Continent", Author: "Tang", Popularity: 10},
+		},
+		docs: map[string]DocumentEntry{
+			"d1": {ID: "d1", ProjectID: "p1", OwnerID: "u1", Title: "第一章 初入斗气", UpdatedAt: time.Unix(1000, 0)},
+			"d2": {ID: "d2", ProjectID: "p1", OwnerID: "u1", Title: "第二章 斗技", UpdatedAt: time.Unix(2000, 0)},
+			"d3": {ID: "d3", ProjectID: "p2", OwnerID: "u2", Title: "第一章 开端", UpdatedAt: time.Unix(3000, 0)},
+		},
+		stats: map[string]string{"s1": "b1"},
+	}
+}
+
+func (f *fakeSource) LoadBooks(ctx context.Context) ([]BookEntry, error) {
+	out := make([]BookEntry, 0, len(f.books))
+	for _, b := range f.books {
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func (f *fakeSource) LoadBook(ctx context.Context, id string) (*BookEntry, error) {
+	b, ok := f.books[id]
+	if !ok {
+		return nil, nil
+	}
+	return &b, nil
+}
+
+func (f *fakeSource) LoadDocuments(ctx context.Context) ([]DocumentEntry, error) {
+	out := make([]DocumentEntry, 0, len(f.docs))
+	for _, d := range f.docs {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (f *fakeSource) LoadDocument(ctx context.Context, id string) (*DocumentEntry, error) {
+	d, ok := f.docs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+func (f *fakeSource) LoadStatistics(ctx context.Context, id string) (string, float64, error) {
+	bookID, ok := f.stats[id]
+	if !ok {
+		return "", 0, nil
+	}
+	return bookID, f.books[bookID].Popularity, nil
+}
+
+func texts(items []Suggestion) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.Text)
+	}
+	return out
+}
+
+func newTestSuggester(t *testing.T, source Source, client *redis.Client) *Suggester {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.RefreshInterval = 0
+	s, err := NewSuggester(source, client, cfg)
+	require.NoError(t, err)
+	return s
+}
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSuggester_Suggest(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSuggester(t, newFakeSource(), nil)
+	require.NoError(t, s.Load(ctx))
+
+	t.Run("initials", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "dpcq"})
+		require.NoError(t, err)
+		require.Len(t, result.Books, 1)
+		assert.Equal(t, "b1", result.Books[0].ID)
+		assert.Equal(t, MatchInitials, result.Books[0].Match)
+		assert.Equal(t, "memory", result.Source)
+	})
+
+	t.Run("pinyin ranked by popularity", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "Dou", Kinds: []Kind{KindBook}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"斗罗大陆", "斗破苍穹", "Douluo Continent"}, texts(result.Books))
+		assert.Equal(t, MatchPinyin, result.Books[0].Match)
+		assert.Equal(t, MatchPrefix, result.Books[2].Match)
+		assert.Empty(t, result.Authors)
+	})
+
+	t.Run("authors aggregate popularity", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "t", Kinds: []Kind{KindAuthor}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"天蚕土豆", "唐家三少", "Tang"}, texts(result.Authors))
+		assert.Equal(t, 350.0, result.Authors[0].Score)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "xuan"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"玄幻"}, texts(result.Tags))
+		assert.Equal(t, 650.0, result.Tags[0].Score)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "d", Kinds: []Kind{KindBook}, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"斗罗大陆"}, texts(result.Books))
+	})
+
+	t.Run("documents are scoped to owner", func(t *testing.T) {
+		result, err := s.Suggest(ctx, &Request{Prefix: "dyz", UserID: "u1", Kinds: []Kind{KindDocument}})
+		require.NoError(t, err)
+		require.Len(t, result.Documents, 1)
+		assert.Equal(t, "d1", result.Documents[0].ID)
+		assert.Equal(t, "p1", result.Documents[0].ProjectID)
+
+		result, err = s.Suggest(ctx, &Request{Prefix: "第", UserID: "u1", Kinds: []Kind{KindDocument}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"第二章 斗技", "第一章 初入斗气"}, texts(result.Documents))
+
+		_, err = s.Suggest(ctx, &Request{Prefix: "第", Kinds: []Kind{KindDocument}})
+		assert.Error(t, err)
+	})
+}
+
+func TestSuggester_HandleSyncEvent(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource()
+	s := newTestSuggester(t, source, nil)
+	require.NoError(t, s.Load(ctx))
+
+	// 热度变化后排序更新
+	b1 := source.books["b1"]
+	b1.Popularity = 1000
+	source.books["b1"] = b1
+	require.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "s1", Type: search.SyncEventUpdate, Index: "book_statistics"}))
+
+	result, err := s.Suggest(ctx, &Request{Prefix: "斗", Kinds: []Kind{KindBook}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"斗破苍穹", "斗罗大陆"}, texts(result.Books))
+
+	// 新书
+	source.books["b5"] = BookEntry{ID: "b5", Title: "斗战狂潮", Author: "骷髅精灵", Popularity: 500}
+	require.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "b5", Type: search.SyncEventInsert, Index: "books"}))
+	result, err = s.Suggest(ctx, &Request{Prefix: "dzkc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"斗战狂潮"}, texts(result.Books))
+
+	// 下架（数据源不再返回）与删除
+	delete(source.books, "b1")
+	require.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "b1", Type: search.SyncEventUpdate, Index: "books"}))
+	require.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "b3", Type: search.SyncEventDelete, Index: "books"}))
+	result, err = s.Suggest(ctx, &Request{Prefix: "tian"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Authors)
+
+	// 文档改名
+	d2 := source.docs["d2"]
+	d2.Title = "终章"
+	source.docs["d2"] = d2
+	require.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "d2", Type: search.SyncEventUpdate, Index: "documents"}))
+	result, err = s.Suggest(ctx, &Request{Prefix: "zhong", UserID: "u1", Kinds: []Kind{KindDocument}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"终章"}, texts(result.Documents))
+	result, err = s.Suggest(ctx, &Request{Prefix: "第二", UserID: "u1", Kinds: []Kind{KindDocument}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Documents)
+
+	// 其他集合忽略
+	assert.NoError(t, s.HandleSyncEvent(ctx, &search.SyncEvent{ID: "x", Type: search.SyncEventUpdate, Index: "users"}))
+}
+
+func TestSuggester_RedisFallback(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	source := newFakeSource()
+
+	loaded := newTestSuggester(t, source, client)
+	require.NoError(t, loaded.Load(ctx))
+
+	// 未加载的实例（如刚启动）从 Redis 读取
+	cold := newTestSuggester(t, source, client)
+	require.False(t, cold.Ready())
+
+	result, err := cold.Suggest(ctx, &Request{Prefix: "dou", Kinds: []Kind{KindBook}})
+	require.NoError(t, err)
+	assert.Equal(t, "redis", result.Source)
+	assert.Equal(t, []string{"斗罗大陆", "斗破苍穹", "Douluo Continent"}, texts(result.Books))
+
+	// 超过前缀深度时按完整前缀过滤
+	result, err = cold.Suggest(ctx, &Request{Prefix: "doupocang", Kinds: []Kind{KindBook}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"斗破苍穹"}, texts(result.Books))
+
+	result, err = cold.Suggest(ctx, &Request{Prefix: "第", UserID: "u2", Kinds: []Kind{KindDocument}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"第一章 开端"}, texts(result.Documents))
+
+	// 增量变更同步到 Redis
+	loaded.RemoveBook(ctx, "b2")
+	result, err = cold.Suggest(ctx, &Request{Prefix: "dou", Kinds: []Kind{KindBook}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"斗破苍穹", "Douluo Continent"}, texts(result.Books))
+
+	loaded.RemoveBook(ctx, "b4")
+	result, err = cold.Suggest(ctx, &Request{Prefix: "douluoc", Kinds: []Kind{KindBook}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Books)
+}
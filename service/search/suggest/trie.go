@@ -0,0 +1,229 @@
+package suggest
+
+import (
+	"sort"
+)
+
+// trieRef 字典树中对条目的引用
+type trieRef struct {
+	id    string
+	match MatchType
+}
+
+// trieNode 字典树节点，top 缓存以该节点为前缀的得分最高的 k 个条目（每个条目一个引用）
+type trieNode struct {
+	children  map[rune]*trieNode
+	terminals []trieRef
+	top       []trieRef
+}
+
+// trieEntry 已索引的条目
+type trieEntry struct {
+	text  string
+	score float64
+	keys  []entryKey
+}
+
+// suggestTrie 前缀联想字典树
+// 每个节点维护前缀下得分最高的 k 个条目，查询时只需走到前缀节点即可得到排好序的结果；
+// 删除条目时自底向上由子节点的 top 重建，代价与路径长度成正比
+type suggestTrie struct {
+	root    *trieNode
+	k       int
+	entries map[string]*trieEntry
+}
+
+func newSuggestTrie(k int) *suggestTrie {
+	return &suggestTrie{
+		root:    &trieNode{},
+		k:       k,
+		entries: make(map[string]*trieEntry),
+	}
+}
+
+// len 条目数
+func (t *suggestTrie) len() int {
+	return len(t.entries)
+}
+
+// get 获取条目
+func (t *suggestTrie) get(id string) (*trieEntry, bool) {
+	e, ok := t.entries[id]
+	return e, ok
+}
+
+// put 写入或覆盖条目
+func (t *suggestTrie) put(id, text string, score float64) {
+	t.remove(id)
+
+	keys := suggestKeys(text)
+	if len(keys) == 0 {
+		return
+	}
+	t.entries[id] = &trieEntry{text: text, score: score, keys: keys}
+	for _, k := range keys {
+		t.insert(k.key, trieRef{id: id, match: k.match})
+	}
+}
+
+// remove 删除条目
+func (t *suggestTrie) remove(id string) {
+	entry, ok := t.entries[id]
+	if !ok {
+		return
+	}
+	for _, k := range entry.keys {
+		t.delete(k.key, trieRef{id: id, match: k.match})
+	}
+	delete(t.entries, id)
+}
+
+func (t *suggestTrie) insert(key string, ref trieRef) {
+	node := t.root
+	t.offer(node, ref)
+	for _, r := range key {
+		child := node.children[r]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+		t.offer(node, ref)
+	}
+	node.terminals = append(node.terminals, ref)
+}
+
+func (t *suggestTrie) delete(key string, ref trieRef) {
+	runes := []rune(key)
+	path := make([]*trieNode, 0, len(runes)+1)
+	node := t.root
+	path = append(path, node)
+	for _, r := range runes {
+		node = node.children[r]
+		if node == nil {
+			return
+		}
+		path = append(path, node)
+	}
+	node.terminals = removeRef(node.terminals, ref)
+
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		if i > 0 && len(n.terminals) == 0 && len(n.children) == 0 {
+			delete(path[i-1].children, runes[i-1])
+			continue
+		}
+		if containsRef(n.top, ref) {
+			t.rebuild(n)
+		}
+	}
+}
+
+// offer 将引用纳入节点的 top-k，同一条目只保留命中方式最优的引用
+func (t *suggestTrie) offer(node *trieNode, ref trieRef) {
+	for i, r := range node.top {
+		if r.id != ref.id {
+			continue
+		}
+		if matchRank[ref.match] < matchRank[r.match] {
+			node.top[i] = ref
+			t.sortRefs(node.top)
+		}
+		return
+	}
+	node.top = append(node.top, ref)
+	t.sortRefs(node.top)
+	if len(node.top) > t.k {
+		node.top = node.top[:t.k]
+	}
+}
+
+// rebuild 由本节点的终止引用与子节点的 top 重建 top-k（子节点须已是最新）
+func (t *suggestTrie) rebuild(node *trieNode) {
+	candidates := append([]trieRef(nil), node.terminals...)
+	for _, child := range node.children {
+		candidates = append(candidates, child.top...)
+	}
+	t.sortRefs(candidates)
+	top := make([]trieRef, 0, t.k)
+	seen := make(map[string]bool, t.k)
+	for _, ref := range candidates {
+		if len(top) >= t.k {
+			break
+		}
+		if !seen[ref.id] {
+			seen[ref.id] = true
+			top = append(top, ref)
+		}
+	}
+	node.top = top
+}
+
+// sortRefs 按得分降序，同分按命中方式、文本排序
+func (t *suggestTrie) sortRefs(refs []trieRef) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		a, b := t.entries[refs[i].id], t.entries[refs[j].id]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if refs[i].match != refs[j].match {
+			return matchRank[refs[i].match] < matchRank[refs[j].match]
+		}
+		return a.text < b.text
+	})
+}
+
+// lookup 返回前缀下至多 limit 个条目，按得分排序
+func (t *suggestTrie) lookup(prefix string, limit int) []trieRef {
+	node := t.root
+	for _, r := range prefix {
+		node = node.children[r]
+		if node == nil {
+			return nil
+		}
+	}
+	return topRefs(node, limit)
+}
+
+// walk 深度优先遍历前缀长度不超过 maxDepth 的节点
+func (t *suggestTrie) walk(maxDepth int, fn func(prefix string, node *trieNode)) {
+	var visit func(node *trieNode, prefix []rune)
+	visit = func(node *trieNode, prefix []rune) {
+		if len(prefix) > 0 {
+			fn(string(prefix), node)
+		}
+		if len(prefix) >= maxDepth {
+			return
+		}
+		for r, child := range node.children {
+			visit(child, append(prefix, r))
+		}
+	}
+	visit(t.root, nil)
+}
+
+func topRefs(node *trieNode, limit int) []trieRef {
+	return append([]trieRef(nil), node.top[:min(limit, len(node.top))]...)
+}
+
+func containsRef(refs []trieRef, ref trieRef) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func removeRef(refs []trieRef, ref trieRef) []trieRef {
+	out := refs[:0]
+	for _, r := range refs {
+		if r != ref {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestKeys(t *testing.T) {
+	t.Run("chinese title", func(t *testing.T) {
+		keys := suggestKeys("斗破苍穹")
+		require.NotEmpty(t, keys)
+		assert.Equal(t, entryKey{key: "斗破苍穹", match: MatchPrefix}, keys[0])
+		assert.Equal(t, entryKey{key: "doupocangqiong", match: MatchPinyin}, keys[1])
+		assert.Contains(t, keys, entryKey{key: "dpcq", match: MatchInitials})
+	})
+
+	t.Run("heteronyms and ascii words", func(t *testing.T) {
+		keys := suggestKeys("重生 2077")
+		assert.Equal(t, entryKey{key: "重生2077", match: MatchPrefix}, keys[0])
+		assert.Contains(t, keys, entryKey{key: "zhongsheng2077", match: MatchPinyin})
+		assert.Contains(t, keys, entryKey{key: "chongsheng2077", match: MatchPinyin})
+		assert.Contains(t, keys, entryKey{key: "cs2", match: MatchInitials})
+	})
+
+	t.Run("variants are capped", func(t *testing.T) {
+		keys := suggestKeys("重重重重重重")
+		pinyinKeys := 0
+		for _, k := range keys {
+			if k.match == MatchPinyin {
+				pinyinKeys++
+			}
+		}
+		assert.LessOrEqual(t, pinyinKeys, maxPinyinVariants)
+	})
+
+	t.Run("ascii only", func(t *testing.T) {
+		assert.Equal(t, []entryKey{{key: "harrypotter", match: MatchPrefix}}, suggestKeys("Harry Potter!"))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Nil(t, suggestKeys(" ，。"))
+	})
+}
+
+func lookupIDs(trie *suggestTrie, prefix string, limit int) []string {
+	var ids []string
+	for _, ref := range trie.lookup(prefix, limit) {
+		ids = append(ids, ref.id)
+	}
+	return ids
+}
+
+func TestSuggestTrie_Lookup(t *testing.T) {
+	trie := newSuggestTrie(6)
+	trie.put("b1", "斗破苍穹", 100)
+	trie.put("b2", "斗罗大陆", 300)
+	trie.put("b3", "大主宰", 50)
+	trie.put("b4", "道诡异仙", 200)
+
+	assert.Equal(t, []string{"b2", "b1"}, lookupIDs(trie, "斗", 10))
+	assert.Equal(t, []string{"b1"}, lookupIDs(trie, "dpcq", 10))
+	assert.Equal(t, []string{"b1"}, lookupIDs(trie, "doupo", 10))
+	assert.Equal(t, []string{"b2", "b4", "b1", "b3"}, lookupIDs(trie, "d", 10))
+	assert.Equal(t, []string{"b2", "b4"}, lookupIDs(trie, "d", 2))
+	assert.Empty(t, lookupIDs(trie, "x", 10))
+
+	refs := trie.lookup("dp", 10)
+	require.Len(t, refs, 1)
+	assert.Equal(t, MatchInitials, refs[0].match)
+}
+
+func TestSuggestTrie_UpdateAndRemove(t *testing.T) {
+	trie := newSuggestTrie(2)
+	trie.put("a", "alpha", 10)
+	trie.put("b", "alpine", 20)
+	trie.put("c", "altitude", 5)
+
+	// k=2 时 c 只在自己的子树中保留
+	assert.Equal(t, []string{"b", "a"}, lookupIDs(trie, "al", 10))
+
+	// 删除后由子节点重建，c 重新进入前缀 top-k
+	trie.remove("b")
+	assert.Equal(t, []string{"a", "c"}, lookupIDs(trie, "al", 10))
+	assert.Empty(t, lookupIDs(trie, "alpi", 10))
+
+	// 更新得分后重新排序
+	trie.put("c", "altitude", 50)
+	assert.Equal(t, []string{"c", "a"}, lookupIDs(trie, "al", 10))
+
+	// 修改文本后旧前缀不再命中
+	trie.put("a", "beta", 10)
+	assert.Equal(t, []string{"c"}, lookupIDs(trie, "al", 10))
+	assert.Equal(t, []string{"a"}, lookupIDs(trie, "be", 10))
+
+	trie.remove("a")
+	trie.remove("c")
+	assert.Equal(t, 0, trie.len())
+	assert.Empty(t, trie.root.children)
+}
@@ -71,7 +71,7 @@ func NewChangeStreamListener(
 		redisClient:    redisClient,
 		zapLogger:      logger,
 		logger:         log.New(log.Writer(), "", log.LstdFlags),
-		collections:    []string{"books", "projects", "documents", "users"},
+		collections:    []string{"books", "projects", "documents", "users", "book_statistics"},
 		eventBuffer:    make([]search.SyncEvent, 0, bufferSize),
 		resumeTokenMap: make(map[string][]byte),
 		ctx:            ctx,
@@ -381,6 +381,15 @@ func (l *ChangeStreamListenerImpl) extractEssentialFields(collection string, doc
 			essential["created_at"] = createdAt
 		}
 
+	case "book_statistics":
+		// 书籍统计关键字段（供联想热度更新）
+		if bookID, ok := doc["book_id"]; ok {
+			essential["book_id"] = bookID
+		}
+		if hotScore, ok := doc["hot_score"]; ok {
+			essential["hot_score"] = hotScore
+		}
+
 	case "users":
 		// 用户关键字段
 		if username, ok := doc["username"]; ok {
@@ -83,6 +83,11 @@ type CollectionConfig struct {
 	Enabled    bool   `json:"enabled"`    // 是否启用
 }
 
+// SyncEventListener 同步事件监听者，在事件同步到搜索引擎后回调
+type SyncEventListener interface {
+	HandleSyncEvent(ctx context.Context, event *search.SyncEvent) error
+}
+
 // SyncEventProcessor 同步事件处理器接口
 type SyncEventProcessor interface {
 	// ProcessInsert 处理插入事件
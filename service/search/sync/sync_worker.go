@@ -35,7 +35,13 @@ type WorkerImpl struct {
 		success int64
 		failed  int64
 	}
-	config *WorkerConfig
+	config    *WorkerConfig
+	listeners []SyncEventListener
+}
+
+// listenerOnlyCollections 仅供进程内监听者使用、不同步到 ES 的集合
+var listenerOnlyCollections = map[string]bool{
+	"book_statistics": true,
 }
 
 // WorkerConfig Worker 配置
@@ -93,6 +99,14 @@ func (w *WorkerImpl) SetDeadLetterQueue(dlq *DeadLetterQueue) {
 	w.dlq = dlq
 }
 
+// AddListener 注册事件监听者，事件同步成功后回调（如搜索联想索引的增量更新）
+// 需在 Start 之前调用
+func (w *WorkerImpl) AddListener(listener SyncEventListener) {
+	if listener != nil {
+		w.listeners = append(w.listeners, listener)
+	}
+}
+
 // Start 启动 Worker
 func (w *WorkerImpl) Start(parentCtx context.Context) error {
 	w.status.Running = true
@@ -231,16 +245,46 @@ func (w *WorkerImpl) ProcessEvent(ctx context.Context, event *search.SyncEvent)
 		)
 	}
 
-	// 根据事件类型处理
+	if err := w.syncToEngine(ctx, event); err != nil {
+		return err
+	}
+
+	w.notifyListeners(ctx, event)
+	return nil
+}
+
+// syncToEngine 根据事件类型同步到搜索引擎
+func (w *WorkerImpl) syncToEngine(ctx context.Context, event *search.SyncEvent) error {
+	switch event.Type {
+	case search.SyncEventInsert, search.SyncEventUpdate, search.SyncEventDelete:
+	default:
+		return fmt.Errorf("unknown event type: %s", event.Type)
+	}
+
+	if w.esEngine == nil || listenerOnlyCollections[event.Index] {
+		return nil
+	}
+
 	switch event.Type {
 	case search.SyncEventInsert:
 		return w.handleInsert(ctx, event)
 	case search.SyncEventUpdate:
 		return w.handleUpdate(ctx, event)
-	case search.SyncEventDelete:
-		return w.handleDelete(ctx, event)
 	default:
-		return fmt.Errorf("unknown event type: %s", event.Type)
+		return w.handleDelete(ctx, event)
+	}
+}
+
+// notifyListeners 通知监听者，监听者失败只记录日志，不影响事件的同步结果
+func (w *WorkerImpl) notifyListeners(ctx context.Context, event *search.SyncEvent) {
+	for _, listener := range w.listeners {
+		if err := listener.HandleSyncEvent(ctx, event); err != nil && w.zapLogger != nil {
+			w.zapLogger.Warn("Sync event listener failed",
+				zap.String("event_id", event.ID),
+				zap.String("collection", event.Index),
+				zap.Error(err),
+			)
+		}
 	}
 }
 
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"Qingyu_backend/models/search"
+)
+
+// recordingListener 记录收到的事件
+type recordingListener struct {
+	events []*search.SyncEvent
+	err    error
+}
+
+func (l *recordingListener) HandleSyncEvent(ctx context.Context, event *search.SyncEvent) error {
+	l.events = append(l.events, event)
+	return l.err
+}
+
+func TestWorker_Listeners(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("listener is notified after engine sync", func(t *testing.T) {
+		engine := new(MockEngine)
+		engine.On("Delete", mock.Anything, "books_search", "b1").Return(nil)
+		worker, _ := NewSyncWorker(nil, nil, nil, engine, zaptest.NewLogger(t), nil, nil)
+		listener := &recordingListener{}
+		worker.AddListener(listener)
+
+		event := &search.SyncEvent{ID: "b1", Type: search.SyncEventDelete, Index: "books"}
+		assert.NoError(t, worker.ProcessEvent(ctx, event))
+		assert.Equal(t, []*search.SyncEvent{event}, listener.events)
+		engine.AssertExpectations(t)
+	})
+
+	t.Run("listener only collections skip engine", func(t *testing.T) {
+		engine := new(MockEngine)
+		worker, _ := NewSyncWorker(nil, nil, nil, engine, zaptest.NewLogger(t), nil, nil)
+		listener := &recordingListener{err: errors.New("listener failed")}
+		worker.AddListener(listener)
+
+		event := &search.SyncEvent{ID: "s1", Type: search.SyncEventUpdate, Index: "book_statistics"}
+		assert.NoError(t, worker.ProcessEvent(ctx, event), "listener errors must not fail the event")
+		assert.Len(t, listener.events, 1)
+		engine.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown event type is not dispatched", func(t *testing.T) {
+		worker, _ := NewSyncWorker(nil, nil, nil, nil, nil, nil, nil)
+		listener := &recordingListener{}
+		worker.AddListener(listener)
+
+		assert.Error(t, worker.ProcessEvent(ctx, &search.SyncEvent{ID: "b1", Type: "truncate", Index: "books"}))
+		assert.Empty(t, listener.events)
+	})
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	searchRepo "Qingyu_backend/repository/search"
+	searchengine "Qingyu_backend/service/search/engine"
+)
+
+// 向量引擎选项
+const (
+	VectorEngineMilvus = "milvus"
+	VectorEngineLocal  = "local"
+
+	VectorPersistenceMemory = "memory"
+	VectorPersistenceFile   = "file"
+	VectorPersistenceMongo  = "mongo"
+)
+
+// defaultVectorStorePath 本地向量文件的默认目录
+const defaultVectorStorePath = "data/vectors"
+
+// NewVectorEngine 按配置创建向量搜索引擎
+// local 引擎与 Milvus 引擎实现同一 Engine 接口，可直接替换；milvusRepo 与 db 仅在对应配置下需要
+func NewVectorEngine(cfg VectorConfig, milvusRepo *searchRepo.MilvusRepository, db *mongo.Database) (searchengine.Engine, error) {
+	switch cfg.Engine {
+	case "", VectorEngineMilvus:
+		return searchengine.NewMilvusEngine(milvusRepo)
+	case VectorEngineLocal:
+		store, err := newVectorStore(cfg.Local, db)
+		if err != nil {
+			return nil, err
+		}
+		return searchengine.NewLocalVectorEngine(searchengine.LocalVectorConfig{
+			Mode:           cfg.Local.Mode,
+			Metric:         cfg.Local.Metric,
+			VectorField:    cfg.Local.VectorField,
+			M:              cfg.Local.M,
+			EfConstruction: cfg.Local.EfConstruction,
+			EfSearch:       cfg.Local.EfSearch,
+			Seed:           cfg.Local.Seed,
+		}, store)
+	default:
+		return nil, fmt.Errorf("unsupported vector engine: %s", cfg.Engine)
+	}
+}
+
+// newVectorStore 创建本地向量引擎的持久化存储，memory 模式返回 nil
+func newVectorStore(cfg LocalVectorConfig, db *mongo.Database) (searchengine.VectorStore, error) {
+	switch cfg.Persistence {
+	case "", VectorPersistenceMemory:
+		return nil, nil
+	case VectorPersistenceFile:
+		path := cfg.Path
+		if path == "" {
+			path = defaultVectorStorePath
+		}
+		return searchengine.NewFileVectorStore(path)
+	case VectorPersistenceMongo:
+		return searchengine.NewMongoVectorStore(db, cfg.Collection)
+	default:
+		return nil, fmt.Errorf("unsupported vector persistence: %s", cfg.Persistence)
+	}
+}
@@ -0,0 +1,307 @@
+package social
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/social"
+)
+
+// 成就计数器ID
+const (
+	CounterLikesGiven      = "likes_given"
+	CounterCommentsPosted  = "comments_posted"
+	CounterUsersFollowed   = "users_followed"
+	CounterAuthorsFollowed = "authors_followed"
+	CounterChaptersRead    = "chapters_read"
+	CounterBooksCompleted  = "books_completed"
+	CounterWordsWritten    = "words_written"
+)
+
+// AchievementCounterRule 成就计数规则（声明式）
+//
+// 字段名均按"去下划线、转小写"比较，因此 user_id / userId / userid 视为同一字段；
+// 用 "a|b" 表示依次尝试多个候选字段，兼容同一语义的不同事件载荷。
+type AchievementCounterRule struct {
+	ID         string   // 计数器ID
+	EventTypes []string // 监听的事件类型
+
+	SubjectField string // 计数归属用户字段
+
+	Match   map[string][]string // 字段值必须在列表内
+	Exclude map[string][]string // 字段值不能在列表内（字段缺失视为通过）
+	AtLeast map[string]float64  // 字段数值必须不小于给定值
+
+	// DistinctFields 非空时按这些字段去重：同一用户同一目标只计一次（如点赞同一本书）
+	DistinctFields []string
+	// KeyFields 非去重计数时参与事件指纹的字段，用于区分同一时刻的不同事件
+	KeyFields []string
+
+	// ValueField 为空时每个事件计 1；否则累加 ValueField - BaselineField 的正增量
+	ValueField    string
+	BaselineField string
+}
+
+// DefaultAchievementCounterRules 默认计数规则
+// 同时监听 events 包中的常量事件与各服务实际发布的事件名
+func DefaultAchievementCounterRules() []AchievementCounterRule {
+	return []AchievementCounterRule{
+		{
+			ID:             CounterLikesGiven,
+			EventTypes:     []string{"like.added", "like.book.added", "like.comment.added"},
+			SubjectField:   "user_id",
+			DistinctFields: []string{"target_type", "target_id"},
+		},
+		{
+			ID:             CounterCommentsPosted,
+			EventTypes:     []string{"comment.created", "comment.replied"},
+			SubjectField:   "author_id|user_id",
+			Exclude:        map[string][]string{"state": {"rejected", "deleted"}},
+			DistinctFields: []string{"comment_id"},
+		},
+		{
+			ID:             CounterUsersFollowed,
+			EventTypes:     []string{"follow.added", "follow.created"},
+			SubjectField:   "follower_id",
+			Exclude:        map[string][]string{"follow_type": {"author"}},
+			DistinctFields: []string{"target_id|followee_id"},
+		},
+		{
+			ID:             CounterAuthorsFollowed,
+			EventTypes:     []string{"author_follow.created"},
+			SubjectField:   "follower_id",
+			DistinctFields: []string{"target_id"},
+		},
+		{
+			ID:             CounterChaptersRead,
+			EventTypes:     []string{"reader.chapter.read", "reading.chapter_read"},
+			SubjectField:   "user_id",
+			DistinctFields: []string{"chapter_id"},
+		},
+		{
+			ID:             CounterBooksCompleted,
+			EventTypes:     []string{"reader.progress.updated", "reading.book_completed"},
+			SubjectField:   "user_id",
+			AtLeast:        map[string]float64{"progress": 1},
+			DistinctFields: []string{"book_id"},
+		},
+		{
+			ID:            CounterWordsWritten,
+			EventTypes:    []string{"document.autosaved", "document.content_updated"},
+			SubjectField:  "user_id",
+			KeyFields:     []string{"document_id"},
+			ValueField:    "word_count",
+			BaselineField: "previous_word_count",
+		},
+	}
+}
+
+// DefaultAchievementDefinitions 默认成就定义（按展示顺序）
+func DefaultAchievementDefinitions() []social.AchievementDefinition {
+	return []social.AchievementDefinition{
+		{ID: "first_like", Name: "初次心动", Description: "第一次点赞", Icon: "like-1", Category: "social", CounterID: CounterLikesGiven, Threshold: 1},
+		{ID: "like_100", Name: "点赞达人", Description: "累计点赞100次", Icon: "like-100", Category: "social", CounterID: CounterLikesGiven, Threshold: 100},
+		{ID: "first_comment", Name: "初次发声", Description: "发表第一条评论", Icon: "comment-1", Category: "social", CounterID: CounterCommentsPosted, Threshold: 1},
+		{ID: "comment_10", Name: "健谈书友", Description: "累计发表10条评论", Icon: "comment-10", Category: "social", CounterID: CounterCommentsPosted, Threshold: 10},
+		{ID: "comment_100", Name: "评论大师", Description: "累计发表100条评论", Icon: "comment-100", Category: "social", CounterID: CounterCommentsPosted, Threshold: 100},
+		{ID: "follow_users_10", Name: "广结书缘", Description: "关注10位用户", Icon: "follow-10", Category: "social", CounterID: CounterUsersFollowed, Threshold: 10},
+		{ID: "follow_authors_10", Name: "追更达人", Description: "关注10位作者", Icon: "author-10", Category: "social", CounterID: CounterAuthorsFollowed, Threshold: 10},
+		{ID: "chapters_1", Name: "开卷有益", Description: "阅读第一个章节", Icon: "read-1", Category: "reading", CounterID: CounterChaptersRead, Threshold: 1},
+		{ID: "chapters_100", Name: "手不释卷", Description: "累计阅读100个章节", Icon: "read-100", Category: "reading", CounterID: CounterChaptersRead, Threshold: 100},
+		{ID: "chapters_1000", Name: "博览群书", Description: "累计阅读1000个章节", Icon: "read-1000", Category: "reading", CounterID: CounterChaptersRead, Threshold: 1000},
+		{ID: "books_1", Name: "善始善终", Description: "读完第一本书", Icon: "book-1", Category: "reading", CounterID: CounterBooksCompleted, Threshold: 1},
+		{ID: "books_10", Name: "十卷书虫", Description: "读完10本书", Icon: "book-10", Category: "reading", CounterID: CounterBooksCompleted, Threshold: 10},
+		{ID: "words_10k", Name: "笔耕不辍", Description: "累计写作1万字", Icon: "words-10k", Category: "writing", CounterID: CounterWordsWritten, Threshold: 10000},
+		{ID: "words_100k", Name: "十万字路", Description: "累计写作10万字", Icon: "words-100k", Category: "writing", CounterID: CounterWordsWritten, Threshold: 100000},
+		{ID: "words_1m", Name: "百万字作家", Description: "累计写作100万字", Icon: "words-1m", Category: "writing", CounterID: CounterWordsWritten, Threshold: 1000000},
+	}
+}
+
+// achievementHit 规则命中结果
+type achievementHit struct {
+	UserID      string
+	Delta       int64
+	Fingerprint string
+}
+
+// evaluate 计算事件对规则的贡献，不命中时返回 false
+func (r *AchievementCounterRule) evaluate(eventType string, at time.Time, data map[string]interface{}) (*achievementHit, bool) {
+	userID := achievementField(data, r.SubjectField)
+	if userID == "" {
+		return nil, false
+	}
+
+	for field, allowed := range r.Match {
+		if !containsString(allowed, achievementField(data, field)) {
+			return nil, false
+		}
+	}
+	for field, denied := range r.Exclude {
+		if value := achievementField(data, field); value != "" && containsString(denied, value) {
+			return nil, false
+		}
+	}
+	for field, min := range r.AtLeast {
+		value, ok := achievementNumber(data, field)
+		if ok && value < min {
+			return nil, false
+		}
+	}
+
+	delta := int64(1)
+	if r.ValueField != "" {
+		value, ok := achievementNumber(data, r.ValueField)
+		if !ok {
+			return nil, false
+		}
+		baseline, ok := achievementNumber(data, r.BaselineField)
+		if !ok {
+			return nil, false
+		}
+		delta = int64(value - baseline)
+		if delta <= 0 {
+			return nil, false
+		}
+	}
+
+	var parts []string
+	if len(r.DistinctFields) > 0 {
+		parts = []string{r.ID, userID}
+		for _, field := range r.DistinctFields {
+			value := achievementField(data, field)
+			if value == "" {
+				return nil, false
+			}
+			parts = append(parts, value)
+		}
+	} else {
+		// 回放的事件时间戳为毫秒精度，指纹统一取毫秒，保证实时与回放一致
+		parts = []string{r.ID, userID, eventType, strconv.FormatInt(at.UnixMilli(), 10)}
+		for _, field := range r.KeyFields {
+			parts = append(parts, achievementField(data, field))
+		}
+	}
+
+	return &achievementHit{
+		UserID:      userID,
+		Delta:       delta,
+		Fingerprint: strings.Join(parts, "|"),
+	}, true
+}
+
+// normalizeAchievementEventData 将事件数据统一为"规范化字段名 -> 值"
+// 支持实时发布的 map / 结构体，以及回放时解码得到的 bson 文档
+func normalizeAchievementEventData(raw interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	var doc map[string]interface{}
+
+	switch data := raw.(type) {
+	case nil:
+		return result
+	case map[string]interface{}:
+		doc = data
+	case primitive.M:
+		doc = data
+	case primitive.D:
+		doc = data.Map()
+	default:
+		// 结构体等类型通过 JSON 转成 map（与发布方的 json tag 保持一致）
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return result
+		}
+		if err := json.Unmarshal(bytes, &doc); err != nil {
+			return result
+		}
+	}
+
+	for key, value := range doc {
+		result[normalizeAchievementKey(key)] = value
+	}
+	// 展开内嵌文档（如回放时 CommentEventData 内嵌的 SocialEventData），不覆盖同名字段
+	for _, value := range doc {
+		var nested map[string]interface{}
+		switch v := value.(type) {
+		case primitive.D:
+			nested = v.Map()
+		case primitive.M:
+			nested = v
+		case map[string]interface{}:
+			nested = v
+		default:
+			continue
+		}
+		for key, nestedValue := range nested {
+			if _, exists := result[normalizeAchievementKey(key)]; !exists {
+				result[normalizeAchievementKey(key)] = nestedValue
+			}
+		}
+	}
+	return result
+}
+
+func normalizeAchievementKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+// achievementField 读取字符串字段，spec 可用 "a|b" 指定候选字段
+func achievementField(data map[string]interface{}, spec string) string {
+	for _, field := range strings.Split(spec, "|") {
+		value, ok := data[normalizeAchievementKey(field)]
+		if !ok || value == nil {
+			continue
+		}
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case primitive.ObjectID:
+			text = v.Hex()
+		case fmt.Stringer:
+			text = v.String()
+		default:
+			text = fmt.Sprint(v)
+		}
+		if text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// achievementNumber 读取数值字段
+func achievementNumber(data map[string]interface{}, spec string) (float64, bool) {
+	for _, field := range strings.Split(spec, "|") {
+		switch v := data[normalizeAchievementKey(field)].(type) {
+		case int:
+			return float64(v), true
+		case int32:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		case float32:
+			return float64(v), true
+		case float64:
+			return v, true
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"Qingyu_backend/models/notification"
+	"Qingyu_backend/models/social"
+	socialRepo "Qingyu_backend/repository/interfaces/social"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+)
+
+// achievementEventTimeout 单个事件的成就处理超时
+const achievementEventTimeout = 5 * time.Second
+
+// AchievementNotifier 成就通知发送接口（由通知服务实现）
+type AchievementNotifier interface {
+	SendNotification(ctx context.Context, userID string, notificationType notification.NotificationType, title, content string, data map[string]interface{}) error
+}
+
+// AchievementService 成就服务
+// 按声明式规则把事件累加为计数器进度，进度达到门槛时颁发徽章并发送通知
+type AchievementService struct {
+	repo         socialRepo.AchievementRepository
+	notifier     AchievementNotifier
+	replayer     events.PersistedEventBusInterface
+	rulesByEvent map[string][]AchievementCounterRule
+	eventTypes   []string
+	definitions  []social.AchievementDefinition
+	serviceName  string
+	version      string
+}
+
+// NewAchievementService 创建成就服务实例
+func NewAchievementService(
+	repo socialRepo.AchievementRepository,
+	notifier AchievementNotifier,
+) *AchievementService {
+	s := &AchievementService{
+		repo:        repo,
+		notifier:    notifier,
+		serviceName: "AchievementService",
+		version:     "1.0.0",
+	}
+	s.SetRules(DefaultAchievementCounterRules(), DefaultAchievementDefinitions())
+	return s
+}
+
+// SetRules 替换计数规则与成就定义
+func (s *AchievementService) SetRules(rules []AchievementCounterRule, definitions []social.AchievementDefinition) {
+	s.rulesByEvent = make(map[string][]AchievementCounterRule)
+	s.eventTypes = nil
+	for _, rule := range rules {
+		for _, eventType := range rule.EventTypes {
+			if _, exists := s.rulesByEvent[eventType]; !exists {
+				s.eventTypes = append(s.eventTypes, eventType)
+			}
+			s.rulesByEvent[eventType] = append(s.rulesByEvent[eventType], rule)
+		}
+	}
+	s.definitions = definitions
+}
+
+// SetEventReplayer 注入事件回放能力（用于成就回填）
+func (s *AchievementService) SetEventReplayer(replayer events.PersistedEventBusInterface) {
+	s.replayer = replayer
+}
+
+// =========================
+// BaseService 接口实现
+// =========================
+
+// Initialize 初始化服务
+func (s *AchievementService) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// Health 健康检查
+func (s *AchievementService) Health(ctx context.Context) error {
+	if err := s.repo.Health(ctx); err != nil {
+		return fmt.Errorf("成就Repository健康检查失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭服务
+func (s *AchievementService) Close(ctx context.Context) error {
+	return nil
+}
+
+// GetServiceName 获取服务名称
+func (s *AchievementService) GetServiceName() string {
+	return s.serviceName
+}
+
+// GetVersion 获取服务版本
+func (s *AchievementService) GetVersion() string {
+	return s.version
+}
+
+// =========================
+// 事件处理
+// =========================
+
+// SupportedEventTypes 返回成就规则监听的全部事件类型
+func (s *AchievementService) SupportedEventTypes() []string {
+	return append([]string(nil), s.eventTypes...)
+}
+
+// ProcessEvent 处理实时事件
+// 异步发布时请求 ctx 可能已取消，这里脱离取消信号并单独设置超时
+func (s *AchievementService) ProcessEvent(ctx context.Context, event base.Event) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), achievementEventTimeout)
+	defer cancel()
+
+	_, err := s.apply(ctx, event, true)
+	return err
+}
+
+// apply 将事件计入所有匹配规则，返回新解锁的成就数
+func (s *AchievementService) apply(ctx context.Context, event base.Event, notify bool) (int, error) {
+	rules := s.rulesByEvent[event.GetEventType()]
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	at := event.GetTimestamp()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	data := normalizeAchievementEventData(event.GetEventData())
+
+	awarded := 0
+	var firstErr error
+	for i := range rules {
+		rule := &rules[i]
+		hit, ok := rule.evaluate(event.GetEventType(), at, data)
+		if !ok {
+			continue
+		}
+
+		applied, err := s.repo.MarkApplied(ctx, hit.Fingerprint, hit.UserID, rule.ID, at)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !applied {
+			// 同一事件（或同一去重目标）已计入
+			continue
+		}
+
+		value, err := s.repo.IncrementProgress(ctx, hit.UserID, rule.ID, hit.Delta, time.Now())
+		if err != nil {
+			if unmarkErr := s.repo.UnmarkApplied(ctx, hit.Fingerprint); unmarkErr != nil {
+				log.Printf("[Achievement] 回滚事件指纹失败: %v", unmarkErr)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		count, err := s.awardReached(ctx, hit.UserID, rule.ID, value, notify)
+		awarded += count
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return awarded, firstErr
+}
+
+// awardReached 颁发计数器已达到门槛的成就（颁发本身幂等）
+func (s *AchievementService) awardReached(ctx context.Context, userID, counterID string, value int64, notify bool) (int, error) {
+	awarded := 0
+	for i := range s.definitions {
+		def := &s.definitions[i]
+		if def.CounterID != counterID || value < def.Threshold {
+			continue
+		}
+
+		created, err := s.repo.AwardAchievement(ctx, &social.UserAchievement{
+			UserID:        userID,
+			AchievementID: def.ID,
+			UnlockedAt:    time.Now(),
+		})
+		if err != nil {
+			return awarded, err
+		}
+		if !created {
+			continue
+		}
+		awarded++
+
+		if notify && s.notifier != nil {
+			err := s.notifier.SendNotification(ctx, userID, notification.NotificationTypeReward,
+				fmt.Sprintf("获得成就：%s", def.Name),
+				def.Description,
+				map[string]interface{}{
+					"achievement_id": def.ID,
+					"icon":           def.Icon,
+					"category":       def.Category,
+				})
+			if err != nil {
+				// 通知失败不影响成就颁发
+				log.Printf("[Achievement] 发送成就通知失败: user=%s achievement=%s err=%v", userID, def.ID, err)
+			}
+		}
+	}
+	return awarded, nil
+}
+
+// =========================
+// 查询
+// =========================
+
+// ListUserAchievements 获取用户已解锁与进行中的成就
+func (s *AchievementService) ListUserAchievements(ctx context.Context, userID string) (*social.AchievementOverview, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("用户ID不能为空")
+	}
+
+	progressList, err := s.repo.ListProgress(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取成就进度失败: %w", err)
+	}
+	unlockedList, err := s.repo.ListUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取已解锁成就失败: %w", err)
+	}
+
+	progress := make(map[string]int64, len(progressList))
+	for _, p := range progressList {
+		progress[p.CounterID] = p.Value
+	}
+	unlocked := make(map[string]time.Time, len(unlockedList))
+	for _, a := range unlockedList {
+		unlocked[a.AchievementID] = a.UnlockedAt
+	}
+
+	overview := &social.AchievementOverview{
+		Earned:     []*social.AchievementView{},
+		InProgress: []*social.AchievementView{},
+		Total:      len(s.definitions),
+	}
+	for _, def := range s.definitions {
+		view := &social.AchievementView{
+			AchievementDefinition: def,
+			Progress:              progress[def.CounterID],
+		}
+		if unlockedAt, ok := unlocked[def.ID]; ok {
+			view.Unlocked = true
+			view.UnlockedAt = &unlockedAt
+			if view.Progress < def.Threshold {
+				// 进度来自后续规则调整时，已解锁成就仍显示为满进度
+				view.Progress = def.Threshold
+			}
+			overview.Earned = append(overview.Earned, view)
+			continue
+		}
+		if view.Progress > 0 {
+			overview.InProgress = append(overview.InProgress, view)
+		}
+	}
+	return overview, nil
+}
+
+// ListEarnedAchievements 获取用户已解锁的成就（用于查看他人主页）
+func (s *AchievementService) ListEarnedAchievements(ctx context.Context, userID string) ([]*social.AchievementView, error) {
+	overview, err := s.ListUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return overview.Earned, nil
+}
+
+// =========================
+// 回填
+// =========================
+
+// BackfillAchievements 回放事件存储中的历史事件，为存量用户补算成就
+// 事件指纹保证与实时处理不重复计数；回填不发送通知
+func (s *AchievementService) BackfillAchievements(ctx context.Context, since *time.Time) (*social.AchievementBackfillResult, error) {
+	if s.replayer == nil {
+		return nil, fmt.Errorf("事件存储未配置，无法回填成就")
+	}
+
+	handler := &achievementBackfillHandler{service: s}
+	result := &social.AchievementBackfillResult{}
+	for _, eventType := range s.eventTypes {
+		replayed, err := s.replayer.Replay(ctx, handler, events.EventFilter{
+			EventType: eventType,
+			StartTime: since,
+		})
+		if err != nil {
+			return result, fmt.Errorf("回放事件 %s 失败: %w", eventType, err)
+		}
+		result.ReplayedCount += replayed.ReplayedCount
+		result.FailedCount += replayed.FailedCount
+	}
+	result.AwardedCount = handler.awarded
+	return result, nil
+}
+
+// achievementBackfillHandler 回填用事件处理器（不发送通知）
+type achievementBackfillHandler struct {
+	service *AchievementService
+	awarded int
+}
+
+// Handle 处理回放事件
+func (h *achievementBackfillHandler) Handle(ctx context.Context, event base.Event) error {
+	awarded, err := h.service.apply(ctx, event, false)
+	h.awarded += awarded
+	return err
+}
+
+// GetHandlerName 获取处理器名称
+func (h *achievementBackfillHandler) GetHandlerName() string {
+	return "AchievementBackfillHandler"
+}
+
+// GetSupportedEventTypes 获取支持的事件类型
+func (h *achievementBackfillHandler) GetSupportedEventTypes() []string {
+	return h.service.SupportedEventTypes()
+}
@@ -0,0 +1,253 @@
+package social
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/notification"
+	socialModel "Qingyu_backend/models/social"
+	"Qingyu_backend/service/base"
+	"Qingyu_backend/service/events"
+)
+
+type fakeAchievementRepo struct {
+	applied  map[string]bool
+	progress map[string]int64
+	awarded  map[string]time.Time
+}
+
+func newFakeAchievementRepo() *fakeAchievementRepo {
+	return &fakeAchievementRepo{
+		applied:  make(map[string]bool),
+		progress: make(map[string]int64),
+		awarded:  make(map[string]time.Time),
+	}
+}
+
+func (r *fakeAchievementRepo) MarkApplied(ctx context.Context, fingerprint, userID, counterID string, at time.Time) (bool, error) {
+	if r.applied[fingerprint] {
+		return false, nil
+	}
+	r.applied[fingerprint] = true
+	return true, nil
+}
+
+func (r *fakeAchievementRepo) UnmarkApplied(ctx context.Context, fingerprint string) error {
+	delete(r.applied, fingerprint)
+	return nil
+}
+
+func (r *fakeAchievementRepo) IncrementProgress(ctx context.Context, userID, counterID string, delta int64, at time.Time) (int64, error) {
+	r.progress[userID+"|"+counterID] += delta
+	return r.progress[userID+"|"+counterID], nil
+}
+
+func (r *fakeAchievementRepo) ListProgress(ctx context.Context, userID string) ([]*socialModel.AchievementProgress, error) {
+	var list []*socialModel.AchievementProgress
+	for key, value := range r.progress {
+		for _, def := range DefaultAchievementCounterRules() {
+			if key == userID+"|"+def.ID {
+				list = append(list, &socialModel.AchievementProgress{UserID: userID, CounterID: def.ID, Value: value})
+			}
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeAchievementRepo) AwardAchievement(ctx context.Context, achievement *socialModel.UserAchievement) (bool, error) {
+	key := achievement.UserID + "|" + achievement.AchievementID
+	if _, ok := r.awarded[key]; ok {
+		return false, nil
+	}
+	r.awarded[key] = achievement.UnlockedAt
+	return true, nil
+}
+
+func (r *fakeAchievementRepo) ListUserAchievements(ctx context.Context, userID string) ([]*socialModel.UserAchievement, error) {
+	var list []*socialModel.UserAchievement
+	for _, def := range DefaultAchievementDefinitions() {
+		if at, ok := r.awarded[userID+"|"+def.ID]; ok {
+			list = append(list, &socialModel.UserAchievement{UserID: userID, AchievementID: def.ID, UnlockedAt: at})
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeAchievementRepo) Health(ctx context.Context) error { return nil }
+
+type fakeAchievementNotifier struct {
+	titles []string
+}
+
+func (n *fakeAchievementNotifier) SendNotification(ctx context.Context, userID string, notificationType notification.NotificationType, title, content string, data map[string]interface{}) error {
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+// fakeEventReplayer 按事件类型回放预置事件
+type fakeEventReplayer struct {
+	stored []base.Event
+}
+
+func (r *fakeEventReplayer) Replay(ctx context.Context, handler base.EventHandler, filter events.EventFilter) (*events.ReplayResult, error) {
+	result := &events.ReplayResult{}
+	for _, event := range r.stored {
+		if event.GetEventType() != filter.EventType {
+			continue
+		}
+		if err := handler.Handle(ctx, event); err != nil {
+			result.FailedCount++
+			continue
+		}
+		result.ReplayedCount++
+	}
+	return result, nil
+}
+
+func achievementEvent(eventType string, data interface{}, at time.Time) base.Event {
+	return &base.BaseEvent{EventType: eventType, EventData: data, Timestamp: at}
+}
+
+func TestAchievementService_LikeCountsDistinctTargetsAndNotifies(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	notifier := &fakeAchievementNotifier{}
+	svc := NewAchievementService(repo, notifier)
+	ctx := context.Background()
+	now := time.Now()
+
+	like := map[string]interface{}{"user_id": "u1", "target_type": "book", "target_id": "b1", "like_count": 3}
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("like.book.added", like, now)))
+	// 取消后再次点赞同一本书不重复计数
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("like.book.added", like, now.Add(time.Minute))))
+
+	assert.Equal(t, int64(1), repo.progress["u1|"+CounterLikesGiven])
+	assert.Contains(t, repo.awarded, "u1|first_like")
+	assert.Equal(t, []string{"获得成就：初次心动"}, notifier.titles)
+}
+
+func TestAchievementService_TypedEventPayload(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	svc := NewAchievementService(repo, nil)
+
+	event := events.NewFollowAddedEvent("u1", "u2")
+	require.NoError(t, svc.ProcessEvent(context.Background(), event))
+
+	assert.Equal(t, int64(1), repo.progress["u1|"+CounterUsersFollowed])
+}
+
+func TestAchievementService_FiltersRejectedCommentsAndAuthorFollows(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	svc := NewAchievementService(repo, nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("comment.created", map[string]interface{}{
+		"comment_id": primitive.NewObjectID(), "author_id": "u1", "state": socialModel.CommentStateRejected,
+	}, now)))
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("comment.created", map[string]interface{}{
+		"comment_id": primitive.NewObjectID(), "author_id": "u1", "state": socialModel.CommentStateNormal,
+	}, now)))
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("follow.created", map[string]interface{}{
+		"follower_id": "u1", "target_id": "a1", "follow_type": "author",
+	}, now)))
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("author_follow.created", map[string]interface{}{
+		"follower_id": "u1", "target_id": "a1", "follow_type": "author",
+	}, now)))
+
+	assert.Equal(t, int64(1), repo.progress["u1|"+CounterCommentsPosted])
+	assert.Zero(t, repo.progress["u1|"+CounterUsersFollowed])
+	assert.Equal(t, int64(1), repo.progress["u1|"+CounterAuthorsFollowed])
+}
+
+func TestAchievementService_WordsWrittenCountsPositiveDelta(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	svc := NewAchievementService(repo, nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	save := func(at time.Time, prev, count int) {
+		require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("document.autosaved", map[string]interface{}{
+			"document_id": "d1", "user_id": "u1", "word_count": count, "previous_word_count": prev,
+		}, at)))
+	}
+	save(now, 0, 6000)
+	save(now.Add(time.Second), 6000, 5000) // 删减不计
+	save(now.Add(2*time.Second), 5000, 9000)
+
+	assert.Equal(t, int64(10000), repo.progress["u1|"+CounterWordsWritten])
+	assert.Contains(t, repo.awarded, "u1|words_10k")
+}
+
+func TestAchievementService_ListUserAchievements(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	svc := NewAchievementService(repo, nil)
+	ctx := context.Background()
+
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("reader.chapter.read", map[string]interface{}{
+		"user_id": "u1", "chapter_id": "c1",
+	}, time.Now())))
+
+	overview, err := svc.ListUserAchievements(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, overview.Earned, 1)
+	assert.Equal(t, "chapters_1", overview.Earned[0].ID)
+	assert.True(t, overview.Earned[0].Unlocked)
+
+	var inProgress []string
+	for _, view := range overview.InProgress {
+		inProgress = append(inProgress, view.ID)
+		assert.Equal(t, int64(1), view.Progress)
+	}
+	assert.Equal(t, []string{"chapters_100", "chapters_1000"}, inProgress)
+	assert.Equal(t, len(DefaultAchievementDefinitions()), overview.Total)
+}
+
+func TestAchievementService_BackfillIsIdempotentWithLiveEvents(t *testing.T) {
+	repo := newFakeAchievementRepo()
+	notifier := &fakeAchievementNotifier{}
+	svc := NewAchievementService(repo, notifier)
+	ctx := context.Background()
+	at := time.Now()
+
+	// 实时事件已处理过一次
+	require.NoError(t, svc.ProcessEvent(ctx, achievementEvent("document.content_updated", map[string]interface{}{
+		"document_id": "d1", "user_id": "u1", "word_count": 3000, "previous_word_count": 1000,
+	}, at)))
+	notifier.titles = nil
+
+	// 回放时数据解码为 bson 文档，时间戳为毫秒精度
+	svc.SetEventReplayer(&fakeEventReplayer{stored: []base.Event{
+		achievementEvent("document.content_updated", primitive.D{
+			{Key: "document_id", Value: "d1"}, {Key: "user_id", Value: "u1"},
+			{Key: "word_count", Value: int32(3000)}, {Key: "previous_word_count", Value: int32(1000)},
+		}, at.Truncate(time.Millisecond)),
+		achievementEvent("document.content_updated", primitive.D{
+			{Key: "document_id", Value: "d1"}, {Key: "user_id", Value: "u1"},
+			{Key: "word_count", Value: int32(11000)}, {Key: "previous_word_count", Value: int32(3000)},
+		}, at.Add(time.Minute).Truncate(time.Millisecond)),
+		achievementEvent("reading.chapter_read", primitive.D{
+			{Key: "userid", Value: "u2"}, {Key: "chapterid", Value: "c9"},
+		}, at),
+	}})
+
+	result, err := svc.BackfillAchievements(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.ReplayedCount)
+	assert.Equal(t, 2, result.AwardedCount)
+	assert.Equal(t, int64(10000), repo.progress["u1|"+CounterWordsWritten])
+	assert.Contains(t, repo.awarded, "u1|words_10k")
+	assert.Contains(t, repo.awarded, "u2|chapters_1")
+	assert.Empty(t, notifier.titles, "回填不应发送通知")
+}
+
+func TestAchievementService_BackfillRequiresReplayer(t *testing.T) {
+	svc := NewAchievementService(newFakeAchievementRepo(), nil)
+
+	_, err := svc.BackfillAchievements(context.Background(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,489 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// ContinuityService 故事连贯性检查服务
+// 基于规则交叉比对时间线事件、角色关系、地点与章节关联，输出项目级报告：
+//   - 同一角色在同一故事时间出现在不同地点
+//   - 事件的故事时间与章节顺序相悖（可能是倒叙，记为警告）
+//   - 角色关系在失效章节之后仍有变化记录
+//   - 角色在首次登场章节之前出现
+type ContinuityService struct {
+	documentRepo      DocumentRepository
+	characterRepo     writerRepo.CharacterRepository
+	locationRepo      writerRepo.LocationRepository
+	timelineEventRepo writerRepo.TimelineEventRepository
+}
+
+// NewContinuityService 创建ContinuityService实例
+func NewContinuityService(
+	documentRepo DocumentRepository,
+	characterRepo writerRepo.CharacterRepository,
+	locationRepo writerRepo.LocationRepository,
+	timelineEventRepo writerRepo.TimelineEventRepository,
+) serviceInterfaces.ContinuityService {
+	return &ContinuityService{
+		documentRepo:      documentRepo,
+		characterRepo:     characterRepo,
+		locationRepo:      locationRepo,
+		timelineEventRepo: timelineEventRepo,
+	}
+}
+
+// continuityContext 一次检查所需的项目数据
+type continuityContext struct {
+	chapterOrder map[string]int // 文档ID -> 阅读顺序
+	chapterTitle map[string]string
+	characters   map[string]*writer.Character
+	locations    map[string]*writer.Location
+	events       []*writer.TimelineEvent
+	relations    []*writer.CharacterRelation
+	documents    []*writer.Document
+}
+
+// CheckProject 检查项目的故事连贯性
+func (s *ContinuityService) CheckProject(ctx context.Context, projectID string) (*writer.ContinuityReport, error) {
+	if projectID == "" {
+		return nil, errors.NewServiceError("ContinuityService", errors.ServiceErrorValidation, "项目ID不能为空", "", nil)
+	}
+
+	cc, err := s.load(ctx, projectID)
+	if err != nil {
+		return nil, errors.NewServiceError("ContinuityService", errors.ServiceErrorInternal, "加载项目设定失败", "", err)
+	}
+
+	report := &writer.ContinuityReport{
+		ProjectID: projectID,
+		Issues:    []writer.ContinuityIssue{},
+		CheckedAt: time.Now(),
+	}
+	cc.checkLocationConflicts(report)
+	cc.checkChronology(report)
+	cc.checkRelationValidity(report)
+	cc.checkIntroductions(report)
+
+	return report, nil
+}
+
+// load 加载检查所需的数据
+func (s *ContinuityService) load(ctx context.Context, projectID string) (*continuityContext, error) {
+	cc := &continuityContext{
+		chapterOrder: make(map[string]int),
+		chapterTitle: make(map[string]string),
+		characters:   make(map[string]*writer.Character),
+		locations:    make(map[string]*writer.Location),
+	}
+
+	if s.documentRepo != nil {
+		documents, err := s.documentRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		cc.documents = documents
+		for i, doc := range flattenDocumentTree(documents) {
+			cc.chapterOrder[doc.ID.Hex()] = i
+			cc.chapterTitle[doc.ID.Hex()] = doc.Title
+		}
+	}
+
+	if s.characterRepo != nil {
+		characters, err := s.characterRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		for _, char := range characters {
+			cc.characters[char.ID.Hex()] = char
+		}
+		if cc.relations, err = s.characterRepo.FindRelations(ctx, projectID, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.locationRepo != nil {
+		locations, err := s.locationRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		for _, loc := range locations {
+			cc.locations[loc.ID.Hex()] = loc
+		}
+	}
+
+	if s.timelineEventRepo != nil {
+		events, err := s.timelineEventRepo.FindByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		cc.events = events
+	}
+
+	return cc, nil
+}
+
+// flattenDocumentTree 按目录树先序遍历得到阅读顺序
+func flattenDocumentTree(documents []*writer.Document) []*writer.Document {
+	tree := buildDocumentTree(documents)
+	ordered := make([]*writer.Document, 0, len(documents))
+	visited := make(map[string]bool, len(documents))
+
+	var walk func(parentID string)
+	walk = func(parentID string) {
+		for _, doc := range tree[parentID] {
+			id := doc.ID.Hex()
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			ordered = append(ordered, doc)
+			walk(id)
+		}
+	}
+	walk("")
+
+	// 父节点缺失的孤立文档排在最后
+	for _, doc := range documents {
+		if !visited[doc.ID.Hex()] {
+			visited[doc.ID.Hex()] = true
+			ordered = append(ordered, doc)
+			walk(doc.ID.Hex())
+		}
+	}
+	return ordered
+}
+
+// ============ 规则：同一时间不同地点 ============
+
+// checkLocationConflicts 检查同一角色在同一故事时间出现在互不包含的地点
+func (cc *continuityContext) checkLocationConflicts(report *writer.ContinuityReport) {
+	// 按故事时刻分组，只比较同一时刻的事件
+	byMoment := make(map[string][]*writer.TimelineEvent)
+	var moments []string
+	for _, event := range cc.events {
+		key := momentKey(event.StoryTime)
+		if key == "" || len(event.LocationIDs) == 0 || len(event.Participants) == 0 {
+			continue
+		}
+		if byMoment[key] == nil {
+			moments = append(moments, key)
+		}
+		byMoment[key] = append(byMoment[key], event)
+	}
+
+	for _, key := range moments {
+		events := byMoment[key]
+		for i := 0; i < len(events); i++ {
+			for j := i + 1; j < len(events); j++ {
+				a, b := events[i], events[j]
+				shared := intersect(a.Participants, b.Participants)
+				if len(shared) == 0 || cc.locationsOverlap(a.LocationIDs, b.LocationIDs) {
+					continue
+				}
+
+				severity := writer.ContinuitySeverityWarning
+				if a.StoryTime != nil && a.StoryTime.Hour > 0 {
+					severity = writer.ContinuitySeverityError
+				}
+				report.AddIssue(writer.ContinuityIssue{
+					Type:     writer.ContinuityLocationConflict,
+					Severity: severity,
+					Message: fmt.Sprintf("%s在%s同时出现在「%s」（%s）与「%s」（%s）",
+						cc.characterNames(shared), a.StoryTime.GetTimeString(),
+						a.Title, cc.locationNames(a.LocationIDs),
+						b.Title, cc.locationNames(b.LocationIDs)),
+					CharacterIDs: shared,
+					LocationIDs:  append(append([]string{}, a.LocationIDs...), b.LocationIDs...),
+					EventIDs:     []string{a.ID.Hex(), b.ID.Hex()},
+				})
+			}
+		}
+	}
+}
+
+// momentKey 故事时刻的比较键，精度不足（未到日）的时间不参与冲突判断
+func momentKey(st *writer.StoryTime) string {
+	if st == nil {
+		return ""
+	}
+	if st.Year > 0 && st.Day > 0 {
+		return fmt.Sprintf("%s|%d|%d|%d|%d|%d", st.Era, st.Year, st.Month, st.Day, st.Hour, st.Minute)
+	}
+	if st.Year == 0 && st.Description != "" {
+		return "desc|" + strings.TrimSpace(st.Description)
+	}
+	return ""
+}
+
+// locationsOverlap 两组地点是否存在相同或上下级关系（如城池与城中酒楼）
+func (cc *continuityContext) locationsOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y || cc.isAncestor(x, y) || cc.isAncestor(y, x) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAncestor 判断 ancestor 是否为 locationID 的上级地点
+func (cc *continuityContext) isAncestor(ancestor, locationID string) bool {
+	seen := make(map[string]bool)
+	for loc := cc.locations[locationID]; loc != nil && loc.ParentID != "" && !seen[loc.ParentID]; loc = cc.locations[loc.ParentID] {
+		if loc.ParentID == ancestor {
+			return true
+		}
+		seen[loc.ParentID] = true
+	}
+	return false
+}
+
+// ============ 规则：故事时间与章节顺序 ============
+
+// checkChronology 同一时间线内，按章节顺序排列的事件其故事时间不应倒退
+func (cc *continuityContext) checkChronology(report *writer.ContinuityReport) {
+	type placedEvent struct {
+		event *writer.TimelineEvent
+		order int
+	}
+
+	byTimeline := make(map[string][]placedEvent)
+	var timelines []string
+	for _, event := range cc.events {
+		order, ok := cc.firstChapter(event.ChapterIDs)
+		if !ok || event.StoryTime == nil || event.StoryTime.Year == 0 {
+			continue
+		}
+		if byTimeline[event.TimelineID] == nil {
+			timelines = append(timelines, event.TimelineID)
+		}
+		byTimeline[event.TimelineID] = append(byTimeline[event.TimelineID], placedEvent{event: event, order: order})
+	}
+
+	for _, timelineID := range timelines {
+		placed := byTimeline[timelineID]
+		sort.SliceStable(placed, func(i, j int) bool {
+			return placed[i].order < placed[j].order
+		})
+
+		for i := 1; i < len(placed); i++ {
+			prev, cur := placed[i-1], placed[i]
+			if prev.order == cur.order {
+				continue
+			}
+			if cmp, ok := cur.event.StoryTime.Compare(prev.event.StoryTime); ok && cmp < 0 {
+				report.AddIssue(writer.ContinuityIssue{
+					Type:     writer.ContinuityChronologyOrder,
+					Severity: writer.ContinuitySeverityWarning,
+					Message: fmt.Sprintf("「%s」（%s）位于章节「%s」，晚于「%s」（%s），但故事时间更早；如非倒叙请检查",
+						cur.event.Title, cur.event.StoryTime.GetTimeString(), cc.chapterTitleAt(cur.event.ChapterIDs),
+						prev.event.Title, prev.event.StoryTime.GetTimeString()),
+					EventIDs:   []string{prev.event.ID.Hex(), cur.event.ID.Hex()},
+					ChapterIDs: []string{cc.firstChapterID(prev.event.ChapterIDs), cc.firstChapterID(cur.event.ChapterIDs)},
+				})
+			}
+		}
+	}
+}
+
+// ============ 规则：关系失效后仍被使用 ============
+
+// checkRelationValidity 检查关系生效区间与变化记录
+func (cc *continuityContext) checkRelationValidity(report *writer.ContinuityReport) {
+	for _, relation := range cc.relations {
+		if relation.ValidUntilChapterID == nil {
+			continue
+		}
+		untilID := *relation.ValidUntilChapterID
+		untilOrder, ok := cc.chapterOrder[untilID]
+		if !ok {
+			continue
+		}
+		relationName := fmt.Sprintf("%s与%s的%s关系", cc.characterName(relation.FromID), cc.characterName(relation.ToID), relation.Type)
+
+		if relation.ValidFromChapterID != nil {
+			if fromOrder, ok := cc.chapterOrder[*relation.ValidFromChapterID]; ok && fromOrder >= untilOrder {
+				report.AddIssue(writer.ContinuityIssue{
+					Type:         writer.ContinuityRelationAfterEnd,
+					Severity:     writer.ContinuitySeverityError,
+					Message:      fmt.Sprintf("%s的生效章节「%s」不早于失效章节「%s」", relationName, cc.chapterTitle[*relation.ValidFromChapterID], cc.chapterTitle[untilID]),
+					CharacterIDs: []string{relation.FromID, relation.ToID},
+					ChapterIDs:   []string{*relation.ValidFromChapterID, untilID},
+					RelationID:   relation.ID.Hex(),
+				})
+			}
+		}
+
+		for _, change := range relation.TimelineEvents {
+			order, ok := cc.chapterOrder[change.ChapterID]
+			if !ok || order <= untilOrder {
+				continue
+			}
+			report.AddIssue(writer.ContinuityIssue{
+				Type:         writer.ContinuityRelationAfterEnd,
+				Severity:     writer.ContinuitySeverityError,
+				Message:      fmt.Sprintf("%s已于「%s」结束，但在「%s」仍有变化记录", relationName, cc.chapterTitle[untilID], cc.chapterTitle[change.ChapterID]),
+				CharacterIDs: []string{relation.FromID, relation.ToID},
+				ChapterIDs:   []string{untilID, change.ChapterID},
+				RelationID:   relation.ID.Hex(),
+			})
+		}
+	}
+}
+
+// ============ 规则：登场前出现 ============
+
+// checkIntroductions 角色不应在首次登场章节之前参与事件或被正文提及
+func (cc *continuityContext) checkIntroductions(report *writer.ContinuityReport) {
+	introOrder := make(map[string]int)
+	introChapter := make(map[string]string)
+	for id, char := range cc.characters {
+		if char.FirstAppearanceID == nil {
+			continue
+		}
+		if chapterID, order, ok := cc.resolveChapter(*char.FirstAppearanceID); ok {
+			introOrder[id] = order
+			introChapter[id] = chapterID
+		}
+	}
+	if len(introOrder) == 0 {
+		return
+	}
+
+	for _, event := range cc.events {
+		order, ok := cc.firstChapter(event.ChapterIDs)
+		if !ok {
+			continue
+		}
+		for _, charID := range event.Participants {
+			intro, ok := introOrder[charID]
+			if !ok || order >= intro {
+				continue
+			}
+			report.AddIssue(writer.ContinuityIssue{
+				Type:     writer.ContinuityAppearanceBeforeIntro,
+				Severity: writer.ContinuitySeverityError,
+				Message: fmt.Sprintf("%s在「%s」登场，却参与了更早章节「%s」中的事件「%s」",
+					cc.characterName(charID), cc.chapterTitle[introChapter[charID]], cc.chapterTitleAt(event.ChapterIDs), event.Title),
+				CharacterIDs: []string{charID},
+				EventIDs:     []string{event.ID.Hex()},
+				ChapterIDs:   []string{cc.firstChapterID(event.ChapterIDs), introChapter[charID]},
+			})
+		}
+	}
+
+	// 正文关联由实体识别自动生成，可能是伏笔式提及，记为警告
+	for _, doc := range cc.documents {
+		order := cc.chapterOrder[doc.ID.Hex()]
+		for _, charOID := range doc.CharacterIDs {
+			charID := charOID.Hex()
+			intro, ok := introOrder[charID]
+			if !ok || order >= intro {
+				continue
+			}
+			report.AddIssue(writer.ContinuityIssue{
+				Type:         writer.ContinuityAppearanceBeforeIntro,
+				Severity:     writer.ContinuitySeverityWarning,
+				Message:      fmt.Sprintf("%s在「%s」登场，却已出现在更早的章节「%s」", cc.characterName(charID), cc.chapterTitle[introChapter[charID]], doc.Title),
+				CharacterIDs: []string{charID},
+				ChapterIDs:   []string{doc.ID.Hex(), introChapter[charID]},
+			})
+		}
+	}
+}
+
+// resolveChapter 解析登场位置：可以是文档ID，也可以是与文档关联的大纲节点ID
+func (cc *continuityContext) resolveChapter(id string) (string, int, bool) {
+	if order, ok := cc.chapterOrder[id]; ok {
+		return id, order, true
+	}
+	for _, doc := range cc.documents {
+		if doc.OutlineNodeID == id {
+			docID := doc.ID.Hex()
+			return docID, cc.chapterOrder[docID], true
+		}
+	}
+	return "", 0, false
+}
+
+// ============ 辅助方法 ============
+
+// firstChapter 事件关联章节中最靠前的阅读顺序
+func (cc *continuityContext) firstChapter(chapterIDs []string) (int, bool) {
+	first, found := 0, false
+	for _, id := range chapterIDs {
+		if order, ok := cc.chapterOrder[id]; ok && (!found || order < first) {
+			first, found = order, true
+		}
+	}
+	return first, found
+}
+
+// firstChapterID 事件关联章节中最靠前的章节ID
+func (cc *continuityContext) firstChapterID(chapterIDs []string) string {
+	firstID := ""
+	for _, id := range chapterIDs {
+		order, ok := cc.chapterOrder[id]
+		if ok && (firstID == "" || order < cc.chapterOrder[firstID]) {
+			firstID = id
+		}
+	}
+	return firstID
+}
+
+func (cc *continuityContext) chapterTitleAt(chapterIDs []string) string {
+	return cc.chapterTitle[cc.firstChapterID(chapterIDs)]
+}
+
+func (cc *continuityContext) characterName(id string) string {
+	if char, ok := cc.characters[id]; ok {
+		return char.Name
+	}
+	return id
+}
+
+func (cc *continuityContext) characterNames(ids []string) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, cc.characterName(id))
+	}
+	return strings.Join(names, "、")
+}
+
+func (cc *continuityContext) locationNames(ids []string) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if loc, ok := cc.locations[id]; ok {
+			names = append(names, loc.Name)
+		} else {
+			names = append(names, id)
+		}
+	}
+	return strings.Join(names, "、")
+}
+
+// intersect 两个字符串切片的交集（保持 a 的顺序）
+func intersect(a, b []string) []string {
+	set := make(map[string]bool, len(b))
+	for _, v := range b {
+		set[v] = true
+	}
+	var result []string
+	for _, v := range a {
+		if set[v] {
+			result = append(result, v)
+			delete(set, v)
+		}
+	}
+	return result
+}
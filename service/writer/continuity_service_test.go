@@ -0,0 +1,261 @@
+package writer
+
+import (
+	"context"
+	"testing"
+
+	"Qingyu_backend/models/writer"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubContinuityCharacterRepo 仅实现连贯性检查所需的角色查询
+type stubContinuityCharacterRepo struct {
+	writerRepo.CharacterRepository
+	characters []*writer.Character
+	relations  []*writer.CharacterRelation
+}
+
+func (r *stubContinuityCharacterRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Character, error) {
+	return r.characters, nil
+}
+
+func (r *stubContinuityCharacterRepo) FindRelations(ctx context.Context, projectID string, characterID *string) ([]*writer.CharacterRelation, error) {
+	return r.relations, nil
+}
+
+// stubContinuityLocationRepo 仅实现按项目查询地点
+type stubContinuityLocationRepo struct {
+	writerRepo.LocationRepository
+	locations []*writer.Location
+}
+
+func (r *stubContinuityLocationRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Location, error) {
+	return r.locations, nil
+}
+
+// stubTimelineEventRepo 仅实现按项目查询时间线事件
+type stubTimelineEventRepo struct {
+	writerRepo.TimelineEventRepository
+	events []*writer.TimelineEvent
+}
+
+func (r *stubTimelineEventRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.TimelineEvent, error) {
+	return r.events, nil
+}
+
+// continuityFixture 两卷四章的测试项目
+type continuityFixture struct {
+	projectID  string
+	chapters   []*writer.Document // 按阅读顺序
+	documents  *MockDocumentRepositoryForExport
+	characters *stubContinuityCharacterRepo
+	locations  *stubContinuityLocationRepo
+	events     *stubTimelineEventRepo
+}
+
+func newContinuityFixture() *continuityFixture {
+	projectID := primitive.NewObjectID().Hex()
+	volume1 := createTestDocument(projectID, "第一卷")
+	volume1.Order = 0
+	volume2 := createTestDocument(projectID, "第二卷")
+	volume2.Order = 1
+
+	var chapters []*writer.Document
+	for i, title := range []string{"第一章", "第二章", "第三章", "第四章"} {
+		chapter := createTestDocument(projectID, title)
+		chapter.Order = i % 2
+		chapter.ParentID = volume1.ID
+		if i >= 2 {
+			chapter.ParentID = volume2.ID
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	documents := new(MockDocumentRepositoryForExport)
+	// 返回顺序与阅读顺序无关
+	documents.On("FindByProjectID", mock.Anything, projectID).
+		Return([]*writer.Document{chapters[3], volume2, chapters[0], chapters[2], volume1, chapters[1]}, nil)
+
+	return &continuityFixture{
+		projectID:  projectID,
+		chapters:   chapters,
+		documents:  documents,
+		characters: &stubContinuityCharacterRepo{},
+		locations:  &stubContinuityLocationRepo{},
+		events:     &stubTimelineEventRepo{},
+	}
+}
+
+func (f *continuityFixture) check(t *testing.T) *writer.ContinuityReport {
+	svc := NewContinuityService(f.documents, f.characters, f.locations, f.events)
+	report, err := svc.CheckProject(context.Background(), f.projectID)
+	require.NoError(t, err)
+	return report
+}
+
+func (f *continuityFixture) chapterID(i int) string {
+	return f.chapters[i].ID.Hex()
+}
+
+func newContinuityCharacter(name string) *writer.Character {
+	c := &writer.Character{}
+	c.ID = primitive.NewObjectID()
+	c.Name = name
+	return c
+}
+
+func newContinuityLocation(name, parentID string) *writer.Location {
+	l := &writer.Location{ParentID: parentID}
+	l.ID = primitive.NewObjectID()
+	l.Name = name
+	return l
+}
+
+func newTimelineEvent(title string, at *writer.StoryTime, participants, locationIDs, chapterIDs []string) *writer.TimelineEvent {
+	return &writer.TimelineEvent{
+		ID:           primitive.NewObjectID(),
+		TimelineID:   "main",
+		Title:        title,
+		StoryTime:    at,
+		Participants: participants,
+		LocationIDs:  locationIDs,
+		ChapterIDs:   chapterIDs,
+	}
+}
+
+func issuesOfType(report *writer.ContinuityReport, issueType writer.ContinuityIssueType) []writer.ContinuityIssue {
+	var issues []writer.ContinuityIssue
+	for _, issue := range report.Issues {
+		if issue.Type == issueType {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func TestContinuityService_LocationConflict(t *testing.T) {
+	f := newContinuityFixture()
+	hero := newContinuityCharacter("林远")
+	city := newContinuityLocation("临安", "")
+	tavern := newContinuityLocation("醉仙楼", city.ID.Hex())
+	fort := newContinuityLocation("北境关", "")
+	f.characters.characters = []*writer.Character{hero}
+	f.locations.locations = []*writer.Location{city, tavern, fort}
+
+	noon := &writer.StoryTime{Year: 3, Month: 5, Day: 1, Hour: 12}
+	f.events.events = []*writer.TimelineEvent{
+		newTimelineEvent("城中议事", noon, []string{hero.ID.Hex()}, []string{city.ID.Hex()}, nil),
+		newTimelineEvent("酒楼密会", noon, []string{hero.ID.Hex()}, []string{tavern.ID.Hex()}, nil),
+		newTimelineEvent("关前鏖战", noon, []string{hero.ID.Hex()}, []string{fort.ID.Hex()}, nil),
+	}
+
+	report := f.check(t)
+	conflicts := issuesOfType(report, writer.ContinuityLocationConflict)
+
+	// 临安与醉仙楼为上下级地点，不构成冲突；二者分别与北境关冲突
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, writer.ContinuitySeverityError, conflicts[0].Severity)
+	assert.Equal(t, []string{hero.ID.Hex()}, conflicts[0].CharacterIDs)
+	assert.Contains(t, conflicts[0].Message, "北境关")
+	assert.Equal(t, 2, report.ErrorCount)
+}
+
+func TestContinuityService_ChronologyFollowsTreeOrder(t *testing.T) {
+	f := newContinuityFixture()
+	f.events.events = []*writer.TimelineEvent{
+		newTimelineEvent("出师", &writer.StoryTime{Year: 1}, nil, nil, []string{f.chapterID(0)}),
+		newTimelineEvent("夺城", &writer.StoryTime{Year: 5}, nil, nil, []string{f.chapterID(2)}),
+		newTimelineEvent("拜师", &writer.StoryTime{Year: 2}, nil, nil, []string{f.chapterID(3)}),
+		newTimelineEvent("外传", &writer.StoryTime{Era: "上古", Year: 1}, nil, nil, []string{f.chapterID(1)}),
+	}
+
+	report := f.check(t)
+	issues := issuesOfType(report, writer.ContinuityChronologyOrder)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, writer.ContinuitySeverityWarning, issues[0].Severity)
+	assert.Equal(t, []string{f.chapterID(2), f.chapterID(3)}, issues[0].ChapterIDs)
+	assert.False(t, report.HasErrors())
+}
+
+func TestContinuityService_RelationUsedAfterEnd(t *testing.T) {
+	f := newContinuityFixture()
+	a, b := newContinuityCharacter("林远"), newContinuityCharacter("苏明")
+	until := f.chapterID(1)
+	relation := &writer.CharacterRelation{
+		FromID:              a.ID.Hex(),
+		ToID:                b.ID.Hex(),
+		Type:                writer.RelationFriend,
+		ValidUntilChapterID: &until,
+		TimelineEvents: []writer.RelationTimelineEvent{
+			{ChapterID: f.chapterID(1), NewType: writer.RelationEnemy},
+			{ChapterID: f.chapterID(3), NewType: writer.RelationAlly},
+		},
+	}
+	relation.ID = primitive.NewObjectID()
+	f.characters.characters = []*writer.Character{a, b}
+	f.characters.relations = []*writer.CharacterRelation{relation}
+
+	report := f.check(t)
+	issues := issuesOfType(report, writer.ContinuityRelationAfterEnd)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, relation.ID.Hex(), issues[0].RelationID)
+	assert.Equal(t, []string{until, f.chapterID(3)}, issues[0].ChapterIDs)
+	assert.Contains(t, issues[0].Message, "林远与苏明")
+}
+
+func TestContinuityService_AppearanceBeforeIntroduction(t *testing.T) {
+	f := newContinuityFixture()
+	hero := newContinuityCharacter("林远")
+	intro := f.chapterID(2)
+	hero.FirstAppearanceID = &intro
+	f.characters.characters = []*writer.Character{hero}
+	f.chapters[0].CharacterIDs = []primitive.ObjectID{hero.ID}
+	f.chapters[3].CharacterIDs = []primitive.ObjectID{hero.ID}
+	f.events.events = []*writer.TimelineEvent{
+		newTimelineEvent("夜袭", nil, []string{hero.ID.Hex()}, nil, []string{f.chapterID(1)}),
+	}
+
+	report := f.check(t)
+	issues := issuesOfType(report, writer.ContinuityAppearanceBeforeIntro)
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, writer.ContinuitySeverityError, issues[0].Severity, "参与事件为确定矛盾")
+	assert.Equal(t, writer.ContinuitySeverityWarning, issues[1].Severity, "正文提及可能是伏笔")
+	assert.Equal(t, []string{f.chapterID(0), intro}, issues[1].ChapterIDs)
+}
+
+func TestPublishProject_ContinuityErrorsBlockPublish(t *testing.T) {
+	f := newContinuityFixture()
+	hero := newContinuityCharacter("林远")
+	intro := f.chapterID(2)
+	hero.FirstAppearanceID = &intro
+	f.characters.characters = []*writer.Character{hero}
+	f.events.events = []*writer.TimelineEvent{
+		newTimelineEvent("夜袭", nil, []string{hero.ID.Hex()}, nil, []string{f.chapterID(0)}),
+	}
+
+	project := createTestProject(primitive.NewObjectID().Hex(), "测试项目")
+	projectRepo := new(MockProjectRepositoryForExport)
+	projectRepo.On("FindByID", mock.Anything, f.projectID).Return(project, nil)
+
+	service := NewPublishService(projectRepo, f.documents, &stubPublicationRepository{}, nil, nil).(*PublishService)
+	service.SetContinuityService(NewContinuityService(f.documents, f.characters, f.locations, f.events))
+
+	req := &serviceInterfaces.PublishProjectRequest{BookstoreID: "local", CheckContinuity: true}
+	_, err := service.PublishProject(context.Background(), f.projectID, project.AuthorID.Hex(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "连贯性错误")
+
+	req.CheckContinuity = false
+	record, err := service.PublishProject(context.Background(), f.projectID, project.AuthorID.Hex(), req)
+	require.NoError(t, err)
+	assert.Equal(t, serviceInterfaces.PublicationStatusPending, record.Status)
+}
@@ -4,33 +4,59 @@ import (
 	"context"
 	"time"
 
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
 	writerRepo "Qingyu_backend/repository/interfaces/writer"
 	"Qingyu_backend/service/interfaces"
 )
 
 // DashboardStats 仪表板统计数据
 type DashboardStats struct {
-	TotalWords int64 `json:"totalWords"`
-	BookCount  int64 `json:"bookCount"`
-	TodayWords int64 `json:"todayWords"`
-	Pending    int64 `json:"pending"`
-	Streak     int   `json:"streak"`
+	TotalWords        int64                  `json:"totalWords"`
+	BookCount         int64                  `json:"bookCount"`
+	TodayWords        int64                  `json:"todayWords"`
+	WeekWords         int64                  `json:"weekWords"`
+	MonthWords        int64                  `json:"monthWords"`
+	Pending           int64                  `json:"pending"`
+	Streak            int                    `json:"streak"`
+	Timezone          string                 `json:"timezone,omitempty"`
+	DailyGoal         int                    `json:"dailyGoal"`
+	DailyGoalProgress float64                `json:"dailyGoalProgress"`
+	ProjectGoals      []*ProjectGoalProgress `json:"projectGoals,omitempty"`
+}
+
+// ProjectGoalProgress 项目目标进度
+type ProjectGoalProgress struct {
+	ProjectID         string  `json:"projectId"`
+	Title             string  `json:"title"`
+	TotalWords        int     `json:"totalWords"`
+	WordCountGoal     int     `json:"wordCountGoal"`
+	GoalProgress      float64 `json:"goalProgress"` // 总字数目标完成百分比（0-100）
+	TodayWords        int     `json:"todayWords"`
+	DailyWordGoal     int     `json:"dailyWordGoal"`
+	DailyGoalProgress float64 `json:"dailyGoalProgress"` // 今日目标完成百分比（0-100）
 }
 
 // DashboardService 仪表板统计服务
 type DashboardService struct {
-	projectRepo   writerRepo.ProjectRepository
+	projectRepo    writerRepo.ProjectRepository
 	publishService interfaces.PublishService
+	ledgerService  *WritingLedgerService
 }
 
 // NewDashboardService 创建仪表板统计服务
 func NewDashboardService(projectRepo writerRepo.ProjectRepository, publishService interfaces.PublishService) *DashboardService {
 	return &DashboardService{
-		projectRepo:   projectRepo,
+		projectRepo:    projectRepo,
 		publishService: publishService,
 	}
 }
 
+// SetWritingLedgerService 设置写作流水服务（今日/本周/本月产出、连续写作天数和目标进度）
+func (s *DashboardService) SetWritingLedgerService(ledgerService *WritingLedgerService) {
+	s.ledgerService = ledgerService
+}
+
 // GetStats 获取作者仪表板统计数据
 func (s *DashboardService) GetStats(ctx context.Context, userID string) (*DashboardStats, error) {
 	stats := &DashboardStats{}
@@ -49,18 +75,10 @@ func (s *DashboardService) GetStats(ctx context.Context, userID string) (*Dashbo
 	}
 
 	var totalWords int64
-	today := time.Now().Truncate(24 * time.Hour)
-
-	var todayWords int64
-
 	for _, p := range projects {
 		totalWords += int64(p.Statistics.TotalWords)
-		if p.UpdatedAt.After(today) {
-			todayWords += int64(p.Statistics.TotalWords)
-		}
 	}
 	stats.TotalWords = totalWords
-	stats.TodayWords = todayWords
 
 	// 待审核数量
 	if s.publishService != nil {
@@ -70,8 +88,112 @@ func (s *DashboardService) GetStats(ctx context.Context, userID string) (*Dashbo
 		}
 	}
 
-	// 连续写作天数 - 暂时返回0，后续通过 Redis 实现
-	stats.Streak = 0
+	// 写作产出、连续写作天数与目标进度来自写作流水
+	if s.ledgerService == nil {
+		return stats, nil
+	}
+	summary, err := s.ledgerService.GetSummary(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stats.TodayWords = summary.TodayWords
+	stats.WeekWords = summary.WeekWords
+	stats.MonthWords = summary.MonthWords
+	stats.Streak = summary.Streak
+	stats.Timezone = summary.Timezone
+	stats.DailyGoal = summary.DailyGoal
+	stats.DailyGoalProgress = summary.DailyGoalProgress
+
+	for _, p := range projects {
+		if p.Settings.WordCountGoal <= 0 && p.Settings.DailyWordGoal <= 0 {
+			continue
+		}
+		projectID := p.ID.Hex()
+		todayWords := summary.ProjectTodayWords[projectID]
+		stats.ProjectGoals = append(stats.ProjectGoals, &ProjectGoalProgress{
+			ProjectID:         projectID,
+			Title:             p.Title,
+			TotalWords:        p.Statistics.TotalWords,
+			WordCountGoal:     p.Settings.WordCountGoal,
+			GoalProgress:      goalProgress(int64(p.Statistics.TotalWords), p.Settings.WordCountGoal),
+			TodayWords:        todayWords,
+			DailyWordGoal:     p.Settings.DailyWordGoal,
+			DailyGoalProgress: goalProgress(int64(todayWords), p.Settings.DailyWordGoal),
+		})
+	}
 
 	return stats, nil
 }
+
+// UpdateProjectGoals 更新项目的总字数目标和每日字数目标（仅项目所有者）
+func (s *DashboardService) UpdateProjectGoals(ctx context.Context, userID, projectID string, wordCountGoal, dailyWordGoal *int) error {
+	if wordCountGoal == nil && dailyWordGoal == nil {
+		return errors.NewServiceError("DashboardService", errors.ServiceErrorValidation, "没有需要更新的内容", "", nil)
+	}
+	if (wordCountGoal != nil && *wordCountGoal < 0) || (dailyWordGoal != nil && *dailyWordGoal < 0) {
+		return errors.NewServiceError("DashboardService", errors.ServiceErrorValidation, "字数目标不能为负数", "", nil)
+	}
+
+	isOwner, err := s.projectRepo.IsOwner(ctx, projectID, userID)
+	if err != nil {
+		return errors.NewServiceError("DashboardService", errors.ServiceErrorValidation, "无效的项目ID", "", err)
+	}
+	if !isOwner {
+		return errors.NewServiceError("DashboardService", errors.ServiceErrorForbidden, "无权修改该项目", "", nil)
+	}
+
+	updates := map[string]interface{}{}
+	if wordCountGoal != nil {
+		updates["settings.word_count_goal"] = *wordCountGoal
+	}
+	if dailyWordGoal != nil {
+		updates["settings.daily_word_goal"] = *dailyWordGoal
+	}
+	if err := s.projectRepo.UpdateByOwner(ctx, projectID, userID, updates); err != nil {
+		return errors.NewServiceError("DashboardService", errors.ServiceErrorInternal, "更新项目目标失败", "", err)
+	}
+	return nil
+}
+
+// GetDailyOutput 获取最近若干天的每日写作产出
+func (s *DashboardService) GetDailyOutput(ctx context.Context, userID string, days int) ([]*DailyWritingOutput, error) {
+	ledger, err := s.ledger()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.GetDailyOutput(ctx, userID, days, time.Now())
+}
+
+// GetWritingGoal 获取作者每日目标和时区
+func (s *DashboardService) GetWritingGoal(ctx context.Context, userID string) (*writer.WritingGoal, error) {
+	ledger, err := s.ledger()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.GetGoal(ctx, userID)
+}
+
+// UpdateWritingGoal 更新作者每日目标和时区
+func (s *DashboardService) UpdateWritingGoal(ctx context.Context, userID string, req *UpdateWritingGoalRequest) (*writer.WritingGoal, error) {
+	ledger, err := s.ledger()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.UpdateGoal(ctx, userID, req)
+}
+
+// BackfillWritingLedger 由历史修订回填作者的写作流水
+func (s *DashboardService) BackfillWritingLedger(ctx context.Context, userID string) (*WritingBackfillResult, error) {
+	ledger, err := s.ledger()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.Backfill(ctx, userID)
+}
+
+func (s *DashboardService) ledger() (*WritingLedgerService, error) {
+	if s.ledgerService == nil {
+		return nil, errors.NewServiceError("DashboardService", errors.ServiceErrorInternal, "写作流水服务未启用", "", nil)
+	}
+	return s.ledgerService, nil
+}
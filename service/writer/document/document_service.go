@@ -30,6 +30,11 @@ func countWords(content string, contentType string) int {
 	return len([]rune(content))
 }
 
+// CountWords 统计内容的字数（与文档保存时的统计口径一致）
+func CountWords(content string, contentType string) int {
+	return countWords(content, contentType)
+}
+
 // countTipTapWords 统计TipTap JSON内容的字数
 func countTipTapWords(tipTapJson string) int {
 	// 解析TipTap JSON
@@ -97,6 +102,48 @@ func extractTextFromTipTapDoc(node interface{}) int {
 	}
 }
 
+// collectTipTapText 提取TipTap文档的纯文本（块级节点之间以换行分隔）
+func collectTipTapText(node interface{}, sb *strings.Builder) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if text, ok := v["text"].(string); ok {
+			sb.WriteString(text)
+		}
+		if children, ok := v["content"].([]interface{}); ok {
+			for _, child := range children {
+				collectTipTapText(child, sb)
+			}
+			if nodeType, _ := v["type"].(string); nodeType != "text" && sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectTipTapText(item, sb)
+		}
+	}
+}
+
+// plainText 获取内容的纯文本
+func plainText(content string, contentType string) string {
+	if contentType != "tiptap_json" {
+		return content
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	collectTipTapText(doc, &sb)
+	return sb.String()
+}
+
+// maxLinkedEntities 文档自动关联的实体上限（与 Document 字段校验一致）
+const maxLinkedEntities = 50
+
+// EntityLinker 实体关联器：识别正文中出现的角色与地点，按出现次数降序返回ID
+type EntityLinker func(ctx context.Context, projectID, text string) (characterIDs, locationIDs []string, err error)
+
 // DocumentService 文档服务
 type DocumentService struct {
 	documentRepo        writerRepo.DocumentRepository
@@ -112,6 +159,8 @@ type DocumentService struct {
 	OnDocumentCreated func(ctx context.Context, projectID string, doc *writer.Document)
 	// OnDocumentTitleUpdated 文档标题更新后的回调（用于双向同步）
 	OnDocumentTitleUpdated func(ctx context.Context, documentID string, newTitle string)
+	// entityLinker 保存正文时自动更新文档关联的角色与地点
+	entityLinker EntityLinker
 }
 
 // SetOnDocumentCreated 设置创建文档后的回调
@@ -124,6 +173,51 @@ func (s *DocumentService) SetOnDocumentTitleUpdated(fn func(ctx context.Context,
 	s.OnDocumentTitleUpdated = fn
 }
 
+// SetEntityLinker 设置实体关联器
+func (s *DocumentService) SetEntityLinker(linker EntityLinker) {
+	s.entityLinker = linker
+}
+
+// linkEntities 识别正文中的实体，合并到文档已关联的角色与地点后写入元数据更新
+// 已有关联（包括手动关联）全部保留，识别出的新实体追加在后，总数不超过上限
+func (s *DocumentService) linkEntities(ctx context.Context, doc *writer.Document, content, contentType string, updates map[string]interface{}) {
+	if s.entityLinker == nil {
+		return
+	}
+	characterIDs, locationIDs, err := s.entityLinker(ctx, doc.ProjectID.Hex(), plainText(content, contentType))
+	if err != nil {
+		fmt.Printf("警告：识别文档关联实体失败: %v\n", err)
+		return
+	}
+	if merged, changed := mergeObjectIDs(doc.CharacterIDs, characterIDs, maxLinkedEntities); changed {
+		updates["character_ids"] = merged
+	}
+	if merged, changed := mergeObjectIDs(doc.LocationIDs, locationIDs, maxLinkedEntities); changed {
+		updates["location_ids"] = merged
+	}
+}
+
+// mergeObjectIDs 将新ID追加到已有列表（去重、忽略无效ID），返回合并结果及是否有新增
+func mergeObjectIDs(existing []primitive.ObjectID, ids []string, limit int) ([]primitive.ObjectID, bool) {
+	merged := append(make([]primitive.ObjectID, 0, len(existing)+len(ids)), existing...)
+	seen := make(map[primitive.ObjectID]bool, len(merged))
+	for _, id := range merged {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if len(merged) >= limit {
+			break
+		}
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil || seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		merged = append(merged, oid)
+	}
+	return merged, len(merged) > len(existing)
+}
+
 // NewDocumentService 创建文档服务
 func NewDocumentService(
 	documentRepo writerRepo.DocumentRepository,
@@ -790,7 +884,7 @@ func (s *DocumentService) AutoSaveDocument(ctx context.Context, req *dto.AutoSav
 	}
 
 	// 4. 验证文档编辑权限
-	_, _, _, err := s.authHelper.VerifyDocumentEdit(ctx, req.DocumentID)
+	userID, doc, _, err := s.authHelper.VerifyDocumentEdit(ctx, req.DocumentID)
 	if err != nil {
 		return nil, err
 	}
@@ -864,6 +958,7 @@ func (s *DocumentService) AutoSaveDocument(ctx context.Context, req *dto.AutoSav
 		"word_count": wordCount,
 		"updated_at": time.Now(),
 	}
+	s.linkEntities(ctx, doc, req.Content, contentType, updates)
 
 	if err := s.documentRepo.Update(ctx, req.DocumentID, updates); err != nil {
 		// 内容已保存，但元数据更新失败，记录错误但不返回失败
@@ -876,10 +971,13 @@ func (s *DocumentService) AutoSaveDocument(ctx context.Context, req *dto.AutoSav
 		s.eventBus.PublishAsync(ctx, &serviceBase.BaseEvent{
 			EventType: "document.autosaved",
 			EventData: map[string]interface{}{
-				"document_id": req.DocumentID,
-				"word_count":  wordCount,
-				"save_type":   req.SaveType,
-				"version":     newVersion,
+				"document_id":         req.DocumentID,
+				"project_id":          doc.ProjectID.Hex(),
+				"user_id":             userID,
+				"word_count":          wordCount,
+				"previous_word_count": doc.WordCount,
+				"save_type":           req.SaveType,
+				"version":             newVersion,
 			},
 			Timestamp: time.Now(),
 			Source:    s.serviceName,
@@ -1025,7 +1123,7 @@ func (s *DocumentService) UpdateDocumentContent(ctx context.Context, req *dto.Up
 	}
 
 	// 4. 验证文档编辑权限
-	_, _, _, err := s.authHelper.VerifyDocumentEdit(ctx, req.DocumentID)
+	userID, doc, _, err := s.authHelper.VerifyDocumentEdit(ctx, req.DocumentID)
 	if err != nil {
 		return err
 	}
@@ -1086,6 +1184,7 @@ func (s *DocumentService) UpdateDocumentContent(ctx context.Context, req *dto.Up
 		"word_count": wordCount,
 		"updated_at": time.Now(),
 	}
+	s.linkEntities(ctx, doc, req.Content, contentType, updates)
 
 	if err := s.documentRepo.Update(ctx, req.DocumentID, updates); err != nil {
 		// 内容已保存，但元数据更新失败，记录错误但不返回失败
@@ -1097,8 +1196,11 @@ func (s *DocumentService) UpdateDocumentContent(ctx context.Context, req *dto.Up
 		s.eventBus.PublishAsync(ctx, &serviceBase.BaseEvent{
 			EventType: "document.content_updated",
 			EventData: map[string]interface{}{
-				"document_id": req.DocumentID,
-				"word_count":  wordCount,
+				"document_id":         req.DocumentID,
+				"project_id":          doc.ProjectID.Hex(),
+				"user_id":             userID,
+				"word_count":          wordCount,
+				"previous_word_count": doc.WordCount,
 			},
 			Timestamp: time.Now(),
 			Source:    s.serviceName,
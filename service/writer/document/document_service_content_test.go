@@ -218,3 +218,51 @@ func TestDocumentService_ReplaceDocumentContents_RejectsDuplicateOrder(t *testin
 	docRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
 	contentRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
 }
+
+func TestDocumentService_UpdateDocumentContent_LinksRecognizedEntities(t *testing.T) {
+	docRepo := new(MockDocumentRepository)
+	projectRepo := new(MockProjectRepository)
+	contentRepo := new(servicemock.MockDocumentContentRepository)
+	svc := NewDocumentService(docRepo, contentRepo, projectRepo, nil)
+
+	userObjID, _ := primitive.ObjectIDFromHex("507f1f77bcf86cd799439011")
+	projectOID := primitive.NewObjectID()
+	docID := primitive.NewObjectID().Hex()
+	characterID := primitive.NewObjectID()
+	manualID := primitive.NewObjectID()
+	locationID := primitive.NewObjectID()
+
+	project := &writer.Project{OwnedEntity: modelbase.OwnedEntity{AuthorID: userObjID}}
+	// 手动关联的角色与地点在自动识别后保留
+	doc := &writer.Document{ProjectID: projectOID, CharacterIDs: []primitive.ObjectID{manualID}, LocationIDs: []primitive.ObjectID{locationID}}
+
+	var scanned string
+	svc.SetEntityLinker(func(ctx context.Context, projectID, text string) ([]string, []string, error) {
+		scanned = text
+		assert.Equal(t, projectOID.Hex(), projectID)
+		return []string{characterID.Hex(), manualID.Hex(), "invalid"}, []string{locationID.Hex()}, nil
+	})
+
+	ctx := context.WithValue(context.Background(), "userId", userObjID.Hex())
+
+	docRepo.On("GetByID", mock.Anything, docID).Return(doc, nil).Once()
+	projectRepo.On("GetByID", mock.Anything, projectOID.Hex()).Return(project, nil).Once()
+	contentRepo.On("GetByDocumentID", mock.Anything, docID).Return(&writer.DocumentContent{Version: 1}, nil).Once()
+	contentRepo.On("UpdateWithVersion", mock.Anything, docID, mock.Anything, 1).Return(nil).Once()
+	docRepo.On("Update", mock.Anything, docID, mock.MatchedBy(func(updates map[string]interface{}) bool {
+		ids, ok := updates["character_ids"].([]primitive.ObjectID)
+		_, locationsUpdated := updates["location_ids"]
+		return ok && assert.ObjectsAreEqual([]primitive.ObjectID{manualID, characterID}, ids) && !locationsUpdated
+	})).Return(nil).Once()
+
+	err := svc.UpdateDocumentContent(ctx, &dto.UpdateContentRequest{
+		DocumentID:  docID,
+		ContentType: "tiptap_json",
+		Content:     `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"林远推门而入"}]}]}`,
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, scanned, "林远推门而入")
+	assert.NotContains(t, scanned, "paragraph")
+	docRepo.AssertExpectations(t)
+}
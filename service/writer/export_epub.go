@@ -0,0 +1,453 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+const (
+	epubDefaultLanguage = "zh-CN"
+	// epubMaxCoverSize 封面图片大小上限，超出时不打包封面
+	epubMaxCoverSize = 10 << 20
+)
+
+// errExportCancelled 导出过程中任务被取消
+var errExportCancelled = errors.New("导出任务已取消")
+
+func isExportCancelled(err error) bool {
+	return errors.Is(err, errExportCancelled)
+}
+
+// epubSection 电子书中的一个 XHTML 章节文件
+type epubSection struct {
+	ID       string
+	FileName string
+	Title    string
+	Volume   bool
+	Body     string
+}
+
+// epubNavItem 目录项，卷下嵌套章节
+type epubNavItem struct {
+	Title    string
+	Href     string
+	Children []*epubNavItem
+}
+
+// epubCover 封面图片
+type epubCover struct {
+	FileName  string
+	MediaType string
+	Data      []byte
+}
+
+// epubFile 打包进 EPUB 的文件
+type epubFile struct {
+	name string
+	data []byte
+}
+
+// epubBook 组装 EPUB 所需的全部内容
+type epubBook struct {
+	Identifier  string
+	Title       string
+	Author      string
+	Language    string
+	Description string
+	Subjects    []string
+	Modified    time.Time
+	Cover       *epubCover
+	Sections    []*epubSection
+	Nav         []*epubNavItem
+	TOCInSpine  bool
+}
+
+// buildProjectEPUB 将项目按卷章树生成 EPUB 3 文件
+// onProgress 在每处理完一个文档后回调，返回错误时中止导出
+func (s *ExportService) buildProjectEPUB(
+	ctx context.Context,
+	project *writer.Project,
+	req *serviceInterfaces.ExportProjectRequest,
+	onProgress func(done, total int) error,
+) ([]byte, error) {
+	documents, err := s.documentRepo.FindByProjectID(ctx, project.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("获取项目文档失败: %w", err)
+	}
+
+	var options *serviceInterfaces.ExportOptions
+	if req != nil {
+		options = req.Options
+	}
+	excluded := make(map[string]bool)
+	if options != nil {
+		for _, id := range options.ExcludeChapters {
+			excluded[id] = true
+		}
+	}
+
+	book := &epubBook{
+		Identifier:  "urn:qingyu:project:" + project.ID.Hex(),
+		Title:       project.Title,
+		Author:      s.lookupAuthorName(ctx, project),
+		Language:    epubDefaultLanguage,
+		Description: project.Summary,
+		Subjects:    project.Tags,
+		Modified:    time.Now().UTC(),
+		TOCInSpine:  options != nil && options.TOC,
+	}
+	if req != nil && strings.TrimSpace(req.Language) != "" {
+		book.Language = strings.TrimSpace(req.Language)
+	}
+	if book.Title == "" {
+		book.Title = "untitled"
+	}
+	book.Cover = s.loadEPUBCover(ctx, project.CoverURL)
+
+	if req == nil || req.IncludeDocuments {
+		tree := buildDocumentTree(documents)
+		walker := &epubTreeWalker{
+			service:    s,
+			tree:       tree,
+			excluded:   excluded,
+			total:      countIncludedDocuments(tree, "", excluded),
+			onProgress: onProgress,
+		}
+		nav, err := walker.walk(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		book.Nav = nav
+		book.Sections = walker.sections
+	}
+
+	return book.render()
+}
+
+// lookupAuthorName 查询作者显示名称，未配置或查询失败时返回空
+func (s *ExportService) lookupAuthorName(ctx context.Context, project *writer.Project) string {
+	if s.authorRepo == nil || project.AuthorID.IsZero() {
+		return ""
+	}
+	user, err := s.authorRepo.FindByID(ctx, project.AuthorID.Hex())
+	if err != nil || user == nil {
+		return ""
+	}
+	return user.GetDisplayName()
+}
+
+// loadEPUBCover 从文件存储下载封面；下载失败或不是图片时忽略封面
+func (s *ExportService) loadEPUBCover(ctx context.Context, coverURL string) *epubCover {
+	if coverURL == "" || s.fileStorage == nil {
+		return nil
+	}
+
+	reader, err := s.fileStorage.Download(ctx, coverURL)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, epubMaxCoverSize+1))
+	if err != nil || len(data) == 0 || len(data) > epubMaxCoverSize {
+		return nil
+	}
+
+	mediaType := http.DetectContentType(data)
+	ext := map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}[mediaType]
+	if ext == "" {
+		return nil
+	}
+
+	return &epubCover{FileName: "images/cover" + ext, MediaType: mediaType, Data: data}
+}
+
+// epubTreeWalker 先序遍历卷章树生成章节文件和目录
+type epubTreeWalker struct {
+	service    *ExportService
+	tree       map[string][]*writer.Document
+	excluded   map[string]bool
+	total      int
+	done       int
+	onProgress func(done, total int) error
+	sections   []*epubSection
+}
+
+// walk 处理 parentID 下的文档，返回对应的目录项
+// 没有正文的叶子节点和没有任何可导出后代的卷会被跳过
+func (w *epubTreeWalker) walk(ctx context.Context, parentID string) ([]*epubNavItem, error) {
+	var items []*epubNavItem
+	for _, doc := range w.tree[parentID] {
+		docID := doc.ID.Hex()
+		if w.excluded[docID] {
+			continue
+		}
+
+		body := ""
+		content, err := w.service.documentContentRepo.FindByID(ctx, docID)
+		if err == nil && content != nil {
+			body = tiptapToXHTML(content.Content)
+		}
+
+		w.done++
+		if w.onProgress != nil {
+			if err := w.onProgress(w.done, w.total); err != nil {
+				return nil, err
+			}
+		}
+
+		// 先占位保证父节点排在子节点之前
+		index := len(w.sections)
+		section := &epubSection{
+			ID:       fmt.Sprintf("section-%04d", index+1),
+			FileName: fmt.Sprintf("text/section-%04d.xhtml", index+1),
+			Title:    doc.Title,
+			Volume:   doc.Type == "volume",
+			Body:     body,
+		}
+		w.sections = append(w.sections, section)
+
+		children, err := w.walk(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+
+		// 子节点都被跳过时占位之后不会再有章节，直接截断即可
+		if strings.TrimSpace(body) == "" && len(children) == 0 {
+			w.sections = w.sections[:index]
+			continue
+		}
+
+		items = append(items, &epubNavItem{Title: doc.Title, Href: section.FileName, Children: children})
+	}
+	return items, nil
+}
+
+// countIncludedDocuments 统计需要处理的文档数，用于计算进度
+func countIncludedDocuments(tree map[string][]*writer.Document, parentID string, excluded map[string]bool) int {
+	count := 0
+	for _, doc := range tree[parentID] {
+		docID := doc.ID.Hex()
+		if excluded[docID] {
+			continue
+		}
+		count += 1 + countIncludedDocuments(tree, docID, excluded)
+	}
+	return count
+}
+
+// render 打包 EPUB：mimetype 必须是第一个且不压缩的条目
+func (b *epubBook) render() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+
+	mimetype, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mimetype.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainerXML)},
+		{"OEBPS/content.opf", []byte(b.packageDocument())},
+		{"OEBPS/nav.xhtml", []byte(b.navDocument())},
+		{"OEBPS/toc.ncx", []byte(b.ncxDocument())},
+		{"OEBPS/styles.css", []byte(epubStylesheet)},
+	}
+	if b.Cover != nil {
+		files = append(files,
+			epubFile{"OEBPS/cover.xhtml", []byte(b.coverDocument())},
+			epubFile{"OEBPS/" + b.Cover.FileName, b.Cover.Data},
+		)
+	}
+	for _, section := range b.Sections {
+		files = append(files, epubFile{"OEBPS/" + section.FileName, []byte(b.sectionDocument(section))})
+	}
+
+	for _, file := range files {
+		w, err := zipWriter.Create(file.name)
+		if err != nil {
+			_ = zipWriter.Close()
+			return nil, err
+		}
+		if _, err := w.Write(file.data); err != nil {
+			_ = zipWriter.Close()
+			return nil, err
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const epubContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubStylesheet = `body { margin: 0 5%; line-height: 1.8; }
+h1 { text-align: center; margin: 2em 0 1em; }
+p { text-indent: 2em; margin: 0.5em 0; }
+section.part h1 { margin-top: 30%; }
+.cover { text-align: center; margin: 0; padding: 0; }
+.cover img { max-width: 100%; max-height: 100%; }
+.image-alt { text-indent: 0; text-align: center; color: #666; }
+`
+
+// packageDocument 生成 content.opf（元数据、清单与阅读顺序）
+func (b *epubBook) packageDocument() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + xmlEscape(b.Language) + `">` + "\n")
+	sb.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	sb.WriteString(`    <dc:identifier id="book-id">` + xmlEscape(b.Identifier) + "</dc:identifier>\n")
+	sb.WriteString(`    <dc:title>` + xmlEscape(b.Title) + "</dc:title>\n")
+	if b.Author != "" {
+		sb.WriteString(`    <dc:creator>` + xmlEscape(b.Author) + "</dc:creator>\n")
+	}
+	sb.WriteString(`    <dc:language>` + xmlEscape(b.Language) + "</dc:language>\n")
+	if b.Description != "" {
+		sb.WriteString(`    <dc:description>` + xmlEscape(b.Description) + "</dc:description>\n")
+	}
+	for _, subject := range b.Subjects {
+		if strings.TrimSpace(subject) != "" {
+			sb.WriteString(`    <dc:subject>` + xmlEscape(subject) + "</dc:subject>\n")
+		}
+	}
+	sb.WriteString(`    <meta property="dcterms:modified">` + b.Modified.Format("2006-01-02T15:04:05Z") + "</meta>\n")
+	if b.Cover != nil {
+		sb.WriteString(`    <meta name="cover" content="cover-image"/>` + "\n")
+	}
+	sb.WriteString("  </metadata>\n")
+
+	sb.WriteString("  <manifest>\n")
+	sb.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	sb.WriteString(`    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>` + "\n")
+	sb.WriteString(`    <item id="css" href="styles.css" media-type="text/css"/>` + "\n")
+	if b.Cover != nil {
+		sb.WriteString(`    <item id="cover-image" href="` + b.Cover.FileName + `" media-type="` + b.Cover.MediaType + `" properties="cover-image"/>` + "\n")
+		sb.WriteString(`    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+	}
+	for _, section := range b.Sections {
+		sb.WriteString(`    <item id="` + section.ID + `" href="` + section.FileName + `" media-type="application/xhtml+xml"/>` + "\n")
+	}
+	sb.WriteString("  </manifest>\n")
+
+	sb.WriteString(`  <spine toc="ncx">` + "\n")
+	if b.Cover != nil {
+		sb.WriteString(`    <itemref idref="cover" linear="no"/>` + "\n")
+	}
+	if b.TOCInSpine {
+		sb.WriteString(`    <itemref idref="nav"/>` + "\n")
+	}
+	for _, section := range b.Sections {
+		sb.WriteString(`    <itemref idref="` + section.ID + `"/>` + "\n")
+	}
+	sb.WriteString("  </spine>\n")
+	sb.WriteString("</package>\n")
+	return sb.String()
+}
+
+// xhtmlDocument 生成 XHTML 页面外壳
+func (b *epubBook) xhtmlDocument(title, cssHref, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<!DOCTYPE html>` + "\n" +
+		`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + xmlEscape(b.Language) + `" lang="` + xmlEscape(b.Language) + `">` + "\n" +
+		"<head>\n" +
+		"  <title>" + xmlEscape(title) + "</title>\n" +
+		`  <link rel="stylesheet" type="text/css" href="` + cssHref + `"/>` + "\n" +
+		"</head>\n" +
+		"<body>\n" + body + "</body>\n</html>\n"
+}
+
+// navDocument 生成 EPUB 3 导航文档
+func (b *epubBook) navDocument() string {
+	var sb strings.Builder
+	sb.WriteString(`<nav epub:type="toc" id="toc">` + "\n")
+	sb.WriteString("<h1>目录</h1>\n")
+	writeEPUBNavList(&sb, b.Nav)
+	sb.WriteString("</nav>\n")
+	return b.xhtmlDocument(b.Title, "styles.css", sb.String())
+}
+
+func writeEPUBNavList(sb *strings.Builder, items []*epubNavItem) {
+	sb.WriteString("<ol>\n")
+	for _, item := range items {
+		sb.WriteString(`<li><a href="` + item.Href + `">` + xmlEscape(item.Title) + "</a>")
+		if len(item.Children) > 0 {
+			sb.WriteString("\n")
+			writeEPUBNavList(sb, item.Children)
+		}
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString("</ol>\n")
+}
+
+// ncxDocument 生成 toc.ncx，兼容只支持 EPUB 2 的阅读器
+func (b *epubBook) ncxDocument() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">` + "\n")
+	sb.WriteString(`  <head><meta name="dtb:uid" content="` + xmlEscape(b.Identifier) + `"/></head>` + "\n")
+	sb.WriteString("  <docTitle><text>" + xmlEscape(b.Title) + "</text></docTitle>\n")
+	sb.WriteString("  <navMap>\n")
+	playOrder := 0
+	writeEPUBNavPoints(&sb, b.Nav, &playOrder)
+	sb.WriteString("  </navMap>\n")
+	sb.WriteString("</ncx>\n")
+	return sb.String()
+}
+
+func writeEPUBNavPoints(sb *strings.Builder, items []*epubNavItem, playOrder *int) {
+	for _, item := range items {
+		*playOrder++
+		sb.WriteString(fmt.Sprintf(`<navPoint id="nav-%d" playOrder="%d">`, *playOrder, *playOrder))
+		sb.WriteString("<navLabel><text>" + xmlEscape(item.Title) + "</text></navLabel>")
+		sb.WriteString(`<content src="` + item.Href + `"/>` + "\n")
+		writeEPUBNavPoints(sb, item.Children, playOrder)
+		sb.WriteString("</navPoint>\n")
+	}
+}
+
+// coverDocument 生成封面页
+func (b *epubBook) coverDocument() string {
+	body := `<section class="cover" epub:type="cover">` + "\n" +
+		`<img src="` + b.Cover.FileName + `" alt="` + xmlEscape(b.Title) + `"/>` + "\n" +
+		"</section>\n"
+	return b.xhtmlDocument(b.Title, "styles.css", body)
+}
+
+// sectionDocument 生成卷或章节页面
+func (b *epubBook) sectionDocument(section *epubSection) string {
+	epubType, class := "chapter", "chapter"
+	if section.Volume {
+		epubType, class = "part", "part"
+	}
+	body := `<section class="` + class + `" epub:type="` + epubType + `">` + "\n" +
+		"<h1>" + xmlEscape(section.Title) + "</h1>\n" +
+		section.Body +
+		"</section>\n"
+	return b.xhtmlDocument(section.Title, "../styles.css", body)
+}
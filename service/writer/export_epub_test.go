@@ -0,0 +1,298 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/users"
+	"Qingyu_backend/models/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// fakeAuthorRepo 固定返回作者信息
+type fakeAuthorRepo struct {
+	user *users.User
+}
+
+func (r *fakeAuthorRepo) FindByID(ctx context.Context, id string) (*users.User, error) {
+	return r.user, nil
+}
+
+// epubPNGCover 最小的 PNG 文件头，足够 http.DetectContentType 识别
+var epubPNGCover = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+
+type epubFixture struct {
+	project     *writer.Project
+	docRepo     *MockDocumentRepositoryForExport
+	contentRepo *MockDocumentContentRepositoryForExport
+	projectRepo *MockProjectRepositoryForExport
+	taskRepo    *MockExportTaskRepositoryForExport
+	storage     *MockFileStorageForExport
+	service     *ExportService
+	excludedID  string
+}
+
+// newEPUBFixture 构造项目：第一卷（两章，其中一章无正文）、第二卷（仅含被排除的章节）、尾声
+func newEPUBFixture(t *testing.T) *epubFixture {
+	f := &epubFixture{
+		docRepo:     new(MockDocumentRepositoryForExport),
+		contentRepo: new(MockDocumentContentRepositoryForExport),
+		projectRepo: new(MockProjectRepositoryForExport),
+		taskRepo:    new(MockExportTaskRepositoryForExport),
+		storage:     new(MockFileStorageForExport),
+	}
+
+	f.project = &writer.Project{}
+	f.project.ID = primitive.NewObjectID()
+	f.project.AuthorID = primitive.NewObjectID()
+	f.project.Title = "山河&远方"
+	f.project.Summary = "一段旅程"
+	f.project.Tags = []string{"玄幻", "成长"}
+	f.project.CoverURL = "/covers/1.png"
+
+	newDoc := func(title, docType string, order int, parent primitive.ObjectID) *writer.Document {
+		doc := &writer.Document{ProjectID: f.project.ID, Title: title, Type: docType, Order: order, ParentID: parent}
+		doc.ID = primitive.NewObjectID()
+		return doc
+	}
+	vol1 := newDoc("第一卷", "volume", 1, primitive.NilObjectID)
+	ch1 := newDoc("第一章", "chapter", 1, vol1.ID)
+	ch2 := newDoc("第二章", "chapter", 2, vol1.ID)
+	vol2 := newDoc("第二卷", "volume", 2, primitive.NilObjectID)
+	ch3 := newDoc("第三章", "chapter", 1, vol2.ID)
+	epilogue := newDoc("尾声", "chapter", 3, primitive.NilObjectID)
+	f.excludedID = ch3.ID.Hex()
+
+	contents := map[string]string{
+		ch1.ID.Hex(): `{"type":"doc","content":[
+			{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"序"}]},
+			{"type":"paragraph","content":[
+				{"type":"text","text":"他说"},
+				{"type":"text","marks":[{"type":"bold"}],"text":"<走>"},
+				{"type":"text","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}],"text":"链接"}
+			]}
+		]}`,
+		ch2.ID.Hex():      "",
+		ch3.ID.Hex():      "被排除",
+		epilogue.ID.Hex(): "第一行\n第二行",
+	}
+	for id, text := range contents {
+		content := &writer.DocumentContent{Content: text}
+		f.contentRepo.On("FindByID", mock.Anything, id).Return(content, nil).Maybe()
+	}
+	f.contentRepo.On("FindByID", mock.Anything, mock.Anything).Return(nil, assert.AnError).Maybe()
+
+	f.docRepo.On("FindByProjectID", mock.Anything, f.project.ID.Hex()).
+		Return([]*writer.Document{epilogue, ch2, vol2, ch1, ch3, vol1}, nil)
+	f.storage.On("Download", mock.Anything, f.project.CoverURL).
+		Return(io.NopCloser(bytes.NewReader(epubPNGCover)), nil).Maybe()
+
+	f.service = NewExportService(f.docRepo, f.contentRepo, f.projectRepo, f.taskRepo, f.storage).(*ExportService)
+	f.service.SetAuthorRepository(&fakeAuthorRepo{user: &users.User{Username: "author", Nickname: "墨客"}})
+	return f
+}
+
+func (f *epubFixture) request() *serviceInterfaces.ExportProjectRequest {
+	return &serviceInterfaces.ExportProjectRequest{
+		Format:           serviceInterfaces.ExportFormatEPUB,
+		IncludeDocuments: true,
+		Options:          &serviceInterfaces.ExportOptions{TOC: true, ExcludeChapters: []string{f.excludedID}},
+	}
+}
+
+func readEPUB(t *testing.T, data []byte) (*zip.Reader, map[string]string) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+	return reader, files
+}
+
+// assertWellFormedXML 确认文件可以被完整解析
+func assertWellFormedXML(t *testing.T, name, content string) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = true
+	decoder.Entity = xml.HTMLEntity
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		if !assert.NoError(t, err, name) {
+			return
+		}
+	}
+}
+
+func TestExportService_BuildProjectEPUB(t *testing.T) {
+	f := newEPUBFixture(t)
+
+	var progress []int
+	data, err := f.service.buildProjectEPUB(context.Background(), f.project, f.request(), func(done, total int) error {
+		assert.Equal(t, 5, total)
+		progress = append(progress, done)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, progress)
+
+	reader, files := readEPUB(t, data)
+
+	// mimetype 必须是第一个且不压缩
+	require.NotEmpty(t, reader.File)
+	assert.Equal(t, "mimetype", reader.File[0].Name)
+	assert.Equal(t, zip.Store, reader.File[0].Method)
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Contains(t, files["META-INF/container.xml"], `full-path="OEBPS/content.opf"`)
+
+	for name, content := range files {
+		if strings.HasSuffix(name, ".xhtml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".ncx") {
+			assertWellFormedXML(t, name, content)
+		}
+	}
+
+	var opf struct {
+		Version  string `xml:"version,attr"`
+		Metadata struct {
+			Identifier string   `xml:"identifier"`
+			Title      string   `xml:"title"`
+			Creator    string   `xml:"creator"`
+			Language   string   `xml:"language"`
+			Subjects   []string `xml:"subject"`
+		} `xml:"metadata"`
+		Manifest []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(files["OEBPS/content.opf"]), &opf))
+	assert.Equal(t, "3.0", opf.Version)
+	assert.Equal(t, "urn:qingyu:project:"+f.project.ID.Hex(), opf.Metadata.Identifier)
+	assert.Equal(t, "山河&远方", opf.Metadata.Title)
+	assert.Equal(t, "墨客", opf.Metadata.Creator)
+	assert.Equal(t, "zh-CN", opf.Metadata.Language)
+	assert.Equal(t, []string{"玄幻", "成长"}, opf.Metadata.Subjects)
+
+	manifest := make(map[string]string)
+	for _, item := range opf.Manifest {
+		manifest[item.ID] = item.Href
+		if item.Properties != "" {
+			manifest[item.Properties] = item.Href
+		}
+		_, packaged := files["OEBPS/"+item.Href]
+		assert.True(t, packaged, "manifest item %s must be packaged", item.Href)
+	}
+	assert.Equal(t, "nav.xhtml", manifest["nav"])
+	assert.Equal(t, "images/cover.png", manifest["cover-image"])
+
+	// 阅读顺序：封面、目录、第一卷、第一章、尾声；空章节与仅含排除章节的卷被跳过
+	var spine []string
+	for _, ref := range opf.Spine {
+		spine = append(spine, ref.IDRef)
+	}
+	assert.Equal(t, []string{"cover", "nav", "section-0001", "section-0002", "section-0003"}, spine)
+	assert.Contains(t, files["OEBPS/"+manifest["section-0001"]], `epub:type="part"`)
+	assert.Contains(t, files["OEBPS/"+manifest["section-0003"]], "<p>第一行</p>")
+
+	nav := files["OEBPS/nav.xhtml"]
+	assert.Contains(t, nav, `epub:type="toc"`)
+	vol := strings.Index(nav, "第一卷")
+	ch1 := strings.Index(nav, "第一章")
+	epilogue := strings.Index(nav, "尾声")
+	assert.True(t, vol >= 0 && vol < ch1 && ch1 < epilogue, "nav must follow the volume/chapter tree")
+	assert.NotContains(t, nav, "第二章")
+	assert.NotContains(t, nav, "第二卷")
+	assert.NotContains(t, nav, "第三章")
+
+	chapter := files["OEBPS/"+manifest["section-0002"]]
+	assert.Contains(t, chapter, "<h1>第一章</h1>")
+	assert.Contains(t, chapter, "<h2>序</h2>")
+	assert.Contains(t, chapter, "<strong>&lt;走&gt;</strong>")
+	assert.NotContains(t, chapter, "javascript:")
+	assert.Equal(t, string(epubPNGCover), files["OEBPS/images/cover.png"])
+}
+
+func TestExportService_ProcessProjectExport_EPUB(t *testing.T) {
+	f := newEPUBFixture(t)
+	task := &serviceInterfaces.ExportTask{ID: "task-1", Format: serviceInterfaces.ExportFormatEPUB}
+
+	var uploaded []byte
+	f.taskRepo.On("FindByID", mock.Anything, "task-1").Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Return(nil)
+	f.storage.On("Upload", mock.Anything, "山河&远方.epub", mock.Anything, "application/epub+zip").
+		Run(func(args mock.Arguments) {
+			uploaded, _ = io.ReadAll(args.Get(2).(io.Reader))
+		}).
+		Return("/exports/task-1.epub", nil)
+
+	f.service.processProjectExport(context.Background(), task, f.project, f.request())
+
+	assert.Equal(t, serviceInterfaces.ExportStatusCompleted, task.Status)
+	assert.Equal(t, 100, task.Progress)
+	assert.Equal(t, "/exports/task-1.epub", task.FileURL)
+	assert.Equal(t, int64(len(uploaded)), task.FileSize)
+	_, files := readEPUB(t, uploaded)
+	assert.Contains(t, files, "OEBPS/content.opf")
+	f.storage.AssertExpectations(t)
+}
+
+func TestExportService_ProcessProjectExport_EPUBStopsWhenCancelled(t *testing.T) {
+	f := newEPUBFixture(t)
+	task := &serviceInterfaces.ExportTask{ID: "task-2", Format: serviceInterfaces.ExportFormatEPUB}
+
+	// 处理完第一个文档后任务被用户取消
+	f.taskRepo.On("FindByID", mock.Anything, "task-2").
+		Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil).Once()
+	f.taskRepo.On("FindByID", mock.Anything, "task-2").
+		Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusCancelled}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Return(nil)
+
+	f.service.processProjectExport(context.Background(), task, f.project, f.request())
+
+	assert.NotEqual(t, serviceInterfaces.ExportStatusFailed, task.Status)
+	assert.NotEqual(t, serviceInterfaces.ExportStatusCompleted, task.Status)
+	assert.Less(t, task.Progress, 80)
+	f.storage.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	f.taskRepo.AssertNumberOfCalls(t, "FindByID", 2)
+}
+
+func TestExportService_ExportProject_RejectsUnknownFormat(t *testing.T) {
+	f := newEPUBFixture(t)
+	f.projectRepo.On("FindByID", mock.Anything, f.project.ID.Hex()).Return(f.project, nil)
+
+	_, err := f.service.ExportProject(context.Background(), f.project.ID.Hex(), "user-1",
+		&serviceInterfaces.ExportProjectRequest{Format: "pdf"})
+
+	assert.Error(t, err)
+	f.taskRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestExportProjectRequest_EPUBWithoutDocumentFormats(t *testing.T) {
+	validate := validator.New()
+	assert.NoError(t, validate.Struct(&serviceInterfaces.ExportProjectRequest{Format: serviceInterfaces.ExportFormatEPUB}))
+	assert.NoError(t, validate.Struct(&serviceInterfaces.ExportProjectRequest{DocumentFormats: serviceInterfaces.ExportFormatMD}))
+	assert.Error(t, validate.Struct(&serviceInterfaces.ExportProjectRequest{DocumentFormats: "pdf"}))
+}
@@ -8,10 +8,12 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"path"
 	"sort"
 	"strings"
 	"time"
 
+	"Qingyu_backend/models/users"
 	"Qingyu_backend/models/writer"
 	"Qingyu_backend/pkg/errors"
 	serviceInterfaces "Qingyu_backend/service/interfaces"
@@ -25,6 +27,9 @@ type ExportService struct {
 	projectRepo         ProjectRepository
 	exportTaskRepo      ExportTaskRepository
 	fileStorage         FileStorage
+	authorRepo          AuthorRepository        // 可选，用于 EPUB 作者信息
+	manuscriptRepo      ManuscriptRepository    // 可选，用于稿件导入写入
+	worldbuildingRepo   WorldbuildingRepository // 可选，用于项目 ZIP 中的物品与组织
 }
 
 // DocumentRepository 文档仓储接口
@@ -53,6 +58,11 @@ type ExportTaskRepository interface {
 	FindByUser(ctx context.Context, userID string, page, pageSize int) ([]*serviceInterfaces.ExportTask, int64, error)
 }
 
+// AuthorRepository 作者信息仓储接口
+type AuthorRepository interface {
+	FindByID(ctx context.Context, id string) (*users.User, error)
+}
+
 // FileStorage 文件存储接口
 type FileStorage interface {
 	Upload(ctx context.Context, filename string, content io.Reader, mimeType string) (string, error)
@@ -78,6 +88,11 @@ func NewExportService(
 	}
 }
 
+// SetAuthorRepository 设置作者信息仓储（EPUB 元数据中的作者）
+func (s *ExportService) SetAuthorRepository(repo AuthorRepository) {
+	s.authorRepo = repo
+}
+
 // ExportDocument 导出文档
 func (s *ExportService) ExportDocument(
 	ctx context.Context,
@@ -211,11 +226,10 @@ func (s *ExportService) generateDOCX(
 	document *writer.Document,
 	_ *serviceInterfaces.ExportDocumentRequest,
 ) ([]byte, string, string) {
-	plainText := tiptapToPlainText(content)
-	docxData, err := generateMinimalDOCX(document.Title, plainText)
+	docxData, err := tiptapToDOCX(document.Title, content)
 	if err != nil {
 		// 降级到可打开的纯文本内容，避免任务直接失败
-		docxData = []byte(document.Title + "\n\n" + plainText)
+		docxData = []byte(document.Title + "\n\n" + tiptapToPlainText(content))
 	}
 
 	filename := fmt.Sprintf("%s.docx", sanitizeFileName(document.Title))
@@ -324,12 +338,20 @@ func (s *ExportService) ExportProject(
 		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorNotFound, "项目不存在", "", err)
 	}
 
+	format := serviceInterfaces.ExportFormatZIP
+	if req != nil && req.Format != "" {
+		if req.Format != serviceInterfaces.ExportFormatZIP && req.Format != serviceInterfaces.ExportFormatEPUB {
+			return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "不支持的项目导出格式", "", nil)
+		}
+		format = req.Format
+	}
+
 	task := &serviceInterfaces.ExportTask{
 		ID:            primitive.NewObjectID().Hex(),
 		Type:          serviceInterfaces.ExportTypeProject,
 		ResourceID:    projectID,
 		ResourceTitle: project.Title,
-		Format:        serviceInterfaces.ExportFormatZIP,
+		Format:        format,
 		Status:        serviceInterfaces.ExportStatusPending,
 		Progress:      0,
 		CreatedBy:     userID,
@@ -354,20 +376,47 @@ func (s *ExportService) processProjectExport(
 ) {
 	s.updateTaskProgress(ctx, task, serviceInterfaces.ExportStatusProcessing, 10)
 
-	archiveData, err := s.buildProjectArchive(ctx, project, req)
+	var (
+		data []byte
+		err  error
+	)
+	if task.Format == serviceInterfaces.ExportFormatEPUB {
+		// 按章节推进 10% ~ 80% 的进度，期间任务被取消则中止
+		data, err = s.buildProjectEPUB(ctx, project, req, func(done, total int) error {
+			if s.isTaskCancelled(ctx, task.ID) {
+				return errExportCancelled
+			}
+			s.updateTaskProgress(ctx, task, serviceInterfaces.ExportStatusProcessing, 10+70*done/total)
+			return nil
+		})
+	} else {
+		data, err = s.buildProjectArchive(ctx, project, req)
+	}
+	if isExportCancelled(err) {
+		return
+	}
 	if err != nil {
 		s.failTask(ctx, task, err.Error())
 		return
 	}
 
+	if s.isTaskCancelled(ctx, task.ID) {
+		return
+	}
 	s.updateTaskProgress(ctx, task, serviceInterfaces.ExportStatusProcessing, 80)
 
-	filename := fmt.Sprintf("%s.zip", sanitizeFileName(project.Title))
-	if err := s.completeTaskWithFile(ctx, task, filename, "application/zip", archiveData); err != nil {
+	filename := fmt.Sprintf("%s.%s", sanitizeFileName(project.Title), task.Format)
+	if err := s.completeTaskWithFile(ctx, task, filename, s.getMimeType(task.Format), data); err != nil {
 		s.failTask(ctx, task, err.Error())
 	}
 }
 
+// isTaskCancelled 检查任务是否已被用户取消
+func (s *ExportService) isTaskCancelled(ctx context.Context, taskID string) bool {
+	current, err := s.exportTaskRepo.FindByID(ctx, taskID)
+	return err == nil && current != nil && current.Status == serviceInterfaces.ExportStatusCancelled
+}
+
 // CancelExportTask 取消导出任务
 func (s *ExportService) CancelExportTask(ctx context.Context, taskID, userID string) error {
 	task, err := s.exportTaskRepo.FindByID(ctx, taskID)
@@ -396,8 +445,10 @@ func (s *ExportService) ExportProjectAsZip(ctx context.Context, projectID, _ str
 	}
 
 	defaultReq := &serviceInterfaces.ExportProjectRequest{
-		IncludeDocuments: true,
-		DocumentFormats:  serviceInterfaces.ExportFormatTXT,
+		IncludeDocuments:     true,
+		IncludeItems:         true,
+		IncludeOrganizations: true,
+		DocumentFormats:      serviceInterfaces.ExportFormatTXT,
 	}
 	return s.buildProjectArchive(ctx, project, defaultReq)
 }
@@ -425,6 +476,11 @@ func (s *ExportService) buildProjectArchive(
 		}
 	}
 
+	if err := s.addWorldbuildingToZip(ctx, zipWriter, rootFolder, project.ID.Hex(), req); err != nil {
+		_ = zipWriter.Close()
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "打包设定数据失败", "", err)
+	}
+
 	if err := zipWriter.Close(); err != nil {
 		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "生成 ZIP 失败", "", err)
 	}
@@ -433,7 +489,13 @@ func (s *ExportService) buildProjectArchive(
 }
 
 // ImportProject 从 ZIP 数据导入项目
+// 目录导入为卷/章，TXT/Markdown/DOCX 文件转换为 TipTap JSON 后导入为文档；
+// 项目、文档和内容通过稿件写入仓储保存，任一步失败时回滚已写入的数据
 func (s *ExportService) ImportProject(ctx context.Context, userID string, zipData []byte) (*serviceInterfaces.ImportResult, error) {
+	if s.manuscriptRepo == nil {
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "项目导入未配置", "", nil)
+	}
+
 	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
 	if err != nil {
 		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "无效的 ZIP 文件", "", err)
@@ -444,79 +506,112 @@ func (s *ExportService) ImportProject(ctx context.Context, userID string, zipDat
 		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "无法找到项目根目录", "", nil)
 	}
 
-	projectTitle := strings.TrimSuffix(rootFolder, "/")
-	project := &writer.Project{
-		WritingType: "novel",
-		Status:      writer.StatusDraft,
-		Visibility:  writer.VisibilityPrivate,
-		Summary:     fmt.Sprintf("从文件导入于 %s", time.Now().Format("2006-01-02 15:04:05")),
+	project, err := newImportedProject(strings.TrimSuffix(rootFolder, "/"), userID)
+	if err != nil {
+		return nil, err
 	}
-	project.Title = projectTitle
-	project.AuthorID, _ = primitive.ObjectIDFromHex(userID)
-	project.CreatedAt = time.Now()
-	project.UpdatedAt = time.Now()
-	project.ID = primitive.NewObjectID()
 
-	documentCount := 0
-	folderDocMap := make(map[string]string)
+	tree := newProjectImportTree(project.ID)
+	var (
+		items []*writer.Item
+		orgs  *organizationArchive
+	)
 
 	for _, file := range reader.File {
-		if file.FileInfo().IsDir() || !strings.HasPrefix(file.Name, rootFolder) {
+		if !strings.HasPrefix(file.Name, rootFolder) {
 			continue
 		}
 
-		relativePath := strings.TrimPrefix(strings.TrimPrefix(file.Name, rootFolder), "/")
-		if relativePath == "" {
+		relativePath := strings.Trim(strings.TrimPrefix(file.Name, rootFolder), "/")
+		if relativePath == "" || relativePath == worldbuildingFolder {
 			continue
 		}
 
-		pathParts := strings.Split(relativePath, "/")
-		parentID := ""
-		for i := 0; i < len(pathParts)-1; i++ {
-			folderPath := strings.Join(pathParts[:i+1], "/")
-			if folderDocID, exists := folderDocMap[folderPath]; exists {
-				parentID = folderDocID
+		// 设定数据不作为文档导入
+		if strings.HasPrefix(relativePath, worldbuildingFolder+"/") {
+			switch strings.TrimPrefix(relativePath, worldbuildingFolder+"/") {
+			case worldbuildingItemsFile:
+				if err := readZipJSON(file, &items); err != nil {
+					return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "物品数据格式错误", "", err)
+				}
+			case worldbuildingOrgsFile:
+				orgs = &organizationArchive{}
+				if err := readZipJSON(file, orgs); err != nil {
+					return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "组织数据格式错误", "", err)
+				}
 			}
+			continue
 		}
 
-		if strings.HasSuffix(file.Name, ".txt") || strings.HasSuffix(file.Name, ".md") || strings.HasSuffix(file.Name, ".docx") {
-			rc, openErr := file.Open()
-			if openErr != nil {
-				continue
-			}
+		if file.FileInfo().IsDir() {
+			tree.folder(relativePath)
+			continue
+		}
 
-			var fileBuf bytes.Buffer
-			_, readErr := fileBuf.ReadFrom(rc)
-			_ = rc.Close()
-			if readErr != nil {
-				continue
-			}
+		switch strings.ToLower(filepathExt(relativePath)) {
+		case ".txt", ".md", ".docx":
+		default:
+			continue
+		}
 
-			title := strings.TrimSuffix(pathParts[len(pathParts)-1], filepathExt(pathParts[len(pathParts)-1]))
-			docID := primitive.NewObjectID().Hex()
-			documentCount++
-			if len(pathParts) > 1 {
-				folderPath := strings.Join(pathParts[:len(pathParts)-1], "/")
-				folderDocMap[folderPath] = docID
-			}
+		rc, openErr := file.Open()
+		if openErr != nil {
+			continue
+		}
+		data, readErr := io.ReadAll(rc)
+		_ = rc.Close()
+		if readErr != nil {
+			continue
+		}
 
-			_ = parentID
-			_ = title
-			_ = fileBuf.String()
+		dir, name := path.Split(relativePath)
+		title := strings.TrimSuffix(name, filepathExt(name))
+		content, convertErr := importDocumentContent(title, name, data)
+		if convertErr != nil {
 			continue
 		}
+		tree.file(strings.TrimSuffix(dir, "/"), title, content)
+	}
 
-		folderPath := strings.TrimSuffix(relativePath, "/")
-		folderDocMap[folderPath] = primitive.NewObjectID().Hex()
+	job := &manuscriptImportJob{repo: s.manuscriptRepo}
+	if err := s.writeImportedProject(ctx, job, project, tree, userID); err != nil {
+		job.rollback(ctx)
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "导入项目失败", "", err)
+	}
+
+	// 设定数据引用项目ID，只在项目写入后导入；导入失败时一并回滚项目
+	itemCount, orgCount, err := s.importWorldbuilding(ctx, project.ID.Hex(), items, orgs)
+	if err != nil {
+		job.rollback(ctx)
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "导入设定数据失败", "", err)
 	}
 
 	return &serviceInterfaces.ImportResult{
-		ProjectID:     project.ID.Hex(),
-		Title:         projectTitle,
-		DocumentCount: documentCount,
+		ProjectID:         project.ID.Hex(),
+		Title:             project.Title,
+		DocumentCount:     len(tree.docs),
+		ItemCount:         itemCount,
+		OrganizationCount: orgCount,
 	}, nil
 }
 
+// importDocumentContent 将导入的 TXT/Markdown/DOCX 文件转换为 TipTap JSON
+// 导出时写在正文开头的文档标题会被去掉，避免重复
+func importDocumentContent(title, fileName string, data []byte) (string, error) {
+	switch strings.ToLower(filepathExt(fileName)) {
+	case ".md":
+		return marshalTipTapDoc(stripLeadingTitle(markdownToTipTapNodes(string(data)), title)), nil
+	case ".docx":
+		blocks, err := docxToTipTapNodes(data)
+		if err != nil {
+			return "", err
+		}
+		return marshalTipTapDoc(stripLeadingTitle(blocks, title)), nil
+	default:
+		return plainTextToTipTap(string(data)), nil
+	}
+}
+
 func (s *ExportService) addDocumentsToZip(
 	ctx context.Context,
 	zipWriter *zip.Writer,
@@ -626,6 +721,8 @@ func (s *ExportService) getMimeType(format string) string {
 		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
 	case serviceInterfaces.ExportFormatZIP:
 		return "application/zip"
+	case serviceInterfaces.ExportFormatEPUB:
+		return "application/epub+zip"
 	default:
 		return "application/octet-stream"
 	}
@@ -800,82 +897,6 @@ func extractTipTapText(node tiptapNode) string {
 	}
 }
 
-func tiptapToMarkdown(content string) string {
-	return tiptapToPlainText(content)
-}
-
-func generateMinimalDOCX(title string, plainText string) ([]byte, error) {
-	var buf bytes.Buffer
-	zipWriter := zip.NewWriter(&buf)
-
-	files := map[string]string{
-		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
-<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
-  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
-  <Default Extension="xml" ContentType="application/xml"/>
-  <Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
-</Types>`,
-		"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
-<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
-  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
-</Relationships>`,
-		"word/document.xml": buildDOCXDocumentXML(title, plainText),
-	}
-
-	for path, content := range files {
-		fileWriter, err := zipWriter.Create(path)
-		if err != nil {
-			_ = zipWriter.Close()
-			return nil, err
-		}
-		if _, err := fileWriter.Write([]byte(content)); err != nil {
-			_ = zipWriter.Close()
-			return nil, err
-		}
-	}
-
-	if err := zipWriter.Close(); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-}
-
-func buildDOCXDocumentXML(title string, plainText string) string {
-	var paragraphs strings.Builder
-	paragraphs.WriteString(docxParagraph(title, true))
-
-	for _, block := range splitParagraphs(plainText) {
-		paragraphs.WriteString(docxParagraph(block, false))
-	}
-
-	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
-		`<w:document xmlns:wpc="http://schemas.microsoft.com/office/word/2010/wordprocessingCanvas"` +
-		` xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"` +
-		` xmlns:o="urn:schemas-microsoft-com:office:office"` +
-		` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"` +
-		` xmlns:m="http://schemas.openxmlformats.org/officeDocument/2006/math"` +
-		` xmlns:v="urn:schemas-microsoft-com:vml"` +
-		` xmlns:wp14="http://schemas.microsoft.com/office/word/2010/wordprocessingDrawing"` +
-		` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"` +
-		` xmlns:w10="urn:schemas-microsoft-com:office:word"` +
-		` xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"` +
-		` xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml"` +
-		` xmlns:wpg="http://schemas.microsoft.com/office/word/2010/wordprocessingGroup"` +
-		` xmlns:wpi="http://schemas.microsoft.com/office/word/2010/wordprocessingInk"` +
-		` xmlns:wne="http://schemas.microsoft.com/office/word/2006/wordml"` +
-		` xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape" mc:Ignorable="w14 wp14">` +
-		`<w:body>` + paragraphs.String() + `<w:sectPr/></w:body></w:document>`
-}
-
-func docxParagraph(text string, heading bool) string {
-	escaped := xmlEscape(text)
-	if heading {
-		return `<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">` + escaped + `</w:t></w:r></w:p>`
-	}
-	return `<w:p><w:r><w:t xml:space="preserve">` + escaped + `</w:t></w:r></w:p>`
-}
-
 func splitParagraphs(text string) []string {
 	text = strings.ReplaceAll(text, "\r\n", "\n")
 	raw := strings.Split(text, "\n")
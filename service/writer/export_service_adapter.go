@@ -3,7 +3,9 @@ package writer
 import (
 	"context"
 
+	usersModel "Qingyu_backend/models/users"
 	writerModel "Qingyu_backend/models/writer"
+	userInterface "Qingyu_backend/repository/interfaces/user"
 	writerInterface "Qingyu_backend/repository/interfaces/writer"
 )
 
@@ -50,6 +52,105 @@ func (a *projectRepoAdapter) FindByID(ctx context.Context, id string) (*writerMo
 	return a.repo.GetByID(ctx, id)
 }
 
+// authorRepoAdapter 将 UserRepository 适配到 ExportService 的 AuthorRepository 接口
+type authorRepoAdapter struct {
+	repo userInterface.UserRepository
+}
+
+// FindByID 适配 GetByID -> FindByID
+func (a *authorRepoAdapter) FindByID(ctx context.Context, id string) (*usersModel.User, error) {
+	return a.repo.GetByID(ctx, id)
+}
+
+// manuscriptRepoAdapter 将项目、文档、文档内容仓储组合为 ExportService 的 ManuscriptRepository 接口
+type manuscriptRepoAdapter struct {
+	projectRepo  writerInterface.ProjectRepository
+	documentRepo writerInterface.DocumentRepository
+	contentRepo  writerInterface.DocumentContentRepository
+}
+
+// CreateProject 委托给 ProjectRepository.Create
+func (a *manuscriptRepoAdapter) CreateProject(ctx context.Context, project *writerModel.Project) error {
+	return a.projectRepo.Create(ctx, project)
+}
+
+// DeleteProject 委托给 ProjectRepository.Delete
+func (a *manuscriptRepoAdapter) DeleteProject(ctx context.Context, id string) error {
+	return a.projectRepo.Delete(ctx, id)
+}
+
+// CreateDocument 委托给 DocumentRepository.Create
+func (a *manuscriptRepoAdapter) CreateDocument(ctx context.Context, doc *writerModel.Document) error {
+	return a.documentRepo.Create(ctx, doc)
+}
+
+// DeleteDocument 委托给 DocumentRepository.Delete
+func (a *manuscriptRepoAdapter) DeleteDocument(ctx context.Context, id string) error {
+	return a.documentRepo.Delete(ctx, id)
+}
+
+// CreateDocumentContent 委托给 DocumentContentRepository.Create
+func (a *manuscriptRepoAdapter) CreateDocumentContent(ctx context.Context, content *writerModel.DocumentContent) error {
+	return a.contentRepo.Create(ctx, content)
+}
+
+// DeleteDocumentContent 委托给 DocumentContentRepository.Delete
+func (a *manuscriptRepoAdapter) DeleteDocumentContent(ctx context.Context, id string) error {
+	return a.contentRepo.Delete(ctx, id)
+}
+
+// UpdateProjectStatistics 更新项目统计字段
+func (a *manuscriptRepoAdapter) UpdateProjectStatistics(ctx context.Context, projectID string, stats writerModel.ProjectStats) error {
+	return a.projectRepo.Update(ctx, projectID, map[string]interface{}{
+		"statistics": stats,
+	})
+}
+
+// worldbuildingRepoAdapter 将物品、组织仓储组合为 ExportService 的 WorldbuildingRepository 接口
+type worldbuildingRepoAdapter struct {
+	itemRepo writerInterface.ItemRepository
+	orgRepo  writerInterface.OrganizationRepository
+}
+
+// FindItems 委托给 ItemRepository.FindByProjectID
+func (a *worldbuildingRepoAdapter) FindItems(ctx context.Context, projectID string) ([]*writerModel.Item, error) {
+	if a.itemRepo == nil {
+		return nil, nil
+	}
+	return a.itemRepo.FindByProjectID(ctx, projectID)
+}
+
+// FindOrganizations 委托给 OrganizationRepository.FindByProjectID
+func (a *worldbuildingRepoAdapter) FindOrganizations(ctx context.Context, projectID string) ([]*writerModel.Organization, error) {
+	if a.orgRepo == nil {
+		return nil, nil
+	}
+	return a.orgRepo.FindByProjectID(ctx, projectID)
+}
+
+// FindOrganizationRelations 获取项目下全部组织关系
+func (a *worldbuildingRepoAdapter) FindOrganizationRelations(ctx context.Context, projectID string) ([]*writerModel.OrgRelation, error) {
+	if a.orgRepo == nil {
+		return nil, nil
+	}
+	return a.orgRepo.FindRelations(ctx, projectID, nil)
+}
+
+// CreateItem 委托给 ItemRepository.Create
+func (a *worldbuildingRepoAdapter) CreateItem(ctx context.Context, item *writerModel.Item) error {
+	return a.itemRepo.Create(ctx, item)
+}
+
+// CreateOrganization 委托给 OrganizationRepository.Create
+func (a *worldbuildingRepoAdapter) CreateOrganization(ctx context.Context, org *writerModel.Organization) error {
+	return a.orgRepo.Create(ctx, org)
+}
+
+// CreateOrganizationRelation 委托给 OrganizationRepository.CreateRelation
+func (a *worldbuildingRepoAdapter) CreateOrganizationRelation(ctx context.Context, relation *writerModel.OrgRelation) error {
+	return a.orgRepo.CreateRelation(ctx, relation)
+}
+
 // NewDocumentRepoAdapter 创建 DocumentRepository 适配器
 func NewDocumentRepoAdapter(repo writerInterface.DocumentRepository) *documentRepoAdapter {
 	return &documentRepoAdapter{repo: repo}
@@ -64,3 +165,25 @@ func NewDocumentContentRepoAdapter(repo writerInterface.DocumentContentRepositor
 func NewProjectRepoAdapter(repo writerInterface.ProjectRepository) *projectRepoAdapter {
 	return &projectRepoAdapter{repo: repo}
 }
+
+// NewAuthorRepoAdapter 创建 AuthorRepository 适配器
+func NewAuthorRepoAdapter(repo userInterface.UserRepository) *authorRepoAdapter {
+	return &authorRepoAdapter{repo: repo}
+}
+
+// NewManuscriptRepoAdapter 创建 ManuscriptRepository 适配器
+func NewManuscriptRepoAdapter(
+	projectRepo writerInterface.ProjectRepository,
+	documentRepo writerInterface.DocumentRepository,
+	contentRepo writerInterface.DocumentContentRepository,
+) *manuscriptRepoAdapter {
+	return &manuscriptRepoAdapter{projectRepo: projectRepo, documentRepo: documentRepo, contentRepo: contentRepo}
+}
+
+// NewWorldbuildingRepoAdapter 创建 WorldbuildingRepository 适配器
+func NewWorldbuildingRepoAdapter(
+	itemRepo writerInterface.ItemRepository,
+	orgRepo writerInterface.OrganizationRepository,
+) *worldbuildingRepoAdapter {
+	return &worldbuildingRepoAdapter{itemRepo: itemRepo, orgRepo: orgRepo}
+}
@@ -0,0 +1,177 @@
+package writer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"Qingyu_backend/models/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 项目 ZIP 中存放设定数据的目录与文件
+const (
+	worldbuildingFolder    = "_worldbuilding"
+	worldbuildingItemsFile = "items.json"
+	worldbuildingOrgsFile  = "organizations.json"
+)
+
+// WorldbuildingRepository 设定数据（物品、组织）读写接口
+type WorldbuildingRepository interface {
+	FindItems(ctx context.Context, projectID string) ([]*writer.Item, error)
+	FindOrganizations(ctx context.Context, projectID string) ([]*writer.Organization, error)
+	FindOrganizationRelations(ctx context.Context, projectID string) ([]*writer.OrgRelation, error)
+	CreateItem(ctx context.Context, item *writer.Item) error
+	CreateOrganization(ctx context.Context, org *writer.Organization) error
+	CreateOrganizationRelation(ctx context.Context, relation *writer.OrgRelation) error
+}
+
+// SetWorldbuildingRepository 设置设定数据仓储（项目 ZIP 导出/导入物品与组织）
+func (s *ExportService) SetWorldbuildingRepository(repo WorldbuildingRepository) {
+	s.worldbuildingRepo = repo
+}
+
+// organizationArchive organizations.json 的内容
+type organizationArchive struct {
+	Organizations []*writer.Organization `json:"organizations"`
+	Relations     []*writer.OrgRelation  `json:"relations"`
+}
+
+// addWorldbuildingToZip 将物品与组织写入 _worldbuilding 目录
+func (s *ExportService) addWorldbuildingToZip(
+	ctx context.Context,
+	zipWriter *zip.Writer,
+	rootFolder string,
+	projectID string,
+	req *serviceInterfaces.ExportProjectRequest,
+) error {
+	if s.worldbuildingRepo == nil || req == nil {
+		return nil
+	}
+
+	if req.IncludeItems {
+		items, err := s.worldbuildingRepo.FindItems(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("获取物品失败: %w", err)
+		}
+		if err := writeZipJSON(zipWriter, rootFolder+"/"+worldbuildingFolder+"/"+worldbuildingItemsFile, items); err != nil {
+			return err
+		}
+	}
+
+	if req.IncludeOrganizations {
+		orgs, err := s.worldbuildingRepo.FindOrganizations(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("获取组织失败: %w", err)
+		}
+		relations, err := s.worldbuildingRepo.FindOrganizationRelations(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("获取组织关系失败: %w", err)
+		}
+		archive := &organizationArchive{Organizations: orgs, Relations: relations}
+		if err := writeZipJSON(zipWriter, rootFolder+"/"+worldbuildingFolder+"/"+worldbuildingOrgsFile, archive); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeZipJSON 以缩进 JSON 写入 ZIP 文件
+func writeZipJSON(zipWriter *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readZipJSON 读取 ZIP 中的 JSON 文件
+func readZipJSON(file *zip.File, v interface{}) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// importWorldbuilding 将 ZIP 中的物品与组织写入新项目，须在项目写入成功后调用
+// 所有ID重新生成，上下级与组织关系按新ID重建；
+// 角色与地点不随 ZIP 导入，对它们的引用（持有者、所在地、领袖、成员、总部）会被清空
+func (s *ExportService) importWorldbuilding(
+	ctx context.Context,
+	projectID string,
+	items []*writer.Item,
+	orgs *organizationArchive,
+) (itemCount, orgCount int, err error) {
+	if s.worldbuildingRepo == nil {
+		return 0, 0, nil
+	}
+
+	itemIDs := make(map[string]string, len(items))
+	for _, item := range items {
+		itemIDs[item.ID] = primitive.NewObjectID().Hex()
+	}
+	for _, item := range items {
+		item.ID = itemIDs[item.ID]
+		item.ProjectID = projectID
+		item.ParentID = itemIDs[item.ParentID]
+		item.OwnerID = ""
+		item.LocationID = ""
+		if err := s.worldbuildingRepo.CreateItem(ctx, item); err != nil {
+			return itemCount, orgCount, fmt.Errorf("导入物品失败: %w", err)
+		}
+		itemCount++
+	}
+
+	if orgs == nil {
+		return itemCount, orgCount, nil
+	}
+
+	orgIDs := make(map[string]string, len(orgs.Organizations))
+	for _, org := range orgs.Organizations {
+		orgIDs[org.ID] = primitive.NewObjectID().Hex()
+	}
+	for _, org := range orgs.Organizations {
+		org.ID = orgIDs[org.ID]
+		org.ProjectID = projectID
+		org.ParentID = orgIDs[org.ParentID]
+		org.LeaderID = ""
+		org.BaseLocationID = ""
+		org.Members = nil
+		if err := s.worldbuildingRepo.CreateOrganization(ctx, org); err != nil {
+			return itemCount, orgCount, fmt.Errorf("导入组织失败: %w", err)
+		}
+		orgCount++
+	}
+
+	for _, relation := range orgs.Relations {
+		from, to := orgIDs[relation.FromOrgID], orgIDs[relation.ToOrgID]
+		if from == "" || to == "" || !relation.Relation.IsValid() {
+			continue
+		}
+		relation.ID = primitive.NewObjectID().Hex()
+		relation.ProjectID = projectID
+		relation.FromOrgID = from
+		relation.ToOrgID = to
+		if err := s.worldbuildingRepo.CreateOrganizationRelation(ctx, relation); err != nil {
+			return itemCount, orgCount, fmt.Errorf("导入组织关系失败: %w", err)
+		}
+	}
+
+	return itemCount, orgCount, nil
+}
@@ -350,12 +350,14 @@ func (d *DocumentManagementImpl) GetVersionHistory(ctx context.Context, document
 	versions := make([]*serviceWriter.VersionInfo, 0, len(versionResp.Versions))
 	for _, v := range versionResp.Versions {
 		versions = append(versions, &serviceWriter.VersionInfo{
-			VersionID: v.VersionID,
-			Version:   v.Version,
-			Message:   v.Message,
-			CreatedAt: v.CreatedAt,
-			CreatedBy: v.CreatedBy,
-			WordCount: v.WordCount,
+			VersionID:           v.VersionID,
+			Version:             v.Version,
+			Message:             v.Message,
+			CreatedAt:           v.CreatedAt,
+			CreatedBy:           v.CreatedBy,
+			WordCount:           v.WordCount,
+			Operation:           v.Operation,
+			RestoredFromVersion: v.RestoredFromVersion,
 		})
 	}
 	return &serviceWriter.VersionHistoryResponse{
@@ -394,21 +396,51 @@ func (d *DocumentManagementImpl) CompareVersions(ctx context.Context, documentID
 	// 转换响应类型
 	changes := make([]serviceWriter.ChangeItem, 0, len(versionResp.Changes))
 	for _, c := range versionResp.Changes {
-		changes = append(changes, serviceWriter.ChangeItem{
-			Type:    c.Type,
-			Line:    c.Line,
-			Content: c.Content,
+		changes = append(changes, toChangeItem(c))
+	}
+	hunks := make([]dto.DiffHunk, 0, len(versionResp.Hunks))
+	for _, h := range versionResp.Hunks {
+		lines := make([]serviceWriter.ChangeItem, 0, len(h.Lines))
+		for _, c := range h.Lines {
+			lines = append(lines, toChangeItem(c))
+		}
+		hunks = append(hunks, dto.DiffHunk{
+			OldStart: h.OldStart,
+			OldLines: h.OldLines,
+			NewStart: h.NewStart,
+			NewLines: h.NewLines,
+			Header:   h.Header,
+			Lines:    lines,
 		})
 	}
 	return &serviceWriter.VersionDiff{
-		FromVersion:  versionResp.FromVersion,
-		ToVersion:    versionResp.ToVersion,
-		Changes:      changes,
-		AddedLines:   versionResp.AddedLines,
-		DeletedLines: versionResp.DeletedLines,
+		FromVersion:   versionResp.FromVersion,
+		ToVersion:     versionResp.ToVersion,
+		Changes:       changes,
+		Hunks:         hunks,
+		Unified:       versionResp.Unified,
+		AddedLines:    versionResp.AddedLines,
+		DeletedLines:  versionResp.DeletedLines,
+		ModifiedLines: versionResp.ModifiedLines,
 	}, nil
 }
 
+// toChangeItem 转换差异变更项
+func toChangeItem(c writerproject.ChangeItem) serviceWriter.ChangeItem {
+	item := serviceWriter.ChangeItem{
+		Type:       c.Type,
+		Line:       c.Line,
+		OldLine:    c.OldLine,
+		NewLine:    c.NewLine,
+		Content:    c.Content,
+		OldContent: c.OldContent,
+	}
+	for _, seg := range c.Segments {
+		item.Segments = append(item.Segments, dto.InlineSegment{Type: seg.Type, Text: seg.Text})
+	}
+	return item
+}
+
 // RestoreVersion 恢复到特定版本
 func (d *DocumentManagementImpl) RestoreVersion(ctx context.Context, documentID, versionID string) error {
 	return d.versionService.RestoreVersion(ctx, documentID, versionID)
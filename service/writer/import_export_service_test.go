@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"Qingyu_backend/models/writer"
@@ -264,7 +266,9 @@ func TestExportService_ImportProject_Success(t *testing.T) {
 		"第二章.txt": "这是第二章的内容",
 	})
 
-	service := NewExportService(mockDocRepo, mockContentRepo, mockProjectRepo, mockTaskRepo, nil)
+	service := NewExportService(mockDocRepo, mockContentRepo, mockProjectRepo, mockTaskRepo, nil).(*ExportService)
+	repo := newFakeManuscriptRepo()
+	service.SetManuscriptRepository(repo)
 
 	// When
 	result, err := service.ImportProject(context.Background(), userID, zipData)
@@ -274,7 +278,95 @@ func TestExportService_ImportProject_Success(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.NotEmpty(t, result.ProjectID)
 	assert.Equal(t, "导入测试项目", result.Title)
-	assert.GreaterOrEqual(t, result.DocumentCount, 2)
+	assert.Equal(t, 2, result.DocumentCount)
+	require.Len(t, repo.projects, 1)
+	assert.Equal(t, result.ProjectID, repo.projects[0].ID.Hex())
+	assert.Len(t, repo.documents, 2)
+	assert.Len(t, repo.contents, 2)
+}
+
+// TestExportService_ImportProject_ReadBack 测试导出的项目 ZIP 导入后可读回层级与正文
+func TestExportService_ImportProject_ReadBack(t *testing.T) {
+	ctx := context.Background()
+	project := createTestProject(primitive.NewObjectID().Hex(), "山河")
+	projectID := project.ID.Hex()
+
+	volume := createTestDocument(projectID, "第一卷")
+	volume.Type = writer.TypeVolume
+	chapter := createTestDocument(projectID, "第一章")
+	chapter.ParentID = volume.ID
+	chapter.Level = 1
+	epilogue := createTestDocument(projectID, "尾声")
+	epilogue.Order = 2
+
+	mockDocRepo := new(MockDocumentRepositoryForExport)
+	mockContentRepo := new(MockDocumentContentRepositoryForExport)
+	mockProjectRepo := new(MockProjectRepositoryForExport)
+	mockProjectRepo.On("FindByID", mock.Anything, projectID).Return(project, nil)
+	mockDocRepo.On("FindByProjectID", mock.Anything, projectID).Return([]*writer.Document{volume, chapter, epilogue}, nil)
+	for doc, text := range map[*writer.Document]string{volume: "少年离家。", chapter: "清晨，他推开了门。", epilogue: "完。"} {
+		mockContentRepo.On("FindByID", mock.Anything, doc.ID.Hex()).Return(&writer.DocumentContent{Content: plainTextToTipTap(text)}, nil)
+	}
+
+	service := NewExportService(mockDocRepo, mockContentRepo, mockProjectRepo, new(MockExportTaskRepositoryForExport), nil).(*ExportService)
+	repo := newFakeManuscriptRepo()
+	service.SetManuscriptRepository(repo)
+
+	zipData, err := service.ExportProjectAsZip(ctx, projectID, "")
+	require.NoError(t, err)
+
+	userID := primitive.NewObjectID().Hex()
+	result, err := service.ImportProject(ctx, userID, zipData)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.DocumentCount)
+
+	require.Len(t, repo.projects, 1)
+	imported := repo.projects[0]
+	assert.Equal(t, result.ProjectID, imported.ID.Hex())
+	assert.Equal(t, "山河", imported.Title)
+	assert.Equal(t, userID, imported.AuthorID.Hex())
+	assert.Empty(t, repo.deleted)
+
+	require.Len(t, repo.documents, 3)
+	byTitle := make(map[string]*writer.Document)
+	for _, doc := range repo.documents {
+		byTitle[doc.Title] = doc
+		assert.Equal(t, imported.ID, doc.ProjectID)
+	}
+	require.Contains(t, byTitle, "第一卷")
+	require.Contains(t, byTitle, "第一章")
+	require.Contains(t, byTitle, "尾声")
+	assert.Equal(t, writer.TypeVolume, byTitle["第一卷"].Type)
+	assert.Equal(t, byTitle["第一卷"].ID, byTitle["第一章"].ParentID)
+	assert.Equal(t, 1, byTitle["第一章"].Level)
+	assert.True(t, byTitle["尾声"].IsRoot())
+	assert.Less(t, byTitle["第一卷"].OrderKey, byTitle["尾声"].OrderKey)
+
+	// 卷自身的正文导出在同名目录内，导入后仍属于卷
+	for title, text := range map[string]string{"第一卷": "少年离家。", "第一章": "清晨，他推开了门。", "尾声": "完。"} {
+		content := repo.contents[byTitle[title].ID.Hex()]
+		require.NotNil(t, content, title)
+		assert.Equal(t, "tiptap_json", content.ContentType)
+		assert.Equal(t, text, tiptapToPlainText(content.Content), title)
+		assert.Equal(t, writer.DocumentStatusCompleted, byTitle[title].Status)
+	}
+	require.NotNil(t, repo.stats)
+	assert.Equal(t, 3, repo.stats.DocumentCount)
+	assert.Equal(t, 2, repo.stats.ChapterCount)
+}
+
+// TestExportService_ImportProject_RollsBackOnFailure 测试写入失败时回滚已写入的项目与文档
+func TestExportService_ImportProject_RollsBackOnFailure(t *testing.T) {
+	zipData := createTestZipData(t, "导入测试项目", map[string]string{"卷一/第一章.txt": "正文"})
+	service := NewExportService(new(MockDocumentRepositoryForExport), new(MockDocumentContentRepositoryForExport), new(MockProjectRepositoryForExport), new(MockExportTaskRepositoryForExport), nil).(*ExportService)
+	repo := newFakeManuscriptRepo()
+	repo.failOn = "第一章"
+	service.SetManuscriptRepository(repo)
+
+	_, err := service.ImportProject(context.Background(), primitive.NewObjectID().Hex(), zipData)
+	require.Error(t, err)
+	require.Len(t, repo.documents, 1)
+	assert.Equal(t, []string{"document:" + repo.documents[0].ID.Hex(), "project:" + repo.projects[0].ID.Hex()}, repo.deleted)
 }
 
 func TestExportService_DownloadExportFile_ReturnsFileContent(t *testing.T) {
@@ -312,7 +404,91 @@ func TestExportService_DownloadExportFile_ReturnsFileContent(t *testing.T) {
 	mockFileStorage.AssertExpectations(t)
 }
 
+// failingWorldbuildingRepo 写入物品时返回错误的设定数据仓储
+type failingWorldbuildingRepo struct {
+	WorldbuildingRepository
+	projectIDs []string
+}
+
+func (r *failingWorldbuildingRepo) CreateItem(ctx context.Context, item *writer.Item) error {
+	r.projectIDs = append(r.projectIDs, item.ProjectID)
+	return fmt.Errorf("写入失败")
+}
+
+// TestExportService_ImportProject_WorldbuildingFailureRollsBack 测试设定数据导入失败时回滚已写入的项目
+func TestExportService_ImportProject_WorldbuildingFailureRollsBack(t *testing.T) {
+	zipData := createTestZipData(t, "设定项目", map[string]string{
+		"第一章.txt": "正文",
+		worldbuildingFolder + "/" + worldbuildingItemsFile: `[{"id":"old-1","name":"乾坤袋"}]`,
+	})
+	service := NewExportService(new(MockDocumentRepositoryForExport), new(MockDocumentContentRepositoryForExport), new(MockProjectRepositoryForExport), new(MockExportTaskRepositoryForExport), nil).(*ExportService)
+	manuscripts := newFakeManuscriptRepo()
+	worldbuilding := &failingWorldbuildingRepo{}
+	service.SetManuscriptRepository(manuscripts)
+	service.SetWorldbuildingRepository(worldbuilding)
+
+	_, err := service.ImportProject(context.Background(), primitive.NewObjectID().Hex(), zipData)
+	require.Error(t, err)
+
+	require.Len(t, manuscripts.projects, 1)
+	projectID := manuscripts.projects[0].ID.Hex()
+	assert.Equal(t, []string{projectID}, worldbuilding.projectIDs, "设定数据写入时项目已存在")
+	assert.Contains(t, manuscripts.deleted, "project:"+projectID)
+}
+
 // createTestZipData 创建测试用的ZIP数据
+// TestExportService_ProjectZip_WorldbuildingRoundTrip 测试物品与组织随项目 ZIP 导出并导入
+func TestExportService_ProjectZip_WorldbuildingRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	project := createTestProject(primitive.NewObjectID().Hex(), "设定项目")
+	projectID := project.ID.Hex()
+
+	mockDocRepo := new(MockDocumentRepositoryForExport)
+	mockProjectRepo := new(MockProjectRepositoryForExport)
+	mockDocRepo.On("FindByProjectID", mock.Anything, projectID).Return([]*writer.Document{}, nil)
+	mockProjectRepo.On("FindByID", mock.Anything, projectID).Return(project, nil)
+
+	itemRepo, orgRepo := newMemoryItemRepo(), newMemoryOrganizationRepo()
+	items := NewItemService(itemRepo, nil)
+	orgs := NewOrganizationService(orgRepo, nil, nil)
+	bag, _ := items.Create(ctx, projectID, "", &serviceInterfaces.CreateItemRequest{Name: "乾坤袋", OwnerID: "char-1"})
+	_, _ = items.Create(ctx, projectID, "", &serviceInterfaces.CreateItemRequest{Name: "筑基丹", ParentID: bag.ID})
+	empire, _ := orgs.Create(ctx, projectID, "", &serviceInterfaces.CreateOrganizationRequest{Name: "大夏"})
+	army, _ := orgs.Create(ctx, projectID, "", &serviceInterfaces.CreateOrganizationRequest{Name: "镇北军", ParentID: empire.ID})
+	_, err := orgs.CreateRelation(ctx, projectID, &serviceInterfaces.CreateOrgRelationRequest{FromOrgID: army.ID, ToOrgID: empire.ID, Relation: string(writer.OrgRelationSubordinate)})
+	require.NoError(t, err)
+
+	service := NewExportService(mockDocRepo, new(MockDocumentContentRepositoryForExport), mockProjectRepo, new(MockExportTaskRepositoryForExport), nil).(*ExportService)
+	manuscripts := newFakeManuscriptRepo()
+	service.SetManuscriptRepository(manuscripts)
+	service.SetWorldbuildingRepository(NewWorldbuildingRepoAdapter(itemRepo, orgRepo))
+
+	zipData, err := service.ExportProjectAsZip(ctx, projectID, "")
+	require.NoError(t, err)
+
+	result, err := service.ImportProject(ctx, primitive.NewObjectID().Hex(), zipData)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.ItemCount)
+	assert.Equal(t, 2, result.OrganizationCount)
+	assert.Equal(t, 0, result.DocumentCount, "设定文件不计为文档")
+	require.Len(t, manuscripts.projects, 1, "设定数据导入前先写入项目")
+	assert.Equal(t, result.ProjectID, manuscripts.projects[0].ID.Hex())
+
+	importedItems, _ := itemRepo.FindByProjectID(ctx, result.ProjectID)
+	require.Len(t, importedItems, 2)
+	assert.NotEqual(t, bag.ID, importedItems[0].ID, "导入时重新生成ID")
+	assert.Empty(t, importedItems[0].OwnerID, "角色引用不随 ZIP 导入")
+	assert.Equal(t, importedItems[0].ID, importedItems[1].ParentID)
+
+	importedRelations, _ := orgRepo.FindRelations(ctx, result.ProjectID, nil)
+	require.Len(t, importedRelations, 1)
+	importedOrgs, _ := orgRepo.FindByProjectID(ctx, result.ProjectID)
+	require.Len(t, importedOrgs, 2)
+	assert.Equal(t, importedOrgs[0].ID, importedOrgs[1].ParentID)
+	assert.Equal(t, importedOrgs[1].ID, importedRelations[0].FromOrgID)
+	assert.Equal(t, importedOrgs[0].ID, importedRelations[0].ToOrgID)
+}
+
 func createTestZipData(t *testing.T, projectName string, files map[string]string) []byte {
 	buf := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buf)
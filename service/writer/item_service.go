@@ -0,0 +1,283 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/service/base"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// ItemService 物品服务实现
+type ItemService struct {
+	itemRepo writerRepo.ItemRepository
+	eventBus base.EventBus
+}
+
+// NewItemService 创建ItemService实例
+func NewItemService(
+	itemRepo writerRepo.ItemRepository,
+	eventBus base.EventBus,
+) serviceInterfaces.ItemService {
+	return &ItemService{
+		itemRepo: itemRepo,
+		eventBus: eventBus,
+	}
+}
+
+// Create 创建物品
+func (s *ItemService) Create(
+	ctx context.Context,
+	projectID, userID string,
+	req *serviceInterfaces.CreateItemRequest,
+) (*writer.Item, error) {
+	if req.ParentID != "" {
+		if _, err := s.GetByID(ctx, req.ParentID, projectID); err != nil {
+			return nil, errors.NewServiceError("ItemService", errors.ServiceErrorNotFound, "parent item not found", "", err)
+		}
+	}
+
+	item := &writer.Item{
+		ProjectID:   projectID,
+		Name:        req.Name,
+		Type:        req.Type,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		OwnerID:     req.OwnerID,
+		LocationID:  req.LocationID,
+		Alias:       req.Alias,
+		Rarity:      req.Rarity,
+		Function:    req.Function,
+		Origin:      req.Origin,
+		ImageURL:    req.ImageURL,
+	}
+
+	if err := s.itemRepo.Create(ctx, item); err != nil {
+		return nil, errors.NewServiceError("ItemService", errors.ServiceErrorInternal, "create item failed", "", err)
+	}
+
+	s.publishEvent(ctx, "item.created", map[string]interface{}{
+		"item_id":    item.ID,
+		"project_id": projectID,
+		"user_id":    userID,
+		"name":       item.Name,
+	})
+
+	return item, nil
+}
+
+// GetByID 根据ID获取物品
+func (s *ItemService) GetByID(
+	ctx context.Context,
+	itemID, projectID string,
+) (*writer.Item, error) {
+	item, err := s.itemRepo.FindByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.ProjectID != projectID {
+		return nil, errors.NewServiceError("ItemService", errors.ServiceErrorForbidden, "no permission to access this item", "", nil)
+	}
+
+	return item, nil
+}
+
+// List 获取项目下的所有物品
+func (s *ItemService) List(
+	ctx context.Context,
+	projectID string,
+) ([]*writer.Item, error) {
+	return s.itemRepo.FindByProjectID(ctx, projectID)
+}
+
+// ListByOwner 获取角色持有的物品
+func (s *ItemService) ListByOwner(
+	ctx context.Context,
+	projectID, characterID string,
+) ([]*writer.Item, error) {
+	return s.itemRepo.FindByOwnerID(ctx, projectID, characterID)
+}
+
+// Update 更新物品
+func (s *ItemService) Update(
+	ctx context.Context,
+	itemID, projectID string,
+	req *serviceInterfaces.UpdateItemRequest,
+) (*writer.Item, error) {
+	item, err := s.GetByID(ctx, itemID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil && *req.ParentID != item.ParentID {
+		if err := s.validateParent(ctx, projectID, itemID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		item.ParentID = *req.ParentID
+	}
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Type != nil {
+		item.Type = *req.Type
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.OwnerID != nil {
+		item.OwnerID = *req.OwnerID
+	}
+	if req.LocationID != nil {
+		item.LocationID = *req.LocationID
+	}
+	if req.Alias != nil {
+		item.Alias = *req.Alias
+	}
+	if req.Rarity != nil {
+		item.Rarity = *req.Rarity
+	}
+	if req.Function != nil {
+		item.Function = *req.Function
+	}
+	if req.Origin != nil {
+		item.Origin = *req.Origin
+	}
+	if req.ImageURL != nil {
+		item.ImageURL = *req.ImageURL
+	}
+
+	if err := s.itemRepo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, "item.updated", map[string]interface{}{
+		"item_id":    itemID,
+		"project_id": projectID,
+	})
+
+	return item, nil
+}
+
+// Delete 删除物品，子物品上移到被删除物品的上级
+func (s *ItemService) Delete(
+	ctx context.Context,
+	itemID, projectID string,
+) error {
+	item, err := s.GetByID(ctx, itemID, projectID)
+	if err != nil {
+		return err
+	}
+
+	children, err := s.itemRepo.FindByParentID(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		child.ParentID = item.ParentID
+		if err := s.itemRepo.Update(ctx, child); err != nil {
+			return errors.NewServiceError("ItemService", errors.ServiceErrorInternal, "reparent child item failed", "", err)
+		}
+	}
+
+	if err := s.itemRepo.Delete(ctx, itemID); err != nil {
+		return err
+	}
+
+	s.publishEvent(ctx, "item.deleted", map[string]interface{}{
+		"item_id":    itemID,
+		"project_id": projectID,
+	})
+
+	return nil
+}
+
+// GetItemTree 获取物品层级树
+func (s *ItemService) GetItemTree(
+	ctx context.Context,
+	projectID string,
+) ([]*serviceInterfaces.ItemNode, error) {
+	items, err := s.itemRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildItemTree(items), nil
+}
+
+// validateParent 校验新的上级物品属于同一项目且不会形成环
+func (s *ItemService) validateParent(ctx context.Context, projectID, itemID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	items, err := s.itemRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	parentOf := make(map[string]string, len(items))
+	for _, it := range items {
+		parentOf[it.ID] = it.ParentID
+	}
+	if _, ok := parentOf[parentID]; !ok {
+		return errors.NewServiceError("ItemService", errors.ServiceErrorNotFound, "parent item not found", "", nil)
+	}
+	if createsCycle(parentOf, itemID, parentID) {
+		return errors.NewServiceError("ItemService", errors.ServiceErrorValidation, "item cannot be moved under itself or its descendants", "", nil)
+	}
+	return nil
+}
+
+// publishEvent 发布物品变更事件
+func (s *ItemService) publishEvent(ctx context.Context, eventType string, data map[string]interface{}) {
+	if s.eventBus == nil {
+		return
+	}
+	event := &base.BaseEvent{
+		EventType: eventType,
+		EventData: data,
+		Timestamp: time.Now(),
+		Source:    "ItemService",
+	}
+	s.eventBus.PublishAsync(ctx, event)
+}
+
+// buildItemTree 构建物品树
+func buildItemTree(items []*writer.Item) []*serviceInterfaces.ItemNode {
+	nodeMap := make(map[string]*serviceInterfaces.ItemNode)
+	var roots []*serviceInterfaces.ItemNode
+
+	for _, item := range items {
+		nodeMap[item.ID] = &serviceInterfaces.ItemNode{
+			Item:     item,
+			Children: []*serviceInterfaces.ItemNode{},
+		}
+	}
+
+	for _, item := range items {
+		node := nodeMap[item.ID]
+		if parent, ok := nodeMap[item.ParentID]; ok && item.ParentID != "" {
+			parent.Children = append(parent.Children, node)
+		} else {
+			// 上级缺失时作为根节点展示，避免数据丢失
+			roots = append(roots, node)
+		}
+	}
+
+	return roots
+}
+
+// createsCycle 判断把 id 挂到 parentID 之下是否会形成环
+func createsCycle(parentOf map[string]string, id, parentID string) bool {
+	seen := make(map[string]bool)
+	for current := parentID; current != "" && !seen[current]; current = parentOf[current] {
+		if current == id {
+			return true
+		}
+		seen[current] = true
+	}
+	return false
+}
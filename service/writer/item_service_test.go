@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"Qingyu_backend/models/writer"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryItemRepo 内存物品仓储
+type memoryItemRepo struct {
+	writerRepo.ItemRepository
+	items  map[string]*writer.Item
+	order  []string
+	nextID int
+}
+
+func newMemoryItemRepo() *memoryItemRepo {
+	return &memoryItemRepo{items: make(map[string]*writer.Item)}
+}
+
+func (r *memoryItemRepo) Create(ctx context.Context, item *writer.Item) error {
+	if item.ID == "" {
+		r.nextID++
+		item.ID = fmt.Sprintf("item-%d", r.nextID)
+	}
+	r.items[item.ID] = item
+	r.order = append(r.order, item.ID)
+	return nil
+}
+
+func (r *memoryItemRepo) FindByID(ctx context.Context, itemID string) (*writer.Item, error) {
+	item, ok := r.items[itemID]
+	if !ok {
+		return nil, fmt.Errorf("item not found: %s", itemID)
+	}
+	return item, nil
+}
+
+func (r *memoryItemRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Item, error) {
+	var result []*writer.Item
+	for _, id := range r.order {
+		if item, ok := r.items[id]; ok && item.ProjectID == projectID {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryItemRepo) FindByParentID(ctx context.Context, parentID string) ([]*writer.Item, error) {
+	var result []*writer.Item
+	for _, id := range r.order {
+		if item, ok := r.items[id]; ok && item.ParentID == parentID {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryItemRepo) Update(ctx context.Context, item *writer.Item) error {
+	r.items[item.ID] = item
+	return nil
+}
+
+func (r *memoryItemRepo) Delete(ctx context.Context, itemID string) error {
+	delete(r.items, itemID)
+	return nil
+}
+
+func TestItemService_TreeAndCycleRejection(t *testing.T) {
+	ctx := context.Background()
+	svc := NewItemService(newMemoryItemRepo(), nil)
+
+	set, err := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "玄甲套装"})
+	require.NoError(t, err)
+	helmet, err := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "玄甲盔", ParentID: set.ID})
+	require.NoError(t, err)
+	gem, err := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "镶嵌宝石", ParentID: helmet.ID})
+	require.NoError(t, err)
+
+	tree, err := svc.GetItemTree(ctx, "p1")
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.Equal(t, gem.ID, tree[0].Children[0].Children[0].Item.ID)
+
+	// 不能把上级挂到自己的子孙之下
+	_, err = svc.Update(ctx, set.ID, "p1", &serviceInterfaces.UpdateItemRequest{ParentID: &gem.ID})
+	assert.Error(t, err)
+
+	// 其他项目无权访问
+	_, err = svc.GetByID(ctx, set.ID, "p2")
+	assert.Error(t, err)
+}
+
+func TestItemService_DeleteReparentsChildren(t *testing.T) {
+	ctx := context.Background()
+	svc := NewItemService(newMemoryItemRepo(), nil)
+
+	bag, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "乾坤袋"})
+	box, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "玉匣", ParentID: bag.ID})
+	pill, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateItemRequest{Name: "筑基丹", ParentID: box.ID})
+
+	require.NoError(t, svc.Delete(ctx, box.ID, "p1"))
+
+	moved, err := svc.GetByID(ctx, pill.ID, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, bag.ID, moved.ParentID)
+}
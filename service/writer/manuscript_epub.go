@@ -0,0 +1,441 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// epubManifestItem OPF 清单条目
+type epubManifestItem struct {
+	Href       string
+	MediaType  string
+	Properties string
+}
+
+// parseEPUBManuscript 按 OPF spine 顺序读取 EPUB 正文，返回稿件块和书名
+func parseEPUBManuscript(data []byte) ([]manuscriptBlock, string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", fmt.Errorf("无效的 EPUB 文件: %w", err)
+	}
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	container, err := readEPUBPart(files, "META-INF/container.xml")
+	if err != nil {
+		return nil, "", err
+	}
+	var containerDoc struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(container, &containerDoc); err != nil || len(containerDoc.Rootfiles) == 0 {
+		return nil, "", fmt.Errorf("EPUB 缺少有效的 container.xml")
+	}
+
+	opfPath := containerDoc.Rootfiles[0].FullPath
+	opf, err := readEPUBPart(files, opfPath)
+	if err != nil {
+		return nil, "", err
+	}
+	var pkg struct {
+		Titles   []string `xml:"metadata>title"`
+		Manifest []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := xml.Unmarshal(opf, &pkg); err != nil {
+		return nil, "", fmt.Errorf("解析 EPUB 包文件失败: %w", err)
+	}
+
+	manifest := make(map[string]epubManifestItem, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		manifest[item.ID] = epubManifestItem{Href: item.Href, MediaType: item.MediaType, Properties: item.Properties}
+	}
+
+	opfDir := path.Dir(opfPath)
+	var blocks []manuscriptBlock
+	for _, ref := range pkg.Spine {
+		item, ok := manifest[ref.IDRef]
+		if !ok || ref.Linear == "no" || item.MediaType != "application/xhtml+xml" {
+			continue
+		}
+		// 目录页和封面页不是正文
+		if hasEPUBProperty(item.Properties, "nav") || hasEPUBProperty(item.Properties, "cover-image") {
+			continue
+		}
+		href, err := url.PathUnescape(item.Href)
+		if err != nil {
+			href = item.Href
+		}
+		content, err := readEPUBPart(files, path.Join(opfDir, href))
+		if err != nil {
+			return nil, "", err
+		}
+		blocks = append(blocks, xhtmlToManuscriptBlocks(content)...)
+	}
+
+	title := ""
+	if len(pkg.Titles) > 0 {
+		title = strings.TrimSpace(pkg.Titles[0])
+	}
+	return blocks, title, nil
+}
+
+func readEPUBPart(files map[string]*zip.File, name string) ([]byte, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("EPUB 缺少 %s", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func hasEPUBProperty(properties, name string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// xhtmlReader 将 XHTML 正文转换为稿件块：段落、标题、引用、列表、代码块和分隔线，保留行内样式
+type xhtmlReader struct {
+	blocks  []manuscriptBlock
+	inline  []tiptapRichNode
+	marks   []tiptapMark
+	markTag []string // 与 marks 对应的标签名
+
+	heading    int // 当前所在 hN 的级别
+	quote      int // blockquote 嵌套深度
+	pre        int // pre 嵌套深度
+	volume     int // epub:type=part 的分节深度
+	listSeq    int // 列表序号，用于合并同一列表的条目
+	lists      []xhtmlList
+	listBlocks map[int]int // 已输出的列表块下标 -> 列表序号
+	skip       int         // head/script/style/nav 等不输出内容的元素深度
+	sectionTag []bool
+}
+
+type xhtmlList struct {
+	seq     int
+	ordered bool
+}
+
+// xhtmlToManuscriptBlocks 解析单个 XHTML 文件
+func xhtmlToManuscriptBlocks(content []byte) []manuscriptBlock {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	r := &xhtmlReader{listBlocks: make(map[int]int)}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			r.start(t)
+		case xml.EndElement:
+			r.end(strings.ToLower(t.Name.Local))
+		case xml.CharData:
+			r.text(string(t))
+		}
+	}
+	r.flush()
+	return r.blocks
+}
+
+var xhtmlMarkTags = map[string]string{
+	"b": "bold", "strong": "bold",
+	"i": "italic", "em": "italic", "cite": "italic",
+	"u": "underline", "ins": "underline",
+	"s": "strike", "strike": "strike", "del": "strike",
+	"mark": "highlight",
+	"sup":  "superscript",
+	"sub":  "subscript",
+	"code": "code",
+}
+
+func (r *xhtmlReader) start(el xml.StartElement) {
+	name := strings.ToLower(el.Name.Local)
+	switch name {
+	case "head", "script", "style", "nav", "title":
+		r.skip++
+		return
+	}
+	if r.skip > 0 {
+		return
+	}
+
+	switch name {
+	case "p", "div", "li", "dt", "dd", "tr":
+		r.flush()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.flush()
+		r.heading = int(name[1] - '0')
+	case "blockquote":
+		r.flush()
+		r.quote++
+	case "pre":
+		r.flush()
+		r.pre++
+	case "ul", "ol":
+		r.flush()
+		r.listSeq++
+		r.lists = append(r.lists, xhtmlList{seq: r.listSeq, ordered: name == "ol"})
+	case "section", "article", "body":
+		r.flush()
+		part := false
+		for _, attr := range el.Attr {
+			if attr.Name.Local == "type" && (hasEPUBProperty(attr.Value, "part") || hasEPUBProperty(attr.Value, "volume")) {
+				part = true
+			}
+		}
+		if part {
+			r.volume++
+		}
+		r.sectionTag = append(r.sectionTag, part)
+	case "hr":
+		r.flush()
+		r.blocks = append(r.blocks, manuscriptBlock{node: tiptapRichNode{Type: "horizontalRule"}})
+	case "br":
+		if r.pre > 0 {
+			r.inline = appendTipTapText(r.inline, "\n", nil)
+		} else {
+			r.inline = append(r.inline, tiptapRichNode{Type: "hardBreak"})
+		}
+	case "a":
+		href := ""
+		for _, attr := range el.Attr {
+			if attr.Name.Local == "href" {
+				href = attr.Value
+			}
+		}
+		// 书内相对链接导入后失效，只保留外部链接
+		if isSafeEPUBLink(href) {
+			r.pushMark(name, tiptapMark{Type: "link", Attrs: map[string]interface{}{"href": href}})
+		}
+	default:
+		if markType, ok := xhtmlMarkTags[name]; ok && !(name == "code" && r.pre > 0) {
+			r.pushMark(name, tiptapMark{Type: markType})
+		}
+	}
+}
+
+func (r *xhtmlReader) end(name string) {
+	switch name {
+	case "head", "script", "style", "nav", "title":
+		if r.skip > 0 {
+			r.skip--
+		}
+		return
+	}
+	if r.skip > 0 {
+		return
+	}
+
+	switch name {
+	case "p", "div", "li", "dt", "dd", "tr":
+		r.flush()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.flush()
+		r.heading = 0
+	case "blockquote":
+		r.flush()
+		if r.quote > 0 {
+			r.quote--
+		}
+	case "pre":
+		r.flush()
+		if r.pre > 0 {
+			r.pre--
+		}
+	case "ul", "ol":
+		r.flush()
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+	case "section", "article", "body":
+		r.flush()
+		if n := len(r.sectionTag); n > 0 {
+			if r.sectionTag[n-1] && r.volume > 0 {
+				r.volume--
+			}
+			r.sectionTag = r.sectionTag[:n-1]
+		}
+	default:
+		r.popMark(name)
+	}
+}
+
+func (r *xhtmlReader) pushMark(tag string, mark tiptapMark) {
+	r.marks = append(r.marks, mark)
+	r.markTag = append(r.markTag, tag)
+}
+
+func (r *xhtmlReader) popMark(tag string) {
+	for i := len(r.markTag) - 1; i >= 0; i-- {
+		if r.markTag[i] == tag {
+			r.marks = append(r.marks[:i:i], r.marks[i+1:]...)
+			r.markTag = append(r.markTag[:i:i], r.markTag[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *xhtmlReader) text(text string) {
+	if r.skip > 0 {
+		return
+	}
+	if r.pre == 0 {
+		text = collapseXHTMLSpace(text)
+		// 块开头的空白没有意义
+		if len(r.inline) == 0 || r.inline[len(r.inline)-1].Type == "hardBreak" {
+			text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		}
+		if text == "" {
+			return
+		}
+	}
+	r.inline = appendTipTapText(r.inline, text, r.marks)
+}
+
+// flush 将当前行内内容输出为一个块
+func (r *xhtmlReader) flush() {
+	inline := r.inline
+	r.inline = nil
+	if r.pre == 0 {
+		inline = trimTrailingXHTMLSpace(inline)
+	}
+	if len(inline) == 0 {
+		return
+	}
+
+	block := manuscriptBlock{text: tiptapRichText(tiptapRichNode{Type: "paragraph", Content: inline})}
+	switch {
+	case r.pre > 0:
+		block.node = tiptapRichNode{Type: "codeBlock", Content: []tiptapRichNode{{Type: "text", Text: block.text}}}
+	case r.heading > 0:
+		block.heading = r.heading
+		block.volume = r.volume > 0 && r.heading == 1
+		// 导出时正文标题整体下移一级，导入时还原
+		level := r.heading
+		if level > 1 {
+			level--
+		}
+		block.node = tiptapRichNode{Type: "heading", Attrs: map[string]interface{}{"level": level}, Content: inline}
+	default:
+		block.node = tiptapRichNode{Type: "paragraph", Content: inline}
+	}
+
+	switch {
+	case len(r.lists) > 0 && block.node.Type == "paragraph":
+		r.appendListItem(block)
+	case r.quote > 0 && block.node.Type != "heading":
+		r.appendQuoted(block)
+	default:
+		r.blocks = append(r.blocks, block)
+	}
+}
+
+// appendListItem 将条目追加到同一列表，列表结构按最内层列表扁平化
+func (r *xhtmlReader) appendListItem(block manuscriptBlock) {
+	list := r.lists[len(r.lists)-1]
+	listType := "bulletList"
+	if list.ordered {
+		listType = "orderedList"
+	}
+	item := tiptapRichNode{Type: "listItem", Content: []tiptapRichNode{block.node}}
+	if n := len(r.blocks); n > 0 && r.blocks[n-1].node.Type == listType && r.listBlocks[n-1] == list.seq {
+		r.blocks[n-1].node.Content = append(r.blocks[n-1].node.Content, item)
+		r.blocks[n-1].text += "\n" + block.text
+		return
+	}
+	r.listBlocks[len(r.blocks)] = list.seq
+	r.blocks = append(r.blocks, manuscriptBlock{
+		node: tiptapRichNode{Type: listType, Content: []tiptapRichNode{item}},
+		text: block.text,
+	})
+}
+
+// appendQuoted 将段落追加到相邻的引用块
+func (r *xhtmlReader) appendQuoted(block manuscriptBlock) {
+	if n := len(r.blocks); n > 0 && r.blocks[n-1].node.Type == "blockquote" {
+		r.blocks[n-1].node.Content = append(r.blocks[n-1].node.Content, block.node)
+		r.blocks[n-1].text += "\n" + block.text
+		return
+	}
+	r.blocks = append(r.blocks, manuscriptBlock{
+		node: tiptapRichNode{Type: "blockquote", Content: []tiptapRichNode{block.node}},
+		text: block.text,
+	})
+}
+
+// collapseXHTMLSpace 按 HTML 规则合并空白
+func collapseXHTMLSpace(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		// 全角空格是中文排版的缩进，不参与合并
+		if r != '　' && unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func trimTrailingXHTMLSpace(inline []tiptapRichNode) []tiptapRichNode {
+	for len(inline) > 0 {
+		last := &inline[len(inline)-1]
+		if last.Type == "hardBreak" {
+			inline = inline[:len(inline)-1]
+			continue
+		}
+		if last.Type != "text" {
+			break
+		}
+		last.Text = strings.TrimRightFunc(last.Text, unicode.IsSpace)
+		if last.Text != "" {
+			break
+		}
+		inline = inline[:len(inline)-1]
+	}
+	if len(inline) > 0 && inline[0].Type == "text" {
+		inline[0].Text = strings.TrimLeftFunc(inline[0].Text, unicode.IsSpace)
+		if inline[0].Text == "" {
+			inline = inline[1:]
+		}
+	}
+	return inline
+}
@@ -0,0 +1,359 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	"Qingyu_backend/pkg/utils"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ManuscriptRepository 稿件导入写入接口
+type ManuscriptRepository interface {
+	CreateProject(ctx context.Context, project *writer.Project) error
+	DeleteProject(ctx context.Context, id string) error
+	CreateDocument(ctx context.Context, doc *writer.Document) error
+	DeleteDocument(ctx context.Context, id string) error
+	CreateDocumentContent(ctx context.Context, content *writer.DocumentContent) error
+	DeleteDocumentContent(ctx context.Context, id string) error
+	UpdateProjectStatistics(ctx context.Context, projectID string, stats writer.ProjectStats) error
+}
+
+// SetManuscriptRepository 设置稿件导入写入仓储
+func (s *ExportService) SetManuscriptRepository(repo ManuscriptRepository) {
+	s.manuscriptRepo = repo
+}
+
+// PreviewManuscriptImport 解析稿件并返回拆分结果，不写入任何数据
+func (s *ExportService) PreviewManuscriptImport(
+	ctx context.Context,
+	userID string,
+	data []byte,
+	req *serviceInterfaces.ManuscriptImportRequest,
+) (*serviceInterfaces.ManuscriptPreview, error) {
+	m, _, err := s.prepareManuscriptImport(ctx, userID, data, req)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeCount, chapterCount, totalWords := m.counts()
+	preview := &serviceInterfaces.ManuscriptPreview{
+		Title:        m.Title,
+		Format:       m.Format,
+		VolumeCount:  volumeCount,
+		ChapterCount: chapterCount,
+		TotalWords:   totalWords,
+		Outline:      make([]*serviceInterfaces.ManuscriptOutlineNode, 0, len(m.Volumes)),
+		Warnings:     m.Warnings,
+	}
+	for _, volume := range m.Volumes {
+		chapters := make([]*serviceInterfaces.ManuscriptOutlineNode, 0, len(volume.Chapters))
+		for _, chapter := range volume.Chapters {
+			chapters = append(chapters, &serviceInterfaces.ManuscriptOutlineNode{
+				Type:      writer.TypeChapter,
+				Title:     chapter.Title,
+				WordCount: chapter.WordCount,
+				Excerpt:   manuscriptExcerpt(chapter.Text),
+			})
+		}
+		if volume.Title == "" {
+			preview.Outline = append(preview.Outline, chapters...)
+			continue
+		}
+		preview.Outline = append(preview.Outline, &serviceInterfaces.ManuscriptOutlineNode{
+			Type:      writer.TypeVolume,
+			Title:     volume.Title,
+			WordCount: volume.WordCount(),
+			Children:  chapters,
+		})
+	}
+	return preview, nil
+}
+
+// ImportManuscript 创建稿件导入任务，按卷章异步写入文档；进度和取消与导出任务一致
+func (s *ExportService) ImportManuscript(
+	ctx context.Context,
+	userID string,
+	data []byte,
+	req *serviceInterfaces.ManuscriptImportRequest,
+) (*serviceInterfaces.ExportTask, error) {
+	if s.manuscriptRepo == nil {
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "稿件导入未配置", "", nil)
+	}
+
+	m, project, err := s.prepareManuscriptImport(ctx, userID, data, req)
+	if err != nil {
+		return nil, err
+	}
+
+	newProject := project == nil
+	if newProject {
+		if project, err = newImportedProject(m.Title, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	task := &serviceInterfaces.ExportTask{
+		ID:            primitive.NewObjectID().Hex(),
+		Type:          serviceInterfaces.ExportTypeImport,
+		ResourceID:    project.ID.Hex(),
+		ResourceTitle: project.Title,
+		Format:        m.Format,
+		Status:        serviceInterfaces.ExportStatusPending,
+		Progress:      0,
+		CreatedBy:     userID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(24 * time.Hour),
+	}
+
+	if err := s.exportTaskRepo.Create(ctx, task); err != nil {
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorInternal, "创建导入任务失败", "", err)
+	}
+
+	go s.processManuscriptImport(context.Background(), task, project, newProject, m)
+	return task, nil
+}
+
+// prepareManuscriptImport 校验请求和目标项目并解析稿件；导入到新项目时返回的 project 为 nil
+func (s *ExportService) prepareManuscriptImport(
+	ctx context.Context,
+	userID string,
+	data []byte,
+	req *serviceInterfaces.ManuscriptImportRequest,
+) (*manuscript, *writer.Project, error) {
+	if req == nil || req.FileName == "" {
+		return nil, nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "文件名不能为空", "", nil)
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "稿件内容为空", "", nil)
+	}
+
+	var project *writer.Project
+	if req.ProjectID != "" {
+		var err error
+		project, err = s.projectRepo.FindByID(ctx, req.ProjectID)
+		if err != nil || project == nil {
+			return nil, nil, errors.NewServiceError("ExportService", errors.ServiceErrorNotFound, "项目不存在", "", err)
+		}
+		if !project.CanEdit(userID) {
+			return nil, nil, errors.NewServiceError("ExportService", errors.ServiceErrorForbidden, "无权导入到此项目", "", nil)
+		}
+	}
+
+	m, err := parseManuscript(req.FileName, data, req)
+	if err != nil {
+		return nil, nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "稿件解析失败", err.Error(), err)
+	}
+	return m, project, nil
+}
+
+// newImportedProject 构建导入用的新项目，默认值与创建项目时一致
+func newImportedProject(title, userID string) (*writer.Project, error) {
+	authorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewServiceError("ExportService", errors.ServiceErrorValidation, "无效的用户ID", "", err)
+	}
+
+	project := &writer.Project{
+		WritingType: "novel",
+		Status:      writer.StatusDraft,
+		Visibility:  writer.VisibilityPrivate,
+		Summary:     fmt.Sprintf("从文件导入于 %s", time.Now().Format("2006-01-02 15:04:05")),
+		Settings: writer.ProjectSettings{
+			AutoBackup:     true,
+			BackupInterval: 24,
+		},
+	}
+	project.ID = primitive.NewObjectID()
+	project.Title = title
+	project.AuthorID = authorID
+	project.Statistics.LastUpdateAt = time.Now()
+	return project, nil
+}
+
+// manuscriptImportJob 记录已写入的数据，失败或取消时回滚
+type manuscriptImportJob struct {
+	repo           ManuscriptRepository
+	projectCreated string
+	documents      []string
+	contents       []string
+}
+
+func (j *manuscriptImportJob) rollback(ctx context.Context) {
+	for i := len(j.contents) - 1; i >= 0; i-- {
+		_ = j.repo.DeleteDocumentContent(ctx, j.contents[i])
+	}
+	for i := len(j.documents) - 1; i >= 0; i-- {
+		_ = j.repo.DeleteDocument(ctx, j.documents[i])
+	}
+	if j.projectCreated != "" {
+		_ = j.repo.DeleteProject(ctx, j.projectCreated)
+	}
+}
+
+func (s *ExportService) processManuscriptImport(
+	ctx context.Context,
+	task *serviceInterfaces.ExportTask,
+	project *writer.Project,
+	newProject bool,
+	m *manuscript,
+) {
+	s.updateTaskProgress(ctx, task, serviceInterfaces.ExportStatusProcessing, 5)
+
+	job := &manuscriptImportJob{repo: s.manuscriptRepo}
+	err := s.writeManuscript(ctx, job, task, project, newProject, m)
+	if isExportCancelled(err) {
+		job.rollback(ctx)
+		return
+	}
+	if err != nil {
+		job.rollback(ctx)
+		s.failTask(ctx, task, err.Error())
+		return
+	}
+
+	task.Status = serviceInterfaces.ExportStatusCompleted
+	task.Progress = 100
+	completedAt := time.Now()
+	task.CompletedAt = &completedAt
+	task.UpdatedAt = completedAt
+	_ = s.exportTaskRepo.Update(ctx, task)
+}
+
+// writeManuscript 依次写入项目、卷、章节文档和内容，最后更新项目统计
+func (s *ExportService) writeManuscript(
+	ctx context.Context,
+	job *manuscriptImportJob,
+	task *serviceInterfaces.ExportTask,
+	project *writer.Project,
+	newProject bool,
+	m *manuscript,
+) error {
+	rootKey, rootOrder := "", 0
+	if newProject {
+		if err := s.manuscriptRepo.CreateProject(ctx, project); err != nil {
+			return fmt.Errorf("创建项目失败: %w", err)
+		}
+		job.projectCreated = project.ID.Hex()
+	} else {
+		// 追加到已有项目时排在现有根文档之后
+		documents, err := s.documentRepo.FindByProjectID(ctx, project.ID.Hex())
+		if err != nil {
+			return fmt.Errorf("获取项目文档失败: %w", err)
+		}
+		for _, doc := range documents {
+			if doc.IsRoot() {
+				rootOrder++
+				if doc.OrderKey > rootKey {
+					rootKey = doc.OrderKey
+				}
+			}
+		}
+	}
+
+	_, total, words := m.counts()
+	done, created := 0, 0
+	for _, volume := range m.Volumes {
+		parentID, level := primitive.NilObjectID, 0
+		chapterKey, chapterOrder := &rootKey, &rootOrder
+		if volume.Title != "" {
+			rootKey = utils.GenerateSiblingOrderKey(rootKey)
+			doc := newImportedDocument(project.ID, volume.Title, writer.TypeVolume, rootKey, rootOrder, volume.WordCount())
+			rootOrder++
+			if err := s.manuscriptRepo.CreateDocument(ctx, doc); err != nil {
+				return fmt.Errorf("创建卷《%s》失败: %w", volume.Title, err)
+			}
+			job.documents = append(job.documents, doc.ID.Hex())
+			created++
+
+			volumeKey, volumeOrder := "", 0
+			parentID, level = doc.ID, 1
+			chapterKey, chapterOrder = &volumeKey, &volumeOrder
+		}
+
+		for _, chapter := range volume.Chapters {
+			if s.isTaskCancelled(ctx, task.ID) {
+				return errExportCancelled
+			}
+
+			*chapterKey = utils.GenerateSiblingOrderKey(*chapterKey)
+			doc := newImportedDocument(project.ID, chapter.Title, writer.TypeChapter, *chapterKey, *chapterOrder, chapter.WordCount)
+			doc.ParentID = parentID
+			doc.Level = level
+			*chapterOrder++
+			if err := s.manuscriptRepo.CreateDocument(ctx, doc); err != nil {
+				return fmt.Errorf("创建章节《%s》失败: %w", chapter.Title, err)
+			}
+			job.documents = append(job.documents, doc.ID.Hex())
+			created++
+
+			// 内容ID与文档ID一致，导出时可按文档ID直接读取
+			content := &writer.DocumentContent{
+				ID:           doc.ID,
+				DocumentID:   doc.ID,
+				Content:      chapter.Content,
+				ContentType:  "tiptap_json",
+				WordCount:    chapter.WordCount,
+				CharCount:    chapter.CharCount,
+				Version:      1,
+				LastEditedBy: task.CreatedBy,
+			}
+			content.TouchForCreate()
+			if err := s.manuscriptRepo.CreateDocumentContent(ctx, content); err != nil {
+				return fmt.Errorf("保存章节《%s》内容失败: %w", chapter.Title, err)
+			}
+			job.contents = append(job.contents, content.ID.Hex())
+
+			done++
+			s.updateTaskProgress(ctx, task, serviceInterfaces.ExportStatusProcessing, 5+90*done/total)
+		}
+	}
+
+	if s.isTaskCancelled(ctx, task.ID) {
+		return errExportCancelled
+	}
+
+	stats := project.Statistics
+	stats.TotalWords += words
+	stats.ChapterCount += total
+	stats.DocumentCount += created
+	stats.LastUpdateAt = time.Now()
+	if err := s.manuscriptRepo.UpdateProjectStatistics(ctx, project.ID.Hex(), stats); err != nil {
+		return fmt.Errorf("更新项目统计失败: %w", err)
+	}
+	return nil
+}
+
+// newImportedDocument 构建导入的卷/章文档；有正文的视为已完成
+func newImportedDocument(projectID primitive.ObjectID, title, docType, orderKey string, order, wordCount int) *writer.Document {
+	doc := &writer.Document{
+		ProjectID: projectID,
+		Title:     title,
+		StableRef: primitive.NewObjectID().Hex(),
+		OrderKey:  orderKey,
+		Type:      docType,
+		Order:     order,
+		Status:    writer.DocumentStatusCompleted,
+		WordCount: wordCount,
+	}
+	if wordCount == 0 {
+		doc.Status = writer.DocumentStatusPlanned
+	}
+	doc.ID = primitive.NewObjectID()
+	return doc
+}
+
+// manuscriptExcerpt 截取正文开头用于预览
+func manuscriptExcerpt(text string) string {
+	text = normalizeHeadingText(text)
+	if utf8.RuneCountInString(text) <= manuscriptExcerptLength {
+		return text
+	}
+	return truncateRunes(text, manuscriptExcerptLength) + "…"
+}
@@ -0,0 +1,406 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"Qingyu_backend/models/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// fakeManuscriptRepo 内存中的稿件写入仓储
+type fakeManuscriptRepo struct {
+	projects  []*writer.Project
+	documents []*writer.Document
+	contents  map[string]*writer.DocumentContent
+	deleted   []string
+	stats     *writer.ProjectStats
+	failOn    string // 创建该标题的文档时返回错误
+}
+
+func newFakeManuscriptRepo() *fakeManuscriptRepo {
+	return &fakeManuscriptRepo{contents: make(map[string]*writer.DocumentContent)}
+}
+
+func (r *fakeManuscriptRepo) CreateProject(ctx context.Context, project *writer.Project) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
+	r.projects = append(r.projects, project)
+	return nil
+}
+
+func (r *fakeManuscriptRepo) DeleteProject(ctx context.Context, id string) error {
+	r.deleted = append(r.deleted, "project:"+id)
+	return nil
+}
+
+func (r *fakeManuscriptRepo) CreateDocument(ctx context.Context, doc *writer.Document) error {
+	if doc.Title == r.failOn {
+		return fmt.Errorf("写入失败")
+	}
+	r.documents = append(r.documents, doc)
+	return nil
+}
+
+func (r *fakeManuscriptRepo) DeleteDocument(ctx context.Context, id string) error {
+	r.deleted = append(r.deleted, "document:"+id)
+	return nil
+}
+
+func (r *fakeManuscriptRepo) CreateDocumentContent(ctx context.Context, content *writer.DocumentContent) error {
+	if err := content.Validate(); err != nil {
+		return err
+	}
+	r.contents[content.DocumentID.Hex()] = content
+	return nil
+}
+
+func (r *fakeManuscriptRepo) DeleteDocumentContent(ctx context.Context, id string) error {
+	r.deleted = append(r.deleted, "content:"+id)
+	return nil
+}
+
+func (r *fakeManuscriptRepo) UpdateProjectStatistics(ctx context.Context, projectID string, stats writer.ProjectStats) error {
+	r.stats = &stats
+	return nil
+}
+
+// sampleManuscriptTXT 带书名、开头目录、卷、章和番外的 TXT 稿件
+var sampleManuscriptTXT = strings.Join([]string{
+	"《山河远方》",
+	"作者：墨客",
+	"",
+	"第一卷 风起",
+	"第一章 出门",
+	"第二章 遇见",
+	"",
+	"第一卷　风起",
+	"　　这一卷讲的是少年离家。",
+	"第一章  出门",
+	"　　清晨，他推开了门。",
+	"　　第三章里才会揭晓答案。",
+	"***",
+	"　　风很大。",
+	"第二章 遇见",
+	"　　她站在桥上。",
+	"第二卷 云涌",
+	"第3章 离别",
+	"　　Goodbye, old friend.",
+	"番外 桥上的人",
+	"　　很多年以后。",
+}, "\r\n")
+
+func TestParseManuscript_TXTSplitsVolumesAndChapters(t *testing.T) {
+	m, err := parseManuscript("upload.txt", []byte(sampleManuscriptTXT), &serviceInterfaces.ManuscriptImportRequest{FileName: "upload.txt"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "山河远方", m.Title)
+	assert.Equal(t, serviceInterfaces.ExportFormatTXT, m.Format)
+	assert.Contains(t, m.Warnings, "已忽略目录中的 3 个重复卷章标题")
+
+	require.Len(t, m.Volumes, 3)
+	root, first, second := m.Volumes[0], m.Volumes[1], m.Volumes[2]
+
+	assert.Equal(t, "", root.Title)
+	require.Len(t, root.Chapters, 1)
+	assert.Equal(t, manuscriptFrontMatter, root.Chapters[0].Title)
+	assert.Equal(t, "《山河远方》\n\n作者：墨客", root.Chapters[0].Text)
+
+	assert.Equal(t, "第一卷 风起", first.Title)
+	var titles []string
+	for _, chapter := range first.Chapters {
+		titles = append(titles, chapter.Title)
+	}
+	assert.Equal(t, []string{manuscriptVolumePreface, "第一章 出门", "第二章 遇见"}, titles)
+
+	opening := first.Chapters[1]
+	assert.JSONEq(t, `{"type":"doc","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"清晨，他推开了门。"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"第三章里才会揭晓答案。"}]},
+		{"type":"horizontalRule"},
+		{"type":"paragraph","content":[{"type":"text","text":"风很大。"}]}
+	]}`, opening.Content)
+	assert.Equal(t, 20, opening.WordCount)
+
+	assert.Equal(t, "第二卷 云涌", second.Title)
+	require.Len(t, second.Chapters, 2)
+	assert.Equal(t, "第3章 离别", second.Chapters[0].Title)
+	assert.Equal(t, 3, second.Chapters[0].WordCount)
+	assert.Equal(t, "番外 桥上的人", second.Chapters[1].Title)
+
+	volumes, chapters, words := m.counts()
+	assert.Equal(t, 2, volumes)
+	assert.Equal(t, 6, chapters)
+	assert.Equal(t, root.WordCount()+first.WordCount()+second.WordCount(), words)
+}
+
+func TestParseManuscript_DecodesGB18030AndCustomPatterns(t *testing.T) {
+	text := "Part 1 Arrival\n正文一\nPart 2 Departure\n正文二"
+	encoded, err := simplifiedchinese.GB18030.NewEncoder().String(text)
+	require.NoError(t, err)
+
+	req := &serviceInterfaces.ManuscriptImportRequest{FileName: "book.txt", ChapterPatterns: []string{`^Part \d+`}}
+	m, err := parseManuscript(req.FileName, []byte(encoded), req)
+	require.NoError(t, err)
+	assert.Equal(t, "book", m.Title)
+	require.Len(t, m.Volumes, 1)
+	require.Len(t, m.Volumes[0].Chapters, 2)
+	assert.Equal(t, "Part 2 Departure", m.Volumes[0].Chapters[1].Title)
+	assert.Equal(t, "正文二", m.Volumes[0].Chapters[1].Text)
+
+	req.ChapterPatterns = []string{"(unclosed"}
+	_, err = parseManuscript(req.FileName, []byte(text), req)
+	assert.ErrorContains(t, err, "无效的标题规则")
+}
+
+func TestParseManuscript_WithoutHeadingsImportsSingleChapter(t *testing.T) {
+	req := &serviceInterfaces.ManuscriptImportRequest{FileName: "短篇.txt", ProjectTitle: "雨夜"}
+	m, err := parseManuscript(req.FileName, []byte("\xEF\xBB\xBF第一段\n第二段"), req)
+	require.NoError(t, err)
+
+	require.Len(t, m.Volumes, 1)
+	require.Len(t, m.Volumes[0].Chapters, 1)
+	assert.Equal(t, "雨夜", m.Volumes[0].Chapters[0].Title)
+	assert.Equal(t, "第一段\n\n第二段", m.Volumes[0].Chapters[0].Text)
+	assert.Contains(t, m.Warnings, "未识别到卷章标题，全文将作为单个章节导入")
+
+	_, err = parseManuscript("空.txt", []byte(" \n\n"), req)
+	assert.Error(t, err)
+	_, err = parseManuscript("book.pdf", []byte("%PDF"), req)
+	assert.Error(t, err)
+}
+
+func TestParseManuscript_DOCXKeepsFormatting(t *testing.T) {
+	data, err := tiptapToDOCX("山河远方", `{"type":"doc","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"第一章 出门"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"他"},{"type":"text","marks":[{"type":"bold"}],"text":"推开"},{"type":"text","text":"门。"}]},
+		{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"尾声"}]},
+		{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"多年以后"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"风停了。"}]}
+	]}`)
+	require.NoError(t, err)
+
+	m, err := parseManuscript("山河远方.docx", data, &serviceInterfaces.ManuscriptImportRequest{FileName: "山河远方.docx"})
+	require.NoError(t, err)
+	require.Len(t, m.Volumes, 1)
+	chapters := m.Volumes[0].Chapters
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "第一章 出门", chapters[0].Title)
+	assert.JSONEq(t, `{"type":"doc","content":[{"type":"paragraph","content":[
+		{"type":"text","text":"他"},{"type":"text","marks":[{"type":"bold"}],"text":"推开"},{"type":"text","text":"门。"}
+	]}]}`, chapters[0].Content)
+	assert.Equal(t, "尾声", chapters[1].Title)
+	assert.Contains(t, chapters[1].Content, `{"type":"heading","attrs":{"level":2}`)
+}
+
+func TestParseManuscript_EPUBRoundTrip(t *testing.T) {
+	f := newEPUBFixture(t)
+	req := f.request()
+	req.Options.ExcludeChapters = nil
+	data, err := f.service.buildProjectEPUB(context.Background(), f.project, req, nil)
+	require.NoError(t, err)
+
+	m, err := parseManuscript("export.epub", data, &serviceInterfaces.ManuscriptImportRequest{FileName: "export.epub"})
+	require.NoError(t, err)
+	assert.Equal(t, "山河&远方", m.Title)
+	assert.Equal(t, serviceInterfaces.ExportFormatEPUB, m.Format)
+
+	// 导出时没有正文的第二章被省略
+	require.Len(t, m.Volumes, 2)
+	assert.Equal(t, "第一卷", m.Volumes[0].Title)
+	require.Len(t, m.Volumes[0].Chapters, 1)
+	first := m.Volumes[0].Chapters[0]
+	assert.Equal(t, "第一章", first.Title)
+	// 正文标题导出时下移一级，导入后还原；不安全的链接在导出时已去掉
+	assert.JSONEq(t, `{"type":"doc","content":[
+		{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"序"}]},
+		{"type":"paragraph","content":[
+			{"type":"text","text":"他说"},
+			{"type":"text","marks":[{"type":"bold"}],"text":"<走>"},
+			{"type":"text","text":"链接"}
+		]}
+	]}`, first.Content)
+
+	assert.Equal(t, "第二卷", m.Volumes[1].Title)
+	var titles []string
+	for _, chapter := range m.Volumes[1].Chapters {
+		titles = append(titles, chapter.Title)
+	}
+	assert.Equal(t, []string{"第三章", "尾声"}, titles)
+	assert.Equal(t, "第一行\n\n第二行", m.Volumes[1].Chapters[1].Text)
+}
+
+func TestExportService_PreviewManuscriptImport(t *testing.T) {
+	f := newEPUBFixture(t)
+	userID := f.project.AuthorID.Hex()
+	f.projectRepo.On("FindByID", mock.Anything, f.project.ID.Hex()).Return(f.project, nil)
+
+	req := &serviceInterfaces.ManuscriptImportRequest{FileName: "upload.txt", ProjectID: f.project.ID.Hex()}
+	preview, err := f.service.PreviewManuscriptImport(context.Background(), userID, []byte(sampleManuscriptTXT), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, preview.VolumeCount)
+	assert.Equal(t, 6, preview.ChapterCount)
+	require.Len(t, preview.Outline, 3)
+	assert.Equal(t, writer.TypeChapter, preview.Outline[0].Type)
+	assert.Equal(t, writer.TypeVolume, preview.Outline[1].Type)
+	assert.Equal(t, "第一卷 风起", preview.Outline[1].Title)
+	require.Len(t, preview.Outline[1].Children, 3)
+	assert.Equal(t, "清晨，他推开了门。 第三章里才会揭晓答案。 风很大。", preview.Outline[1].Children[1].Excerpt)
+
+	_, err = f.service.PreviewManuscriptImport(context.Background(), primitive.NewObjectID().Hex(), []byte(sampleManuscriptTXT), req)
+	assert.Error(t, err, "非项目作者不能导入")
+	f.taskRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestExportService_ProcessManuscriptImport_CreatesHierarchy(t *testing.T) {
+	f := newEPUBFixture(t)
+	repo := newFakeManuscriptRepo()
+	f.service.SetManuscriptRepository(repo)
+
+	userID := primitive.NewObjectID().Hex()
+	m, err := parseManuscript("upload.txt", []byte(sampleManuscriptTXT), &serviceInterfaces.ManuscriptImportRequest{FileName: "upload.txt"})
+	require.NoError(t, err)
+	project, err := newImportedProject(m.Title, userID)
+	require.NoError(t, err)
+
+	task := &serviceInterfaces.ExportTask{ID: "import-1", CreatedBy: userID}
+	var progress []int
+	f.taskRepo.On("FindByID", mock.Anything, "import-1").Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Run(func(args mock.Arguments) {
+		progress = append(progress, task.Progress)
+	}).Return(nil)
+
+	f.service.processManuscriptImport(context.Background(), task, project, true, m)
+
+	assert.Equal(t, serviceInterfaces.ExportStatusCompleted, task.Status)
+	assert.NotNil(t, task.CompletedAt)
+	assert.IsIncreasing(t, progress)
+	require.Len(t, repo.projects, 1)
+	assert.Equal(t, "山河远方", repo.projects[0].Title)
+	assert.Empty(t, repo.deleted)
+
+	// 作品相关、第一卷（卷首语、两章）、第二卷（两章）
+	require.Len(t, repo.documents, 8)
+	byTitle := make(map[string]*writer.Document)
+	for _, doc := range repo.documents {
+		byTitle[doc.Title] = doc
+		assert.Equal(t, project.ID, doc.ProjectID)
+		assert.NotEmpty(t, doc.StableRef)
+		assert.NotEmpty(t, doc.OrderKey)
+	}
+	front, vol1, vol2 := byTitle[manuscriptFrontMatter], byTitle["第一卷 风起"], byTitle["第二卷 云涌"]
+	assert.True(t, front.IsRoot())
+	assert.Equal(t, writer.TypeVolume, vol1.Type)
+	assert.True(t, front.OrderKey < vol1.OrderKey && vol1.OrderKey < vol2.OrderKey)
+	assert.Equal(t, []int{0, 1, 2}, []int{front.Order, vol1.Order, vol2.Order})
+
+	ch1, ch2 := byTitle["第一章 出门"], byTitle["第二章 遇见"]
+	assert.Equal(t, vol1.ID, ch1.ParentID)
+	assert.Equal(t, 1, ch1.Level)
+	assert.True(t, byTitle[manuscriptVolumePreface].OrderKey < ch1.OrderKey && ch1.OrderKey < ch2.OrderKey)
+	assert.Equal(t, vol2.ID, byTitle["番外 桥上的人"].ParentID)
+	assert.Equal(t, writer.DocumentStatusCompleted, ch1.Status)
+	assert.Equal(t, ch1.WordCount+ch2.WordCount+byTitle[manuscriptVolumePreface].WordCount, vol1.WordCount)
+
+	content := repo.contents[ch1.ID.Hex()]
+	require.NotNil(t, content)
+	assert.Equal(t, ch1.ID, content.ID)
+	assert.Equal(t, "tiptap_json", content.ContentType)
+	assert.Equal(t, 1, content.Version)
+	assert.Equal(t, ch1.WordCount, content.WordCount)
+	assert.Len(t, repo.contents, 6)
+
+	require.NotNil(t, repo.stats)
+	assert.Equal(t, 6, repo.stats.ChapterCount)
+	assert.Equal(t, 8, repo.stats.DocumentCount)
+	_, _, words := m.counts()
+	assert.Equal(t, words, repo.stats.TotalWords)
+}
+
+func TestExportService_ProcessManuscriptImport_AppendsToExistingProject(t *testing.T) {
+	f := newEPUBFixture(t)
+	repo := newFakeManuscriptRepo()
+	f.service.SetManuscriptRepository(repo)
+	f.project.Statistics = writer.ProjectStats{TotalWords: 100, ChapterCount: 4, DocumentCount: 6}
+
+	m, err := parseManuscript("续.txt", []byte("第四章 归来\n正文"), &serviceInterfaces.ManuscriptImportRequest{FileName: "续.txt"})
+	require.NoError(t, err)
+
+	task := &serviceInterfaces.ExportTask{ID: "import-2"}
+	f.taskRepo.On("FindByID", mock.Anything, "import-2").Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Return(nil)
+
+	f.service.processManuscriptImport(context.Background(), task, f.project, false, m)
+
+	assert.Equal(t, serviceInterfaces.ExportStatusCompleted, task.Status)
+	assert.Empty(t, repo.projects)
+	require.Len(t, repo.documents, 1)
+	// 现有三个根文档使用空排序键，新章节排在其后
+	assert.Equal(t, 3, repo.documents[0].Order)
+	assert.Equal(t, 5, repo.stats.ChapterCount)
+	assert.Equal(t, 7, repo.stats.DocumentCount)
+	assert.Equal(t, 102, repo.stats.TotalWords)
+}
+
+func TestExportService_ProcessManuscriptImport_RollsBackWhenCancelled(t *testing.T) {
+	f := newEPUBFixture(t)
+	repo := newFakeManuscriptRepo()
+	f.service.SetManuscriptRepository(repo)
+
+	userID := primitive.NewObjectID().Hex()
+	m, err := parseManuscript("upload.txt", []byte(sampleManuscriptTXT), &serviceInterfaces.ManuscriptImportRequest{FileName: "upload.txt"})
+	require.NoError(t, err)
+	project, err := newImportedProject(m.Title, userID)
+	require.NoError(t, err)
+
+	// 写完第一章后任务被用户取消
+	task := &serviceInterfaces.ExportTask{ID: "import-3", CreatedBy: userID}
+	f.taskRepo.On("FindByID", mock.Anything, "import-3").
+		Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil).Once()
+	f.taskRepo.On("FindByID", mock.Anything, "import-3").
+		Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusCancelled}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Return(nil)
+
+	f.service.processManuscriptImport(context.Background(), task, project, true, m)
+
+	assert.NotEqual(t, serviceInterfaces.ExportStatusCompleted, task.Status)
+	assert.NotEqual(t, serviceInterfaces.ExportStatusFailed, task.Status)
+	assert.Nil(t, repo.stats)
+	require.Len(t, repo.documents, 2)
+	assert.Equal(t, []string{
+		"content:" + repo.documents[0].ID.Hex(),
+		"document:" + repo.documents[1].ID.Hex(),
+		"document:" + repo.documents[0].ID.Hex(),
+		"project:" + project.ID.Hex(),
+	}, repo.deleted)
+}
+
+func TestExportService_ProcessManuscriptImport_FailsAndRollsBack(t *testing.T) {
+	f := newEPUBFixture(t)
+	repo := newFakeManuscriptRepo()
+	repo.failOn = "第二章 遇见"
+	f.service.SetManuscriptRepository(repo)
+
+	m, err := parseManuscript("upload.txt", []byte(sampleManuscriptTXT), &serviceInterfaces.ManuscriptImportRequest{FileName: "upload.txt"})
+	require.NoError(t, err)
+	task := &serviceInterfaces.ExportTask{ID: "import-4"}
+	f.taskRepo.On("FindByID", mock.Anything, "import-4").Return(&serviceInterfaces.ExportTask{Status: serviceInterfaces.ExportStatusProcessing}, nil)
+	f.taskRepo.On("Update", mock.Anything, task).Return(nil)
+
+	f.service.processManuscriptImport(context.Background(), task, f.project, false, m)
+
+	assert.Equal(t, serviceInterfaces.ExportStatusFailed, task.Status)
+	assert.Contains(t, task.ErrorMsg, "第二章 遇见")
+	assert.Len(t, repo.deleted, len(repo.documents)+len(repo.contents))
+	assert.NotContains(t, repo.deleted, "project:"+f.project.ID.Hex())
+}
@@ -0,0 +1,451 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"Qingyu_backend/models/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+	"Qingyu_backend/service/writer/document"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// manuscriptNumeral 卷章序号，支持中文小写/大写数字和半角/全角阿拉伯数字
+const manuscriptNumeral = `[零〇一二两三四五六七八九十百千万壹贰叁肆伍陆柒捌玖拾佰仟0-9０-９]+`
+
+// defaultVolumePatterns 默认卷标题规则
+var defaultVolumePatterns = []string{
+	`^(正文\s*)?第` + manuscriptNumeral + `[卷部集]`,
+	`^卷` + manuscriptNumeral + `(\s|$|[:：、.．])`,
+}
+
+// defaultChapterPatterns 默认章标题规则
+var defaultChapterPatterns = []string{
+	`^(正文\s*)?第` + manuscriptNumeral + `[章回节话]`,
+	`^(序章|序言|楔子|引子|前言|尾声|终章|后记|完本感言)(\s|$|[:：·])`,
+	`^番外`,
+	`^(?i)chapter\s*[0-9]+`,
+}
+
+const (
+	defaultMaxHeadingLength = 40
+	manuscriptExcerptLength = 60
+	manuscriptFrontMatter   = "作品相关" // 第一个卷章标题之前的内容
+	manuscriptVolumePreface = "卷首语"  // 卷标题与第一章之间的内容
+)
+
+// bookTitlePattern TXT 开头常见的《书名》行
+var bookTitlePattern = regexp.MustCompile(`^《([^》]+)》`)
+
+// manuscriptBlock 稿件解析后的块级内容
+type manuscriptBlock struct {
+	node    tiptapRichNode
+	text    string // 纯文本，用于标题识别
+	heading int    // 来源中的标题级别（DOCX 标题样式、EPUB hN），0 表示普通段落
+	volume  bool   // 来源明确标注为卷（EPUB 的 part 分节）
+}
+
+// manuscriptChapter 拆分后的章节
+type manuscriptChapter struct {
+	Title  string
+	Blocks []tiptapRichNode
+
+	// 以下字段由 measure 计算
+	Content   string // TipTap JSON
+	Text      string
+	WordCount int
+	CharCount int
+}
+
+// manuscriptVolume 拆分后的卷；Title 为空表示章节直接挂在项目根下
+type manuscriptVolume struct {
+	Title    string
+	Chapters []*manuscriptChapter
+}
+
+// WordCount 卷内章节字数合计
+func (v *manuscriptVolume) WordCount() int {
+	total := 0
+	for _, chapter := range v.Chapters {
+		total += chapter.WordCount
+	}
+	return total
+}
+
+// manuscript 拆分完成的稿件
+type manuscript struct {
+	Title    string
+	Format   string
+	Volumes  []*manuscriptVolume
+	Warnings []string
+}
+
+// manuscriptSplitter 按卷章标题规则拆分稿件
+type manuscriptSplitter struct {
+	volumePatterns   []*regexp.Regexp
+	chapterPatterns  []*regexp.Regexp
+	maxHeadingLength int
+}
+
+// newManuscriptSplitter 编译请求中的标题规则，未配置时使用默认规则
+func newManuscriptSplitter(req *serviceInterfaces.ManuscriptImportRequest) (*manuscriptSplitter, error) {
+	volumePatterns, chapterPatterns := defaultVolumePatterns, defaultChapterPatterns
+	maxHeadingLength := defaultMaxHeadingLength
+	if req != nil {
+		if len(req.VolumePatterns) > 0 {
+			volumePatterns = req.VolumePatterns
+		}
+		if len(req.ChapterPatterns) > 0 {
+			chapterPatterns = req.ChapterPatterns
+		}
+		if req.MaxHeadingLength > 0 {
+			maxHeadingLength = req.MaxHeadingLength
+		}
+	}
+
+	sp := &manuscriptSplitter{maxHeadingLength: maxHeadingLength}
+	var err error
+	if sp.volumePatterns, err = compileHeadingPatterns(volumePatterns); err != nil {
+		return nil, err
+	}
+	if sp.chapterPatterns, err = compileHeadingPatterns(chapterPatterns); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+func compileHeadingPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的标题规则 %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// classify 判断块是否为卷/章标题，返回类型（volume、chapter 或空）和规范化后的标题
+func (sp *manuscriptSplitter) classify(block manuscriptBlock) (string, string) {
+	if block.node.Type != "paragraph" && block.node.Type != "heading" {
+		return "", ""
+	}
+	title := normalizeHeadingText(block.text)
+	if title == "" || utf8.RuneCountInString(title) > sp.maxHeadingLength {
+		return "", ""
+	}
+	// 以句读结尾的通常是正文，例如“第三章里写到……。”
+	if block.heading == 0 && strings.ContainsAny(lastRune(title), "。，；,;") {
+		return "", ""
+	}
+
+	for _, re := range sp.volumePatterns {
+		if re.MatchString(title) {
+			return writer.TypeVolume, title
+		}
+	}
+	for _, re := range sp.chapterPatterns {
+		if re.MatchString(title) {
+			return writer.TypeChapter, title
+		}
+	}
+	switch {
+	case block.volume:
+		return writer.TypeVolume, title
+	case block.heading == 1:
+		return writer.TypeChapter, title
+	}
+	return "", ""
+}
+
+// split 按标题将块序列拆分为卷和章节
+func (sp *manuscriptSplitter) split(blocks []manuscriptBlock) ([]*manuscriptVolume, int) {
+	root := &manuscriptVolume{}
+	volumes := []*manuscriptVolume{root}
+	current := root
+	var chapter *manuscriptChapter
+	headings := 0
+
+	for _, block := range blocks {
+		kind, title := sp.classify(block)
+		switch kind {
+		case writer.TypeVolume:
+			current = &manuscriptVolume{Title: title}
+			volumes = append(volumes, current)
+			chapter = nil
+			headings++
+			continue
+		case writer.TypeChapter:
+			chapter = &manuscriptChapter{Title: title}
+			current.Chapters = append(current.Chapters, chapter)
+			headings++
+			continue
+		}
+
+		if chapter == nil {
+			// 标题前只有分隔线等装饰内容时不单独成章
+			if strings.TrimSpace(block.text) == "" {
+				continue
+			}
+			title := manuscriptFrontMatter
+			if current.Title != "" {
+				title = manuscriptVolumePreface
+			}
+			chapter = &manuscriptChapter{Title: title}
+			current.Chapters = append(current.Chapters, chapter)
+		}
+		chapter.Blocks = append(chapter.Blocks, block.node)
+	}
+	return volumes, headings
+}
+
+// dropTableOfContents 去掉开头目录中的卷章标题：没有正文且在后文重复出现的标题视为目录项
+func dropTableOfContents(volumes []*manuscriptVolume) ([]*manuscriptVolume, int) {
+	laterChapters := make(map[string]bool)
+	laterVolumes := make(map[string]bool)
+	dropped := 0
+	result := make([]*manuscriptVolume, 0, len(volumes))
+
+	// 倒序遍历，判断每个标题之后是否还出现过
+	for i := len(volumes) - 1; i >= 0; i-- {
+		volume := volumes[i]
+		kept := make([]*manuscriptChapter, 0, len(volume.Chapters))
+		for j := len(volume.Chapters) - 1; j >= 0; j-- {
+			chapter := volume.Chapters[j]
+			if len(chapter.Blocks) == 0 && laterChapters[chapter.Title] {
+				dropped++
+				continue
+			}
+			laterChapters[chapter.Title] = true
+			kept = append(kept, chapter)
+		}
+		slices.Reverse(kept)
+		volume.Chapters = kept
+
+		if volume.Title != "" {
+			if len(volume.Chapters) == 0 && laterVolumes[volume.Title] {
+				dropped++
+				continue
+			}
+			laterVolumes[volume.Title] = true
+		}
+		result = append(result, volume)
+	}
+	slices.Reverse(result)
+	return result, dropped
+}
+
+// parseManuscript 解析稿件文件并拆分卷章
+func parseManuscript(fileName string, data []byte, req *serviceInterfaces.ManuscriptImportRequest) (*manuscript, error) {
+	splitter, err := newManuscriptSplitter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		blocks    []manuscriptBlock
+		bookTitle string
+		format    string
+	)
+	switch strings.ToLower(filepathExt(fileName)) {
+	case ".txt":
+		format = serviceInterfaces.ExportFormatTXT
+		blocks, bookTitle = parseTXTManuscript(data)
+	case ".docx":
+		format = serviceInterfaces.ExportFormatDOCX
+		nodes, err := docxToTipTapNodes(data)
+		if err != nil {
+			return nil, err
+		}
+		blocks = tiptapManuscriptBlocks(nodes)
+	case ".epub":
+		format = serviceInterfaces.ExportFormatEPUB
+		if blocks, bookTitle, err = parseEPUBManuscript(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的稿件格式，仅支持 TXT、DOCX、EPUB")
+	}
+
+	title := ""
+	if req != nil {
+		title = strings.TrimSpace(req.ProjectTitle)
+	}
+	if title == "" {
+		title = bookTitle
+	}
+	if title == "" {
+		base := fileName[strings.LastIndexAny(fileName, `/\`)+1:]
+		title = strings.TrimSpace(strings.TrimSuffix(base, filepathExt(base)))
+	}
+	if title == "" {
+		title = "导入的作品"
+	}
+
+	m := &manuscript{Title: truncateRunes(title, 100), Format: format}
+	volumes, headings := splitter.split(blocks)
+	volumes, tocEntries := dropTableOfContents(volumes)
+	if tocEntries > 0 {
+		m.Warnings = append(m.Warnings, fmt.Sprintf("已忽略目录中的 %d 个重复卷章标题", tocEntries))
+	}
+
+	for _, volume := range volumes {
+		if volume.Title == "" && len(volume.Chapters) == 0 {
+			continue
+		}
+		m.Volumes = append(m.Volumes, volume)
+	}
+	if len(m.Volumes) == 0 {
+		return nil, fmt.Errorf("稿件内容为空")
+	}
+	if headings == 0 {
+		// 没有识别到任何标题时全文作为一个章节
+		m.Volumes[0].Chapters[0].Title = m.Title
+		m.Warnings = append(m.Warnings, "未识别到卷章标题，全文将作为单个章节导入")
+	}
+	m.measure()
+	return m, nil
+}
+
+// measure 生成各章节的 TipTap 内容并统计字数
+func (m *manuscript) measure() {
+	counter := document.NewWordCountService()
+	for _, volume := range m.Volumes {
+		volume.Title = truncateRunes(volume.Title, 200)
+		for _, chapter := range volume.Chapters {
+			chapter.Title = truncateRunes(chapter.Title, 200)
+			chapter.Content = marshalTipTapDoc(chapter.Blocks)
+			if len(chapter.Blocks) > 0 {
+				chapter.Text = tiptapToPlainText(chapter.Content)
+			}
+			chapter.WordCount = counter.CalculateWordCount(chapter.Text).TotalCount
+			chapter.CharCount = utf8.RuneCountInString(chapter.Text)
+		}
+	}
+}
+
+// counts 返回卷数、章节数和总字数
+func (m *manuscript) counts() (volumes, chapters, words int) {
+	for _, volume := range m.Volumes {
+		if volume.Title != "" {
+			volumes++
+		}
+		chapters += len(volume.Chapters)
+		words += volume.WordCount()
+	}
+	return volumes, chapters, words
+}
+
+// parseTXTManuscript 按行解析 TXT 稿件，每个非空行作为一个段落；同时识别开头的《书名》
+func parseTXTManuscript(data []byte) ([]manuscriptBlock, string) {
+	text := decodeManuscriptText(data)
+	var (
+		blocks    []manuscriptBlock
+		bookTitle string
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimFunc(strings.TrimSuffix(line, "\r"), unicode.IsSpace)
+		if line == "" {
+			continue
+		}
+		if len(blocks) == 0 && bookTitle == "" {
+			if match := bookTitlePattern.FindStringSubmatch(line); match != nil {
+				bookTitle = strings.TrimSpace(match[1])
+			}
+		}
+		node := tiptapRichNode{Type: "paragraph", Content: []tiptapRichNode{{Type: "text", Text: line}}}
+		if isSceneBreakText(line) {
+			node = tiptapRichNode{Type: "horizontalRule"}
+		}
+		blocks = append(blocks, manuscriptBlock{node: node, text: line})
+	}
+	return blocks, bookTitle
+}
+
+// decodeManuscriptText 识别 BOM 和 GB18030 编码，统一转换为 UTF-8
+func decodeManuscriptText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true)
+	case utf8.Valid(data):
+		return string(data)
+	}
+	// 国内常见的 GBK/GB2312 文本，GB18030 是其超集
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(bytes.ToValidUTF8(data, []byte("�")))
+	}
+	return string(decoded)
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// tiptapManuscriptBlocks 将 TipTap 块级节点包装为稿件块
+func tiptapManuscriptBlocks(nodes []tiptapRichNode) []manuscriptBlock {
+	blocks := make([]manuscriptBlock, 0, len(nodes))
+	for _, node := range nodes {
+		block := manuscriptBlock{node: node, text: tiptapRichText(node)}
+		if node.Type == "heading" {
+			block.heading = tiptapAttrInt(node.Attrs, "level", 1)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// tiptapRichText 返回节点的纯文本
+func tiptapRichText(node tiptapRichNode) string {
+	if node.Type == "hardBreak" {
+		return "\n"
+	}
+	if node.Type == "text" {
+		return node.Text
+	}
+	var b strings.Builder
+	for i, child := range node.Content {
+		if i > 0 && child.Type != "text" && child.Type != "hardBreak" && node.Type != "paragraph" && node.Type != "heading" {
+			b.WriteString("\n")
+		}
+		b.WriteString(tiptapRichText(child))
+	}
+	return b.String()
+}
+
+// normalizeHeadingText 合并标题中的连续空白（含全角空格）
+func normalizeHeadingText(text string) string {
+	return strings.Join(strings.FieldsFunc(text, unicode.IsSpace), " ")
+}
+
+func lastRune(text string) string {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return string(r)
+}
+
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
@@ -182,6 +182,27 @@ func (m *MockPublicationRepository) FindPublishedByProjectID(ctx context.Context
 	return args.Get(0).(*serviceInterfaces.PublicationRecord), args.Error(1)
 }
 
+func (m *MockPublicationRepository) FindScheduledByProjectID(ctx context.Context, projectID string) ([]*serviceInterfaces.PublicationRecord, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceInterfaces.PublicationRecord), args.Error(1)
+}
+
+func (m *MockPublicationRepository) FindDueScheduled(ctx context.Context, dueBefore, staleBefore time.Time, limit int) ([]*serviceInterfaces.PublicationRecord, error) {
+	args := m.Called(ctx, dueBefore, staleBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceInterfaces.PublicationRecord), args.Error(1)
+}
+
+func (m *MockPublicationRepository) UpdateIfUnchanged(ctx context.Context, record *serviceInterfaces.PublicationRecord, expectedStatus string, expectedUpdatedAt time.Time) (bool, error) {
+	args := m.Called(ctx, record, expectedStatus, expectedUpdatedAt)
+	return args.Bool(0), args.Error(1)
+}
+
 // MockEventBus Mock事件总线
 type MockEventBus struct {
 	mock.Mock
@@ -0,0 +1,425 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	"Qingyu_backend/service/base"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// OrganizationService 组织服务实现
+type OrganizationService struct {
+	orgRepo       writerRepo.OrganizationRepository
+	characterRepo writerRepo.CharacterRepository // 可选，用于校验成员与领袖
+	eventBus      base.EventBus
+}
+
+// NewOrganizationService 创建OrganizationService实例
+func NewOrganizationService(
+	orgRepo writerRepo.OrganizationRepository,
+	characterRepo writerRepo.CharacterRepository,
+	eventBus base.EventBus,
+) serviceInterfaces.OrganizationService {
+	return &OrganizationService{
+		orgRepo:       orgRepo,
+		characterRepo: characterRepo,
+		eventBus:      eventBus,
+	}
+}
+
+// Create 创建组织
+func (s *OrganizationService) Create(
+	ctx context.Context,
+	projectID, userID string,
+	req *serviceInterfaces.CreateOrganizationRequest,
+) (*writer.Organization, error) {
+	if req.ParentID != "" {
+		if _, err := s.GetByID(ctx, req.ParentID, projectID); err != nil {
+			return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "parent organization not found", "", err)
+		}
+	}
+	if req.LeaderID != "" {
+		if err := s.validateCharacter(ctx, projectID, req.LeaderID); err != nil {
+			return nil, err
+		}
+	}
+
+	members := make([]string, 0, len(req.Members))
+	for _, characterID := range req.Members {
+		if err := s.validateCharacter(ctx, projectID, characterID); err != nil {
+			return nil, err
+		}
+		members = appendUnique(members, characterID)
+	}
+
+	org := &writer.Organization{
+		ProjectID:      projectID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Description:    req.Description,
+		LeaderID:       req.LeaderID,
+		BaseLocationID: req.BaseLocationID,
+		ParentID:       req.ParentID,
+		Members:        members,
+		Alias:          req.Alias,
+		Motto:          req.Motto,
+		Resources:      req.Resources,
+	}
+
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorInternal, "create organization failed", "", err)
+	}
+
+	s.publishEvent(ctx, "organization.created", map[string]interface{}{
+		"organization_id": org.ID,
+		"project_id":      projectID,
+		"user_id":         userID,
+		"name":            org.Name,
+	})
+
+	return org, nil
+}
+
+// GetByID 根据ID获取组织
+func (s *OrganizationService) GetByID(
+	ctx context.Context,
+	orgID, projectID string,
+) (*writer.Organization, error) {
+	org, err := s.orgRepo.FindByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.ProjectID != projectID {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorForbidden, "no permission to access this organization", "", nil)
+	}
+
+	return org, nil
+}
+
+// List 获取项目下的所有组织
+func (s *OrganizationService) List(
+	ctx context.Context,
+	projectID string,
+) ([]*writer.Organization, error) {
+	return s.orgRepo.FindByProjectID(ctx, projectID)
+}
+
+// Update 更新组织
+func (s *OrganizationService) Update(
+	ctx context.Context,
+	orgID, projectID string,
+	req *serviceInterfaces.UpdateOrganizationRequest,
+) (*writer.Organization, error) {
+	org, err := s.GetByID(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil && *req.ParentID != org.ParentID {
+		if err := s.validateParent(ctx, projectID, orgID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		org.ParentID = *req.ParentID
+	}
+	if req.LeaderID != nil && *req.LeaderID != org.LeaderID {
+		if *req.LeaderID != "" {
+			if err := s.validateCharacter(ctx, projectID, *req.LeaderID); err != nil {
+				return nil, err
+			}
+		}
+		org.LeaderID = *req.LeaderID
+	}
+	if req.Name != nil {
+		org.Name = *req.Name
+	}
+	if req.Type != nil {
+		org.Type = *req.Type
+	}
+	if req.Description != nil {
+		org.Description = *req.Description
+	}
+	if req.BaseLocationID != nil {
+		org.BaseLocationID = *req.BaseLocationID
+	}
+	if req.Alias != nil {
+		org.Alias = *req.Alias
+	}
+	if req.Motto != nil {
+		org.Motto = *req.Motto
+	}
+	if req.Resources != nil {
+		org.Resources = *req.Resources
+	}
+
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, "organization.updated", map[string]interface{}{
+		"organization_id": orgID,
+		"project_id":      projectID,
+	})
+
+	return org, nil
+}
+
+// Delete 删除组织，子组织上移到被删除组织的上级，并清理相关外交关系
+func (s *OrganizationService) Delete(
+	ctx context.Context,
+	orgID, projectID string,
+) error {
+	org, err := s.GetByID(ctx, orgID, projectID)
+	if err != nil {
+		return err
+	}
+
+	children, err := s.orgRepo.FindByParentID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		child.ParentID = org.ParentID
+		if err := s.orgRepo.Update(ctx, child); err != nil {
+			return errors.NewServiceError("OrganizationService", errors.ServiceErrorInternal, "reparent child organization failed", "", err)
+		}
+	}
+
+	if err := s.orgRepo.DeleteRelationsByOrgID(ctx, orgID); err != nil {
+		return err
+	}
+	if err := s.orgRepo.Delete(ctx, orgID); err != nil {
+		return err
+	}
+
+	s.publishEvent(ctx, "organization.deleted", map[string]interface{}{
+		"organization_id": orgID,
+		"project_id":      projectID,
+	})
+
+	return nil
+}
+
+// GetOrganizationTree 获取组织层级树
+func (s *OrganizationService) GetOrganizationTree(
+	ctx context.Context,
+	projectID string,
+) ([]*serviceInterfaces.OrganizationNode, error) {
+	orgs, err := s.orgRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildOrganizationTree(orgs), nil
+}
+
+// AddMember 添加组织成员
+func (s *OrganizationService) AddMember(
+	ctx context.Context,
+	orgID, projectID, characterID string,
+) (*writer.Organization, error) {
+	if _, err := s.GetByID(ctx, orgID, projectID); err != nil {
+		return nil, err
+	}
+	if err := s.validateCharacter(ctx, projectID, characterID); err != nil {
+		return nil, err
+	}
+
+	if err := s.orgRepo.AddMember(ctx, orgID, characterID); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorInternal, "add organization member failed", "", err)
+	}
+
+	s.publishEvent(ctx, "organization.updated", map[string]interface{}{
+		"organization_id": orgID,
+		"project_id":      projectID,
+		"member_added":    characterID,
+	})
+
+	return s.orgRepo.FindByID(ctx, orgID)
+}
+
+// RemoveMember 移除组织成员
+func (s *OrganizationService) RemoveMember(
+	ctx context.Context,
+	orgID, projectID, characterID string,
+) (*writer.Organization, error) {
+	org, err := s.GetByID(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.HasMember(characterID) {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "character is not a member of this organization", "", nil)
+	}
+
+	if err := s.orgRepo.RemoveMember(ctx, orgID, characterID); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorInternal, "remove organization member failed", "", err)
+	}
+
+	s.publishEvent(ctx, "organization.updated", map[string]interface{}{
+		"organization_id": orgID,
+		"project_id":      projectID,
+		"member_removed":  characterID,
+	})
+
+	return s.orgRepo.FindByID(ctx, orgID)
+}
+
+// ListByMember 获取角色所属的组织
+func (s *OrganizationService) ListByMember(
+	ctx context.Context,
+	projectID, characterID string,
+) ([]*writer.Organization, error) {
+	return s.orgRepo.FindByMemberID(ctx, projectID, characterID)
+}
+
+// CreateRelation 创建组织关系
+func (s *OrganizationService) CreateRelation(
+	ctx context.Context,
+	projectID string,
+	req *serviceInterfaces.CreateOrgRelationRequest,
+) (*writer.OrgRelation, error) {
+	if req.FromOrgID == req.ToOrgID {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorValidation, "organization cannot relate to itself", "", nil)
+	}
+	if _, err := s.GetByID(ctx, req.FromOrgID, projectID); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "source organization not found", "", err)
+	}
+	if _, err := s.GetByID(ctx, req.ToOrgID, projectID); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "target organization not found", "", err)
+	}
+
+	relationType := writer.OrgRelationType(req.Relation)
+	if !relationType.IsValid() {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorValidation, "invalid relation type", "", nil)
+	}
+
+	relation := &writer.OrgRelation{
+		ProjectID: projectID,
+		FromOrgID: req.FromOrgID,
+		ToOrgID:   req.ToOrgID,
+		Relation:  relationType,
+		Notes:     req.Notes,
+	}
+
+	if err := s.orgRepo.CreateRelation(ctx, relation); err != nil {
+		return nil, errors.NewServiceError("OrganizationService", errors.ServiceErrorInternal, "create organization relation failed", "", err)
+	}
+
+	return relation, nil
+}
+
+// ListRelations 获取组织关系列表
+func (s *OrganizationService) ListRelations(
+	ctx context.Context,
+	projectID string,
+	orgID *string,
+) ([]*writer.OrgRelation, error) {
+	return s.orgRepo.FindRelations(ctx, projectID, orgID)
+}
+
+// DeleteRelation 删除组织关系
+func (s *OrganizationService) DeleteRelation(
+	ctx context.Context,
+	relationID, projectID string,
+) error {
+	relation, err := s.orgRepo.FindRelationByID(ctx, relationID)
+	if err != nil {
+		return err
+	}
+
+	if relation.ProjectID != projectID {
+		return errors.NewServiceError("OrganizationService", errors.ServiceErrorForbidden, "no permission to delete this relation", "", nil)
+	}
+
+	return s.orgRepo.DeleteRelation(ctx, relationID)
+}
+
+// validateParent 校验新的上级组织属于同一项目且不会形成环
+func (s *OrganizationService) validateParent(ctx context.Context, projectID, orgID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	orgs, err := s.orgRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	parentOf := make(map[string]string, len(orgs))
+	for _, org := range orgs {
+		parentOf[org.ID] = org.ParentID
+	}
+	if _, ok := parentOf[parentID]; !ok {
+		return errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "parent organization not found", "", nil)
+	}
+	if createsCycle(parentOf, orgID, parentID) {
+		return errors.NewServiceError("OrganizationService", errors.ServiceErrorValidation, "organization cannot be moved under itself or its descendants", "", nil)
+	}
+	return nil
+}
+
+// validateCharacter 校验角色存在且属于该项目
+func (s *OrganizationService) validateCharacter(ctx context.Context, projectID, characterID string) error {
+	if s.characterRepo == nil {
+		return nil
+	}
+	character, err := s.characterRepo.FindByID(ctx, characterID)
+	if err != nil {
+		return errors.NewServiceError("OrganizationService", errors.ServiceErrorNotFound, "character not found", "", err)
+	}
+	if character.ProjectID.Hex() != projectID {
+		return errors.NewServiceError("OrganizationService", errors.ServiceErrorForbidden, "character does not belong to this project", "", nil)
+	}
+	return nil
+}
+
+// publishEvent 发布组织变更事件
+func (s *OrganizationService) publishEvent(ctx context.Context, eventType string, data map[string]interface{}) {
+	if s.eventBus == nil {
+		return
+	}
+	event := &base.BaseEvent{
+		EventType: eventType,
+		EventData: data,
+		Timestamp: time.Now(),
+		Source:    "OrganizationService",
+	}
+	s.eventBus.PublishAsync(ctx, event)
+}
+
+// buildOrganizationTree 构建组织树
+func buildOrganizationTree(orgs []*writer.Organization) []*serviceInterfaces.OrganizationNode {
+	nodeMap := make(map[string]*serviceInterfaces.OrganizationNode)
+	var roots []*serviceInterfaces.OrganizationNode
+
+	for _, org := range orgs {
+		nodeMap[org.ID] = &serviceInterfaces.OrganizationNode{
+			Organization: org,
+			Children:     []*serviceInterfaces.OrganizationNode{},
+		}
+	}
+
+	for _, org := range orgs {
+		node := nodeMap[org.ID]
+		if parent, ok := nodeMap[org.ParentID]; ok && org.ParentID != "" {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	return roots
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
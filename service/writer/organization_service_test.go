@@ -0,0 +1,232 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"Qingyu_backend/models/writer"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOrganizationRepo 内存组织仓储
+type memoryOrganizationRepo struct {
+	writerRepo.OrganizationRepository
+	orgs      map[string]*writer.Organization
+	order     []string
+	relations map[string]*writer.OrgRelation
+	nextID    int
+}
+
+func newMemoryOrganizationRepo() *memoryOrganizationRepo {
+	return &memoryOrganizationRepo{
+		orgs:      make(map[string]*writer.Organization),
+		relations: make(map[string]*writer.OrgRelation),
+	}
+}
+
+func (r *memoryOrganizationRepo) newID() string {
+	r.nextID++
+	return fmt.Sprintf("org-%d", r.nextID)
+}
+
+func (r *memoryOrganizationRepo) Create(ctx context.Context, org *writer.Organization) error {
+	if org.ID == "" {
+		org.ID = r.newID()
+	}
+	r.orgs[org.ID] = org
+	r.order = append(r.order, org.ID)
+	return nil
+}
+
+func (r *memoryOrganizationRepo) FindByID(ctx context.Context, orgID string) (*writer.Organization, error) {
+	org, ok := r.orgs[orgID]
+	if !ok {
+		return nil, fmt.Errorf("organization not found: %s", orgID)
+	}
+	return org, nil
+}
+
+func (r *memoryOrganizationRepo) FindByProjectID(ctx context.Context, projectID string) ([]*writer.Organization, error) {
+	var result []*writer.Organization
+	for _, id := range r.order {
+		if org, ok := r.orgs[id]; ok && org.ProjectID == projectID {
+			result = append(result, org)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryOrganizationRepo) FindByParentID(ctx context.Context, parentID string) ([]*writer.Organization, error) {
+	var result []*writer.Organization
+	for _, id := range r.order {
+		if org, ok := r.orgs[id]; ok && org.ParentID == parentID {
+			result = append(result, org)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryOrganizationRepo) FindByMemberID(ctx context.Context, projectID, characterID string) ([]*writer.Organization, error) {
+	var result []*writer.Organization
+	for _, id := range r.order {
+		if org, ok := r.orgs[id]; ok && org.ProjectID == projectID && org.HasMember(characterID) {
+			result = append(result, org)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryOrganizationRepo) Update(ctx context.Context, org *writer.Organization) error {
+	r.orgs[org.ID] = org
+	return nil
+}
+
+func (r *memoryOrganizationRepo) Delete(ctx context.Context, orgID string) error {
+	delete(r.orgs, orgID)
+	return nil
+}
+
+func (r *memoryOrganizationRepo) AddMember(ctx context.Context, orgID, characterID string) error {
+	org := r.orgs[orgID]
+	org.Members = appendUnique(org.Members, characterID)
+	return nil
+}
+
+func (r *memoryOrganizationRepo) RemoveMember(ctx context.Context, orgID, characterID string) error {
+	org := r.orgs[orgID]
+	members := org.Members[:0]
+	for _, id := range org.Members {
+		if id != characterID {
+			members = append(members, id)
+		}
+	}
+	org.Members = members
+	return nil
+}
+
+func (r *memoryOrganizationRepo) CreateRelation(ctx context.Context, relation *writer.OrgRelation) error {
+	if relation.ID == "" {
+		relation.ID = r.newID()
+	}
+	r.relations[relation.ID] = relation
+	return nil
+}
+
+func (r *memoryOrganizationRepo) FindRelations(ctx context.Context, projectID string, orgID *string) ([]*writer.OrgRelation, error) {
+	var result []*writer.OrgRelation
+	for _, relation := range r.relations {
+		if relation.ProjectID != projectID {
+			continue
+		}
+		if orgID != nil && relation.FromOrgID != *orgID && relation.ToOrgID != *orgID {
+			continue
+		}
+		result = append(result, relation)
+	}
+	return result, nil
+}
+
+func (r *memoryOrganizationRepo) DeleteRelationsByOrgID(ctx context.Context, orgID string) error {
+	for id, relation := range r.relations {
+		if relation.FromOrgID == orgID || relation.ToOrgID == orgID {
+			delete(r.relations, id)
+		}
+	}
+	return nil
+}
+
+// stubOrganizationCharacterRepo 仅实现按ID查询角色
+type stubOrganizationCharacterRepo struct {
+	writerRepo.CharacterRepository
+	characters map[string]*writer.Character
+}
+
+func (r *stubOrganizationCharacterRepo) FindByID(ctx context.Context, id string) (*writer.Character, error) {
+	char, ok := r.characters[id]
+	if !ok {
+		return nil, fmt.Errorf("character not found: %s", id)
+	}
+	return char, nil
+}
+
+func TestOrganizationService_Membership(t *testing.T) {
+	ctx := context.Background()
+	projectID := primitive.NewObjectID()
+	hero := &writer.Character{}
+	hero.ID = primitive.NewObjectID()
+	hero.ProjectID = projectID
+	outsider := &writer.Character{}
+	outsider.ID = primitive.NewObjectID()
+	outsider.ProjectID = primitive.NewObjectID()
+
+	characters := &stubOrganizationCharacterRepo{characters: map[string]*writer.Character{
+		hero.ID.Hex():     hero,
+		outsider.ID.Hex(): outsider,
+	}}
+	svc := NewOrganizationService(newMemoryOrganizationRepo(), characters, nil)
+
+	sect, err := svc.Create(ctx, projectID.Hex(), "u1", &serviceInterfaces.CreateOrganizationRequest{Name: "天机阁"})
+	require.NoError(t, err)
+
+	updated, err := svc.AddMember(ctx, sect.ID, projectID.Hex(), hero.ID.Hex())
+	require.NoError(t, err)
+	assert.True(t, updated.HasMember(hero.ID.Hex()))
+
+	_, err = svc.AddMember(ctx, sect.ID, projectID.Hex(), outsider.ID.Hex())
+	assert.Error(t, err, "其他项目的角色不能加入")
+
+	orgs, err := svc.ListByMember(ctx, projectID.Hex(), hero.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+
+	updated, err = svc.RemoveMember(ctx, sect.ID, projectID.Hex(), hero.ID.Hex())
+	require.NoError(t, err)
+	assert.False(t, updated.HasMember(hero.ID.Hex()))
+
+	_, err = svc.RemoveMember(ctx, sect.ID, projectID.Hex(), hero.ID.Hex())
+	assert.Error(t, err)
+}
+
+func TestOrganizationService_RelationsAndDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryOrganizationRepo()
+	svc := NewOrganizationService(repo, nil, nil)
+
+	empire, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateOrganizationRequest{Name: "大夏"})
+	army, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateOrganizationRequest{Name: "镇北军", ParentID: empire.ID})
+	guard, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateOrganizationRequest{Name: "玄甲卫", ParentID: army.ID})
+	rebels, _ := svc.Create(ctx, "p1", "u1", &serviceInterfaces.CreateOrganizationRequest{Name: "北狄"})
+
+	_, err := svc.Update(ctx, empire.ID, "p1", &serviceInterfaces.UpdateOrganizationRequest{ParentID: &guard.ID})
+	assert.Error(t, err, "不能形成环")
+
+	_, err = svc.CreateRelation(ctx, "p1", &serviceInterfaces.CreateOrgRelationRequest{FromOrgID: army.ID, ToOrgID: rebels.ID, Relation: "仇人"})
+	assert.Error(t, err, "非法关系类型")
+	_, err = svc.CreateRelation(ctx, "p1", &serviceInterfaces.CreateOrgRelationRequest{FromOrgID: army.ID, ToOrgID: army.ID, Relation: string(writer.OrgRelationAlly)})
+	assert.Error(t, err, "不能与自身建立关系")
+
+	relation, err := svc.CreateRelation(ctx, "p1", &serviceInterfaces.CreateOrgRelationRequest{FromOrgID: army.ID, ToOrgID: rebels.ID, Relation: string(writer.OrgRelationHostile)})
+	require.NoError(t, err)
+	assert.Equal(t, writer.OrgRelationHostile, relation.Relation)
+
+	require.NoError(t, svc.Delete(ctx, army.ID, "p1"))
+
+	moved, err := svc.GetByID(ctx, guard.ID, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, empire.ID, moved.ParentID)
+
+	relations, err := svc.ListRelations(ctx, "p1", nil)
+	require.NoError(t, err)
+	assert.Empty(t, relations, "删除组织时清理其外交关系")
+
+	tree, err := svc.GetOrganizationTree(ctx, "p1")
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, guard.ID, tree[0].Children[0].Organization.ID)
+}
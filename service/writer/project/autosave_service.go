@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -26,6 +27,10 @@ type autoSaveSession struct {
 	ticker     *time.Ticker
 	stopChan   chan struct{}
 	lastSaved  time.Time
+	// snapshot 非空时为快照会话：保存时从数据源读取完整内容（如协作编辑合并后的文档）
+	snapshot func() (content, editorID string, changed bool)
+	// contentType 快照内容的格式，按该格式保存
+	contentType string
 }
 
 // NewAutoSaveService 创建自动保存服务
@@ -74,6 +79,56 @@ func (s *AutoSaveService) StartAutoSave(documentID, projectID, nodeID, userID st
 	return nil
 }
 
+// StartSnapshotAutoSave 启动基于内容快照的自动保存
+// 每个周期调用 snapshot 获取最新内容，仅在 changed 为 true 时按 contentType 写入新修订
+func (s *AutoSaveService) StartSnapshotAutoSave(documentID, contentType string, snapshot func() (content, editorID string, changed bool)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, exists := s.sessions[documentID]; exists {
+		log.Printf("[AutoSave] 文档 %s 的自动保存已在运行，重启会话", documentID)
+		close(session.stopChan)
+		session.ticker.Stop()
+	}
+
+	session := &autoSaveSession{
+		documentID:  documentID,
+		ticker:      time.NewTicker(s.interval),
+		stopChan:    make(chan struct{}),
+		lastSaved:   time.Now(),
+		snapshot:    snapshot,
+		contentType: contentType,
+	}
+	s.sessions[documentID] = session
+
+	go s.runAutoSave(session)
+
+	log.Printf("[AutoSave] 启动文档快照自动保存: documentID=%s, interval=%v", documentID, s.interval)
+	return nil
+}
+
+// FlushAndStop 停止文档自动保存并立即执行最后一次保存
+func (s *AutoSaveService) FlushAndStop(documentID string) error {
+	s.mu.Lock()
+	session, exists := s.sessions[documentID]
+	if exists {
+		close(session.stopChan)
+		session.ticker.Stop()
+		delete(s.sessions, documentID)
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return pkgErrors.ProjectFactory.BusinessError(
+			"AUTOSAVE_NOT_FOUND",
+			"未找到自动保存会话",
+		)
+	}
+
+	log.Printf("[AutoSave] 停止文档自动保存并保存: documentID=%s", documentID)
+	return s.performAutoSave(session)
+}
+
 // StopAutoSave 停止文档自动保存
 func (s *AutoSaveService) StopAutoSave(documentID string) error {
 	s.mu.Lock()
@@ -122,6 +177,10 @@ func (s *AutoSaveService) runAutoSave(session *autoSaveSession) {
 
 // performAutoSave 执行自动保存操作
 func (s *AutoSaveService) performAutoSave(session *autoSaveSession) error {
+	if session.snapshot != nil {
+		return s.performSnapshotSave(session)
+	}
+
 	// MVP: 直接调用VersionService创建版本
 	// 注意：这里不检查内容是否变化，每30秒必定保存（简化实现）
 	_, err := s.versionService.BumpVersionAndCreateRevision(
@@ -142,6 +201,25 @@ func (s *AutoSaveService) performAutoSave(session *autoSaveSession) error {
 	return nil
 }
 
+// performSnapshotSave 保存快照会话的最新内容，内容未变化时跳过
+func (s *AutoSaveService) performSnapshotSave(session *autoSaveSession) error {
+	content, editorID, changed := session.snapshot()
+	if !changed {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := s.versionService.SaveDocumentSnapshot(ctx, session.documentID, editorID, content, session.contentType, "协作编辑自动保存"); err != nil {
+		return pkgErrors.ProjectFactory.InternalError(
+			"AUTOSAVE_FAILED",
+			"自动保存版本创建失败",
+			err,
+		)
+	}
+	return nil
+}
+
 // GetStatus 获取自动保存状态
 // 返回：是否正在自动保存、最后保存时间
 func (s *AutoSaveService) GetStatus(documentID string) (isRunning bool, lastSaved *time.Time) {
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	writerService "Qingyu_backend/service/writer"
+	"Qingyu_backend/service/writer/document"
+)
+
+// collabStoreTimeout 启动自动保存时读取文档内容格式的超时时间
+const collabStoreTimeout = 5 * time.Second
+
+// CollabDocumentStore 协作编辑会话的文档存储
+// 校验编辑权限、加载文档内容，并通过自动保存服务定期持久化协作合并后的内容
+type CollabDocumentStore struct {
+	versionService *VersionService
+	autoSave       *AutoSaveService
+	authHelper     *document.AuthHelper
+}
+
+// NewCollabDocumentStore 创建协作编辑文档存储
+func NewCollabDocumentStore(versionService *VersionService, autoSave *AutoSaveService, authHelper *document.AuthHelper) *CollabDocumentStore {
+	return &CollabDocumentStore{
+		versionService: versionService,
+		autoSave:       autoSave,
+		authHelper:     authHelper,
+	}
+}
+
+// LoadDocument 校验用户对文档的编辑权限并返回 TipTap 内容
+// 协作编辑以 TipTap 文档为模型，Markdown 内容转换后参与协作，保存时再转回 Markdown
+func (s *CollabDocumentStore) LoadDocument(ctx context.Context, userID, documentID string) (string, error) {
+	if _, _, _, err := s.authHelper.VerifyDocumentEdit(context.WithValue(ctx, "userId", userID), documentID); err != nil {
+		return "", err
+	}
+
+	content, err := s.versionService.getDocumentContent(ctx, documentID)
+	if err != nil {
+		return "", err
+	}
+	if content == nil {
+		return "", nil
+	}
+	return toCollabContent(content.Content, collabContentType(content))
+}
+
+// StartAutoSave 启动协作内容的定期保存，保存时保持文档原有的内容格式
+func (s *CollabDocumentStore) StartAutoSave(documentID string, snapshot func() (content, editorID string, changed bool)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), collabStoreTimeout)
+	defer cancel()
+	content, err := s.versionService.getDocumentContent(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	contentType := collabContentType(content)
+	return s.autoSave.StartSnapshotAutoSave(documentID, contentType, func() (string, string, bool) {
+		doc, editorID, changed := snapshot()
+		if !changed {
+			return "", "", false
+		}
+		return fromCollabContent(doc, contentType), editorID, true
+	})
+}
+
+// StopAutoSave 停止定期保存并立即保存未落盘的修改
+func (s *CollabDocumentStore) StopAutoSave(documentID string) error {
+	return s.autoSave.FlushAndStop(documentID)
+}
+
+// collabContentType 文档内容的存储格式，尚无内容的文档按 Markdown 保存（与文档服务默认值一致）
+func collabContentType(content *writer.DocumentContent) string {
+	if content == nil || content.ContentType == "" {
+		return contentTypeMarkdown
+	}
+	return content.ContentType
+}
+
+// toCollabContent 将存储内容转换为协作编辑使用的 TipTap JSON
+func toCollabContent(content, contentType string) (string, error) {
+	switch contentType {
+	case contentTypeTipTapJSON, contentTypeTipTap:
+		return content, nil
+	case contentTypeMarkdown:
+		if strings.TrimSpace(content) == "" {
+			return "", nil
+		}
+		return writerService.MarkdownToTipTap(content), nil
+	default:
+		return "", fmt.Errorf("内容格式 %s 不支持协作编辑", contentType)
+	}
+}
+
+// fromCollabContent 将协作编辑后的 TipTap JSON 转回文档原有格式
+func fromCollabContent(content, contentType string) string {
+	if contentType == contentTypeMarkdown {
+		return writerService.TipTapToMarkdown(content)
+	}
+	return content
+}
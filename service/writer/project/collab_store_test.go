@@ -0,0 +1,39 @@
+package project
+
+import (
+	"testing"
+
+	"Qingyu_backend/models/writer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollabContent_MarkdownKeepsFormat(t *testing.T) {
+	markdown := "# 第一章\n\n他**推开**了门。\n"
+
+	doc, err := toCollabContent(markdown, collabContentType(&writer.DocumentContent{ContentType: "markdown"}))
+	require.NoError(t, err)
+	assert.Contains(t, doc, `"type":"heading"`)
+	assert.Contains(t, doc, `"type":"bold"`)
+
+	// 保存时转回 Markdown，而不是改写为 TipTap JSON
+	assert.Equal(t, markdown, fromCollabContent(doc, contentTypeMarkdown))
+}
+
+func TestCollabContent_TipTapPassesThrough(t *testing.T) {
+	doc := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"正文"}]}]}`
+
+	loaded, err := toCollabContent(doc, contentTypeTipTapJSON)
+	require.NoError(t, err)
+	assert.Equal(t, doc, loaded)
+	assert.Equal(t, doc, fromCollabContent(doc, contentTypeTipTapJSON))
+}
+
+func TestCollabContent_DefaultsAndUnsupportedFormats(t *testing.T) {
+	assert.Equal(t, contentTypeMarkdown, collabContentType(nil))
+	assert.Equal(t, contentTypeMarkdown, collabContentType(&writer.DocumentContent{}))
+
+	_, err := toCollabContent("<p>正文</p>", "richtext")
+	assert.Error(t, err)
+}
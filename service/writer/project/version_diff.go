@@ -0,0 +1,594 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultDiffContextLines 差异块默认上下文行数（与 git diff 一致）
+	DefaultDiffContextLines = 3
+	// maxInlineDiffRunes 行内字符级差异的长度上限，超出后整行按删除+新增处理
+	maxInlineDiffRunes = 20000
+	// patchApplyFuzz 应用统一差异时允许的最大行偏移
+	patchApplyFuzz = 200
+)
+
+// 差异格式
+const (
+	DiffFormatFull    = "full"    // 完整内容替换
+	DiffFormatUnified = "unified" // 统一差异格式（unified diff）
+)
+
+const noNewlineMarker = `\ No newline at end of file`
+
+var (
+	// ErrPatchNotApplicable 补丁上下文与当前内容不匹配
+	ErrPatchNotApplicable = errors.New("patch_not_applicable")
+	// ErrInvalidUnifiedDiff 统一差异格式解析失败
+	ErrInvalidUnifiedDiff = errors.New("invalid_unified_diff")
+
+	hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+)
+
+type diffOpKind int8
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp 编辑脚本中的一步；equal 同时引用 a、b，delete 只引用 a，insert 只引用 b
+type diffOp struct {
+	Kind   diffOpKind
+	AIndex int
+	BIndex int
+}
+
+// myersDiff 基于 Myers O(ND) 算法（线性空间的中间蛇分治版本）计算最短编辑脚本
+func myersDiff[T comparable](a, b []T) []diffOp {
+	d := &myersDiffer[T]{
+		a:        a,
+		b:        b,
+		deletedA: make([]bool, len(a)),
+		insertB:  make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && d.deletedA[i]:
+			ops = append(ops, diffOp{Kind: diffDelete, AIndex: i, BIndex: j})
+			i++
+		case j < len(b) && d.insertB[j]:
+			ops = append(ops, diffOp{Kind: diffInsert, AIndex: i, BIndex: j})
+			j++
+		default:
+			ops = append(ops, diffOp{Kind: diffEqual, AIndex: i, BIndex: j})
+			i++
+			j++
+		}
+	}
+	return ops
+}
+
+type myersDiffer[T comparable] struct {
+	a, b     []T
+	deletedA []bool
+	insertB  []bool
+}
+
+// compare 标记 a[aLo:aHi] 与 b[bLo:bHi] 之间的删除与插入
+func (d *myersDiffer[T]) compare(aLo, aHi, bLo, bHi int) {
+	// 去掉公共前缀和后缀
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.insertB[j] = true
+		}
+		return
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.deletedA[i] = true
+		}
+		return
+	}
+
+	x, y, ok := d.middleSnake(aLo, aHi, bLo, bHi)
+	if !ok {
+		for i := aLo; i < aHi; i++ {
+			d.deletedA[i] = true
+		}
+		for j := bLo; j < bHi; j++ {
+			d.insertB[j] = true
+		}
+		return
+	}
+	d.compare(aLo, x, bLo, y)
+	d.compare(x, aHi, y, bHi)
+}
+
+// middleSnake 同时从两端搜索，返回最短编辑路径上的分割点
+func (d *myersDiffer[T]) middleSnake(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	size := 2*maxD + 3
+	vf := make([]int, size)
+	vb := make([]int, size)
+	for i := range vf {
+		vf[i] = -1
+		vb[i] = -1
+	}
+	vf[offset+1] = 0
+	vb[offset+1] = 0
+
+	delta := n - m
+	front := delta%2 != 0
+	k1start, k1end, k2start, k2end := 0, 0, 0, 0
+
+	for step := 0; step < maxD+1; step++ {
+		// 正向搜索
+		for k1 := -step + k1start; k1 <= step-k1end; k1 += 2 {
+			k1Offset := offset + k1
+			var x1 int
+			if k1 == -step || (k1 != step && vf[k1Offset-1] < vf[k1Offset+1]) {
+				x1 = vf[k1Offset+1]
+			} else {
+				x1 = vf[k1Offset-1] + 1
+			}
+			y1 := x1 - k1
+			for x1 < n && y1 < m && d.a[aLo+x1] == d.b[bLo+y1] {
+				x1++
+				y1++
+			}
+			vf[k1Offset] = x1
+			switch {
+			case x1 > n:
+				k1end += 2
+			case y1 > m:
+				k1start += 2
+			case front:
+				k2Offset := offset + delta - k1
+				if k2Offset >= 0 && k2Offset < size && vb[k2Offset] != -1 {
+					if x1 >= n-vb[k2Offset] {
+						return aLo + x1, bLo + y1, true
+					}
+				}
+			}
+		}
+
+		// 反向搜索
+		for k2 := -step + k2start; k2 <= step-k2end; k2 += 2 {
+			k2Offset := offset + k2
+			var x2 int
+			if k2 == -step || (k2 != step && vb[k2Offset-1] < vb[k2Offset+1]) {
+				x2 = vb[k2Offset+1]
+			} else {
+				x2 = vb[k2Offset-1] + 1
+			}
+			y2 := x2 - k2
+			for x2 < n && y2 < m && d.a[aHi-x2-1] == d.b[bHi-y2-1] {
+				x2++
+				y2++
+			}
+			vb[k2Offset] = x2
+			switch {
+			case x2 > n:
+				k2end += 2
+			case y2 > m:
+				k2start += 2
+			case !front:
+				k1Offset := offset + delta - k2
+				if k1Offset >= 0 && k1Offset < size && vf[k1Offset] != -1 {
+					x1 := vf[k1Offset]
+					y1 := offset + x1 - k1Offset
+					if x1 >= n-x2 {
+						return aLo + x1, bLo + y1, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitLinesKeepEnds 按行分割并保留行尾换行符，用于区分末行是否以换行结尾
+func splitLinesKeepEnds(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffHunkRange 差异块在编辑脚本中的范围
+type diffHunkRange struct {
+	opStart, opEnd     int
+	oldStart, oldLines int
+	newStart, newLines int
+}
+
+// groupHunks 将编辑脚本按上下文行数分组为差异块
+func groupHunks(ops []diffOp, contextLines int) []diffHunkRange {
+	if contextLines < 0 {
+		contextLines = 0
+	}
+	var hunks []diffHunkRange
+	i := 0
+	for i < len(ops) {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		if len(hunks) > 0 && start < hunks[len(hunks)-1].opEnd {
+			start = hunks[len(hunks)-1].opEnd
+		}
+
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != diffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == diffEqual {
+				run++
+			}
+			if run < len(ops) && run-end <= 2*contextLines {
+				end = run
+				continue
+			}
+			if end+contextLines < run {
+				end += contextLines
+			} else {
+				end = run
+			}
+			break
+		}
+
+		h := diffHunkRange{
+			opStart:  start,
+			opEnd:    end,
+			oldStart: ops[start].AIndex,
+			newStart: ops[start].BIndex,
+		}
+		for _, op := range ops[start:end] {
+			switch op.Kind {
+			case diffEqual:
+				h.oldLines++
+				h.newLines++
+			case diffDelete:
+				h.oldLines++
+			case diffInsert:
+				h.newLines++
+			}
+		}
+		hunks = append(hunks, h)
+		i = end
+	}
+	return hunks
+}
+
+// header 返回统一差异格式的块头，行号从 1 开始；空范围按惯例指向前一行
+func (h diffHunkRange) header() string {
+	oldStart, newStart := h.oldStart+1, h.newStart+1
+	if h.oldLines == 0 {
+		oldStart = h.oldStart
+	}
+	if h.newLines == 0 {
+		newStart = h.newStart
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, h.oldLines, newStart, h.newLines)
+}
+
+// buildVersionDiff 计算两段文本的行级差异，生成差异块、变更项与统一差异文本
+func buildVersionDiff(fromLabel, toLabel, fromContent, toContent string, contextLines int) *VersionDiff {
+	a := splitLinesKeepEnds(fromContent)
+	b := splitLinesKeepEnds(toContent)
+	ops := myersDiff(a, b)
+	ranges := groupHunks(ops, contextLines)
+
+	diff := &VersionDiff{
+		Changes: make([]ChangeItem, 0),
+		Hunks:   make([]DiffHunk, 0, len(ranges)),
+	}
+
+	for _, r := range ranges {
+		hunk := DiffHunk{
+			OldStart: r.oldStart + 1,
+			OldLines: r.oldLines,
+			NewStart: r.newStart + 1,
+			NewLines: r.newLines,
+			Header:   r.header(),
+			Lines:    make([]ChangeItem, 0, r.opEnd-r.opStart),
+		}
+
+		hunkOps := ops[r.opStart:r.opEnd]
+		for i := 0; i < len(hunkOps); {
+			op := hunkOps[i]
+			if op.Kind == diffEqual {
+				hunk.Lines = append(hunk.Lines, ChangeItem{
+					Type:    "context",
+					Line:    op.BIndex + 1,
+					OldLine: op.AIndex + 1,
+					NewLine: op.BIndex + 1,
+					Content: trimLineEnd(b[op.BIndex]),
+				})
+				i++
+				continue
+			}
+
+			// 收集连续的删除与插入，成对的视为修改
+			var dels, ins []diffOp
+			for i < len(hunkOps) && hunkOps[i].Kind == diffDelete {
+				dels = append(dels, hunkOps[i])
+				i++
+			}
+			for i < len(hunkOps) && hunkOps[i].Kind == diffInsert {
+				ins = append(ins, hunkOps[i])
+				i++
+			}
+
+			paired := len(dels)
+			if len(ins) < paired {
+				paired = len(ins)
+			}
+			for k := 0; k < paired; k++ {
+				oldText := trimLineEnd(a[dels[k].AIndex])
+				newText := trimLineEnd(b[ins[k].BIndex])
+				hunk.Lines = append(hunk.Lines, ChangeItem{
+					Type:       "modified",
+					Line:       ins[k].BIndex + 1,
+					OldLine:    dels[k].AIndex + 1,
+					NewLine:    ins[k].BIndex + 1,
+					Content:    newText,
+					OldContent: oldText,
+					Segments:   inlineDiff(oldText, newText),
+				})
+				diff.ModifiedLines++
+			}
+			for _, d := range dels[paired:] {
+				hunk.Lines = append(hunk.Lines, ChangeItem{
+					Type:    "deleted",
+					Line:    d.AIndex + 1,
+					OldLine: d.AIndex + 1,
+					Content: trimLineEnd(a[d.AIndex]),
+				})
+				diff.DeletedLines++
+			}
+			for _, in := range ins[paired:] {
+				hunk.Lines = append(hunk.Lines, ChangeItem{
+					Type:    "added",
+					Line:    in.BIndex + 1,
+					NewLine: in.BIndex + 1,
+					Content: trimLineEnd(b[in.BIndex]),
+				})
+				diff.AddedLines++
+			}
+		}
+
+		for _, line := range hunk.Lines {
+			if line.Type != "context" {
+				diff.Changes = append(diff.Changes, line)
+			}
+		}
+		diff.Hunks = append(diff.Hunks, hunk)
+	}
+
+	diff.Unified = formatUnifiedDiff(fromLabel, toLabel, a, b, ops, ranges)
+	return diff
+}
+
+// formatUnifiedDiff 输出统一差异格式文本，可直接作为 unified 补丁提交
+func formatUnifiedDiff(fromLabel, toLabel string, a, b []string, ops []diffOp, ranges []diffHunkRange) string {
+	if len(ranges) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("--- " + fromLabel + "\n")
+	sb.WriteString("+++ " + toLabel + "\n")
+	for _, r := range ranges {
+		sb.WriteString(r.header())
+		sb.WriteByte('\n')
+		for _, op := range ops[r.opStart:r.opEnd] {
+			var prefix byte
+			var line string
+			switch op.Kind {
+			case diffEqual:
+				prefix, line = ' ', a[op.AIndex]
+			case diffDelete:
+				prefix, line = '-', a[op.AIndex]
+			case diffInsert:
+				prefix, line = '+', b[op.BIndex]
+			}
+			sb.WriteByte(prefix)
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n" + noNewlineMarker + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// inlineDiff 计算一行内的字符级差异（按 rune 比较，适用于中文文本）
+func inlineDiff(oldText, newText string) []InlineSegment {
+	a, b := []rune(oldText), []rune(newText)
+	if len(a)+len(b) > maxInlineDiffRunes {
+		return []InlineSegment{
+			{Type: "delete", Text: oldText},
+			{Type: "insert", Text: newText},
+		}
+	}
+
+	segments := make([]InlineSegment, 0)
+	appendSegment := func(kind string, r rune) {
+		if n := len(segments); n > 0 && segments[n-1].Type == kind {
+			segments[n-1].Text += string(r)
+			return
+		}
+		segments = append(segments, InlineSegment{Type: kind, Text: string(r)})
+	}
+	for _, op := range myersDiff(a, b) {
+		switch op.Kind {
+		case diffEqual:
+			appendSegment("equal", a[op.AIndex])
+		case diffDelete:
+			appendSegment("delete", a[op.AIndex])
+		case diffInsert:
+			appendSegment("insert", b[op.BIndex])
+		}
+	}
+	return segments
+}
+
+// unifiedHunk 解析后的差异块
+type unifiedHunk struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+}
+
+// parseUnifiedDiff 解析统一差异文本
+func parseUnifiedDiff(patch string) ([]unifiedHunk, error) {
+	var hunks []unifiedHunk
+	var current *unifiedHunk
+	// lastSide 记录上一行所属的一侧，用于处理"无换行结尾"标记
+	var lastSide byte
+
+	for _, raw := range strings.Split(patch, "\n") {
+		if m := hunkHeaderPattern.FindStringSubmatch(raw); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			hunks = append(hunks, unifiedHunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+			if m[2] == "0" {
+				// 空范围指向插入点之前的行
+				current.oldStart++
+			}
+			lastSide = 0
+			continue
+		}
+		if current == nil || raw == "" {
+			continue
+		}
+
+		switch raw[0] {
+		case ' ':
+			current.oldLines = append(current.oldLines, raw[1:]+"\n")
+			current.newLines = append(current.newLines, raw[1:]+"\n")
+		case '-':
+			current.oldLines = append(current.oldLines, raw[1:]+"\n")
+		case '+':
+			current.newLines = append(current.newLines, raw[1:]+"\n")
+		case '\\':
+			if lastSide == ' ' || lastSide == '-' {
+				trimLastLineEnd(current.oldLines)
+			}
+			if lastSide == ' ' || lastSide == '+' {
+				trimLastLineEnd(current.newLines)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidUnifiedDiff, raw)
+		}
+		lastSide = raw[0]
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: no hunks found", ErrInvalidUnifiedDiff)
+	}
+	return hunks, nil
+}
+
+// applyUnifiedDiff 将统一差异补丁应用到文本；上下文不匹配时在附近行查找，仍失败则返回 ErrPatchNotApplicable
+func applyUnifiedDiff(base, patch string) (string, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return "", err
+	}
+
+	lines := splitLinesKeepEnds(base)
+	var sb strings.Builder
+	cursor := 0
+	for i, h := range hunks {
+		pos, ok := locateHunk(lines, h, cursor)
+		if !ok {
+			return "", fmt.Errorf("%w: hunk %d at line %d", ErrPatchNotApplicable, i+1, h.oldStart)
+		}
+		for _, line := range lines[cursor:pos] {
+			sb.WriteString(line)
+		}
+		for _, line := range h.newLines {
+			sb.WriteString(line)
+		}
+		cursor = pos + len(h.oldLines)
+	}
+	for _, line := range lines[cursor:] {
+		sb.WriteString(line)
+	}
+	return sb.String(), nil
+}
+
+// locateHunk 查找差异块在原文中的位置，优先使用块头行号，其次向两侧偏移查找
+func locateHunk(lines []string, h unifiedHunk, minPos int) (int, bool) {
+	expected := h.oldStart - 1
+	if expected < minPos {
+		expected = minPos
+	}
+	for offset := 0; offset <= patchApplyFuzz; offset++ {
+		for _, pos := range []int{expected - offset, expected + offset} {
+			if pos < minPos || pos+len(h.oldLines) > len(lines) {
+				continue
+			}
+			if linesEqual(lines[pos:pos+len(h.oldLines)], h.oldLines) {
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func linesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func trimLastLineEnd(lines []string) {
+	if n := len(lines); n > 0 {
+		lines[n-1] = strings.TrimSuffix(lines[n-1], "\n")
+	}
+}
+
+func trimLineEnd(line string) string {
+	return strings.TrimSuffix(line, "\n")
+}
@@ -0,0 +1,130 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildVersionDiff_InsertedLineDoesNotShiftFollowingLines(t *testing.T) {
+	from := "第一章\n林动睁开眼。\n山风很冷。\n他站起身。\n"
+	to := "第一章\n夜色深沉。\n林动睁开眼。\n山风很冷。\n他站起身。\n"
+
+	diff := buildVersionDiff("a", "b", from, to, DefaultDiffContextLines)
+
+	require.Len(t, diff.Changes, 1)
+	assert.Equal(t, "added", diff.Changes[0].Type)
+	assert.Equal(t, 2, diff.Changes[0].NewLine)
+	assert.Equal(t, "夜色深沉。", diff.Changes[0].Content)
+	assert.Equal(t, 1, diff.AddedLines)
+	assert.Equal(t, 0, diff.DeletedLines)
+	assert.Equal(t, 0, diff.ModifiedLines)
+
+	require.Len(t, diff.Hunks, 1)
+	assert.Equal(t, "@@ -1,4 +1,5 @@", diff.Hunks[0].Header)
+}
+
+func TestBuildVersionDiff_ModifiedLineHasInlineSegments(t *testing.T) {
+	diff := buildVersionDiff("a", "b", "他缓缓走进大殿。\n", "他快步走进大殿。\n", DefaultDiffContextLines)
+
+	require.Len(t, diff.Changes, 1)
+	change := diff.Changes[0]
+	assert.Equal(t, "modified", change.Type)
+	assert.Equal(t, "他缓缓走进大殿。", change.OldContent)
+	assert.Equal(t, []InlineSegment{
+		{Type: "equal", Text: "他"},
+		{Type: "delete", Text: "缓缓"},
+		{Type: "insert", Text: "快步"},
+		{Type: "equal", Text: "走进大殿。"},
+	}, change.Segments)
+}
+
+func TestBuildVersionDiff_SeparateHunks(t *testing.T) {
+	var fromLines, toLines []string
+	for i := 0; i < 30; i++ {
+		line := strings.Repeat("段", i+1)
+		fromLines = append(fromLines, line)
+		toLines = append(toLines, line)
+	}
+	toLines[2] = "改动一"
+	toLines[25] = "改动二"
+
+	diff := buildVersionDiff("a", "b", strings.Join(fromLines, "\n"), strings.Join(toLines, "\n"), 2)
+
+	require.Len(t, diff.Hunks, 2)
+	assert.Equal(t, "@@ -1,5 +1,5 @@", diff.Hunks[0].Header)
+	assert.Equal(t, "@@ -24,5 +24,5 @@", diff.Hunks[1].Header)
+	assert.Equal(t, 2, diff.ModifiedLines)
+}
+
+func TestBuildVersionDiff_IdenticalContent(t *testing.T) {
+	diff := buildVersionDiff("a", "b", "相同\n内容\n", "相同\n内容\n", DefaultDiffContextLines)
+
+	assert.Empty(t, diff.Changes)
+	assert.Empty(t, diff.Hunks)
+	assert.Empty(t, diff.Unified)
+}
+
+func TestApplyUnifiedDiff_RoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"insert at top", "b\nc\n", "a\nb\nc\n"},
+		{"delete all", "a\nb\n", ""},
+		{"from empty", "", "新内容\n"},
+		{"add trailing newline", "a\nb", "a\nb\n"},
+		{"remove trailing newline", "a\nb\n", "a\nb"},
+		{"mixed edits", "一\n二\n三\n四\n五\n六\n七\n八\n九\n十\n", "一\n二\n叁\n四\n五\n六\n七\n八\n九\n十\n十一\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := buildVersionDiff("a", "b", tc.from, tc.to, DefaultDiffContextLines)
+			got, err := applyUnifiedDiff(tc.from, diff.Unified)
+			require.NoError(t, err)
+			assert.Equal(t, tc.to, got)
+		})
+	}
+}
+
+func TestApplyUnifiedDiff_ToleratesShiftedContext(t *testing.T) {
+	from := "甲\n乙\n丙\n丁\n"
+	to := "甲\n乙\n丙二\n丁\n"
+	patch := buildVersionDiff("a", "b", from, to, 1).Unified
+
+	// 基线之后在开头插入了两行，补丁仍应按上下文定位
+	current := "序一\n序二\n" + from
+	got, err := applyUnifiedDiff(current, patch)
+	require.NoError(t, err)
+	assert.Equal(t, "序一\n序二\n"+to, got)
+}
+
+func TestApplyUnifiedDiff_ContextMismatch(t *testing.T) {
+	patch := buildVersionDiff("a", "b", "甲\n乙\n", "甲\n丙\n", DefaultDiffContextLines).Unified
+
+	_, err := applyUnifiedDiff("完全不同\n的内容\n", patch)
+	assert.ErrorIs(t, err, ErrPatchNotApplicable)
+}
+
+func TestParseUnifiedDiff_Invalid(t *testing.T) {
+	_, err := parseUnifiedDiff("not a diff")
+	assert.ErrorIs(t, err, ErrInvalidUnifiedDiff)
+}
+
+func TestMyersDiff_MinimalEditScript(t *testing.T) {
+	a := []rune("ABCABBA")
+	b := []rune("CBABAC")
+
+	edits := 0
+	for _, op := range myersDiff(a, b) {
+		if op.Kind != diffEqual {
+			edits++
+		}
+	}
+	// Myers 论文中的经典示例，最短编辑距离为 5
+	assert.Equal(t, 5, edits)
+}
@@ -20,8 +20,18 @@ type VersionInfo struct {
 	CreatedAt time.Time `json:"createdAt"`
 	CreatedBy string    `json:"createdBy"`
 	WordCount int       `json:"wordCount"`
+	// Operation 特殊修订类型：restore（恢复）、backup（恢复前自动备份）、collab（协作编辑保存）
+	Operation           string `json:"operation,omitempty"`
+	RestoredFromVersion int    `json:"restoredFromVersion,omitempty"`
 }
 
+// 修订操作类型（记录在 FileRevision.Metadata["operation"]）
+const (
+	RevisionOperationRestore = "restore"
+	RevisionOperationBackup  = "backup"
+	RevisionOperationCollab  = "collab"
+)
+
 // VersionDetail 版本详情
 type VersionDetail struct {
 	VersionID  string    `json:"versionId"`
@@ -36,16 +46,39 @@ type VersionDetail struct {
 
 // VersionDiff 版本差异
 type VersionDiff struct {
-	FromVersion  string       `json:"fromVersion"`
-	ToVersion    string       `json:"toVersion"`
-	Changes      []ChangeItem `json:"changes"`
-	AddedLines   int          `json:"addedLines"`
-	DeletedLines int          `json:"deletedLines"`
+	FromVersion   string       `json:"fromVersion"`
+	ToVersion     string       `json:"toVersion"`
+	Changes       []ChangeItem `json:"changes"`
+	Hunks         []DiffHunk   `json:"hunks"`
+	Unified       string       `json:"unified,omitempty"` // 统一差异格式文本，可作为 unified 补丁提交
+	AddedLines    int          `json:"addedLines"`
+	DeletedLines  int          `json:"deletedLines"`
+	ModifiedLines int          `json:"modifiedLines"`
+}
+
+// DiffHunk 差异块（包含上下文行）
+type DiffHunk struct {
+	OldStart int          `json:"oldStart"`
+	OldLines int          `json:"oldLines"`
+	NewStart int          `json:"newStart"`
+	NewLines int          `json:"newLines"`
+	Header   string       `json:"header"`
+	Lines    []ChangeItem `json:"lines"`
 }
 
 // ChangeItem 变更项
 type ChangeItem struct {
-	Type    string `json:"type"` // added, deleted, modified
-	Line    int    `json:"line"`
-	Content string `json:"content"`
+	Type       string          `json:"type"` // added, deleted, modified, context
+	Line       int             `json:"line"`
+	OldLine    int             `json:"oldLine,omitempty"`
+	NewLine    int             `json:"newLine,omitempty"`
+	Content    string          `json:"content"`
+	OldContent string          `json:"oldContent,omitempty"` // 修改前内容（仅 modified）
+	Segments   []InlineSegment `json:"segments,omitempty"`   // 行内字符级差异（仅 modified）
+}
+
+// InlineSegment 行内差异片段
+type InlineSegment struct {
+	Type string `json:"type"` // equal, insert, delete
+	Text string `json:"text"`
 }
@@ -0,0 +1,366 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"Qingyu_backend/models/writer"
+)
+
+// 文档内容格式
+const (
+	contentTypeTipTapJSON = "tiptap_json"
+	contentTypeTipTap     = "tiptap"
+	contentTypeMarkdown   = "markdown"
+)
+
+var (
+	// ErrMergeConflicts 三路合并存在未解决的冲突块
+	ErrMergeConflicts = errors.New("merge_conflicts")
+	// ErrInvalidConflictChoice 冲突块解决选择无效
+	ErrInvalidConflictChoice = errors.New("invalid_conflict_choice")
+)
+
+// mergeHunk 一侧相对基线的一处修改：用 lines 替换 base[start:end]
+type mergeHunk struct {
+	start, end int
+	lines      []string
+	ours       bool
+}
+
+// mergeSegment 合并结果片段，conflict 非空时表示冲突块
+type mergeSegment struct {
+	lines    []string
+	conflict *mergeConflict
+}
+
+type mergeConflict struct {
+	baseStart          int
+	base, ours, theirs []string
+}
+
+// mergeOutcome 三路合并的中间结果
+type mergeOutcome struct {
+	segments   []mergeSegment
+	conflicts  int
+	autoMerged int
+}
+
+// collectMergeHunks 将 base→side 的编辑脚本整理为基线上的修改区间
+func collectMergeHunks(base, side []string, ours bool) []mergeHunk {
+	ops := myersDiff(base, side)
+	var hunks []mergeHunk
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+		h := mergeHunk{start: ops[i].AIndex, end: ops[i].AIndex, ours: ours}
+		for i < len(ops) && ops[i].Kind != diffEqual {
+			if ops[i].Kind == diffDelete {
+				h.end = ops[i].AIndex + 1
+			} else {
+				h.lines = append(h.lines, side[ops[i].BIndex])
+			}
+			i++
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// hunksOverlap 判断两个修改区间是否冲突：相交的区间、同一位置的两处插入、落在对方区间内部的插入
+func hunksOverlap(aStart, aEnd, bStart, bEnd int) bool {
+	aEmpty, bEmpty := aStart == aEnd, bStart == bEnd
+	switch {
+	case aEmpty && bEmpty:
+		return aStart == bStart
+	case aEmpty:
+		return bStart < aStart && aStart < bEnd
+	case bEmpty:
+		return aStart < bStart && bStart < aEnd
+	default:
+		return aStart < bEnd && bStart < aEnd
+	}
+}
+
+// applyHunks 对 base[lo:hi] 应用同一侧落在该区间内的修改
+func applyHunks(base []string, lo, hi int, hunks []mergeHunk) []string {
+	out := make([]string, 0, hi-lo)
+	cursor := lo
+	for _, h := range hunks {
+		out = append(out, base[cursor:h.start]...)
+		out = append(out, h.lines...)
+		cursor = h.end
+	}
+	return append(out, base[cursor:hi]...)
+}
+
+// threeWayMerge 以 base 为共同祖先合并 ours 与 theirs：
+// 互不重叠的修改自动合并，双方做出相同修改的区域直接采纳，其余重叠区域输出为冲突块
+func threeWayMerge(base, ours, theirs []string) mergeOutcome {
+	all := append(collectMergeHunks(base, ours, true), collectMergeHunks(base, theirs, false)...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].start != all[j].start {
+			return all[i].start < all[j].start
+		}
+		return all[i].end < all[j].end
+	})
+
+	var outcome mergeOutcome
+	used := make([]bool, len(all))
+	cursor := 0
+	for i := range all {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		lo, hi := all[i].start, all[i].end
+		members := []mergeHunk{all[i]}
+
+		// 吸收所有与当前区域重叠的修改（可能来自任意一侧）
+		for changed := true; changed; {
+			changed = false
+			for j := i + 1; j < len(all); j++ {
+				if used[j] {
+					continue
+				}
+				if all[j].start > hi {
+					break
+				}
+				if hunksOverlap(lo, hi, all[j].start, all[j].end) {
+					used[j] = true
+					members = append(members, all[j])
+					if all[j].end > hi {
+						hi = all[j].end
+					}
+					changed = true
+				}
+			}
+		}
+
+		var oursHunks, theirsHunks []mergeHunk
+		for _, h := range members {
+			if h.ours {
+				oursHunks = append(oursHunks, h)
+			} else {
+				theirsHunks = append(theirsHunks, h)
+			}
+		}
+		sortHunks(oursHunks)
+		sortHunks(theirsHunks)
+
+		if cursor < lo {
+			outcome.segments = append(outcome.segments, mergeSegment{lines: base[cursor:lo]})
+		}
+		cursor = hi
+
+		oursLines := applyHunks(base, lo, hi, oursHunks)
+		theirsLines := applyHunks(base, lo, hi, theirsHunks)
+		switch {
+		case len(theirsHunks) == 0:
+			outcome.segments = append(outcome.segments, mergeSegment{lines: oursLines})
+			outcome.autoMerged += len(oursHunks)
+		case len(oursHunks) == 0:
+			outcome.segments = append(outcome.segments, mergeSegment{lines: theirsLines})
+			outcome.autoMerged += len(theirsHunks)
+		case linesEqual(oursLines, theirsLines):
+			outcome.segments = append(outcome.segments, mergeSegment{lines: oursLines})
+			outcome.autoMerged++
+		default:
+			outcome.segments = append(outcome.segments, mergeSegment{conflict: &mergeConflict{
+				baseStart: lo,
+				base:      base[lo:hi],
+				ours:      oursLines,
+				theirs:    theirsLines,
+			}})
+			outcome.conflicts++
+		}
+	}
+	if cursor < len(base) {
+		outcome.segments = append(outcome.segments, mergeSegment{lines: base[cursor:]})
+	}
+	return outcome
+}
+
+func sortHunks(hunks []mergeHunk) {
+	sort.SliceStable(hunks, func(i, j int) bool { return hunks[i].start < hunks[j].start })
+}
+
+// resolveSegments 按冲突块选择拼接最终内容；choices 为空且存在冲突时返回 ErrMergeConflicts
+func resolveSegments(segments []mergeSegment, choices []writer.ConflictBlockChoice, contentType string) ([]string, error) {
+	byIndex := make(map[int]writer.ConflictBlockChoice, len(choices))
+	for _, c := range choices {
+		byIndex[c.Index] = c
+	}
+
+	var out []string
+	index := 0
+	for _, seg := range segments {
+		if seg.conflict == nil {
+			out = append(out, seg.lines...)
+			continue
+		}
+		choice, ok := byIndex[index]
+		if !ok {
+			return nil, fmt.Errorf("%w: conflict block %d unresolved", ErrMergeConflicts, index)
+		}
+		switch choice.Choice {
+		case writer.ConflictChoiceOurs:
+			out = append(out, seg.conflict.ours...)
+		case writer.ConflictChoiceTheirs:
+			out = append(out, seg.conflict.theirs...)
+		case writer.ConflictChoiceBase:
+			out = append(out, seg.conflict.base...)
+		case writer.ConflictChoiceBoth:
+			out = append(out, seg.conflict.ours...)
+			out = append(out, seg.conflict.theirs...)
+		case writer.ConflictChoiceCustom:
+			for _, line := range choice.Content {
+				if contentType == contentTypeTipTapJSON {
+					if !json.Valid([]byte(line)) {
+						return nil, fmt.Errorf("%w: block %d contains invalid node json", ErrInvalidConflictChoice, index)
+					}
+					out = append(out, line)
+				} else {
+					out = append(out, line+"\n")
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidConflictChoice, choice.Choice)
+		}
+		index++
+	}
+	return out, nil
+}
+
+// mergeDocument 可合并文档：纯文本按段落拆分，TipTap 文档按顶层节点拆分
+type mergeDocument struct {
+	units []string
+	// envelope 保存 TipTap 文档除 content 外的顶层字段
+	envelope map[string]json.RawMessage
+}
+
+// splitMergeUnits 将内容拆分为参与合并的单元
+func splitMergeUnits(content, contentType string) (*mergeDocument, error) {
+	if contentType != contentTypeTipTapJSON {
+		return &mergeDocument{units: splitLinesKeepEnds(content)}, nil
+	}
+
+	doc := &mergeDocument{envelope: map[string]json.RawMessage{}}
+	if strings.TrimSpace(content) == "" {
+		return doc, nil
+	}
+	if err := json.Unmarshal([]byte(content), &doc.envelope); err != nil {
+		return nil, fmt.Errorf("解析TipTap文档失败: %w", err)
+	}
+
+	var nodes []interface{}
+	if raw, ok := doc.envelope["content"]; ok {
+		if err := json.Unmarshal(raw, &nodes); err != nil {
+			return nil, fmt.Errorf("解析TipTap节点失败: %w", err)
+		}
+	}
+	for _, node := range nodes {
+		// 重新序列化得到键有序的规范形式，避免字段顺序差异被当成修改
+		canonical, err := json.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+		doc.units = append(doc.units, string(canonical))
+	}
+	return doc, nil
+}
+
+// joinMergeUnits 将合并单元还原为文档内容
+func joinMergeUnits(units []string, contentType string, envelope map[string]json.RawMessage) (string, error) {
+	if contentType != contentTypeTipTapJSON {
+		return strings.Join(units, ""), nil
+	}
+
+	out := make(map[string]json.RawMessage, len(envelope)+2)
+	for k, v := range envelope {
+		out[k] = v
+	}
+	if _, ok := out["type"]; !ok {
+		out["type"] = json.RawMessage(`"doc"`)
+	}
+	nodes := make([]json.RawMessage, 0, len(units))
+	for _, u := range units {
+		nodes = append(nodes, json.RawMessage(u))
+	}
+	content, err := json.Marshal(nodes)
+	if err != nil {
+		return "", err
+	}
+	out["content"] = content
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// mergeContents 对三个版本的内容执行三路合并
+func mergeContents(baseContent, oursContent, theirsContent, contentType string) (*writer.MergeResult, []mergeSegment, *mergeDocument, error) {
+	base, err := splitMergeUnits(baseContent, contentType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ours, err := splitMergeUnits(oursContent, contentType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	theirs, err := splitMergeUnits(theirsContent, contentType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	outcome := threeWayMerge(base.units, ours.units, theirs.units)
+	result := &writer.MergeResult{
+		ContentType:  contentType,
+		HasConflicts: outcome.conflicts > 0,
+		AutoMerged:   outcome.autoMerged,
+	}
+
+	index := 0
+	for _, seg := range outcome.segments {
+		if seg.conflict == nil {
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, writer.MergeConflictBlock{
+			Index:     index,
+			BaseStart: seg.conflict.baseStart + 1,
+			Base:      displayUnits(seg.conflict.base, contentType),
+			Ours:      displayUnits(seg.conflict.ours, contentType),
+			Theirs:    displayUnits(seg.conflict.theirs, contentType),
+		})
+		index++
+	}
+
+	if !result.HasConflicts {
+		units, _ := resolveSegments(outcome.segments, nil, contentType)
+		merged, err := joinMergeUnits(units, contentType, ours.envelope)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		result.MergedContent = merged
+	}
+	return result, outcome.segments, ours, nil
+}
+
+// displayUnits 返回冲突块中用于展示的单元（纯文本去掉行尾换行）
+func displayUnits(units []string, contentType string) []string {
+	out := make([]string, 0, len(units))
+	for _, u := range units {
+		if contentType == contentTypeTipTapJSON {
+			out = append(out, u)
+		} else {
+			out = append(out, trimLineEnd(u))
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package project
+
+import (
+	"testing"
+
+	"Qingyu_backend/models/writer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeContents_NonOverlappingParagraphsMerge(t *testing.T) {
+	base := "第一段\n第二段\n第三段\n第四段\n"
+	ours := "第一段（改）\n第二段\n第三段\n第四段\n"
+	theirs := "第一段\n第二段\n第三段\n第四段（改）\n新增第五段\n"
+
+	result, _, _, err := mergeContents(base, ours, theirs, "markdown")
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts)
+	assert.Equal(t, "第一段（改）\n第二段\n第三段\n第四段（改）\n新增第五段\n", result.MergedContent)
+	assert.Equal(t, 2, result.AutoMerged)
+}
+
+func TestMergeContents_AdjacentParagraphEditsMerge(t *testing.T) {
+	base := "甲\n乙\n丙\n"
+	ours := "甲\n乙一\n丙\n"
+	theirs := "甲\n乙\n丙一\n"
+
+	result, _, _, err := mergeContents(base, ours, theirs, "markdown")
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts)
+	assert.Equal(t, "甲\n乙一\n丙一\n", result.MergedContent)
+}
+
+func TestMergeContents_IdenticalEditsAreNotConflicts(t *testing.T) {
+	result, _, _, err := mergeContents("甲\n乙\n", "甲\n乙改\n", "甲\n乙改\n", "markdown")
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts)
+	assert.Equal(t, "甲\n乙改\n", result.MergedContent)
+}
+
+func TestMergeContents_OverlappingEditsProduceConflictBlock(t *testing.T) {
+	base := "甲\n乙\n丙\n"
+	ours := "甲\n乙（我方）\n丙\n"
+	theirs := "甲\n乙（对方）\n丙\n"
+
+	result, segments, ours2, err := mergeContents(base, ours, theirs, "markdown")
+	require.NoError(t, err)
+
+	require.True(t, result.HasConflicts)
+	assert.Empty(t, result.MergedContent)
+	require.Len(t, result.Conflicts, 1)
+	assert.Equal(t, writer.MergeConflictBlock{
+		Index:     0,
+		BaseStart: 2,
+		Base:      []string{"乙"},
+		Ours:      []string{"乙（我方）"},
+		Theirs:    []string{"乙（对方）"},
+	}, result.Conflicts[0])
+
+	_, err = resolveSegments(segments, nil, "markdown")
+	assert.ErrorIs(t, err, ErrMergeConflicts)
+
+	units, err := resolveSegments(segments, []writer.ConflictBlockChoice{{Index: 0, Choice: writer.ConflictChoiceBoth}}, "markdown")
+	require.NoError(t, err)
+	merged, err := joinMergeUnits(units, "markdown", ours2.envelope)
+	require.NoError(t, err)
+	assert.Equal(t, "甲\n乙（我方）\n乙（对方）\n丙\n", merged)
+
+	units, err = resolveSegments(segments, []writer.ConflictBlockChoice{{Index: 0, Choice: writer.ConflictChoiceCustom, Content: []string{"乙（合并）"}}}, "markdown")
+	require.NoError(t, err)
+	merged, err = joinMergeUnits(units, "markdown", ours2.envelope)
+	require.NoError(t, err)
+	assert.Equal(t, "甲\n乙（合并）\n丙\n", merged)
+
+	_, err = resolveSegments(segments, []writer.ConflictBlockChoice{{Index: 0, Choice: "unknown"}}, "markdown")
+	assert.ErrorIs(t, err, ErrInvalidConflictChoice)
+}
+
+func TestMergeContents_InsertionsAtSamePointConflict(t *testing.T) {
+	result, _, _, err := mergeContents("甲\n乙\n", "甲\n插入A\n乙\n", "甲\n插入B\n乙\n", "markdown")
+	require.NoError(t, err)
+
+	require.True(t, result.HasConflicts)
+	assert.Equal(t, []string{}, result.Conflicts[0].Base)
+	assert.Equal(t, []string{"插入A"}, result.Conflicts[0].Ours)
+	assert.Equal(t, []string{"插入B"}, result.Conflicts[0].Theirs)
+}
+
+func TestMergeContents_TipTapNodes(t *testing.T) {
+	base := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"甲"}]},{"type":"paragraph","content":[{"type":"text","text":"乙"}]}]}`
+	// 字段顺序不同但语义相同的节点不应被视为修改
+	ours := `{"type":"doc","content":[{"content":[{"text":"甲改","type":"text"}],"type":"paragraph"},{"type":"paragraph","content":[{"type":"text","text":"乙"}]}]}`
+	theirs := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"甲"}]},{"content":[{"type":"text","text":"乙"}],"type":"paragraph"},{"type":"horizontalRule"}]}`
+
+	result, _, _, err := mergeContents(base, ours, theirs, contentTypeTipTapJSON)
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts)
+	assert.JSONEq(t, `{"type":"doc","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"甲改"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"乙"}]},
+		{"type":"horizontalRule"}
+	]}`, result.MergedContent)
+}
+
+func TestMergeContents_TipTapConflict(t *testing.T) {
+	base := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"甲"}]}]}`
+	ours := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"甲A"}]}]}`
+	theirs := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"甲B"}]}]}`
+
+	result, _, _, err := mergeContents(base, ours, theirs, contentTypeTipTapJSON)
+	require.NoError(t, err)
+
+	require.True(t, result.HasConflicts)
+	require.Len(t, result.Conflicts, 1)
+	assert.JSONEq(t, `{"type":"paragraph","content":[{"type":"text","text":"甲A"}]}`, result.Conflicts[0].Ours[0])
+	assert.JSONEq(t, `{"type":"paragraph","content":[{"type":"text","text":"甲B"}]}`, result.Conflicts[0].Theirs[0])
+}
+
+func TestMergeContents_InvalidTipTap(t *testing.T) {
+	_, _, _, err := mergeContents(`{"type":"doc"}`, `not json`, `{"type":"doc"}`, contentTypeTipTapJSON)
+	assert.Error(t, err)
+}
@@ -3,9 +3,13 @@ package project
 import (
 	"Qingyu_backend/models/writer"
 	"Qingyu_backend/repository"
+	serviceBase "Qingyu_backend/service/base"
+	"Qingyu_backend/service/writer/document"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,7 +20,8 @@ import (
 
 // VersionService 版本管理服务
 type VersionService struct {
-	db *mongo.Database
+	db       *mongo.Database
+	eventBus serviceBase.EventBus
 }
 
 // NewVersionService 创建版本服务
@@ -24,7 +29,13 @@ func NewVersionService(db *mongo.Database) *VersionService {
 	return &VersionService{db: db}
 }
 
+// SetEventBus 设置事件总线（用于发布版本恢复等写作事件）
+func (s *VersionService) SetEventBus(eventBus serviceBase.EventBus) {
+	s.eventBus = eventBus
+}
+
 func (s *VersionService) fileCol() *mongo.Collection    { return s.db.Collection("novel_files") }       // 文件集合（Document元数据）
+func (s *VersionService) docCol() *mongo.Collection     { return s.db.Collection("documents") }         // 文档集合
 func (s *VersionService) contentCol() *mongo.Collection { return s.db.Collection("document_contents") } // 文档内容集合
 func (s *VersionService) revCol() *mongo.Collection     { return s.db.Collection("file_revisions") }    // 版本集合
 func (s *VersionService) patchCol() *mongo.Collection   { return s.db.Collection("file_patches") }      // 补丁集合
@@ -40,8 +51,16 @@ func objectIDFromHex(id, resource string) (primitive.ObjectID, error) {
 
 // getDocumentContent 获取文档内容（辅助函数）
 func (s *VersionService) getDocumentContent(ctx context.Context, documentID string) (*writer.DocumentContent, error) {
+	// document_id 以 ObjectID 存储，兼容早期以字符串写入的数据
+	var filter bson.M
+	if objectID, err := repository.ParseID(documentID); err == nil {
+		filter = bson.M{"document_id": bson.M{"$in": bson.A{objectID, documentID}}}
+	} else {
+		filter = bson.M{"document_id": documentID}
+	}
+
 	var content writer.DocumentContent
-	err := s.contentCol().FindOne(ctx, bson.M{"document_id": documentID}).Decode(&content)
+	err := s.contentCol().FindOne(ctx, filter).Decode(&content)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -226,6 +245,11 @@ func (s *VersionService) CreatePatch(projectID, nodeID string, baseVersion int,
 	if projectID == "" || nodeID == "" {
 		return nil, errors.New("invalid arguments")
 	}
+	if diffFormat == DiffFormatUnified {
+		if _, err := parseUnifiedDiff(diffPayload); err != nil {
+			return nil, err
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -287,18 +311,29 @@ func (s *VersionService) ApplyPatch(projectID, patchID, applierID string) (*writ
 		return nil, errors.New("文档内容不存在")
 	}
 
-	// 简化：只支持完整替换的 diffFormat 为 "full"
-	if p.DiffFormat != "full" {
-		return nil, errors.New("only full diffFormat supported currently")
-	}
-
-	// 要求 baseVersion 匹配当前版本以直接应用
-	if p.BaseVersion != docContent.Version {
-		return nil, errors.New("version_conflict")
+	var newContent string
+	switch p.DiffFormat {
+	case DiffFormatFull:
+		// 完整替换要求 baseVersion 匹配当前版本以直接应用
+		if p.BaseVersion != docContent.Version {
+			return nil, errors.New("version_conflict")
+		}
+		newContent = p.DiffPayload
+	case DiffFormatUnified:
+		// 统一差异按上下文定位，基线版本之后的无关修改不影响应用
+		newContent, err = applyUnifiedDiff(docContent.Content, p.DiffPayload)
+		if err != nil {
+			if errors.Is(err, ErrPatchNotApplicable) {
+				return nil, fmt.Errorf("version_conflict: %w", err)
+			}
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported diffFormat: %s", p.DiffFormat)
 	}
 
 	// 使用乐观锁更新内容
-	rev, err := s.UpdateContentWithVersion(projectID, p.NodeID, applierID, p.Preview, p.DiffPayload, docContent.Version)
+	rev, err := s.UpdateContentWithVersion(projectID, p.NodeID, applierID, p.Preview, newContent, docContent.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -398,10 +433,104 @@ func (s *VersionService) DetectConflicts(ctx context.Context, projectID, nodeID
 	}, nil
 }
 
+// DetectConflictsWithContent 检测版本冲突，并以 expectedVersion 对应的修订为共同祖先，
+// 将本地内容与当前最新版本做三路合并
+func (s *VersionService) DetectConflictsWithContent(ctx context.Context, projectID, nodeID string, expectedVersion int, content string) (*writer.ConflictInfo, error) {
+	conflict, err := s.DetectConflicts(ctx, projectID, nodeID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !conflict.HasConflict || content == "" {
+		return conflict, nil
+	}
+
+	merge, err := s.MergeConcurrentEdit(ctx, projectID, nodeID, expectedVersion, content)
+	if err != nil {
+		return nil, err
+	}
+	conflict.Merge = merge
+	return conflict, nil
+}
+
+// MergeConcurrentEdit 三路合并并发编辑：base 为 baseVersion 的修订，ours 为本地内容，theirs 为最新修订
+func (s *VersionService) MergeConcurrentEdit(ctx context.Context, projectID, nodeID string, baseVersion int, content string) (*writer.MergeResult, error) {
+	result, _, _, err := s.mergeAgainstLatest(ctx, projectID, nodeID, baseVersion, content)
+	return result, err
+}
+
+// mergeAgainstLatest 执行三路合并并返回合并片段，供按冲突块解决时复用
+func (s *VersionService) mergeAgainstLatest(ctx context.Context, projectID, nodeID string, baseVersion int, content string) (*writer.MergeResult, []mergeSegment, *mergeDocument, error) {
+	if projectID == "" || nodeID == "" || baseVersion <= 0 {
+		return nil, nil, nil, errors.New("invalid arguments")
+	}
+
+	_, baseContent, err := s.loadRevisionContent(ctx, projectID, nodeID, baseVersion)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("获取基线版本失败: %w", err)
+	}
+	latest, theirsContent, err := s.loadRevisionContent(ctx, projectID, nodeID, 0)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("获取最新版本失败: %w", err)
+	}
+
+	contentType := s.resolveContentType(ctx, projectID, nodeID, content)
+	result, segments, ours, err := mergeContents(baseContent, content, theirsContent, contentType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	result.BaseVersion = baseVersion
+	result.TheirsVersion = latest.Version
+	return result, segments, ours, nil
+}
+
+// loadRevisionContent 读取指定版本的修订及其快照内容，version 为 0 时读取最新修订
+func (s *VersionService) loadRevisionContent(ctx context.Context, projectID, nodeID string, version int) (*writer.FileRevision, string, error) {
+	filter := bson.M{"project_id": projectID, "node_id": nodeID}
+	opts := options.FindOne()
+	if version > 0 {
+		filter["version"] = version
+	} else {
+		opts.SetSort(bson.D{{Key: "version", Value: -1}})
+	}
+
+	var rev writer.FileRevision
+	if err := s.revCol().FindOne(ctx, filter, opts).Decode(&rev); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, "", errors.New("revision_not_found")
+		}
+		return nil, "", err
+	}
+	content, err := s.RetrieveSnapshot(rev.Snapshot, rev.StorageRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve snapshot: %w", err)
+	}
+	return &rev, content, nil
+}
+
+// resolveContentType 获取文档内容类型；文档内容不存在时根据内容推断
+func (s *VersionService) resolveContentType(ctx context.Context, projectID, nodeID, content string) string {
+	var f writer.Document
+	if err := s.fileCol().FindOne(ctx, bson.M{"project_id": projectID, "node_id": nodeID}).Decode(&f); err == nil {
+		if docContent, err := s.getDocumentContent(ctx, f.ID.Hex()); err == nil && docContent != nil && docContent.ContentType != "" {
+			return docContent.ContentType
+		}
+	}
+
+	var doc struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal([]byte(content), &doc) == nil && doc.Type == "doc" {
+		return contentTypeTipTapJSON
+	}
+	return contentTypeMarkdown
+}
+
 // BatchDetectConflicts 批量检测多个文件的版本冲突
+// 文件携带本地内容时同时给出三路合并结果
 func (s *VersionService) BatchDetectConflicts(ctx context.Context, projectID string, files []struct {
 	NodeID          string `json:"node_id"`
 	ExpectedVersion int    `json:"expected_version"`
+	Content         string `json:"content,omitempty"`
 }) (*writer.BatchConflictResult, error) {
 	if projectID == "" || len(files) == 0 {
 		return nil, errors.New("invalid arguments")
@@ -414,7 +543,7 @@ func (s *VersionService) BatchDetectConflicts(ctx context.Context, projectID str
 	}
 
 	for _, file := range files {
-		conflict, err := s.DetectConflicts(ctx, projectID, file.NodeID, file.ExpectedVersion)
+		conflict, err := s.DetectConflictsWithContent(ctx, projectID, file.NodeID, file.ExpectedVersion, file.Content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to detect conflicts for file %s: %w", file.NodeID, err)
 		}
@@ -438,11 +567,13 @@ func (s *VersionService) CreateCommit(ctx context.Context, projectID, authorID,
 	var conflictFiles []struct {
 		NodeID          string `json:"node_id"`
 		ExpectedVersion int    `json:"expected_version"`
+		Content         string `json:"content,omitempty"`
 	}
 	for _, file := range files {
 		conflictFiles = append(conflictFiles, struct {
 			NodeID          string `json:"node_id"`
 			ExpectedVersion int    `json:"expected_version"`
+			Content         string `json:"content,omitempty"`
 		}{
 			NodeID:          file.NodeID,
 			ExpectedVersion: file.ExpectedVersion,
@@ -671,8 +802,8 @@ func (s *VersionService) ResolveBatchConflicts(ctx context.Context, req *writer.
 	var commitFiles []writer.CommitFile
 
 	for nodeID, resolution := range req.Resolutions {
-		// 验证冲突是否仍然存在
-		conflict, err := s.DetectConflicts(ctx, req.ProjectID, nodeID, 0) // 使用0表示检查当前版本
+		// 验证冲突是否仍然存在（未提供基线版本时使用0表示检查当前版本）
+		conflict, err := s.DetectConflicts(ctx, req.ProjectID, nodeID, resolution.BaseVersion)
 		if err != nil {
 			return nil, fmt.Errorf("failed to detect conflicts for file %s: %w", nodeID, err)
 		}
@@ -686,11 +817,25 @@ func (s *VersionService) ResolveBatchConflicts(ctx context.Context, req *writer.
 		var resolvedContent string
 		switch resolution.Strategy {
 		case "auto":
-			// 自动合并（简化实现，实际项目中需要更复杂的合并算法）
-			resolvedContent = resolution.MergedContent
+			// 三路合并，仅在没有重叠修改时成功
+			merge, err := s.MergeConcurrentEdit(ctx, req.ProjectID, nodeID, resolution.BaseVersion, resolution.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge file %s: %w", nodeID, err)
+			}
+			if merge.HasConflicts {
+				return nil, fmt.Errorf("file %s: %w", nodeID, ErrMergeConflicts)
+			}
+			resolvedContent = merge.MergedContent
 		case "manual":
-			// 手动解决
-			resolvedContent = resolution.MergedContent
+			// 手动解决：逐块选择时重新合并并应用选择，否则直接使用提交的合并内容
+			if len(resolution.Choices) == 0 {
+				resolvedContent = resolution.MergedContent
+				break
+			}
+			resolvedContent, err = s.resolveWithChoices(ctx, req.ProjectID, nodeID, resolution)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve file %s: %w", nodeID, err)
+			}
 		case "force":
 			// 强制覆盖
 			resolvedContent = resolution.MergedContent
@@ -720,32 +865,67 @@ func (s *VersionService) ResolveBatchConflicts(ctx context.Context, req *writer.
 	return s.CreateCommit(ctx, req.ProjectID, req.AuthorID, message, commitFiles)
 }
 
-// AutoResolveConflicts 自动解决简单冲突
-func (s *VersionService) AutoResolveConflicts(ctx context.Context, projectID, nodeID string, conflictingRevisions []writer.FileRevision) (string, error) {
-	// 简化的自动合并实现
-	// 在实际项目中，这里需要实现更复杂的三路合并算法
+// resolveWithChoices 重新执行三路合并，并按冲突块选择生成最终内容
+func (s *VersionService) resolveWithChoices(ctx context.Context, projectID, nodeID string, resolution *writer.ConflictResolution) (string, error) {
+	result, segments, ours, err := s.mergeAgainstLatest(ctx, projectID, nodeID, resolution.BaseVersion, resolution.Content)
+	if err != nil {
+		return "", err
+	}
+	units, err := resolveSegments(segments, resolution.Choices, result.ContentType)
+	if err != nil {
+		return "", err
+	}
+	return joinMergeUnits(units, result.ContentType, ours.envelope)
+}
 
+// AutoResolveConflicts 自动解决冲突
+// 以冲突修订的共同祖先为基线，依次三路合并各修订；存在重叠修改时返回 ErrMergeConflicts
+func (s *VersionService) AutoResolveConflicts(ctx context.Context, projectID, nodeID string, conflictingRevisions []writer.FileRevision) (string, error) {
 	if len(conflictingRevisions) < 2 {
 		return "", errors.New("insufficient revisions for auto-merge")
 	}
 
-	// 获取最新版本的内容作为基础
-	latestRevision := conflictingRevisions[0]
-	for _, rev := range conflictingRevisions {
-		if rev.Version > latestRevision.Version {
-			latestRevision = rev
+	revs := make([]writer.FileRevision, len(conflictingRevisions))
+	copy(revs, conflictingRevisions)
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Version < revs[j].Version })
+
+	// 共同祖先：修订记录的最小父版本，缺失时取最早冲突修订的前一版本
+	baseVersion := revs[0].Version - 1
+	for _, rev := range revs {
+		if rev.ParentVers > 0 && rev.ParentVers < baseVersion {
+			baseVersion = rev.ParentVers
 		}
 	}
+	if baseVersion <= 0 {
+		return "", errors.New("common ancestor revision not found")
+	}
+
+	_, baseContent, err := s.loadRevisionContent(ctx, projectID, nodeID, baseVersion)
+	if err != nil {
+		return "", fmt.Errorf("获取共同祖先版本失败: %w", err)
+	}
 
-	// 检索快照内容
-	content, err := s.RetrieveSnapshot(latestRevision.Snapshot, latestRevision.StorageRef)
+	merged, err := s.RetrieveSnapshot(revs[0].Snapshot, revs[0].StorageRef)
 	if err != nil {
 		return "", fmt.Errorf("failed to retrieve snapshot: %w", err)
 	}
+	contentType := s.resolveContentType(ctx, projectID, nodeID, merged)
 
-	// 简化的合并策略：返回最新版本的内容
-	// 在实际项目中，这里需要实现更智能的合并算法
-	return content, nil
+	for _, rev := range revs[1:] {
+		content, err := s.RetrieveSnapshot(rev.Snapshot, rev.StorageRef)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve snapshot: %w", err)
+		}
+		result, _, _, err := mergeContents(baseContent, merged, content, contentType)
+		if err != nil {
+			return "", err
+		}
+		if result.HasConflicts {
+			return "", fmt.Errorf("version %d: %w", rev.Version, ErrMergeConflicts)
+		}
+		merged = result.MergedContent
+	}
+	return merged, nil
 }
 
 // GetVersionHistory 获取版本历史
@@ -780,14 +960,22 @@ func (s *VersionService) GetVersionHistory(ctx context.Context, documentID strin
 	// 转换为响应格式
 	versions := make([]*VersionInfo, 0, len(revisions))
 	for _, rev := range revisions {
-		versions = append(versions, &VersionInfo{
+		info := &VersionInfo{
 			VersionID: rev.ID.Hex(),
 			Version:   rev.Version,
 			Message:   rev.Message,
 			CreatedAt: rev.CreatedAt,
 			CreatedBy: rev.AuthorID,
-			WordCount: 0, // TODO: 从快照中获取字数
-		})
+			WordCount: metadataInt(rev.Metadata, "word_count"),
+		}
+		if info.WordCount == 0 && rev.StorageRef == "" {
+			info.WordCount = len([]rune(rev.Snapshot))
+		}
+		if operation, ok := rev.Metadata["operation"].(string); ok {
+			info.Operation = operation
+			info.RestoredFromVersion = metadataInt(rev.Metadata, "restored_from_version")
+		}
+		versions = append(versions, info)
 	}
 
 	return &VersionHistoryResponse{
@@ -834,6 +1022,12 @@ func (s *VersionService) GetVersion(ctx context.Context, documentID, versionID s
 
 // CompareVersions 比较两个版本
 func (s *VersionService) CompareVersions(ctx context.Context, documentID, fromVersionID, toVersionID string) (*VersionDiff, error) {
+	return s.CompareVersionsWithContext(ctx, documentID, fromVersionID, toVersionID, DefaultDiffContextLines)
+}
+
+// CompareVersionsWithContext 比较两个版本，contextLines 指定差异块的上下文行数
+// 使用 Myers 最短编辑脚本计算行级差异，并对修改行给出字符级差异
+func (s *VersionService) CompareVersionsWithContext(ctx context.Context, documentID, fromVersionID, toVersionID string, contextLines int) (*VersionDiff, error) {
 	// 获取两个版本
 	fromVersion, err := s.GetVersion(ctx, documentID, fromVersionID)
 	if err != nil {
@@ -845,128 +1039,386 @@ func (s *VersionService) CompareVersions(ctx context.Context, documentID, fromVe
 		return nil, fmt.Errorf("获取目标版本失败: %w", err)
 	}
 
-	// 简单的行差异比较
-	fromLines := splitLines(fromVersion.Content)
-	toLines := splitLines(toVersion.Content)
-
-	changes := make([]ChangeItem, 0)
-	addedLines := 0
-	deletedLines := 0
-
-	// 简单的差异算法（实际应该使用更好的diff算法）
-	maxLen := len(fromLines)
-	if len(toLines) > maxLen {
-		maxLen = len(toLines)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		fromLine := ""
-		toLine := ""
-		if i < len(fromLines) {
-			fromLine = fromLines[i]
-		}
-		if i < len(toLines) {
-			toLine = toLines[i]
-		}
-
-		if fromLine != toLine {
-			if fromLine == "" {
-				// 新增行
-				changes = append(changes, ChangeItem{
-					Type:    "added",
-					Line:    i + 1,
-					Content: toLine,
-				})
-				addedLines++
-			} else if toLine == "" {
-				// 删除行
-				changes = append(changes, ChangeItem{
-					Type:    "deleted",
-					Line:    i + 1,
-					Content: fromLine,
-				})
-				deletedLines++
-			} else {
-				// 修改行
-				changes = append(changes, ChangeItem{
-					Type:    "modified",
-					Line:    i + 1,
-					Content: toLine,
-				})
-			}
-		}
-	}
-
-	return &VersionDiff{
-		FromVersion:  fromVersionID,
-		ToVersion:    toVersionID,
-		Changes:      changes,
-		AddedLines:   addedLines,
-		DeletedLines: deletedLines,
-	}, nil
+	diff := buildVersionDiff(
+		fmt.Sprintf("%s@v%d", documentID, fromVersion.Version),
+		fmt.Sprintf("%s@v%d", documentID, toVersion.Version),
+		fromVersion.Content,
+		toVersion.Content,
+		contextLines,
+	)
+	diff.FromVersion = fromVersionID
+	diff.ToVersion = toVersionID
+	return diff, nil
 }
 
 // RestoreVersion 恢复到特定版本
 func (s *VersionService) RestoreVersion(ctx context.Context, documentID, versionID string) error {
+	_, err := s.RestoreVersionAsRevision(ctx, documentID, versionID, "")
+	return err
+}
+
+// RestoreVersionAsRevision 以新修订的方式恢复到特定版本
+// 不覆盖历史：恢复结果记录为一条标记了回滚来源的修订，当前内容若未被任何修订记录则先自动备份，
+// 因此恢复本身也可以通过 UndoRestore 撤销
+func (s *VersionService) RestoreVersionAsRevision(ctx context.Context, documentID, versionID, authorID string) (*writer.FileRevision, error) {
 	// 获取要恢复的版本
-	version, err := s.GetVersion(ctx, documentID, versionID)
+	target, err := s.GetVersion(ctx, documentID, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取版本失败: %w", err)
+	}
+
+	documentObjectID, err := objectIDFromHex(documentID, "document")
 	if err != nil {
-		return fmt.Errorf("获取版本失败: %w", err)
+		return nil, err
+	}
+
+	// 获取文档元数据和当前内容
+	var doc writer.Document
+	if err := s.docCol().FindOne(ctx, bson.M{"_id": documentObjectID}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("文档不存在")
+		}
+		return nil, fmt.Errorf("获取文档失败: %w", err)
 	}
+	projectID := doc.ProjectID.Hex()
 
-	// 获取文档当前内容
 	currentContent, err := s.getDocumentContent(ctx, documentID)
 	if err != nil {
-		return fmt.Errorf("获取当前文档失败: %w", err)
+		return nil, fmt.Errorf("获取当前文档失败: %w", err)
+	}
+	contentType := contentTypeMarkdown
+	currentVersion := 0
+	if currentContent != nil {
+		currentVersion = currentContent.Version
+		if currentContent.ContentType != "" {
+			contentType = currentContent.ContentType
+		}
 	}
 
-	// 更新文档内容
-	if currentContent == nil {
-		// 创建新内容
-		_, err = s.contentCol().InsertOne(ctx, bson.M{
-			"document_id": documentID,
-			"content":     version.Content,
-			"updated_at":  time.Now(),
-		})
-	} else {
-		// 更新现有内容
-		_, err = s.contentCol().UpdateOne(ctx,
-			bson.M{"document_id": documentID},
-			bson.M{
-				"$set": bson.M{
-					"content":    version.Content,
-					"updated_at": time.Now(),
-				},
+	latest, latestContent, err := s.loadLatestDocumentRevision(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	latestVersion := 0
+	if latest != nil {
+		latestVersion = latest.Version
+	}
+
+	// 新版本号需同时领先于内容版本和已有修订
+	next := currentVersion
+	if latestVersion > next {
+		next = latestVersion
+	}
+	next++
+
+	now := time.Now()
+	var backup *writer.FileRevision
+	previousRevisionID := ""
+	if latest != nil {
+		previousRevisionID = latest.ID.Hex()
+	}
+	if currentContent != nil && (latest == nil || latestContent != currentContent.Content) {
+		// 当前内容未被修订记录（如自动保存），先备份以便撤销恢复
+		snapshot, storageRef, err := s.StoreSnapshot(currentContent.Content, projectID, documentID, next)
+		if err != nil {
+			return nil, err
+		}
+		backup = &writer.FileRevision{
+			ID:         primitive.NewObjectID(),
+			ProjectID:  projectID,
+			NodeID:     documentID,
+			Version:    next,
+			AuthorID:   authorID,
+			Message:    fmt.Sprintf("恢复到 v%d 前的自动备份", target.Version),
+			Snapshot:   snapshot,
+			StorageRef: storageRef,
+			ParentVers: latestVersion,
+			Metadata: map[string]interface{}{
+				"operation":  RevisionOperationBackup,
+				"word_count": currentContent.WordCount,
 			},
-		)
+			CreatedAt: now,
+		}
+		previousRevisionID = backup.ID.Hex()
+		next++
+	}
+
+	wordCount := document.CountWords(target.Content, contentType)
+	snapshot, storageRef, err := s.StoreSnapshot(target.Content, projectID, documentID, next)
+	if err != nil {
+		return nil, err
+	}
+	rev := &writer.FileRevision{
+		ID:         primitive.NewObjectID(),
+		ProjectID:  projectID,
+		NodeID:     documentID,
+		Version:    next,
+		AuthorID:   authorID,
+		Message:    fmt.Sprintf("恢复到 v%d", target.Version),
+		Snapshot:   snapshot,
+		StorageRef: storageRef,
+		ParentVers: next - 1,
+		Metadata: map[string]interface{}{
+			"operation":             RevisionOperationRestore,
+			"restored_from_version": target.Version,
+			"restored_from_id":      target.VersionID,
+			"previous_revision_id":  previousRevisionID,
+			"word_count":            wordCount,
+		},
+		CreatedAt: now,
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := s.writeDocumentContent(sessCtx, documentObjectID, currentContent, target.Content, contentType, wordCount, next, authorID, now); err != nil {
+			return nil, err
+		}
+
+		if backup != nil {
+			if _, err := s.revCol().InsertOne(sessCtx, backup); err != nil {
+				return nil, fmt.Errorf("备份当前版本失败: %w", err)
+			}
+		}
+		if _, err := s.revCol().InsertOne(sessCtx, rev); err != nil {
+			return nil, fmt.Errorf("创建恢复版本失败: %w", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(ctx, &serviceBase.BaseEvent{
+			EventType: "document.restored",
+			EventData: map[string]interface{}{
+				"document_id":           documentID,
+				"project_id":            projectID,
+				"revision_id":           rev.ID.Hex(),
+				"version":               rev.Version,
+				"restored_from_version": target.Version,
+				"word_count":            wordCount,
+				"user_id":               authorID,
+			},
+			Timestamp: now,
+			Source:    "VersionService",
+		})
 	}
 
+	return rev, nil
+}
+
+// UndoRestore 撤销一次恢复操作：恢复到该次恢复之前的版本（同样记录为新修订）
+func (s *VersionService) UndoRestore(ctx context.Context, documentID, restoreVersionID, authorID string) (*writer.FileRevision, error) {
+	restoreObjectID, err := objectIDFromHex(restoreVersionID, "version")
 	if err != nil {
-		return fmt.Errorf("更新文档内容失败: %w", err)
+		return nil, err
+	}
+
+	var rev writer.FileRevision
+	if err := s.revCol().FindOne(ctx, bson.M{"_id": restoreObjectID, "node_id": documentID}).Decode(&rev); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("版本不存在")
+		}
+		return nil, fmt.Errorf("查询版本失败: %w", err)
+	}
+	if operation, _ := rev.Metadata["operation"].(string); operation != RevisionOperationRestore {
+		return nil, errors.New("该版本不是恢复操作")
+	}
+	previousID, _ := rev.Metadata["previous_revision_id"].(string)
+	if previousID == "" {
+		return nil, errors.New("恢复前没有可回退的版本")
 	}
 
-	// TODO: 创建一个新的版本记录，标记为恢复操作
+	return s.RestoreVersionAsRevision(ctx, documentID, previousID, authorID)
+}
 
+// writeDocumentContent 以乐观锁写入文档内容并同步文档字数，内容被并发修改时返回 version_conflict
+func (s *VersionService) writeDocumentContent(ctx context.Context, documentObjectID primitive.ObjectID, current *writer.DocumentContent, content, contentType string, wordCount, version int, authorID string, now time.Time) error {
+	if current == nil {
+		newContent := &writer.DocumentContent{
+			DocumentID:   documentObjectID,
+			Content:      content,
+			ContentType:  contentType,
+			WordCount:    wordCount,
+			CharCount:    len(content),
+			Version:      version,
+			LastEditedBy: authorID,
+		}
+		newContent.TouchForCreate()
+		if _, err := s.contentCol().InsertOne(ctx, newContent); err != nil {
+			return fmt.Errorf("创建文档内容失败: %w", err)
+		}
+	} else {
+		res, err := s.contentCol().UpdateOne(ctx,
+			bson.M{"_id": current.ID, "version": current.Version},
+			bson.M{"$set": bson.M{
+				"content":        content,
+				"content_type":   contentType,
+				"word_count":     wordCount,
+				"char_count":     len(content),
+				"version":        version,
+				"updated_at":     now,
+				"last_saved_at":  now,
+				"last_edited_by": authorID,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("更新文档内容失败: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			return errors.New("version_conflict")
+		}
+	}
+
+	if _, err := s.docCol().UpdateOne(ctx,
+		bson.M{"_id": documentObjectID},
+		bson.M{"$set": bson.M{"word_count": wordCount, "updated_at": now}},
+	); err != nil {
+		return fmt.Errorf("更新文档字数失败: %w", err)
+	}
 	return nil
 }
 
-// splitLines 将文本按行分割
-func splitLines(text string) []string {
-	if text == "" {
-		return []string{}
+// SaveDocumentSnapshot 保存文档的完整内容并记录为一条修订（用于协作编辑等由服务端合并出内容的场景）
+// 内容与当前一致时不产生修订，返回 nil
+func (s *VersionService) SaveDocumentSnapshot(ctx context.Context, documentID, authorID, content, contentType, message string) (*writer.FileRevision, error) {
+	documentObjectID, err := objectIDFromHex(documentID, "document")
+	if err != nil {
+		return nil, err
+	}
+
+	var doc writer.Document
+	if err := s.docCol().FindOne(ctx, bson.M{"_id": documentObjectID}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("文档不存在")
+		}
+		return nil, fmt.Errorf("获取文档失败: %w", err)
+	}
+	projectID := doc.ProjectID.Hex()
+
+	current, err := s.getDocumentContent(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Content == content && current.ContentType == contentType {
+		return nil, nil
+	}
+
+	latest, _, err := s.loadLatestDocumentRevision(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	next, parent := 0, 0
+	if current != nil {
+		next = current.Version
+	}
+	if latest != nil {
+		parent = latest.Version
+		if latest.Version > next {
+			next = latest.Version
+		}
+	}
+	next++
+
+	now := time.Now()
+	wordCount := document.CountWords(content, contentType)
+	snapshot, storageRef, err := s.StoreSnapshot(content, projectID, documentID, next)
+	if err != nil {
+		return nil, err
+	}
+	rev := &writer.FileRevision{
+		ID:         primitive.NewObjectID(),
+		ProjectID:  projectID,
+		NodeID:     documentID,
+		Version:    next,
+		AuthorID:   authorID,
+		Message:    message,
+		Snapshot:   snapshot,
+		StorageRef: storageRef,
+		ParentVers: parent,
+		Metadata: map[string]interface{}{
+			"operation":  RevisionOperationCollab,
+			"word_count": wordCount,
+		},
+		CreatedAt: now,
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := s.writeDocumentContent(sessCtx, documentObjectID, current, content, contentType, wordCount, next, authorID, now); err != nil {
+			return nil, err
+		}
+		if _, err := s.revCol().InsertOne(sessCtx, rev); err != nil {
+			return nil, fmt.Errorf("创建修订失败: %w", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(ctx, &serviceBase.BaseEvent{
+			EventType: "document.content_updated",
+			EventData: map[string]interface{}{
+				"document_id":         documentID,
+				"project_id":          projectID,
+				"revision_id":         rev.ID.Hex(),
+				"version":             rev.Version,
+				"word_count":          wordCount,
+				"previous_word_count": doc.WordCount,
+				"user_id":             authorID,
+			},
+			Timestamp: now,
+			Source:    "VersionService",
+		})
 	}
-	lines := []string{}
-	currentLine := ""
-	for _, ch := range text {
-		if ch == '\n' {
-			lines = append(lines, currentLine)
-			currentLine = ""
-		} else {
-			currentLine += string(ch)
+	return rev, nil
+}
+
+// loadLatestDocumentRevision 获取文档最新修订及其内容，不存在时返回 nil
+func (s *VersionService) loadLatestDocumentRevision(ctx context.Context, documentID string) (*writer.FileRevision, string, error) {
+	var rev writer.FileRevision
+	err := s.revCol().FindOne(ctx,
+		bson.M{"node_id": documentID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+	).Decode(&rev)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, "", nil
 		}
+		return nil, "", fmt.Errorf("查询最新版本失败: %w", err)
+	}
+	content, err := s.RetrieveSnapshot(rev.Snapshot, rev.StorageRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("获取版本内容失败: %w", err)
 	}
-	if currentLine != "" {
-		lines = append(lines, currentLine)
+	return &rev, content, nil
+}
+
+// metadataInt 读取修订元数据中的整数字段（兼容 BSON 解码出的各种数值类型）
+func metadataInt(metadata map[string]interface{}, key string) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
 	}
-	return lines
 }
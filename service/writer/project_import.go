@@ -0,0 +1,155 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/utils"
+	"Qingyu_backend/service/writer/document"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// projectImportTypes 导入文档按层级使用的类型：卷、章、节、场景
+var projectImportTypes = []string{writer.TypeVolume, writer.TypeChapter, writer.TypeSection, writer.TypeScene}
+
+// projectImportTree 按 ZIP 目录结构构建待写入的文档树
+type projectImportTree struct {
+	projectID primitive.ObjectID
+	docs      []*writer.Document // 写入顺序，父文档在子文档之前
+	contents  map[primitive.ObjectID]string
+	folders   map[string]*writer.Document
+	lastKeys  map[primitive.ObjectID]string // 各父文档下最后一个子文档的排序键
+	counts    map[primitive.ObjectID]int
+}
+
+func newProjectImportTree(projectID primitive.ObjectID) *projectImportTree {
+	return &projectImportTree{
+		projectID: projectID,
+		contents:  make(map[primitive.ObjectID]string),
+		folders:   make(map[string]*writer.Document),
+		lastKeys:  make(map[primitive.ObjectID]string),
+		counts:    make(map[primitive.ObjectID]int),
+	}
+}
+
+// folder 返回目录对应的文档，不存在时连同上级目录一起创建
+func (t *projectImportTree) folder(dir string) *writer.Document {
+	if dir == "" {
+		return nil
+	}
+	if doc, ok := t.folders[dir]; ok {
+		return doc
+	}
+
+	parentDir, name := path.Split(dir)
+	parent := t.folder(strings.TrimSuffix(parentDir, "/"))
+	doc := t.add(parent, name, true)
+	t.folders[dir] = doc
+	return doc
+}
+
+// file 添加文件对应的文档；导出时有子文档的文档正文写在同名目录内，导入时还原为目录文档的正文
+func (t *projectImportTree) file(dir, title, content string) {
+	parent := t.folder(dir)
+	if parent != nil && parent.Title == title {
+		if _, exists := t.contents[parent.ID]; !exists {
+			t.contents[parent.ID] = content
+			return
+		}
+	}
+
+	doc := t.add(parent, title, false)
+	t.contents[doc.ID] = content
+}
+
+func (t *projectImportTree) add(parent *writer.Document, title string, isFolder bool) *writer.Document {
+	parentID, level := primitive.NilObjectID, 0
+	if parent != nil {
+		parentID, level = parent.ID, parent.Level+1
+	}
+
+	// 根目录为卷，根目录下的文件与卷内文档为章，更深层依次为节、场景
+	typeIndex := level
+	if !isFolder || level > 0 {
+		typeIndex = min(max(level, 1), len(projectImportTypes)-1)
+	}
+
+	key := utils.GenerateSiblingOrderKey(t.lastKeys[parentID])
+	t.lastKeys[parentID] = key
+	doc := newImportedDocument(t.projectID, truncateRunes(title, 200), projectImportTypes[typeIndex], key, t.counts[parentID], 0)
+	doc.ParentID = parentID
+	doc.Level = level
+	t.counts[parentID]++
+	t.docs = append(t.docs, doc)
+	return doc
+}
+
+// writeImportedProject 依次写入项目、文档和内容，最后更新项目统计
+func (s *ExportService) writeImportedProject(
+	ctx context.Context,
+	job *manuscriptImportJob,
+	project *writer.Project,
+	tree *projectImportTree,
+	userID string,
+) error {
+	if err := s.manuscriptRepo.CreateProject(ctx, project); err != nil {
+		return fmt.Errorf("创建项目失败: %w", err)
+	}
+	job.projectCreated = project.ID.Hex()
+
+	counter := document.NewWordCountService()
+	stats := project.Statistics
+	for _, doc := range tree.docs {
+		content, hasContent := tree.contents[doc.ID]
+		text := ""
+		if hasContent {
+			text = tiptapToPlainText(content)
+			doc.WordCount = counter.CalculateWordCount(text).TotalCount
+			if doc.WordCount > 0 {
+				doc.Status = writer.DocumentStatusCompleted
+			}
+		}
+
+		if err := s.manuscriptRepo.CreateDocument(ctx, doc); err != nil {
+			return fmt.Errorf("创建文档《%s》失败: %w", doc.Title, err)
+		}
+		job.documents = append(job.documents, doc.ID.Hex())
+		stats.DocumentCount++
+		stats.TotalWords += doc.WordCount
+		if doc.Type == writer.TypeChapter {
+			stats.ChapterCount++
+		}
+
+		if !hasContent {
+			continue
+		}
+
+		// 内容ID与文档ID一致，导出时可按文档ID直接读取
+		docContent := &writer.DocumentContent{
+			ID:           doc.ID,
+			DocumentID:   doc.ID,
+			Content:      content,
+			ContentType:  "tiptap_json",
+			WordCount:    doc.WordCount,
+			CharCount:    utf8.RuneCountInString(text),
+			Version:      1,
+			LastEditedBy: userID,
+		}
+		docContent.TouchForCreate()
+		if err := s.manuscriptRepo.CreateDocumentContent(ctx, docContent); err != nil {
+			return fmt.Errorf("保存文档《%s》内容失败: %w", doc.Title, err)
+		}
+		job.contents = append(job.contents, docContent.ID.Hex())
+	}
+
+	stats.LastUpdateAt = time.Now()
+	if err := s.manuscriptRepo.UpdateProjectStatistics(ctx, project.ID.Hex(), stats); err != nil {
+		return fmt.Errorf("更新项目统计失败: %w", err)
+	}
+	return nil
+}
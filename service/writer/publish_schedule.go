@@ -0,0 +1,173 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"Qingyu_backend/models/dto"
+	"Qingyu_backend/pkg/errors"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+const (
+	// defaultDripIntervalHours 逐章定时发布的默认间隔（每日一更）
+	defaultDripIntervalHours = 24
+	// scheduledClaimTimeout 认领后超过该时长仍未完成的定时发布视为实例中断，允许重新认领
+	scheduledClaimTimeout = 10 * time.Minute
+)
+
+// dripPublishTime 计算逐章定时发布计划中第 index 章的发布时间
+func dripPublishTime(drip *dto.DripSchedule, index int) *time.Time {
+	interval := drip.IntervalHours
+	if interval <= 0 {
+		interval = defaultDripIntervalHours
+	}
+	perRelease := drip.ChaptersPerRelease
+	if perRelease <= 0 {
+		perRelease = 1
+	}
+	t := drip.StartAt.Add(time.Duration(index/perRelease*interval) * time.Hour)
+	return &t
+}
+
+// isSchedulable 记录是否仍可修改或取消定时发布
+func isSchedulable(record *serviceInterfaces.PublicationRecord) bool {
+	if record.ScheduledTime == nil {
+		return false
+	}
+	return record.Status == serviceInterfaces.PublicationStatusPending ||
+		record.Status == serviceInterfaces.PublicationStatusScheduled
+}
+
+// GetScheduledPublications 获取项目下尚未发布的定时发布记录，按发布时间升序
+func (s *PublishService) GetScheduledPublications(ctx context.Context, projectID, userID string) ([]*serviceInterfaces.PublicationRecord, error) {
+	project, err := s.projectRepo.FindByID(ctx, projectID)
+	if err != nil {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorNotFound, "项目不存在", "", err)
+	}
+	if !project.IsOwner(userID) {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorForbidden, "无权查看此项目的定时发布", "", nil)
+	}
+
+	records, err := s.publicationRepo.FindScheduledByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorInternal, "查询定时发布失败", "", err)
+	}
+	return records, nil
+}
+
+// ReschedulePublication 修改定时发布时间
+// 待审核的记录只更新发布时间，审核通过后仍按新时间发布
+func (s *PublishService) ReschedulePublication(
+	ctx context.Context,
+	recordID, userID string,
+	req *serviceInterfaces.ReschedulePublicationRequest,
+) (*serviceInterfaces.PublicationRecord, error) {
+	record, err := s.findOwnSchedulableRecord(ctx, recordID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !req.PublishTime.After(now) {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorValidation, "发布时间必须晚于当前时间", "", nil)
+	}
+
+	expectedStatus, expectedUpdatedAt := record.Status, record.UpdatedAt
+	publishTime := req.PublishTime
+	record.ScheduledTime = &publishTime
+	record.UpdatedAt = now
+	if err := s.updateSchedule(ctx, record, expectedStatus, expectedUpdatedAt); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// CancelScheduledPublication 取消尚未执行的定时发布
+func (s *PublishService) CancelScheduledPublication(ctx context.Context, recordID, userID string) error {
+	record, err := s.findOwnSchedulableRecord(ctx, recordID, userID)
+	if err != nil {
+		return err
+	}
+
+	expectedStatus, expectedUpdatedAt := record.Status, record.UpdatedAt
+	record.Status = serviceInterfaces.PublicationStatusCancelled
+	record.UpdatedAt = time.Now()
+	return s.updateSchedule(ctx, record, expectedStatus, expectedUpdatedAt)
+}
+
+func (s *PublishService) findOwnSchedulableRecord(ctx context.Context, recordID, userID string) (*serviceInterfaces.PublicationRecord, error) {
+	record, err := s.publicationRepo.FindByID(ctx, recordID)
+	if err != nil || record == nil {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorNotFound, "发布记录不存在", "", err)
+	}
+	if record.CreatedBy != userID {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorForbidden, "无权修改此定时发布", "", nil)
+	}
+	if !isSchedulable(record) {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorValidation, "当前记录不是待执行的定时发布", "", nil)
+	}
+	return record, nil
+}
+
+// updateSchedule 以读取时的状态为条件写回，避免覆盖调度器已认领的记录
+func (s *PublishService) updateSchedule(
+	ctx context.Context,
+	record *serviceInterfaces.PublicationRecord,
+	expectedStatus string,
+	expectedUpdatedAt time.Time,
+) error {
+	ok, err := s.publicationRepo.UpdateIfUnchanged(ctx, record, expectedStatus, expectedUpdatedAt)
+	if err != nil {
+		return errors.NewServiceError("PublishService", errors.ServiceErrorInternal, "更新定时发布失败", "", err)
+	}
+	if !ok {
+		return errors.NewServiceError("PublishService", errors.ServiceErrorBusiness, "定时发布已开始执行或已被修改，请刷新后重试", "", nil)
+	}
+	return nil
+}
+
+// DispatchDuePublications 发布到期的定时发布记录，最多处理 limit 条
+// 每条记录先以条件更新认领为发布中，只有认领成功的实例执行发布，保证多实例下只发布一次；
+// 认领后中断（超过 scheduledClaimTimeout 未完成）的记录会被重新认领，书城章节按文档ID幂等写入
+func (s *PublishService) DispatchDuePublications(ctx context.Context, now time.Time, limit int) (*dto.ScheduledDispatchResult, error) {
+	records, err := s.publicationRepo.FindDueScheduled(ctx, now, now.Add(-scheduledClaimTimeout), limit)
+	if err != nil {
+		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorInternal, "查询到期定时发布失败", "", err)
+	}
+
+	result := &dto.ScheduledDispatchResult{Due: len(records)}
+	for _, record := range records {
+		claimed, err := s.claimScheduled(ctx, record)
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+		result.Claimed++
+
+		if err := s.executePublication(ctx, record); err != nil {
+			s.failPublication(ctx, record, err.Error())
+		}
+		if record.Status == serviceInterfaces.PublicationStatusPublished {
+			result.Published++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// claimScheduled 将到期记录认领为发布中
+func (s *PublishService) claimScheduled(ctx context.Context, record *serviceInterfaces.PublicationRecord) (bool, error) {
+	expectedStatus, expectedUpdatedAt := record.Status, record.UpdatedAt
+	record.Status = serviceInterfaces.PublicationStatusPublishing
+	record.UpdatedAt = time.Now()
+
+	ok, err := s.publicationRepo.UpdateIfUnchanged(ctx, record, expectedStatus, expectedUpdatedAt)
+	if err != nil {
+		return false, errors.NewServiceError("PublishService", errors.ServiceErrorInternal, "认领定时发布失败", "", err)
+	}
+	return ok, nil
+}
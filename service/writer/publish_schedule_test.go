@@ -0,0 +1,297 @@
+package writer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/dto"
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/models/writer/base"
+	"Qingyu_backend/pkg/distlock"
+	serviceInterfaces "Qingyu_backend/service/interfaces"
+)
+
+// memoryPublicationRepository 内存发布记录仓储，按值保存记录以模拟数据库
+type memoryPublicationRepository struct {
+	stubPublicationRepository
+	mu      sync.Mutex
+	records map[string]serviceInterfaces.PublicationRecord
+}
+
+func newMemoryPublicationRepository(records ...*serviceInterfaces.PublicationRecord) *memoryPublicationRepository {
+	r := &memoryPublicationRepository{records: map[string]serviceInterfaces.PublicationRecord{}}
+	for _, record := range records {
+		r.records[record.ID] = *record
+	}
+	return r
+}
+
+func (r *memoryPublicationRepository) FindByID(ctx context.Context, id string) (*serviceInterfaces.PublicationRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[id]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *memoryPublicationRepository) Update(ctx context.Context, record *serviceInterfaces.PublicationRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.ID] = *record
+	return nil
+}
+
+func (r *memoryPublicationRepository) FindDueScheduled(ctx context.Context, dueBefore, staleBefore time.Time, limit int) ([]*serviceInterfaces.PublicationRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*serviceInterfaces.PublicationRecord
+	for _, record := range r.records {
+		record := record
+		switch {
+		case record.Status == serviceInterfaces.PublicationStatusScheduled && !record.ScheduledTime.After(dueBefore),
+			record.Status == serviceInterfaces.PublicationStatusPublishing && !record.UpdatedAt.After(staleBefore):
+			due = append(due, &record)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ScheduledTime.Before(*due[j].ScheduledTime) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *memoryPublicationRepository) UpdateIfUnchanged(ctx context.Context, record *serviceInterfaces.PublicationRecord, expectedStatus string, expectedUpdatedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.records[record.ID]
+	if !ok || current.Status != expectedStatus || !current.UpdatedAt.Equal(expectedUpdatedAt) {
+		return false, nil
+	}
+	r.records[record.ID] = *record
+	return true, nil
+}
+
+func (r *memoryPublicationRepository) status(id string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.records[id].Status
+}
+
+func newScheduledRecord(documentID string, publishAt time.Time, status string) *serviceInterfaces.PublicationRecord {
+	return &serviceInterfaces.PublicationRecord{
+		ID:            primitive.NewObjectID().Hex(),
+		Type:          "document",
+		ResourceID:    documentID,
+		Status:        status,
+		ScheduledTime: &publishAt,
+		Metadata:      dto.PublicationMetadata{ChapterTitle: "第一百二十章", ChapterNumber: 120},
+		CreatedBy:     "author-1",
+		CreatedAt:     publishAt.Add(-24 * time.Hour),
+		UpdatedAt:     publishAt.Add(-24 * time.Hour),
+	}
+}
+
+func newTestDocumentRepo(ids ...string) *MockDocumentRepositoryForExport {
+	repo := new(MockDocumentRepositoryForExport)
+	projectID := primitive.NewObjectID()
+	for _, id := range ids {
+		objectID, _ := primitive.ObjectIDFromHex(id)
+		repo.On("FindByID", mock.Anything, id).Return(&writer.Document{
+			IdentifiedEntity: base.IdentifiedEntity{ID: objectID},
+			ProjectID:        projectID,
+		}, nil)
+	}
+	return repo
+}
+
+func TestReviewPublication_FutureScheduleWaitsForDispatcher(t *testing.T) {
+	documentID := primitive.NewObjectID().Hex()
+	publishAt := time.Now().Add(time.Hour)
+	record := newScheduledRecord(documentID, publishAt, serviceInterfaces.PublicationStatusPending)
+	repo := newMemoryPublicationRepository(record)
+	bookstoreClient := new(MockBookstoreClient)
+	service := NewPublishService(nil, newTestDocumentRepo(documentID), repo, bookstoreClient, nil).(*PublishService)
+
+	reviewed, err := service.ReviewPublication(context.Background(), record.ID, "admin-1", true, "")
+	require.NoError(t, err)
+	assert.Equal(t, serviceInterfaces.PublicationStatusScheduled, reviewed.Status)
+
+	// 未到发布时间不发布
+	result, err := service.DispatchDuePublications(context.Background(), time.Now(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Due)
+
+	bookstoreClient.On("PublishChapter", mock.Anything, mock.MatchedBy(func(req *BookstorePublishChapterRequest) bool {
+		return req.DocumentID == documentID && req.ChapterNumber == 120
+	})).Return(&BookstorePublishResponse{Success: true, BookstoreID: "local", ExternalID: "chapter-120"}, nil).Once()
+
+	result, err = service.DispatchDuePublications(context.Background(), publishAt, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Published)
+	assert.Equal(t, serviceInterfaces.PublicationStatusPublished, repo.status(record.ID))
+	bookstoreClient.AssertExpectations(t)
+}
+
+func TestDispatchDuePublications_PublishesOnceAcrossInstances(t *testing.T) {
+	now := time.Now()
+	var records []*serviceInterfaces.PublicationRecord
+	var documentIDs []string
+	for i := 0; i < 30; i++ {
+		documentID := primitive.NewObjectID().Hex()
+		documentIDs = append(documentIDs, documentID)
+		records = append(records, newScheduledRecord(documentID, now.Add(-time.Duration(i)*time.Minute), serviceInterfaces.PublicationStatusScheduled))
+	}
+	// 认领后实例中断的记录会被重新认领
+	stale := newScheduledRecord(primitive.NewObjectID().Hex(), now.Add(-time.Hour), serviceInterfaces.PublicationStatusPublishing)
+	stale.UpdatedAt = now.Add(-scheduledClaimTimeout - time.Minute)
+	records = append(records, stale)
+	documentIDs = append(documentIDs, stale.ResourceID)
+	repo := newMemoryPublicationRepository(records...)
+
+	var published int32
+	newInstance := func() *PublishService {
+		bookstoreClient := new(MockBookstoreClient)
+		bookstoreClient.On("PublishChapter", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { atomic.AddInt32(&published, 1) }).
+			Return(&BookstorePublishResponse{Success: true}, nil)
+		return NewPublishService(nil, newTestDocumentRepo(documentIDs...), repo, bookstoreClient, nil).(*PublishService)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		service := newInstance()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				result, err := service.DispatchDuePublications(context.Background(), now, 8)
+				assert.NoError(t, err)
+				if result.Due == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(len(records)), atomic.LoadInt32(&published))
+	for _, record := range records {
+		assert.Equal(t, serviceInterfaces.PublicationStatusPublished, repo.status(record.ID))
+	}
+}
+
+func TestRescheduleAndCancelScheduledPublication(t *testing.T) {
+	ctx := context.Background()
+	record := newScheduledRecord(primitive.NewObjectID().Hex(), time.Now().Add(time.Hour), serviceInterfaces.PublicationStatusScheduled)
+	repo := newMemoryPublicationRepository(record)
+	service := NewPublishService(nil, nil, repo, nil, nil).(*PublishService)
+
+	_, err := service.ReschedulePublication(ctx, record.ID, "author-1", &serviceInterfaces.ReschedulePublicationRequest{PublishTime: time.Now().Add(-time.Minute)})
+	assert.Error(t, err)
+	_, err = service.ReschedulePublication(ctx, record.ID, "other", &serviceInterfaces.ReschedulePublicationRequest{PublishTime: time.Now().Add(time.Hour)})
+	assert.Error(t, err)
+
+	friday := time.Now().Add(72 * time.Hour)
+	updated, err := service.ReschedulePublication(ctx, record.ID, "author-1", &serviceInterfaces.ReschedulePublicationRequest{PublishTime: friday})
+	require.NoError(t, err)
+	assert.True(t, updated.ScheduledTime.Equal(friday))
+	assert.Equal(t, serviceInterfaces.PublicationStatusScheduled, updated.Status)
+
+	require.NoError(t, service.CancelScheduledPublication(ctx, record.ID, "author-1"))
+	assert.Equal(t, serviceInterfaces.PublicationStatusCancelled, repo.status(record.ID))
+	assert.Error(t, service.CancelScheduledPublication(ctx, record.ID, "author-1"))
+
+	// 调度器已认领的记录不能再取消
+	claimed := newScheduledRecord(primitive.NewObjectID().Hex(), time.Now().Add(-time.Minute), serviceInterfaces.PublicationStatusScheduled)
+	require.NoError(t, repo.Update(ctx, claimed))
+	stale := *claimed
+	ok, err := service.claimScheduled(ctx, claimed)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.UpdateIfUnchanged(ctx, &stale, stale.Status, stale.UpdatedAt)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Error(t, service.CancelScheduledPublication(ctx, claimed.ID, "author-1"))
+}
+
+func TestDripPublishTime(t *testing.T) {
+	start := time.Date(2026, 10, 16, 20, 0, 0, 0, time.Local)
+
+	daily := &dto.DripSchedule{StartAt: start}
+	assert.Equal(t, start, *dripPublishTime(daily, 0))
+	assert.Equal(t, start.Add(48*time.Hour), *dripPublishTime(daily, 2))
+
+	twoPerRelease := &dto.DripSchedule{StartAt: start, IntervalHours: 12, ChaptersPerRelease: 2}
+	assert.Equal(t, start, *dripPublishTime(twoPerRelease, 1))
+	assert.Equal(t, start.Add(12*time.Hour), *dripPublishTime(twoPerRelease, 2))
+	assert.Equal(t, start.Add(24*time.Hour), *dripPublishTime(twoPerRelease, 5))
+}
+
+// blockingDispatcher 在第一批调度时阻塞，用于验证调度互斥
+type blockingDispatcher struct {
+	calls   int32
+	due     []int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDispatcher) DispatchDuePublications(ctx context.Context, now time.Time, limit int) (*dto.ScheduledDispatchResult, error) {
+	n := atomic.AddInt32(&d.calls, 1)
+	if n == 1 && d.started != nil {
+		close(d.started)
+		<-d.release
+	}
+	due := 0
+	if int(n) <= len(d.due) {
+		due = d.due[n-1]
+	}
+	return &dto.ScheduledDispatchResult{Due: due, Claimed: due, Published: due}, nil
+}
+
+func TestPublishScheduler_RunIsExclusiveAndDrainsBacklog(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	lock := distlock.NewRedisLockService(client, "distlock")
+
+	dispatcher := &blockingDispatcher{
+		due:     []int{publishSchedulerBatchSize, publishSchedulerBatchSize, 3},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	first := NewPublishScheduler(dispatcher, lock, nil)
+	second := NewPublishScheduler(dispatcher, lock, nil)
+
+	done := make(chan *dto.ScheduledDispatchResult)
+	go func() {
+		result, err := first.Run(context.Background())
+		assert.NoError(t, err)
+		done <- result
+	}()
+	<-dispatcher.started
+
+	// 其他实例持有锁时跳过本轮调度
+	result, err := second.Run(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	close(dispatcher.release)
+	result = <-done
+	require.NotNil(t, result)
+	assert.Equal(t, 2*publishSchedulerBatchSize+3, result.Published)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&dispatcher.calls))
+	assert.Empty(t, mr.Keys())
+}
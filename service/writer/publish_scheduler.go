@@ -0,0 +1,137 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"Qingyu_backend/models/dto"
+	"Qingyu_backend/pkg/distlock"
+)
+
+const (
+	// publishSchedulerLockKey 定时发布调度的分布式锁键，同一时刻只有一个实例执行调度
+	publishSchedulerLockKey = "writer:publish-scheduler"
+	publishSchedulerLockTTL = 5 * time.Minute
+	// publishSchedulerBatchSize 每批处理的到期记录数
+	publishSchedulerBatchSize = 50
+)
+
+// ScheduledPublicationDispatcher 定时发布执行器
+type ScheduledPublicationDispatcher interface {
+	DispatchDuePublications(ctx context.Context, now time.Time, limit int) (*dto.ScheduledDispatchResult, error)
+}
+
+// PublishScheduler 定时发布调度器
+// 每分钟整点检查到期的定时发布；启动时先补发停机期间错过的记录
+type PublishScheduler struct {
+	dispatcher ScheduledPublicationDispatcher
+	lock       *distlock.RedisLockService // 为 nil 时按单实例运行
+	cron       *cron.Cron
+	logger     *zap.Logger
+	batchSize  int
+}
+
+// NewPublishScheduler 创建定时发布调度器
+func NewPublishScheduler(dispatcher ScheduledPublicationDispatcher, lock *distlock.RedisLockService, logger *zap.Logger) *PublishScheduler {
+	if logger == nil {
+		logger = zap.L()
+	}
+	return &PublishScheduler{
+		dispatcher: dispatcher,
+		lock:       lock,
+		cron:       cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		logger:     logger,
+		batchSize:  publishSchedulerBatchSize,
+	}
+}
+
+// Start 启动调度器
+func (s *PublishScheduler) Start() error {
+	_, err := s.cron.AddFunc("0 * * * * *", s.dispatchDue)
+	if err != nil {
+		return fmt.Errorf("failed to add publish schedule job: %w", err)
+	}
+
+	s.cron.Start()
+	s.logger.Info("Publish scheduler started")
+
+	// 补发停机期间错过的定时发布
+	go s.catchUp()
+	return nil
+}
+
+// Stop 停止调度器
+func (s *PublishScheduler) Stop() {
+	s.cron.Stop()
+	s.logger.Info("Publish scheduler stopped")
+}
+
+func (s *PublishScheduler) dispatchDue() {
+	result, err := s.Run(context.Background())
+	if err != nil {
+		s.logger.Error("Failed to dispatch scheduled publications", zap.Error(err))
+		return
+	}
+	if result != nil && result.Claimed > 0 {
+		s.logger.Info("Dispatched scheduled publications", zap.Int("published", result.Published), zap.Int("failed", result.Failed))
+	}
+}
+
+func (s *PublishScheduler) catchUp() {
+	result, err := s.Run(context.Background())
+	if err != nil {
+		s.logger.Error("Failed to catch up scheduled publications", zap.Error(err))
+		return
+	}
+	if result != nil && result.Claimed > 0 {
+		s.logger.Info("Caught up overdue scheduled publications", zap.Int("published", result.Published), zap.Int("failed", result.Failed))
+	}
+}
+
+// Run 在分布式锁保护下分批发布所有到期记录，直到没有积压
+// 其他实例正在调度时直接返回 nil
+func (s *PublishScheduler) Run(ctx context.Context) (*dto.ScheduledDispatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, publishSchedulerLockTTL)
+	defer cancel()
+
+	var lockID string
+	if s.lock != nil {
+		id, err := s.lock.Acquire(ctx, publishSchedulerLockKey, publishSchedulerLockTTL)
+		if errors.Is(err, distlock.ErrLockAcquisitionFailed) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		lockID = id
+		defer s.lock.Release(context.Background(), publishSchedulerLockKey, lockID)
+	}
+
+	total := &dto.ScheduledDispatchResult{}
+	for {
+		result, err := s.dispatcher.DispatchDuePublications(ctx, time.Now(), s.batchSize)
+		if result != nil {
+			total.Due += result.Due
+			total.Claimed += result.Claimed
+			total.Published += result.Published
+			total.Failed += result.Failed
+		}
+		if err != nil {
+			return total, err
+		}
+		// 本批未满或无法认领任何记录时说明积压已处理完
+		if result.Due < s.batchSize || result.Claimed == 0 {
+			return total, nil
+		}
+		if s.lock != nil {
+			if err := s.lock.Extend(ctx, publishSchedulerLockKey, lockID, publishSchedulerLockTTL); err != nil {
+				return total, err
+			}
+		}
+	}
+}
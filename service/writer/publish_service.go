@@ -22,6 +22,8 @@ type PublishService struct {
 	publicationRepo PublicationRepository
 	bookstoreClient BookstoreClient // 书城客户端接口
 	eventBus        EventBus
+
+	continuityService serviceInterfaces.ContinuityService // 可选，发布前连贯性检查
 }
 
 // isValidProjectID 验证项目ID格式
@@ -45,6 +47,9 @@ type PublicationRepository interface {
 	Update(ctx context.Context, record *serviceInterfaces.PublicationRecord) error
 	Delete(ctx context.Context, id string) error
 	FindPublishedByProjectID(ctx context.Context, projectID string) (*serviceInterfaces.PublicationRecord, error)
+	FindScheduledByProjectID(ctx context.Context, projectID string) ([]*serviceInterfaces.PublicationRecord, error)
+	FindDueScheduled(ctx context.Context, dueBefore, staleBefore time.Time, limit int) ([]*serviceInterfaces.PublicationRecord, error)
+	UpdateIfUnchanged(ctx context.Context, record *serviceInterfaces.PublicationRecord, expectedStatus string, expectedUpdatedAt time.Time) (bool, error)
 }
 
 // BookstoreClient 书城客户端接口
@@ -125,6 +130,11 @@ func NewPublishService(
 	}
 }
 
+// SetContinuityService 设置连贯性检查服务（请求 checkContinuity 时在发布前执行）
+func (s *PublishService) SetContinuityService(continuityService serviceInterfaces.ContinuityService) {
+	s.continuityService = continuityService
+}
+
 // PublishProject 发布项目到书城
 func (s *PublishService) PublishProject(
 	ctx context.Context,
@@ -148,6 +158,18 @@ func (s *PublishService) PublishProject(
 		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorValidation, "项目已发布，请先取消发布", "", nil)
 	}
 
+	// 发布前连贯性检查
+	if req.CheckContinuity && s.continuityService != nil {
+		report, err := s.continuityService.CheckProject(ctx, projectID)
+		if err != nil {
+			return nil, errors.NewServiceError("PublishService", errors.ServiceErrorInternal, "连贯性检查失败", "", err)
+		}
+		if report.HasErrors() {
+			return nil, errors.NewServiceError("PublishService", errors.ServiceErrorValidation,
+				fmt.Sprintf("存在%d处连贯性错误，请修正后再发布", report.ErrorCount), report.Issues[0].Message, nil)
+		}
+	}
+
 	// 创建发布记录
 	record := &serviceInterfaces.PublicationRecord{
 		ID:            primitive.NewObjectID().Hex(),
@@ -592,11 +614,16 @@ func (s *PublishService) BatchPublishDocuments(
 			chapterNumber = 0
 		}
 
+		publishTime := req.PublishTime
+		if req.Drip != nil {
+			publishTime = dripPublishTime(req.Drip, i)
+		}
+
 		publishReq := &serviceInterfaces.PublishDocumentRequest{
 			ChapterTitle:  "Chapter " + string(rune(i+1)), // 实际应该从文档标题获取
 			ChapterNumber: chapterNumber,
 			IsFree:        req.IsFree,
-			PublishTime:   req.PublishTime,
+			PublishTime:   publishTime,
 		}
 
 		record, err := s.PublishDocument(ctx, docID, projectID, userID, publishReq)
@@ -688,11 +715,33 @@ func (s *PublishService) ReviewPublication(
 		return record, nil
 	}
 
+	// 指定了未来发布时间的记录转入定时发布，由调度器到点发布
+	if record.ScheduledTime != nil && record.ScheduledTime.After(now) {
+		record.Status = serviceInterfaces.PublicationStatusScheduled
+		if err := s.publicationRepo.Update(ctx, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+
+	if err := s.executePublication(ctx, record); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.publicationRepo.FindByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// executePublication 按记录类型执行真正发布，结果写回发布记录
+func (s *PublishService) executePublication(ctx context.Context, record *serviceInterfaces.PublicationRecord) error {
 	switch record.Type {
 	case "project":
 		project, err := s.projectRepo.FindByID(ctx, record.ResourceID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		req := &serviceInterfaces.PublishProjectRequest{
 			BookstoreID:   record.BookstoreID,
@@ -714,7 +763,7 @@ func (s *PublishService) ReviewPublication(
 	case "document":
 		document, err := s.documentRepo.FindByID(ctx, record.ResourceID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		req := &serviceInterfaces.PublishDocumentRequest{
 			ChapterTitle:  record.Metadata.ChapterTitle,
@@ -724,14 +773,9 @@ func (s *PublishService) ReviewPublication(
 		}
 		s.executeDocumentPublish(ctx, record, document, req)
 	default:
-		return nil, errors.NewServiceError("PublishService", errors.ServiceErrorValidation, "未知发布类型", "", nil)
-	}
-
-	updated, err := s.publicationRepo.FindByID(ctx, recordID)
-	if err != nil {
-		return nil, err
+		return errors.NewServiceError("PublishService", errors.ServiceErrorValidation, "未知发布类型", "", nil)
 	}
-	return updated, nil
+	return nil
 }
 
 func (s *PublishService) publishEventWithAudit(ctx context.Context, record *serviceInterfaces.PublicationRecord, event interface{}, eventName string) {
@@ -41,6 +41,15 @@ func (s *stubPublicationRepository) Delete(ctx context.Context, id string) error
 func (s *stubPublicationRepository) FindPublishedByProjectID(ctx context.Context, projectID string) (*serviceInterfaces.PublicationRecord, error) {
 	return nil, errors.New("not implemented")
 }
+func (s *stubPublicationRepository) FindScheduledByProjectID(ctx context.Context, projectID string) ([]*serviceInterfaces.PublicationRecord, error) {
+	return nil, nil
+}
+func (s *stubPublicationRepository) FindDueScheduled(ctx context.Context, dueBefore, staleBefore time.Time, limit int) ([]*serviceInterfaces.PublicationRecord, error) {
+	return nil, nil
+}
+func (s *stubPublicationRepository) UpdateIfUnchanged(ctx context.Context, record *serviceInterfaces.PublicationRecord, expectedStatus string, expectedUpdatedAt time.Time) (bool, error) {
+	return true, s.Update(ctx, record)
+}
 
 type stubEventBus struct {
 	mock.Mock
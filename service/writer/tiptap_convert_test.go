@@ -0,0 +1,185 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// richTipTapDoc 覆盖常见节点和样式的文档
+const richTipTapDoc = `{"type":"doc","content":[
+	{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"第一幕"}]},
+	{"type":"paragraph","content":[
+		{"type":"text","text":"他"},
+		{"type":"text","marks":[{"type":"bold"}],"text":"猛地"},
+		{"type":"text","marks":[{"type":"bold"},{"type":"italic"}],"text":"回头"},
+		{"type":"text","marks":[{"type":"italic"}],"text":"，看见"},
+		{"type":"text","marks":[{"type":"strike"}],"text":"一只"},
+		{"type":"text","marks":[{"type":"underline"}],"text":"黑猫"},
+		{"type":"text","text":"说 "},
+		{"type":"text","marks":[{"type":"code"}],"text":"a*b"},
+		{"type":"text","text":" 和 "},
+		{"type":"text","marks":[{"type":"link","attrs":{"href":"https://example.com/a b"}}],"text":"链接"},
+		{"type":"hardBreak"},
+		{"type":"text","text":"第二行 *不是强调* [也不是链接]"}
+	]},
+	{"type":"paragraph","content":[{"type":"text","text":"1. 这不是列表"}]},
+	{"type":"paragraph","content":[{"type":"text","text":"# 这也不是标题"}]},
+	{"type":"blockquote","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"引用第一段"}]},
+		{"type":"paragraph","content":[{"type":"text","marks":[{"type":"highlight"}],"text":"引用第二段"}]}
+	]},
+	{"type":"horizontalRule"},
+	{"type":"bulletList","content":[
+		{"type":"listItem","content":[
+			{"type":"paragraph","content":[{"type":"text","text":"线索一"}]},
+			{"type":"orderedList","attrs":{"start":3},"content":[
+				{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"细节甲"}]}]},
+				{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"细节乙"}]}]}
+			]}
+		]},
+		{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"线索二"}]}]}
+	]},
+	{"type":"codeBlock","content":[{"type":"text","text":"line 1\n  line 2"}]}
+]}`
+
+// normalizeTipTap 统一 JSON 格式以便比较
+func normalizeTipTap(t *testing.T, content string) string {
+	blocks, ok := parseTipTapNodes(content)
+	require.True(t, ok)
+	return marshalTipTapDoc(blocks)
+}
+
+func TestTipTapToMarkdown_PreservesFormatting(t *testing.T) {
+	md := tiptapToMarkdown(richTipTapDoc)
+
+	assert.Contains(t, md, "## 第一幕\n")
+	assert.Contains(t, md, "他**猛地*回头****，看见*~~一只~~<u>黑猫</u>说 `a*b` 和 [链接](https://example.com/a%20b)\\\n")
+	assert.Contains(t, md, `第二行 \*不是强调\* \[也不是链接\]`)
+	assert.Contains(t, md, "1\\. 这不是列表")
+	assert.Contains(t, md, "\\# 这也不是标题")
+	assert.Contains(t, md, "> 引用第一段\n>\n> ==引用第二段==")
+	assert.Contains(t, md, "\n---\n")
+	assert.Contains(t, md, "- 线索一\n  3. 细节甲\n  4. 细节乙\n- 线索二")
+	assert.Contains(t, md, "```\nline 1\n  line 2\n```")
+}
+
+func TestMarkdownToTipTap_RoundTrip(t *testing.T) {
+	md := tiptapToMarkdown(richTipTapDoc)
+	assert.JSONEq(t, normalizeTipTap(t, richTipTapDoc), markdownToTipTap(md))
+}
+
+func TestMarkdownToTipTap_ParsesCommonMarkdown(t *testing.T) {
+	md := strings.Join([]string{
+		"# 标题 #",
+		"",
+		"第一行",
+		"hello",
+		"world  ",
+		"下一行 __粗体__ 和 _斜体_ 以及 snake_case",
+		"",
+		"* * *",
+		"",
+		"1. 第一项",
+		"",
+		"2. 第二项",
+		"   - 嵌套",
+		"",
+		"```go",
+		"fmt.Println(\"**\")",
+		"```",
+	}, "\n")
+
+	expected := `{"type":"doc","content":[
+		{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"标题"}]},
+		{"type":"paragraph","content":[
+			{"type":"text","text":"第一行hello world"},
+			{"type":"hardBreak"},
+			{"type":"text","text":"下一行 "},
+			{"type":"text","marks":[{"type":"bold"}],"text":"粗体"},
+			{"type":"text","text":" 和 "},
+			{"type":"text","marks":[{"type":"italic"}],"text":"斜体"},
+			{"type":"text","text":" 以及 snake_case"}
+		]},
+		{"type":"horizontalRule"},
+		{"type":"orderedList","content":[
+			{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"第一项"}]}]},
+			{"type":"listItem","content":[
+				{"type":"paragraph","content":[{"type":"text","text":"第二项"}]},
+				{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"嵌套"}]}]}]}
+			]}
+		]},
+		{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"fmt.Println(\"**\")"}]}
+	]}`
+	assert.JSONEq(t, expected, markdownToTipTap(md))
+}
+
+func readDOCXPart(t *testing.T, data []byte, name string) string {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	for _, file := range reader.File {
+		if file.Name == name {
+			rc, err := file.Open()
+			require.NoError(t, err)
+			defer rc.Close()
+			content, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("missing %s", name)
+	return ""
+}
+
+func TestTipTapToDOCX_UsesStylesAndRunProperties(t *testing.T) {
+	data, err := tiptapToDOCX("标题", richTipTapDoc)
+	require.NoError(t, err)
+
+	document := readDOCXPart(t, data, "word/document.xml")
+	assertWellFormedXML(t, "document.xml", document)
+	assert.Contains(t, document, `<w:pStyle w:val="Title"/>`)
+	assert.Contains(t, document, `<w:pStyle w:val="Heading2"/>`)
+	assert.Contains(t, document, `<w:pStyle w:val="Quote"/>`)
+	assert.Contains(t, document, `<w:pStyle w:val="SceneBreak"/>`)
+	assert.Contains(t, document, `<w:rPr><w:b/><w:i/></w:rPr><w:t xml:space="preserve">回头</w:t>`)
+	assert.Contains(t, document, `<w:u w:val="single"/>`)
+	assert.Contains(t, document, `<w:numPr><w:ilvl w:val="1"/>`)
+	assert.Contains(t, document, `<w:hyperlink r:id="rId3"`)
+
+	rels := readDOCXPart(t, data, "word/_rels/document.xml.rels")
+	assert.Contains(t, rels, `Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/a b"`)
+	numbering := readDOCXPart(t, data, "word/numbering.xml")
+	assertWellFormedXML(t, "numbering.xml", numbering)
+	assert.Contains(t, numbering, `<w:startOverride w:val="3"/>`)
+	assertWellFormedXML(t, "styles.xml", readDOCXPart(t, data, "word/styles.xml"))
+}
+
+func TestDOCXToTipTap_RoundTrip(t *testing.T) {
+	data, err := tiptapToDOCX("标题", richTipTapDoc)
+	require.NoError(t, err)
+
+	content, err := docxToTipTap(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, normalizeTipTap(t, richTipTapDoc), content)
+}
+
+func TestImportDocumentContent_StripsExportedTitle(t *testing.T) {
+	md := "# 第一章\n\n正文 **加粗**\n"
+	content, err := importDocumentContent("第一章", "项目/第一章.md", []byte(md))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"doc","content":[{"type":"paragraph","content":[
+		{"type":"text","text":"正文 "},{"type":"text","marks":[{"type":"bold"}],"text":"加粗"}
+	]}]}`, content)
+
+	txt, err := importDocumentContent("第二章", "项目/第二章.txt", []byte("第一段\r\n\r\n第二段"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"doc","content":[
+		{"type":"paragraph","content":[{"type":"text","text":"第一段"}]},
+		{"type":"paragraph","content":[{"type":"text","text":"第二段"}]}
+	]}`, txt)
+}
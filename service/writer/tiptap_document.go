@@ -0,0 +1,150 @@
+package writer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// tiptapRichNode 带属性和样式的 TipTap 节点，导入导出共用
+type tiptapRichNode struct {
+	Type    string                 `json:"type"`
+	Text    string                 `json:"text,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Marks   []tiptapMark           `json:"marks,omitempty"`
+	Content []tiptapRichNode       `json:"content,omitempty"`
+}
+
+// tiptapMark TipTap 行内样式
+type tiptapMark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// parseTipTapNodes 解析 TipTap JSON，返回块级节点列表（doc 根节点会被展开）
+// 内容不是 TipTap JSON 时 ok 为 false
+func parseTipTapNodes(content string) ([]tiptapRichNode, bool) {
+	trimmed := strings.TrimSpace(content)
+	var nodes []tiptapRichNode
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		var root tiptapRichNode
+		if err := json.Unmarshal([]byte(trimmed), &root); err != nil || root.Type == "" {
+			return nil, false
+		}
+		nodes = []tiptapRichNode{root}
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal([]byte(trimmed), &nodes); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	blocks := make([]tiptapRichNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Type == "doc" {
+			blocks = append(blocks, node.Content...)
+			continue
+		}
+		blocks = append(blocks, node)
+	}
+	return blocks, true
+}
+
+// marshalTipTapDoc 将块级节点序列化为 TipTap doc JSON
+func marshalTipTapDoc(blocks []tiptapRichNode) string {
+	if len(blocks) == 0 {
+		blocks = []tiptapRichNode{{Type: "paragraph"}}
+	}
+	data, _ := json.Marshal(tiptapRichNode{Type: "doc", Content: blocks})
+	return string(data)
+}
+
+// plainTextToTipTap 将纯文本按行转换为段落
+func plainTextToTipTap(text string) string {
+	var blocks []tiptapRichNode
+	for _, line := range splitParagraphs(text) {
+		if line == "" {
+			continue
+		}
+		blocks = append(blocks, tiptapRichNode{Type: "paragraph", Content: []tiptapRichNode{{Type: "text", Text: line}}})
+	}
+	return marshalTipTapDoc(blocks)
+}
+
+// tiptapAttrInt 读取数值属性（JSON 数字解码为 float64）
+func tiptapAttrInt(attrs map[string]interface{}, key string, fallback int) int {
+	switch v := attrs[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return fallback
+	}
+}
+
+// tiptapAttrString 读取字符串属性
+func tiptapAttrString(attrs map[string]interface{}, key string) string {
+	v, _ := attrs[key].(string)
+	return v
+}
+
+// tiptapMarkOrder 行内样式的嵌套顺序，链接在最外层、代码在最内层
+var tiptapMarkOrder = map[string]int{
+	"link":        0,
+	"bold":        1,
+	"italic":      2,
+	"strike":      3,
+	"underline":   4,
+	"highlight":   5,
+	"superscript": 6,
+	"subscript":   7,
+	"code":        8,
+}
+
+// sortedTipTapMarks 按嵌套顺序返回已知样式，忽略不支持的样式
+func sortedTipTapMarks(marks []tiptapMark) []tiptapMark {
+	result := make([]tiptapMark, 0, len(marks))
+	for _, mark := range marks {
+		if _, ok := tiptapMarkOrder[mark.Type]; ok {
+			result = append(result, mark)
+		}
+	}
+	for i := 1; i < len(result); i++ {
+		for j := i; j > 0 && tiptapMarkOrder[result[j].Type] < tiptapMarkOrder[result[j-1].Type]; j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
+	return result
+}
+
+func sameTipTapMark(a, b tiptapMark) bool {
+	return a.Type == b.Type && tiptapAttrString(a.Attrs, "href") == tiptapAttrString(b.Attrs, "href")
+}
+
+// appendTipTapText 追加文本节点，与前一个样式相同的文本节点合并
+func appendTipTapText(nodes []tiptapRichNode, text string, marks []tiptapMark) []tiptapRichNode {
+	if text == "" {
+		return nodes
+	}
+	marks = sortedTipTapMarks(marks)
+	if n := len(nodes); n > 0 && nodes[n-1].Type == "text" && len(nodes[n-1].Marks) == len(marks) {
+		same := true
+		for i := range marks {
+			if !sameTipTapMark(nodes[n-1].Marks[i], marks[i]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			nodes[n-1].Text += text
+			return nodes
+		}
+	}
+	node := tiptapRichNode{Type: "text", Text: text}
+	if len(marks) > 0 {
+		node.Marks = append([]tiptapMark(nil), marks...)
+	}
+	return append(nodes, node)
+}
@@ -0,0 +1,898 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// TipTap -> DOCX
+// ============================================================================
+
+// docxMaxListLevel Word 列表最多 9 级（ilvl 0-8）
+const docxMaxListLevel = 8
+
+// docxSceneBreakText 场景分隔段落的文字
+const docxSceneBreakText = "* * *"
+
+// docxWriter 将 TipTap 节点渲染为 WordprocessingML
+type docxWriter struct {
+	body      strings.Builder
+	links     []string       // 超链接地址，按出现顺序分配关系 ID
+	linkIndex map[string]int // 地址 -> links 下标
+	lists     []docxListInstance
+}
+
+// docxListInstance 一个列表实例，对应 numbering.xml 中的一个 w:num
+type docxListInstance struct {
+	ordered bool
+	level   int
+	start   int
+}
+
+// docxParagraphProps 段落属性
+type docxParagraphProps struct {
+	style  string
+	numID  int // 0 表示不是列表项
+	ilvl   int
+	indent int // 左缩进（twip）
+}
+
+// tiptapToDOCX 将 TipTap JSON 转换为 DOCX，标题、引用、代码、列表使用段落样式，行内样式使用 run 属性
+func tiptapToDOCX(title, content string) ([]byte, error) {
+	blocks, ok := parseTipTapNodes(content)
+	if !ok {
+		for _, line := range splitParagraphs(content) {
+			if line != "" {
+				blocks = append(blocks, tiptapRichNode{Type: "paragraph", Content: []tiptapRichNode{{Type: "text", Text: line}}})
+			}
+		}
+	}
+
+	w := &docxWriter{linkIndex: make(map[string]int)}
+	if title != "" {
+		w.paragraph(docxParagraphProps{style: "Title"}, []tiptapRichNode{{Type: "text", Text: title}})
+	}
+	w.blocks(blocks, "")
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypesXML},
+		{"_rels/.rels", docxPackageRelsXML},
+		{"word/_rels/document.xml.rels", w.documentRels()},
+		{"word/document.xml", buildDOCXDocumentXML(w.body.String())},
+		{"word/styles.xml", docxStylesXML},
+		{"word/numbering.xml", w.numberingXML()},
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, file := range files {
+		fileWriter, err := zipWriter.Create(file.name)
+		if err != nil {
+			_ = zipWriter.Close()
+			return nil, err
+		}
+		if _, err := fileWriter.Write([]byte(file.content)); err != nil {
+			_ = zipWriter.Close()
+			return nil, err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blocks 渲染块级节点；style 为引用等容器传递下来的段落样式
+func (w *docxWriter) blocks(nodes []tiptapRichNode, style string) {
+	for _, node := range nodes {
+		switch node.Type {
+		case "paragraph":
+			w.paragraph(docxParagraphProps{style: style}, node.Content)
+		case "heading":
+			level := min(max(tiptapAttrInt(node.Attrs, "level", 1), 1), 6)
+			w.paragraph(docxParagraphProps{style: fmt.Sprintf("Heading%d", level)}, node.Content)
+		case "blockquote":
+			w.blocks(node.Content, "Quote")
+		case "bulletList", "orderedList":
+			w.list(node, 0)
+		case "codeBlock":
+			var code strings.Builder
+			for _, child := range node.Content {
+				code.WriteString(child.Text)
+			}
+			w.paragraph(docxParagraphProps{style: "CodeBlock"}, []tiptapRichNode{{Type: "text", Text: code.String()}})
+		case "horizontalRule":
+			w.paragraph(docxParagraphProps{style: "SceneBreak"}, []tiptapRichNode{{Type: "text", Text: docxSceneBreakText}})
+		case "image":
+			// 图片不嵌入文档
+		default:
+			w.blocks(node.Content, style)
+		}
+	}
+}
+
+// list 渲染列表：列表项首段带编号，其余段落按层级缩进，嵌套列表使用下一级编号
+func (w *docxWriter) list(node tiptapRichNode, level int) {
+	level = min(level, docxMaxListLevel)
+	w.lists = append(w.lists, docxListInstance{
+		ordered: node.Type == "orderedList",
+		level:   level,
+		start:   tiptapAttrInt(node.Attrs, "start", 1),
+	})
+	numID := len(w.lists)
+
+	for _, item := range node.Content {
+		numbered := false
+		for _, child := range item.Content {
+			switch child.Type {
+			case "bulletList", "orderedList":
+				if !numbered {
+					w.paragraph(docxParagraphProps{style: "ListParagraph", numID: numID, ilvl: level}, nil)
+					numbered = true
+				}
+				w.list(child, level+1)
+			case "paragraph", "heading":
+				if !numbered {
+					w.paragraph(docxParagraphProps{style: "ListParagraph", numID: numID, ilvl: level}, child.Content)
+					numbered = true
+				} else {
+					w.paragraph(docxParagraphProps{style: "ListParagraph", indent: docxListIndent(level)}, child.Content)
+				}
+			default:
+				if !numbered {
+					w.paragraph(docxParagraphProps{style: "ListParagraph", numID: numID, ilvl: level}, nil)
+					numbered = true
+				}
+				w.blocks([]tiptapRichNode{child}, "ListParagraph")
+			}
+		}
+		if !numbered {
+			w.paragraph(docxParagraphProps{style: "ListParagraph", numID: numID, ilvl: level}, nil)
+		}
+	}
+}
+
+func docxListIndent(level int) int {
+	return 720 * (level + 1)
+}
+
+func (w *docxWriter) paragraph(props docxParagraphProps, inline []tiptapRichNode) {
+	w.body.WriteString("<w:p>")
+	if props.style != "" || props.numID > 0 || props.indent > 0 {
+		w.body.WriteString("<w:pPr>")
+		if props.style != "" {
+			w.body.WriteString(`<w:pStyle w:val="` + props.style + `"/>`)
+		}
+		if props.numID > 0 {
+			w.body.WriteString(fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, props.ilvl, props.numID))
+		}
+		if props.indent > 0 {
+			w.body.WriteString(fmt.Sprintf(`<w:ind w:left="%d"/>`, props.indent))
+		}
+		w.body.WriteString("</w:pPr>")
+	}
+	w.inline(inline)
+	w.body.WriteString("</w:p>")
+}
+
+func (w *docxWriter) inline(nodes []tiptapRichNode) {
+	for _, node := range nodes {
+		switch node.Type {
+		case "text":
+			run := docxRun(node.Text, node.Marks)
+			href := ""
+			for _, mark := range node.Marks {
+				if mark.Type == "link" {
+					href = tiptapAttrString(mark.Attrs, "href")
+				}
+			}
+			if href == "" {
+				w.body.WriteString(run)
+				continue
+			}
+			w.body.WriteString(`<w:hyperlink r:id="` + w.linkRelID(href) + `" w:history="1">` + run + "</w:hyperlink>")
+		case "hardBreak":
+			w.body.WriteString("<w:r><w:br/></w:r>")
+		default:
+			w.inline(node.Content)
+		}
+	}
+}
+
+func (w *docxWriter) linkRelID(href string) string {
+	index, ok := w.linkIndex[href]
+	if !ok {
+		index = len(w.links)
+		w.links = append(w.links, href)
+		w.linkIndex[href] = index
+	}
+	// rId1、rId2 留给样式和编号
+	return fmt.Sprintf("rId%d", index+3)
+}
+
+// docxRun 生成带 run 属性的文本；换行转为 w:br，制表符转为 w:tab
+func docxRun(text string, marks []tiptapMark) string {
+	var props strings.Builder
+	has := make(map[string]bool, len(marks))
+	for _, mark := range marks {
+		has[mark.Type] = true
+	}
+	// 子元素顺序需符合 CT_RPr 定义
+	switch {
+	case has["code"]:
+		props.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+	case has["link"]:
+		props.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	}
+	if has["bold"] {
+		props.WriteString("<w:b/>")
+	}
+	if has["italic"] {
+		props.WriteString("<w:i/>")
+	}
+	if has["strike"] {
+		props.WriteString("<w:strike/>")
+	}
+	if has["highlight"] {
+		props.WriteString(`<w:highlight w:val="yellow"/>`)
+	}
+	if has["underline"] {
+		props.WriteString(`<w:u w:val="single"/>`)
+	}
+	if has["superscript"] {
+		props.WriteString(`<w:vertAlign w:val="superscript"/>`)
+	} else if has["subscript"] {
+		props.WriteString(`<w:vertAlign w:val="subscript"/>`)
+	}
+
+	var run strings.Builder
+	run.WriteString("<w:r>")
+	if props.Len() > 0 {
+		run.WriteString("<w:rPr>" + props.String() + "</w:rPr>")
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			run.WriteString("<w:br/>")
+		}
+		for j, segment := range strings.Split(line, "\t") {
+			if j > 0 {
+				run.WriteString("<w:tab/>")
+			}
+			if segment != "" {
+				run.WriteString(`<w:t xml:space="preserve">` + xmlEscape(segment) + "</w:t>")
+			}
+		}
+	}
+	run.WriteString("</w:r>")
+	return run.String()
+}
+
+func (w *docxWriter) documentRels() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	b.WriteString(`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for i, href := range w.links {
+		b.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`, i+3, xmlEscape(href)))
+	}
+	b.WriteString("</Relationships>")
+	return b.String()
+}
+
+// numberingXML 生成编号定义：0 为项目符号，1 为数字编号；每个列表实例单独重新计数
+func (w *docxWriter) numberingXML() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+	bullets := []string{"•", "◦", "▪"}
+	for abstractID, ordered := range []bool{false, true} {
+		b.WriteString(fmt.Sprintf(`<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstractID))
+		for level := 0; level <= docxMaxListLevel; level++ {
+			format, text := "bullet", bullets[level%len(bullets)]
+			if ordered {
+				format, text = "decimal", fmt.Sprintf("%%%d.", level+1)
+			}
+			b.WriteString(fmt.Sprintf(
+				`<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				level, format, text, docxListIndent(level)))
+		}
+		b.WriteString("</w:abstractNum>")
+	}
+	for i, list := range w.lists {
+		abstractID := 0
+		if list.ordered {
+			abstractID = 1
+		}
+		b.WriteString(fmt.Sprintf(`<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, i+1, abstractID))
+		if list.ordered {
+			b.WriteString(fmt.Sprintf(`<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, list.level, list.start))
+		}
+		b.WriteString("</w:num>")
+	}
+	b.WriteString("</w:numbering>")
+	return b.String()
+}
+
+func buildDOCXDocumentXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:wpc="http://schemas.microsoft.com/office/word/2010/wordprocessingCanvas"` +
+		` xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"` +
+		` xmlns:o="urn:schemas-microsoft-com:office:office"` +
+		` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"` +
+		` xmlns:m="http://schemas.openxmlformats.org/officeDocument/2006/math"` +
+		` xmlns:v="urn:schemas-microsoft-com:vml"` +
+		` xmlns:wp14="http://schemas.microsoft.com/office/word/2010/wordprocessingDrawing"` +
+		` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"` +
+		` xmlns:w10="urn:schemas-microsoft-com:office:word"` +
+		` xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"` +
+		` xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml"` +
+		` xmlns:wpg="http://schemas.microsoft.com/office/word/2010/wordprocessingGroup"` +
+		` xmlns:wpi="http://schemas.microsoft.com/office/word/2010/wordprocessingInk"` +
+		` xmlns:wne="http://schemas.microsoft.com/office/word/2006/wordml"` +
+		` xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape" mc:Ignorable="w14 wp14">` +
+		`<w:body>` + body + `<w:sectPr/></w:body></w:document>`
+}
+
+const docxContentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+  <Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+  <Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+</Types>`
+
+const docxPackageRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxStylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:docDefaults>
+    <w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:hAnsi="Times New Roman" w:eastAsia="SimSun"/><w:sz w:val="24"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault>
+    <w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="360" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+  </w:docDefaults>
+  <w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+  <w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:jc w:val="center"/><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="44"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="24"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720" w:right="720"/></w:pPr><w:rPr><w:i/><w:color w:val="404040"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="CodeBlock"><w:name w:val="Code Block"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/><w:spacing w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="SceneBreak"><w:name w:val="Scene Break"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:before="240" w:after="240"/><w:jc w:val="center"/></w:pPr></w:style>
+  <w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720"/></w:pPr></w:style>
+  <w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:rPr></w:style>
+  <w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+</w:styles>`
+
+// ============================================================================
+// DOCX -> TipTap
+// ============================================================================
+
+// docxElement 简化的 XML 元素树，名称和属性只保留本地名
+type docxElement struct {
+	Name     string
+	Attr     map[string]string
+	Children []*docxElement
+	Text     string
+}
+
+func (e *docxElement) child(name string) *docxElement {
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func parseDOCXElement(data []byte) (*docxElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := &docxElement{}
+	stack := []*docxElement{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &docxElement{Name: t.Name.Local, Attr: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				el.Attr[attr.Name.Local] = attr.Value
+			}
+			parent.Children = append(parent.Children, el)
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.Text += string(t)
+		}
+	}
+	return root, nil
+}
+
+// docxReader 读取 DOCX 包中的正文、样式、编号和超链接关系
+type docxReader struct {
+	rels       map[string]string // 关系 ID -> 超链接地址
+	styleNames map[string]string // 样式 ID -> 样式名
+	numFormats map[string]map[int]string
+	numStarts  map[string]map[int]int
+}
+
+// docxParagraph 解析后的段落
+type docxParagraph struct {
+	kind    string // paragraph, heading, title, quote, code, sceneBreak, list, listContinuation
+	level   int
+	numID   string
+	ilvl    int
+	ordered bool
+	start   int
+	inline  []tiptapRichNode
+}
+
+// docxToTipTap 将 DOCX 解析为 TipTap JSON
+func docxToTipTap(data []byte) (string, error) {
+	blocks, err := docxToTipTapNodes(data)
+	if err != nil {
+		return "", err
+	}
+	return marshalTipTapDoc(blocks), nil
+}
+
+// docxToTipTapNodes 将 DOCX 解析为块级节点；文档标题（Title 样式）不计入正文
+func docxToTipTapNodes(data []byte) ([]tiptapRichNode, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("无效的 DOCX 文件: %w", err)
+	}
+
+	parts := make(map[string][]byte)
+	for _, file := range reader.File {
+		switch file.Name {
+		case "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels":
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			parts[file.Name] = content
+		}
+	}
+	if parts["word/document.xml"] == nil {
+		return nil, fmt.Errorf("DOCX 缺少 word/document.xml")
+	}
+
+	r := &docxReader{
+		rels:       make(map[string]string),
+		styleNames: make(map[string]string),
+		numFormats: make(map[string]map[int]string),
+		numStarts:  make(map[string]map[int]int),
+	}
+	r.loadRels(parts["word/_rels/document.xml.rels"])
+	r.loadStyles(parts["word/styles.xml"])
+	r.loadNumbering(parts["word/numbering.xml"])
+
+	document, err := parseDOCXElement(parts["word/document.xml"])
+	if err != nil {
+		return nil, fmt.Errorf("解析 DOCX 正文失败: %w", err)
+	}
+	var body *docxElement
+	if root := document.child("document"); root != nil {
+		body = root.child("body")
+	}
+	if body == nil {
+		return nil, fmt.Errorf("DOCX 缺少正文")
+	}
+
+	var paragraphs []docxParagraph
+	r.collectParagraphs(body, &paragraphs)
+	return assembleDOCXBlocks(paragraphs), nil
+}
+
+func (r *docxReader) loadRels(data []byte) {
+	if data == nil {
+		return
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if xml.Unmarshal(data, &rels) != nil {
+		return
+	}
+	for _, rel := range rels.Items {
+		if strings.HasSuffix(rel.Type, "/hyperlink") {
+			r.rels[rel.ID] = rel.Target
+		}
+	}
+}
+
+func (r *docxReader) loadStyles(data []byte) {
+	if data == nil {
+		return
+	}
+	var styles struct {
+		Items []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+		} `xml:"style"`
+	}
+	if xml.Unmarshal(data, &styles) != nil {
+		return
+	}
+	for _, style := range styles.Items {
+		r.styleNames[style.ID] = style.Name.Val
+	}
+}
+
+func (r *docxReader) loadNumbering(data []byte) {
+	if data == nil {
+		return
+	}
+	type level struct {
+		Ilvl  int `xml:"ilvl,attr"`
+		Start struct {
+			Val string `xml:"val,attr"`
+		} `xml:"start"`
+		NumFmt struct {
+			Val string `xml:"val,attr"`
+		} `xml:"numFmt"`
+	}
+	var numbering struct {
+		Abstracts []struct {
+			ID     string  `xml:"abstractNumId,attr"`
+			Levels []level `xml:"lvl"`
+		} `xml:"abstractNum"`
+		Nums []struct {
+			ID       string `xml:"numId,attr"`
+			Abstract struct {
+				Val string `xml:"val,attr"`
+			} `xml:"abstractNumId"`
+			Overrides []struct {
+				Ilvl  int `xml:"ilvl,attr"`
+				Start struct {
+					Val string `xml:"val,attr"`
+				} `xml:"startOverride"`
+			} `xml:"lvlOverride"`
+		} `xml:"num"`
+	}
+	if xml.Unmarshal(data, &numbering) != nil {
+		return
+	}
+
+	abstracts := make(map[string][]level)
+	for _, abstract := range numbering.Abstracts {
+		abstracts[abstract.ID] = abstract.Levels
+	}
+	for _, num := range numbering.Nums {
+		formats, starts := make(map[int]string), make(map[int]int)
+		for _, lvl := range abstracts[num.Abstract.Val] {
+			formats[lvl.Ilvl] = lvl.NumFmt.Val
+			if n, err := strconv.Atoi(lvl.Start.Val); err == nil {
+				starts[lvl.Ilvl] = n
+			}
+		}
+		for _, override := range num.Overrides {
+			if n, err := strconv.Atoi(override.Start.Val); err == nil {
+				starts[override.Ilvl] = n
+			}
+		}
+		r.numFormats[num.ID] = formats
+		r.numStarts[num.ID] = starts
+	}
+}
+
+// collectParagraphs 按顺序收集段落，表格单元格中的段落展开处理
+func (r *docxReader) collectParagraphs(el *docxElement, out *[]docxParagraph) {
+	for _, child := range el.Children {
+		switch child.Name {
+		case "p":
+			*out = append(*out, r.paragraph(child))
+		case "tbl", "tr", "tc", "sdt", "sdtContent", "customXml":
+			r.collectParagraphs(child, out)
+		}
+	}
+}
+
+func (r *docxReader) paragraph(p *docxElement) docxParagraph {
+	para := docxParagraph{kind: "paragraph", inline: r.inline(p, nil, nil)}
+
+	styleID := ""
+	if pPr := p.child("pPr"); pPr != nil {
+		if style := pPr.child("pStyle"); style != nil {
+			styleID = style.Attr["val"]
+		}
+		if numPr := pPr.child("numPr"); numPr != nil {
+			if numID := numPr.child("numId"); numID != nil && numID.Attr["val"] != "0" {
+				para.numID = numID.Attr["val"]
+			}
+			if ilvl := numPr.child("ilvl"); ilvl != nil {
+				para.ilvl, _ = strconv.Atoi(ilvl.Attr["val"])
+			}
+		}
+	}
+
+	name := r.styleNames[styleID]
+	if name == "" {
+		name = styleID
+	}
+	name = strings.ToLower(strings.ReplaceAll(name, " ", ""))
+
+	switch {
+	case para.numID != "":
+		para.kind = "list"
+		format := r.numFormats[para.numID][para.ilvl]
+		para.ordered = format != "" && format != "bullet" && format != "none"
+		para.start = 1
+		if start, ok := r.numStarts[para.numID][para.ilvl]; ok {
+			para.start = start
+		}
+	case name == "title":
+		para.kind = "title"
+	case strings.HasPrefix(name, "heading") || strings.HasPrefix(name, "标题"):
+		level, err := strconv.Atoi(strings.TrimLeft(name, "heading标题"))
+		if err != nil || level < 1 {
+			break
+		}
+		para.kind, para.level = "heading", min(level, 6)
+	case strings.Contains(name, "quote") || strings.Contains(name, "引用"):
+		para.kind = "quote"
+	case name == "codeblock" || name == "code" || name == "htmlpreformatted":
+		para.kind = "code"
+	case name == "scenebreak":
+		para.kind = "sceneBreak"
+	case name == "listparagraph":
+		para.kind = "listContinuation"
+	}
+
+	if para.kind == "paragraph" && isSceneBreakText(docxPlainText(para.inline)) {
+		para.kind = "sceneBreak"
+	}
+	return para
+}
+
+// inline 解析段落中的 run、超链接和修订内容
+func (r *docxReader) inline(el *docxElement, marks []tiptapMark, nodes []tiptapRichNode) []tiptapRichNode {
+	for _, child := range el.Children {
+		switch child.Name {
+		case "pPr", "del", "moveFrom":
+			continue
+		case "r":
+			nodes = r.run(child, marks, nodes)
+		case "hyperlink":
+			linkMarks := marks
+			if href := r.rels[child.Attr["id"]]; href != "" {
+				linkMarks = append(append([]tiptapMark(nil), marks...), tiptapMark{Type: "link", Attrs: map[string]interface{}{"href": href}})
+			}
+			nodes = r.inline(child, linkMarks, nodes)
+		default:
+			nodes = r.inline(child, marks, nodes)
+		}
+	}
+	return nodes
+}
+
+func (r *docxReader) run(run *docxElement, inherited []tiptapMark, nodes []tiptapRichNode) []tiptapRichNode {
+	marks := append([]tiptapMark(nil), inherited...)
+	if rPr := run.child("rPr"); rPr != nil {
+		for _, prop := range rPr.Children {
+			switch prop.Name {
+			case "b":
+				if docxToggleOn(prop) {
+					marks = append(marks, tiptapMark{Type: "bold"})
+				}
+			case "i":
+				if docxToggleOn(prop) {
+					marks = append(marks, tiptapMark{Type: "italic"})
+				}
+			case "strike", "dstrike":
+				if docxToggleOn(prop) {
+					marks = append(marks, tiptapMark{Type: "strike"})
+				}
+			case "u":
+				if prop.Attr["val"] != "none" {
+					marks = append(marks, tiptapMark{Type: "underline"})
+				}
+			case "highlight":
+				if prop.Attr["val"] != "none" {
+					marks = append(marks, tiptapMark{Type: "highlight"})
+				}
+			case "vertAlign":
+				switch prop.Attr["val"] {
+				case "superscript":
+					marks = append(marks, tiptapMark{Type: "superscript"})
+				case "subscript":
+					marks = append(marks, tiptapMark{Type: "subscript"})
+				}
+			case "rStyle":
+				if strings.Contains(strings.ToLower(prop.Attr["val"]), "code") {
+					marks = append(marks, tiptapMark{Type: "code"})
+				}
+			}
+		}
+	}
+
+	for _, child := range run.Children {
+		switch child.Name {
+		case "t":
+			nodes = appendTipTapText(nodes, child.Text, marks)
+		case "tab":
+			nodes = appendTipTapText(nodes, "\t", marks)
+		case "noBreakHyphen":
+			nodes = appendTipTapText(nodes, "-", marks)
+		case "br":
+			if t := child.Attr["type"]; t != "page" && t != "column" {
+				nodes = append(nodes, tiptapRichNode{Type: "hardBreak"})
+			}
+		case "cr":
+			nodes = append(nodes, tiptapRichNode{Type: "hardBreak"})
+		}
+	}
+	return nodes
+}
+
+func docxToggleOn(prop *docxElement) bool {
+	switch prop.Attr["val"] {
+	case "0", "false", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+func docxPlainText(nodes []tiptapRichNode) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		b.WriteString(node.Text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// isSceneBreakText 判断段落是否是手写的场景分隔符
+func isSceneBreakText(text string) bool {
+	switch strings.ReplaceAll(text, " ", "") {
+	case "***", "---", "＊＊＊", "※※※", "###":
+		return true
+	}
+	return false
+}
+
+// assembleDOCXBlocks 将段落序列组装为块级节点：合并相邻的引用和代码段落，按层级重建列表
+func assembleDOCXBlocks(paragraphs []docxParagraph) []tiptapRichNode {
+	var blocks []tiptapRichNode
+	for i := 0; i < len(paragraphs); {
+		para := paragraphs[i]
+		switch para.kind {
+		case "list":
+			j := i + 1
+			for j < len(paragraphs) && (paragraphs[j].kind == "list" || paragraphs[j].kind == "listContinuation") {
+				if paragraphs[j].kind == "listContinuation" {
+					paragraphs[j].ilvl = paragraphs[j-1].ilvl
+					paragraphs[j].numID = paragraphs[j-1].numID
+				}
+				j++
+			}
+			blocks = append(blocks, buildDOCXLists(paragraphs[i:j])...)
+			i = j
+		case "quote":
+			quote := tiptapRichNode{Type: "blockquote"}
+			for ; i < len(paragraphs) && paragraphs[i].kind == "quote"; i++ {
+				if len(paragraphs[i].inline) > 0 {
+					quote.Content = append(quote.Content, tiptapRichNode{Type: "paragraph", Content: paragraphs[i].inline})
+				}
+			}
+			if len(quote.Content) > 0 {
+				blocks = append(blocks, quote)
+			}
+		case "code":
+			var lines []string
+			for ; i < len(paragraphs) && paragraphs[i].kind == "code"; i++ {
+				var line strings.Builder
+				for _, node := range paragraphs[i].inline {
+					if node.Type == "hardBreak" {
+						line.WriteString("\n")
+					} else {
+						line.WriteString(node.Text)
+					}
+				}
+				lines = append(lines, line.String())
+			}
+			block := tiptapRichNode{Type: "codeBlock"}
+			if code := strings.Join(lines, "\n"); code != "" {
+				block.Content = []tiptapRichNode{{Type: "text", Text: code}}
+			}
+			blocks = append(blocks, block)
+		case "heading":
+			if len(para.inline) > 0 {
+				blocks = append(blocks, tiptapRichNode{Type: "heading", Attrs: map[string]interface{}{"level": para.level}, Content: para.inline})
+			}
+			i++
+		case "sceneBreak":
+			blocks = append(blocks, tiptapRichNode{Type: "horizontalRule"})
+			i++
+		case "title":
+			i++
+		default:
+			// Word 常用空段落调整间距，导入时忽略
+			if len(para.inline) > 0 {
+				blocks = append(blocks, tiptapRichNode{Type: "paragraph", Content: para.inline})
+			}
+			i++
+		}
+	}
+	return blocks
+}
+
+// buildDOCXLists 根据缩进级别重建嵌套列表；同级编号实例变化时开始新列表
+func buildDOCXLists(paragraphs []docxParagraph) []tiptapRichNode {
+	var lists []tiptapRichNode
+	for i := 0; i < len(paragraphs); {
+		first := paragraphs[i]
+		list := tiptapRichNode{Type: "bulletList"}
+		if first.ordered {
+			list.Type = "orderedList"
+			if first.start != 1 {
+				list.Attrs = map[string]interface{}{"start": first.start}
+			}
+		}
+
+		for i < len(paragraphs) && paragraphs[i].ilvl == first.ilvl && paragraphs[i].numID == first.numID && paragraphs[i].kind == "list" {
+			item := tiptapRichNode{Type: "listItem", Content: []tiptapRichNode{{Type: "paragraph", Content: paragraphs[i].inline}}}
+			i++
+			for i < len(paragraphs) && paragraphs[i].kind == "listContinuation" && paragraphs[i].ilvl == first.ilvl {
+				if len(paragraphs[i].inline) > 0 {
+					item.Content = append(item.Content, tiptapRichNode{Type: "paragraph", Content: paragraphs[i].inline})
+				}
+				i++
+			}
+			j := i
+			for j < len(paragraphs) && paragraphs[j].ilvl > first.ilvl {
+				j++
+			}
+			item.Content = append(item.Content, buildDOCXLists(paragraphs[i:j])...)
+			i = j
+			list.Content = append(list.Content, item)
+		}
+
+		if len(list.Content) == 0 {
+			// 孤立的续行段落
+			if len(paragraphs[i].inline) > 0 {
+				lists = append(lists, tiptapRichNode{Type: "paragraph", Content: paragraphs[i].inline})
+			}
+			i++
+			continue
+		}
+		lists = append(lists, list)
+	}
+	return lists
+}
@@ -0,0 +1,830 @@
+package writer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// TipTap -> Markdown
+// ============================================================================
+
+// TipTapToMarkdown 将 TipTap JSON 转换为 Markdown（供协作编辑等需要保持 Markdown 存储格式的模块使用）
+func TipTapToMarkdown(content string) string {
+	return tiptapToMarkdown(content)
+}
+
+// tiptapToMarkdown 将 TipTap JSON 转换为 Markdown，保留标题、列表、引用、分隔线和行内样式
+// 下划线、上下标等 Markdown 不支持的样式使用内联 HTML 标签；非 JSON 内容原样返回
+func tiptapToMarkdown(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return ""
+	}
+
+	blocks, ok := parseTipTapNodes(trimmed)
+	if !ok {
+		return trimmed
+	}
+
+	result := strings.Join(markdownBlocks(blocks), "\n\n")
+	if result == "" {
+		return ""
+	}
+	return result + "\n"
+}
+
+// markdownBlocks 渲染块级节点，每个元素是一个（可能多行的）块
+func markdownBlocks(nodes []tiptapRichNode) []string {
+	blocks := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if block := markdownBlock(node); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+func markdownBlock(node tiptapRichNode) string {
+	switch node.Type {
+	case "paragraph":
+		return escapeMarkdownLineStarts(markdownInline(node.Content))
+	case "heading":
+		level := min(max(tiptapAttrInt(node.Attrs, "level", 1), 1), 6)
+		text := markdownInline(node.Content)
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\\\n", " ")
+	case "blockquote":
+		inner := strings.Join(markdownBlocks(node.Content), "\n\n")
+		if inner == "" {
+			return ""
+		}
+		return prefixMarkdownLines(inner, "> ", ">")
+	case "bulletList", "orderedList":
+		return markdownList(node)
+	case "codeBlock":
+		var code strings.Builder
+		for _, child := range node.Content {
+			code.WriteString(child.Text)
+		}
+		fence := strings.Repeat("`", max(3, longestRun(code.String(), '`')+1))
+		return fence + tiptapAttrString(node.Attrs, "language") + "\n" + code.String() + "\n" + fence
+	case "horizontalRule":
+		return "---"
+	case "image":
+		src := tiptapAttrString(node.Attrs, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + escapeMarkdownText(tiptapAttrString(node.Attrs, "alt")) + "](" + escapeMarkdownURL(src) + ")"
+	default:
+		return strings.Join(markdownBlocks(node.Content), "\n\n")
+	}
+}
+
+// markdownList 渲染列表；单段落的列表项保持紧凑，嵌套内容按标记宽度缩进
+func markdownList(node tiptapRichNode) string {
+	start := tiptapAttrInt(node.Attrs, "start", 1)
+	items := make([]string, 0, len(node.Content))
+	for i, item := range node.Content {
+		marker := "- "
+		if node.Type == "orderedList" {
+			marker = strconv.Itoa(start+i) + ". "
+		}
+
+		var body strings.Builder
+		for j, child := range item.Content {
+			block := markdownBlock(child)
+			if block == "" {
+				continue
+			}
+			if j > 0 && body.Len() > 0 {
+				if child.Type == "bulletList" || child.Type == "orderedList" {
+					body.WriteString("\n")
+				} else {
+					body.WriteString("\n\n")
+				}
+			}
+			body.WriteString(block)
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+prefixMarkdownContinuation(body.String(), indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+// markdownInline 渲染行内内容，相邻文本节点共享的样式只开闭一次
+func markdownInline(nodes []tiptapRichNode) string {
+	var b strings.Builder
+	var active []tiptapMark
+	closeTo := func(n int) {
+		for len(active) > n {
+			b.WriteString(markdownMarkDelimiter(active[len(active)-1], false))
+			active = active[:len(active)-1]
+		}
+	}
+
+	for _, node := range nodes {
+		switch node.Type {
+		case "text":
+			marks := sortedTipTapMarks(node.Marks)
+			code := false
+			if n := len(marks); n > 0 && marks[n-1].Type == "code" {
+				code = true
+				marks = marks[:n-1]
+			}
+
+			common := 0
+			for common < len(active) && common < len(marks) && sameTipTapMark(active[common], marks[common]) {
+				common++
+			}
+			closeTo(common)
+			for _, mark := range marks[common:] {
+				b.WriteString(markdownMarkDelimiter(mark, true))
+				active = append(active, mark)
+			}
+
+			if code {
+				b.WriteString(markdownCodeSpan(node.Text))
+			} else {
+				b.WriteString(escapeMarkdownText(node.Text))
+			}
+		case "hardBreak":
+			closeTo(0)
+			b.WriteString("\\\n")
+		case "image":
+			closeTo(0)
+			b.WriteString(escapeMarkdownText(tiptapAttrString(node.Attrs, "alt")))
+		default:
+			closeTo(0)
+			b.WriteString(markdownInline(node.Content))
+		}
+	}
+	closeTo(0)
+	return b.String()
+}
+
+func markdownMarkDelimiter(mark tiptapMark, open bool) string {
+	switch mark.Type {
+	case "bold":
+		return "**"
+	case "italic":
+		return "*"
+	case "strike":
+		return "~~"
+	case "highlight":
+		return "=="
+	case "underline", "superscript", "subscript":
+		tag := map[string]string{"underline": "u", "superscript": "sup", "subscript": "sub"}[mark.Type]
+		if open {
+			return "<" + tag + ">"
+		}
+		return "</" + tag + ">"
+	case "link":
+		if open {
+			return "["
+		}
+		return "](" + escapeMarkdownURL(tiptapAttrString(mark.Attrs, "href")) + ")"
+	default:
+		return ""
+	}
+}
+
+func markdownCodeSpan(text string) string {
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownEscaper 转义可能被解析为 Markdown 语法的字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	"~", `\~`,
+	"=", `\=`,
+)
+
+func escapeMarkdownText(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func escapeMarkdownURL(url string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(url)
+}
+
+var markdownOrderedStart = regexp.MustCompile(`^(\d{1,9})([.)])(\s|$)`)
+
+// escapeMarkdownLineStarts 转义段落行首会被识别为标题、引用或列表的字符
+func escapeMarkdownLineStarts(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"), strings.HasPrefix(line, ">"):
+			lines[i] = `\` + line
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "+ "), line == "-", line == "+",
+			strings.HasPrefix(line, "---"):
+			lines[i] = `\` + line
+		default:
+			if m := markdownOrderedStart.FindStringSubmatchIndex(line); m != nil {
+				lines[i] = line[:m[3]] + `\` + line[m[3]:]
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func prefixMarkdownLines(text, prefix, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixMarkdownContinuation 缩进除首行外的所有非空行
+func prefixMarkdownContinuation(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func longestRun(text string, c byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] == c {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+// ============================================================================
+// Markdown -> TipTap
+// ============================================================================
+
+// MarkdownToTipTap 将 Markdown 解析为 TipTap JSON
+func MarkdownToTipTap(markdown string) string {
+	return markdownToTipTap(markdown)
+}
+
+// markdownToTipTap 将 Markdown 解析为 TipTap JSON
+func markdownToTipTap(markdown string) string {
+	return marshalTipTapDoc(markdownToTipTapNodes(markdown))
+}
+
+// markdownToTipTapNodes 将 Markdown 解析为块级节点
+func markdownToTipTapNodes(markdown string) []tiptapRichNode {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	return parseMarkdownBlocks(strings.Split(markdown, "\n"))
+}
+
+var (
+	markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	markdownListPattern    = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])( +|$)`)
+	markdownImagePattern   = regexp.MustCompile(`^!\[((?:\\.|[^\]\\])*)\]\(([^)\s]*)\)$`)
+	markdownBreakPattern   = regexp.MustCompile(`^(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+)
+
+func parseMarkdownBlocks(lines []string) []tiptapRichNode {
+	var blocks []tiptapRichNode
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			var block tiptapRichNode
+			block, i = parseMarkdownFence(lines, i)
+			blocks = append(blocks, block)
+		case markdownHeadingPattern.MatchString(trimmed):
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			heading := tiptapRichNode{Type: "heading", Attrs: map[string]interface{}{"level": len(m[1])}}
+			heading.Content = parseMarkdownInline(m[2], nil)
+			blocks = append(blocks, heading)
+			i++
+		case markdownBreakPattern.MatchString(trimmed):
+			blocks = append(blocks, tiptapRichNode{Type: "horizontalRule"})
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines); i++ {
+				line := strings.TrimLeft(lines[i], " ")
+				if !strings.HasPrefix(line, ">") {
+					break
+				}
+				line = strings.TrimPrefix(line[1:], " ")
+				quoted = append(quoted, line)
+			}
+			blocks = append(blocks, tiptapRichNode{Type: "blockquote", Content: nonEmptyBlocks(parseMarkdownBlocks(quoted))})
+		case markdownListPattern.MatchString(lines[i]) && !markdownBreakPattern.MatchString(trimmed):
+			var list tiptapRichNode
+			list, i = parseMarkdownList(lines, i)
+			blocks = append(blocks, list)
+		case markdownImagePattern.MatchString(trimmed):
+			m := markdownImagePattern.FindStringSubmatch(trimmed)
+			blocks = append(blocks, tiptapRichNode{Type: "image", Attrs: map[string]interface{}{
+				"src": m[2],
+				"alt": unescapeMarkdown(m[1]),
+			}})
+			i++
+		default:
+			start := i
+			for i++; i < len(lines) && !interruptsMarkdownParagraph(lines[i]); i++ {
+			}
+			blocks = append(blocks, tiptapRichNode{Type: "paragraph", Content: parseMarkdownParagraph(lines[start:i])})
+		}
+	}
+	return blocks
+}
+
+func nonEmptyBlocks(blocks []tiptapRichNode) []tiptapRichNode {
+	if len(blocks) == 0 {
+		return []tiptapRichNode{{Type: "paragraph"}}
+	}
+	return blocks
+}
+
+func interruptsMarkdownParagraph(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") ||
+		strings.HasPrefix(trimmed, ">") ||
+		markdownHeadingPattern.MatchString(trimmed) ||
+		markdownBreakPattern.MatchString(trimmed) ||
+		markdownListPattern.MatchString(line)
+}
+
+func parseMarkdownFence(lines []string, start int) (tiptapRichNode, int) {
+	opening := strings.TrimSpace(lines[start])
+	fenceChar := opening[0]
+	fenceLen := 0
+	for fenceLen < len(opening) && opening[fenceLen] == fenceChar {
+		fenceLen++
+	}
+	language := strings.TrimSpace(opening[fenceLen:])
+
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, strings.Repeat(string(fenceChar), fenceLen)) && strings.Trim(trimmed, string(fenceChar)) == "" {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	block := tiptapRichNode{Type: "codeBlock"}
+	if language != "" {
+		block.Attrs = map[string]interface{}{"language": language}
+	}
+	if text := strings.Join(code, "\n"); text != "" {
+		block.Content = []tiptapRichNode{{Type: "text", Text: text}}
+	}
+	return block, i
+}
+
+// parseMarkdownList 解析一个列表（含嵌套内容），返回列表节点和下一行位置
+func parseMarkdownList(lines []string, start int) (tiptapRichNode, int) {
+	first := markdownListPattern.FindStringSubmatch(lines[start])
+	baseIndent := len(first[1])
+	ordered := isOrderedMarker(first[2])
+
+	list := tiptapRichNode{Type: "bulletList"}
+	if ordered {
+		list.Type = "orderedList"
+		if n, _ := strconv.Atoi(first[2][:len(first[2])-1]); n != 1 {
+			list.Attrs = map[string]interface{}{"start": n}
+		}
+	}
+
+	i := start
+	for i < len(lines) {
+		m := markdownListPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != baseIndent || isOrderedMarker(m[2]) != ordered || markdownBreakPattern.MatchString(strings.TrimSpace(lines[i])) {
+			break
+		}
+
+		contentIndent := len(m[0])
+		if m[3] == "" {
+			contentIndent = len(m[1]) + len(m[2]) + 1
+		}
+		itemLines := []string{strings.TrimSpace(lines[i][len(m[0]):])}
+		i++
+	collect:
+		for i < len(lines) {
+			line := lines[i]
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			switch {
+			case strings.TrimSpace(line) == "":
+				next := nextNonBlankLine(lines, i)
+				if next < 0 || leadingSpaces(lines[next]) < contentIndent {
+					break collect
+				}
+				itemLines = append(itemLines, "")
+			case indent >= contentIndent:
+				itemLines = append(itemLines, line[contentIndent:])
+			case indent > baseIndent && markdownListPattern.MatchString(line):
+				itemLines = append(itemLines, line[indent:])
+			case !interruptsMarkdownParagraph(line) && itemLines[len(itemLines)-1] != "":
+				// 懒惰续行：属于上一段落
+				itemLines = append(itemLines, strings.TrimSpace(line))
+			default:
+				break collect
+			}
+			i++
+		}
+		list.Content = append(list.Content, tiptapRichNode{Type: "listItem", Content: nonEmptyBlocks(parseMarkdownBlocks(itemLines))})
+
+		// 列表项之间允许空行
+		if next := nextNonBlankLine(lines, i); next > i {
+			if m := markdownListPattern.FindStringSubmatch(lines[next]); m != nil && len(m[1]) == baseIndent && isOrderedMarker(m[2]) == ordered {
+				i = next
+			}
+		}
+	}
+	return list, i
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+func nextNonBlankLine(lines []string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// parseMarkdownParagraph 解析段落的多行文本；反斜杠或两个空格结尾表示硬换行
+func parseMarkdownParagraph(lines []string) []tiptapRichNode {
+	var nodes []tiptapRichNode
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ")
+		text := strings.TrimSpace(line)
+		// 结尾奇数个反斜杠时最后一个表示换行，偶数个是转义的反斜杠
+		if trailing := len(text) - len(strings.TrimRight(text, `\`)); trailing%2 == 1 {
+			hardBreak = true
+			text = text[:len(text)-1]
+		}
+
+		for _, node := range parseMarkdownInline(text, nil) {
+			if node.Type == "text" {
+				nodes = appendTipTapText(nodes, node.Text, node.Marks)
+			} else {
+				nodes = append(nodes, node)
+			}
+		}
+
+		if i == len(lines)-1 {
+			break
+		}
+		if hardBreak {
+			nodes = append(nodes, tiptapRichNode{Type: "hardBreak"})
+		} else if needsSoftBreakSpace(text, lines[i+1]) {
+			nodes = appendTipTapText(nodes, " ", nil)
+		}
+	}
+	return nodes
+}
+
+// needsSoftBreakSpace 普通换行在西文单词之间转为空格，中文之间直接连接
+func needsSoftBreakSpace(prev, next string) bool {
+	next = strings.TrimSpace(next)
+	if prev == "" || next == "" {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+	return last < utf8.RuneSelf && first < utf8.RuneSelf
+}
+
+// markdownPunctuation 可以被反斜杠转义的字符
+const markdownPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+func unescapeMarkdown(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && strings.IndexByte(markdownPunctuation, text[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// parseMarkdownInline 解析行内样式：强调、删除线、高亮、代码、链接以及 <u>/<sup>/<sub> 标签
+func parseMarkdownInline(text string, marks []tiptapMark) []tiptapRichNode {
+	var nodes []tiptapRichNode
+	var buf strings.Builder
+	flush := func() {
+		nodes = appendTipTapText(nodes, buf.String(), marks)
+		buf.Reset()
+	}
+	withMark := func(mark tiptapMark) []tiptapMark {
+		return append(append([]tiptapMark(nil), marks...), mark)
+	}
+	appendNodes := func(children []tiptapRichNode) {
+		for _, child := range children {
+			if child.Type == "text" {
+				nodes = appendTipTapText(nodes, child.Text, child.Marks)
+			} else {
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(markdownPunctuation, text[i+1]) >= 0:
+			buf.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == '`':
+			run := runLength(text, i, '`')
+			if end := findCodeSpanEnd(text, i+run, run); end >= 0 {
+				code := text[i+run : end]
+				if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				flush()
+				nodes = appendTipTapText(nodes, code, withMark(tiptapMark{Type: "code"}))
+				i = end + run
+				continue
+			}
+			buf.WriteString(text[i : i+run])
+			i += run
+			continue
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, _, end, ok := parseMarkdownLink(text, i+1); ok {
+				buf.WriteString(unescapeMarkdown(label))
+				i = end
+				continue
+			}
+		case c == '[':
+			if label, href, end, ok := parseMarkdownLink(text, i); ok {
+				flush()
+				appendNodes(parseMarkdownInline(label, withMark(tiptapMark{Type: "link", Attrs: map[string]interface{}{"href": href}})))
+				i = end
+				continue
+			}
+		case c == '<':
+			if mark, open, closeTag, ok := matchMarkdownHTMLTag(text[i:]); ok {
+				if end := strings.Index(text[i+len(open):], closeTag); end >= 0 {
+					inner := text[i+len(open) : i+len(open)+end]
+					flush()
+					appendNodes(parseMarkdownInline(inner, withMark(tiptapMark{Type: mark})))
+					i += len(open) + end + len(closeTag)
+					continue
+				}
+			}
+		case c == '*' || c == '_' || c == '~' || c == '=':
+			if delim, mark := markdownDelimiterAt(text, i); delim != "" && i+len(delim) < len(text) && text[i+len(delim)] != ' ' {
+				if end := findMarkdownCloser(text, i+len(delim), delim); end >= 0 {
+					flush()
+					appendNodes(parseMarkdownInline(text[i+len(delim):end], withMark(tiptapMark{Type: mark})))
+					i = end + len(delim)
+					continue
+				}
+			}
+			run := runLength(text, i, c)
+			buf.WriteString(text[i : i+run])
+			i += run
+			continue
+		}
+		buf.WriteByte(c)
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// markdownDelimiterAt 识别 i 处的强调分隔符
+func markdownDelimiterAt(text string, i int) (string, string) {
+	c := text[i]
+	double := i+1 < len(text) && text[i+1] == c
+	switch c {
+	case '*':
+		if double {
+			return "**", "bold"
+		}
+		return "*", "italic"
+	case '_':
+		// 单词内部的下划线不作为强调
+		if i > 0 && isASCIIAlnum(text[i-1]) {
+			return "", ""
+		}
+		if double {
+			return "__", "bold"
+		}
+		return "_", "italic"
+	case '~':
+		if double {
+			return "~~", "strike"
+		}
+	case '=':
+		if double {
+			return "==", "highlight"
+		}
+	}
+	return "", ""
+}
+
+// findMarkdownCloser 查找与 delim 配对的闭合分隔符位置
+// 同一字符的连续分隔符（如 ***）按内部尚未闭合的强调决定闭合位置
+func findMarkdownCloser(text string, from int, delim string) int {
+	c := delim[0]
+	for j := from; j < len(text); {
+		switch text[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			run := runLength(text, j, '`')
+			if end := findCodeSpanEnd(text, j+run, run); end >= 0 {
+				j = end + run
+			} else {
+				j += run
+			}
+			continue
+		case c:
+			run := runLength(text, j, c)
+			if text[j-1] == ' ' {
+				j += run
+				continue
+			}
+			candidate := -1
+			if len(delim) == 2 && run >= 2 {
+				candidate = j
+				if run >= 3 && c == '*' && pendingSingleEmphasis(text[from:j]) {
+					candidate = j + 1
+				}
+			} else if len(delim) == 1 && run != 2 {
+				candidate = j
+				if run >= 3 && pendingDoubleEmphasis(text[from:j], c) {
+					candidate = j + 2
+				}
+			}
+			if candidate > from && candidate+len(delim) <= j+run {
+				return candidate
+			}
+			j += run
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// pendingSingleEmphasis 内部是否有尚未闭合的单个 *
+func pendingSingleEmphasis(inner string) bool {
+	singles := 0
+	for j := 0; j < len(inner); {
+		if inner[j] == '\\' {
+			j += 2
+			continue
+		}
+		if inner[j] != '*' {
+			j++
+			continue
+		}
+		run := runLength(inner, j, '*')
+		singles += run % 2
+		j += run
+	}
+	return singles%2 == 1
+}
+
+// pendingDoubleEmphasis 内部是否有尚未闭合的双分隔符
+func pendingDoubleEmphasis(inner string, c byte) bool {
+	doubles := 0
+	for j := 0; j < len(inner); {
+		if inner[j] == '\\' {
+			j += 2
+			continue
+		}
+		if inner[j] != c {
+			j++
+			continue
+		}
+		run := runLength(inner, j, c)
+		doubles += run / 2
+		j += run
+	}
+	return doubles%2 == 1
+}
+
+func findCodeSpanEnd(text string, from, run int) int {
+	for j := from; j < len(text); {
+		if text[j] != '`' {
+			j++
+			continue
+		}
+		n := runLength(text, j, '`')
+		if n == run {
+			return j
+		}
+		j += n
+	}
+	return -1
+}
+
+// parseMarkdownLink 解析 [label](href)，start 指向 '['
+func parseMarkdownLink(text string, start int) (string, string, int, bool) {
+	depth := 0
+	for j := start; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if j+1 >= len(text) || text[j+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(text[j+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			href := strings.TrimSpace(text[j+2 : j+2+end])
+			href = strings.TrimSuffix(strings.TrimPrefix(href, "<"), ">")
+			href = strings.NewReplacer("%20", " ", "%28", "(", "%29", ")").Replace(href)
+			return text[start+1 : j], href, j + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+func matchMarkdownHTMLTag(text string) (mark, open, closeTag string, ok bool) {
+	for tag, markType := range map[string]string{"u": "underline", "sup": "superscript", "sub": "subscript"} {
+		if strings.HasPrefix(text, "<"+tag+">") {
+			return markType, "<" + tag + ">", "</" + tag + ">", true
+		}
+	}
+	return "", "", "", false
+}
+
+func runLength(text string, i int, c byte) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func isASCIIAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// markdownHeadingText 返回标题节点的纯文本
+func markdownHeadingText(node tiptapRichNode) string {
+	var b strings.Builder
+	for _, child := range node.Content {
+		b.WriteString(child.Text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// stripLeadingTitle 去掉导出时写在正文开头、与文档标题相同的一级标题
+func stripLeadingTitle(blocks []tiptapRichNode, title string) []tiptapRichNode {
+	if len(blocks) > 0 && blocks[0].Type == "heading" && tiptapAttrInt(blocks[0].Attrs, "level", 1) == 1 &&
+		markdownHeadingText(blocks[0]) == strings.TrimSpace(title) {
+		return blocks[1:]
+	}
+	return blocks
+}
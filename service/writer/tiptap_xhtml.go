@@ -0,0 +1,144 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tiptapToXHTML 将 TipTap JSON 转换为 XHTML 片段；非 JSON 内容按段落输出
+// 标题级别整体下移一级，h1 留给章节标题
+func tiptapToXHTML(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return ""
+	}
+
+	nodes, ok := parseTipTapNodes(trimmed)
+	if !ok {
+		return plainTextToXHTML(trimmed)
+	}
+
+	var b strings.Builder
+	for _, node := range nodes {
+		writeTipTapXHTML(&b, node)
+	}
+	return b.String()
+}
+
+// plainTextToXHTML 将纯文本按行转换为段落
+func plainTextToXHTML(text string) string {
+	var b strings.Builder
+	for _, line := range splitParagraphs(text) {
+		if line == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(xmlEscape(line))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+func writeTipTapXHTML(b *strings.Builder, node tiptapRichNode) {
+	children := func() {
+		for _, child := range node.Content {
+			writeTipTapXHTML(b, child)
+		}
+	}
+	block := func(tag string) {
+		b.WriteString("<" + tag + ">")
+		children()
+		b.WriteString("</" + tag + ">\n")
+	}
+
+	switch node.Type {
+	case "doc":
+		children()
+	case "paragraph":
+		block("p")
+	case "heading":
+		level := 1
+		if v, ok := node.Attrs["level"].(float64); ok && v >= 1 {
+			level = int(v)
+		}
+		block(fmt.Sprintf("h%d", min(level+1, 6)))
+	case "blockquote":
+		block("blockquote")
+	case "bulletList":
+		block("ul")
+	case "orderedList":
+		if v, ok := node.Attrs["start"].(float64); ok && v > 1 {
+			b.WriteString(fmt.Sprintf(`<ol start="%d">`, int(v)))
+			children()
+			b.WriteString("</ol>\n")
+			return
+		}
+		block("ol")
+	case "listItem":
+		block("li")
+	case "codeBlock":
+		b.WriteString("<pre><code>")
+		for _, child := range node.Content {
+			b.WriteString(xmlEscape(child.Text))
+		}
+		b.WriteString("</code></pre>\n")
+	case "hardBreak":
+		b.WriteString("<br/>")
+	case "horizontalRule":
+		b.WriteString("<hr/>\n")
+	case "text":
+		writeTipTapText(b, node)
+	case "image":
+		// 外部图片不打包进电子书，仅保留替代文字
+		if alt, ok := node.Attrs["alt"].(string); ok && alt != "" {
+			b.WriteString(`<p class="image-alt">` + xmlEscape(alt) + "</p>\n")
+		}
+	default:
+		children()
+	}
+}
+
+// writeTipTapText 输出带样式的文本
+func writeTipTapText(b *strings.Builder, node tiptapRichNode) {
+	var open, close []string
+	for _, mark := range node.Marks {
+		var tag, attrs string
+		switch mark.Type {
+		case "bold":
+			tag = "strong"
+		case "italic":
+			tag = "em"
+		case "underline":
+			tag = "u"
+		case "strike":
+			tag = "s"
+		case "code":
+			tag = "code"
+		case "superscript":
+			tag = "sup"
+		case "subscript":
+			tag = "sub"
+		case "highlight":
+			tag = "mark"
+		case "link":
+			href, _ := mark.Attrs["href"].(string)
+			if !isSafeEPUBLink(href) {
+				continue
+			}
+			tag, attrs = "a", ` href="`+xmlEscape(href)+`"`
+		default:
+			continue
+		}
+		open = append(open, "<"+tag+attrs+">")
+		close = append([]string{"</" + tag + ">"}, close...)
+	}
+
+	b.WriteString(strings.Join(open, ""))
+	b.WriteString(xmlEscape(node.Text))
+	b.WriteString(strings.Join(close, ""))
+}
+
+func isSafeEPUBLink(href string) bool {
+	lower := strings.ToLower(href)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:")
+}
@@ -0,0 +1,500 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/pkg/errors"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	baseInterfaces "Qingyu_backend/service/interfaces/base"
+)
+
+const (
+	// streakWindowDays 计算连续写作天数时每次向前加载的天数
+	streakWindowDays = 60
+	// maxStreakDays 连续写作天数的计算上限
+	maxStreakDays = 3650
+	// maxOutputDays 每日产出曲线最多返回的天数
+	maxOutputDays = 366
+	// writingLedgerEventTimeout 异步事件写入流水的超时时间
+	writingLedgerEventTimeout = 10 * time.Second
+)
+
+// 参与写作流水统计的内容保存事件
+var writingLedgerEventTypes = []string{"document.autosaved", "document.content_updated"}
+
+// WritingLedgerRepository 写作流水仓储接口
+type WritingLedgerRepository interface {
+	// Increment 累加某作者某项目某日的流水（不存在时创建）
+	Increment(ctx context.Context, authorID, projectID, date string, added, deleted int, at time.Time) error
+	// InsertIfAbsent 仅当该作者、项目、日期尚无流水时写入，返回是否写入
+	InsertIfAbsent(ctx context.Context, entry *writer.WritingLedgerEntry) (bool, error)
+	// ListByAuthor 按日期闭区间查询作者流水
+	ListByAuthor(ctx context.Context, authorID, fromDate, toDate string) ([]*writer.WritingLedgerEntry, error)
+	// GetGoal 获取作者写作目标，未设置时返回 nil
+	GetGoal(ctx context.Context, authorID string) (*writer.WritingGoal, error)
+	// SaveGoal 保存作者写作目标
+	SaveGoal(ctx context.Context, goal *writer.WritingGoal) error
+}
+
+// WritingRevisionSource 历史修订数据源（用于回填流水）
+type WritingRevisionSource interface {
+	// ListProjectRevisions 按 node_id、version 升序返回项目的全部修订
+	ListProjectRevisions(ctx context.Context, projectID string) ([]*writer.FileRevision, error)
+}
+
+// WritingSummary 作者写作产出汇总
+type WritingSummary struct {
+	Timezone          string         `json:"timezone"`
+	TodayWords        int64          `json:"todayWords"`
+	WeekWords         int64          `json:"weekWords"`
+	MonthWords        int64          `json:"monthWords"`
+	Streak            int            `json:"streak"`
+	DailyGoal         int            `json:"dailyGoal"`
+	DailyGoalProgress float64        `json:"dailyGoalProgress"` // 今日目标完成百分比（0-100）
+	ProjectTodayWords map[string]int `json:"-"`
+}
+
+// DailyWritingOutput 单日写作产出
+type DailyWritingOutput struct {
+	Date         string `json:"date"`
+	WordsAdded   int    `json:"wordsAdded"`
+	WordsDeleted int    `json:"wordsDeleted"`
+	NetWords     int    `json:"netWords"`
+	SaveCount    int    `json:"saveCount"`
+}
+
+// UpdateWritingGoalRequest 更新写作目标请求
+type UpdateWritingGoalRequest struct {
+	DailyWordGoal *int    `json:"dailyWordGoal"`
+	Timezone      *string `json:"timezone"`
+}
+
+// WritingBackfillResult 流水回填结果
+type WritingBackfillResult struct {
+	Projects  int `json:"projects"`
+	Revisions int `json:"revisions"`
+	Inserted  int `json:"inserted"`
+	Skipped   int `json:"skipped"` // 当日已有流水而跳过的条目
+}
+
+// WritingLedgerService 写作流水服务
+// 订阅内容保存事件，按作者时区的自然日记录净字数，驱动今日/本周/本月产出、连续写作天数和目标进度
+type WritingLedgerService struct {
+	ledgerRepo  WritingLedgerRepository
+	projectRepo writerRepo.ProjectRepository
+	revisions   WritingRevisionSource
+	name        string
+}
+
+// NewWritingLedgerService 创建写作流水服务
+func NewWritingLedgerService(ledgerRepo WritingLedgerRepository, projectRepo writerRepo.ProjectRepository) *WritingLedgerService {
+	return &WritingLedgerService{
+		ledgerRepo:  ledgerRepo,
+		projectRepo: projectRepo,
+		name:        "WritingLedgerService",
+	}
+}
+
+// SetRevisionSource 设置历史修订数据源（回填流水需要）
+func (s *WritingLedgerService) SetRevisionSource(source WritingRevisionSource) {
+	s.revisions = source
+}
+
+// RecordSave 记录一次内容保存带来的字数变化
+func (s *WritingLedgerService) RecordSave(ctx context.Context, authorID, projectID string, previousWords, currentWords int, at time.Time) error {
+	if authorID == "" || projectID == "" {
+		return errors.NewServiceError(s.name, errors.ServiceErrorValidation, "作者ID和项目ID不能为空", "", nil)
+	}
+
+	loc, err := s.location(ctx, authorID)
+	if err != nil {
+		return err
+	}
+
+	added, deleted := 0, 0
+	if delta := currentWords - previousWords; delta > 0 {
+		added = delta
+	} else {
+		deleted = -delta
+	}
+
+	date := at.In(loc).Format(writer.WritingLedgerDateLayout)
+	if err := s.ledgerRepo.Increment(ctx, authorID, projectID, date, added, deleted, at); err != nil {
+		return errors.NewServiceError(s.name, errors.ServiceErrorInternal, "记录写作流水失败", "", err)
+	}
+	return nil
+}
+
+// Handle 处理内容保存事件
+// 事件需携带 user_id、project_id、word_count 和 previous_word_count，缺少任一字段时忽略
+func (s *WritingLedgerService) Handle(ctx context.Context, event baseInterfaces.Event) error {
+	if event == nil {
+		return nil
+	}
+	data, ok := event.GetEventData().(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	userID, _ := data["user_id"].(string)
+	projectID, _ := data["project_id"].(string)
+	current, hasCurrent := ledgerInt(data, "word_count")
+	previous, hasPrevious := ledgerInt(data, "previous_word_count")
+	if userID == "" || projectID == "" || !hasCurrent || !hasPrevious {
+		return nil
+	}
+
+	at := event.GetTimestamp()
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	// 异步事件的请求上下文可能已结束
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writingLedgerEventTimeout)
+	defer cancel()
+	return s.RecordSave(ctx, userID, projectID, previous, current, at)
+}
+
+// GetHandlerName 获取处理器名称
+func (s *WritingLedgerService) GetHandlerName() string {
+	return s.name
+}
+
+// GetSupportedEventTypes 获取支持的事件类型
+func (s *WritingLedgerService) GetSupportedEventTypes() []string {
+	return writingLedgerEventTypes
+}
+
+// GetSummary 获取作者的写作产出汇总
+func (s *WritingLedgerService) GetSummary(ctx context.Context, authorID string, now time.Time) (*WritingSummary, error) {
+	goal, err := s.GetGoal(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	loc := loadWritingLocation(goal.Timezone)
+	today := startOfDay(now.In(loc))
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)) // 周一为一周开始
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+
+	from := weekStart
+	if monthStart.Before(from) {
+		from = monthStart
+	}
+	entries, err := s.ledgerRepo.ListByAuthor(ctx, authorID, formatLedgerDate(from), formatLedgerDate(today))
+	if err != nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询写作流水失败", "", err)
+	}
+
+	summary := &WritingSummary{
+		Timezone:          goal.Timezone,
+		DailyGoal:         goal.DailyWordGoal,
+		ProjectTodayWords: make(map[string]int),
+	}
+	todayKey := formatLedgerDate(today)
+	weekKey := formatLedgerDate(weekStart)
+	monthKey := formatLedgerDate(monthStart)
+	for _, entry := range entries {
+		net := int64(entry.NetWords)
+		if entry.Date == todayKey {
+			summary.TodayWords += net
+			summary.ProjectTodayWords[entry.ProjectID] += entry.NetWords
+		}
+		if entry.Date >= weekKey {
+			summary.WeekWords += net
+		}
+		if entry.Date >= monthKey {
+			summary.MonthWords += net
+		}
+	}
+	summary.DailyGoalProgress = goalProgress(summary.TodayWords, goal.DailyWordGoal)
+
+	summary.Streak, err = s.streak(ctx, authorID, today)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetDailyOutput 获取最近若干天（含今天）的每日产出，缺失的日期补零
+func (s *WritingLedgerService) GetDailyOutput(ctx context.Context, authorID string, days int, now time.Time) ([]*DailyWritingOutput, error) {
+	if days <= 0 || days > maxOutputDays {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorValidation, fmt.Sprintf("天数必须在1到%d之间", maxOutputDays), "", nil)
+	}
+	loc, err := s.location(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	today := startOfDay(now.In(loc))
+	from := today.AddDate(0, 0, -(days - 1))
+
+	entries, err := s.ledgerRepo.ListByAuthor(ctx, authorID, formatLedgerDate(from), formatLedgerDate(today))
+	if err != nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询写作流水失败", "", err)
+	}
+
+	byDate := make(map[string]*DailyWritingOutput, days)
+	output := make([]*DailyWritingOutput, 0, days)
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		item := &DailyWritingOutput{Date: formatLedgerDate(day)}
+		byDate[item.Date] = item
+		output = append(output, item)
+	}
+	for _, entry := range entries {
+		if item, ok := byDate[entry.Date]; ok {
+			item.WordsAdded += entry.WordsAdded
+			item.WordsDeleted += entry.WordsDeleted
+			item.NetWords += entry.NetWords
+			item.SaveCount += entry.SaveCount
+		}
+	}
+	return output, nil
+}
+
+// GetGoal 获取作者写作目标，未设置时返回默认值
+func (s *WritingLedgerService) GetGoal(ctx context.Context, authorID string) (*writer.WritingGoal, error) {
+	goal, err := s.ledgerRepo.GetGoal(ctx, authorID)
+	if err != nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询写作目标失败", "", err)
+	}
+	if goal == nil {
+		goal = &writer.WritingGoal{AuthorID: authorID}
+	}
+	if goal.Timezone == "" {
+		goal.Timezone = writer.DefaultWritingTimezone
+	}
+	return goal, nil
+}
+
+// UpdateGoal 更新作者每日目标和时区
+// 时区只影响之后记录的流水，已有流水保留记录时的日期归属
+func (s *WritingLedgerService) UpdateGoal(ctx context.Context, authorID string, req *UpdateWritingGoalRequest) (*writer.WritingGoal, error) {
+	if req == nil || (req.DailyWordGoal == nil && req.Timezone == nil) {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorValidation, "没有需要更新的内容", "", nil)
+	}
+	goal, err := s.GetGoal(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DailyWordGoal != nil {
+		if *req.DailyWordGoal < 0 {
+			return nil, errors.NewServiceError(s.name, errors.ServiceErrorValidation, "每日字数目标不能为负数", "", nil)
+		}
+		goal.DailyWordGoal = *req.DailyWordGoal
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			return nil, errors.NewServiceError(s.name, errors.ServiceErrorValidation, "无效的时区: "+*req.Timezone, "", err)
+		}
+		goal.Timezone = *req.Timezone
+	}
+	goal.UpdatedAt = time.Now()
+
+	if err := s.ledgerRepo.SaveGoal(ctx, goal); err != nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "保存写作目标失败", "", err)
+	}
+	return goal, nil
+}
+
+// Backfill 由 file_revisions 回填作者名下项目的历史流水
+// 每篇文档按版本顺序比较相邻修订的字数，差值计入修订作者在修订当日的流水；
+// 恢复和备份修订不计入产出。已有流水的日期不会被覆盖，可重复执行
+func (s *WritingLedgerService) Backfill(ctx context.Context, authorID string) (*WritingBackfillResult, error) {
+	if s.revisions == nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "未配置历史修订数据源", "", nil)
+	}
+	loc, err := s.location(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.projectRepo.GetListByOwnerID(ctx, authorID, 1000, 0)
+	if err != nil {
+		return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询项目列表失败", "", err)
+	}
+
+	result := &WritingBackfillResult{}
+	for _, project := range projects {
+		projectID := project.ID.Hex()
+		revisions, err := s.revisions.ListProjectRevisions(ctx, projectID)
+		if err != nil {
+			return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询历史修订失败", "", err)
+		}
+		result.Projects++
+		result.Revisions += len(revisions)
+
+		for _, entry := range backfillLedgerEntries(authorID, projectID, revisions, loc) {
+			inserted, err := s.ledgerRepo.InsertIfAbsent(ctx, entry)
+			if err != nil {
+				return nil, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "写入回填流水失败", "", err)
+			}
+			if inserted {
+				result.Inserted++
+			} else {
+				result.Skipped++
+			}
+		}
+	}
+
+	log.Printf("[WritingLedgerService] 回填作者 %s 的写作流水: %+v", authorID, result)
+	return result, nil
+}
+
+// backfillLedgerEntries 根据项目修订计算作者每日流水
+func backfillLedgerEntries(authorID, projectID string, revisions []*writer.FileRevision, loc *time.Location) []*writer.WritingLedgerEntry {
+	sorted := make([]*writer.FileRevision, len(revisions))
+	copy(sorted, revisions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].NodeID != sorted[j].NodeID {
+			return sorted[i].NodeID < sorted[j].NodeID
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	lastWords := make(map[string]int)
+	byDate := make(map[string]*writer.WritingLedgerEntry)
+	for _, rev := range sorted {
+		words, ok := ledgerInt(rev.Metadata, "word_count")
+		if !ok {
+			continue
+		}
+		operation, _ := rev.Metadata["operation"].(string)
+		// 备份修订记录的是恢复前的内容统计，口径不同，不作为基线
+		if operation == "backup" {
+			continue
+		}
+
+		previous, seen := lastWords[rev.NodeID]
+		lastWords[rev.NodeID] = words
+		if !seen && rev.ParentVers > 0 {
+			// 缺少前序字数，无法得到可信的增量
+			continue
+		}
+		if operation == "restore" || rev.AuthorID != authorID {
+			continue
+		}
+
+		date := rev.CreatedAt.In(loc).Format(writer.WritingLedgerDateLayout)
+		entry, ok := byDate[date]
+		if !ok {
+			entry = &writer.WritingLedgerEntry{
+				AuthorID:  authorID,
+				ProjectID: projectID,
+				Date:      date,
+				Source:    writer.WritingLedgerSourceBackfill,
+			}
+			byDate[date] = entry
+		}
+		if delta := words - previous; delta > 0 {
+			entry.WordsAdded += delta
+		} else {
+			entry.WordsDeleted -= delta
+		}
+		entry.NetWords = entry.WordsAdded - entry.WordsDeleted
+		entry.SaveCount++
+		if rev.CreatedAt.After(entry.UpdatedAt) {
+			entry.UpdatedAt = rev.CreatedAt
+		}
+	}
+
+	entries := make([]*writer.WritingLedgerEntry, 0, len(byDate))
+	for _, entry := range byDate {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Date < entries[j].Date })
+	return entries
+}
+
+// streak 计算截至今天的连续写作天数
+// 当天有新增字数即视为写作日；今天尚未写作时从昨天开始计算，不会中断连续记录
+func (s *WritingLedgerService) streak(ctx context.Context, authorID string, today time.Time) (int, error) {
+	streak := 0
+	cursor := today
+	for streak < maxStreakDays {
+		from := cursor.AddDate(0, 0, -(streakWindowDays - 1))
+		entries, err := s.ledgerRepo.ListByAuthor(ctx, authorID, formatLedgerDate(from), formatLedgerDate(cursor))
+		if err != nil {
+			return 0, errors.NewServiceError(s.name, errors.ServiceErrorInternal, "查询写作流水失败", "", err)
+		}
+		active := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			if entry.WordsAdded > 0 {
+				active[entry.Date] = true
+			}
+		}
+
+		for day := cursor; !day.Before(from); day = day.AddDate(0, 0, -1) {
+			if active[formatLedgerDate(day)] {
+				streak++
+				continue
+			}
+			if day.Equal(today) {
+				continue
+			}
+			return streak, nil
+		}
+		cursor = from.AddDate(0, 0, -1)
+	}
+	return streak, nil
+}
+
+// location 获取作者设置的时区
+func (s *WritingLedgerService) location(ctx context.Context, authorID string) (*time.Location, error) {
+	goal, err := s.GetGoal(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	return loadWritingLocation(goal.Timezone), nil
+}
+
+// loadWritingLocation 加载时区，失败时回退到默认时区（UTC+8）
+func loadWritingLocation(name string) *time.Location {
+	if name == "" {
+		name = writer.DefaultWritingTimezone
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}
+
+// startOfDay 返回所在时区当天零点
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func formatLedgerDate(t time.Time) string {
+	return t.Format(writer.WritingLedgerDateLayout)
+}
+
+// goalProgress 计算目标完成百分比，上限 100
+func goalProgress(words int64, goal int) float64 {
+	if goal <= 0 || words <= 0 {
+		return 0
+	}
+	progress := float64(words) * 100 / float64(goal)
+	if progress > 100 {
+		return 100
+	}
+	return progress
+}
+
+// ledgerInt 从事件数据或修订元数据中读取整数（兼容 JSON/BSON 解码后的数值类型）
+func ledgerInt(data map[string]interface{}, key string) (int, bool) {
+	switch v := data[key].(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,392 @@
+package writer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"Qingyu_backend/models/writer"
+	writerRepo "Qingyu_backend/repository/interfaces/writer"
+	serviceBase "Qingyu_backend/service/base"
+)
+
+// fakeWritingLedgerRepo 内存版写作流水仓储
+type fakeWritingLedgerRepo struct {
+	entries   map[string]*writer.WritingLedgerEntry
+	goals     map[string]*writer.WritingGoal
+	revisions map[string][]*writer.FileRevision
+}
+
+func newFakeWritingLedgerRepo() *fakeWritingLedgerRepo {
+	return &fakeWritingLedgerRepo{
+		entries:   make(map[string]*writer.WritingLedgerEntry),
+		goals:     make(map[string]*writer.WritingGoal),
+		revisions: make(map[string][]*writer.FileRevision),
+	}
+}
+
+func ledgerKey(authorID, projectID, date string) string {
+	return authorID + "|" + projectID + "|" + date
+}
+
+func (r *fakeWritingLedgerRepo) Increment(ctx context.Context, authorID, projectID, date string, added, deleted int, at time.Time) error {
+	key := ledgerKey(authorID, projectID, date)
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &writer.WritingLedgerEntry{AuthorID: authorID, ProjectID: projectID, Date: date, Source: writer.WritingLedgerSourceLive}
+		r.entries[key] = entry
+	}
+	entry.WordsAdded += added
+	entry.WordsDeleted += deleted
+	entry.NetWords += added - deleted
+	entry.SaveCount++
+	entry.UpdatedAt = at
+	return nil
+}
+
+func (r *fakeWritingLedgerRepo) InsertIfAbsent(ctx context.Context, entry *writer.WritingLedgerEntry) (bool, error) {
+	key := ledgerKey(entry.AuthorID, entry.ProjectID, entry.Date)
+	if _, ok := r.entries[key]; ok {
+		return false, nil
+	}
+	r.entries[key] = entry
+	return true, nil
+}
+
+func (r *fakeWritingLedgerRepo) ListByAuthor(ctx context.Context, authorID, fromDate, toDate string) ([]*writer.WritingLedgerEntry, error) {
+	var result []*writer.WritingLedgerEntry
+	for _, entry := range r.entries {
+		if entry.AuthorID == authorID && entry.Date >= fromDate && entry.Date <= toDate {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeWritingLedgerRepo) GetGoal(ctx context.Context, authorID string) (*writer.WritingGoal, error) {
+	if goal, ok := r.goals[authorID]; ok {
+		copied := *goal
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeWritingLedgerRepo) SaveGoal(ctx context.Context, goal *writer.WritingGoal) error {
+	copied := *goal
+	r.goals[goal.AuthorID] = &copied
+	return nil
+}
+
+func (r *fakeWritingLedgerRepo) ListProjectRevisions(ctx context.Context, projectID string) ([]*writer.FileRevision, error) {
+	return r.revisions[projectID], nil
+}
+
+func (r *fakeWritingLedgerRepo) entry(authorID, projectID, date string) *writer.WritingLedgerEntry {
+	return r.entries[ledgerKey(authorID, projectID, date)]
+}
+
+// fakeLedgerProjectRepo 只实现仪表板和回填用到的方法
+type fakeLedgerProjectRepo struct {
+	writerRepo.ProjectRepository
+	projects []*writer.Project
+	updates  map[string]interface{}
+}
+
+func (r *fakeLedgerProjectRepo) GetListByOwnerID(ctx context.Context, ownerID string, limit, offset int64) ([]*writer.Project, error) {
+	return r.projects, nil
+}
+
+func (r *fakeLedgerProjectRepo) CountByOwner(ctx context.Context, ownerID string) (int64, error) {
+	return int64(len(r.projects)), nil
+}
+
+func (r *fakeLedgerProjectRepo) IsOwner(ctx context.Context, projectID, ownerID string) (bool, error) {
+	for _, p := range r.projects {
+		if p.ID.Hex() == projectID {
+			return p.AuthorID.Hex() == ownerID, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeLedgerProjectRepo) UpdateByOwner(ctx context.Context, projectID, ownerID string, updates map[string]interface{}) error {
+	r.updates = updates
+	return nil
+}
+
+func newTestLedgerService() (*WritingLedgerService, *fakeWritingLedgerRepo, *fakeLedgerProjectRepo) {
+	repo := newFakeWritingLedgerRepo()
+	projects := &fakeLedgerProjectRepo{}
+	svc := NewWritingLedgerService(repo, projects)
+	svc.SetRevisionSource(repo)
+	return svc, repo, projects
+}
+
+func TestWritingLedger_RecordSaveUsesAuthorTimezone(t *testing.T) {
+	svc, repo, _ := newTestLedgerService()
+	ctx := context.Background()
+	at := time.Date(2026, 10, 15, 17, 30, 0, 0, time.UTC) // 上海时间 10-16 01:30
+
+	require.NoError(t, svc.RecordSave(ctx, "author", "p1", 100, 350, at))
+	require.NoError(t, svc.RecordSave(ctx, "author", "p1", 350, 300, at.Add(time.Minute)))
+
+	entry := repo.entry("author", "p1", "2026-10-16")
+	require.NotNil(t, entry)
+	assert.Equal(t, 250, entry.WordsAdded)
+	assert.Equal(t, 50, entry.WordsDeleted)
+	assert.Equal(t, 200, entry.NetWords)
+	assert.Equal(t, 2, entry.SaveCount)
+
+	tz := "America/New_York"
+	_, err := svc.UpdateGoal(ctx, "author", &UpdateWritingGoalRequest{Timezone: &tz})
+	require.NoError(t, err)
+	require.NoError(t, svc.RecordSave(ctx, "author", "p1", 300, 310, at))
+	assert.Equal(t, 10, repo.entry("author", "p1", "2026-10-15").NetWords)
+}
+
+func TestWritingLedger_HandleSaveEvents(t *testing.T) {
+	svc, repo, _ := newTestLedgerService()
+	ctx := context.Background()
+	at := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+
+	// 持久化事件回放后数值为 float64
+	require.NoError(t, svc.Handle(ctx, &serviceBase.BaseEvent{
+		EventType: "document.autosaved",
+		EventData: map[string]interface{}{
+			"document_id":         "d1",
+			"project_id":          "p1",
+			"user_id":             "author",
+			"word_count":          float64(1200),
+			"previous_word_count": float64(1000),
+		},
+		Timestamp: at,
+	}))
+	// 缺少 previous_word_count 的旧事件无法计算增量，忽略
+	require.NoError(t, svc.Handle(ctx, &serviceBase.BaseEvent{
+		EventType: "document.content_updated",
+		EventData: map[string]interface{}{"document_id": "d1", "word_count": 5000},
+		Timestamp: at,
+	}))
+
+	entry := repo.entry("author", "p1", "2026-10-16")
+	require.NotNil(t, entry)
+	assert.Equal(t, 200, entry.NetWords)
+	assert.Equal(t, 1, entry.SaveCount)
+	assert.ElementsMatch(t, []string{"document.autosaved", "document.content_updated"}, svc.GetSupportedEventTypes())
+}
+
+func TestWritingLedger_SummaryAndStreak(t *testing.T) {
+	svc, repo, _ := newTestLedgerService()
+	ctx := context.Background()
+	goal := 1000
+	_, err := svc.UpdateGoal(ctx, "author", &UpdateWritingGoalRequest{DailyWordGoal: &goal})
+	require.NoError(t, err)
+
+	// 2026-10-16 是周五
+	now := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	add := func(date, projectID string, added, deleted int) {
+		repo.entries[ledgerKey("author", projectID, date)] = &writer.WritingLedgerEntry{
+			AuthorID: "author", ProjectID: projectID, Date: date,
+			WordsAdded: added, WordsDeleted: deleted, NetWords: added - deleted, SaveCount: 1,
+		}
+	}
+	add("2026-10-16", "p1", 500, 100)
+	add("2026-10-16", "p2", 200, 0)
+	add("2026-10-15", "p1", 300, 0)
+	add("2026-10-14", "p1", 100, 0)
+	add("2026-10-12", "p1", 50, 0)
+	add("2026-10-11", "p1", 1000, 0) // 上周日
+	add("2026-09-30", "p1", 9999, 0) // 上月
+	add("2026-10-16", "other", 0, 0)
+	repo.entries[ledgerKey("someone", "p1", "2026-10-16")] = &writer.WritingLedgerEntry{AuthorID: "someone", ProjectID: "p1", Date: "2026-10-16", WordsAdded: 7, NetWords: 7}
+
+	summary, err := svc.GetSummary(ctx, "author", now)
+	require.NoError(t, err)
+	assert.Equal(t, writer.DefaultWritingTimezone, summary.Timezone)
+	assert.Equal(t, int64(600), summary.TodayWords)
+	assert.Equal(t, int64(1050), summary.WeekWords)
+	assert.Equal(t, int64(2050), summary.MonthWords)
+	assert.Equal(t, 3, summary.Streak, "10-13 无写作，连续天数为 14、15、16 日")
+	assert.Equal(t, 1000, summary.DailyGoal)
+	assert.InDelta(t, 60.0, summary.DailyGoalProgress, 0.001)
+	assert.Equal(t, 400, summary.ProjectTodayWords["p1"])
+
+	// 今天尚未写作时，连续记录从昨天开始计算
+	summary, err = svc.GetSummary(ctx, "author", now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Streak)
+	assert.Equal(t, int64(0), summary.TodayWords)
+
+	// 中断一整天后归零
+	summary, err = svc.GetSummary(ctx, "author", now.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.Streak)
+}
+
+func TestWritingLedger_StreakSpansMultipleWindows(t *testing.T) {
+	svc, repo, _ := newTestLedgerService()
+	loc := loadWritingLocation(writer.DefaultWritingTimezone)
+	today := time.Date(2026, 10, 16, 12, 0, 0, 0, loc)
+	for i := 0; i < 150; i++ {
+		date := formatLedgerDate(today.AddDate(0, 0, -i))
+		repo.entries[ledgerKey("author", "p1", date)] = &writer.WritingLedgerEntry{
+			AuthorID: "author", ProjectID: "p1", Date: date, WordsAdded: 10, NetWords: 10,
+		}
+	}
+
+	summary, err := svc.GetSummary(context.Background(), "author", today)
+	require.NoError(t, err)
+	assert.Equal(t, 150, summary.Streak)
+}
+
+func TestWritingLedger_GetDailyOutputFillsGaps(t *testing.T) {
+	svc, repo, _ := newTestLedgerService()
+	now := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	repo.entries[ledgerKey("author", "p1", "2026-10-14")] = &writer.WritingLedgerEntry{
+		AuthorID: "author", ProjectID: "p1", Date: "2026-10-14", WordsAdded: 30, WordsDeleted: 10, NetWords: 20, SaveCount: 2,
+	}
+	repo.entries[ledgerKey("author", "p2", "2026-10-14")] = &writer.WritingLedgerEntry{
+		AuthorID: "author", ProjectID: "p2", Date: "2026-10-14", WordsAdded: 5, NetWords: 5, SaveCount: 1,
+	}
+
+	output, err := svc.GetDailyOutput(context.Background(), "author", 3, now)
+	require.NoError(t, err)
+	require.Len(t, output, 3)
+	assert.Equal(t, "2026-10-14", output[0].Date)
+	assert.Equal(t, 25, output[0].NetWords)
+	assert.Equal(t, 3, output[0].SaveCount)
+	assert.Equal(t, "2026-10-16", output[2].Date)
+	assert.Equal(t, 0, output[2].NetWords)
+
+	_, err = svc.GetDailyOutput(context.Background(), "author", 0, now)
+	assert.Error(t, err)
+}
+
+func TestWritingLedger_UpdateGoalValidation(t *testing.T) {
+	svc, _, _ := newTestLedgerService()
+	ctx := context.Background()
+
+	negative := -1
+	_, err := svc.UpdateGoal(ctx, "author", &UpdateWritingGoalRequest{DailyWordGoal: &negative})
+	assert.Error(t, err)
+
+	invalid := "Mars/Olympus"
+	_, err = svc.UpdateGoal(ctx, "author", &UpdateWritingGoalRequest{Timezone: &invalid})
+	assert.Error(t, err)
+
+	_, err = svc.UpdateGoal(ctx, "author", &UpdateWritingGoalRequest{})
+	assert.Error(t, err)
+
+	goal, err := svc.GetGoal(ctx, "author")
+	require.NoError(t, err)
+	assert.Equal(t, writer.DefaultWritingTimezone, goal.Timezone)
+	assert.Equal(t, 0, goal.DailyWordGoal)
+}
+
+func TestWritingLedger_BackfillFromRevisions(t *testing.T) {
+	svc, repo, projects := newTestLedgerService()
+	projectID := primitive.NewObjectID()
+	projects.projects = []*writer.Project{{}}
+	projects.projects[0].ID = projectID
+
+	day1 := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	rev := func(node string, version, parent int, author string, at time.Time, metadata map[string]interface{}) *writer.FileRevision {
+		return &writer.FileRevision{NodeID: node, Version: version, ParentVers: parent, AuthorID: author, CreatedAt: at, Metadata: metadata}
+	}
+	words := func(n int, operation string) map[string]interface{} {
+		metadata := map[string]interface{}{"word_count": int32(n)}
+		if operation != "" {
+			metadata["operation"] = operation
+		}
+		return metadata
+	}
+	repo.revisions[projectID.Hex()] = []*writer.FileRevision{
+		rev("d1", 2, 1, "author", day1.Add(time.Hour), words(300, "collab")),
+		rev("d1", 1, 0, "author", day1, words(100, "collab")),
+		rev("d1", 3, 2, "coauthor", day1.Add(2*time.Hour), words(500, "collab")), // 协作者的修订不计入
+		rev("d1", 4, 3, "author", day2, words(999, "backup")),                    // 备份口径不同，跳过
+		rev("d1", 5, 4, "author", day2.Add(time.Minute), words(100, "restore")),  // 恢复不算写作，只更新基线
+		rev("d1", 6, 5, "author", day2.Add(time.Hour), words(150, "collab")),
+		rev("d2", 7, 6, "author", day2, words(800, "collab")),          // 缺少前序基线，无法得到增量
+		rev("d2", 8, 7, "author", day2.Add(time.Hour), words(700, "")), // 删除 100 字
+		rev("d3", 1, 0, "author", day2, map[string]interface{}{}),      // 没有字数记录
+	}
+	// 已有实时流水的日期不被覆盖
+	require.NoError(t, repo.Increment(context.Background(), "author", projectID.Hex(), "2026-10-02", 1, 0, day2))
+
+	result, err := svc.Backfill(context.Background(), "author")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Projects)
+	assert.Equal(t, 9, result.Revisions)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Skipped)
+
+	entry := repo.entry("author", projectID.Hex(), "2026-10-01")
+	require.NotNil(t, entry)
+	assert.Equal(t, 300, entry.WordsAdded)
+	assert.Equal(t, 300, entry.NetWords)
+	assert.Equal(t, 2, entry.SaveCount)
+	assert.Equal(t, writer.WritingLedgerSourceBackfill, entry.Source)
+	assert.Equal(t, 1, repo.entry("author", projectID.Hex(), "2026-10-02").NetWords)
+
+	// 单独验证第二天的增量计算
+	entries := backfillLedgerEntries("author", projectID.Hex(), repo.revisions[projectID.Hex()], loadWritingLocation(""))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2026-10-02", entries[1].Date)
+	assert.Equal(t, 50, entries[1].WordsAdded)
+	assert.Equal(t, 100, entries[1].WordsDeleted)
+	assert.Equal(t, -50, entries[1].NetWords)
+
+	// 重复回填不会重复计数
+	result, err = svc.Backfill(context.Background(), "author")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Inserted)
+}
+
+func TestDashboardService_GetStatsUsesLedger(t *testing.T) {
+	svc, repo, projects := newTestLedgerService()
+	ownerID := primitive.NewObjectID()
+	project := &writer.Project{}
+	project.ID = primitive.NewObjectID()
+	project.AuthorID = ownerID
+	project.Title = "长篇"
+	project.Statistics.TotalWords = 50000
+	project.Settings.WordCountGoal = 200000
+	project.Settings.DailyWordGoal = 2000
+	idle := &writer.Project{}
+	idle.ID = primitive.NewObjectID()
+	idle.Statistics.TotalWords = 100
+	projects.projects = []*writer.Project{project, idle}
+
+	dashboard := NewDashboardService(projects, nil)
+	stats, err := dashboard.GetStats(context.Background(), ownerID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, int64(50100), stats.TotalWords)
+	assert.Equal(t, int64(0), stats.TodayWords, "未配置流水服务时不再用项目总字数冒充今日字数")
+
+	dashboard.SetWritingLedgerService(svc)
+	require.NoError(t, svc.RecordSave(context.Background(), ownerID.Hex(), project.ID.Hex(), 0, 500, time.Now()))
+	require.NoError(t, svc.RecordSave(context.Background(), ownerID.Hex(), idle.ID.Hex(), 100, 40, time.Now()))
+
+	stats, err = dashboard.GetStats(context.Background(), ownerID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, int64(440), stats.TodayWords)
+	assert.Equal(t, 1, stats.Streak)
+	require.Len(t, stats.ProjectGoals, 1)
+	goal := stats.ProjectGoals[0]
+	assert.Equal(t, 500, goal.TodayWords)
+	assert.InDelta(t, 25.0, goal.GoalProgress, 0.001)
+	assert.InDelta(t, 25.0, goal.DailyGoalProgress, 0.001)
+	assert.Len(t, repo.entries, 2)
+
+	target := 300000
+	require.NoError(t, dashboard.UpdateProjectGoals(context.Background(), ownerID.Hex(), project.ID.Hex(), &target, nil))
+	assert.Equal(t, map[string]interface{}{"settings.word_count_goal": 300000}, projects.updates)
+	err = dashboard.UpdateProjectGoals(context.Background(), primitive.NewObjectID().Hex(), project.ID.Hex(), &target, nil)
+	assert.Error(t, err, "非所有者不能修改项目目标")
+}
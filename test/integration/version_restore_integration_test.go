@@ -0,0 +1,99 @@
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Qingyu_backend/global"
+	"Qingyu_backend/models/writer"
+	"Qingyu_backend/service/writer/project"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestoreVersionAsRevision_BackupAndUndo(t *testing.T) {
+	requireDirectDB(t)
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	svc := project.NewVersionService(global.DB)
+	projectID := primitive.NewObjectID()
+	doc := &writer.Document{ProjectID: projectID, Title: "第一章", Type: writer.TypeChapter}
+	doc.ID = primitive.NewObjectID()
+	documentID := doc.ID.Hex()
+	_, err := global.DB.Collection("documents").InsertOne(ctx, doc)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		global.DB.Collection("documents").DeleteOne(ctx, bson.M{"_id": doc.ID})
+		global.DB.Collection("document_contents").DeleteMany(ctx, bson.M{"document_id": doc.ID})
+		global.DB.Collection("file_revisions").DeleteMany(ctx, bson.M{"node_id": documentID})
+	})
+
+	v1, err := svc.SaveDocumentSnapshot(ctx, documentID, "author", "林动睁开眼", "markdown", "初稿")
+	require.NoError(t, err)
+	_, err = svc.SaveDocumentSnapshot(ctx, documentID, "author", "林动睁开眼。\n\n山风很冷。", "markdown", "续写")
+	require.NoError(t, err)
+
+	// 自动保存的内容不产生修订
+	_, err = global.DB.Collection("document_contents").UpdateOne(ctx,
+		bson.M{"document_id": doc.ID},
+		bson.M{"$set": bson.M{"content": "未提交的修改", "version": 3, "updated_at": time.Now()}},
+	)
+	require.NoError(t, err)
+
+	restored, err := svc.RestoreVersionAsRevision(ctx, documentID, v1.ID.Hex(), "editor")
+	require.NoError(t, err)
+	assert.Equal(t, 5, restored.Version, "备份占用 v4，恢复修订为 v5")
+	assert.Equal(t, project.RevisionOperationRestore, restored.Metadata["operation"])
+	assert.Equal(t, 1, restored.Metadata["restored_from_version"])
+
+	backup, err := svc.GetVersion(ctx, documentID, restored.Metadata["previous_revision_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 4, backup.Version)
+	assert.Equal(t, "未提交的修改", backup.Content)
+
+	var content writer.DocumentContent
+	require.NoError(t, global.DB.Collection("document_contents").FindOne(ctx, bson.M{"document_id": doc.ID}).Decode(&content))
+	assert.Equal(t, "林动睁开眼", content.Content)
+	assert.Equal(t, 5, content.Version)
+	assert.Equal(t, "editor", content.LastEditedBy)
+
+	// 撤销恢复：回到备份内容，当前内容已被修订记录，不再重复备份
+	undone, err := svc.UndoRestore(ctx, documentID, restored.ID.Hex(), "editor")
+	require.NoError(t, err)
+	assert.Equal(t, 6, undone.Version)
+	assert.Equal(t, 4, undone.Metadata["restored_from_version"])
+	assert.Equal(t, restored.ID.Hex(), undone.Metadata["previous_revision_id"])
+
+	require.NoError(t, global.DB.Collection("document_contents").FindOne(ctx, bson.M{"document_id": doc.ID}).Decode(&content))
+	assert.Equal(t, "未提交的修改", content.Content)
+	assert.Equal(t, 6, content.Version)
+
+	// 撤销对象必须是恢复修订
+	_, err = svc.UndoRestore(ctx, documentID, backup.VersionID, "editor")
+	assert.Error(t, err)
+
+	history, err := svc.GetVersionHistory(ctx, documentID, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, 5, history.Total)
+	operations := make([]string, 0, len(history.Versions))
+	restoredFrom := make([]int, 0, len(history.Versions))
+	for _, info := range history.Versions {
+		operations = append(operations, info.Operation)
+		restoredFrom = append(restoredFrom, info.RestoredFromVersion)
+	}
+	assert.Equal(t, []string{
+		project.RevisionOperationRestore,
+		project.RevisionOperationRestore,
+		project.RevisionOperationBackup,
+		project.RevisionOperationCollab,
+		project.RevisionOperationCollab,
+	}, operations)
+	assert.Equal(t, []int{4, 1, 0, 0, 0}, restoredFrom)
+}